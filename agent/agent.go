@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/alexisbouchez/ravel/agent/allocator"
@@ -13,7 +14,9 @@ import (
 	"github.com/alexisbouchez/ravel/agent/machinerunner"
 	"github.com/alexisbouchez/ravel/agent/machinerunner/state"
 	"github.com/alexisbouchez/ravel/agent/node"
+	"github.com/alexisbouchez/ravel/agent/privnet"
 	"github.com/alexisbouchez/ravel/agent/server"
+	"github.com/alexisbouchez/ravel/agent/transfer"
 	"github.com/alexisbouchez/ravel/core/cluster"
 	"github.com/alexisbouchez/ravel/core/cluster/placement"
 	"github.com/alexisbouchez/ravel/core/config"
@@ -41,8 +44,15 @@ type Agent struct {
 	server       *server.AgentServer
 	placement    *placement.Listener
 	network      *network.NetworkService
+	privnet      *privnet.Manager
 	registries   registry.RegistriesConfig
 	buildService *build.Service
+	transfers    *transfer.Client
+	grants       *transfer.Grants
+	serverAPI    *serverAPIClient
+
+	migrationsLock sync.Mutex
+	migrations     map[string]*incomingMigration
 }
 
 type Config struct {
@@ -54,6 +64,7 @@ type Config struct {
 type Store interface {
 	state.Store
 	allocator.AllocationsStore
+	node.Store
 }
 
 func New(config Config, store Store, runtime *runtime.Runtime, netservice *network.NetworkService) (*Agent, error) {
@@ -72,13 +83,33 @@ func New(config Config, store Store, runtime *runtime.Runtime, netservice *netwo
 		return nil, fmt.Errorf("failed to create reservation service: %w", err)
 	}
 
-	node := node.NewNode(cs, api.Node{
+	privnet, err := privnet.New(nc, config.Agent)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := newTransferClient(config.Agent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer client: %w", err)
+	}
+
+	serverAPI, err := newServerAPIClient(config.Agent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create server API client: %w", err)
+	}
+
+	node, err := node.NewNode(cs, store, api.Node{
 		Id:            config.Agent.NodeId,
 		Address:       config.Agent.Address,
 		AgentPort:     config.Agent.Port,
 		Region:        config.Agent.Region,
 		HeartbeatedAt: time.Now(),
+		Labels:        config.Agent.Labels,
+		Runtimes:      runtime.Runtimes(),
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load node: %w", err)
+	}
 
 	agent := &Agent{
 		node:       node,
@@ -92,7 +123,12 @@ func New(config Config, store Store, runtime *runtime.Runtime, netservice *netwo
 		runtime:    runtime,
 		placement:  placement.NewListener(nc),
 		network:    netservice,
+		privnet:    privnet,
 		registries: config.Registries,
+		transfers:  transfers,
+		grants:     transfer.NewGrants(),
+		serverAPI:  serverAPI,
+		migrations: map[string]*incomingMigration{},
 	}
 
 	events, err := store.LoadMachineInstanceEvents()
@@ -151,12 +187,15 @@ func (a *Agent) Start() error {
 		if err != nil {
 			return err
 		}
+		a.privnet.Restore(m.Machine, m.Network.PrivateNetworks)
 		machine := a.newMachine(m)
 		a.machines.AddMachine(machine)
 		go machine.Run()
 	}
 
 	a.server = server.NewAgentServer(a)
+	a.server.SetTransferSource(a)
+	a.server.SetMigrationTarget(a)
 
 	// Initialize build service if BuildKit is enabled
 	if a.config.BuildKit != nil && a.config.BuildKit.Enabled {
@@ -199,11 +238,16 @@ func (a *Agent) Start() error {
 		return err
 	}
 
+	if err = a.privnet.Start(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (d *Agent) Stop(ctx context.Context) error {
 	d.placement.Stop()
+	d.privnet.Stop()
 
 	return d.server.Shutdown(ctx)
 }
@@ -143,3 +143,32 @@ func (a *Allocator) ConfirmAllocation(id string) (structs.Allocation, error) {
 
 	return reservation, nil
 }
+
+// ResizeAllocation changes the resources of a confirmed allocation.
+// It fails if the node cannot fit the new resources.
+func (a *Allocator) ResizeAllocation(id string, res api.Resources) (structs.Allocation, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	reservation, ok := a.reservations[id]
+	if !ok {
+		return structs.Allocation{}, errdefs.NewNotFound("reservation not found")
+	}
+
+	after := a.current.Sub(reservation.Resources)
+	after = after.Add(res)
+	if after.GT(a.max) {
+		return structs.Allocation{}, ErrNotEnoughResources
+	}
+
+	reservation.Resources = res
+
+	if err := a.store.PutAllocation(reservation); err != nil {
+		return structs.Allocation{}, err
+	}
+
+	a.current = after
+	a.reservations[id] = reservation
+
+	return reservation, nil
+}
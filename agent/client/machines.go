@@ -22,6 +22,15 @@ func (a *AgentClient) PutMachine(ctx context.Context, opt cluster.PutMachineOpti
 	return &machineInstance, nil
 }
 
+func (a *AgentClient) UpdateMachine(ctx context.Context, id string, opt cluster.UpdateMachineOptions) error {
+	err := a.client.Post(ctx, "/machines/"+id+"/update", nil, httpclient.WithJSONBody(opt))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (a *AgentClient) StartMachine(ctx context.Context, id string) error {
 	err := a.client.Post(ctx, "/machines/"+id+"/start", nil)
 	if err != nil {
@@ -73,9 +82,29 @@ func (a *AgentClient) MachineExec(ctx context.Context, id string, cmd []string,
 	return &result, nil
 }
 
-func (a *AgentClient) GetMachineLogs(ctx context.Context, id string) ([]*api.LogEntry, error) {
+func (a *AgentClient) MachineExecStream(ctx context.Context, id string, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	return a.client.RawPost(ctx, "/machines/"+id+"/exec/stream", httpclient.WithJSONBody(req))
+}
+
+func (a *AgentClient) MachineExecSession(ctx context.Context, id string) (io.ReadWriteCloser, error) {
+	ws, err := a.client.DialWebSocket(ctx, "/machines/"+id+"/exec/tty")
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func machineLogsPath(id string, opts api.LogsOptions) string {
+	path := "/machines/" + id + "/logs"
+	if query := opts.Values().Encode(); query != "" {
+		path += "?" + query
+	}
+	return path
+}
+
+func (a *AgentClient) GetMachineLogs(ctx context.Context, id string, opts api.LogsOptions) ([]*api.LogEntry, error) {
 	var logs []*api.LogEntry
-	err := a.client.Get(ctx, "/machines/"+id+"/logs", &logs)
+	err := a.client.Get(ctx, machineLogsPath(id, opts), &logs)
 	if err != nil {
 		return nil, err
 	}
@@ -99,12 +128,13 @@ func (a *AgentClient) SubscribeToMachineLogs(ctx context.Context, id string) ([]
 	return streamutil.SubscribeToLogs(body)
 }
 
-func (a *AgentClient) GetMachineLogsRaw(ctx context.Context, id string, follow bool) (io.ReadCloser, error) {
-	path := "/machines/" + id + "/logs"
+// GetMachineLogsRaw returns the logs of a machine as a JSON array, or as a stream of
+// entries when following them, the options only apply to the former.
+func (a *AgentClient) GetMachineLogsRaw(ctx context.Context, id string, follow bool, opts api.LogsOptions) (io.ReadCloser, error) {
 	if follow {
-		path += "/follow"
+		return a.client.RawGet(ctx, "/machines/"+id+"/logs/follow")
 	}
-	return a.client.RawGet(ctx, path)
+	return a.client.RawGet(ctx, machineLogsPath(id, opts))
 }
 
 func (a *AgentClient) DisableMachineGateway(ctx context.Context, id string) error {
@@ -135,16 +165,17 @@ func (a *AgentClient) WaitForMachineStatus(ctx context.Context, id string, statu
 }
 
 // MachineSnapshot creates a snapshot of a running machine for fast restore.
-func (a *AgentClient) MachineSnapshot(ctx context.Context, id string, snapshotId string) error {
+func (a *AgentClient) MachineSnapshot(ctx context.Context, id string, snapshotId string) (*api.MachineSnapshot, error) {
 	path := "/machines/" + id + "/snapshot"
 	body := struct {
 		SnapshotId string `json:"snapshot_id"`
 	}{SnapshotId: snapshotId}
-	err := a.client.Post(ctx, path, nil, httpclient.WithJSONBody(body))
+	var snapshot api.MachineSnapshot
+	err := a.client.Post(ctx, path, &snapshot, httpclient.WithJSONBody(body))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return &snapshot, nil
 }
 
 // MachineRestore restores a machine from a snapshot.
@@ -159,3 +190,42 @@ func (a *AgentClient) MachineRestore(ctx context.Context, id string, snapshotId
 	}
 	return nil
 }
+
+// ForkMachine creates machines from a snapshot of a running machine.
+func (a *AgentClient) ForkMachine(ctx context.Context, id string, opt cluster.ForkMachineOptions) ([]cluster.MachineInstance, error) {
+	var instances []cluster.MachineInstance
+	err := a.client.Post(ctx, "/machines/"+id+"/fork", &instances, httpclient.WithJSONBody(opt))
+	if err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+// DeleteMachineSnapshot deletes a machine snapshot from the node.
+func (a *AgentClient) DeleteMachineSnapshot(ctx context.Context, snapshotId string) error {
+	err := a.client.Delete(ctx, "/snapshots/"+snapshotId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PullMachineSnapshot copies a machine snapshot of another node on the agent node.
+func (a *AgentClient) PullMachineSnapshot(ctx context.Context, snapshotId string, opt cluster.PullOptions) error {
+	return a.client.Post(ctx, "/snapshots/"+snapshotId+"/pull", nil, httpclient.WithJSONBody(opt))
+}
+
+// GrantMachineSnapshotTransfer allows a node to pull a machine snapshot of the agent node.
+func (a *AgentClient) GrantMachineSnapshotTransfer(ctx context.Context, snapshotId string, grant cluster.TransferGrant) error {
+	return a.client.Post(ctx, "/snapshots/"+snapshotId+"/grants", nil, httpclient.WithJSONBody(grant))
+}
+
+// MigrateMachine live migrates a running machine to the target node.
+func (a *AgentClient) MigrateMachine(ctx context.Context, id string, opt cluster.MigrateMachineOptions) error {
+	return a.client.Post(ctx, "/machines/"+id+"/migrate", nil, httpclient.WithJSONBody(opt))
+}
+
+// GrantMigrationTransfer allows a node to migrate a machine to the agent node.
+func (a *AgentClient) GrantMigrationTransfer(ctx context.Context, instanceId string, grant cluster.TransferGrant) error {
+	return a.client.Post(ctx, "/migrations/"+instanceId+"/grants", nil, httpclient.WithJSONBody(grant))
+}
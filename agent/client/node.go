@@ -0,0 +1,14 @@
+package agentclient
+
+import (
+	"context"
+)
+
+// CordonNode stops the agent from answering the placement requests.
+func (a *AgentClient) CordonNode(ctx context.Context) error {
+	return a.client.Post(ctx, "/node/cordon", nil)
+}
+
+func (a *AgentClient) UncordonNode(ctx context.Context) error {
+	return a.client.Post(ctx, "/node/uncordon", nil)
+}
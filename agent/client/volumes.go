@@ -0,0 +1,80 @@
+package agentclient
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/internal/httpclient"
+)
+
+func (a *AgentClient) CreateVolume(ctx context.Context, opt cluster.CreateVolumeOptions) error {
+	err := a.client.Post(ctx, "/volumes", nil, httpclient.WithJSONBody(opt))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *AgentClient) DestroyVolume(ctx context.Context, id string) error {
+	err := a.client.Delete(ctx, "/volumes/"+id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *AgentClient) ResizeVolume(ctx context.Context, id string, sizeMB uint64) error {
+	err := a.client.Patch(ctx, "/volumes/"+id, nil, httpclient.WithJSONBody(cluster.ResizeVolumeOptions{SizeMB: sizeMB}))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *AgentClient) CreateVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error {
+	err := a.client.Post(ctx, "/volumes/"+volumeId+"/snapshots/"+snapshotId, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *AgentClient) DeleteVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error {
+	err := a.client.Delete(ctx, "/volumes/"+volumeId+"/snapshots/"+snapshotId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *AgentClient) RestoreVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error {
+	err := a.client.Post(ctx, "/volumes/"+volumeId+"/snapshots/"+snapshotId+"/restore", nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *AgentClient) PullVolume(ctx context.Context, volumeId string, opt cluster.PullVolumeOptions) error {
+	err := a.client.Post(ctx, "/volumes/"+volumeId+"/pull", nil, httpclient.WithJSONBody(opt))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *AgentClient) GrantVolumeTransfer(ctx context.Context, volumeId string, grant cluster.TransferGrant) error {
+	err := a.client.Post(ctx, "/volumes/"+volumeId+"/grants", nil, httpclient.WithJSONBody(grant))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
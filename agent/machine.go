@@ -2,7 +2,9 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"time"
 
@@ -21,6 +23,7 @@ func (a *Agent) onMachineDestroyed(m structs.MachineInstance) {
 	}
 
 	a.network.Release(m.Network)
+	a.privnet.Release(m.Machine.Id, m.Network.PrivateNetworks)
 
 	err = a.allocator.DeleteAllocation(m.Machine.Id)
 	if err != nil {
@@ -29,7 +32,22 @@ func (a *Agent) onMachineDestroyed(m structs.MachineInstance) {
 }
 
 func (a *Agent) reportState(mi cluster.MachineInstance) error {
-	return a.cluster.UpsertInstance(context.Background(), mi)
+	if err := a.cluster.UpsertInstance(context.Background(), mi); err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(mi)
+	if err != nil {
+		return err
+	}
+
+	// the servers answer once the state is stored, the sync is retried until one of them does
+	_, err = a.nc.Request("machines.instances", bytes, time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to report instance state: %w", err)
+	}
+
+	return nil
 }
 
 func (a *Agent) newMachine(machineInstance structs.MachineInstance) *machinerunner.MachineRunner {
@@ -40,11 +58,25 @@ func (a *Agent) newMachine(machineInstance structs.MachineInstance) *machinerunn
 		a.reportState,
 		a.eventer,
 		a.onMachineDestroyed,
+		a.fetchMachineSecrets,
 	)
 }
 
 func (a *Agent) PutMachine(ctx context.Context, opt cluster.PutMachineOptions) (*cluster.MachineInstance, error) {
-	_, err := a.allocator.ConfirmAllocation(opt.AllocationId)
+	machine, err := a.putMachine(opt, "")
+	if err != nil {
+		return nil, err
+	}
+
+	mi := machine.MachineInstance()
+	ci := mi.ClusterInstance()
+
+	return &ci, nil
+}
+
+// putMachine creates the machine instance and runs it, its first start restores the snapshot if it is not empty.
+func (a *Agent) putMachine(opt cluster.PutMachineOptions, restoreSnapshot string) (machine *machinerunner.MachineRunner, err error) {
+	_, err = a.allocator.ConfirmAllocation(opt.AllocationId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to confirm reservation: %w", err)
 	}
@@ -66,6 +98,16 @@ func (a *Agent) PutMachine(ctx context.Context, opt cluster.PutMachineOptions) (
 		}
 	}()
 
+	network.PrivateNetworks, err = a.privnet.Configure(opt.Machine, opt.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure private networks: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			a.privnet.Release(opt.Machine.Id, network.PrivateNetworks)
+		}
+	}()
+
 	var desiredStatus api.MachineStatus
 	if opt.Start {
 		desiredStatus = api.MachineStatusRunning
@@ -82,21 +124,76 @@ func (a *Agent) PutMachine(ctx context.Context, opt cluster.PutMachineOptions) (
 			CreatedAt:             time.Now(),
 			UpdatedAt:             time.Now(),
 			MachineGatewayEnabled: opt.EnableGateway,
+			RestoreSnapshot:       restoreSnapshot,
 		},
 		Network: network,
 	}
 
-	if err := a.store.CreateMachineInstance(machineInstance); err != nil {
+	if err = a.store.CreateMachineInstance(machineInstance); err != nil {
 		return nil, fmt.Errorf("failed to put machine: %w", err)
 	}
 
-	machine := a.newMachine(machineInstance)
+	machine = a.newMachine(machineInstance)
 	a.machines.AddMachine(machine)
 	go machine.Run()
 
-	ci := machineInstance.ClusterInstance()
+	return machine, nil
+}
 
-	return &ci, nil
+// ForkMachine snapshots the memory of a running machine and creates the given machines from the
+// snapshot. Each of them restores it with its own network, hostname and entropy.
+func (a *Agent) ForkMachine(ctx context.Context, id string, opt cluster.ForkMachineOptions) ([]cluster.MachineInstance, error) {
+	source, err := a.machines.GetMachine(id)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := source.Snapshot(ctx, fmt.Sprintf("fork-%s-%d", id, time.Now().UnixNano()))
+	if err != nil {
+		return nil, err
+	}
+
+	clones := make([]*machinerunner.MachineRunner, 0, len(opt.Machines))
+	defer func() {
+		go a.deleteForkSnapshot(snapshot.Id, clones)
+	}()
+
+	for _, m := range opt.Machines {
+		m.Start = true
+		clone, err := a.putMachine(m, snapshot.Id)
+		if err != nil {
+			for _, clone := range clones {
+				if err := clone.Destroy(context.Background(), true); err != nil {
+					slog.Error("failed to destroy machine fork", "machine_id", clone.Id(), "err", err)
+				}
+			}
+			return nil, fmt.Errorf("failed to fork machine: %w", err)
+		}
+		clones = append(clones, clone)
+	}
+
+	instances := make([]cluster.MachineInstance, len(clones))
+	for i, clone := range clones {
+		mi := clone.MachineInstance()
+		instances[i] = mi.ClusterInstance()
+	}
+
+	return instances, nil
+}
+
+// deleteForkSnapshot deletes the snapshot of a fork once the forks have restored it,
+// a restore copies the snapshot into the instance.
+func (a *Agent) deleteForkSnapshot(snapshotId string, clones []*machinerunner.MachineRunner) {
+	deadline := time.Now().Add(10 * time.Minute)
+	for _, clone := range clones {
+		for clone.RestoringSnapshot() && time.Now().Before(deadline) {
+			time.Sleep(time.Second)
+		}
+	}
+
+	if err := a.runtime.DeleteInstanceSnapshot(context.Background(), snapshotId); err != nil {
+		slog.Error("failed to delete fork snapshot", "snapshot", snapshotId, "err", err)
+	}
 }
 
 func (d *Agent) DestroyMachine(ctx context.Context, id string, force bool) error {
@@ -108,6 +205,29 @@ func (d *Agent) DestroyMachine(ctx context.Context, id string, force bool) error
 	return machine.Destroy(ctx, force)
 }
 
+func (a *Agent) UpdateMachine(ctx context.Context, id string, opt cluster.UpdateMachineOptions) error {
+	machine, err := a.machines.GetMachine(id)
+	if err != nil {
+		return err
+	}
+
+	previous := machine.MachineInstance().Version.Resources
+
+	if _, err := a.allocator.ResizeAllocation(id, opt.Version.Resources); err != nil {
+		return err
+	}
+
+	err = machine.Update(ctx, opt.InstanceId, opt.Version)
+	if err != nil {
+		if _, err := a.allocator.ResizeAllocation(id, previous); err != nil {
+			slog.Error("failed to restore reservation", "machine_id", id, "err", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (d *Agent) StartMachine(ctx context.Context, id string) error {
 	machine, err := d.machines.GetMachine(id)
 	if err != nil {
@@ -136,13 +256,13 @@ func (d *Agent) SubscribeToMachineLogs(ctx context.Context, id string) ([]*api.L
 	return machine.SubscribeToLogs(ctx, id)
 }
 
-func (d *Agent) GetMachineLogs(ctx context.Context, id string) ([]*api.LogEntry, error) {
+func (d *Agent) GetMachineLogs(ctx context.Context, id string, opts api.LogsOptions) ([]*api.LogEntry, error) {
 	machine, err := d.machines.GetMachine(id)
 	if err != nil {
 		return nil, err
 	}
 
-	return machine.GetLogs()
+	return machine.GetLogs(opts)
 }
 
 func (d *Agent) MachineExec(ctx context.Context, id string, cmd []string, timeout time.Duration) (*api.ExecResult, error) {
@@ -154,6 +274,24 @@ func (d *Agent) MachineExec(ctx context.Context, id string, cmd []string, timeou
 	return machine.Exec(ctx, cmd, timeout)
 }
 
+func (d *Agent) MachineExecStream(ctx context.Context, id string, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	machine, err := d.machines.GetMachine(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return machine.ExecStream(ctx, req)
+}
+
+func (d *Agent) MachineExecSession(ctx context.Context, id string) (io.ReadWriteCloser, error) {
+	machine, err := d.machines.GetMachine(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return machine.ExecSession(ctx)
+}
+
 func (d *Agent) EnableMachineGateway(ctx context.Context, id string) error {
 	machine, err := d.machines.GetMachine(id)
 	if err != nil {
@@ -194,10 +332,10 @@ func (a *Agent) WaitForMachineStatus(ctx context.Context, id string, status api.
 
 // MachineSnapshot saves the running VM state for fast restore.
 // This enables sub-100ms cold starts for AI sandbox workloads.
-func (d *Agent) MachineSnapshot(ctx context.Context, id string, snapshotId string) error {
+func (d *Agent) MachineSnapshot(ctx context.Context, id string, snapshotId string) (*api.MachineSnapshot, error) {
 	machine, err := d.machines.GetMachine(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	return machine.Snapshot(ctx, snapshotId)
@@ -212,3 +350,12 @@ func (d *Agent) MachineRestore(ctx context.Context, id string, snapshotId string
 
 	return machine.Restore(ctx, snapshotId)
 }
+
+// DeleteMachineSnapshot deletes a snapshot of the node and the files of an unfinished pull of it.
+func (d *Agent) DeleteMachineSnapshot(ctx context.Context, snapshotId string) error {
+	if err := removeStagingDir("snapshots", snapshotId); err != nil {
+		return err
+	}
+
+	return d.runtime.DeleteInstanceSnapshot(ctx, snapshotId)
+}
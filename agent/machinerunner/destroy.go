@@ -27,12 +27,16 @@ type destroyPayload struct {
 }
 
 func (m *MachineRunner) destroyImpl() {
-	err := m.stopAndDestroyInstance()
+	err := m.stopAndDestroyInstance(m.state.InstanceId())
 	if err != nil {
 		slog.Error("failed to stop and destroy instance", "instance", m.state.InstanceId(), "error", err)
 		return
 	}
 
+	if err := m.runtime.RemoveMachineLogs(m.state.Id()); err != nil {
+		slog.Error("failed to remove machine logs", "machine", m.state.Id(), "error", err)
+	}
+
 	_, _, err = m.state.PushDestroyedEvent()
 	if err != nil {
 		slog.Error("failed to push destroyed event", "instance", m.state.InstanceId(), "error", err)
@@ -40,9 +44,9 @@ func (m *MachineRunner) destroyImpl() {
 
 }
 
-func (m *MachineRunner) stopAndDestroyInstance() error {
+func (m *MachineRunner) stopAndDestroyInstance(instanceId string) error {
 	timeout := 0
-	if err := m.runtime.StopInstance(context.Background(), m.state.InstanceId(), &api.StopConfig{
+	if err := m.runtime.StopInstance(context.Background(), instanceId, &api.StopConfig{
 		Timeout: &timeout,
 	}); err != nil {
 		if errdefs.IsNotFound(err) {
@@ -52,7 +56,7 @@ func (m *MachineRunner) stopAndDestroyInstance() error {
 		return err
 	}
 
-	if err := m.runtime.DestroyInstance(context.Background(), m.state.InstanceId()); err != nil {
+	if err := m.runtime.DestroyInstance(context.Background(), instanceId); err != nil {
 		if errdefs.IsNotFound(err) {
 			return nil
 		}
@@ -3,6 +3,7 @@ package machinerunner
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"time"
 
@@ -53,7 +54,32 @@ func (m *MachineRunner) startInstance() {
 			return
 		}
 
-		err = m.runtime.StartInstance(ctx, m.state.InstanceId())
+		if migration := m.takeIncomingMigration(); migration != nil {
+			err = m.runtime.ReceiveInstanceMigration(ctx, instanceId, migration.opt, migration.conn)
+			migration.done <- err
+			if err != nil {
+				m.state.PushStartFailedEvent(err.Error())
+				return
+			}
+			m.state.PushStartedEvent()
+			return
+		}
+
+		snapshotId, err := m.state.TakeRestoreSnapshot()
+		if err != nil {
+			m.state.PushStartFailedEvent(err.Error())
+			return
+		}
+
+		if snapshotId != "" {
+			err = m.runtime.StartInstanceFromSnapshot(ctx, instanceId, snapshotId)
+			if errdefs.IsNotFound(err) {
+				slog.Warn("snapshot to restore not found, booting the instance", "machine_id", m.state.Id(), "snapshot", snapshotId)
+				err = m.bootInstance(ctx, i)
+			}
+		} else {
+			err = m.bootInstance(ctx, i)
+		}
 		if err != nil {
 			m.state.PushStartFailedEvent(err.Error())
 			return
@@ -62,6 +88,36 @@ func (m *MachineRunner) startInstance() {
 	}()
 }
 
+// bootInstance boots the instance and delivers the secrets its init waits for. The secrets are
+// fetched first, the instance is not booted if they cannot be.
+func (m *MachineRunner) bootInstance(ctx context.Context, i *instance.Instance) error {
+	if !i.Config.WaitSecrets {
+		return m.runtime.StartInstance(ctx, i.Id)
+	}
+
+	mi := m.state.MachineInstance()
+	secrets, err := m.fetchSecrets(ctx, mi.Machine.Id, mi.Version.Id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the secrets: %w", err)
+	}
+
+	if err := m.runtime.StartInstance(ctx, i.Id); err != nil {
+		return err
+	}
+
+	deliverCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := m.runtime.SetInstanceSecrets(deliverCtx, i.Id, secrets); err != nil {
+		if err := m.runtime.StopInstance(ctx, i.Id, nil); err != nil {
+			slog.Error("failed to stop the instance", "machine_id", m.state.Id(), "error", err)
+		}
+		return fmt.Errorf("failed to deliver the secrets: %w", err)
+	}
+
+	return nil
+}
+
 func (m *MachineRunner) Stop(ctx context.Context, stopConfig *api.StopConfig) error {
 	slog.Info("Stopping machine", "machine_id", m.state.Id())
 	prev, _, err := m.state.PushStopEvent(api.MachineStopEventPayload{
@@ -100,11 +156,37 @@ func (m *MachineRunner) Exec(ctx context.Context, cmd []string, timeout time.Dur
 	return m.runtime.InstanceExec(ctx, m.state.InstanceId(), cmd, timeout)
 }
 
-func (m *MachineRunner) GetLogs() ([]*api.LogEntry, error) {
+func (m *MachineRunner) ExecStream(ctx context.Context, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	if err := m.canUseInstance(); err != nil {
+		return nil, err
+	}
+
+	status := m.state.Status()
+	if status != api.MachineStatusRunning {
+		return nil, errMachineIs(status)
+	}
+
+	return m.runtime.InstanceExecStream(ctx, m.state.InstanceId(), req)
+}
+
+func (m *MachineRunner) ExecSession(ctx context.Context) (io.ReadWriteCloser, error) {
 	if err := m.canUseInstance(); err != nil {
 		return nil, err
 	}
-	return m.runtime.GetInstanceLogs(m.state.InstanceId())
+
+	status := m.state.Status()
+	if status != api.MachineStatusRunning {
+		return nil, errMachineIs(status)
+	}
+
+	return m.runtime.InstanceExecSession(ctx, m.state.InstanceId())
+}
+
+func (m *MachineRunner) GetLogs(opts api.LogsOptions) ([]*api.LogEntry, error) {
+	if err := m.canUseInstance(); err != nil {
+		return nil, err
+	}
+	return m.runtime.GetInstanceLogs(m.state.InstanceId(), opts)
 }
 
 func (m *MachineRunner) SubscribeToLogs(ctx context.Context, id string) ([]*api.LogEntry, <-chan *api.LogEntry, error) {
@@ -114,20 +196,41 @@ func (m *MachineRunner) SubscribeToLogs(ctx context.Context, id string) ([]*api.
 	return m.runtime.SubscribeToInstanceLogs(ctx, m.state.MachineInstance().Machine.InstanceId)
 }
 
-// Snapshot saves the running VM state for fast restore.
-// Path format: /var/lib/ravel/snapshots/{snapshotId}
-func (m *MachineRunner) Snapshot(ctx context.Context, snapshotId string) error {
+// Snapshot saves the running VM state in the snapshots directory of the node for fast restore.
+func (m *MachineRunner) Snapshot(ctx context.Context, snapshotId string) (*api.MachineSnapshot, error) {
 	if err := m.canUseInstance(); err != nil {
-		return err
+		return nil, err
 	}
 
 	status := m.state.Status()
 	if status != api.MachineStatusRunning {
-		return errMachineIs(status)
+		return nil, errMachineIs(status)
 	}
 
-	path := fmt.Sprintf("/var/lib/ravel/snapshots/%s", snapshotId)
-	return m.runtime.InstanceSnapshot(ctx, m.state.InstanceId(), path)
+	mi := m.state.MachineInstance()
+	snapshot, err := m.runtime.InstanceSnapshot(ctx, m.state.InstanceId(), snapshotId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.MachineSnapshot{
+		Id:             snapshot.Id,
+		MachineId:      mi.Machine.Id,
+		Namespace:      mi.Machine.Namespace,
+		Node:           mi.Machine.Node,
+		SizeBytes:      snapshot.SizeBytes,
+		MachineVersion: mi.Version.Id,
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// RestoringSnapshot reports whether the instance is yet to restore the snapshot it is created from.
+func (m *MachineRunner) RestoringSnapshot() bool {
+	state := m.state.State()
+	if state.Status == api.MachineStatusDestroying || state.Status == api.MachineStatusDestroyed {
+		return false
+	}
+	return state.RestoreSnapshot != "" || state.Status == api.MachineStatusStarting
 }
 
 // Restore restores the VM from a previously saved snapshot.
@@ -141,6 +244,5 @@ func (m *MachineRunner) Restore(ctx context.Context, snapshotId string) error {
 		return errMachineIs(status)
 	}
 
-	path := fmt.Sprintf("/var/lib/ravel/snapshots/%s", snapshotId)
-	return m.runtime.InstanceRestore(ctx, m.state.InstanceId(), path)
+	return m.runtime.InstanceRestore(ctx, m.state.InstanceId(), snapshotId)
 }
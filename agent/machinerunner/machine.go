@@ -3,19 +3,28 @@ package machinerunner
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/alexisbouchez/ravel/agent/machinerunner/state"
 	"github.com/alexisbouchez/ravel/agent/structs"
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/core/cluster"
 	"github.com/alexisbouchez/ravel/core/daemon"
+	"github.com/alexisbouchez/ravel/initd"
 )
 
+// SecretsFetcher fetches the values of the secrets referenced by a version of a machine.
+type SecretsFetcher func(ctx context.Context, machineId, version string) (initd.Secrets, error)
+
 type MachineRunner struct {
-	state       *state.MachineInstanceState
-	runtime     daemon.Runtime
-	runLock     sync.Mutex
-	onDestroyed func(m structs.MachineInstance)
+	state        *state.MachineInstanceState
+	runtime      daemon.Runtime
+	runLock      sync.Mutex
+	onDestroyed  func(m structs.MachineInstance)
+	fetchSecrets SecretsFetcher
+	migrated     atomic.Bool // the VM has been sent to another node
+	incomingLock sync.Mutex
+	incoming     *incomingMigration
 }
 
 func (m *MachineRunner) Id() string {
@@ -29,16 +38,22 @@ func New(
 	reportState func(mi cluster.MachineInstance) error,
 	eventer state.Eventer,
 	onDestroyed func(m structs.MachineInstance),
+	fetchSecrets SecretsFetcher,
 ) *MachineRunner {
 	m := &MachineRunner{
-		state:       state.NewMachineInstanceState(store, machine, eventer, reportState),
-		runtime:     runtime,
-		onDestroyed: onDestroyed,
+		state:        state.NewMachineInstanceState(store, machine, eventer, reportState),
+		runtime:      runtime,
+		onDestroyed:  onDestroyed,
+		fetchSecrets: fetchSecrets,
 	}
 
 	return m
 }
 
+func (m *MachineRunner) MachineInstance() structs.MachineInstance {
+	return m.state.MachineInstance()
+}
+
 func (m *MachineRunner) WaitForStatus(ctx context.Context, status api.MachineStatus) error {
 	return m.state.WaitForStatus(ctx, status)
 }
@@ -0,0 +1,72 @@
+package machinerunner
+
+import (
+	"context"
+	"io"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/daemon"
+)
+
+// incomingMigration is the migration received by the next start of the instance, instead of
+// booting it.
+type incomingMigration struct {
+	opt  daemon.InstanceMigrationOptions
+	conn io.ReadWriteCloser
+	done chan error
+}
+
+func (m *MachineRunner) setIncomingMigration(migration *incomingMigration) {
+	m.incomingLock.Lock()
+	defer m.incomingLock.Unlock()
+	m.incoming = migration
+}
+
+func (m *MachineRunner) takeIncomingMigration() *incomingMigration {
+	m.incomingLock.Lock()
+	defer m.incomingLock.Unlock()
+	migration := m.incoming
+	m.incoming = nil
+	return migration
+}
+
+// SendMigration sends the running VM of the machine to another node. The instance then exits
+// without the restart policy being applied, the machine runs on the other node.
+func (m *MachineRunner) SendMigration(ctx context.Context, conn io.ReadWriteCloser) error {
+	status := m.state.Status()
+	if status != api.MachineStatusRunning {
+		return errMachineIs(status)
+	}
+
+	m.migrated.Store(true)
+	err := m.runtime.SendInstanceMigration(ctx, m.state.InstanceId(), conn)
+	if err != nil {
+		m.migrated.Store(false)
+		return err
+	}
+
+	return nil
+}
+
+// ReceiveMigration starts the prepared machine from the running VM of another node sent over
+// conn, the machine then has the desired status of the machine on the other node.
+func (m *MachineRunner) ReceiveMigration(ctx context.Context, opt daemon.InstanceMigrationOptions, conn io.ReadWriteCloser, desiredStatus api.MachineStatus) error {
+	done := make(chan error, 1)
+	m.setIncomingMigration(&incomingMigration{opt: opt, conn: conn, done: done})
+
+	if _, _, err := m.state.PushStartEvent(false); err != nil {
+		m.takeIncomingMigration()
+		return err
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return m.state.UpdateDesiredStatus(desiredStatus)
+}
@@ -41,6 +41,8 @@ func (m *MachineRunner) Run() {
 			go m.stopInstance(context.Background(), event.Payload.Stop.Config)
 		case api.MachineDestroy:
 			go m.destroyImpl()
+		case api.MachineUpdate:
+			go m.updateImpl(event.Payload.Update)
 		case api.MachineDestroyed:
 			m.onDestroyed(m.state.MachineInstance())
 			return
@@ -78,6 +80,13 @@ func (m *MachineRunner) runInstance() error {
 
 func (m *MachineRunner) handleExit(p *api.MachineExitedEventPayload) {
 	state := m.state.State()
+	if state.Status == api.MachineStatusPreparing {
+		return // the previous instance was stopped by an update
+	}
+
+	if m.migrated.Load() {
+		return // the machine runs on another node
+	}
 
 	config := m.state.MachineInstance().Version.Config
 	if config.Workload.AutoDestroy {
@@ -6,17 +6,20 @@
 //   - created -> preparing -> stopped -> starting -> running
 //   - running -> stopping -> stopped
 //   - stopped -> destroying -> destroyed
+//   - stopped/running -> preparing (update) -> stopped -> starting -> running
 //
 // Each state transition generates events that are propagated through the cluster
 // via NATS, enabling distributed coordination and monitoring.
 package state
 
 import (
+	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/alexisbouchez/ravel/agent/structs"
 	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
 	"github.com/alexisbouchez/ravel/internal/sm"
 )
 
@@ -201,6 +204,45 @@ func (s *MachineInstanceState) PushDestroyedEvent() (prev, next *MachineState, _
 	return s.pushEvent(event)
 }
 
+// PushUpdateEvent swaps the machine version and instance id then moves the machine
+// back to the "preparing" state so that a new instance is created from the new version.
+// The previous instance id is kept in the event payload so it can be destroyed.
+func (s *MachineInstanceState) PushUpdateEvent(instanceId string, version api.MachineVersion) (prev, next *MachineState, _ error) {
+	prev = s.State()
+	if !canUpdate(prev, nil) {
+		return prev, nil, errdefs.NewFailedPrecondition(fmt.Sprintf("machine is in %s status", prev.Status))
+	}
+
+	payload := api.MachineUpdateEventPayload{
+		PreviousVersion:    s.machineVersion.Id,
+		Version:            version.Id,
+		PreviousInstanceId: s.machine.InstanceId,
+	}
+
+	machine := s.machine
+	machine.InstanceId = instanceId
+	machine.MachineVersion = version.Id
+	machine.UpdatedAt = time.Now()
+
+	if err := s.store.UpdateMachineInstanceVersion(machine.Id, machine, version); err != nil {
+		return prev, nil, err
+	}
+
+	s.machine = machine
+	s.machineVersion = version
+
+	event := s.newEvent(
+		api.MachineUpdate,
+		api.OriginUser,
+		api.MachineStatusPreparing,
+		api.MachineEventPayload{
+			Update: &payload,
+		},
+	)
+
+	return s.pushEvent(event)
+}
+
 func (s *MachineInstanceState) EnableGateway() error {
 	return s.fsm.Mutate(func(mis *structs.MachineInstanceState) {
 		mis.MachineGatewayEnabled = true
@@ -213,16 +255,37 @@ func (s *MachineInstanceState) DisableGateway() error {
 	})
 }
 
+// TakeRestoreSnapshot returns the snapshot to restore on this start of the instance and clears
+// it, the next starts boot the instance.
+func (s *MachineInstanceState) TakeRestoreSnapshot() (string, error) {
+	var snapshotId string
+	if s.fsm.State().RestoreSnapshot == "" {
+		return "", nil
+	}
+
+	err := s.fsm.Mutate(func(mis *structs.MachineInstanceState) {
+		snapshotId = mis.RestoreSnapshot
+		mis.RestoreSnapshot = ""
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return snapshotId, nil
+}
+
 func copyStatefunc(mis *structs.MachineInstanceState) *structs.MachineInstanceState {
 	return &structs.MachineInstanceState{
 		DesiredStatus:         mis.DesiredStatus,
 		Status:                mis.Status,
+		Health:                mis.Health,
 		Restarts:              mis.Restarts,
 		LastEvents:            mis.LastEvents,
 		CreatedAt:             mis.CreatedAt,
 		UpdatedAt:             mis.UpdatedAt,
 		LocalIPV4:             mis.LocalIPV4,
 		MachineGatewayEnabled: mis.MachineGatewayEnabled,
+		RestoreSnapshot:       mis.RestoreSnapshot,
 	}
 }
 
@@ -318,6 +381,14 @@ func applyExited(mis *structs.MachineInstanceState, me *api.MachineEvent) {
 	if mis.Status == api.MachineStatusDestroying || mis.Status == api.MachineStatusDestroyed {
 		me.Status = mis.Status // When machine is force destroying/destroyed, we don't want to change the status
 	}
+
+	if mis.Status == api.MachineStatusPreparing {
+		me.Status = mis.Status // The previous instance exited during an update
+	}
+}
+
+func canUpdate(mis *structs.MachineInstanceState, _ *api.MachineEvent) bool {
+	return mis.Status == api.MachineStatusStopped || mis.Status == api.MachineStatusRunning
 }
 
 func newFSM(initial *structs.MachineInstanceState, afterAll func(mis *structs.MachineInstanceState, me *api.MachineEvent) error, afterMutate func(mis *structs.MachineInstanceState) error) *stateMachine {
@@ -361,6 +432,9 @@ func newFSM(initial *structs.MachineInstanceState, afterAll func(mis *structs.Ma
 					Apply: applyDestroy,
 				},
 				api.MachineDestroyed: {},
+				api.MachineUpdate: {
+					Can: canUpdate,
+				},
 			},
 		},
 	)
@@ -0,0 +1,122 @@
+package state
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/alexisbouchez/ravel/agent/structs"
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+type memoryStore struct {
+	mu        sync.Mutex
+	states    []structs.MachineInstanceState
+	updateErr error
+}
+
+func (s *memoryStore) CreateMachineInstance(mi structs.MachineInstance) error { return nil }
+
+func (s *memoryStore) LoadMachineInstances() ([]structs.MachineInstance, error) { return nil, nil }
+
+func (s *memoryStore) DeleteMachineInstance(id string) error { return nil }
+
+func (s *memoryStore) UpdateMachineInstance(id string, mi *structs.MachineInstanceState, event *api.MachineEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.updateErr != nil {
+		return s.updateErr
+	}
+	s.states = append(s.states, *mi)
+	return nil
+}
+
+func (s *memoryStore) UpdateMachineInstanceVersion(id string, machine cluster.Machine, version api.MachineVersion) error {
+	return nil
+}
+
+func (s *memoryStore) DeleteMachineInstanceEvent(eventId string) error { return nil }
+
+func (s *memoryStore) LoadMachineInstanceEvents() ([]api.MachineEvent, error) { return nil, nil }
+
+func (s *memoryStore) lastState() (structs.MachineInstanceState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.states) == 0 {
+		return structs.MachineInstanceState{}, false
+	}
+	return s.states[len(s.states)-1], true
+}
+
+type noopEventer struct{}
+
+func (noopEventer) ReportEvent(event *api.MachineEvent) {}
+
+func newTestState(store Store, restoreSnapshot string) *MachineInstanceState {
+	return NewMachineInstanceState(store, structs.MachineInstance{
+		Machine: cluster.Machine{Id: "machine-1", InstanceId: "instance-1"},
+		State: structs.MachineInstanceState{
+			DesiredStatus:   api.MachineStatusRunning,
+			Status:          api.MachineStatusCreated,
+			RestoreSnapshot: restoreSnapshot,
+		},
+	}, noopEventer{}, func(mi cluster.MachineInstance) error { return nil })
+}
+
+func TestTakeRestoreSnapshot(t *testing.T) {
+	store := &memoryStore{}
+	s := newTestState(store, "fork-1")
+
+	snapshotId, err := s.TakeRestoreSnapshot()
+	if err != nil {
+		t.Fatalf("TakeRestoreSnapshot() error = %v", err)
+	}
+	if snapshotId != "fork-1" {
+		t.Errorf("TakeRestoreSnapshot() = %q, want %q", snapshotId, "fork-1")
+	}
+
+	if restore := s.State().RestoreSnapshot; restore != "" {
+		t.Errorf("RestoreSnapshot = %q after it was taken, want it cleared", restore)
+	}
+
+	stored, ok := store.lastState()
+	if !ok || stored.RestoreSnapshot != "" {
+		t.Errorf("stored state = %+v, want the cleared snapshot stored", stored)
+	}
+
+	// the next starts boot the instance
+	snapshotId, err = s.TakeRestoreSnapshot()
+	if err != nil || snapshotId != "" {
+		t.Errorf("second TakeRestoreSnapshot() = %q, %v, want no snapshot", snapshotId, err)
+	}
+}
+
+func TestTakeRestoreSnapshotWithoutSnapshot(t *testing.T) {
+	store := &memoryStore{}
+	s := newTestState(store, "")
+
+	snapshotId, err := s.TakeRestoreSnapshot()
+	if err != nil || snapshotId != "" {
+		t.Fatalf("TakeRestoreSnapshot() = %q, %v, want no snapshot", snapshotId, err)
+	}
+
+	if _, ok := store.lastState(); ok {
+		t.Error("TakeRestoreSnapshot() stored the state of an instance without snapshot")
+	}
+}
+
+func TestTakeRestoreSnapshotStoreError(t *testing.T) {
+	store := &memoryStore{updateErr: errors.New("store is closed")}
+	s := newTestState(store, "fork-1")
+
+	snapshotId, err := s.TakeRestoreSnapshot()
+	if err == nil {
+		t.Fatal("TakeRestoreSnapshot() succeeded although the state could not be stored")
+	}
+	if snapshotId != "" {
+		t.Errorf("TakeRestoreSnapshot() = %q with an error, want no snapshot", snapshotId)
+	}
+}
@@ -28,6 +28,7 @@ type Store interface {
 	LoadMachineInstances() ([]structs.MachineInstance, error)
 	DeleteMachineInstance(id string) error
 	UpdateMachineInstance(id string, mi *structs.MachineInstanceState, event *api.MachineEvent) error
+	UpdateMachineInstanceVersion(id string, machine cluster.Machine, version api.MachineVersion) error
 	DeleteMachineInstanceEvent(eventId string) error
 	LoadMachineInstanceEvents() ([]api.MachineEvent, error)
 }
@@ -33,8 +33,10 @@ func (s *MachineInstanceState) sync() (finished bool, err error) {
 		Id:                   s.machine.InstanceId,
 		Node:                 s.machine.Node,
 		Namespace:            s.machine.Namespace,
+		MachineId:            s.machine.Id,
 		MachineVersion:       s.machine.MachineVersion,
 		Status:               state.Status,
+		Health:               state.Health,
 		Events:               state.LastEvents,
 		LocalIPV4:            s.networking.Local.InstanceIP.String(),
 		CreatedAt:            state.CreatedAt,
@@ -0,0 +1,33 @@
+package machinerunner
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+// Update replaces the machine version and its instance. The previous instance
+// is stopped and destroyed, then a new instance is prepared from the new version
+// and started if the machine is desired to be running.
+func (m *MachineRunner) Update(ctx context.Context, instanceId string, version api.MachineVersion) error {
+	_, _, err := m.state.PushUpdateEvent(instanceId, version)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *MachineRunner) updateImpl(p *api.MachineUpdateEventPayload) {
+	m.runLock.Lock()
+	err := m.stopAndDestroyInstance(p.PreviousInstanceId)
+	m.runLock.Unlock()
+	if err != nil {
+		slog.Error("failed to stop and destroy previous instance", "instance", p.PreviousInstanceId, "error", err)
+		m.state.PushPrepareFailedEvent("Failed to destroy previous instance")
+		return
+	}
+
+	m.prepare(context.Background())
+}
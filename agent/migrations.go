@@ -0,0 +1,449 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexisbouchez/ravel/agent/machinerunner"
+	"github.com/alexisbouchez/ravel/agent/structs"
+	"github.com/alexisbouchez/ravel/agent/transfer"
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/daemon"
+	"github.com/alexisbouchez/ravel/core/validation"
+	"github.com/alexisbouchez/ravel/internal/httpclient"
+	"golang.org/x/net/websocket"
+)
+
+// The volumes of a migrated machine are copied twice: a first time while the machine runs,
+// then only the changes since the first copy once its filesystems are frozen.
+const (
+	migrationBaseSnapshot  = "migration-base"
+	migrationFinalSnapshot = "migration-final"
+)
+
+// incomingMigration is a live migration received by the agent, it is identified by the
+// instance id of the machine on the agent node. Its fields are guarded by mu, which is held for
+// the whole of each step of the migration.
+type incomingMigration struct {
+	mu            sync.Mutex
+	aborted       bool
+	volumes       []string
+	machine       *machinerunner.MachineRunner // set once the migration is prepared
+	rootfs        string
+	opt           daemon.InstanceMigrationOptions
+	desiredStatus api.MachineStatus
+}
+
+// lockIncomingMigration returns a locked incoming migration, it is created if create is true
+// and it does not exist.
+func (a *Agent) lockIncomingMigration(id string, create bool) (*incomingMigration, error) {
+	a.migrationsLock.Lock()
+	m, ok := a.migrations[id]
+	if !ok && create {
+		m = &incomingMigration{}
+		a.migrations[id] = m
+	}
+	a.migrationsLock.Unlock()
+
+	if m == nil {
+		return nil, errdefs.NewNotFound("migration not found")
+	}
+
+	m.mu.Lock()
+	if m.aborted {
+		m.mu.Unlock()
+		return nil, errdefs.NewNotFound("migration not found")
+	}
+
+	return m, nil
+}
+
+// MigrateMachine copies the volumes, the root filesystem and then the memory of a running
+// machine to the target node. The target node is told to abort the migration if it fails, the
+// machine then keeps running on the agent node.
+func (a *Agent) MigrateMachine(ctx context.Context, id string, opt cluster.MigrateMachineOptions) (err error) {
+	machine, err := a.machines.GetMachine(id)
+	if err != nil {
+		return err
+	}
+
+	mi := machine.MachineInstance()
+	if mi.State.Status != api.MachineStatusRunning {
+		return errdefs.NewFailedPrecondition(fmt.Sprintf("machine is in %s status", mi.State.Status))
+	}
+
+	if len(mi.Network.PrivateNetworks) > 0 {
+		return errdefs.NewFailedPrecondition("machines attached to private networks cannot be live migrated")
+	}
+
+	instanceId := mi.Machine.InstanceId
+	devices, err := a.runtime.InstanceDevices(ctx, instanceId)
+	if err != nil {
+		return err
+	}
+
+	rootfs, err := a.runtime.InstanceRootFS(ctx, instanceId)
+	if err != nil {
+		return err
+	}
+
+	volumes := mi.InstanceOptions().Config.GetDisks()
+	target := a.transfers.Agent(opt.Target, opt.Token)
+	path := "/transfers/migrations/" + opt.Machine.Machine.InstanceId
+
+	var snapshots []string
+	frozen := false
+	defer func() {
+		if err != nil {
+			if err := target.Delete(context.Background(), path); err != nil {
+				slog.Error("failed to abort migration on the target node", "machine_id", id, "err", err)
+			}
+			if frozen {
+				if err := a.runtime.ThawInstanceFilesystems(context.Background(), instanceId); err != nil {
+					slog.Error("failed to thaw machine filesystems", "machine_id", id, "err", err)
+				}
+			}
+		}
+		a.deleteMigrationSnapshots(volumes, snapshots)
+	}()
+
+	snapshots = append(snapshots, migrationBaseSnapshot)
+	for _, volume := range volumes {
+		if err = a.sendMigrationVolume(ctx, target, path, volume, migrationBaseSnapshot, ""); err != nil {
+			return fmt.Errorf("failed to send volume %s: %w", volume, err)
+		}
+	}
+
+	err = target.Post(ctx, path, nil, httpclient.WithJSONBody(transfer.IncomingMigration{
+		Machine:       opt.Machine,
+		DesiredStatus: mi.State.DesiredStatus,
+		Network:       mi.Network,
+		Devices:       devices,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to prepare migration: %w", err)
+	}
+
+	var hashes []string
+	if err = target.Get(ctx, path+"/rootfs", &hashes); err != nil {
+		return fmt.Errorf("failed to get root filesystem blocks: %w", err)
+	}
+
+	putBlock := func(ctx context.Context, index int, block []byte) error {
+		return target.Post(ctx, fmt.Sprintf("%s/rootfs/%d", path, index), nil, httpclient.WithBody(bytes.NewReader(block)))
+	}
+
+	if hashes, err = transfer.PushBlocks(ctx, rootfs, hashes, putBlock); err != nil {
+		return fmt.Errorf("failed to send root filesystem: %w", err)
+	}
+
+	if err = a.runtime.FreezeInstanceFilesystems(ctx, instanceId); err != nil {
+		return fmt.Errorf("failed to freeze machine filesystems: %w", err)
+	}
+	frozen = true
+
+	snapshots = append(snapshots, migrationFinalSnapshot)
+	for _, volume := range volumes {
+		if err = a.sendMigrationVolume(ctx, target, path, volume, migrationFinalSnapshot, migrationBaseSnapshot); err != nil {
+			return fmt.Errorf("failed to send volume %s: %w", volume, err)
+		}
+	}
+
+	if _, err = transfer.PushBlocks(ctx, rootfs, hashes, putBlock); err != nil {
+		return fmt.Errorf("failed to send root filesystem: %w", err)
+	}
+
+	ws, err := target.DialWebSocket(ctx, path+"/memory")
+	if err != nil {
+		return fmt.Errorf("failed to connect to the target node: %w", err)
+	}
+	ws.PayloadType = websocket.BinaryFrame
+
+	if err = machine.SendMigration(ctx, ws); err != nil {
+		return fmt.Errorf("failed to send machine memory: %w", err)
+	}
+
+	return nil
+}
+
+// sendMigrationVolume streams a snapshot of a volume to the target node, only the changes
+// since the base snapshot if it is set.
+func (a *Agent) sendMigrationVolume(ctx context.Context, target *httpclient.Client, path, volume, snapshot, base string) error {
+	disk, err := a.runtime.GetDisk(volume)
+	if err != nil {
+		return err
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(a.runtime.SendDisk(volume, snapshot, base, w))
+	}()
+
+	err = target.Post(ctx, path+"/volumes/"+volume, nil,
+		httpclient.WithBody(r),
+		httpclient.WithQuery("size_mb", strconv.FormatUint(disk.SizeMB, 10)),
+	)
+	r.CloseWithError(err) // unblocks the sender if the request failed
+
+	return err
+}
+
+func (a *Agent) deleteMigrationSnapshots(volumes []string, snapshots []string) {
+	for _, volume := range volumes {
+		for _, snapshot := range snapshots {
+			if err := a.runtime.DeleteSentDiskSnapshot(volume, snapshot); err != nil {
+				slog.Warn("failed to delete migration snapshot", "volume", volume, "snapshot", snapshot, "err", err)
+			}
+		}
+	}
+}
+
+// GrantMigrationTransfer allows a node to migrate a machine to the agent node.
+func (a *Agent) GrantMigrationTransfer(ctx context.Context, instanceId string, grant cluster.TransferGrant) error {
+	if err := validation.ValidateObjectId(instanceId); err != nil {
+		return errdefs.NewInvalidArgument(err.Error())
+	}
+
+	return a.grants.Grant(transfer.MigrationTransfer, instanceId, grant.Node, grant.Token)
+}
+
+// ReceiveMigrationVolume creates a volume of an incoming migration, or applies the changes
+// sent once the machine is frozen.
+func (a *Agent) ReceiveMigrationVolume(id string, volume string, sizeMB uint64, r io.Reader) error {
+	m, err := a.lockIncomingMigration(id, true)
+	if err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	received := false
+	for _, v := range m.volumes {
+		if v == volume {
+			received = true
+		}
+	}
+
+	if !received {
+		if _, err := a.runtime.GetDisk(volume); err == nil {
+			return errdefs.NewAlreadyExists("volume already exists")
+		}
+	}
+
+	if _, err := a.runtime.ReceiveDisk(volume, sizeMB, r); err != nil {
+		return err
+	}
+
+	if !received {
+		m.volumes = append(m.volumes, volume)
+	}
+
+	return nil
+}
+
+// PrepareIncomingMigration creates the machine of an incoming migration, it is started from
+// the memory of the machine of the source node by ReceiveMachineMigration.
+func (a *Agent) PrepareIncomingMigration(ctx context.Context, id string, opt transfer.IncomingMigration) error {
+	if opt.Machine.Machine.InstanceId != id {
+		return errdefs.NewInvalidArgument("the migration id must be the instance id of the machine")
+	}
+
+	m, err := a.lockIncomingMigration(id, true)
+	if err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if m.machine != nil {
+		return errdefs.NewAlreadyExists("migration already prepared")
+	}
+
+	machine, err := a.putIncomingMachine(opt, &m.opt)
+	if err != nil {
+		return err
+	}
+	m.machine = machine
+	m.desiredStatus = opt.DesiredStatus
+
+	if err := machine.WaitForStatus(ctx, api.MachineStatusStopped); err != nil {
+		return err
+	}
+
+	rootfs, err := a.runtime.InstanceRootFS(ctx, id)
+	if err != nil {
+		return err
+	}
+	m.rootfs = rootfs
+
+	return nil
+}
+
+// putIncomingMachine creates a stopped machine with the network of the source node if it is
+// free on the agent node, otherwise the network of the VM is reset once it is received.
+func (a *Agent) putIncomingMachine(opt transfer.IncomingMigration, migration *daemon.InstanceMigrationOptions) (machine *machinerunner.MachineRunner, err error) {
+	_, err = a.allocator.ConfirmAllocation(opt.Machine.AllocationId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm reservation: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if err := a.allocator.DeleteAllocation(opt.Machine.AllocationId); err != nil {
+				slog.Error("failed to release reservation", "err", err)
+			}
+		}
+	}()
+
+	network := opt.Network
+	network.PrivateNetworks = nil
+	if err := a.network.Allocate(network); err != nil {
+		slog.Debug("network of the migrated machine is not free", "machine_id", opt.Machine.Machine.Id, "err", err)
+
+		network, err = a.network.AllocateNext()
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate network: %w", err)
+		}
+		// the tap device is named in the configuration of the VM
+		network.TapDevice = opt.Network.TapDevice
+		migration.ResetNetwork = true
+	}
+	defer func() {
+		if err != nil {
+			a.network.Release(network)
+		}
+	}()
+	migration.Devices = opt.Devices
+
+	machineInstance := structs.MachineInstance{
+		Machine: opt.Machine.Machine,
+		Version: opt.Machine.Version,
+		State: structs.MachineInstanceState{
+			DesiredStatus:         api.MachineStatusStopped,
+			Status:                api.MachineStatusCreated,
+			CreatedAt:             time.Now(),
+			UpdatedAt:             time.Now(),
+			MachineGatewayEnabled: opt.Machine.EnableGateway,
+		},
+		Network: network,
+	}
+
+	if err = a.store.CreateMachineInstance(machineInstance); err != nil {
+		return nil, fmt.Errorf("failed to put machine: %w", err)
+	}
+
+	machine = a.newMachine(machineInstance)
+	a.machines.AddMachine(machine)
+	go machine.Run()
+
+	return machine, nil
+}
+
+// MigrationRootFSHashes returns the hashes of the blocks of the root filesystem of the machine
+// of an incoming migration.
+func (a *Agent) MigrationRootFSHashes(id string) ([]string, error) {
+	m, err := a.lockIncomingMigration(id, false)
+	if err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	if m.rootfs == "" {
+		return nil, errdefs.NewFailedPrecondition("migration is not prepared")
+	}
+
+	return transfer.BlockHashes(m.rootfs)
+}
+
+func (a *Agent) WriteMigrationRootFSBlock(id string, index int, r io.Reader) error {
+	m, err := a.lockIncomingMigration(id, false)
+	if err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if m.rootfs == "" {
+		return errdefs.NewFailedPrecondition("migration is not prepared")
+	}
+
+	return transfer.WriteBlock(m.rootfs, index, r)
+}
+
+// ReceiveMachineMigration starts the machine of an incoming migration from the memory of the
+// machine of the source node, sent over conn.
+func (a *Agent) ReceiveMachineMigration(ctx context.Context, id string, conn io.ReadWriteCloser) error {
+	m, err := a.lockIncomingMigration(id, false)
+	if err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if m.rootfs == "" {
+		return errdefs.NewFailedPrecondition("migration is not prepared")
+	}
+
+	if err := m.machine.ReceiveMigration(ctx, m.opt, conn, m.desiredStatus); err != nil {
+		return err
+	}
+
+	a.migrationsLock.Lock()
+	delete(a.migrations, id)
+	a.migrationsLock.Unlock()
+
+	// the filesystems have been frozen on the source node before the last copy
+	if err := a.runtime.ThawInstanceFilesystems(ctx, id); err != nil {
+		slog.Error("failed to thaw migrated machine filesystems", "machine_id", m.machine.Id(), "err", err)
+	}
+
+	a.deleteMigrationSnapshots(m.volumes, []string{migrationBaseSnapshot, migrationFinalSnapshot})
+
+	return nil
+}
+
+// AbortIncomingMigration destroys the machine and the volumes received by an incoming migration,
+// once the step of the migration in progress is done.
+func (a *Agent) AbortIncomingMigration(id string) error {
+	a.migrationsLock.Lock()
+	m, ok := a.migrations[id]
+	delete(a.migrations, id)
+	a.migrationsLock.Unlock()
+
+	if !ok {
+		return errdefs.NewNotFound("migration not found")
+	}
+
+	go func() {
+		m.mu.Lock()
+		m.aborted = true
+		defer m.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if m.machine != nil {
+			if err := m.machine.Destroy(ctx, true); err != nil {
+				slog.Error("failed to destroy migrated machine", "machine_id", m.machine.Id(), "err", err)
+				return
+			}
+
+			// the volumes are attached to the instance until it is destroyed
+			if err := m.machine.WaitForStatus(ctx, api.MachineStatusDestroyed); err != nil {
+				slog.Error("failed to destroy migrated machine", "machine_id", m.machine.Id(), "err", err)
+				return
+			}
+		}
+
+		for _, volume := range m.volumes {
+			if err := a.runtime.DestroyDisk(volume); err != nil {
+				slog.Error("failed to destroy migrated volume", "volume", volume, "err", err)
+			}
+		}
+	}()
+
+	return nil
+}
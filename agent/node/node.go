@@ -3,16 +3,25 @@ package node
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/core/cluster"
 )
 
+// Store persists whether the node is cordoned.
+type Store interface {
+	LoadNodeCordoned() (bool, error)
+	PutNodeCordoned(cordoned bool) error
+}
+
 type Node struct {
 	ctx       context.Context
 	cancelCtx context.CancelFunc
 	cluster   cluster.ClusterState
+	store     Store
+	lock      sync.RWMutex
 	localNode api.Node
 }
 
@@ -20,14 +29,41 @@ func (n *Node) Id() string {
 	return n.localNode.Id
 }
 
-func NewNode(c cluster.ClusterState, node api.Node) *Node {
+func NewNode(c cluster.ClusterState, store Store, node api.Node) (*Node, error) {
+	cordoned, err := store.LoadNodeCordoned()
+	if err != nil {
+		return nil, err
+	}
+	node.Cordoned = cordoned
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Node{
 		cluster:   c,
+		store:     store,
 		localNode: node,
 		ctx:       ctx,
 		cancelCtx: cancel,
+	}, nil
+}
+
+// Cordoned reports whether the node must not answer the placement requests.
+func (n *Node) Cordoned() bool {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.localNode.Cordoned
+}
+
+// SetCordoned cordons or uncordons the node, the change is kept across restarts.
+func (n *Node) SetCordoned(cordoned bool) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if err := n.store.PutNodeCordoned(cordoned); err != nil {
+		return err
 	}
+	n.localNode.Cordoned = cordoned
+
+	return nil
 }
 
 func (n *Node) Start() error {
@@ -40,8 +76,12 @@ func (n *Node) Stop() {
 }
 
 func (n *Node) heartbeat(ctx context.Context) error {
+	n.lock.Lock()
 	n.localNode.HeartbeatedAt = time.Now()
-	return n.cluster.UpsertNode(ctx, n.localNode)
+	node := n.localNode
+	n.lock.Unlock()
+
+	return n.cluster.UpsertNode(ctx, node)
 }
 
 func (n *Node) startHeartbeating(ctx context.Context) {
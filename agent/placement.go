@@ -4,18 +4,46 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/alexisbouchez/ravel/agent/machinerunner"
+	"github.com/alexisbouchez/ravel/api"
+
 	"github.com/alexisbouchez/ravel/core/cluster/placement"
 )
 
 func (a *Agent) startPlacementHandler() error {
 	l := placement.NewListener(a.nc)
 	max := a.allocator.Max()
+	runtimes := a.runtime.Runtimes()
 
 	err := l.HandleMachinePlacementRequest(
 		context.Background(),
 		a.config.Region,
 		func(msg *placement.PlacementRequest) *placement.PlacementResponse {
 			slog.Debug("Received placement request", "request", msg)
+			if msg.Node != "" && msg.Node != a.node.Id() {
+				return nil
+			}
+
+			if a.node.Cordoned() {
+				slog.Debug("Ignoring placement request, the node is cordoned")
+				return nil
+			}
+
+			if !msg.MatchesNodeLabels(a.config.Labels) {
+				slog.Debug("Ignoring placement request, the node labels do not match")
+				return nil
+			}
+
+			if !msg.MatchesRuntimes(runtimes) {
+				slog.Debug("Ignoring placement request, the node does not support the runtime", "runtime", msg.Runtime)
+				return nil
+			}
+
+			if msg.AvoidFleet != "" && a.runsFleet(msg.AvoidFleet) {
+				slog.Debug("Ignoring placement request, the node runs a machine of the fleet", "fleet", msg.AvoidFleet)
+				return nil
+			}
+
 			_, before, after, err := a.allocator.CreateAllocation(msg.AllocationId, msg.Resources)
 			if err != nil {
 				slog.Error("Failed to create reservation", "error", err)
@@ -28,6 +56,8 @@ func (a *Agent) startPlacementHandler() error {
 				Allocatable:     max,
 				AllocatedBefore: before,
 				AllocatedAfter:  after,
+				Machines:        a.countMachines(),
+				Runtimes:        runtimes,
 			}
 		})
 	if err != nil {
@@ -36,3 +66,38 @@ func (a *Agent) startPlacementHandler() error {
 
 	return nil
 }
+
+// runsFleet reports whether a machine of the fleet, not destroyed, is on the node.
+func (a *Agent) runsFleet(fleet string) bool {
+	found := false
+	a.machines.Foreach(func(m *machinerunner.MachineRunner) {
+		mi := m.MachineInstance()
+		if mi.Machine.FleetId != fleet {
+			return
+		}
+
+		status := mi.State.Status
+		if status != api.MachineStatusDestroying && status != api.MachineStatusDestroyed {
+			found = true
+		}
+	})
+
+	return found
+}
+
+func (a *Agent) countMachines() int {
+	count := 0
+	a.machines.Foreach(func(m *machinerunner.MachineRunner) {
+		count++
+	})
+
+	return count
+}
+
+func (a *Agent) CordonNode(ctx context.Context) error {
+	return a.node.SetCordoned(true)
+}
+
+func (a *Agent) UncordonNode(ctx context.Context) error {
+	return a.node.SetCordoned(false)
+}
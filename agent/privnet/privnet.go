@@ -0,0 +1,321 @@
+// Package privnet configures the Wireguard tunnels of the machines private networks.
+//
+// Each machine gets one Wireguard interface per private network on its node, with its own
+// keypair and listen port. When an interface is configured, the agent joins the network
+// through the servers which record the machine as a peer and publish the peers of the network
+// to every agent, which then update the interfaces of their local machines.
+package privnet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/config"
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/runtime/wireguard"
+	"github.com/nats-io/nats.go"
+)
+
+const resyncInterval = 30 * time.Second
+
+var DefaultPorts = config.PortRange{Min: 51820, Max: 52819}
+
+type networkKey struct {
+	namespace string
+	network   string
+}
+
+type member struct {
+	machineId string
+	config    instance.WireguardNetworkConfig
+}
+
+type Manager struct {
+	nc        *nats.Conn
+	nodeId    string
+	endpoint  string
+	ports     config.PortRange
+	ctx       context.Context
+	cancelCtx context.CancelFunc
+	sub       *nats.Subscription
+
+	mu      sync.Mutex
+	used    map[int]bool
+	members map[networkKey][]member
+	peers   map[networkKey][]cluster.NetworkPeer
+}
+
+func New(nc *nats.Conn, agent *config.AgentConfig) (*Manager, error) {
+	endpoint := agent.Address
+	ports := DefaultPorts
+	if agent.Wireguard != nil {
+		if agent.Wireguard.Endpoint != "" {
+			endpoint = agent.Wireguard.Endpoint
+		}
+		if agent.Wireguard.Ports != nil {
+			ports = *agent.Wireguard.Ports
+		}
+	}
+
+	if err := ports.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid wireguard ports: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		nc:        nc,
+		nodeId:    agent.NodeId,
+		endpoint:  endpoint,
+		ports:     ports,
+		ctx:       ctx,
+		cancelCtx: cancel,
+		used:      map[int]bool{},
+		members:   map[networkKey][]member{},
+		peers:     map[networkKey][]cluster.NetworkPeer{},
+	}, nil
+}
+
+// Start listens for the peers published by the servers and periodically applies them
+// to the interfaces of the local machines, which may have been recreated since.
+func (m *Manager) Start() error {
+	sub, err := m.nc.Subscribe("networks.peers", func(msg *nats.Msg) {
+		var peers cluster.NetworkPeers
+		if err := json.Unmarshal(msg.Data, &peers); err != nil {
+			slog.Info("failed to unmarshal message", "error", err)
+			return
+		}
+
+		m.setPeers(peers)
+	})
+	if err != nil {
+		return err
+	}
+	m.sub = sub
+
+	go m.resync()
+
+	return nil
+}
+
+func (m *Manager) Stop() {
+	m.cancelCtx()
+	if m.sub != nil {
+		m.sub.Unsubscribe()
+	}
+}
+
+func (m *Manager) resync() {
+	ticker := time.NewTicker(resyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.mu.Lock()
+			for key := range m.members {
+				m.applyPeers(key)
+			}
+			m.mu.Unlock()
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// Configure creates the Wireguard configuration of a new machine private networks and
+// joins them. The machine version must hold the IPs allocated by the servers.
+func (m *Manager) Configure(machine cluster.Machine, version api.MachineVersion) (configs []instance.WireguardNetworkConfig, err error) {
+	networks := version.Config.Workload.PrivateNetworks
+	if len(networks) == 0 {
+		return nil, nil
+	}
+
+	defer func() {
+		if err != nil {
+			m.Release(machine.Id, configs)
+		}
+	}()
+
+	for _, pn := range networks {
+		keys, err := wireguard.GenerateKeyPair()
+		if err != nil {
+			return configs, err
+		}
+
+		port, err := m.allocatePort()
+		if err != nil {
+			return configs, err
+		}
+
+		configs = append(configs, instance.WireguardNetworkConfig{
+			NetworkName:   pn.Name,
+			InterfaceName: "rw" + strconv.Itoa(port),
+			PrivateKey:    keys.PrivateKey.String(),
+			PublicKey:     keys.PublicKey.String(),
+			IPAddress:     pn.IP,
+			ListenPort:    port,
+		})
+	}
+
+	for i := range configs {
+		peers, err := m.join(machine, configs[i])
+		if err != nil {
+			return configs, fmt.Errorf("failed to join network %s: %w", configs[i].NetworkName, err)
+		}
+		configs[i].Peers = m.instancePeers(configs[i], peers.Peers)
+	}
+
+	return configs, nil
+}
+
+// Restore registers the private networks of a machine loaded from the store and joins them
+// again, the peers may have changed while the agent was down.
+func (m *Manager) Restore(machine cluster.Machine, configs []instance.WireguardNetworkConfig) {
+	m.mu.Lock()
+	for _, c := range configs {
+		m.used[c.ListenPort] = true
+	}
+	m.mu.Unlock()
+
+	for _, c := range configs {
+		if _, err := m.join(machine, c); err != nil {
+			slog.Error("failed to join private network", "machine_id", machine.Id, "network", c.NetworkName, "error", err)
+		}
+	}
+}
+
+// Release frees the ports of a machine private networks. The servers remove the machine
+// from the networks peers once it is destroyed.
+func (m *Manager) Release(machineId string, configs []instance.WireguardNetworkConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range configs {
+		delete(m.used, c.ListenPort)
+	}
+
+	for key, members := range m.members {
+		kept := members[:0]
+		for _, mb := range members {
+			if mb.machineId != machineId {
+				kept = append(kept, mb)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(m.members, key)
+			delete(m.peers, key)
+			continue
+		}
+		m.members[key] = kept
+	}
+}
+
+func (m *Manager) allocatePort() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for port := m.ports.Min; port <= m.ports.Max; port++ {
+		if !m.used[port] {
+			m.used[port] = true
+			return port, nil
+		}
+	}
+
+	return 0, errors.New("no wireguard port available")
+}
+
+func (m *Manager) join(machine cluster.Machine, c instance.WireguardNetworkConfig) (cluster.NetworkPeers, error) {
+	key := networkKey{namespace: machine.Namespace, network: c.NetworkName}
+
+	m.mu.Lock()
+	m.members[key] = append(m.members[key], member{machineId: machine.Id, config: c})
+	m.mu.Unlock()
+
+	bytes, err := json.Marshal(cluster.NetworkJoin{
+		Namespace: machine.Namespace,
+		Network:   c.NetworkName,
+		Peer: cluster.NetworkPeer{
+			MachineId: machine.Id,
+			Node:      m.nodeId,
+			PublicKey: c.PublicKey,
+			Endpoint:  net.JoinHostPort(m.endpoint, strconv.Itoa(c.ListenPort)),
+		},
+	})
+	if err != nil {
+		return cluster.NetworkPeers{}, err
+	}
+
+	msg, err := m.nc.Request("networks.join", bytes, 5*time.Second)
+	if err != nil {
+		return cluster.NetworkPeers{}, err
+	}
+
+	var peers cluster.NetworkPeers
+	if err := json.Unmarshal(msg.Data, &peers); err != nil {
+		return cluster.NetworkPeers{}, err
+	}
+
+	m.mu.Lock()
+	m.peers[key] = peers.Peers
+	m.mu.Unlock()
+
+	return peers, nil
+}
+
+func (m *Manager) setPeers(peers cluster.NetworkPeers) {
+	key := networkKey{namespace: peers.Namespace, network: peers.Network}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.members[key]; !ok {
+		return // no local machine in this network
+	}
+
+	m.peers[key] = peers.Peers
+	m.applyPeers(key)
+}
+
+// applyPeers updates the interfaces of the local members of a network, m.mu must be held.
+func (m *Manager) applyPeers(key networkKey) {
+	for _, mb := range m.members[key] {
+		peers, err := wireguard.PeersFromInstance(m.instancePeers(mb.config, m.peers[key]))
+		if err != nil {
+			slog.Error("invalid network peers", "network", key.network, "error", err)
+			continue
+		}
+
+		err = wireguard.SetPeers(mb.config.InterfaceName, peers)
+		if err != nil && !errors.Is(err, os.ErrNotExist) { // the interface exists only while the machine runs
+			slog.Error("failed to set network peers", "machine_id", mb.machineId, "network", key.network, "error", err)
+		}
+	}
+}
+
+// instancePeers converts the peers of a network to the peers of a member interface.
+func (m *Manager) instancePeers(c instance.WireguardNetworkConfig, peers []cluster.NetworkPeer) []instance.WireguardPeer {
+	result := make([]instance.WireguardPeer, 0, len(peers))
+	for _, p := range peers {
+		if p.PublicKey == c.PublicKey {
+			continue
+		}
+
+		result = append(result, instance.WireguardPeer{
+			PublicKey:  p.PublicKey,
+			AllowedIPs: []string{p.IP + "/32"},
+			Endpoint:   p.Endpoint,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/config"
+	"github.com/alexisbouchez/ravel/initd"
+	"github.com/alexisbouchez/ravel/internal/httpclient"
+)
+
+type serverAPIClient struct {
+	client *httpclient.Client
+	bearer string
+}
+
+// newServerAPIClient returns the client of the API server the secrets of the machines are fetched
+// from, it authenticates with the agent certificate over https. It is nil if no API server is
+// configured.
+func newServerAPIClient(c *config.AgentConfig) (*serverAPIClient, error) {
+	if c.ServerAPI == nil || c.ServerAPI.URL == "" {
+		return nil, nil
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	if c.TLS != nil && strings.HasPrefix(c.ServerAPI.URL, "https://") {
+		cert, err := c.TLS.LoadCert()
+		if err != nil {
+			return nil, err
+		}
+
+		ca, err := c.TLS.LoadCA()
+		if err != nil {
+			return nil, err
+		}
+
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:            ca,
+				Certificates:       []tls.Certificate{cert},
+				InsecureSkipVerify: c.TLS.SkipVerifyServer,
+			},
+		}
+	}
+
+	return &serverAPIClient{
+		client: httpclient.NewClient(c.ServerAPI.URL, client),
+		bearer: c.ServerAPI.BearerToken,
+	}, nil
+}
+
+func (c *serverAPIClient) get(ctx context.Context, path string, dest any) error {
+	var opts []httpclient.ReqOpt
+	if c.bearer != "" {
+		opts = append(opts, httpclient.WithHeader("Authorization", c.bearer))
+	}
+	return c.client.Get(ctx, path, dest, opts...)
+}
+
+// fetchMachineSecrets fetches the secrets referenced by a version of a machine from the API
+// server, they are handed to the init of the machine and never stored on the node.
+func (a *Agent) fetchMachineSecrets(ctx context.Context, machineId, version string) (initd.Secrets, error) {
+	if a.serverAPI == nil {
+		return initd.Secrets{}, errdefs.NewFailedPrecondition("the machine uses secrets but no server API is configured on the agent")
+	}
+
+	var values []cluster.MachineSecret
+	err := a.serverAPI.get(ctx, "/internal/machines/"+machineId+"/versions/"+version+"/secrets", &values)
+	if err != nil {
+		return initd.Secrets{}, err
+	}
+
+	return newInitdSecrets(values), nil
+}
+
+func newInitdSecrets(values []cluster.MachineSecret) initd.Secrets {
+	secrets := initd.Secrets{
+		Env:   []string{},
+		Files: []initd.SecretFile{},
+	}
+
+	for _, value := range values {
+		if value.EnvVar != "" {
+			secrets.Env = append(secrets.Env, value.EnvVar+"="+value.Value)
+		}
+		if value.File != "" {
+			secrets.Files = append(secrets.Files, initd.SecretFile{Name: value.File, Value: value.Value})
+		}
+	}
+
+	return secrets
+}
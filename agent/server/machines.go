@@ -2,12 +2,14 @@ package server
 
 import (
 	"context"
+	"io"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
 	"github.com/alexisbouchez/ravel/core/cluster"
 	"github.com/alexisbouchez/ravel/internal/streamutil"
 	"github.com/danielgtaylor/huma/v2"
+	"golang.org/x/net/websocket"
 )
 
 type CreateMachineRequest struct {
@@ -65,6 +67,62 @@ func (s *AgentServer) machineExec(ctx context.Context, req *MachineExecRequest)
 	return &MachineExecResponse{Body: res}, nil
 }
 
+type MachineExecStreamRequest struct {
+	Id   string `path:"id"`
+	Body api.ExecStreamRequest
+}
+
+func (s *AgentServer) machineExecStream(ctx context.Context, req *MachineExecStreamRequest) (*huma.StreamResponse, error) {
+	output, err := s.agent.MachineExecStream(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to stream machine exec", err)
+		return nil, err
+	}
+
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			streamutil.CopyLines(ctx, output)
+		},
+	}, nil
+}
+
+type MachineExecSessionRequest struct {
+	Id string `path:"id"`
+}
+
+func (s *AgentServer) machineExecSession(ctx context.Context, req *MachineExecSessionRequest) (*huma.StreamResponse, error) {
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			streamutil.ServeWebSocket(ctx, func(ws *websocket.Conn) {
+				streamutil.RelayExecSession(ws, func() (io.ReadWriteCloser, error) {
+					session, err := s.agent.MachineExecSession(ctx.Context(), req.Id)
+					if err != nil {
+						s.log("Failed to open machine exec session", err)
+					}
+					return session, err
+				})
+			})
+		},
+	}, nil
+}
+
+type UpdateMachineRequest struct {
+	Id   string `path:"id"`
+	Body cluster.UpdateMachineOptions
+}
+
+type UpdateMachineResponse struct {
+}
+
+func (s *AgentServer) updateMachine(ctx context.Context, req *UpdateMachineRequest) (*UpdateMachineResponse, error) {
+	err := s.agent.UpdateMachine(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to update machine", err)
+		return nil, err
+	}
+	return &UpdateMachineResponse{}, nil
+}
+
 type StartMachineRequest struct {
 	Id string `path:"id"`
 }
@@ -117,6 +175,7 @@ func (s *AgentServer) followMachineLogs(ctx context.Context, req *FollowMachineL
 
 type GetMachineLogsRequest struct {
 	Id string `path:"id"`
+	api.LogsOptions
 }
 
 type GetMachineLogsResponse struct {
@@ -124,7 +183,7 @@ type GetMachineLogsResponse struct {
 }
 
 func (s *AgentServer) getMachineLogs(ctx context.Context, req *GetMachineLogsRequest) (*GetMachineLogsResponse, error) {
-	logs, err := s.agent.GetMachineLogs(ctx, req.Id)
+	logs, err := s.agent.GetMachineLogs(ctx, req.Id, req.LogsOptions)
 	if err != nil {
 		s.log("Failed to get machine logs", err)
 		return nil, err
@@ -202,28 +261,17 @@ type MachineSnapshotRequest struct {
 }
 
 type MachineSnapshotResponse struct {
-	Body struct {
-		SnapshotId string `json:"snapshot_id"`
-		Path       string `json:"path"`
-	}
+	Body api.MachineSnapshot
 }
 
 func (s *AgentServer) machineSnapshot(ctx context.Context, req *MachineSnapshotRequest) (*MachineSnapshotResponse, error) {
-	err := s.agent.MachineSnapshot(ctx, req.Id, req.Body.SnapshotId)
+	snapshot, err := s.agent.MachineSnapshot(ctx, req.Id, req.Body.SnapshotId)
 	if err != nil {
 		s.log("Failed to snapshot machine", err)
 		return nil, err
 	}
 
-	return &MachineSnapshotResponse{
-		Body: struct {
-			SnapshotId string `json:"snapshot_id"`
-			Path       string `json:"path"`
-		}{
-			SnapshotId: req.Body.SnapshotId,
-			Path:       "/var/lib/ravel/snapshots/" + req.Body.SnapshotId,
-		},
-	}, nil
+	return &MachineSnapshotResponse{Body: *snapshot}, nil
 }
 
 type MachineRestoreRequest struct {
@@ -245,3 +293,75 @@ func (s *AgentServer) machineRestore(ctx context.Context, req *MachineRestoreReq
 
 	return &MachineRestoreResponse{}, nil
 }
+
+type ForkMachineRequest struct {
+	Id   string `path:"id"`
+	Body cluster.ForkMachineOptions
+}
+
+type ForkMachineResponse struct {
+	Body []cluster.MachineInstance
+}
+
+func (s *AgentServer) forkMachine(ctx context.Context, req *ForkMachineRequest) (*ForkMachineResponse, error) {
+	instances, err := s.agent.ForkMachine(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to fork machine", err)
+		return nil, err
+	}
+
+	return &ForkMachineResponse{Body: instances}, nil
+}
+
+type DeleteMachineSnapshotRequest struct {
+	Id string `path:"id"`
+}
+
+type DeleteMachineSnapshotResponse struct {
+}
+
+func (s *AgentServer) deleteMachineSnapshot(ctx context.Context, req *DeleteMachineSnapshotRequest) (*DeleteMachineSnapshotResponse, error) {
+	err := s.agent.DeleteMachineSnapshot(ctx, req.Id)
+	if err != nil {
+		s.log("Failed to delete machine snapshot", err)
+		return nil, err
+	}
+
+	return &DeleteMachineSnapshotResponse{}, nil
+}
+
+type MigrateMachineRequest struct {
+	Id   string `path:"id"`
+	Body cluster.MigrateMachineOptions
+}
+
+type MigrateMachineResponse struct {
+}
+
+func (s *AgentServer) migrateMachine(ctx context.Context, req *MigrateMachineRequest) (*MigrateMachineResponse, error) {
+	err := s.agent.MigrateMachine(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to migrate machine", err)
+		return nil, err
+	}
+
+	return &MigrateMachineResponse{}, nil
+}
+
+type GrantMigrationTransferRequest struct {
+	Id   string `path:"id"`
+	Body cluster.TransferGrant
+}
+
+type GrantMigrationTransferResponse struct {
+}
+
+func (s *AgentServer) grantMigrationTransfer(ctx context.Context, req *GrantMigrationTransferRequest) (*GrantMigrationTransferResponse, error) {
+	err := s.agent.GrantMigrationTransfer(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to grant migration transfer", err)
+		return nil, err
+	}
+
+	return &GrantMigrationTransferResponse{}, nil
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/alexisbouchez/ravel/agent/transfer"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"golang.org/x/net/websocket"
+)
+
+// MigrationTarget receives the machines live migrated by the other agents.
+type MigrationTarget interface {
+	// TransferAllowed reports whether the token grants the migration to the node
+	TransferAllowed(kind string, id string, node string, token string) bool
+	ReceiveMigrationVolume(id string, volume string, sizeMB uint64, r io.Reader) error
+	PrepareIncomingMigration(ctx context.Context, id string, opt transfer.IncomingMigration) error
+	MigrationRootFSHashes(id string) ([]string, error)
+	WriteMigrationRootFSBlock(id string, index int, r io.Reader) error
+	ReceiveMachineMigration(ctx context.Context, id string, conn io.ReadWriteCloser) error
+	AbortIncomingMigration(id string) error
+}
+
+// SetMigrationTarget sets the migration target on the agent server
+func (s *AgentServer) SetMigrationTarget(mt MigrationTarget) {
+	s.migrationTarget = mt
+}
+
+func (s *AgentServer) registerMigrationEndpoints(mux *http.ServeMux) {
+	mux.HandleFunc("POST /transfers/migrations/{id}/volumes/{volume}", s.migrationHandler(s.receiveMigrationVolume))
+	mux.HandleFunc("POST /transfers/migrations/{id}", s.migrationHandler(s.prepareIncomingMigration))
+	mux.HandleFunc("GET /transfers/migrations/{id}/rootfs", s.migrationHandler(s.migrationRootFSHashes))
+	mux.HandleFunc("POST /transfers/migrations/{id}/rootfs/{index}", s.migrationHandler(s.writeMigrationRootFSBlock))
+	mux.HandleFunc("GET /transfers/migrations/{id}/memory", s.receiveMachineMigration)
+	mux.HandleFunc("DELETE /transfers/migrations/{id}", s.migrationHandler(s.abortIncomingMigration))
+}
+
+// writeTransferError writes the error like the huma endpoints, the other agent decodes it.
+func writeTransferError(w http.ResponseWriter, err error) {
+	var rerr *errdefs.RavelError
+	if !errors.As(err, &rerr) {
+		errors.As(errdefs.NewUnknown(err.Error()), &rerr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(rerr.GetStatus())
+	json.NewEncoder(w).Encode(rerr)
+}
+
+// authorizeMigration allows the migrations granted by the ravel server only, from the node of the
+// agent certificate of the peer.
+func (s *AgentServer) authorizeMigration(w http.ResponseWriter, r *http.Request) bool {
+	if s.migrationTarget == nil {
+		writeTransferError(w, errdefs.NewNotImplemented("migrations are not enabled on this agent"))
+		return false
+	}
+
+	if !transferAllowed(r, transfer.MigrationTransfer, s.migrationTarget.TransferAllowed) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+func (s *AgentServer) migrationHandler(handler func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorizeMigration(w, r) {
+			return
+		}
+
+		body, err := handler(r)
+		if err != nil {
+			s.log("Failed to handle incoming migration", err)
+			writeTransferError(w, err)
+			return
+		}
+
+		if body == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func (s *AgentServer) receiveMigrationVolume(r *http.Request) (any, error) {
+	sizeMB, err := strconv.ParseUint(r.URL.Query().Get("size_mb"), 10, 64)
+	if err != nil {
+		return nil, errdefs.NewInvalidArgument("invalid volume size")
+	}
+
+	return nil, s.migrationTarget.ReceiveMigrationVolume(r.PathValue("id"), r.PathValue("volume"), sizeMB, r.Body)
+}
+
+func (s *AgentServer) prepareIncomingMigration(r *http.Request) (any, error) {
+	var opt transfer.IncomingMigration
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		return nil, errdefs.NewInvalidArgument("invalid migration options: " + err.Error())
+	}
+
+	return nil, s.migrationTarget.PrepareIncomingMigration(r.Context(), r.PathValue("id"), opt)
+}
+
+func (s *AgentServer) migrationRootFSHashes(r *http.Request) (any, error) {
+	hashes, err := s.migrationTarget.MigrationRootFSHashes(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+func (s *AgentServer) writeMigrationRootFSBlock(r *http.Request) (any, error) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		return nil, errdefs.NewInvalidArgument("invalid block index")
+	}
+
+	return nil, s.migrationTarget.WriteMigrationRootFSBlock(r.PathValue("id"), index, r.Body)
+}
+
+func (s *AgentServer) abortIncomingMigration(r *http.Request) (any, error) {
+	return nil, s.migrationTarget.AbortIncomingMigration(r.PathValue("id"))
+}
+
+// receiveMachineMigration receives the memory of the machine over a WebSocket, the source agent
+// sees the migration fail if the connection is closed before the VM is sent.
+func (s *AgentServer) receiveMachineMigration(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeMigration(w, r) {
+		return
+	}
+
+	id := r.PathValue("id")
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		ws.PayloadType = websocket.BinaryFrame
+		// the connection is hijacked, closing it aborts the migration
+		err := s.migrationTarget.ReceiveMachineMigration(context.Background(), id, ws)
+		if err != nil {
+			s.log("Failed to receive machine migration", err)
+			ws.Close()
+		}
+	}}
+	server.ServeHTTP(w, r)
+}
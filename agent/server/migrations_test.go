@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexisbouchez/ravel/agent/transfer"
+)
+
+type fakeMigrationTarget struct {
+	*transfer.Grants
+	aborted []string
+}
+
+func (f *fakeMigrationTarget) TransferAllowed(kind string, id string, node string, token string) bool {
+	return f.Allowed(kind, id, node, token)
+}
+
+func (f *fakeMigrationTarget) ReceiveMigrationVolume(id string, volume string, sizeMB uint64, r io.Reader) error {
+	return nil
+}
+
+func (f *fakeMigrationTarget) PrepareIncomingMigration(ctx context.Context, id string, opt transfer.IncomingMigration) error {
+	return nil
+}
+
+func (f *fakeMigrationTarget) MigrationRootFSHashes(id string) ([]string, error) { return nil, nil }
+
+func (f *fakeMigrationTarget) WriteMigrationRootFSBlock(id string, index int, r io.Reader) error {
+	return nil
+}
+
+func (f *fakeMigrationTarget) ReceiveMachineMigration(ctx context.Context, id string, conn io.ReadWriteCloser) error {
+	return nil
+}
+
+func (f *fakeMigrationTarget) AbortIncomingMigration(id string) error {
+	f.aborted = append(f.aborted, id)
+	return nil
+}
+
+func TestMigrationEndpointsRequireGrant(t *testing.T) {
+	target := &fakeMigrationTarget{Grants: transfer.NewGrants()}
+	if err := target.Grant(transfer.MigrationTransfer, "instance-1", "node-1", "token-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &AgentServer{migrationTarget: target}
+	mux := http.NewServeMux()
+	s.registerMigrationEndpoints(mux)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{name: "abort without token", method: http.MethodDelete, path: "/transfers/migrations/instance-1", want: http.StatusForbidden},
+		{name: "abort of another migration", method: http.MethodDelete, path: "/transfers/migrations/instance-2", token: "token-1", want: http.StatusForbidden},
+		{name: "root filesystem without token", method: http.MethodGet, path: "/transfers/migrations/instance-1/rootfs", want: http.StatusForbidden},
+		{name: "memory without token", method: http.MethodGet, path: "/transfers/migrations/instance-1/memory", want: http.StatusForbidden},
+		{name: "granted abort", method: http.MethodDelete, path: "/transfers/migrations/instance-1", token: "token-1", want: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			transfer.SetToken(r.Header, tt.token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+
+	if len(target.aborted) != 1 || target.aborted[0] != "instance-1" {
+		t.Errorf("aborted migrations = %v, want the granted one only", target.aborted)
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+)
+
+type CordonNodeRequest struct {
+}
+
+type CordonNodeResponse struct {
+}
+
+func (s *AgentServer) cordonNode(ctx context.Context, req *CordonNodeRequest) (*CordonNodeResponse, error) {
+	err := s.agent.CordonNode(ctx)
+	if err != nil {
+		s.log("Failed to cordon node", err)
+		return nil, err
+	}
+
+	return &CordonNodeResponse{}, nil
+}
+
+func (s *AgentServer) uncordonNode(ctx context.Context, req *CordonNodeRequest) (*CordonNodeResponse, error) {
+	err := s.agent.UncordonNode(ctx)
+	if err != nil {
+		s.log("Failed to uncordon node", err)
+		return nil, err
+	}
+
+	return &CordonNodeResponse{}, nil
+}
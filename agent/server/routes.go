@@ -37,6 +37,12 @@ func (s *AgentServer) registerEndpoints(mux humago.Mux) {
 		Method:      http.MethodPost,
 	}, s.putMachine)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "updateMachine",
+		Path:        "/machines/{id}/update",
+		Method:      http.MethodPost,
+	}, s.updateMachine)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "startMachine",
 		Path:        "/machines/{id}/start",
@@ -55,6 +61,18 @@ func (s *AgentServer) registerEndpoints(mux humago.Mux) {
 		Method:      http.MethodPost,
 	}, s.machineExec)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "machineExecStream",
+		Path:        "/machines/{id}/exec/stream",
+		Method:      http.MethodPost,
+	}, s.machineExecStream)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "machineExecSession",
+		Path:        "/machines/{id}/exec/tty",
+		Method:      http.MethodGet,
+	}, s.machineExecSession)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "getMachineLogs",
 		Path:        "/machines/{id}/logs",
@@ -91,6 +109,54 @@ func (s *AgentServer) registerEndpoints(mux humago.Mux) {
 		Method:      http.MethodGet,
 	}, s.waitForMachineStatus)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "createVolume",
+		Path:        "/volumes",
+		Method:      http.MethodPost,
+	}, s.createVolume)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "destroyVolume",
+		Path:        "/volumes/{id}",
+		Method:      http.MethodDelete,
+	}, s.destroyVolume)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "resizeVolume",
+		Path:        "/volumes/{id}",
+		Method:      http.MethodPatch,
+	}, s.resizeVolume)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "createVolumeSnapshot",
+		Path:        "/volumes/{id}/snapshots/{snapshot}",
+		Method:      http.MethodPost,
+	}, s.createVolumeSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteVolumeSnapshot",
+		Path:        "/volumes/{id}/snapshots/{snapshot}",
+		Method:      http.MethodDelete,
+	}, s.deleteVolumeSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "restoreVolumeSnapshot",
+		Path:        "/volumes/{id}/snapshots/{snapshot}/restore",
+		Method:      http.MethodPost,
+	}, s.restoreVolumeSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "pullVolume",
+		Path:        "/volumes/{id}/pull",
+		Method:      http.MethodPost,
+	}, s.pullVolume)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "grantVolumeTransfer",
+		Path:        "/volumes/{id}/grants",
+		Method:      http.MethodPost,
+	}, s.grantVolumeTransfer)
+
 	// Snapshot/Restore endpoints for AI sandbox fast starts
 	huma.Register(api, huma.Operation{
 		OperationID: "machineSnapshot",
@@ -108,6 +174,66 @@ func (s *AgentServer) registerEndpoints(mux humago.Mux) {
 		Tags:        []string{"sandbox"},
 	}, s.machineRestore)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "forkMachine",
+		Path:        "/machines/{id}/fork",
+		Method:      http.MethodPost,
+		Summary:     "Create machines from a snapshot of a running machine",
+		Tags:        []string{"sandbox"},
+	}, s.forkMachine)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteMachineSnapshot",
+		Path:        "/snapshots/{id}",
+		Method:      http.MethodDelete,
+		Summary:     "Delete a machine snapshot",
+		Tags:        []string{"sandbox"},
+	}, s.deleteMachineSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "pullMachineSnapshot",
+		Path:        "/snapshots/{id}/pull",
+		Method:      http.MethodPost,
+		Summary:     "Pull a machine snapshot from another agent",
+		Tags:        []string{"sandbox"},
+	}, s.pullMachineSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "grantMachineSnapshotTransfer",
+		Path:        "/snapshots/{id}/grants",
+		Method:      http.MethodPost,
+		Summary:     "Allow another agent to pull a machine snapshot",
+		Tags:        []string{"sandbox"},
+	}, s.grantMachineSnapshotTransfer)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "migrateMachine",
+		Path:        "/machines/{id}/migrate",
+		Method:      http.MethodPost,
+		Summary:     "Live migrate a running machine to another agent",
+	}, s.migrateMachine)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "grantMigrationTransfer",
+		Path:        "/migrations/{id}/grants",
+		Method:      http.MethodPost,
+		Summary:     "Allow another agent to migrate a machine to this agent",
+	}, s.grantMigrationTransfer)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "cordonNode",
+		Path:        "/node/cordon",
+		Method:      http.MethodPost,
+		Summary:     "Stop answering placement requests",
+	}, s.cordonNode)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "uncordonNode",
+		Path:        "/node/uncordon",
+		Method:      http.MethodPost,
+		Summary:     "Answer placement requests again",
+	}, s.uncordonNode)
+
 	// Build endpoints
 	huma.Register(api, huma.Operation{
 		OperationID: "createBuild",
@@ -12,9 +12,11 @@ import (
 )
 
 type AgentServer struct {
-	server       *http.Server
-	agent        cluster.Agent
-	buildService BuildService
+	server          *http.Server
+	agent           cluster.Agent
+	buildService    BuildService
+	transferSource  TransferSource
+	migrationTarget MigrationTarget
 }
 
 func (e *AgentServer) log(msg string, err error) {
@@ -43,9 +45,11 @@ func NewAgentServer(agent cluster.Agent) *AgentServer {
 	mux := http.NewServeMux()
 
 	as.registerEndpoints(mux)
+	as.registerTransferEndpoints(mux)
+	as.registerMigrationEndpoints(mux)
 
 	server := &http.Server{
-		Handler: mux,
+		Handler: restrictAgentPeers(mux),
 	}
 
 	as.server = server
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/alexisbouchez/ravel/agent/transfer"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/internal/mtls"
+)
+
+// TransferSource gives the files of the snapshots and volumes of the node pulled by the other agents.
+type TransferSource interface {
+	// TransferAllowed reports whether the token grants the pull of the object to the node
+	TransferAllowed(kind string, id string, node string, token string) bool
+	MachineSnapshotFiles(snapshotId string) ([]transfer.LocalFile, error)
+	VolumeFiles(volumeId string) ([]transfer.LocalFile, error)
+}
+
+// SetTransferSource sets the transfer source on the agent server
+func (s *AgentServer) SetTransferSource(ts TransferSource) {
+	s.transferSource = ts
+}
+
+// restrictAgentPeers allows the other agents to call the transfer endpoints only, the pulls are
+// checked by authorizeTransfer and the migrations by authorizeMigration.
+func restrictAgentPeers(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && mtls.IsAgentConnection(*r.TLS) && !strings.HasPrefix(r.URL.Path, "/transfers/") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *AgentServer) registerTransferEndpoints(mux *http.ServeMux) {
+	mux.HandleFunc("GET /transfers/snapshots/{id}", s.authorizeTransfer(transfer.MachineSnapshotTransfer, s.transferManifest(s.snapshotFiles)))
+	mux.HandleFunc("GET /transfers/snapshots/{id}/files/{name...}", s.authorizeTransfer(transfer.MachineSnapshotTransfer, s.transferFile(s.snapshotFiles)))
+	mux.HandleFunc("GET /transfers/volumes/{id}", s.authorizeTransfer(transfer.VolumeTransfer, s.transferManifest(s.volumeFiles)))
+	mux.HandleFunc("GET /transfers/volumes/{id}/files/{name...}", s.authorizeTransfer(transfer.VolumeTransfer, s.transferFile(s.volumeFiles)))
+}
+
+// authorizeTransfer allows the pulls granted by the ravel server only, to the node of the agent
+// certificate of the peer.
+func (s *AgentServer) authorizeTransfer(kind string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.transferSource == nil {
+			writeTransferError(w, errdefs.NewNotImplemented("transfers are not enabled on this agent"))
+			return
+		}
+
+		if !transferAllowed(r, kind, s.transferSource.TransferAllowed) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// transferAllowed checks the token of the transfer, without TLS the peers are not authenticated
+// and the token alone is checked.
+func transferAllowed(r *http.Request, kind string, allowed func(kind, id, node, token string) bool) bool {
+	var node string
+	if r.TLS != nil {
+		var ok bool
+		node, ok = mtls.AgentNode(*r.TLS)
+		if !ok {
+			return false
+		}
+	}
+
+	return allowed(kind, r.PathValue("id"), node, transfer.GetToken(r))
+}
+
+func (s *AgentServer) snapshotFiles(id string) ([]transfer.LocalFile, error) {
+	if s.transferSource == nil {
+		return nil, errdefs.NewNotImplemented("transfers are not enabled on this agent")
+	}
+	return s.transferSource.MachineSnapshotFiles(id)
+}
+
+func (s *AgentServer) volumeFiles(id string) ([]transfer.LocalFile, error) {
+	if s.transferSource == nil {
+		return nil, errdefs.NewNotImplemented("transfers are not enabled on this agent")
+	}
+	return s.transferSource.VolumeFiles(id)
+}
+
+func (s *AgentServer) transferManifest(files func(id string) ([]transfer.LocalFile, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		localFiles, err := files(r.PathValue("id"))
+		if err != nil {
+			s.log("Failed to get transfer files", err)
+			writeTransferError(w, err)
+			return
+		}
+
+		manifest, err := transfer.NewManifest(localFiles)
+		if err != nil {
+			s.log("Failed to compute transfer manifest", err)
+			writeTransferError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(manifest)
+	}
+}
+
+func (s *AgentServer) transferFile(files func(id string) ([]transfer.LocalFile, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		localFiles, err := files(r.PathValue("id"))
+		if err != nil {
+			s.log("Failed to get transfer files", err)
+			writeTransferError(w, err)
+			return
+		}
+
+		transfer.ServeFile(w, r, localFiles, r.PathValue("name"))
+	}
+}
+
+type PullMachineSnapshotRequest struct {
+	Id   string `path:"id"`
+	Body cluster.PullOptions
+}
+
+type PullMachineSnapshotResponse struct {
+}
+
+func (s *AgentServer) pullMachineSnapshot(ctx context.Context, req *PullMachineSnapshotRequest) (*PullMachineSnapshotResponse, error) {
+	err := s.agent.PullMachineSnapshot(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to pull machine snapshot", err)
+		return nil, err
+	}
+
+	return &PullMachineSnapshotResponse{}, nil
+}
+
+type GrantMachineSnapshotTransferRequest struct {
+	Id   string `path:"id"`
+	Body cluster.TransferGrant
+}
+
+type GrantMachineSnapshotTransferResponse struct {
+}
+
+func (s *AgentServer) grantMachineSnapshotTransfer(ctx context.Context, req *GrantMachineSnapshotTransferRequest) (*GrantMachineSnapshotTransferResponse, error) {
+	err := s.agent.GrantMachineSnapshotTransfer(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to grant machine snapshot transfer", err)
+		return nil, err
+	}
+
+	return &GrantMachineSnapshotTransferResponse{}, nil
+}
+
+type PullVolumeRequest struct {
+	Id   string `path:"id"`
+	Body cluster.PullVolumeOptions
+}
+
+type PullVolumeResponse struct {
+}
+
+func (s *AgentServer) pullVolume(ctx context.Context, req *PullVolumeRequest) (*PullVolumeResponse, error) {
+	err := s.agent.PullVolume(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to pull volume", err)
+		return nil, err
+	}
+
+	return &PullVolumeResponse{}, nil
+}
+
+type GrantVolumeTransferRequest struct {
+	Id   string `path:"id"`
+	Body cluster.TransferGrant
+}
+
+type GrantVolumeTransferResponse struct {
+}
+
+func (s *AgentServer) grantVolumeTransfer(ctx context.Context, req *GrantVolumeTransferRequest) (*GrantVolumeTransferResponse, error) {
+	err := s.agent.GrantVolumeTransfer(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to grant volume transfer", err)
+		return nil, err
+	}
+
+	return &GrantVolumeTransferResponse{}, nil
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexisbouchez/ravel/agent/transfer"
+)
+
+func peerConnection(commonName string) *tls.ConnectionState {
+	return &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: commonName}}},
+	}
+}
+
+func TestTransferAllowed(t *testing.T) {
+	grants := transfer.NewGrants()
+	if err := grants.Grant(transfer.VolumeTransfer, "vol-1", "node-2", "token-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		conn  *tls.ConnectionState
+		id    string
+		token string
+		want  bool
+	}{
+		{name: "granted node", conn: peerConnection("node-2.eu.agent.ravel"), id: "vol-1", token: "token-1", want: true},
+		{name: "other agent", conn: peerConnection("node-3.eu.agent.ravel"), id: "vol-1", token: "token-1", want: false},
+		{name: "server", conn: peerConnection("node-2.eu.server.ravel"), id: "vol-1", token: "token-1", want: false},
+		{name: "other volume", conn: peerConnection("node-2.eu.agent.ravel"), id: "vol-2", token: "token-1", want: false},
+		{name: "without token", conn: peerConnection("node-2.eu.agent.ravel"), id: "vol-1", want: false},
+		{name: "without TLS", conn: nil, id: "vol-1", token: "token-1", want: true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/transfers/volumes/"+tt.id, nil)
+		r.TLS = tt.conn
+		r.SetPathValue("id", tt.id)
+		if tt.token != "" {
+			transfer.SetToken(r.Header, tt.token)
+		}
+
+		if got := transferAllowed(r, transfer.VolumeTransfer, grants.Allowed); got != tt.want {
+			t.Errorf("%s: transferAllowed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
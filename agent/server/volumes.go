@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+type CreateVolumeRequest struct {
+	Body cluster.CreateVolumeOptions
+}
+
+type CreateVolumeResponse struct {
+}
+
+func (s *AgentServer) createVolume(ctx context.Context, req *CreateVolumeRequest) (*CreateVolumeResponse, error) {
+	err := s.agent.CreateVolume(ctx, req.Body)
+	if err != nil {
+		s.log("Failed to create volume", err)
+		return nil, err
+	}
+
+	return &CreateVolumeResponse{}, nil
+}
+
+type DestroyVolumeRequest struct {
+	Id string `path:"id"`
+}
+
+type DestroyVolumeResponse struct {
+}
+
+func (s *AgentServer) destroyVolume(ctx context.Context, req *DestroyVolumeRequest) (*DestroyVolumeResponse, error) {
+	err := s.agent.DestroyVolume(ctx, req.Id)
+	if err != nil {
+		s.log("Failed to destroy volume", err)
+		return nil, err
+	}
+
+	return &DestroyVolumeResponse{}, nil
+}
+
+type ResizeVolumeRequest struct {
+	Id   string `path:"id"`
+	Body cluster.ResizeVolumeOptions
+}
+
+type ResizeVolumeResponse struct {
+}
+
+func (s *AgentServer) resizeVolume(ctx context.Context, req *ResizeVolumeRequest) (*ResizeVolumeResponse, error) {
+	err := s.agent.ResizeVolume(ctx, req.Id, req.Body.SizeMB)
+	if err != nil {
+		s.log("Failed to resize volume", err)
+		return nil, err
+	}
+
+	return &ResizeVolumeResponse{}, nil
+}
+
+type VolumeSnapshotRequest struct {
+	Id       string `path:"id"`
+	Snapshot string `path:"snapshot"`
+}
+
+type CreateVolumeSnapshotResponse struct {
+}
+
+func (s *AgentServer) createVolumeSnapshot(ctx context.Context, req *VolumeSnapshotRequest) (*CreateVolumeSnapshotResponse, error) {
+	err := s.agent.CreateVolumeSnapshot(ctx, req.Id, req.Snapshot)
+	if err != nil {
+		s.log("Failed to create volume snapshot", err)
+		return nil, err
+	}
+
+	return &CreateVolumeSnapshotResponse{}, nil
+}
+
+type DeleteVolumeSnapshotResponse struct {
+}
+
+func (s *AgentServer) deleteVolumeSnapshot(ctx context.Context, req *VolumeSnapshotRequest) (*DeleteVolumeSnapshotResponse, error) {
+	err := s.agent.DeleteVolumeSnapshot(ctx, req.Id, req.Snapshot)
+	if err != nil {
+		s.log("Failed to delete volume snapshot", err)
+		return nil, err
+	}
+
+	return &DeleteVolumeSnapshotResponse{}, nil
+}
+
+type RestoreVolumeSnapshotResponse struct {
+}
+
+func (s *AgentServer) restoreVolumeSnapshot(ctx context.Context, req *VolumeSnapshotRequest) (*RestoreVolumeSnapshotResponse, error) {
+	err := s.agent.RestoreVolumeSnapshot(ctx, req.Id, req.Snapshot)
+	if err != nil {
+		s.log("Failed to restore volume snapshot", err)
+		return nil, err
+	}
+
+	return &RestoreVolumeSnapshotResponse{}, nil
+}
@@ -18,6 +18,8 @@ type MachineInstanceState struct {
 	LocalIPV4             string             `json:"local_ipv4"`
 	LastEvents            []api.MachineEvent `json:"last_events"`
 	MachineGatewayEnabled bool               `json:"machine_gateway_enabled"`
+	// RestoreSnapshot is the memory snapshot restored by the next start of the instance, instead of booting it
+	RestoreSnapshot string `json:"restore_snapshot,omitempty"`
 }
 
 type MachineInstance struct {
@@ -28,11 +30,15 @@ type MachineInstance struct {
 }
 
 func (mi *MachineInstance) InstanceOptions() instance.InstanceOptions {
-	// Convert VolumeMount to instance.Mount
+	// Convert VolumeMount to instance.Mount, the disk of a volume is named after its id
 	mounts := make([]instance.Mount, len(mi.Version.Config.Workload.Volumes))
 	for i, vol := range mi.Version.Config.Workload.Volumes {
+		disk := vol.Volume
+		if disk == "" {
+			disk = vol.Name
+		}
 		mounts[i] = instance.Mount{
-			Disk: vol.Name,
+			Disk: disk,
 			Path: vol.Path,
 		}
 	}
@@ -49,11 +55,14 @@ func (mi *MachineInstance) InstanceOptions() instance.InstanceOptions {
 				MemoryMB: mi.Version.Resources.MemoryMB,
 				CpusMHz:  mi.Version.Resources.CpusMHz,
 				VCpus:    mi.Version.Config.Guest.Cpus,
+				Runtime:  mi.Version.Config.Guest.Runtime,
 			},
 			Init:   mi.Version.Config.Workload.Init,
 			Stop:   mi.Version.Config.StopConfig,
 			Env:    mi.Version.Config.Workload.Env,
 			Mounts: mounts,
+
+			WaitSecrets: len(mi.Version.Config.Workload.Secrets) > 0,
 		},
 		Network: mi.Network,
 	}
@@ -0,0 +1,123 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+)
+
+// BlockSize is the size of the blocks compared by the block transfers, the devices written by
+// running machines are transferred by sending the blocks which differ.
+const BlockSize = 4 << 20
+
+func blockHash(block []byte) string {
+	sum := sha256.Sum256(block)
+	return hex.EncodeToString(sum[:])
+}
+
+// readBlocks calls fn with each block of a file or a block device.
+func readBlocks(path string, fn func(index int, block []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, BlockSize)
+	for index := 0; ; index++ {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			if err := fn(index, buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// BlockHashes returns the sha256 of the blocks of a file or a block device.
+func BlockHashes(path string) ([]string, error) {
+	hashes := []string{}
+	err := readBlocks(path, func(_ int, block []byte) error {
+		hashes = append(hashes, blockHash(block))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// PushBlocks sends with put the blocks of a device which differ from the hashes of the blocks of
+// the destination device. The device may be written while it is read, the returned hashes are
+// the ones of the blocks as they were sent: the next push sends the blocks written meanwhile.
+func PushBlocks(ctx context.Context, path string, remote []string, put func(ctx context.Context, index int, block []byte) error) ([]string, error) {
+	hashes := make([]string, 0, len(remote))
+	err := readBlocks(path, func(index int, block []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if index >= len(remote) {
+			return errdefs.NewFailedPrecondition("the destination device is smaller than the source one")
+		}
+
+		hash := blockHash(block)
+		if hash != remote[index] {
+			if err := put(ctx, index, block); err != nil {
+				return err
+			}
+		}
+
+		hashes = append(hashes, hash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hashes) != len(remote) {
+		return nil, errdefs.NewFailedPrecondition("the destination device is larger than the source one")
+	}
+
+	return hashes, nil
+}
+
+// WriteBlock writes a block sent by PushBlocks at its index in a file or a block device.
+func WriteBlock(path string, index int, r io.Reader) error {
+	if index < 0 {
+		return errdefs.NewInvalidArgument("invalid block index")
+	}
+
+	block, err := io.ReadAll(io.LimitReader(r, BlockSize+1))
+	if err != nil {
+		return err
+	}
+
+	if len(block) > BlockSize {
+		return errdefs.NewInvalidArgument("block is too large")
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteAt(block, int64(index)*BlockSize); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
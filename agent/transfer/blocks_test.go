@@ -0,0 +1,70 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPushBlocks(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+
+	data := bytes.Repeat([]byte("a"), 2*BlockSize+BlockSize/2)
+	if err := os.WriteFile(dst, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data[BlockSize+1] = 'b'
+	data[len(data)-1] = 'c'
+	if err := os.WriteFile(src, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	remote, err := BlockHashes(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remote) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(remote))
+	}
+
+	var sent []int
+	put := func(ctx context.Context, index int, block []byte) error {
+		sent = append(sent, index)
+		return WriteBlock(dst, index, bytes.NewReader(block))
+	}
+
+	hashes, err := PushBlocks(context.Background(), src, remote, put)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent) != 2 || sent[0] != 1 || sent[1] != 2 {
+		t.Fatalf("expected blocks 1 and 2 to be sent, got %v", sent)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("destination differs from the source")
+	}
+
+	// nothing changed since the previous push
+	sent = nil
+	if _, err := PushBlocks(context.Background(), src, hashes, put); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 0 {
+		t.Fatalf("expected no block to be sent, got %v", sent)
+	}
+
+	if _, err := PushBlocks(context.Background(), src, hashes[:2], put); err == nil {
+		t.Fatal("expected an error for devices of different sizes")
+	}
+}
@@ -0,0 +1,115 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/httpclient"
+)
+
+// The kinds of objects transferred between the agents, the migrations are pushed by the source
+// node and identified by the instance id of the machine on the target node.
+const (
+	MachineSnapshotTransfer = "snapshots"
+	VolumeTransfer          = "volumes"
+	MigrationTransfer       = "migrations"
+)
+
+// grantTTL bounds the time a transfer can be used after it was granted or last used, the
+// interrupted pulls are resumed with the same token.
+const grantTTL = time.Hour
+
+type grant struct {
+	kind      string
+	id        string
+	node      string
+	expiresAt time.Time
+}
+
+// Grants holds the transfers the other agents are allowed to make, the ravel server grants each
+// one for an object and the other node with a token.
+type Grants struct {
+	mu     sync.Mutex
+	grants map[string]grant // by hash of the token
+	now    func() time.Time
+}
+
+func NewGrants() *Grants {
+	return &Grants{grants: map[string]grant{}, now: time.Now}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Grant allows the node to transfer the object of the given kind with the token.
+func (g *Grants) Grant(kind string, id string, node string, token string) error {
+	if token == "" || node == "" {
+		return errdefs.NewInvalidArgument("a transfer is granted to a node with a token")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	for hash, grant := range g.grants {
+		if now.After(grant.expiresAt) {
+			delete(g.grants, hash)
+		}
+	}
+
+	g.grants[hashToken(token)] = grant{kind: kind, id: id, node: node, expiresAt: now.Add(grantTTL)}
+	return nil
+}
+
+// Allowed reports whether the token grants the transfer of the object to the node, the grant is
+// extended while it is used. The node is empty if the agents do not use TLS, the token alone is
+// checked then.
+func (g *Grants) Allowed(kind string, id string, node string, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hash := hashToken(token)
+	grant, ok := g.grants[hash]
+	now := g.now()
+	if !ok || now.After(grant.expiresAt) {
+		return false
+	}
+
+	if grant.kind != kind || grant.id != id || (node != "" && grant.node != node) {
+		return false
+	}
+
+	grant.expiresAt = now.Add(grantTTL)
+	g.grants[hash] = grant
+	return true
+}
+
+// SetToken sets the token of the transfer on a request to the other agent.
+func SetToken(header http.Header, token string) {
+	header.Set("Authorization", "Bearer "+token)
+}
+
+// WithToken sets the token of the transfer on the requests of a client of the other agent.
+func WithToken(token string) httpclient.ReqOpt {
+	return httpclient.WithHeader("Authorization", "Bearer "+token)
+}
+
+// GetToken returns the token of the transfer of a request of an agent.
+func GetToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
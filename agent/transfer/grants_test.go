@@ -0,0 +1,98 @@
+package transfer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGrants(t *testing.T) {
+	g := NewGrants()
+	if err := g.Grant(VolumeTransfer, "vol-1", "node-2", "token-1"); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		kind  string
+		id    string
+		node  string
+		token string
+		want  bool
+	}{
+		{name: "granted", kind: VolumeTransfer, id: "vol-1", node: "node-2", token: "token-1", want: true},
+		{name: "without TLS", kind: VolumeTransfer, id: "vol-1", node: "", token: "token-1", want: true},
+		{name: "other node", kind: VolumeTransfer, id: "vol-1", node: "node-3", token: "token-1", want: false},
+		{name: "other volume", kind: VolumeTransfer, id: "vol-2", node: "node-2", token: "token-1", want: false},
+		{name: "other kind", kind: MachineSnapshotTransfer, id: "vol-1", node: "node-2", token: "token-1", want: false},
+		{name: "other token", kind: VolumeTransfer, id: "vol-1", node: "node-2", token: "token-2", want: false},
+		{name: "no token", kind: VolumeTransfer, id: "vol-1", node: "node-2", token: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := g.Allowed(tt.kind, tt.id, tt.node, tt.token); got != tt.want {
+			t.Errorf("%s: Allowed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGrantsExpire(t *testing.T) {
+	now := time.Now()
+	g := NewGrants()
+	g.now = func() time.Time { return now }
+
+	if err := g.Grant(MachineSnapshotTransfer, "msnap_1", "node-2", "token-1"); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+
+	// the interrupted pulls are resumed with the same token, the grant is extended while it is used
+	now = now.Add(grantTTL / 2)
+	if !g.Allowed(MachineSnapshotTransfer, "msnap_1", "node-2", "token-1") {
+		t.Error("Allowed() = false before the grant expired")
+	}
+
+	now = now.Add(grantTTL * 3 / 4)
+	if !g.Allowed(MachineSnapshotTransfer, "msnap_1", "node-2", "token-1") {
+		t.Error("Allowed() = false while the grant is used")
+	}
+
+	now = now.Add(grantTTL + time.Second)
+	if g.Allowed(MachineSnapshotTransfer, "msnap_1", "node-2", "token-1") {
+		t.Error("Allowed() = true after the grant expired")
+	}
+
+	// the expired grants are dropped with the next one
+	if err := g.Grant(MachineSnapshotTransfer, "msnap_2", "node-2", "token-2"); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	if len(g.grants) != 1 {
+		t.Errorf("%d grants kept, want the expired one dropped", len(g.grants))
+	}
+}
+
+func TestGrantRequiresTokenAndNode(t *testing.T) {
+	g := NewGrants()
+	if err := g.Grant(VolumeTransfer, "vol-1", "node-2", ""); err == nil {
+		t.Error("Grant() accepted a transfer without token")
+	}
+	if err := g.Grant(VolumeTransfer, "vol-1", "", "token-1"); err == nil {
+		t.Error("Grant() accepted a transfer without node")
+	}
+}
+
+func TestGetToken(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/transfers/volumes/vol-1", nil)
+	if token := GetToken(r); token != "" {
+		t.Errorf("GetToken() = %q without header, want none", token)
+	}
+
+	SetToken(r.Header, "token-1")
+	if token := GetToken(r); token != "token-1" {
+		t.Errorf("GetToken() = %q, want %q", token, "token-1")
+	}
+
+	r.Header.Set("Authorization", "Basic token-1")
+	if token := GetToken(r); token != "" {
+		t.Errorf("GetToken() = %q with another scheme, want none", token)
+	}
+}
@@ -0,0 +1,18 @@
+package transfer
+
+import (
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/instance"
+)
+
+// IncomingMigration prepares the machine of a live migration on the target node, once its
+// volumes have been received.
+type IncomingMigration struct {
+	Machine       cluster.PutMachineOptions `json:"machine"`
+	DesiredStatus api.MachineStatus         `json:"desired_status"`
+	// Network is the network of the machine on the source node, it is kept if it is free on the target node
+	Network instance.NetworkingConfig `json:"network"`
+	// Devices are the paths of the disks of the machine in the VMM of the source node, the root filesystem first
+	Devices []string `json:"devices"`
+}
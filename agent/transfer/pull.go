@@ -0,0 +1,171 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/httpclient"
+)
+
+const maxAttempts = 5
+
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// Client pulls the files served by the other agents.
+type Client struct {
+	client *http.Client
+	scheme string
+}
+
+func NewClient(client *http.Client, tls bool) *Client {
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	return &Client{client: client, scheme: scheme}
+}
+
+// Agent returns a client of the API of the agent at address, its requests carry the token of
+// the transfer granted on the agent.
+func (c *Client) Agent(address string, token string) *httpclient.Client {
+	return httpclient.NewClient(fmt.Sprintf("%s://%s", c.scheme, address), c.client, WithToken(token))
+}
+
+// Pull downloads the files served on path by the agent at address into dir, with the token of
+// the transfer granted on the source agent. The files already present in dir are the downloads
+// of a previous attempt, they are resumed. A file is kept only once its checksum matches the one
+// of the source.
+func (c *Client) Pull(ctx context.Context, address string, path string, token string, dir string) (Manifest, error) {
+	baseURL := fmt.Sprintf("%s://%s%s", c.scheme, address, path)
+
+	var manifest Manifest
+	if err := httpclient.NewClient(baseURL, c.client, WithToken(token)).Get(ctx, "", &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("failed to get transfer manifest: %w", err)
+	}
+
+	for _, file := range manifest.Files {
+		if err := validateName(file.Name); err != nil {
+			return Manifest{}, err
+		}
+
+		dst := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			return Manifest{}, err
+		}
+
+		if err := c.pullFile(ctx, baseURL+"/files/"+file.Name, token, dst, file); err != nil {
+			return Manifest{}, fmt.Errorf("failed to pull %s: %w", file.Name, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+func (c *Client) pullFile(ctx context.Context, url string, token string, dst string, file File) error {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = c.pullOnce(ctx, url, token, dst, file)
+		if err == nil || ctx.Err() != nil || errdefs.IsNotFound(err) {
+			return err
+		}
+
+		slog.Warn("failed to download file, retrying", "file", file.Name, "attempt", attempt, "error", err)
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+
+	return err
+}
+
+func (c *Client) pullOnce(ctx context.Context, url string, token string, dst string, file File) error {
+	if err := c.download(ctx, url, token, dst, file); err != nil {
+		return err
+	}
+
+	_, sum, err := checksum(dst)
+	if err != nil {
+		return err
+	}
+
+	if sum != file.Sha256 {
+		// the partial file is corrupted, the next attempt downloads it again
+		if err := os.Truncate(dst, 0); err != nil {
+			return err
+		}
+		return errChecksumMismatch
+	}
+
+	return nil
+}
+
+// download appends the missing part of the file to dst.
+func (c *Client) download(ctx context.Context, url string, token string, dst string, file File) error {
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset := info.Size()
+	if offset > file.Size {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		offset = 0
+	}
+	if offset == file.Size {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	SetToken(req.Header, token)
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// the range is ignored by the source, the whole file is sent
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		offset = 0
+	case http.StatusNotFound:
+		return errdefs.NewNotFound("file not found on the source agent")
+	default:
+		return errdefs.FromHTTPResponse(resp)
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
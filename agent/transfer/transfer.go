@@ -0,0 +1,124 @@
+// Package transfer moves the files of snapshots and volumes between agents. The agent holding the
+// files serves them with their checksums, the destination agent pulls them and resumes the
+// downloads interrupted by a failure.
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+)
+
+// File is a file of a transfer, the destination checks its checksum once downloaded.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+type Manifest struct {
+	Files []File `json:"files"`
+}
+
+// LocalFile is a file served by the agent under the given name, it may be a block device.
+type LocalFile struct {
+	Name string
+	Path string
+}
+
+func validateName(name string) error {
+	if !filepath.IsLocal(name) {
+		return errdefs.NewInvalidArgument("invalid file name: " + name)
+	}
+	return nil
+}
+
+// checksum returns the size and the sha256 of a file or a block device.
+func checksum(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// NewManifest computes the checksums of the files, they must not change until they are pulled.
+func NewManifest(files []LocalFile) (Manifest, error) {
+	manifest := Manifest{Files: make([]File, len(files))}
+	for i, file := range files {
+		if err := validateName(file.Name); err != nil {
+			return Manifest{}, err
+		}
+
+		size, sum, err := checksum(file.Path)
+		if err != nil {
+			return Manifest{}, err
+		}
+
+		manifest.Files[i] = File{Name: file.Name, Size: size, Sha256: sum}
+	}
+
+	return manifest, nil
+}
+
+// DirFiles lists the regular files of a directory and of its subdirectories.
+func DirFiles(dir string) ([]LocalFile, error) {
+	files := []LocalFile{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, LocalFile{Name: filepath.ToSlash(name), Path: path})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// ServeFile writes the named file, the range requests of the resumed downloads are supported.
+func ServeFile(w http.ResponseWriter, r *http.Request, files []LocalFile, name string) {
+	for _, file := range files {
+		if file.Name != name {
+			continue
+		}
+
+		f, err := os.Open(file.Path)
+		if err != nil {
+			http.Error(w, "failed to open file", http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "", time.Time{}, f)
+		return
+	}
+
+	http.NotFound(w, r)
+}
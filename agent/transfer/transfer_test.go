@@ -0,0 +1,118 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testToken = "transfer-token"
+
+func serveDir(t *testing.T, dir string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /transfer", func(w http.ResponseWriter, r *http.Request) {
+		if GetToken(r) != testToken {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		files, err := DirFiles(dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		manifest, err := NewManifest(files)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(manifest)
+	})
+	mux.HandleFunc("GET /transfer/files/{name...}", func(w http.ResponseWriter, r *http.Request) {
+		if GetToken(r) != testToken {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		files, err := DirFiles(dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ServeFile(w, r, files, r.PathValue("name"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+}
+
+func TestPull(t *testing.T) {
+	src := t.TempDir()
+	memory := bytes.Repeat([]byte("memory"), 10000)
+	config := []byte(`{"cpus":{"boot_vcpus":1}}`)
+	writeFile(t, filepath.Join(src, "memory-ranges"), memory)
+	writeFile(t, filepath.Join(src, "state", "config.json"), config)
+
+	server := serveDir(t, src)
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	dst := t.TempDir()
+	// an interrupted download of the memory and a corrupted one of the config
+	writeFile(t, filepath.Join(dst, "memory-ranges"), memory[:1000])
+	writeFile(t, filepath.Join(dst, "state", "config.json"), []byte(`{"gpus"`))
+
+	manifest, err := NewClient(server.Client(), false).Pull(context.Background(), address, "/transfer", testToken, dst)
+	if err != nil {
+		t.Fatalf("failed to pull: %v", err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(manifest.Files))
+	}
+
+	for name, expected := range map[string][]byte{"memory-ranges": memory, "state/config.json": config} {
+		data, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("unexpected content for %s", name)
+		}
+	}
+}
+
+func TestPullWithoutToken(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "disk"), []byte("disk"))
+
+	server := serveDir(t, src)
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	_, err := NewClient(server.Client(), false).Pull(context.Background(), address, "/transfer", "other-token", t.TempDir())
+	if err == nil {
+		t.Fatalf("expected the pull to be refused without the token of the transfer")
+	}
+}
+
+func TestManifestRejectsNonLocalNames(t *testing.T) {
+	_, err := NewManifest([]LocalFile{{Name: "../disk", Path: "/dev/null"}})
+	if err == nil {
+		t.Fatalf("expected an error for a non local name")
+	}
+}
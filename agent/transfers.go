@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/alexisbouchez/ravel/agent/transfer"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/config"
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/core/validation"
+)
+
+// newTransferClient returns the client pulling the transfers of the other agents, it
+// authenticates with the agent certificate.
+func newTransferClient(c *config.AgentConfig) (*transfer.Client, error) {
+	if c.TLS == nil {
+		return transfer.NewClient(&http.Client{}, false), nil
+	}
+
+	cert, err := c.TLS.LoadCert()
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := c.TLS.LoadCA()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:            ca,
+				Certificates:       []tls.Certificate{cert},
+				InsecureSkipVerify: c.TLS.SkipVerifyServer,
+			},
+		},
+	}
+
+	return transfer.NewClient(client, true), nil
+}
+
+// getStagingDir returns the directory where the files of a transfer are downloaded, it is kept
+// after a failure so that the next pull resumes the transfer.
+func getStagingDir(kind string, id string) (string, error) {
+	if err := validation.ValidateObjectId(id); err != nil {
+		return "", errdefs.NewInvalidArgument(err.Error())
+	}
+	return filepath.Join(config.TRANSFERS_DIRECTORY, kind, id), nil
+}
+
+func removeStagingDir(kind string, id string) error {
+	dir, err := getStagingDir(kind, id)
+	if err != nil {
+		return nil // nothing can have been staged
+	}
+	return os.RemoveAll(dir)
+}
+
+// TransferAllowed reports whether the token grants the pull of the object to the node of the
+// agent, see transfer.Grants.
+func (a *Agent) TransferAllowed(kind string, id string, node string, token string) bool {
+	return a.grants.Allowed(kind, id, node, token)
+}
+
+// GrantMachineSnapshotTransfer allows a node to pull a machine snapshot of the agent node.
+func (a *Agent) GrantMachineSnapshotTransfer(ctx context.Context, snapshotId string, grant cluster.TransferGrant) error {
+	if _, err := a.runtime.GetInstanceSnapshotDir(snapshotId); err != nil {
+		return err
+	}
+
+	return a.grants.Grant(transfer.MachineSnapshotTransfer, snapshotId, grant.Node, grant.Token)
+}
+
+// GrantVolumeTransfer allows a node to pull a volume of the agent node.
+func (a *Agent) GrantVolumeTransfer(ctx context.Context, volumeId string, grant cluster.TransferGrant) error {
+	if _, err := a.runtime.GetDisk(volumeId); err != nil {
+		return err
+	}
+
+	return a.grants.Grant(transfer.VolumeTransfer, volumeId, grant.Node, grant.Token)
+}
+
+func (a *Agent) MachineSnapshotFiles(snapshotId string) ([]transfer.LocalFile, error) {
+	dir, err := a.runtime.GetInstanceSnapshotDir(snapshotId)
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer.DirFiles(dir)
+}
+
+// VolumeFiles returns the device of a volume, it is refused while a machine uses the volume as
+// its device changes while it is read.
+func (a *Agent) VolumeFiles(volumeId string) ([]transfer.LocalFile, error) {
+	disk, err := a.runtime.GetDisk(volumeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if disk.AttachedInstance != "" {
+		i, err := a.runtime.GetInstance(disk.AttachedInstance)
+		if err != nil && !errdefs.IsNotFound(err) {
+			return nil, err
+		}
+		if err == nil && instanceUsesDisks(i.State) {
+			return nil, errdefs.NewFailedPrecondition("volume is used by a running machine")
+		}
+	}
+
+	return []transfer.LocalFile{{Name: "disk", Path: disk.Path}}, nil
+}
+
+// instanceUsesDisks reports whether an instance may write to its disks, the disks of a stopped
+// instance stay attached to it until it is destroyed.
+func instanceUsesDisks(state instance.State) bool {
+	switch state.Status {
+	case instance.InstanceStatusStarting, instance.InstanceStatusRunning:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a *Agent) PullMachineSnapshot(ctx context.Context, snapshotId string, opt cluster.PullOptions) error {
+	if _, err := a.runtime.GetInstanceSnapshotDir(snapshotId); err == nil {
+		return errdefs.NewAlreadyExists("snapshot already exists")
+	}
+
+	dir, err := getStagingDir("snapshots", snapshotId)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.transfers.Pull(ctx, opt.Source, "/transfers/snapshots/"+snapshotId, opt.Token, dir); err != nil {
+		return err
+	}
+
+	return a.runtime.ImportInstanceSnapshot(snapshotId, dir)
+}
+
+func (a *Agent) PullVolume(ctx context.Context, volumeId string, opt cluster.PullVolumeOptions) error {
+	if _, err := a.runtime.GetDisk(volumeId); err == nil {
+		return errdefs.NewAlreadyExists("volume already exists")
+	}
+
+	dir, err := getStagingDir("volumes", volumeId)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.transfers.Pull(ctx, opt.Source, "/transfers/volumes/"+volumeId, opt.Token, dir); err != nil {
+		return err
+	}
+
+	if _, err := a.runtime.ImportDisk(ctx, volumeId, opt.SizeMB, filepath.Join(dir, "disk")); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
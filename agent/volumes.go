@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+func (a *Agent) CreateVolume(ctx context.Context, opt cluster.CreateVolumeOptions) error {
+	if opt.FromSnapshot != nil {
+		_, err := a.runtime.CreateDiskFromSnapshot(opt.Id, opt.FromSnapshot.VolumeId, opt.FromSnapshot.SnapshotId)
+		return err
+	}
+
+	_, err := a.runtime.CreateDisk(ctx, opt.Id, opt.SizeMB)
+	return err
+}
+
+func (a *Agent) DestroyVolume(ctx context.Context, id string) error {
+	if err := removeStagingDir("volumes", id); err != nil {
+		return err
+	}
+
+	return a.runtime.DestroyDisk(id)
+}
+
+func (a *Agent) ResizeVolume(ctx context.Context, id string, sizeMB uint64) error {
+	_, err := a.runtime.ResizeDisk(ctx, id, sizeMB)
+	return err
+}
+
+func (a *Agent) CreateVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error {
+	_, err := a.runtime.CreateDiskSnapshot(volumeId, snapshotId)
+	return err
+}
+
+func (a *Agent) DeleteVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error {
+	return a.runtime.DeleteDiskSnapshot(volumeId, snapshotId)
+}
+
+func (a *Agent) RestoreVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error {
+	return a.runtime.RestoreDiskSnapshot(volumeId, snapshotId)
+}
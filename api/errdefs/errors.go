@@ -122,6 +122,10 @@ func IsNotImplemented(err error) bool {
 	return is(err, CodeNotImplemented)
 }
 
+func IsDeadlineExceeded(err error) bool {
+	return is(err, CodeDeadlineExceeded)
+}
+
 func getHTTPStatus(Code Code) int {
 	switch Code {
 	case CodeInvalidArgument:
@@ -0,0 +1,65 @@
+package api
+
+// An interactive exec session runs a command attached to a pseudo terminal inside a machine.
+// The session is a WebSocket carrying one JSON value per line: the client first sends the
+// ExecSessionOptions, then both sides exchange ExecMessages until the server sends the
+// exit code of the command, or an error, and closes the connection.
+
+type ExecSessionOptions struct {
+	Cmd  []string `json:"cmd"`
+	Env  []string `json:"env,omitempty"`
+	Cols uint16   `json:"cols,omitempty"`
+	Rows uint16   `json:"rows,omitempty"`
+}
+
+type ExecMessageType string
+
+const (
+	// ExecMessageStdin carries the input of the terminal, sent by the client.
+	ExecMessageStdin ExecMessageType = "stdin"
+	// ExecMessageResize carries the new size of the terminal, sent by the client.
+	ExecMessageResize ExecMessageType = "resize"
+	// ExecMessageStdout carries the output of the terminal, sent by the server.
+	ExecMessageStdout ExecMessageType = "stdout"
+	// ExecMessageExit carries the exit code of the command, it is the last message sent by the server.
+	ExecMessageExit ExecMessageType = "exit"
+	// ExecMessageError is sent by the server when the session fails, it is the last message.
+	ExecMessageError ExecMessageType = "error"
+)
+
+type ExecMessage struct {
+	Type     ExecMessageType `json:"type"`
+	Data     []byte          `json:"data,omitempty"`
+	Cols     uint16          `json:"cols,omitempty"`
+	Rows     uint16          `json:"rows,omitempty"`
+	ExitCode int             `json:"exit_code,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// ExecStreamRequest starts a streamed exec, or attaches to a streamed exec which is still
+// running or recently completed when ExecId is set. A streamed exec keeps running when
+// its client disconnects. Its output is sent as ExecOutputLines, one per line of JSON.
+type ExecStreamRequest struct {
+	ExecOptions
+	ExecId string `json:"exec_id,omitempty" doc:"ID of the exec to attach to, a new exec is started when empty"`
+	From   int    `json:"from,omitempty" doc:"Sequence number of the first line to send when attaching"`
+}
+
+const (
+	// ExecStreamStart is the first line of an exec, the client learns the exec ID from it.
+	ExecStreamStart  = "start"
+	ExecStreamStdout = "stdout"
+	ExecStreamStderr = "stderr"
+	// ExecStreamExit is the last line of a completed exec, it holds the exit code.
+	ExecStreamExit = "exit"
+	// ExecStreamError is the last line of an exec which failed to run, it holds the error.
+	ExecStreamError = "error"
+)
+
+type ExecOutputLine struct {
+	ExecId   string `json:"exec_id"`
+	Seq      int    `json:"seq"`
+	Stream   string `json:"stream"`
+	Data     string `json:"data,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+}
@@ -9,6 +9,8 @@ type Fleet struct {
 	CreatedAt time.Time   `json:"created_at"`
 	Status    FleetStatus `json:"status"`
 	Metadata  *Metadata   `json:"metadata,omitempty"`
+	// PlacementStrategy ranks the nodes of the machines of the fleet, the server strategy is used if it is empty
+	PlacementStrategy PlacementStrategy `json:"placement_strategy,omitempty"`
 }
 
 type FleetStatus string
@@ -19,6 +21,22 @@ const (
 )
 
 type CreateFleetPayload struct {
-	Name     string    `json:"name"`
-	Metadata *Metadata `json:"metadata,omitempty"`
+	Name              string            `json:"name"`
+	Metadata          *Metadata         `json:"metadata,omitempty"`
+	PlacementStrategy PlacementStrategy `json:"placement_strategy,omitempty" enum:"binpack,spread,least-allocated,random-top-k" doc:"Strategy ranking the nodes of the machines of the fleet, defaults to the server strategy"`
 }
+
+// PlacementStrategy ranks the nodes answering a placement request, the machine is placed on the
+// first one.
+type PlacementStrategy string
+
+const (
+	// PlacementStrategyBinpack prefers the most allocated nodes, to keep the other nodes free
+	PlacementStrategyBinpack PlacementStrategy = "binpack"
+	// PlacementStrategySpread prefers the nodes running the fewest machines
+	PlacementStrategySpread PlacementStrategy = "spread"
+	// PlacementStrategyLeastAllocated prefers the least allocated nodes
+	PlacementStrategyLeastAllocated PlacementStrategy = "least-allocated"
+	// PlacementStrategyRandomTopK picks a random node among the k least allocated ones
+	PlacementStrategyRandomTopK PlacementStrategy = "random-top-k"
+)
@@ -1,15 +1,64 @@
 package api
 
+import "time"
+
+const (
+	GatewayProtocolHTTP  = "http"
+	GatewayProtocolHTTPS = "https"
+	GatewayProtocolTCP   = "tcp"
+	GatewayProtocolUDP   = "udp"
+)
+
 type CreateGatewayPayload struct {
-	Name       string `json:"name,omitempty"`
-	TargetPort int    `json:"target_port"`
+	Name          string `json:"name,omitempty"`
+	TargetPort    int    `json:"target_port"`
+	Protocol      string `json:"protocol,omitempty"`       // http, https, tcp or udp, defaults to https
+	ProxyProtocol bool   `json:"proxy_protocol,omitempty"` // send a PROXY protocol v2 header to the machines, tcp and udp only
 }
 
 type Gateway struct {
-	Id         string `json:"id"`
-	Name       string `json:"name"`
-	Namespace  string `json:"namespace"`
-	FleetId    string `json:"fleet_id"`
-	Protocol   string `json:"protocol"`
-	TargetPort int    `json:"target_port"`
+	Id            string          `json:"id"`
+	Name          string          `json:"name"`
+	Namespace     string          `json:"namespace"`
+	FleetId       string          `json:"fleet_id"`
+	Protocol      string          `json:"protocol"`
+	TargetPort    int             `json:"target_port"`
+	PublicPort    int             `json:"public_port,omitempty"` // port allocated on the proxy for tcp and udp gateways
+	ProxyProtocol bool            `json:"proxy_protocol,omitempty"`
+	Domains       []GatewayDomain `json:"domains,omitempty"`
+}
+
+// IsL4 reports whether the gateway forwards raw tcp or udp traffic on a dedicated public port.
+func (g *Gateway) IsL4() bool {
+	return g.Protocol == GatewayProtocolTCP || g.Protocol == GatewayProtocolUDP
+}
+
+type GatewayDomainStatus string
+
+const (
+	GatewayDomainStatusPending  GatewayDomainStatus = "pending"
+	GatewayDomainStatusVerified GatewayDomainStatus = "verified"
+)
+
+type AddGatewayDomainPayload struct {
+	Hostname string `json:"hostname"`
+}
+
+// GatewayDomainVerification describes the DNS records proving the ownership of a domain.
+// Only one of them is required: a TXT record named TXTName containing TXTValue,
+// or a CNAME record on the hostname pointing to CNAMETarget.
+type GatewayDomainVerification struct {
+	TXTName     string `json:"txt_name"`
+	TXTValue    string `json:"txt_value"`
+	CNAMETarget string `json:"cname_target,omitempty"`
+}
+
+type GatewayDomain struct {
+	Hostname     string                    `json:"hostname"`
+	GatewayId    string                    `json:"gateway_id"`
+	Namespace    string                    `json:"namespace"`
+	Status       GatewayDomainStatus       `json:"status"`
+	Verification GatewayDomainVerification `json:"verification"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	VerifiedAt   *time.Time                `json:"verified_at,omitempty"`
 }
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// LogsOptions selects the log entries to return. Timestamps are unix seconds, the limits
+// apply from the most recent entries when Tail is set and from the oldest ones otherwise.
+type LogsOptions struct {
+	Since    int64 `query:"since" json:"since,omitempty" doc:"Only return the entries logged at or after this unix timestamp"`
+	Until    int64 `query:"until" json:"until,omitempty" doc:"Only return the entries logged at or before this unix timestamp"`
+	Tail     int   `query:"tail" json:"tail,omitempty" doc:"Only return the last n entries"`
+	Limit    int   `query:"limit" json:"limit,omitempty" doc:"Maximum number of entries to return"`
+	MaxBytes int   `query:"max_bytes" json:"max_bytes,omitempty" doc:"Maximum size of the returned messages in bytes"`
+}
+
+// Values encodes the options as query parameters.
+func (o LogsOptions) Values() url.Values {
+	values := url.Values{}
+	set := func(key string, v int64) {
+		if v > 0 {
+			values.Set(key, strconv.FormatInt(v, 10))
+		}
+	}
+
+	set("since", o.Since)
+	set("until", o.Until)
+	set("tail", int64(o.Tail))
+	set("limit", int64(o.Limit))
+	set("max_bytes", int64(o.MaxBytes))
+
+	return values
+}
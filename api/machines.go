@@ -34,6 +34,19 @@ type Machine struct {
 	Metadata       *Metadata      `json:"metadata,omitempty"`
 }
 
+// MachineSnapshot is a memory snapshot of a running machine, stored on the machine node.
+// It can only be restored on the machine it was taken from, at the same machine version,
+// and follows the machine when it is restored after a migration.
+type MachineSnapshot struct {
+	Id             string    `json:"id"`
+	MachineId      string    `json:"machine_id"`
+	Namespace      string    `json:"namespace"`
+	Node           string    `json:"node"`
+	SizeBytes      int64     `json:"size_bytes"`
+	MachineVersion string    `json:"machine_version"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 type MachineStatus string
 
 const (
@@ -77,6 +90,25 @@ const (
 	RestartPolicyNever     RestartPolicy = "never"
 )
 
+// Runtime runs the machines on a node, a node supports one or more runtimes.
+type Runtime string
+
+const (
+	RuntimeCloudHypervisor Runtime = "cloudhypervisor"
+	RuntimeFirecracker     Runtime = "firecracker"
+	// RuntimeContainerd runs the machine as a container instead of a microVM
+	RuntimeContainerd Runtime = "containerd"
+)
+
+// IsValid reports whether the runtime is known, the empty runtime is the default one of the node.
+func (r Runtime) IsValid() bool {
+	switch r {
+	case "", RuntimeCloudHypervisor, RuntimeFirecracker, RuntimeContainerd:
+		return true
+	}
+	return false
+}
+
 type (
 	MachineConfig struct {
 		Image      string      `json:"image"`
@@ -86,9 +118,10 @@ type (
 	}
 
 	GuestConfig struct {
-		CpuKind  string `json:"cpu_kind"`
-		MemoryMB int    `json:"memory_mb" minimum:"1"`
-		Cpus     int    `json:"cpus" minimum:"1"`
+		CpuKind  string  `json:"cpu_kind"`
+		MemoryMB int     `json:"memory_mb" minimum:"1"`
+		Cpus     int     `json:"cpus" minimum:"1"`
+		Runtime  Runtime `json:"runtime,omitempty" enum:"cloudhypervisor,firecracker,containerd" doc:"Runtime running the machine, defaults to the runtime of its node"`
 	}
 
 	Workload struct {
@@ -110,18 +143,22 @@ type (
 	}
 
 	VolumeMount struct {
-		Name string `json:"name" doc:"Name of the disk to mount"`
-		Path string `json:"path" doc:"Mount path inside the machine"`
+		Name   string `json:"name" doc:"Name of the volume to mount"`
+		Path   string `json:"path" doc:"Mount path inside the machine"`
+		Volume string `json:"volume,omitempty" readOnly:"true" doc:"Id of the volume, resolved by the server"`
 	}
 
 	PrivateNetwork struct {
 		Name string `json:"name" doc:"Name of the private network to join"`
-		IP   string `json:"ip" doc:"IP address for this machine in the private network (e.g., 10.0.1.2/24)"`
+		IP   string `json:"ip,omitempty" doc:"IP address for this machine in the private network (e.g., 10.0.1.2/24), allocated automatically if empty"`
 	}
 
+	// SecretRef references a secret delivered to the machine when it boots, its value is never
+	// stored in the machine version.
 	SecretRef struct {
 		Name   string `json:"name" doc:"Name of the secret in the namespace"`
-		EnvVar string `json:"env_var" doc:"Environment variable name to inject the secret into"`
+		EnvVar string `json:"env_var,omitempty" doc:"Environment variable name to inject the secret into"`
+		File   string `json:"file,omitempty" doc:"Name of the file holding the secret in /run/secrets"`
 	}
 
 	InitConfig struct {
@@ -158,14 +195,28 @@ const (
 	MachineExited        MachineEventType = "machine.exited"
 	MachineDestroy       MachineEventType = "machine.destroy"
 	MachineDestroyed     MachineEventType = "machine.destroyed"
+	MachineUpdate        MachineEventType = "machine.update"
 )
 
 type CreateMachinePayload struct {
-	Region               string        `json:"region"`
-	Config               MachineConfig `json:"config"`
-	SkipStart            bool          `json:"skip_start,omitempty"`
-	EnableMachineGateway bool          `json:"enable_machine_gateway,omitempty"`
-	Metadata             *Metadata     `json:"metadata,omitempty"`
+	Region               string                `json:"region"`
+	Config               MachineConfig         `json:"config"`
+	SkipStart            bool                  `json:"skip_start,omitempty"`
+	EnableMachineGateway bool                  `json:"enable_machine_gateway,omitempty"`
+	Metadata             *Metadata             `json:"metadata,omitempty"`
+	Placement            *PlacementConstraints `json:"placement,omitempty"`
+}
+
+// PlacementConstraints restrict the nodes a machine is placed on, they are applied again when a
+// node drain migrates the machine.
+type PlacementConstraints struct {
+	NodeLabels        map[string]string `json:"node_labels,omitempty" doc:"Labels the node of the machine must have"`
+	FleetAntiAffinity bool              `json:"fleet_anti_affinity,omitempty" doc:"Place the machine on a node which runs no other machine of its fleet"`
+	VolumeAffinity    string            `json:"volume_affinity,omitempty" doc:"Place the machine on the node of this volume of the namespace"`
+}
+
+type UpdateMachinePayload struct {
+	Config MachineConfig `json:"config"`
 }
 
 type MachineStartEventPayload struct {
@@ -199,6 +250,12 @@ type MachineDestroyEventPayload struct {
 	Force       bool   `json:"force"`
 }
 
+type MachineUpdateEventPayload struct {
+	PreviousVersion    string `json:"previous_version"`
+	Version            string `json:"version"`
+	PreviousInstanceId string `json:"previous_instance_id"`
+}
+
 type MachineEventPayload struct {
 	PrepareFailed *MachinePrepareFailedEventPayload `json:"prepare_failed,omitempty"`
 	Stop          *MachineStopEventPayload          `json:"stop,omitempty"`
@@ -207,6 +264,7 @@ type MachineEventPayload struct {
 	Started       *MachineStartedEventPayload       `json:"started,omitempty"`
 	Exited        *MachineExitedEventPayload        `json:"stopped,omitempty"`
 	Destroy       *MachineDestroyEventPayload       `json:"destroy,omitempty"`
+	Update        *MachineUpdateEventPayload        `json:"update,omitempty"`
 }
 
 type Origin string
@@ -0,0 +1,26 @@
+package api
+
+import "time"
+
+type CreateNetworkPayload struct {
+	Name string `json:"name"`
+	CIDR string `json:"cidr" doc:"IPv4 range of the network, e.g. 10.0.1.0/24"`
+}
+
+// Network is a namespace private network. Machines joining the network get an IP in
+// its CIDR and reach each other through WireGuard tunnels, whatever node they run on.
+type Network struct {
+	Id        string          `json:"id"`
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	CIDR      string          `json:"cidr"`
+	CreatedAt time.Time       `json:"created_at"`
+	Members   []NetworkMember `json:"members,omitempty"`
+}
+
+type NetworkMember struct {
+	MachineId string `json:"machine_id"`
+	IP        string `json:"ip"`
+	Node      string `json:"node,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+}
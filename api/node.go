@@ -11,8 +11,57 @@ type Node struct {
 	AgentPort     int       `json:"agent_port"`
 	Region        string    `json:"region"`
 	HeartbeatedAt time.Time `json:"heartbeated_at"`
+	// Cordoned nodes do not answer the placement requests, their machines keep running
+	Cordoned bool              `json:"cordoned"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	// Runtimes supported by the node, the default one first
+	Runtimes []Runtime `json:"runtimes,omitempty"`
 }
 
 func (n *Node) AgentAddress() string {
 	return fmt.Sprintf("%s:%d", n.Address, n.AgentPort)
 }
+
+type NodeDrainStatus string
+
+const (
+	NodeDrainStatusDraining NodeDrainStatus = "draining"
+	NodeDrainStatusDone     NodeDrainStatus = "done"
+)
+
+type NodeDrainAction string
+
+const (
+	// NodeDrainActionMigrate moves the machine to another node, live if it is running
+	NodeDrainActionMigrate NodeDrainAction = "migrate"
+	// NodeDrainActionStop stops the machine on the node, it is not restarted by its restart policy
+	NodeDrainActionStop NodeDrainAction = "stop"
+)
+
+type NodeDrainMachineStatus string
+
+const (
+	NodeDrainMachinePending NodeDrainMachineStatus = "pending"
+	NodeDrainMachineDone    NodeDrainMachineStatus = "done"
+	NodeDrainMachineFailed  NodeDrainMachineStatus = "failed"
+)
+
+// NodeDrain is the progress of the drain of a cordoned node.
+type NodeDrain struct {
+	Node        string             `json:"node"`
+	Status      NodeDrainStatus    `json:"status"`
+	Remaining   int                `json:"remaining" doc:"Number of machines not drained yet"`
+	Machines    []NodeDrainMachine `json:"machines"`
+	StartedAt   time.Time          `json:"started_at"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+}
+
+type NodeDrainMachine struct {
+	Id        string                 `json:"id"`
+	Namespace string                 `json:"namespace"`
+	FleetId   string                 `json:"fleet_id"`
+	Action    NodeDrainAction        `json:"action"`
+	Status    NodeDrainMachineStatus `json:"status"`
+	Node      string                 `json:"node,omitempty" doc:"Node the machine has been migrated to"`
+	Error     string                 `json:"error,omitempty"`
+}
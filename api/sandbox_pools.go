@@ -0,0 +1,69 @@
+package api
+
+import "time"
+
+// SandboxPoolConfig configures a pool of pre-warmed sandboxes.
+type SandboxPoolConfig struct {
+	Fleet              string `json:"fleet" doc:"Fleet of the namespace the sandbox machines are created in"`
+	Region             string `json:"region" doc:"Region of the sandbox machines, they are spread across its nodes"`
+	MinWarm            int    `json:"min_warm" minimum:"0" doc:"Number of ready sandboxes to maintain"`
+	MaxWarm            int    `json:"max_warm" minimum:"1" doc:"Maximum number of sandboxes, claimed ones included"`
+	TemplateImage      string `json:"template_image" doc:"Image of the sandbox machines"`
+	SnapshotAfterBoot  bool   `json:"snapshot_after_boot,omitempty" doc:"Snapshot the sandboxes after boot and restore them on release"`
+	CpuKind            string `json:"cpu_kind"`
+	DefaultCPUs        int    `json:"default_cpus,omitempty"`
+	DefaultMemoryMB    int    `json:"default_memory_mb,omitempty"`
+	IdleTimeoutSeconds int    `json:"idle_timeout_seconds,omitempty" doc:"Time a ready sandbox above min_warm stays warm before being destroyed"`
+}
+
+func (c *SandboxPoolConfig) GetIdleTimeout() time.Duration {
+	return time.Duration(c.IdleTimeoutSeconds) * time.Second
+}
+
+type CreateSandboxPoolPayload struct {
+	Name   string            `json:"name"`
+	Config SandboxPoolConfig `json:"config"`
+}
+
+type ClaimSandboxPayload struct {
+	TimeoutMs int `json:"timeout_ms,omitempty" doc:"Time to wait for a ready sandbox (default: 10000, max: 20000)"`
+}
+
+// SandboxPool keeps warm sandboxes ready to be claimed by code execution workloads.
+type SandboxPool struct {
+	Id        string            `json:"id"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Config    SandboxPoolConfig `json:"config"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type SandboxState string
+
+const (
+	SandboxStateWarming  SandboxState = "warming"  // VM is booting
+	SandboxStateReady    SandboxState = "ready"    // VM is warm and available
+	SandboxStateClaimed  SandboxState = "claimed"  // VM is in use
+	SandboxStateReseting SandboxState = "reseting" // VM is restoring from snapshot
+)
+
+// Sandbox is a machine of a sandbox pool.
+type Sandbox struct {
+	Id         string       `json:"id"`
+	PoolId     string       `json:"pool_id"`
+	MachineId  string       `json:"machine_id"`
+	Node       string       `json:"node"`
+	State      SandboxState `json:"state"`
+	SnapshotId string       `json:"snapshot_id,omitempty"`
+	ClaimedAt  *time.Time   `json:"claimed_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt time.Time    `json:"last_used_at"`
+}
+
+type SandboxPoolStats struct {
+	Total    int `json:"total"`
+	Ready    int `json:"ready"`
+	Claimed  int `json:"claimed"`
+	Warming  int `json:"warming"`
+	Reseting int `json:"reseting"`
+}
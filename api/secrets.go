@@ -16,5 +16,6 @@ type CreateSecretPayload struct {
 }
 
 type UpdateSecretPayload struct {
-	Value string `json:"value" minLength:"1" doc:"New secret value"`
+	Value           string `json:"value" minLength:"1" doc:"New secret value"`
+	RestartMachines bool   `json:"restart_machines,omitempty" doc:"Restart the running machines using the secret one after the other so that they get the new value"`
 }
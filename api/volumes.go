@@ -0,0 +1,35 @@
+package api
+
+import "time"
+
+type CreateVolumePayload struct {
+	Name     string `json:"name"`
+	SizeMB   uint64 `json:"size_mb,omitempty" doc:"Size of the volume, ignored when created from a snapshot"`
+	Region   string `json:"region,omitempty" doc:"Region of the volume, required unless a node or a snapshot is set"`
+	Node     string `json:"node,omitempty" doc:"Node holding the volume, picked in the region if empty"`
+	Snapshot string `json:"snapshot,omitempty" doc:"Id of a volume snapshot to copy, the volume is created on the node of the snapshot"`
+}
+
+type ResizeVolumePayload struct {
+	SizeMB uint64 `json:"size_mb" doc:"New size of the volume, volumes can only grow"`
+}
+
+// Volume is a disk created on a node. Machines mounting a volume are placed on its node.
+type Volume struct {
+	Id              string    `json:"id"`
+	Name            string    `json:"name"`
+	Namespace       string    `json:"namespace"`
+	Region          string    `json:"region"`
+	Node            string    `json:"node"`
+	SizeMB          uint64    `json:"size_mb"`
+	AttachedMachine string    `json:"attached_machine,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// VolumeSnapshot is a point in time copy of a volume, stored on the volume node.
+type VolumeSnapshot struct {
+	Id        string    `json:"id"`
+	VolumeId  string    `json:"volume_id"`
+	Namespace string    `json:"namespace"`
+	CreatedAt time.Time `json:"created_at"`
+}
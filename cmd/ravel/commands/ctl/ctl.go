@@ -30,6 +30,9 @@ func NewCtlCmd() *cobra.Command {
 	ctlCmd.AddCommand(newFleetsCmd())
 	ctlCmd.AddCommand(newNamespacesCmd())
 	ctlCmd.AddCommand(newGatewaysCmd())
+	ctlCmd.AddCommand(newNetworksCmd())
+	ctlCmd.AddCommand(newVolumesCmd())
+	ctlCmd.AddCommand(newSandboxPoolsCmd())
 	ctlCmd.AddCommand(newNodesCmd())
 	ctlCmd.AddCommand(newConfigCmd())
 
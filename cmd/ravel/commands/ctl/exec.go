@@ -0,0 +1,119 @@
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/pkg/ravelctl"
+	"github.com/spf13/cobra"
+)
+
+// execReconnectAttempts is the number of times a lost exec stream is attached to again.
+const execReconnectAttempts = 5
+
+func newMachinesExecCmd() *cobra.Command {
+	var fleet, attach string
+	var from int
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "exec <machine-id> [-- command...]",
+		Short: "Run a command in a machine and stream its output",
+		Long:  "Run a command in a machine and stream its output. The command keeps running when the connection is lost, its output is followed again automatically, or later with --attach.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			req := api.ExecStreamRequest{ExecId: attach, From: from}
+			if attach == "" {
+				if len(args) < 2 {
+					return fmt.Errorf("a command is required")
+				}
+				req.Cmd = args[1:]
+				req.TimeoutMs = int(timeout.Milliseconds())
+			}
+
+			exitCode, err := streamExec(cmd, client, fleet, args[0], req)
+			if err != nil {
+				return err
+			}
+
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+	cmd.Flags().StringVar(&attach, "attach", "", "Follow the output of a running or recently completed exec")
+	cmd.Flags().IntVar(&from, "from", 0, "Sequence number of the first output line to show when attaching")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Kill the command after this duration, no timeout by default")
+
+	return cmd
+}
+
+func streamExec(cmd *cobra.Command, client *ravelctl.Client, fleet, machineId string, req api.ExecStreamRequest) (int, error) {
+	attempts := 0
+	for {
+		body, err := client.ExecStream(namespace, fleet, machineId, req)
+		if err == nil {
+			var done bool
+			var exitCode int
+			done, exitCode, err = readExecOutput(cmd, body, &req)
+			body.Close()
+			if done {
+				return exitCode, err
+			}
+		}
+
+		if req.ExecId == "" || attempts >= execReconnectAttempts {
+			return -1, err
+		}
+		attempts++
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Lost exec %s (%v), attaching again\n", req.ExecId, err)
+		time.Sleep(time.Second)
+	}
+}
+
+// readExecOutput prints the output lines of an exec and records the position reached in
+// the request, to attach again if the stream ends early.
+func readExecOutput(cmd *cobra.Command, body io.Reader, req *api.ExecStreamRequest) (bool, int, error) {
+	dec := json.NewDecoder(body)
+	for {
+		var line api.ExecOutputLine
+		if err := dec.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return false, -1, err
+		}
+
+		req.ExecId = line.ExecId
+		req.From = line.Seq + 1
+
+		switch line.Stream {
+		case api.ExecStreamStart:
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exec %s started\n", line.ExecId)
+		case api.ExecStreamStdout:
+			fmt.Fprintln(cmd.OutOrStdout(), line.Data)
+		case api.ExecStreamStderr:
+			fmt.Fprintln(cmd.ErrOrStderr(), line.Data)
+		case api.ExecStreamExit:
+			return true, line.ExitCode, nil
+		case api.ExecStreamError:
+			return true, -1, errors.New(line.Data)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"text/tabwriter"
 
+	"github.com/alexisbouchez/ravel/api"
 	"github.com/spf13/cobra"
 )
 
@@ -57,7 +58,9 @@ func newFleetsListCmd() *cobra.Command {
 }
 
 func newFleetsCreateCmd() *cobra.Command {
-	return &cobra.Command{
+	var strategy string
+
+	cmd := &cobra.Command{
 		Use:   "create <name>",
 		Short: "Create a fleet",
 		Args:  cobra.ExactArgs(1),
@@ -67,7 +70,10 @@ func newFleetsCreateCmd() *cobra.Command {
 				return err
 			}
 
-			fleet, err := client.CreateFleet(namespace, args[0])
+			fleet, err := client.CreateFleet(namespace, api.CreateFleetPayload{
+				Name:              args[0],
+				PlacementStrategy: api.PlacementStrategy(strategy),
+			})
 			if err != nil {
 				return err
 			}
@@ -82,6 +88,10 @@ func newFleetsCreateCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&strategy, "placement-strategy", "", "Placement strategy of the machines: binpack, spread, least-allocated or random-top-k (defaults to the server strategy)")
+
+	return cmd
 }
 
 func newFleetsDeleteCmd() *cobra.Command {
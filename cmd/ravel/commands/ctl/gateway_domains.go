@@ -0,0 +1,173 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/spf13/cobra"
+)
+
+func newGatewayDomainsCmd() *cobra.Command {
+	domainsCmd := &cobra.Command{
+		Use:     "domains",
+		Aliases: []string{"domain"},
+		Short:   "Manage the custom domains of a gateway",
+	}
+
+	domainsCmd.AddCommand(newGatewayDomainsListCmd())
+	domainsCmd.AddCommand(newGatewayDomainsAddCmd())
+	domainsCmd.AddCommand(newGatewayDomainsVerifyCmd())
+	domainsCmd.AddCommand(newGatewayDomainsRemoveCmd())
+
+	return domainsCmd
+}
+
+func printGatewayDomain(cmd *cobra.Command, domain *api.GatewayDomain) {
+	if outputFmt == "json" {
+		data, _ := json.MarshalIndent(domain, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Domain %s is %s\n", domain.Hostname, domain.Status)
+	if domain.Status == api.GatewayDomainStatusVerified {
+		return
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Create one of the following DNS records to verify it:")
+	fmt.Fprintf(cmd.OutOrStdout(), "  %s TXT %q\n", domain.Verification.TXTName, domain.Verification.TXTValue)
+	if domain.Verification.CNAMETarget != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "  %s CNAME %s\n", domain.Hostname, domain.Verification.CNAMETarget)
+	}
+}
+
+func newGatewayDomainsListCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:     "list <gateway>",
+		Aliases: []string{"ls"},
+		Short:   "List the custom domains of a gateway",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			domains, err := client.ListGatewayDomains(namespace, fleet, args[0])
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(domains, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "HOSTNAME\tSTATUS\tCREATED")
+			for _, d := range domains {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", d.Hostname, d.Status, d.CreatedAt.Format("2006-01-02 15:04:05"))
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
+
+func newGatewayDomainsAddCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:   "add <gateway> <hostname>",
+		Short: "Add a custom domain to a gateway",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			domain, err := client.AddGatewayDomain(namespace, fleet, args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			printGatewayDomain(cmd, domain)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
+
+func newGatewayDomainsVerifyCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:   "verify <gateway> <hostname>",
+		Short: "Verify the DNS records of a custom domain",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			domain, err := client.VerifyGatewayDomain(namespace, fleet, args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			printGatewayDomain(cmd, domain)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
+
+func newGatewayDomainsRemoveCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:     "remove <gateway> <hostname>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a custom domain from a gateway",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteGatewayDomain(namespace, fleet, args[0], args[1]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Domain %s removed\n", args[1])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"text/tabwriter"
 
+	"github.com/alexisbouchez/ravel/api"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +19,7 @@ func newGatewaysCmd() *cobra.Command {
 	gwCmd.AddCommand(newGatewaysListCmd())
 	gwCmd.AddCommand(newGatewaysCreateCmd())
 	gwCmd.AddCommand(newGatewaysDeleteCmd())
+	gwCmd.AddCommand(newGatewayDomainsCmd())
 
 	return gwCmd
 }
@@ -51,9 +53,13 @@ func newGatewaysListCmd() *cobra.Command {
 			}
 
 			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
-			fmt.Fprintln(w, "NAME\tTARGET PORT\tPROTOCOL")
+			fmt.Fprintln(w, "NAME\tTARGET PORT\tPROTOCOL\tPUBLIC PORT")
 			for _, gw := range gateways {
-				fmt.Fprintf(w, "%s\t%d\t%s\n", gw.Name, gw.TargetPort, gw.Protocol)
+				publicPort := "-"
+				if gw.PublicPort > 0 {
+					publicPort = fmt.Sprintf("%d", gw.PublicPort)
+				}
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", gw.Name, gw.TargetPort, gw.Protocol, publicPort)
 			}
 			w.Flush()
 
@@ -70,6 +76,8 @@ func newGatewaysListCmd() *cobra.Command {
 func newGatewaysCreateCmd() *cobra.Command {
 	var fleet string
 	var targetPort int
+	var protocol string
+	var proxyProtocol bool
 
 	cmd := &cobra.Command{
 		Use:   "create <name>",
@@ -85,7 +93,12 @@ func newGatewaysCreateCmd() *cobra.Command {
 				return err
 			}
 
-			gw, err := client.CreateGateway(namespace, fleet, args[0], targetPort)
+			gw, err := client.CreateGateway(namespace, fleet, api.CreateGatewayPayload{
+				Name:          args[0],
+				TargetPort:    targetPort,
+				Protocol:      protocol,
+				ProxyProtocol: proxyProtocol,
+			})
 			if err != nil {
 				return err
 			}
@@ -96,6 +109,11 @@ func newGatewaysCreateCmd() *cobra.Command {
 				return nil
 			}
 
+			if gw.PublicPort > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Gateway %s created on %s port %d\n", gw.Name, gw.Protocol, gw.PublicPort)
+				return nil
+			}
+
 			fmt.Fprintf(cmd.OutOrStdout(), "Gateway %s created\n", gw.Name)
 			return nil
 		},
@@ -103,6 +121,8 @@ func newGatewaysCreateCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
 	cmd.Flags().IntVarP(&targetPort, "port", "p", 80, "Target port")
+	cmd.Flags().StringVar(&protocol, "protocol", "", "Gateway protocol: http, https, tcp or udp (default https)")
+	cmd.Flags().BoolVar(&proxyProtocol, "proxy-protocol", false, "Send a PROXY protocol v2 header to the machines (tcp and udp only)")
 	cmd.MarkFlagRequired("fleet")
 
 	return cmd
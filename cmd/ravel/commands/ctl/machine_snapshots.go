@@ -0,0 +1,158 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func newMachineSnapshotsCmd() *cobra.Command {
+	snapCmd := &cobra.Command{
+		Use:     "snapshots",
+		Aliases: []string{"snap", "snapshot"},
+		Short:   "Manage machine memory snapshots",
+	}
+
+	snapCmd.AddCommand(newMachineSnapshotsListCmd())
+	snapCmd.AddCommand(newMachineSnapshotsCreateCmd())
+	snapCmd.AddCommand(newMachineSnapshotsDeleteCmd())
+	snapCmd.AddCommand(newMachineSnapshotsRestoreCmd())
+
+	return snapCmd
+}
+
+func newMachineSnapshotsListCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:     "list <machine-id>",
+		Aliases: []string{"ls"},
+		Short:   "List the snapshots of a machine",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			snapshots, err := client.ListMachineSnapshots(namespace, fleet, args[0])
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(snapshots, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNODE\tSIZE\tVERSION\tCREATED")
+			for _, s := range snapshots {
+				fmt.Fprintf(w, "%s\t%s\t%d MB\t%s\t%s\n", s.Id, s.Node, s.SizeBytes/(1024*1024), s.MachineVersion, s.CreatedAt)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
+
+func newMachineSnapshotsCreateCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:   "create <machine-id>",
+		Short: "Snapshot the memory of a running machine",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := client.CreateMachineSnapshot(namespace, fleet, args[0])
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(snapshot, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Snapshot %s of machine %s created\n", snapshot.Id, args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
+
+func newMachineSnapshotsDeleteCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:     "delete <machine-id> <snapshot>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a machine snapshot",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteMachineSnapshot(namespace, fleet, args[0], args[1]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Snapshot %s deleted\n", args[1])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
+
+func newMachineSnapshotsRestoreCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:   "restore <machine-id> <snapshot>",
+		Short: "Bring a running machine back to the memory state of a snapshot",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.RestoreMachineSnapshot(namespace, fleet, args[0], args[1]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Machine %s restored to snapshot %s\n", args[0], args[1])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
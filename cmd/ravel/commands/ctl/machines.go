@@ -3,9 +3,13 @@ package ctl
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"text/tabwriter"
+	"time"
 
+	"github.com/alexisbouchez/ravel/api"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
 func newMachinesCmd() *cobra.Command {
@@ -18,9 +22,15 @@ func newMachinesCmd() *cobra.Command {
 	machinesCmd.AddCommand(newMachinesListCmd())
 	machinesCmd.AddCommand(newMachinesGetCmd())
 	machinesCmd.AddCommand(newMachinesLogsCmd())
+	machinesCmd.AddCommand(newMachinesExecCmd())
+	machinesCmd.AddCommand(newMachinesSSHCmd())
+	machinesCmd.AddCommand(newMachinesUpdateCmd())
 	machinesCmd.AddCommand(newMachinesStartCmd())
 	machinesCmd.AddCommand(newMachinesStopCmd())
 	machinesCmd.AddCommand(newMachinesDeleteCmd())
+	machinesCmd.AddCommand(newMachineSnapshotsCmd())
+	machinesCmd.AddCommand(newMachinesForkCmd())
+	machinesCmd.AddCommand(newMachinesMigrateCmd())
 
 	return machinesCmd
 }
@@ -107,6 +117,8 @@ func newMachinesGetCmd() *cobra.Command {
 
 func newMachinesLogsCmd() *cobra.Command {
 	var fleet string
+	var since time.Duration
+	var opts api.LogsOptions
 
 	cmd := &cobra.Command{
 		Use:   "logs <machine-id>",
@@ -122,7 +134,11 @@ func newMachinesLogsCmd() *cobra.Command {
 				return err
 			}
 
-			logs, err := client.GetMachineLogs(namespace, fleet, args[0])
+			if since > 0 {
+				opts.Since = time.Now().Add(-since).Unix()
+			}
+
+			logs, err := client.GetMachineLogs(namespace, fleet, args[0], opts)
 			if err != nil {
 				return err
 			}
@@ -135,6 +151,83 @@ func newMachinesLogsCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
 	cmd.MarkFlagRequired("fleet")
+	cmd.Flags().DurationVar(&since, "since", 0, "Only show the logs more recent than a duration (e.g. 10m)")
+	cmd.Flags().IntVar(&opts.Tail, "tail", 0, "Only show the last n lines")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 0, "Maximum number of lines to show")
+
+	return cmd
+}
+
+func newMachinesUpdateCmd() *cobra.Command {
+	var fleet string
+	var configFile string
+	var image string
+
+	cmd := &cobra.Command{
+		Use:   "update <machine-id>",
+		Short: "Update a machine config",
+		Long: `Update the config of a machine. The machine instance is replaced by a new
+one created from the new config. The config is read from a json or yaml file,
+if no file is given the current config is used and only the image is updated.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if fleet == "" {
+				return fmt.Errorf("--fleet is required")
+			}
+
+			if configFile == "" && image == "" {
+				return fmt.Errorf("--config or --image is required")
+			}
+
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			var config api.MachineConfig
+			if configFile != "" {
+				file, err := os.ReadFile(configFile)
+				if err != nil {
+					return fmt.Errorf("unable to read config file %s: %w", configFile, err)
+				}
+
+				if err = yaml.Unmarshal(file, &config); err != nil {
+					return fmt.Errorf("unable to unmarshal config file %s: %w", configFile, err)
+				}
+			} else {
+				machine, err := client.GetMachine(namespace, fleet, args[0])
+				if err != nil {
+					return err
+				}
+				config = machine.Config
+			}
+
+			if image != "" {
+				config.Image = image
+			}
+
+			machine, err := client.UpdateMachine(namespace, fleet, args[0], &api.UpdateMachinePayload{
+				Config: config,
+			})
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(machine, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Machine %s updated to version %s\n", machine.Id, machine.MachineVersion)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.Flags().StringVarP(&configFile, "config", "c", "", "Config file which contains the machine config")
+	cmd.Flags().StringVar(&image, "image", "", "Image to use")
+	cmd.MarkFlagRequired("fleet")
 
 	return cmd
 }
@@ -171,6 +264,92 @@ func newMachinesStartCmd() *cobra.Command {
 	return cmd
 }
 
+func newMachinesForkCmd() *cobra.Command {
+	var fleet string
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "fork <machine-id>",
+		Short: "Create running machines from a snapshot of the memory of a running machine",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if fleet == "" {
+				return fmt.Errorf("--fleet is required")
+			}
+
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			machines, err := client.ForkMachine(namespace, fleet, args[0], count)
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(machines, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tREGION\tSTATUS\tCREATED")
+			for _, m := range machines {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Id, m.Region, m.Status, m.CreatedAt)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+	cmd.Flags().IntVarP(&count, "count", "n", 1, "Number of machines to create")
+
+	return cmd
+}
+
+func newMachinesMigrateCmd() *cobra.Command {
+	var fleet string
+	var node string
+
+	cmd := &cobra.Command{
+		Use:   "migrate <machine-id>",
+		Short: "Move a machine and its volumes to another node of its region, running machines are live migrated",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if fleet == "" {
+				return fmt.Errorf("--fleet is required")
+			}
+			if node == "" {
+				return fmt.Errorf("--node is required")
+			}
+
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			machine, err := client.MigrateMachine(namespace, fleet, args[0], node)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Machine %s migrated to node %s\n", machine.Id, node)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+	cmd.Flags().StringVar(&node, "node", "", "Id of the destination node (required)")
+	cmd.MarkFlagRequired("node")
+
+	return cmd
+}
+
 func newMachinesStopCmd() *cobra.Command {
 	var fleet string
 
@@ -0,0 +1,155 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/spf13/cobra"
+)
+
+func newNetworksCmd() *cobra.Command {
+	netCmd := &cobra.Command{
+		Use:     "networks",
+		Aliases: []string{"net", "network"},
+		Short:   "Manage private networks",
+	}
+
+	netCmd.AddCommand(newNetworksListCmd())
+	netCmd.AddCommand(newNetworksGetCmd())
+	netCmd.AddCommand(newNetworksCreateCmd())
+	netCmd.AddCommand(newNetworksDeleteCmd())
+
+	return netCmd
+}
+
+func newNetworksListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List private networks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			networks, err := client.ListNetworks(namespace)
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(networks, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tCIDR\tCREATED")
+			for _, n := range networks {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", n.Name, n.CIDR, n.CreatedAt)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+}
+
+func newNetworksGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <name>",
+		Short: "Show a private network and its members",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			network, err := client.GetNetwork(namespace, args[0])
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(network, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Network %s (%s)\n\n", network.Name, network.CIDR)
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "MACHINE\tIP\tNODE")
+			for _, m := range network.Members {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", m.MachineId, m.IP, m.Node)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+}
+
+func newNetworksCreateCmd() *cobra.Command {
+	var cidr string
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a private network",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			network, err := client.CreateNetwork(namespace, api.CreateNetworkPayload{
+				Name: args[0],
+				CIDR: cidr,
+			})
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(network, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Network %s created (%s)\n", network.Name, network.CIDR)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&cidr, "cidr", "", "IPv4 range of the network (e.g. 10.0.1.0/24)")
+	cmd.MarkFlagRequired("cidr")
+
+	return cmd
+}
+
+func newNetworksDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a private network",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteNetwork(namespace, args[0]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Network %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
@@ -3,8 +3,11 @@ package ctl
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"text/tabwriter"
+	"time"
 
+	"github.com/alexisbouchez/ravel/api"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +19,9 @@ func newNodesCmd() *cobra.Command {
 	}
 
 	nodesCmd.AddCommand(newNodesListCmd())
+	nodesCmd.AddCommand(newNodesCordonCmd())
+	nodesCmd.AddCommand(newNodesUncordonCmd())
+	nodesCmd.AddCommand(newNodesDrainCmd())
 
 	return nodesCmd
 }
@@ -43,9 +49,9 @@ func newNodesListCmd() *cobra.Command {
 			}
 
 			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
-			fmt.Fprintln(w, "ID\tADDRESS\tREGION")
+			fmt.Fprintln(w, "ID\tADDRESS\tREGION\tCORDONED")
 			for _, n := range nodes {
-				fmt.Fprintf(w, "%s\t%s\t%s\n", n.Id, n.Address, n.Region)
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", n.Id, n.Address, n.Region, n.Cordoned)
 			}
 			w.Flush()
 
@@ -53,3 +59,129 @@ func newNodesListCmd() *cobra.Command {
 		},
 	}
 }
+
+func newNodesCordonCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cordon <node-id>",
+		Short: "Stop placing machines on a node, its machines keep running",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.CordonNode(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Node %s cordoned\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newNodesUncordonCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uncordon <node-id>",
+		Short: "Place machines on a cordoned node again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.UncordonNode(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Node %s uncordoned\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newNodesDrainCmd() *cobra.Command {
+	var detach bool
+
+	cmd := &cobra.Command{
+		Use:   "drain <node-id>",
+		Short: "Cordon a node and migrate or stop its machines according to their restart policy",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			drain, err := client.DrainNode(args[0])
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Node %s cordoned, draining %d machines\n", drain.Node, len(drain.Machines))
+			if detach {
+				return nil
+			}
+
+			reported := make([]bool, len(drain.Machines))
+			for {
+				printDrainProgress(out, drain, reported)
+				if drain.Status == api.NodeDrainStatusDone {
+					break
+				}
+
+				time.Sleep(2 * time.Second)
+				if drain, err = client.GetNodeDrain(args[0]); err != nil {
+					return err
+				}
+			}
+
+			failed := 0
+			for _, m := range drain.Machines {
+				if m.Status == api.NodeDrainMachineFailed {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d machines failed to be drained from node %s", failed, drain.Node)
+			}
+
+			fmt.Fprintf(out, "Node %s drained\n", drain.Node)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&detach, "detach", false, "Return once the drain is started, without waiting for it")
+
+	return cmd
+}
+
+// printDrainProgress prints the machines handled since the last call.
+func printDrainProgress(out io.Writer, drain *api.NodeDrain, reported []bool) {
+	done := 0
+	for _, r := range reported {
+		if r {
+			done++
+		}
+	}
+
+	for i, m := range drain.Machines {
+		if i >= len(reported) || reported[i] || m.Status == api.NodeDrainMachinePending {
+			continue
+		}
+		reported[i] = true
+		done++
+
+		switch {
+		case m.Status == api.NodeDrainMachineFailed:
+			fmt.Fprintf(out, "[%d/%d] machine %s failed to %s: %s\n", done, len(drain.Machines), m.Id, m.Action, m.Error)
+		case m.Action == api.NodeDrainActionStop:
+			fmt.Fprintf(out, "[%d/%d] machine %s stopped\n", done, len(drain.Machines), m.Id)
+		default:
+			fmt.Fprintf(out, "[%d/%d] machine %s migrated to node %s\n", done, len(drain.Machines), m.Id, m.Node)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/sandbox"
+	"github.com/spf13/cobra"
+)
+
+func newSandboxPoolsCmd() *cobra.Command {
+	poolsCmd := &cobra.Command{
+		Use:     "sandbox-pools",
+		Aliases: []string{"sbp", "sandbox-pool"},
+		Short:   "Manage pools of pre-warmed sandboxes",
+	}
+
+	poolsCmd.AddCommand(newSandboxPoolsListCmd())
+	poolsCmd.AddCommand(newSandboxPoolsCreateCmd())
+	poolsCmd.AddCommand(newSandboxPoolsDeleteCmd())
+	poolsCmd.AddCommand(newSandboxPoolsStatsCmd())
+	poolsCmd.AddCommand(newSandboxPoolsSandboxesCmd())
+	poolsCmd.AddCommand(newSandboxPoolsClaimCmd())
+	poolsCmd.AddCommand(newSandboxPoolsReleaseCmd())
+
+	return poolsCmd
+}
+
+func newSandboxPoolsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List sandbox pools",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			pools, err := client.ListSandboxPools(namespace)
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(pools, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tFLEET\tREGION\tIMAGE\tWARM\tCREATED")
+			for _, p := range pools {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d-%d\t%s\n", p.Name, p.Config.Fleet, p.Config.Region, p.Config.TemplateImage, p.Config.MinWarm, p.Config.MaxWarm, p.CreatedAt)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+}
+
+func newSandboxPoolsCreateCmd() *cobra.Command {
+	defaults := sandbox.DefaultPoolConfig()
+	config := api.SandboxPoolConfig{}
+	var idleTimeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a sandbox pool",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			config.IdleTimeoutSeconds = int(idleTimeout.Seconds())
+
+			pool, err := client.CreateSandboxPool(namespace, api.CreateSandboxPoolPayload{
+				Name:   args[0],
+				Config: config,
+			})
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(pool, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Sandbox pool %s created\n", pool.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&config.Fleet, "fleet", "f", "", "Fleet the sandbox machines are created in (required)")
+	cmd.MarkFlagRequired("fleet")
+	cmd.Flags().StringVar(&config.Region, "region", "", "Region of the sandbox machines (required)")
+	cmd.MarkFlagRequired("region")
+	cmd.Flags().StringVar(&config.TemplateImage, "image", defaults.TemplateImage, "Image of the sandbox machines")
+	cmd.Flags().StringVar(&config.CpuKind, "cpu-kind", "", "CPU kind of the sandbox machines")
+	cmd.Flags().IntVar(&config.DefaultCPUs, "cpus", defaults.DefaultCPUs, "Number of CPUs of the sandbox machines")
+	cmd.Flags().IntVar(&config.DefaultMemoryMB, "memory", defaults.DefaultMemoryMB, "Memory of the sandbox machines in MB")
+	cmd.Flags().IntVar(&config.MinWarm, "min-warm", defaults.MinWarm, "Number of ready sandboxes to maintain")
+	cmd.Flags().IntVar(&config.MaxWarm, "max-warm", defaults.MaxWarm, "Maximum number of sandboxes, claimed ones included")
+	cmd.Flags().BoolVar(&config.SnapshotAfterBoot, "snapshot-after-boot", defaults.SnapshotAfterBoot, "Snapshot the sandboxes after boot and restore them on release")
+	cmd.Flags().DurationVar(&idleTimeout, "idle-timeout", defaults.GetIdleTimeout(), "Time a ready sandbox above min-warm stays warm")
+
+	return cmd
+}
+
+func newSandboxPoolsDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a sandbox pool and destroy its sandboxes",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteSandboxPool(namespace, args[0]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Sandbox pool %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newSandboxPoolsStatsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats <name>",
+		Short: "Show the sandboxes count of a pool by state",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			stats, err := client.GetSandboxPoolStats(namespace, args[0])
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(stats, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "TOTAL\tREADY\tCLAIMED\tWARMING\tRESETING")
+			fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\n", stats.Total, stats.Ready, stats.Claimed, stats.Warming, stats.Reseting)
+			w.Flush()
+
+			return nil
+		},
+	}
+}
+
+func newSandboxPoolsSandboxesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sandboxes <name>",
+		Short: "List the sandboxes of a pool",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			sandboxes, err := client.ListSandboxes(namespace, args[0])
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(sandboxes, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTATE\tMACHINE\tNODE\tCREATED")
+			for _, s := range sandboxes {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Id, s.State, s.MachineId, s.Node, s.CreatedAt)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+}
+
+func newSandboxPoolsClaimCmd() *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "claim <name>",
+		Short: "Claim a ready sandbox of a pool",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			sandbox, err := client.ClaimSandbox(namespace, args[0], api.ClaimSandboxPayload{TimeoutMs: int(timeout.Milliseconds())})
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(sandbox, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Sandbox %s claimed, machine %s on node %s\n", sandbox.Id, sandbox.MachineId, sandbox.Node)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Time to wait for a ready sandbox, 10s by default")
+
+	return cmd
+}
+
+func newSandboxPoolsReleaseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "release <name> <sandbox-id>",
+		Short: "Release a claimed sandbox, it is reset and returned to the pool",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.ReleaseSandbox(namespace, args[0], args[1]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Sandbox %s released\n", args[1])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+)
+
+func newMachinesSSHCmd() *cobra.Command {
+	var fleet string
+
+	cmd := &cobra.Command{
+		Use:   "ssh <machine-id> [-- command...]",
+		Short: "Open an interactive shell in a running machine",
+		Long:  "Open an interactive shell in a running machine, or run a command attached to a terminal. The command defaults to /bin/sh.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			command := args[1:]
+			if len(command) == 0 {
+				command = []string{"/bin/sh"}
+			}
+
+			conn, err := client.ExecSession(namespace, fleet, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open exec session: %w", err)
+			}
+			defer conn.Close()
+
+			exitCode, err := runExecSession(conn, command)
+			if err != nil {
+				return err
+			}
+
+			if exitCode != 0 {
+				conn.Close()
+				os.Exit(exitCode)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&fleet, "fleet", "f", "", "Fleet name (required)")
+	cmd.MarkFlagRequired("fleet")
+
+	return cmd
+}
+
+type execSessionWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (w *execSessionWriter) send(v any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(v)
+}
+
+// runExecSession attaches the local terminal to an exec session and returns the exit
+// code of the remote command.
+func runExecSession(conn io.ReadWriter, command []string) (int, error) {
+	fd := int(os.Stdin.Fd())
+	w := &execSessionWriter{enc: json.NewEncoder(conn)}
+
+	opts := api.ExecSessionOptions{Cmd: command}
+	if term := os.Getenv("TERM"); term != "" {
+		opts.Env = []string{"TERM=" + term}
+	}
+	if size, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err == nil {
+		opts.Cols, opts.Rows = size.Col, size.Row
+	}
+
+	if err := w.send(opts); err != nil {
+		return -1, err
+	}
+
+	if state, err := makeRaw(fd); err == nil {
+		defer unix.IoctlSetTermios(fd, unix.TCSETS, state)
+	}
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+	go func() {
+		for range resize {
+			size, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+			if err != nil {
+				continue
+			}
+			if err := w.send(api.ExecMessage{Type: api.ExecMessageResize, Cols: size.Col, Rows: size.Row}); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				if err := w.send(api.ExecMessage{Type: api.ExecMessageStdin, Data: buf[:n]}); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	dec := json.NewDecoder(conn)
+	for {
+		var msg api.ExecMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return -1, errors.New("exec session closed")
+			}
+			return -1, err
+		}
+
+		switch msg.Type {
+		case api.ExecMessageStdout:
+			os.Stdout.Write(msg.Data)
+		case api.ExecMessageExit:
+			return msg.ExitCode, nil
+		case api.ExecMessageError:
+			return -1, errors.New(msg.Error)
+		}
+	}
+}
+
+// makeRaw puts a terminal in raw mode and returns its previous state.
+func makeRaw(fd int) (*unix.Termios, error) {
+	state, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := *state
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
@@ -0,0 +1,282 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/spf13/cobra"
+)
+
+func newVolumesCmd() *cobra.Command {
+	volCmd := &cobra.Command{
+		Use:     "volumes",
+		Aliases: []string{"vol", "volume"},
+		Short:   "Manage volumes",
+	}
+
+	volCmd.AddCommand(newVolumesListCmd())
+	volCmd.AddCommand(newVolumesCreateCmd())
+	volCmd.AddCommand(newVolumesResizeCmd())
+	volCmd.AddCommand(newVolumesDeleteCmd())
+	volCmd.AddCommand(newVolumeSnapshotsCmd())
+
+	return volCmd
+}
+
+func newVolumesListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List volumes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			volumes, err := client.ListVolumes(namespace)
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(volumes, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSIZE\tREGION\tNODE\tMACHINE\tCREATED")
+			for _, v := range volumes {
+				fmt.Fprintf(w, "%s\t%dMB\t%s\t%s\t%s\t%s\n", v.Name, v.SizeMB, v.Region, v.Node, v.AttachedMachine, v.CreatedAt)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+}
+
+func newVolumesCreateCmd() *cobra.Command {
+	var sizeMB uint64
+	var region, node, snapshot string
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a volume",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			volume, err := client.CreateVolume(namespace, api.CreateVolumePayload{
+				Name:     args[0],
+				SizeMB:   sizeMB,
+				Region:   region,
+				Node:     node,
+				Snapshot: snapshot,
+			})
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(volume, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Volume %s created on node %s\n", volume.Name, volume.Node)
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint64Var(&sizeMB, "size", 1024, "Size of the volume in MB")
+	cmd.Flags().StringVar(&region, "region", "", "Region of the volume")
+	cmd.Flags().StringVar(&node, "node", "", "Node holding the volume, picked in the region if empty")
+	cmd.Flags().StringVar(&snapshot, "snapshot", "", "Create the volume from a snapshot, on the node and with the size of the snapshotted volume")
+
+	return cmd
+}
+
+func newVolumesResizeCmd() *cobra.Command {
+	var sizeMB uint64
+
+	cmd := &cobra.Command{
+		Use:   "resize <name>",
+		Short: "Grow a volume, the machine mounting it keeps running",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			volume, err := client.ResizeVolume(namespace, args[0], api.ResizeVolumePayload{SizeMB: sizeMB})
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(volume, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Volume %s resized to %dMB\n", volume.Name, volume.SizeMB)
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint64Var(&sizeMB, "size", 0, "New size of the volume in MB")
+	cmd.MarkFlagRequired("size")
+
+	return cmd
+}
+
+func newVolumesDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a volume",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteVolume(namespace, args[0]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Volume %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newVolumeSnapshotsCmd() *cobra.Command {
+	snapCmd := &cobra.Command{
+		Use:     "snapshots",
+		Aliases: []string{"snap", "snapshot"},
+		Short:   "Manage volume snapshots",
+	}
+
+	snapCmd.AddCommand(newVolumeSnapshotsListCmd())
+	snapCmd.AddCommand(newVolumeSnapshotsCreateCmd())
+	snapCmd.AddCommand(newVolumeSnapshotsDeleteCmd())
+	snapCmd.AddCommand(newVolumeSnapshotsRestoreCmd())
+
+	return snapCmd
+}
+
+func newVolumeSnapshotsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list <volume>",
+		Aliases: []string{"ls"},
+		Short:   "List the snapshots of a volume",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			snapshots, err := client.ListVolumeSnapshots(namespace, args[0])
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(snapshots, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tCREATED")
+			for _, s := range snapshots {
+				fmt.Fprintf(w, "%s\t%s\n", s.Id, s.CreatedAt)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+}
+
+func newVolumeSnapshotsCreateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create <volume>",
+		Short: "Snapshot a volume",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := client.CreateVolumeSnapshot(namespace, args[0])
+			if err != nil {
+				return err
+			}
+
+			if outputFmt == "json" {
+				data, _ := json.MarshalIndent(snapshot, "", "  ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Snapshot %s of volume %s created\n", snapshot.Id, args[0])
+			return nil
+		},
+	}
+}
+
+func newVolumeSnapshotsDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <volume> <snapshot>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a volume snapshot",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteVolumeSnapshot(namespace, args[0], args[1]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Snapshot %s deleted\n", args[1])
+			return nil
+		},
+	}
+}
+
+func newVolumeSnapshotsRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <volume> <snapshot>",
+		Short: "Roll a detached volume back to a snapshot, the more recent snapshots are deleted",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := getClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.RestoreVolumeSnapshot(namespace, args[0], args[1]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Volume %s restored to snapshot %s\n", args[0], args[1])
+			return nil
+		},
+	}
+}
@@ -10,6 +10,7 @@ func NewDBCmd() *cobra.Command {
 
 	cmd.AddCommand(NewDBInfosCmd())
 	cmd.AddCommand(NewMigrateCmd())
+	cmd.AddCommand(NewRotateSecretsKeyCmd())
 
 	return cmd
 }
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/config"
+	"github.com/alexisbouchez/ravel/ravel/secrets"
+	"github.com/alexisbouchez/ravel/ravel/state"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+func NewRotateSecretsKeyCmd() *cobra.Command {
+	var configPath string
+
+	cmd := &cobra.Command{
+		Use:   "rotate-secrets-key",
+		Short: "Rotate the data keys of the namespaces and encrypt the secrets again",
+		Long: `Rotate the data keys of the namespaces and encrypt the secrets again.
+
+The new data keys are wrapped by the master key of server.secrets.key_file, the
+master keys of server.secrets.previous_key_files are only used to decrypt the
+current secrets and can be removed once the rotation is done. The secrets
+stored in plaintext before the encryption was configured are encrypted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRotateSecretsKeyCmd(cmd, configPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "/etc/ravel/config.toml", "Path to the configuration file")
+
+	return cmd
+}
+
+func runRotateSecretsKeyCmd(cmd *cobra.Command, configPath string) error {
+	config, err := config.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	if config.Server.Secrets == nil {
+		return fmt.Errorf("server.secrets is not configured")
+	}
+
+	keys, err := secrets.NewKeyProvider(*config.Server.Secrets)
+	if err != nil {
+		return fmt.Errorf("server.secrets: %w", err)
+	}
+
+	pool, err := pgxpool.New(cmd.Context(), config.Server.PostgresURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	namespaces, rotated, err := state.New(pool, cluster.NewNoop(), keys).RotateSecretsKeys(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Encrypted %d secrets of %d namespaces with master key %s\n", rotated, namespaces, keys.KeyId())
+	return nil
+}
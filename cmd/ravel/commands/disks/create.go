@@ -1,6 +1,8 @@
 package disks
 
 import (
+	"strings"
+
 	"github.com/alexisbouchez/ravel/cmd/ravel/util"
 	"github.com/alexisbouchez/ravel/core/daemon"
 	"github.com/spf13/cobra"
@@ -8,9 +10,10 @@ import (
 
 func newCreateCmd() *cobra.Command {
 	var size uint64
+	var fromSnapshot string
 
 	cmd := &cobra.Command{
-		Use:   "create <id> [--size|-s <size>]",
+		Use:   "create <id> [--size|-s <size>] [--from-snapshot <disk>@<snapshot>]",
 		Short: "Create a disk",
 		RunE: func(cmd *cobra.Command, args []string) error {
 
@@ -18,20 +21,32 @@ func newCreateCmd() *cobra.Command {
 				cmd.Println("id is required")
 				return cmd.Usage()
 			}
-			return runCreateDisk(cmd, args[0], size)
+			return runCreateDisk(cmd, args[0], size, fromSnapshot)
 		},
 	}
 
 	cmd.Flags().Uint64VarP(&size, "size", "s", 512, "Size of the disk in MB")
+	cmd.Flags().StringVar(&fromSnapshot, "from-snapshot", "", "Create the disk from a snapshot, the size is the one of the snapshotted disk")
 
 	return cmd
 }
 
-func runCreateDisk(cmd *cobra.Command, id string, size uint64) error {
-	disk, err := util.GetDaemonClient(cmd).CreateDisk(cmd.Context(), daemon.DiskOptions{
+func runCreateDisk(cmd *cobra.Command, id string, size uint64, fromSnapshot string) error {
+	opts := daemon.DiskOptions{
 		SizeMB: size,
 		Id:     id,
-	})
+	}
+
+	if fromSnapshot != "" {
+		disk, snapshot, ok := strings.Cut(fromSnapshot, "@")
+		if !ok {
+			cmd.Println("snapshot must be of the form <disk>@<snapshot>")
+			return cmd.Usage()
+		}
+		opts.FromSnapshot = &daemon.DiskSnapshotRef{Disk: disk, Snapshot: snapshot}
+	}
+
+	disk, err := util.GetDaemonClient(cmd).CreateDisk(cmd.Context(), opts)
 
 	if err != nil {
 		return err
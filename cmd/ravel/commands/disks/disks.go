@@ -11,6 +11,8 @@ func NewDisksCmd() *cobra.Command {
 	cmd.AddCommand(newCreateCmd())
 	cmd.AddCommand(newListCmd())
 	cmd.AddCommand(newDestroyCmd())
+	cmd.AddCommand(newResizeCmd())
+	cmd.AddCommand(newSnapshotsCmd())
 
 	return cmd
 }
@@ -0,0 +1,33 @@
+package disks
+
+import (
+	"github.com/alexisbouchez/ravel/cmd/ravel/util"
+	"github.com/alexisbouchez/ravel/core/daemon"
+	"github.com/spf13/cobra"
+)
+
+func newResizeCmd() *cobra.Command {
+	var size uint64
+
+	cmd := &cobra.Command{
+		Use:   "resize <id> --size|-s <size>",
+		Short: "Grow a disk and its filesystem, online if the disk is mounted by a running instance",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			disk, err := util.GetDaemonClient(cmd).ResizeDisk(cmd.Context(), args[0], daemon.ResizeDiskOptions{
+				SizeMB: size,
+			})
+			if err != nil {
+				return err
+			}
+
+			cmd.Printf("Disk %s resized to %dMB\n", disk.Id, disk.SizeMB)
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint64VarP(&size, "size", "s", 0, "New size of the disk in MB")
+	cmd.MarkFlagRequired("size")
+
+	return cmd
+}
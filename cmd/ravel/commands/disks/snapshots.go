@@ -0,0 +1,96 @@
+package disks
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/alexisbouchez/ravel/cmd/ravel/util"
+	"github.com/alexisbouchez/ravel/core/daemon"
+	"github.com/spf13/cobra"
+)
+
+func newSnapshotsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshots",
+		Short: "Manage disk snapshots",
+	}
+
+	cmd.AddCommand(newSnapshotCreateCmd())
+	cmd.AddCommand(newSnapshotListCmd())
+	cmd.AddCommand(newSnapshotDestroyCmd())
+	cmd.AddCommand(newSnapshotRestoreCmd())
+
+	return cmd
+}
+
+func newSnapshotCreateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create <disk> <id>",
+		Short: "Snapshot a disk",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			snapshot, err := util.GetDaemonClient(cmd).CreateDiskSnapshot(cmd.Context(), args[0], daemon.DiskSnapshotOptions{Id: args[1]})
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Snapshot %s of disk %s created\n", snapshot.Id, snapshot.DiskId)
+			return nil
+		},
+	}
+}
+
+func newSnapshotListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls <disk>",
+		Short: "List the snapshots of a disk",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			snapshots, err := util.GetDaemonClient(cmd).ListDiskSnapshots(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 1, 1, 1, ' ', 0)
+
+			fmt.Fprintln(w, "ID\tCREATED AT")
+			for _, snapshot := range snapshots {
+				fmt.Fprintf(w, "%s\t%s\n", snapshot.Id, snapshot.CreatedAt)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+}
+
+func newSnapshotDestroyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "destroy <disk> <id>",
+		Short: "Destroy a disk snapshot",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := util.GetDaemonClient(cmd).DeleteDiskSnapshot(cmd.Context(), args[0], args[1])
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Snapshot %s destroyed\n", args[1])
+			return nil
+		},
+	}
+}
+
+func newSnapshotRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <disk> <id>",
+		Short: "Roll a detached disk back to a snapshot, the more recent snapshots are destroyed",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := util.GetDaemonClient(cmd).RestoreDiskSnapshot(cmd.Context(), args[0], args[1])
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Disk %s restored to snapshot %s\n", args[0], args[1])
+			return nil
+		},
+	}
+}
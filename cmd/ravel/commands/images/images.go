@@ -8,13 +8,16 @@ import (
 
 func NewImagesCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "image",
-		Short: "Manage images",
+		Use:     "image",
+		Aliases: []string{"images"},
+		Short:   "Manage images",
 	}
 
 	cmd.AddCommand(newListImagesCmd())
 	cmd.AddCommand(newPullImageCmd())
 	cmd.AddCommand(newDeleteImageCmd())
+	cmd.AddCommand(newPruneImagesCmd())
+	cmd.AddCommand(newImageGCStatsCmd())
 
 	return cmd
 }
@@ -104,3 +107,59 @@ func deleteImage(cmd *cobra.Command, args []string) error {
 	cmd.Println("Image deleted")
 	return nil
 }
+
+func newPruneImagesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete all the images no instance uses",
+		RunE:  pruneImages,
+	}
+
+	return cmd
+}
+
+func pruneImages(cmd *cobra.Command, args []string) error {
+	client := util.GetDaemonClient(cmd)
+
+	cmd.Println("Pruning images...")
+	result, err := client.PruneImages(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range result.Deleted {
+		cmd.Println("Deleted", ref)
+	}
+
+	cmd.Printf("%d images deleted, %dMB reclaimed\n", len(result.Deleted), result.ReclaimedBytes/(1024*1024))
+	return nil
+}
+
+func newImageGCStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Show the garbage collection of the images",
+		RunE:  imageGCStats,
+	}
+
+	return cmd
+}
+
+func imageGCStats(cmd *cobra.Command, args []string) error {
+	client := util.GetDaemonClient(cmd)
+
+	stats, err := client.ImageGCStats(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Disk usage:      %dMB / %dMB\n", stats.DiskUsedBytes/(1024*1024), stats.DiskTotalBytes/(1024*1024))
+	cmd.Printf("Runs:            %d (%d failed)\n", stats.Runs, stats.Errors)
+	cmd.Printf("Deleted images:  %d\n", stats.DeletedImages)
+	cmd.Printf("Reclaimed:       %dMB\n", stats.ReclaimedBytes/(1024*1024))
+	if !stats.LastRunAt.IsZero() {
+		cmd.Printf("Last run at:     %s\n", stats.LastRunAt)
+	}
+
+	return nil
+}
@@ -2,30 +2,40 @@ package instance
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/cmd/ravel/util"
 	"github.com/spf13/cobra"
 )
 
 func newGetInstanceLogsCmd() *cobra.Command {
 	var follow bool
+	var since time.Duration
+	var opts api.LogsOptions
 
 	var getLogsCmd = &cobra.Command{
 		Use:   "logs",
 		Short: "Get logs of an instance",
 		Long:  `Get logs of an instance`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGetInstanceLogs(cmd, args, follow)
+			if since > 0 {
+				opts.Since = time.Now().Add(-since).Unix()
+			}
+			return runGetInstanceLogs(cmd, args, follow, opts)
 		},
 	}
 
 	getLogsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow logs")
+	getLogsCmd.Flags().DurationVar(&since, "since", 0, "Only show the logs more recent than a duration (e.g. 10m)")
+	getLogsCmd.Flags().IntVarP(&opts.Tail, "tail", "n", 0, "Only show the last n lines")
+	getLogsCmd.Flags().IntVar(&opts.Limit, "limit", 0, "Maximum number of lines to show")
 
 	return getLogsCmd
 
 }
 
-func runGetInstanceLogs(cmd *cobra.Command, args []string, follow bool) error {
+func runGetInstanceLogs(cmd *cobra.Command, args []string, follow bool, opts api.LogsOptions) error {
 	if len(args) == 0 {
 		cmd.Help()
 		return fmt.Errorf("please specify a instanceId")
@@ -37,7 +47,7 @@ func runGetInstanceLogs(cmd *cobra.Command, args []string, follow bool) error {
 		return followInstanceLogs(cmd, instanceId)
 	}
 
-	return printInstanceLogs(cmd, instanceId)
+	return printInstanceLogs(cmd, instanceId, opts)
 }
 
 func followInstanceLogs(cmd *cobra.Command, instanceId string) error {
@@ -59,8 +69,8 @@ func followInstanceLogs(cmd *cobra.Command, instanceId string) error {
 	return nil
 }
 
-func printInstanceLogs(cmd *cobra.Command, instanceId string) error {
-	logs, err := util.GetDaemonClient(cmd).GetInstanceLogs(cmd.Context(), instanceId)
+func printInstanceLogs(cmd *cobra.Command, instanceId string, opts api.LogsOptions) error {
+	logs, err := util.GetDaemonClient(cmd).GetInstanceLogs(cmd.Context(), instanceId, opts)
 	if err != nil {
 		return fmt.Errorf("unable to get instance logs: %w", err)
 	}
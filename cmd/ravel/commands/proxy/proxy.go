@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/alexisbouchez/ravel/core/config"
+	"github.com/alexisbouchez/ravel/proxy"
+	"github.com/spf13/cobra"
+)
+
+type startProxyOptions struct {
+	config string
+}
+
+func NewProxyCmd() *cobra.Command {
+	var opts startProxyOptions
+
+	var startCmd = &cobra.Command{
+		Use:   "proxy",
+		Short: "Start the HTTP edge proxy",
+		Long:  `Start the HTTP edge proxy which routes <gateway-name>.<domain> to the machines of the gateway fleet.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return startProxy(cmd.Context(), opts)
+		},
+	}
+
+	startCmd.Flags().StringVarP(&opts.config, "config", "c", "/etc/ravel/ravel.toml", "Path to config file")
+	return startCmd
+}
+
+func startProxy(ctx context.Context, opt startProxyOptions) error {
+	slog.Info("Starting proxy")
+	ravelConfig, err := config.ReadFile(opt.config)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	p, err := proxy.New(ravelConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create proxy: %w", err)
+	}
+
+	err = p.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start proxy: %w", err)
+	}
+
+	p.Run(ctx)
+
+	return nil
+}
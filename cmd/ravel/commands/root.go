@@ -9,6 +9,7 @@ import (
 	"github.com/alexisbouchez/ravel/cmd/ravel/commands/disks"
 	"github.com/alexisbouchez/ravel/cmd/ravel/commands/images"
 	"github.com/alexisbouchez/ravel/cmd/ravel/commands/instance"
+	"github.com/alexisbouchez/ravel/cmd/ravel/commands/proxy"
 	"github.com/alexisbouchez/ravel/cmd/ravel/commands/server"
 	"github.com/alexisbouchez/ravel/cmd/ravel/commands/tls"
 	"github.com/spf13/cobra"
@@ -36,6 +37,7 @@ func NewRootCmd() *cobra.Command {
 		instance.NewInstanceCmd(),
 		daemon.NewDaemonCmd(),
 		server.NewServerCmd(),
+		proxy.NewProxyCmd(),
 		db.NewDBCmd(),
 		images.NewImagesCmd(),
 		tls.NewTLSCommand(),
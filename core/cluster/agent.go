@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
@@ -15,24 +16,119 @@ type PutMachineOptions struct {
 	EnableGateway bool               `json:"enable_gateway"`
 }
 
+type ForkMachineOptions struct {
+	// Machines are created on the node of the forked machine, from a snapshot of its memory
+	Machines []PutMachineOptions `json:"machines"`
+}
+
+type UpdateMachineOptions struct {
+	InstanceId string             `json:"instance_id"`
+	Version    api.MachineVersion `json:"version"`
+}
+
+type CreateVolumeOptions struct {
+	Id     string `json:"id"`
+	SizeMB uint64 `json:"size_mb"`
+	// FromSnapshot creates the volume as a copy of a snapshot of another volume of the node
+	FromSnapshot *VolumeSnapshotRef `json:"from_snapshot,omitempty"`
+}
+
+type ResizeVolumeOptions struct {
+	SizeMB uint64 `json:"size_mb"`
+}
+
+type VolumeSnapshotRef struct {
+	VolumeId   string `json:"volume_id"`
+	SnapshotId string `json:"snapshot_id"`
+}
+
+// PullOptions locates the agent a snapshot or a volume is pulled from.
+type PullOptions struct {
+	Source string `json:"source"` // address of the agent API of the source node
+	Token  string `json:"token"`  // token of the transfer granted on the source node
+}
+
+type PullVolumeOptions struct {
+	Source string `json:"source"`
+	Token  string `json:"token"`
+	SizeMB uint64 `json:"size_mb"`
+}
+
+// TransferGrant allows the agent of a node to pull a snapshot or a volume of another node, or to
+// migrate a machine to it, with a token.
+type TransferGrant struct {
+	Node  string `json:"node"` // id of the other node of the transfer
+	Token string `json:"token"`
+}
+
+// MigrateMachineOptions moves a running machine to another node without stopping it.
+type MigrateMachineOptions struct {
+	Target string `json:"target"` // address of the agent API of the target node
+	Token  string `json:"token"`  // token of the migration granted on the target node
+	// Machine confirms the allocation placed before on the target node for the machine
+	Machine PutMachineOptions `json:"machine"`
+}
+
 type Agent interface {
 	// PutMachine confirm an allocation placed before on the agent
 	// It returns the machine instance created on the agent
 	// The agent should then create an instance of the machine and start it
 	// if the start flag is set to true
 	PutMachine(ctx context.Context, opt PutMachineOptions) (*MachineInstance, error)
+	// UpdateMachine replaces the machine instance by a new one created
+	// from the given version, keeping the machine state
+	UpdateMachine(ctx context.Context, machineId string, opt UpdateMachineOptions) error
 	StartMachine(ctx context.Context, machineId string) error
 	StopMachine(ctx context.Context, machineId string, opt *api.StopConfig) error
 	MachineExec(ctx context.Context, machineId string, cmd []string, timeout time.Duration) (*api.ExecResult, error)
+	// MachineExecStream starts a streamed exec or attaches to it, see api.ExecStreamRequest.
+	MachineExecStream(ctx context.Context, machineId string, req api.ExecStreamRequest) (io.ReadCloser, error)
+	// MachineExecSession opens an interactive exec session, see api.ExecSessionOptions.
+	MachineExecSession(ctx context.Context, machineId string) (io.ReadWriteCloser, error)
 	DestroyMachine(ctx context.Context, machineId string, force bool) error
 	SubscribeToMachineLogs(ctx context.Context, id string) ([]*api.LogEntry, <-chan *api.LogEntry, error)
-	GetMachineLogs(ctx context.Context, id string) ([]*api.LogEntry, error)
+	GetMachineLogs(ctx context.Context, id string, opts api.LogsOptions) ([]*api.LogEntry, error)
 	WaitForMachineStatus(ctx context.Context, id string, status api.MachineStatus, timeout uint) error
 
 	EnableMachineGateway(ctx context.Context, id string) error
 	DisableMachineGateway(ctx context.Context, id string) error
 
+	// CreateVolume creates the disk of a volume on the agent node
+	CreateVolume(ctx context.Context, opt CreateVolumeOptions) error
+	DestroyVolume(ctx context.Context, id string) error
+	// ResizeVolume grows a volume and its filesystem, online if it is mounted by a running machine
+	ResizeVolume(ctx context.Context, id string, sizeMB uint64) error
+	CreateVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error
+	DeleteVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error
+	// RestoreVolumeSnapshot rolls a detached volume back to a snapshot, the more recent snapshots are destroyed
+	RestoreVolumeSnapshot(ctx context.Context, volumeId string, snapshotId string) error
+	// PullVolume copies a detached volume of another node on the agent node
+	PullVolume(ctx context.Context, volumeId string, opt PullVolumeOptions) error
+	// GrantVolumeTransfer allows a node to pull a volume of the agent node
+	GrantVolumeTransfer(ctx context.Context, volumeId string, grant TransferGrant) error
+
 	// Sandbox fast start methods for AI workloads
-	MachineSnapshot(ctx context.Context, machineId string, snapshotId string) error
+	MachineSnapshot(ctx context.Context, machineId string, snapshotId string) (*api.MachineSnapshot, error)
 	MachineRestore(ctx context.Context, machineId string, snapshotId string) error
+	// ForkMachine creates running machines from a memory snapshot of a running machine,
+	// it returns the machine instances created on the agent
+	ForkMachine(ctx context.Context, machineId string, opt ForkMachineOptions) ([]MachineInstance, error)
+	// DeleteMachineSnapshot deletes a machine snapshot from the node, the machine may have been destroyed
+	DeleteMachineSnapshot(ctx context.Context, snapshotId string) error
+	// PullMachineSnapshot copies a machine snapshot of another node on the agent node, an
+	// interrupted pull is resumed by the next one
+	PullMachineSnapshot(ctx context.Context, snapshotId string, opt PullOptions) error
+	// GrantMachineSnapshotTransfer allows a node to pull a machine snapshot of the agent node
+	GrantMachineSnapshotTransfer(ctx context.Context, snapshotId string, grant TransferGrant) error
+	// MigrateMachine live migrates a running machine to the target node, its volumes and its memory
+	// are copied while it runs. The machine is left stopped on the agent node once it runs on the
+	// target node.
+	MigrateMachine(ctx context.Context, machineId string, opt MigrateMachineOptions) error
+	// GrantMigrationTransfer allows a node to migrate a machine to the agent node, the migration
+	// is identified by the instance id of the machine on the agent node
+	GrantMigrationTransfer(ctx context.Context, instanceId string, grant TransferGrant) error
+
+	// CordonNode stops the agent from answering the placement requests, its machines keep running
+	CordonNode(ctx context.Context) error
+	UncordonNode(ctx context.Context) error
 }
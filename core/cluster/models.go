@@ -18,6 +18,15 @@ type Machine struct {
 	UpdatedAt      time.Time     `json:"updated_at"`
 	DestroyedAt    *time.Time    `json:"destroyed_at"`
 	Metadata       *api.Metadata `json:"metadata,omitempty"`
+	// Placement are the constraints the machine was placed with, applied again when it is migrated
+	Placement *api.PlacementConstraints `json:"placement,omitempty"`
+}
+
+// MachineSecret is the value of a secret referenced by a machine version, the agent fetches it
+// from the API server when the machine boots.
+type MachineSecret struct {
+	api.SecretRef
+	Value string `json:"value"`
 }
 
 type MachineInstance struct {
@@ -34,3 +43,26 @@ type MachineInstance struct {
 	UpdatedAt            time.Time          `json:"updated_at"`
 	EnableMachineGateway bool               `json:"enable_machine_gateway"`
 }
+
+// NetworkPeer is a machine reachable through the WireGuard tunnels of a private network.
+type NetworkPeer struct {
+	MachineId string `json:"machine_id"`
+	Node      string `json:"node"`
+	PublicKey string `json:"public_key"`
+	Endpoint  string `json:"endpoint"`
+	IP        string `json:"ip"`
+}
+
+// NetworkJoin is sent by an agent once the WireGuard interface of a machine is configured.
+type NetworkJoin struct {
+	Namespace string      `json:"namespace"`
+	Network   string      `json:"network"`
+	Peer      NetworkPeer `json:"peer"`
+}
+
+// NetworkPeers lists the peers of a private network, it is published to every agent each time they change.
+type NetworkPeers struct {
+	Namespace string        `json:"namespace"`
+	Network   string        `json:"network"`
+	Peers     []NetworkPeer `json:"peers"`
+}
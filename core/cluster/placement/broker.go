@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
-	"slices"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -13,16 +12,25 @@ import (
 var ErrPlacementFailed = errors.New("placement failed")
 
 type Broker struct {
-	nc *nats.Conn
+	nc     *nats.Conn
+	scorer Scorer
 }
 
-func NewBroker(nc *nats.Conn) *Broker {
+// NewBroker returns a broker ranking the offers with the scorer, binpack if it is nil.
+func NewBroker(nc *nats.Conn, scorer Scorer) *Broker {
+	if scorer == nil {
+		scorer = binpackScorer{}
+	}
+
 	return &Broker{
-		nc: nc,
+		nc:     nc,
+		scorer: scorer,
 	}
 }
 
-func (b *Broker) GetAvailableWorkers(req PlacementRequest) ([]PlacementResponse, error) {
+// GetAvailableWorkers returns the offers of the nodes answering the request, ranked by the scorer
+// or by the scorer of the broker if it is nil.
+func (b *Broker) GetAvailableWorkers(req PlacementRequest, scorer Scorer) ([]PlacementResponse, error) {
 	inbox := nats.NewInbox()
 
 	bytes, err := json.Marshal(req)
@@ -39,6 +47,11 @@ func (b *Broker) GetAvailableWorkers(req PlacementRequest) ([]PlacementResponse,
 			return
 		}
 
+		// agents unaware of the runtimes answer any request
+		if !req.MatchesRuntimes(response.Runtimes) {
+			return
+		}
+
 		offers = append(offers, response)
 	})
 
@@ -62,22 +75,9 @@ func (b *Broker) GetAvailableWorkers(req PlacementRequest) ([]PlacementResponse,
 		return nil, ErrPlacementFailed
 	}
 
-	return sortCandidates(offers), nil
-}
-
-func sortCandidates(candidates []PlacementResponse) []PlacementResponse {
-	var sorted []PlacementResponse
-	sorted = append(sorted, candidates...)
-
-	slices.SortStableFunc(sorted, func(a PlacementResponse, b PlacementResponse) int {
-		aScore := a.GetScore()
-		bScore := b.GetScore()
-
-		if aScore > bScore {
-			return -1
-		}
+	if scorer == nil {
+		scorer = b.scorer
+	}
 
-		return 1
-	})
-	return sorted
+	return scorer.Rank(offers), nil
 }
@@ -0,0 +1,118 @@
+package placement
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+// DefaultTopK is the number of nodes the random-top-k strategy picks from when it is not configured.
+const DefaultTopK = 3
+
+// Scorer ranks the placement offers, the machine is placed on the first one.
+type Scorer interface {
+	Rank(offers []PlacementResponse) []PlacementResponse
+}
+
+// NewScorer returns the scorer of the strategy, binpack if it is empty. topK is only used by the
+// random-top-k strategy, DefaultTopK if it is not positive.
+func NewScorer(strategy api.PlacementStrategy, topK int) (Scorer, error) {
+	switch strategy {
+	case "", api.PlacementStrategyBinpack:
+		return binpackScorer{}, nil
+	case api.PlacementStrategySpread:
+		return spreadScorer{}, nil
+	case api.PlacementStrategyLeastAllocated:
+		return leastAllocatedScorer{}, nil
+	case api.PlacementStrategyRandomTopK:
+		if topK <= 0 {
+			topK = DefaultTopK
+		}
+		return randomTopKScorer{k: topK, base: leastAllocatedScorer{}, intN: rand.IntN}, nil
+	default:
+		return nil, fmt.Errorf("unknown placement strategy %q", strategy)
+	}
+}
+
+// binpackScorer packs the machines on the most allocated nodes, see PlacementResponse.GetScore.
+type binpackScorer struct{}
+
+func (binpackScorer) Rank(offers []PlacementResponse) []PlacementResponse {
+	return sortOffers(offers, func(a, b PlacementResponse) int {
+		return compareFloat(b.GetScore(), a.GetScore())
+	})
+}
+
+// leastAllocatedScorer prefers the nodes with the lowest cpu and memory utilization after the placement.
+type leastAllocatedScorer struct{}
+
+func (leastAllocatedScorer) Rank(offers []PlacementResponse) []PlacementResponse {
+	return sortOffers(offers, compareUtilization)
+}
+
+// spreadScorer prefers the nodes running the fewest machines, then the least allocated ones.
+type spreadScorer struct{}
+
+func (spreadScorer) Rank(offers []PlacementResponse) []PlacementResponse {
+	return sortOffers(offers, func(a, b PlacementResponse) int {
+		if a.Machines != b.Machines {
+			return a.Machines - b.Machines
+		}
+		return compareUtilization(a, b)
+	})
+}
+
+// randomTopKScorer moves a random offer among the k first offers of the base scorer to the
+// front, to avoid placing the machines created at the same time on the same node.
+type randomTopKScorer struct {
+	k    int
+	base Scorer
+	intN func(n int) int
+}
+
+func (s randomTopKScorer) Rank(offers []PlacementResponse) []PlacementResponse {
+	ranked := s.base.Rank(offers)
+	if len(ranked) == 0 {
+		return ranked
+	}
+
+	chosen := s.intN(min(s.k, len(ranked)))
+	offer := ranked[chosen]
+	ranked = slices.Delete(ranked, chosen, chosen+1)
+	return slices.Insert(ranked, 0, offer)
+}
+
+func sortOffers(offers []PlacementResponse, cmp func(a, b PlacementResponse) int) []PlacementResponse {
+	sorted := slices.Clone(offers)
+	slices.SortStableFunc(sorted, cmp)
+	return sorted
+}
+
+func compareUtilization(a, b PlacementResponse) int {
+	return compareFloat(a.utilization(), b.utilization())
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// utilization is the mean of the cpu and memory utilization of the node after the placement.
+func (r PlacementResponse) utilization() float64 {
+	return (ratio(r.AllocatedAfter.CpusMHz, r.Allocatable.CpusMHz) + ratio(r.AllocatedAfter.MemoryMB, r.Allocatable.MemoryMB)) / 2
+}
+
+func ratio(allocated, allocatable int) float64 {
+	if allocatable <= 0 {
+		return 1
+	}
+	return float64(allocated) / float64(allocatable)
+}
@@ -0,0 +1,129 @@
+package placement
+
+import (
+	"testing"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+func offer(node string, cpus, memory int, machines int) PlacementResponse {
+	allocatable := api.Resources{CpusMHz: 10000, MemoryMB: 10000}
+	return PlacementResponse{
+		NodeId:          node,
+		Allocatable:     allocatable,
+		AllocatedBefore: api.Resources{CpusMHz: cpus - 1000, MemoryMB: memory - 1000},
+		AllocatedAfter:  api.Resources{CpusMHz: cpus, MemoryMB: memory},
+		Machines:        machines,
+	}
+}
+
+// syntheticNodes are a nearly full node, an empty node and a half allocated node running many
+// small machines.
+func syntheticNodes() []PlacementResponse {
+	return []PlacementResponse{
+		offer("full", 9000, 9000, 4),
+		offer("empty", 1000, 1000, 0),
+		offer("busy", 5000, 5000, 20),
+	}
+}
+
+func nodeIds(offers []PlacementResponse) []string {
+	ids := make([]string, len(offers))
+	for i, o := range offers {
+		ids[i] = o.NodeId
+	}
+	return ids
+}
+
+func TestScorers(t *testing.T) {
+	tests := []struct {
+		strategy api.PlacementStrategy
+		want     []string
+	}{
+		{"", []string{"full", "busy", "empty"}},
+		{api.PlacementStrategyBinpack, []string{"full", "busy", "empty"}},
+		{api.PlacementStrategyLeastAllocated, []string{"empty", "busy", "full"}},
+		{api.PlacementStrategySpread, []string{"empty", "full", "busy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			scorer, err := NewScorer(tt.strategy, 0)
+			if err != nil {
+				t.Fatalf("NewScorer() error = %v", err)
+			}
+
+			got := nodeIds(scorer.Rank(syntheticNodes()))
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Rank() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSpreadScorerTies(t *testing.T) {
+	offers := []PlacementResponse{
+		offer("a", 8000, 8000, 2),
+		offer("b", 2000, 2000, 2),
+	}
+
+	got := nodeIds(spreadScorer{}.Rank(offers))
+	if got[0] != "b" {
+		t.Errorf("Rank() = %v, want the least allocated node first", got)
+	}
+}
+
+func TestRandomTopKScorer(t *testing.T) {
+	nodes := append(syntheticNodes(), offer("quiet", 3000, 3000, 1))
+
+	for chosen := range 3 {
+		scorer := randomTopKScorer{k: 3, base: leastAllocatedScorer{}, intN: func(n int) int {
+			if n != 3 {
+				t.Fatalf("intN(%d), want 3", n)
+			}
+			return chosen
+		}}
+
+		ranked := scorer.Rank(nodes)
+		if len(ranked) != len(nodes) {
+			t.Fatalf("Rank() returned %d offers, want %d", len(ranked), len(nodes))
+		}
+
+		base := nodeIds(leastAllocatedScorer{}.Rank(nodes))
+		if ranked[0].NodeId != base[chosen] {
+			t.Errorf("Rank()[0] = %s, want %s", ranked[0].NodeId, base[chosen])
+		}
+	}
+
+	scorer, err := NewScorer(api.PlacementStrategyRandomTopK, 2)
+	if err != nil {
+		t.Fatalf("NewScorer() error = %v", err)
+	}
+	for range 100 {
+		first := scorer.Rank(nodes)[0].NodeId
+		if first != "empty" && first != "quiet" {
+			t.Fatalf("Rank()[0] = %s, want one of the 2 least allocated nodes", first)
+		}
+	}
+}
+
+func TestRandomTopKScorerFewOffers(t *testing.T) {
+	scorer, _ := NewScorer(api.PlacementStrategyRandomTopK, 5)
+
+	if got := scorer.Rank(nil); len(got) != 0 {
+		t.Errorf("Rank(nil) = %v, want no offers", got)
+	}
+
+	got := scorer.Rank([]PlacementResponse{offer("only", 1000, 1000, 0)})
+	if len(got) != 1 || got[0].NodeId != "only" {
+		t.Errorf("Rank() = %v, want the only offer", nodeIds(got))
+	}
+}
+
+func TestNewScorerUnknownStrategy(t *testing.T) {
+	if _, err := NewScorer("densest", 0); err == nil {
+		t.Error("NewScorer() expected an error for an unknown strategy")
+	}
+}
@@ -1,11 +1,37 @@
 package placement
 
-import "github.com/alexisbouchez/ravel/api"
+import (
+	"slices"
+
+	"github.com/alexisbouchez/ravel/api"
+)
 
 type PlacementRequest struct {
 	AllocationId string        `json:"allocation_id"`
 	Region       string        `json:"region"`
+	Node         string        `json:"node,omitempty"` // only this node may answer, used to place machines next to their volumes
 	Resources    api.Resources `json:"resources"`
+	// NodeLabels are the labels the node must have to answer
+	NodeLabels map[string]string `json:"node_labels,omitempty"`
+	// AvoidFleet excludes the nodes running machines of this fleet, to spread its machines across the nodes
+	AvoidFleet string `json:"avoid_fleet,omitempty"`
+	// Runtime the node must support to answer, any node answers if it is empty
+	Runtime api.Runtime `json:"runtime,omitempty"`
+}
+
+// MatchesNodeLabels reports whether a node with the given labels has all the labels required by the request.
+func (r *PlacementRequest) MatchesNodeLabels(labels map[string]string) bool {
+	for key, value := range r.NodeLabels {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesRuntimes reports whether a node supporting the given runtimes can run the machine of the request.
+func (r *PlacementRequest) MatchesRuntimes(runtimes []api.Runtime) bool {
+	return r.Runtime == "" || slices.Contains(runtimes, r.Runtime)
 }
 
 type PlacementResponse struct {
@@ -13,6 +39,8 @@ type PlacementResponse struct {
 	Allocatable     api.Resources `json:"allocatable"`
 	AllocatedBefore api.Resources `json:"allocated_before"`
 	AllocatedAfter  api.Resources `json:"allocated_after"`
+	Machines        int           `json:"machines"` // machines on the node before the placement
+	Runtimes        []api.Runtime `json:"runtimes,omitempty"`
 }
 
 func (r PlacementResponse) GetScore() float64 {
@@ -0,0 +1,59 @@
+package placement
+
+import (
+	"testing"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+func TestMatchesNodeLabels(t *testing.T) {
+	labels := map[string]string{"disk": "nvme", "zone": "a"}
+
+	tests := []struct {
+		name     string
+		required map[string]string
+		want     bool
+	}{
+		{"no required labels", nil, true},
+		{"matching label", map[string]string{"disk": "nvme"}, true},
+		{"all labels matching", map[string]string{"disk": "nvme", "zone": "a"}, true},
+		{"different value", map[string]string{"zone": "b"}, false},
+		{"missing label", map[string]string{"gpu": "true"}, false},
+		{"empty value of a missing label", map[string]string{"gpu": ""}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := PlacementRequest{NodeLabels: tt.required}
+			if got := req.MatchesNodeLabels(labels); got != tt.want {
+				t.Errorf("MatchesNodeLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesRuntimes(t *testing.T) {
+	runtimes := []api.Runtime{api.RuntimeCloudHypervisor, api.RuntimeContainerd}
+
+	tests := []struct {
+		name     string
+		runtime  api.Runtime
+		runtimes []api.Runtime
+		want     bool
+	}{
+		{"default runtime", "", runtimes, true},
+		{"default runtime of a node advertising none", "", nil, true},
+		{"supported runtime", api.RuntimeContainerd, runtimes, true},
+		{"unsupported runtime", api.RuntimeFirecracker, runtimes, false},
+		{"node advertising no runtime", api.RuntimeCloudHypervisor, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := PlacementRequest{Runtime: tt.runtime}
+			if got := req.MatchesRuntimes(tt.runtimes); got != tt.want {
+				t.Errorf("MatchesRuntimes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
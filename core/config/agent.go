@@ -3,13 +3,31 @@ package config
 import "github.com/alexisbouchez/ravel/api"
 
 type AgentConfig struct {
-	NodeId    string          `json:"node_id" toml:"node_id"`
-	Region    string          `json:"region" toml:"region"`
-	Address   string          `json:"address" toml:"address"`
-	Port      int             `json:"port" toml:"port"`
-	Resources api.Resources   `json:"resources" toml:"resources"`
-	TLS       *TLSConfig      `json:"tls" toml:"tls"`
-	BuildKit  *BuildKitConfig `json:"buildkit" toml:"buildkit"`
+	NodeId    string           `json:"node_id" toml:"node_id"`
+	Region    string           `json:"region" toml:"region"`
+	Address   string           `json:"address" toml:"address"`
+	Port      int              `json:"port" toml:"port"`
+	Resources api.Resources    `json:"resources" toml:"resources"`
+	TLS       *TLSConfig       `json:"tls" toml:"tls"`
+	BuildKit  *BuildKitConfig  `json:"buildkit" toml:"buildkit"`
+	Wireguard *WireguardConfig `json:"wireguard" toml:"wireguard"`
+	// Labels of the node, matched against the node labels required by the machines placement constraints
+	Labels map[string]string `json:"labels" toml:"labels"`
+	// ServerAPI is the API server the secrets of the machines are fetched from when they boot
+	ServerAPI *AgentServerAPIConfig `json:"server_api" toml:"server_api"`
+}
+
+// AgentServerAPIConfig is the API server reached by the agent, with the agent certificate if
+// its URL is https.
+type AgentServerAPIConfig struct {
+	URL         string `json:"url" toml:"url"`
+	BearerToken string `json:"bearer_token" toml:"bearer_token"` // bearer token of the API server, if it does not verify the agent certificate
+}
+
+// WireguardConfig holds the configuration of the machines private networks tunnels
+type WireguardConfig struct {
+	Endpoint string     `json:"endpoint" toml:"endpoint"` // Address reachable by the other agents, defaults to the agent address
+	Ports    *PortRange `json:"ports" toml:"ports"`       // UDP ports of the Wireguard interfaces, one per machine and network
 }
 
 // BuildKitConfig holds configuration for the BuildKit image builder
@@ -13,10 +13,13 @@ import (
 
 const LOGS_DIRECTORY = "/var/log/ravel"
 const DAEMON_DB_PATH = "/var/lib/ravel/daemon.db"
+const SNAPSHOTS_DIRECTORY = "/var/lib/ravel/snapshots"
+const TRANSFERS_DIRECTORY = "/var/lib/ravel/transfers"
 
 type RavelConfig struct {
 	Daemon     DaemonConfig              `json:"daemon" toml:"daemon"`
 	Server     ServerConfig              `json:"server" toml:"server"`
+	Proxy      *ProxyConfig              `json:"proxy" toml:"proxy"`
 	Nats       *NatsConfig               `json:"nats" toml:"nats"`
 	Registries registry.RegistriesConfig `json:"registries" toml:"registries"`
 }
@@ -0,0 +1,18 @@
+package config
+
+type ProxyConfig struct {
+	PostgresURL     string      `json:"postgres_url" toml:"postgres_url"`
+	Domain          string      `json:"domain" toml:"domain"`                     // gateways are served on <gateway-name>.<domain>
+	HTTPAddress     string      `json:"http_address" toml:"http_address"`         // defaults to :80
+	HTTPSAddress    string      `json:"https_address" toml:"https_address"`       // defaults to :443, only used when acme is configured
+	ListenIP        string      `json:"listen_ip" toml:"listen_ip"`               // IP on which the tcp and udp gateways ports are opened, defaults to all interfaces
+	RefreshInterval int         `json:"refresh_interval" toml:"refresh_interval"` // in seconds, defaults to 5
+	ACME            *ACMEConfig `json:"acme" toml:"acme"`
+}
+
+// ACMEConfig configures the automatic issuance of the gateways certificates.
+type ACMEConfig struct {
+	Email        string `json:"email" toml:"email"`
+	DirectoryURL string `json:"directory_url" toml:"directory_url"` // defaults to Let's Encrypt production directory
+	CaFile       string `json:"ca_file" toml:"ca_file"`             // CA used to verify the ACME directory, for local stand-ins like Pebble
+}
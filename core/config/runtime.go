@@ -1,22 +1,89 @@
 package config
 
-// RuntimeType specifies which VM runtime to use.
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// RuntimeType specifies which runtime runs the instances.
 type RuntimeType string
 
 const (
 	RuntimeTypeCloudHypervisor RuntimeType = "cloudhypervisor"
 	RuntimeTypeFirecracker     RuntimeType = "firecracker"
+	// RuntimeTypeContainerd runs the instances as runc containers instead of microVMs
+	RuntimeTypeContainerd RuntimeType = "containerd"
 )
 
 type RuntimeConfig struct {
-	// RuntimeType specifies which VM runtime to use: "cloudhypervisor" (default) or "firecracker"
-	RuntimeType           RuntimeType `json:"runtime_type" toml:"runtime_type"`
-	CloudHypervisorBinary string      `json:"cloud_hypervisor_binary" toml:"cloud_hypervisor_binary"`
-	FirecrackerBinary     string      `json:"firecracker_binary" toml:"firecracker_binary"`
-	JailerBinary          string      `json:"jailer_binary" toml:"jailer_binary"`
-	InitBinary            string      `json:"init_binary" toml:"init_binary"`
-	LinuxKernel           string      `json:"linux_kernel" toml:"linux_kernel"`
-	ZFSPool               string      `json:"zfs_pool" toml:"zfs_pool"`
+	// RuntimeType specifies which runtime to use: "cloudhypervisor" (default), "firecracker" or "containerd"
+	RuntimeType RuntimeType `json:"runtime_type" toml:"runtime_type"`
+	// Runtimes lists the other runtimes the node supports, the machines choose one in their guest config
+	Runtimes              []RuntimeType `json:"runtimes" toml:"runtimes"`
+	CloudHypervisorBinary string        `json:"cloud_hypervisor_binary" toml:"cloud_hypervisor_binary"`
+	FirecrackerBinary     string        `json:"firecracker_binary" toml:"firecracker_binary"`
+	JailerBinary          string        `json:"jailer_binary" toml:"jailer_binary"`
+	InitBinary            string        `json:"init_binary" toml:"init_binary"`
+	LinuxKernel           string        `json:"linux_kernel" toml:"linux_kernel"`
+	ZFSPool               string        `json:"zfs_pool" toml:"zfs_pool"`
+	ImageGC               ImageGCConfig `json:"image_gc" toml:"image_gc"`
+}
+
+// ImageGCConfig is the garbage collection of the images no instance uses. When the disk holding
+// the unpacked images is used above the high threshold, the least recently used images are deleted
+// until it is used below the low threshold.
+type ImageGCConfig struct {
+	Disabled      bool   `json:"disabled" toml:"disabled"`
+	Interval      int    `json:"interval" toml:"interval"`             // in seconds, defaults to 300
+	HighThreshold int    `json:"high_threshold" toml:"high_threshold"` // disk usage percentage, defaults to 85
+	LowThreshold  int    `json:"low_threshold" toml:"low_threshold"`   // disk usage percentage, defaults to 70
+	MinAge        int    `json:"min_age" toml:"min_age"`               // in seconds an image is kept after it has been pulled or last used, defaults to 600
+	Path          string `json:"path" toml:"path"`                     // filesystem holding the images, defaults to /var/lib/containerd
+	ThinPool      string `json:"thin_pool" toml:"thin_pool"`           // device mapper thin pool of the devmapper snapshotter, its usage is watched instead of the filesystem
+}
+
+func (c ImageGCConfig) GetInterval() time.Duration {
+	if c.Interval <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(c.Interval) * time.Second
+}
+
+func (c ImageGCConfig) GetHighThreshold() int {
+	if c.HighThreshold == 0 {
+		return 85
+	}
+	return c.HighThreshold
+}
+
+func (c ImageGCConfig) GetLowThreshold() int {
+	if c.LowThreshold == 0 {
+		return 70
+	}
+	return c.LowThreshold
+}
+
+func (c ImageGCConfig) GetMinAge() time.Duration {
+	if c.MinAge <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.MinAge) * time.Second
+}
+
+func (c ImageGCConfig) GetPath() string {
+	if c.Path == "" {
+		return "/var/lib/containerd"
+	}
+	return c.Path
+}
+
+func (c ImageGCConfig) Validate() error {
+	high, low := c.GetHighThreshold(), c.GetLowThreshold()
+	if high < 1 || high > 100 || low < 0 || low >= high {
+		return fmt.Errorf("invalid image gc thresholds: high %d, low %d", high, low)
+	}
+	return nil
 }
 
 // GetRuntimeType returns the runtime type, defaulting to CloudHypervisor if not specified.
@@ -26,3 +93,14 @@ func (c *RuntimeConfig) GetRuntimeType() RuntimeType {
 	}
 	return c.RuntimeType
 }
+
+// GetRuntimeTypes returns the runtimes supported by the node, the default one first.
+func (c *RuntimeConfig) GetRuntimeTypes() []RuntimeType {
+	types := []RuntimeType{c.GetRuntimeType()}
+	for _, t := range c.Runtimes {
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
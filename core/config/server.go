@@ -1,5 +1,11 @@
 package config
 
+import (
+	"fmt"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
 type VCpusMemory struct {
 	VCpus         int   `json:"vcpus" toml:"vcpus"`
 	MemoryConfigs []int `json:"memory_configs" toml:"memory_configs"`
@@ -16,6 +22,35 @@ type ServerConfig struct {
 	API                ServerAPIConfig                      `json:"api" toml:"api"`
 	MainRegistry       string                               `json:"main_registry" toml:"main_registry"`
 	NamespacedRegistry bool                                 `json:"namespaced_registry" toml:"namespaced_registry"` // if true, ravel doesnt pull images from main registry if the repository name is different from the namespace
+	GatewaysDomain     string                               `json:"gateways_domain" toml:"gateways_domain"`         // domain on which the proxy serves the gateways, custom domains can CNAME to <gateway-name>.<gateways_domain>
+	GatewayPorts       *PortRange                           `json:"gateway_ports" toml:"gateway_ports"`             // public ports allocated to tcp and udp gateways, they are disabled if unset
+	Placement          PlacementConfig                      `json:"placement" toml:"placement"`
+	Secrets            *SecretsConfig                       `json:"secrets" toml:"secrets"` // the secrets are stored in plaintext if unset
+}
+
+// SecretsConfig is the master key wrapping the data keys which encrypt the secrets of the namespaces.
+type SecretsConfig struct {
+	Provider         string   `json:"provider" toml:"provider"`                     // local if empty, the only provider for now
+	KeyFile          string   `json:"key_file" toml:"key_file"`                     // file holding the base64 encoded 32 bytes master key
+	PreviousKeyFiles []string `json:"previous_key_files" toml:"previous_key_files"` // previous master keys, until the data keys are rotated
+}
+
+// PlacementConfig is the placement strategy of the fleets without their own strategy.
+type PlacementConfig struct {
+	Strategy api.PlacementStrategy `json:"strategy" toml:"strategy"` // binpack if empty
+	TopK     int                   `json:"top_k" toml:"top_k"`       // nodes the random-top-k strategy picks from
+}
+
+type PortRange struct {
+	Min int `json:"min" toml:"min"`
+	Max int `json:"max" toml:"max"`
+}
+
+func (r PortRange) Validate() error {
+	if r.Min < 1 || r.Max > 65535 || r.Min > r.Max {
+		return fmt.Errorf("invalid port range %d-%d", r.Min, r.Max)
+	}
+	return nil
 }
 
 type ServerAPIConfig struct {
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
@@ -36,10 +37,37 @@ func (i *InstanceOptions) Validate() error {
 }
 
 type DiskOptions struct {
-	Id     string `json:"id"`
+	Id           string           `json:"id"`
+	SizeMB       uint64           `json:"size_mb"`
+	FromSnapshot *DiskSnapshotRef `json:"from_snapshot,omitempty"` // the disk is a copy of the snapshot and has its size
+}
+
+type ResizeDiskOptions struct {
 	SizeMB uint64 `json:"size_mb"`
 }
 
+type DiskSnapshotRef struct {
+	Disk     string `json:"disk"`
+	Snapshot string `json:"snapshot"`
+}
+
+type DiskSnapshotOptions struct {
+	Id string `json:"id"`
+}
+
+// InstanceSnapshot is a memory snapshot of an instance, stored on the node.
+type InstanceSnapshot struct {
+	Id        string `json:"id"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// InstanceMigrationOptions configures the instance receiving the running VM of an instance of
+// another node.
+type InstanceMigrationOptions struct {
+	Devices      []string `json:"devices"`       // paths of the disks in the source VMM, the root filesystem first
+	ResetNetwork bool     `json:"reset_network"` // the instance has not been given the network of the source one
+}
+
 type Daemon interface {
 	CreateInstance(ctx context.Context, opt InstanceOptions) (*instance.Instance, error)
 	GetInstance(ctx context.Context, id string) (*instance.Instance, error)
@@ -47,22 +75,39 @@ type Daemon interface {
 	DestroyInstance(ctx context.Context, id string) error
 	StartInstance(ctx context.Context, id string) error
 	// StartInstanceFromSnapshot starts an instance by restoring from a snapshot (fast cold start)
-	StartInstanceFromSnapshot(ctx context.Context, id string, globalSnapshotPath, jailSnapshotPath string) error
+	StartInstanceFromSnapshot(ctx context.Context, id string, snapshotId string) error
 	StopInstance(ctx context.Context, id string, opt *api.StopConfig) error
 	InstanceExec(ctx context.Context, id string, cmd []string, timeout time.Duration) (*api.ExecResult, error)
-	GetInstanceLogs(ctx context.Context, id string) ([]*api.LogEntry, error)
+	// InstanceExecStream starts a streamed exec or attaches to it, see api.ExecStreamRequest.
+	InstanceExecStream(ctx context.Context, id string, req api.ExecStreamRequest) (io.ReadCloser, error)
+	// InstanceExecSession opens an interactive exec session, see api.ExecSessionOptions.
+	InstanceExecSession(ctx context.Context, id string) (io.ReadWriteCloser, error)
+	GetInstanceLogs(ctx context.Context, id string, opts api.LogsOptions) ([]*api.LogEntry, error)
 	SubscribeToInstanceLogs(ctx context.Context, id string) ([]*api.LogEntry, <-chan *api.LogEntry, error)
 
 	// Sandbox fast start methods for AI workloads
-	InstanceSnapshot(ctx context.Context, id string, path string) error
-	InstanceRestore(ctx context.Context, id string, path string) error
+	InstanceSnapshot(ctx context.Context, id string, snapshotId string) (*InstanceSnapshot, error)
+	InstanceRestore(ctx context.Context, id string, snapshotId string) error
+	DeleteInstanceSnapshot(ctx context.Context, snapshotId string) error
 
 	DeleteImage(ctx context.Context, ref string) error
 	ListImages(ctx context.Context) ([]images.Image, error)
 	PullImage(ctx context.Context, opt ImagePullOptions) (*images.Image, error)
+	// PruneImages deletes all the images no instance uses.
+	PruneImages(ctx context.Context) (*ImagePruneResult, error)
+	// ImageGCStats returns the counters of the garbage collection of the images.
+	ImageGCStats(ctx context.Context) (*ImageGCStats, error)
 
 	CreateDisk(ctx context.Context, opt DiskOptions) (*disks.Disk, error)
 	GetDisk(ctx context.Context, id string) (*disks.Disk, error)
 	ListDisks(ctx context.Context) ([]disks.Disk, error)
 	DestroyDisk(ctx context.Context, id string) error
+	// ResizeDisk grows a disk and its filesystem, without downtime if it is mounted by a running instance
+	ResizeDisk(ctx context.Context, id string, opt ResizeDiskOptions) (*disks.Disk, error)
+
+	CreateDiskSnapshot(ctx context.Context, disk string, opt DiskSnapshotOptions) (*disks.DiskSnapshot, error)
+	ListDiskSnapshots(ctx context.Context, disk string) ([]disks.DiskSnapshot, error)
+	DeleteDiskSnapshot(ctx context.Context, disk string, id string) error
+	// RestoreDiskSnapshot rolls a detached disk back to a snapshot, the more recent snapshots are deleted
+	RestoreDiskSnapshot(ctx context.Context, disk string, id string) error
 }
@@ -2,16 +2,25 @@ package daemon
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/core/instance"
 	"github.com/alexisbouchez/ravel/core/registry"
+	"github.com/alexisbouchez/ravel/initd"
+	imagegc "github.com/alexisbouchez/ravel/runtime/images"
 	"github.com/containerd/containerd/v2/core/images"
 )
 
 type Image = images.Image
 
+// ImagePruneResult lists the images deleted by a prune and the disk space it reclaimed.
+type ImagePruneResult = imagegc.GCResult
+
+// ImageGCStats are the counters of the garbage collection of the images of the node.
+type ImageGCStats = imagegc.GCStats
+
 type ImagePullOptions struct {
 	Ref  string                    `json:"ref"`
 	Auth registry.RegistriesConfig `json:"auth,omitempty"`
@@ -23,20 +32,36 @@ type Runtime interface {
 	ListInstances() []instance.Instance
 	StartInstance(ctx context.Context, id string) error
 	// StartInstanceFromSnapshot starts an instance by restoring from a snapshot (fast cold start)
-	StartInstanceFromSnapshot(ctx context.Context, id string, globalSnapshotPath, jailSnapshotPath string) error
+	StartInstanceFromSnapshot(ctx context.Context, id string, snapshotId string) error
+	// SetInstanceSecrets delivers the secrets to a started instance waiting for them, its
+	// workload starts once they are received
+	SetInstanceSecrets(ctx context.Context, id string, secrets initd.Secrets) error
 	StopInstance(ctx context.Context, id string, opt *api.StopConfig) error
-	GetInstanceLogs(id string) ([]*api.LogEntry, error)
+	GetInstanceLogs(id string, opts api.LogsOptions) ([]*api.LogEntry, error)
 	SubscribeToInstanceLogs(ctx context.Context, id string) ([]*api.LogEntry, <-chan *api.LogEntry, error)
+	// RemoveMachineLogs deletes the logs shared by the instances of a destroyed machine
+	RemoveMachineLogs(machineId string) error
 	WatchInstanceState(ctx context.Context, id string) (<-chan instance.State, error)
 
 	DeleteImage(ctx context.Context, ref string) error
 	DestroyInstance(ctx context.Context, id string) error
 	InstanceExec(ctx context.Context, id string, cmd []string, timeout time.Duration) (*api.ExecResult, error)
+	InstanceExecStream(ctx context.Context, id string, req api.ExecStreamRequest) (io.ReadCloser, error)
+	InstanceExecSession(ctx context.Context, id string) (io.ReadWriteCloser, error)
 	ListImages(ctx context.Context) ([]images.Image, error)
-	PruneImages(ctx context.Context) error
+	// PruneImages deletes all the images no instance uses
+	PruneImages(ctx context.Context) (*ImagePruneResult, error)
+	ImageGCStats() ImageGCStats
 	PullImage(ctx context.Context, opt ImagePullOptions) (*images.Image, error)
 
 	// Sandbox fast start methods for AI workloads
-	InstanceSnapshot(ctx context.Context, id string, path string) error
-	InstanceRestore(ctx context.Context, id string, path string) error
+	InstanceSnapshot(ctx context.Context, id string, snapshotId string) (*InstanceSnapshot, error)
+	InstanceRestore(ctx context.Context, id string, snapshotId string) error
+	DeleteInstanceSnapshot(ctx context.Context, snapshotId string) error
+
+	// SendInstanceMigration sends the running VM of an instance to another node, the instance
+	// exits once it is sent
+	SendInstanceMigration(ctx context.Context, id string, conn io.ReadWriteCloser) error
+	// ReceiveInstanceMigration starts a created instance from the VM sent by SendInstanceMigration
+	ReceiveInstanceMigration(ctx context.Context, id string, opt InstanceMigrationOptions, conn io.ReadWriteCloser) error
 }
@@ -57,6 +57,8 @@ type InstanceConfig struct {
 	Stop   *api.StopConfig     `json:"stop,omitempty"`
 	Env    []string            `json:"env,omitempty"`
 	Mounts []Mount             `json:"mounts,omitempty"`
+	// WaitSecrets makes the init wait for the secrets delivered by the agent before starting the workload
+	WaitSecrets bool `json:"wait_secrets,omitempty"`
 }
 
 func (ic InstanceConfig) GetDisks() []string {
@@ -71,4 +73,6 @@ type InstanceGuestConfig struct {
 	MemoryMB int `json:"memory_mb" minimum:"1"` // in MB
 	VCpus    int `json:"vcpus" minimum:"1"`     // number of virtual CPUs (correspond to vm vcpus)
 	CpusMHz  int `json:"cpus_mhz" minimum:"1"`  // in MHz
+	// Runtime running the instance, the runtime sets the default one of the node when it is empty
+	Runtime api.Runtime `json:"runtime,omitempty"`
 }
@@ -127,6 +127,11 @@ func WithTUN() Opt {
 }
 
 func WithBlockDevice(device string) Opt {
+	return WithBlockDeviceAt(device, device)
+}
+
+// WithBlockDeviceAt creates the block device at another path in the jail.
+func WithBlockDeviceAt(device string, path string) Opt {
 	return func(o *options) error {
 		stat, err := os.Stat(device)
 		if err != nil {
@@ -135,7 +140,7 @@ func WithBlockDevice(device string) Opt {
 		rdev := stat.Sys().(*syscall.Stat_t).Rdev
 
 		o.devices = append(o.devices, Device{
-			Path: device,
+			Path: path,
 			Mode: 0600 | unix.S_IFBLK,
 			Dev:  rdev,
 		})
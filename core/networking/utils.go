@@ -1,6 +1,7 @@
 package networking
 
 import (
+	"encoding/binary"
 	"net"
 	"net/netip"
 )
@@ -44,3 +45,39 @@ func PrevBy(ip netip.Addr, by int) netip.Addr {
 func Overlap(a, b *net.IPNet) bool {
 	return a.Contains(b.IP) || b.Contains(a.IP)
 }
+
+// broadcastAddress returns the last address of an IPv4 prefix.
+func broadcastAddress(prefix netip.Prefix) netip.Addr {
+	a := prefix.Masked().Addr().As4()
+	last := binary.BigEndian.Uint32(a[:]) | (1<<(32-prefix.Bits()) - 1)
+	binary.BigEndian.PutUint32(a[:], last)
+	return netip.AddrFrom4(a)
+}
+
+// IsHostAddress reports whether addr can be assigned to a host of an IPv4 prefix,
+// that is it is neither the network nor the broadcast address.
+func IsHostAddress(prefix netip.Prefix, addr netip.Addr) bool {
+	prefix = prefix.Masked()
+	if !prefix.Addr().Is4() || !prefix.Contains(addr) {
+		return false
+	}
+
+	return addr != prefix.Addr() && addr != broadcastAddress(prefix)
+}
+
+// FirstFreeAddress returns the lowest host address of an IPv4 prefix which is not used.
+func FirstFreeAddress(prefix netip.Prefix, used map[netip.Addr]bool) (netip.Addr, bool) {
+	prefix = prefix.Masked()
+	if !prefix.Addr().Is4() {
+		return netip.Addr{}, false
+	}
+
+	broadcast := broadcastAddress(prefix)
+	for addr := prefix.Addr().Next(); addr.Less(broadcast); addr = addr.Next() {
+		if !used[addr] {
+			return addr, true
+		}
+	}
+
+	return netip.Addr{}, false
+}
@@ -0,0 +1,47 @@
+package networking
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsHostAddress(t *testing.T) {
+	prefix := netip.MustParsePrefix("10.0.1.0/24")
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "10.0.1.1", want: true},
+		{addr: "10.0.1.254", want: true},
+		{addr: "10.0.1.0", want: false},
+		{addr: "10.0.1.255", want: false},
+		{addr: "10.0.2.1", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsHostAddress(prefix, netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsHostAddress(%s, %s) = %v, want %v", prefix, tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestFirstFreeAddress(t *testing.T) {
+	prefix := netip.MustParsePrefix("10.0.1.0/30")
+
+	addr, ok := FirstFreeAddress(prefix, map[netip.Addr]bool{})
+	if !ok || addr != netip.MustParseAddr("10.0.1.1") {
+		t.Fatalf("FirstFreeAddress() = %s, %v, want 10.0.1.1", addr, ok)
+	}
+
+	used := map[netip.Addr]bool{netip.MustParseAddr("10.0.1.1"): true}
+	addr, ok = FirstFreeAddress(prefix, used)
+	if !ok || addr != netip.MustParseAddr("10.0.1.2") {
+		t.Fatalf("FirstFreeAddress() = %s, %v, want 10.0.1.2", addr, ok)
+	}
+
+	used[netip.MustParseAddr("10.0.1.2")] = true
+	if addr, ok := FirstFreeAddress(prefix, used); ok {
+		t.Fatalf("FirstFreeAddress() = %s, want no address left", addr)
+	}
+}
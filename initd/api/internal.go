@@ -2,10 +2,14 @@ package api
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/alexisbouchez/ravel/initd"
 	"github.com/alexisbouchez/ravel/initd/environment"
+	"github.com/alexisbouchez/ravel/initd/exec"
+	"github.com/alexisbouchez/ravel/internal/streamutil"
 	"github.com/danielgtaylor/huma/v2"
+	"golang.org/x/net/websocket"
 )
 
 type InternalEndpoint struct {
@@ -26,6 +30,48 @@ func (e *InternalEndpoint) registerRoutes(api huma.API) {
 		OperationID: "signal",
 		Description: "Send a signal to the container main process",
 	}, e.signal)
+
+	huma.Register(api, huma.Operation{
+		Path:        "/filesystems/grow",
+		Method:      "POST",
+		OperationID: "growFilesystem",
+		Description: "Grow a mounted filesystem to the size of its device",
+	}, e.growFilesystem)
+
+	huma.Register(api, huma.Operation{
+		Path:        "/filesystems/freeze",
+		Method:      "POST",
+		OperationID: "freezeFilesystems",
+		Description: "Flush and suspend the writes to the filesystems of the disks",
+	}, e.freezeFilesystems)
+
+	huma.Register(api, huma.Operation{
+		Path:        "/filesystems/thaw",
+		Method:      "POST",
+		OperationID: "thawFilesystems",
+		Description: "Resume the writes to the frozen filesystems",
+	}, e.thawFilesystems)
+
+	huma.Register(api, huma.Operation{
+		Path:        "/identity/reset",
+		Method:      "POST",
+		OperationID: "resetIdentity",
+		Description: "Reset the hostname, the network and the random generator of a VM restored from a snapshot",
+	}, e.resetIdentity)
+
+	huma.Register(api, huma.Operation{
+		Path:        "/secrets",
+		Method:      "POST",
+		OperationID: "setSecrets",
+		Description: "Deliver the secrets of the machine and start the container main process",
+	}, e.setSecrets)
+
+	huma.Register(api, huma.Operation{
+		Path:        "/exec/tty",
+		Method:      "GET",
+		OperationID: "execSession",
+		Description: "Run an interactive command attached to a pseudo terminal over a WebSocket",
+	}, e.execSession)
 }
 
 type WaitRequest struct{}
@@ -51,3 +97,83 @@ func (e *InternalEndpoint) signal(ctx context.Context, req *SignalRequest) (*Sig
 	}
 	return &SignalResponse{}, nil
 }
+
+type GrowFilesystemRequest struct {
+	Body initd.GrowFilesystemOptions
+}
+
+type GrowFilesystemResponse struct{}
+
+func (e *InternalEndpoint) growFilesystem(ctx context.Context, req *GrowFilesystemRequest) (*GrowFilesystemResponse, error) {
+	err := environment.GrowFilesystem(req.Body.DevicePath)
+	if err != nil {
+		return nil, err
+	}
+	return &GrowFilesystemResponse{}, nil
+}
+
+type FreezeFilesystemsRequest struct{}
+
+type FreezeFilesystemsResponse struct{}
+
+func (e *InternalEndpoint) freezeFilesystems(ctx context.Context, req *FreezeFilesystemsRequest) (*FreezeFilesystemsResponse, error) {
+	err := environment.FreezeFilesystems()
+	if err != nil {
+		return nil, err
+	}
+	return &FreezeFilesystemsResponse{}, nil
+}
+
+type ThawFilesystemsRequest struct{}
+
+type ThawFilesystemsResponse struct{}
+
+func (e *InternalEndpoint) thawFilesystems(ctx context.Context, req *ThawFilesystemsRequest) (*ThawFilesystemsResponse, error) {
+	err := environment.ThawFilesystems()
+	if err != nil {
+		return nil, err
+	}
+	return &ThawFilesystemsResponse{}, nil
+}
+
+type ResetIdentityRequest struct {
+	Body initd.ResetIdentityOptions
+}
+
+type ResetIdentityResponse struct{}
+
+func (e *InternalEndpoint) resetIdentity(ctx context.Context, req *ResetIdentityRequest) (*ResetIdentityResponse, error) {
+	err := environment.ResetIdentity(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &ResetIdentityResponse{}, nil
+}
+
+type SetSecretsRequest struct {
+	Body initd.Secrets
+}
+
+type SetSecretsResponse struct{}
+
+func (e *InternalEndpoint) setSecrets(ctx context.Context, req *SetSecretsRequest) (*SetSecretsResponse, error) {
+	err := e.env.SetSecrets(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &SetSecretsResponse{}, nil
+}
+
+type ExecSessionRequest struct{}
+
+func (e *InternalEndpoint) execSession(ctx context.Context, req *ExecSessionRequest) (*huma.StreamResponse, error) {
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			streamutil.ServeWebSocket(ctx, func(ws *websocket.Conn) {
+				if err := exec.Session(ws, e.env.WorkloadEnv()); err != nil {
+					slog.Debug("exec session failed", "error", err)
+				}
+			})
+		},
+	}, nil
+}
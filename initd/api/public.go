@@ -33,6 +33,13 @@ func (e *publicEndpoints) registerRoutes(api huma.API) {
 		Description: "Execute a command",
 	}, e.exec)
 
+	huma.Register(api, huma.Operation{
+		Method:      "POST",
+		Path:        "/exec/stream",
+		OperationID: "execStream",
+		Description: "Execute a command and stream its output, or attach to a streamed command",
+	}, e.execStream)
+
 	huma.Register(api, huma.Operation{
 		Method:      "GET",
 		Path:        "/fs/ls",
@@ -104,7 +111,8 @@ func (e *publicEndpoints) registerRoutes(api huma.API) {
 }
 
 type publicEndpoints struct {
-	files *files.Service
+	files   *files.Service
+	streams *exec.Streams
 }
 
 type ListDirRequest struct {
@@ -196,6 +204,45 @@ func (e *publicEndpoints) exec(ctx context.Context, req *ExecRequest) (*ExecResp
 	return &ExecResponse{Body: result}, nil
 }
 
+type ExecStreamRequest struct {
+	Body api.ExecStreamRequest
+}
+
+func (e *publicEndpoints) execStream(ctx context.Context, req *ExecStreamRequest) (*huma.StreamResponse, error) {
+	execId := req.Body.ExecId
+	if execId == "" {
+		id, err := e.streams.Start(req.Body.ExecOptions)
+		if err != nil {
+			return nil, err
+		}
+		execId = id
+	} else if !e.streams.Exists(execId) {
+		return nil, errdefs.NewNotFound("exec not found")
+	}
+
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			ctx.SetHeader("Content-Type", "application/x-ndjson")
+			rw := ctx.BodyWriter().(http.ResponseWriter)
+			rc := http.NewResponseController(rw)
+			rc.SetWriteDeadline(time.Time{}) // Disable write deadline
+
+			e.streams.Follow(ctx.Context(), execId, req.Body.From, func(line api.ExecOutputLine) error {
+				bytes, err := json.Marshal(line)
+				if err != nil {
+					return err
+				}
+
+				if _, err := rw.Write(append(bytes, '\n')); err != nil {
+					return err
+				}
+
+				return rc.Flush()
+			})
+		},
+	}, nil
+}
+
 type GetStatusRequest struct {
 }
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/alexisbouchez/ravel/initd"
 	"github.com/alexisbouchez/ravel/initd/environment"
+	"github.com/alexisbouchez/ravel/initd/exec"
 	"github.com/alexisbouchez/ravel/initd/files"
 	"github.com/alexisbouchez/ravel/internal/humautil"
 	"github.com/alexisbouchez/ravel/pkg/vsock"
@@ -18,7 +19,8 @@ import (
 func ServeInitdAPI(env *environment.Env) error {
 	humautil.OverrideHumaErrorBuilder()
 	publicEndpoints := &publicEndpoints{
-		files: &files.Service{},
+		files:   &files.Service{},
+		streams: exec.NewStreams(),
 	}
 
 	publicMux := http.NewServeMux()
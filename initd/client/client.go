@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"io"
 	"net"
 	"net/http"
 
@@ -54,6 +55,33 @@ func (c *InternalClient) Signal(ctx context.Context, signal int) error {
 	return c.client.Post(ctx, "/signal", nil, httpclient.WithJSONBody(req))
 }
 
+// GrowFilesystem grows the filesystem mounted from a device which has been resized.
+func (c *InternalClient) GrowFilesystem(ctx context.Context, devicePath string) error {
+	req := initd.GrowFilesystemOptions{
+		DevicePath: devicePath,
+	}
+	return c.client.Post(ctx, "/filesystems/grow", nil, httpclient.WithJSONBody(req))
+}
+
+// FreezeFilesystems suspends the writes to the filesystems of the disks until they are thawed.
+func (c *InternalClient) FreezeFilesystems(ctx context.Context) error {
+	return c.client.Post(ctx, "/filesystems/freeze", nil)
+}
+
+func (c *InternalClient) ThawFilesystems(ctx context.Context) error {
+	return c.client.Post(ctx, "/filesystems/thaw", nil)
+}
+
+// ResetIdentity gives its own identity to a VM restored from the snapshot of another instance.
+func (c *InternalClient) ResetIdentity(ctx context.Context, opts initd.ResetIdentityOptions) error {
+	return c.client.Post(ctx, "/identity/reset", nil, httpclient.WithJSONBody(opts))
+}
+
+// SetSecrets delivers the secrets to an init waiting for them, it starts the main process.
+func (c *InternalClient) SetSecrets(ctx context.Context, secrets initd.Secrets) error {
+	return c.client.Post(ctx, "/secrets", nil, httpclient.WithJSONBody(secrets))
+}
+
 func (c *InternalClient) Exec(ctx context.Context, opts api.ExecOptions) (*api.ExecResult, error) {
 	var res api.ExecResult
 	err := c.client.Post(ctx, "/exec", &res, httpclient.WithJSONBody(opts))
@@ -63,6 +91,21 @@ func (c *InternalClient) Exec(ctx context.Context, opts api.ExecOptions) (*api.E
 	return &res, nil
 }
 
+// ExecStream starts a streamed exec or attaches to it, the output lines are read from the
+// returned body.
+func (c *InternalClient) ExecStream(ctx context.Context, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	return c.client.RawPost(ctx, "/exec/stream", httpclient.WithJSONBody(req))
+}
+
+// ExecSession opens an interactive exec session, see api.ExecSessionOptions.
+func (c *InternalClient) ExecSession(ctx context.Context) (io.ReadWriteCloser, error) {
+	ws, err := c.client.DialWebSocket(ctx, "/exec/tty")
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
 func (c *InternalClient) HealthCheck(ctx context.Context) (err error) {
 	var res initd.Status
 	err = c.client.Get(ctx, "/status", &res)
@@ -12,9 +12,17 @@ type (
 		Gateway   string
 	}
 
+	// Route is a route to a private network, added on top of the default route.
+	Route struct {
+		Destination string
+		Gateway     string
+		Source      string
+	}
+
 	NetworkConfig struct {
 		IPConfigs      []IPConfig
 		DefaultGateway string
+		Routes         []Route
 	}
 
 	ImageConfig struct {
@@ -49,6 +57,8 @@ type (
 		ExtraEnv           []string
 		RootDevice         string
 		Mounts             []Mounts
+		// WaitSecrets delays the start of the command until the secrets are delivered, see Secrets
+		WaitSecrets bool
 
 		EtcResolv EtcResolv
 		EtcHost   []EtcHost
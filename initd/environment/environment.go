@@ -1,7 +1,10 @@
 package environment
 
 import (
+	"errors"
 	"os/exec"
+	"slices"
+	"sync"
 
 	"github.com/alexisbouchez/ravel/initd"
 	"golang.org/x/sys/unix"
@@ -13,6 +16,13 @@ type Env struct {
 	result initd.WaitResult
 	uid    int
 	gid    int
+
+	waitSecrets bool // the command is started by SetSecrets
+	secretsOnce sync.Once
+	secretsUid  int // the user running the command owns the secret files
+	secretsGid  int
+
+	envLock sync.Mutex // the environment of the command grows with its secrets
 }
 
 func (e *Env) Wait() initd.WaitResult {
@@ -20,6 +30,16 @@ func (e *Env) Wait() initd.WaitResult {
 	return e.result
 }
 
+// WorkloadEnv returns the environment of the command, with its secrets once they are set.
+func (e *Env) WorkloadEnv() []string {
+	e.envLock.Lock()
+	defer e.envLock.Unlock()
+	return slices.Clone(e.cmd.Env)
+}
+
 func (e *Env) Signal(sig int) error {
+	if e.cmd.Process == nil {
+		return errors.New("the command has not been started")
+	}
 	return e.cmd.Process.Signal(unix.Signal(sig))
 }
@@ -0,0 +1,98 @@
+package environment
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// FIFREEZE and FITHAW, the ioctls used by fsfreeze
+const (
+	fiFreeze = 0xC0045877
+	fiThaw   = 0xC0045878
+)
+
+// FreezeFilesystems flushes and suspends the writes to the filesystems mounted from the disks
+// of the VM, the host can then copy the disks in a consistent state. On failure the filesystems
+// already frozen are thawed.
+func FreezeFilesystems() error {
+	mountPaths, err := diskMountPaths()
+	if err != nil {
+		return err
+	}
+
+	for i, mountPath := range mountPaths {
+		if err := fsIoctl(mountPath, fiFreeze); err != nil && !errors.Is(err, unix.EBUSY) { // EBUSY: already frozen
+			thawFilesystems(mountPaths[:i])
+			return fmt.Errorf("failed to freeze filesystem %s: %w", mountPath, err)
+		}
+	}
+
+	return nil
+}
+
+// ThawFilesystems resumes the writes to the filesystems frozen by FreezeFilesystems.
+func ThawFilesystems() error {
+	mountPaths, err := diskMountPaths()
+	if err != nil {
+		return err
+	}
+
+	return thawFilesystems(mountPaths)
+}
+
+func thawFilesystems(mountPaths []string) error {
+	var errs []error
+	// the filesystems are thawed in the reverse order, the root filesystem last
+	for _, mountPath := range slices.Backward(mountPaths) {
+		if err := fsIoctl(mountPath, fiThaw); err != nil && !errors.Is(err, unix.EINVAL) { // EINVAL: not frozen
+			errs = append(errs, fmt.Errorf("failed to thaw filesystem %s: %w", mountPath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func fsIoctl(mountPath string, req uint) error {
+	mnt, err := os.Open(mountPath)
+	if err != nil {
+		return err
+	}
+	defer mnt.Close()
+
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, mnt.Fd(), uintptr(req), 0); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// diskMountPaths returns the mount paths of the virtio disks, the root filesystem first.
+func diskMountPaths() ([]string, error) {
+	f, err := os.Open("/proc/mounts")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mountPaths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/dev/vd") || slices.Contains(mountPaths, fields[1]) {
+			continue
+		}
+		mountPaths = append(mountPaths, fields[1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(mountPaths, func(a, b string) int { return len(a) - len(b) })
+	return mountPaths, nil
+}
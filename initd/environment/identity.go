@@ -0,0 +1,63 @@
+package environment
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/alexisbouchez/ravel/initd"
+	"golang.org/x/sys/unix"
+)
+
+func setHostname(hostname string) error {
+	if err := unix.Sethostname([]byte(hostname)); err != nil {
+		return fmt.Errorf("error setting hostname: %w", err)
+	}
+
+	if err := os.WriteFile("/etc/hostname", []byte(hostname+"\n"), perm0755); err != nil {
+		return fmt.Errorf("error writing /etc/hostname: %w", err)
+	}
+
+	return nil
+}
+
+// reseedEntropy mixes the seed in the kernel random pool and reseeds the generator
+// of the kernel with it.
+func reseedEntropy(seed []byte) error {
+	f, err := os.OpenFile("/dev/urandom", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("error opening /dev/urandom: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(seed); err != nil {
+		return fmt.Errorf("error writing entropy: %w", err)
+	}
+
+	if err := unix.IoctlSetInt(int(f.Fd()), unix.RNDRESEEDCRNG, 0); err != nil {
+		return fmt.Errorf("error reseeding the random generator: %w", err)
+	}
+
+	return nil
+}
+
+// ResetIdentity gives its own identity to a VM restored from the snapshot of another one.
+func ResetIdentity(opts initd.ResetIdentityOptions) error {
+	slog.Info("[ravel-initd] Resetting identity", "hostname", opts.Hostname)
+
+	if len(opts.Entropy) > 0 {
+		if err := reseedEntropy(opts.Entropy); err != nil {
+			return err
+		}
+	}
+
+	if err := setHostname(opts.Hostname); err != nil {
+		return err
+	}
+
+	if err := resetNetwork(opts.Network); err != nil {
+		return fmt.Errorf("error resetting network: %w", err)
+	}
+
+	return nil
+}
@@ -125,16 +125,20 @@ func (e *Env) Init() error {
 		return fmt.Errorf("error mounting additional drives: %w", err)
 	}
 
-	if err := unix.Sethostname([]byte(config.Hostname)); err != nil {
-		return (fmt.Errorf("error setting hostname: %w", err))
+	if config.WaitSecrets {
+		if err := mountSecretsDir(uid, gid); err != nil {
+			return err
+		}
+		e.waitSecrets = true
+		e.secretsUid, e.secretsGid = uid, gid
 	}
 
 	if err := mkdir("/etc", perm0755); err != nil {
 		return (fmt.Errorf("could not create /etc dir: %w", err))
 	}
 
-	if err := os.WriteFile("/etc/hostname", []byte(config.Hostname+"\n"), perm0755); err != nil {
-		return (fmt.Errorf("error writing /etc/hostname: %w", err))
+	if err := setHostname(config.Hostname); err != nil {
+		return err
 	}
 
 	if err := writeEtcResolv(config.EtcResolv); err != nil {
@@ -159,7 +163,16 @@ func (e *Env) Init() error {
 	return nil
 }
 
+// Start starts the command, unless it waits for its secrets, see SetSecrets.
 func (e *Env) Start() error {
+	if e.waitSecrets {
+		slog.Info("[ravel-initd] Waiting for the secrets")
+		return nil
+	}
+	return e.start()
+}
+
+func (e *Env) start() error {
 	var err error
 	defer func() {
 		if err != nil {
@@ -78,6 +78,39 @@ func networkSetup(config initd.NetworkConfig) error {
 		return fmt.Errorf("error getting eth0 interface: %v", err)
 	}
 
+	return configureInterface(eth0, config)
+}
+
+// resetNetwork replaces the addresses and the routes of eth0, the neighbours learned
+// with the previous configuration are flushed when the interface goes down.
+func resetNetwork(config initd.NetworkConfig) error {
+	eth0, err := netlink.LinkByName("eth0")
+	if err != nil {
+		return fmt.Errorf("error getting eth0 interface: %v", err)
+	}
+
+	if err := netlink.LinkSetDown(eth0); err != nil {
+		return fmt.Errorf("error setting eth0 interface down: %v", err)
+	}
+
+	addrs, err := netlink.AddrList(eth0, netlink.FAMILY_ALL)
+	if err != nil {
+		return fmt.Errorf("error listing eth0 addresses: %v", err)
+	}
+
+	for _, addr := range addrs {
+		if addr.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if err := netlink.AddrDel(eth0, &addr); err != nil {
+			return fmt.Errorf("error removing address %s: %v", addr.IPNet, err)
+		}
+	}
+
+	return configureInterface(eth0, config)
+}
+
+func configureInterface(eth0 netlink.Link, config initd.NetworkConfig) error {
 	for _, v := range config.IPConfigs {
 		err := applyIPConfig(eth0, v)
 		if err != nil {
@@ -91,15 +124,36 @@ func networkSetup(config initd.NetworkConfig) error {
 	}
 
 	slog.Debug("Adding default route", "gateway", config.DefaultGateway)
-	if err := netlink.RouteAdd(&netlink.Route{
+	if err := netlink.RouteReplace(&netlink.Route{
 		Gw: net.ParseIP(config.DefaultGateway),
 	}); err != nil {
 		return fmt.Errorf("error adding default route: %v", err)
 	}
 
+	for _, r := range config.Routes {
+		slog.Debug("Adding route", "destination", r.Destination, "gateway", r.Gateway)
+		if err := addRoute(eth0, r); err != nil {
+			return fmt.Errorf("error adding route to %s: %v", r.Destination, err)
+		}
+	}
+
 	return nil
 }
 
+func addRoute(link netlink.Link, route initd.Route) error {
+	_, dst, err := net.ParseCIDR(route.Destination)
+	if err != nil {
+		return fmt.Errorf("error parsing route destination: %v", err)
+	}
+
+	return netlink.RouteReplace(&netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Dst:       dst,
+		Gw:        net.ParseIP(route.Gateway),
+		Src:       net.ParseIP(route.Source),
+	})
+}
+
 func writeEtcResolv(entries initd.EtcResolv) error {
 	slog.Debug("populating /etc/resolv.conf")
 
@@ -0,0 +1,74 @@
+package environment
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// EXT4_IOC_RESIZE_FS, the ioctl used by resize2fs to grow a mounted filesystem
+const ext4IocResizeFS = 0x40086610
+
+// GrowFilesystem grows the ext4 filesystem mounted from a device to the size of the device,
+// the guest kernel updates the device size when the host resizes the disk.
+func GrowFilesystem(device string) error {
+	mountPath, err := findMountPath(device)
+	if err != nil {
+		return err
+	}
+
+	dev, err := os.Open(device)
+	if err != nil {
+		return fmt.Errorf("failed to open device %s: %w", device, err)
+	}
+	defer dev.Close()
+
+	var size uint64
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, dev.Fd(), unix.BLKGETSIZE64, uintptr(unsafe.Pointer(&size))); errno != 0 {
+		return fmt.Errorf("failed to get device %s size: %w", device, errno)
+	}
+
+	var stat unix.Statfs_t
+	if err := unix.Statfs(mountPath, &stat); err != nil {
+		return fmt.Errorf("failed to stat filesystem %s: %w", mountPath, err)
+	}
+
+	mnt, err := os.Open(mountPath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", mountPath, err)
+	}
+	defer mnt.Close()
+
+	blocks := size / uint64(stat.Bsize)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, mnt.Fd(), ext4IocResizeFS, uintptr(unsafe.Pointer(&blocks))); errno != 0 {
+		return fmt.Errorf("failed to resize filesystem %s: %w", mountPath, errno)
+	}
+
+	return nil
+}
+
+func findMountPath(device string) (string, error) {
+	f, err := os.Open("/proc/mounts")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == device {
+			return fields[1], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("device %s is not mounted", device)
+}
@@ -0,0 +1,83 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexisbouchez/ravel/initd"
+	"golang.org/x/sys/unix"
+)
+
+const secretsDir = "/run/secrets"
+
+// mountSecretsDir mounts the tmpfs holding the secret files, only the user running the
+// command can read it.
+func mountSecretsDir(uid, gid int) error {
+	if err := mkdir(secretsDir, unix.S_IRWXU); err != nil {
+		return fmt.Errorf("could not create %s directory: %w", secretsDir, err)
+	}
+
+	if err := mount("secrets", secretsDir, "tmpfs", commonMntFlags, "mode=0700"); err != nil {
+		return err
+	}
+
+	if err := unix.Chown(secretsDir, uid, gid); err != nil {
+		return fmt.Errorf("error setting permissions: %w", err)
+	}
+
+	return nil
+}
+
+// SetSecrets writes the secret files, adds the secret environment variables and starts the
+// command. The secrets are only accepted once, before the command is started.
+func (e *Env) SetSecrets(secrets initd.Secrets) error {
+	if !e.waitSecrets {
+		return errors.New("the command does not wait for secrets")
+	}
+
+	err := errors.New("the secrets have already been set")
+	e.secretsOnce.Do(func() {
+		err = e.setSecrets(secrets)
+	})
+	return err
+}
+
+func (e *Env) setSecrets(secrets initd.Secrets) error {
+	for _, file := range secrets.Files {
+		if err := writeSecretFile(file, e.secretsUid, e.secretsGid); err != nil {
+			return err
+		}
+	}
+
+	for _, pair := range secrets.Env {
+		name, _, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || strings.IndexByte(pair, 0) >= 0 {
+			return errors.New("invalid secret env var")
+		}
+	}
+	e.envLock.Lock()
+	e.cmd.Env = append(e.cmd.Env, secrets.Env...)
+	e.envLock.Unlock()
+
+	return e.start()
+}
+
+func writeSecretFile(file initd.SecretFile, uid, gid int) error {
+	if file.Name == "" || file.Name != filepath.Base(file.Name) || file.Name == "." || file.Name == ".." {
+		return fmt.Errorf("invalid secret file name: %q", file.Name)
+	}
+
+	path := filepath.Join(secretsDir, file.Name)
+	if err := os.WriteFile(path, []byte(file.Value), 0400); err != nil {
+		return fmt.Errorf("error writing secret file %s: %w", file.Name, err)
+	}
+
+	if err := unix.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("error setting permissions: %w", err)
+	}
+
+	return nil
+}
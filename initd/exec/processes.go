@@ -6,6 +6,7 @@ import (
 	"context"
 	"io"
 	"os/exec"
+	"time"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
@@ -52,6 +53,22 @@ func Exec(ctx context.Context, opts api.ExecOptions) (*api.ExecResult, error) {
 	}, nil
 }
 
+const (
+	// defaultStreamTimeout bounds a streamed exec which has no timeout.
+	defaultStreamTimeout = time.Hour
+	// maxStreamTimeout bounds the timeout of a streamed exec.
+	maxStreamTimeout = 24 * time.Hour
+)
+
+// streamTimeout returns the timeout of a streamed exec, the default one when unset.
+func streamTimeout(opts api.ExecOptions) time.Duration {
+	timeout := opts.GetTimeout()
+	if timeout <= 0 {
+		return defaultStreamTimeout
+	}
+	return min(timeout, maxStreamTimeout)
+}
+
 // ExecOutputLine represents a single line of output from a streaming exec.
 type ExecOutputLine struct {
 	Stream string `json:"stream"` // "stdout" or "stderr"
@@ -75,10 +92,10 @@ func ExecStream(ctx context.Context, opts api.ExecOptions, outputCh chan<- ExecO
 	name := opts.Cmd[0]
 	args := opts.Cmd[1:]
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, opts.GetTimeout())
+	ctx, cancel := context.WithTimeout(ctx, streamTimeout(opts))
 	defer cancel()
 
-	cmd := exec.CommandContext(timeoutCtx, name, args...)
+	cmd := exec.CommandContext(ctx, name, args...)
 	if cmd.Err != nil {
 		return nil, errdefs.NewInvalidArgument(cmd.Err.Error())
 	}
@@ -0,0 +1,125 @@
+package exec
+
+import (
+	"encoding/json"
+	"io"
+	"os/exec"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+)
+
+// drainTimeout bounds the time spent reading the terminal output once the command has
+// exited, the terminal stays open as long as a background process of the session holds it.
+const drainTimeout = 2 * time.Second
+
+// Session runs an interactive exec session over a connection, the command is attached
+// to a new pseudo terminal and runs with the environment of the workload, env, and the
+// variables of the session. See api.ExecSessionOptions for the protocol.
+func Session(conn io.ReadWriter, env []string) error {
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+
+	var opts api.ExecSessionOptions
+	err := dec.Decode(&opts)
+	if err != nil {
+		err = errdefs.NewInvalidArgument("invalid session options: " + err.Error())
+	} else {
+		var exitCode int
+		exitCode, err = runSession(dec, enc, env, opts)
+		if err == nil {
+			return enc.Encode(api.ExecMessage{Type: api.ExecMessageExit, ExitCode: exitCode})
+		}
+	}
+
+	enc.Encode(api.ExecMessage{Type: api.ExecMessageError, Error: err.Error()})
+	return err
+}
+
+func runSession(dec *json.Decoder, enc *json.Encoder, env []string, opts api.ExecSessionOptions) (int, error) {
+	if len(opts.Cmd) == 0 {
+		return -1, errdefs.NewInvalidArgument("cmd cannot be empty")
+	}
+
+	ptmx, pts, err := openPTY()
+	if err != nil {
+		return -1, err
+	}
+	defer ptmx.Close()
+
+	if err := setWinsize(ptmx, opts.Cols, opts.Rows); err != nil {
+		pts.Close()
+		return -1, err
+	}
+
+	cmd := exec.Command(opts.Cmd[0], opts.Cmd[1:]...)
+	cmd.Env = append(slices.Clone(env), opts.Env...)
+	cmd.Stdin = pts
+	cmd.Stdout = pts
+	cmd.Stderr = pts
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid:  true,
+		Setctty: true,
+		Ctty:    0, // stdin in the child
+	}
+
+	err = cmd.Start()
+	pts.Close()
+	if err != nil {
+		return -1, errdefs.NewInvalidArgument(err.Error())
+	}
+
+	go func() {
+		for {
+			var msg api.ExecMessage
+			if err := dec.Decode(&msg); err != nil {
+				cmd.Process.Signal(syscall.SIGHUP) // the client is gone
+				return
+			}
+
+			switch msg.Type {
+			case api.ExecMessageStdin:
+				ptmx.Write(msg.Data)
+			case api.ExecMessageResize:
+				setWinsize(ptmx, msg.Cols, msg.Rows)
+			}
+		}
+	}()
+
+	output := make(chan struct{})
+	go func() {
+		defer close(output)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := ptmx.Read(buf)
+			if n > 0 {
+				if err := enc.Encode(api.ExecMessage{Type: api.ExecMessageStdout, Data: buf[:n]}); err != nil {
+					return
+				}
+			}
+			if err != nil { // EIO once every process of the session has closed the terminal
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+
+	select {
+	case <-output:
+	case <-time.After(drainTimeout):
+		ptmx.Close()
+		<-output
+	}
+
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return -1, err
+		}
+	}
+
+	return cmd.ProcessState.ExitCode(), nil
+}
@@ -0,0 +1,117 @@
+package exec
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+// runTestSession starts a session with the options, sends the input messages and returns the
+// output of the terminal and the last message of the server.
+func runTestSession(t *testing.T, env []string, opts api.ExecSessionOptions, input ...api.ExecMessage) (string, api.ExecMessage) {
+	t.Helper()
+
+	if ptmx, pts, err := openPTY(); err != nil {
+		t.Skipf("pseudo terminals are not available: %v", err)
+	} else {
+		ptmx.Close()
+		pts.Close()
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	client.SetDeadline(time.Now().Add(10 * time.Second))
+
+	go func() {
+		Session(server, env)
+		server.Close()
+	}()
+
+	enc := json.NewEncoder(client)
+	if err := enc.Encode(opts); err != nil {
+		t.Fatalf("failed to send the options: %v", err)
+	}
+
+	go func() {
+		for _, msg := range input {
+			enc.Encode(msg)
+		}
+	}()
+
+	dec := json.NewDecoder(client)
+	var output strings.Builder
+	for {
+		var msg api.ExecMessage
+		if err := dec.Decode(&msg); err != nil {
+			t.Fatalf("failed to read a message: %v", err)
+		}
+
+		switch msg.Type {
+		case api.ExecMessageStdout:
+			output.Write(msg.Data)
+		case api.ExecMessageExit, api.ExecMessageError:
+			return output.String(), msg
+		default:
+			t.Fatalf("unexpected message type %q", msg.Type)
+		}
+	}
+}
+
+func TestSessionEnv(t *testing.T) {
+	output, last := runTestSession(t, []string{"FOO=workload", "BAR=workload"}, api.ExecSessionOptions{
+		Cmd: []string{"/bin/sh", "-c", `echo "$FOO $BAR $HOME"; exit 3`},
+		Env: []string{"BAR=session"},
+	})
+
+	if last.Type != api.ExecMessageExit || last.ExitCode != 3 {
+		t.Fatalf("last message = %+v, want exit code 3", last)
+	}
+
+	// the variables of initd itself are not inherited
+	if !strings.Contains(output, "workload session \r\n") {
+		t.Errorf("output = %q, want the workload environment overridden by the session", output)
+	}
+}
+
+func TestSessionStdin(t *testing.T) {
+	output, last := runTestSession(t, nil, api.ExecSessionOptions{
+		Cmd:  []string{"/bin/sh", "-c", `stty size; read line; echo "got $line"`},
+		Cols: 100,
+		Rows: 40,
+	}, api.ExecMessage{Type: api.ExecMessageStdin, Data: []byte("hello\n")})
+
+	if last.Type != api.ExecMessageExit || last.ExitCode != 0 {
+		t.Fatalf("last message = %+v, want exit code 0", last)
+	}
+
+	if !strings.Contains(output, "40 100") {
+		t.Errorf("output = %q, want the terminal size", output)
+	}
+
+	if !strings.Contains(output, "got hello") {
+		t.Errorf("output = %q, want the input echoed by the command", output)
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts api.ExecSessionOptions
+	}{
+		{"empty command", api.ExecSessionOptions{}},
+		{"unknown command", api.ExecSessionOptions{Cmd: []string{"/does/not/exist"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, last := runTestSession(t, nil, tt.opts)
+			if last.Type != api.ExecMessageError || last.Error == "" {
+				t.Errorf("last message = %+v, want an error", last)
+			}
+		})
+	}
+}
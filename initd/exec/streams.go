@@ -0,0 +1,164 @@
+package exec
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/id"
+)
+
+const (
+	// streamRetention is the time a completed exec can still be attached to.
+	streamRetention = 5 * time.Minute
+	// maxStreamLines is the number of output lines kept per exec, the oldest are dropped.
+	maxStreamLines = 10000
+)
+
+// Streams runs the streamed execs. Their output is buffered so that a client can
+// attach again after a disconnection, the command keeps running meanwhile.
+type Streams struct {
+	mu        sync.Mutex
+	execs     map[string]*stream
+	retention time.Duration
+}
+
+func NewStreams() *Streams {
+	return &Streams{execs: map[string]*stream{}, retention: streamRetention}
+}
+
+type stream struct {
+	id string
+
+	mu     sync.Mutex
+	lines  []api.ExecOutputLine
+	seq    int
+	done   bool
+	notify chan struct{} // closed when a line is appended
+}
+
+func (s *stream) append(line api.ExecOutputLine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line.ExecId = s.id
+	line.Seq = s.seq
+	s.seq++
+
+	s.lines = append(s.lines, line)
+	if len(s.lines) > maxStreamLines {
+		s.lines = s.lines[len(s.lines)-maxStreamLines:]
+	}
+
+	if line.Stream == api.ExecStreamExit || line.Stream == api.ExecStreamError {
+		s.done = true
+	}
+
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// since returns the lines from a sequence number, whether the exec is done and a channel
+// closed when more lines are available.
+func (s *stream) since(from int) ([]api.ExecOutputLine, bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := []api.ExecOutputLine{}
+	for _, l := range s.lines {
+		if l.Seq >= from {
+			lines = append(lines, l)
+		}
+	}
+
+	return lines, s.done, s.notify
+}
+
+// Start runs a command in the background and returns its exec ID.
+func (s *Streams) Start(opts api.ExecOptions) (string, error) {
+	if len(opts.Cmd) == 0 {
+		return "", errdefs.NewInvalidArgument("cmd cannot be empty")
+	}
+
+	st := &stream{
+		id:     id.Generate(),
+		notify: make(chan struct{}),
+	}
+
+	st.append(api.ExecOutputLine{Stream: api.ExecStreamStart})
+
+	s.mu.Lock()
+	s.execs[st.id] = st
+	s.mu.Unlock()
+
+	go s.run(st, opts)
+
+	return st.id, nil
+}
+
+func (s *Streams) run(st *stream, opts api.ExecOptions) {
+	output := make(chan ExecOutputLine)
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		for line := range output {
+			st.append(api.ExecOutputLine{Stream: line.Stream, Data: line.Data})
+		}
+	}()
+
+	// the exec is not tied to the request which started it
+	result, err := ExecStream(context.Background(), opts, output)
+	<-consumed
+	if err != nil {
+		st.append(api.ExecOutputLine{Stream: api.ExecStreamError, Data: err.Error()})
+	} else {
+		st.append(api.ExecOutputLine{Stream: api.ExecStreamExit, ExitCode: result.ExitCode})
+	}
+
+	time.AfterFunc(s.retention, func() {
+		s.mu.Lock()
+		delete(s.execs, st.id)
+		s.mu.Unlock()
+	})
+}
+
+// Exists reports whether an exec can be attached to.
+func (s *Streams) Exists(execId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.execs[execId]
+	return ok
+}
+
+// Follow sends the output of an exec from a sequence number until its last line,
+// or until the context is done.
+func (s *Streams) Follow(ctx context.Context, execId string, from int, send func(api.ExecOutputLine) error) error {
+	s.mu.Lock()
+	st, ok := s.execs[execId]
+	s.mu.Unlock()
+	if !ok {
+		return errdefs.NewNotFound("exec not found")
+	}
+
+	for {
+		lines, done, notify := st.since(from)
+		for _, l := range lines {
+			if err := send(l); err != nil {
+				return err
+			}
+			from = l.Seq + 1
+		}
+
+		if done {
+			return nil
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
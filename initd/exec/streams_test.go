@@ -0,0 +1,191 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+)
+
+func follow(t *testing.T, s *Streams, execId string, from int) []api.ExecOutputLine {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lines := []api.ExecOutputLine{}
+	err := s.Follow(ctx, execId, from, func(l api.ExecOutputLine) error {
+		lines = append(lines, l)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Follow() error = %v", err)
+	}
+	return lines
+}
+
+func TestStreams(t *testing.T) {
+	s := NewStreams()
+
+	execId, err := s.Start(api.ExecOptions{Cmd: []string{"/bin/sh", "-c", "echo one; echo two >&2; exit 4"}})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if !s.Exists(execId) {
+		t.Fatal("Exists() = false for a started exec")
+	}
+
+	lines := follow(t, s, execId, 0)
+	if len(lines) != 4 {
+		t.Fatalf("Follow() sent %d lines, want 4: %+v", len(lines), lines)
+	}
+
+	for i, l := range lines {
+		if l.ExecId != execId || l.Seq != i {
+			t.Errorf("line %d = %+v, want exec %s and seq %d", i, l, execId, i)
+		}
+	}
+
+	if lines[0].Stream != api.ExecStreamStart {
+		t.Errorf("first line = %+v, want the start line", lines[0])
+	}
+
+	last := lines[3]
+	if last.Stream != api.ExecStreamExit || last.ExitCode != 4 {
+		t.Errorf("last line = %+v, want exit code 4", last)
+	}
+
+	// attaching again only sends the lines from the sequence number
+	if again := follow(t, s, execId, 3); len(again) != 1 || again[0] != last {
+		t.Errorf("Follow() from 3 = %+v, want the exit line", again)
+	}
+}
+
+func TestStreamsErrors(t *testing.T) {
+	s := NewStreams()
+
+	if _, err := s.Start(api.ExecOptions{}); !errdefs.IsInvalidArgument(err) {
+		t.Errorf("Start() of an empty command error = %v, want invalid argument", err)
+	}
+
+	err := s.Follow(context.Background(), "unknown", 0, func(api.ExecOutputLine) error { return nil })
+	if !errdefs.IsNotFound(err) {
+		t.Errorf("Follow() of an unknown exec error = %v, want not found", err)
+	}
+
+	execId, err := s.Start(api.ExecOptions{Cmd: []string{"/does/not/exist"}})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	lines := follow(t, s, execId, 0)
+	if last := lines[len(lines)-1]; last.Stream != api.ExecStreamError || last.Data == "" {
+		t.Errorf("last line = %+v, want an error", last)
+	}
+}
+
+func TestStreamsFollowSendError(t *testing.T) {
+	s := NewStreams()
+
+	execId, err := s.Start(api.ExecOptions{Cmd: []string{"/bin/sh", "-c", "sleep 0.2; echo done"}})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// a client gone after the first line does not stop the exec
+	sendErr := errors.New("client disconnected")
+	err = s.Follow(context.Background(), execId, 0, func(api.ExecOutputLine) error { return sendErr })
+	if !errors.Is(err, sendErr) {
+		t.Fatalf("Follow() error = %v, want %v", err, sendErr)
+	}
+
+	lines := follow(t, s, execId, 1)
+	if len(lines) != 2 || lines[0].Data != "done" || lines[1].Stream != api.ExecStreamExit {
+		t.Errorf("Follow() after a reconnection = %+v, want the output and the exit line", lines)
+	}
+}
+
+func TestStreamsTimeout(t *testing.T) {
+	s := NewStreams()
+
+	execId, err := s.Start(api.ExecOptions{Cmd: []string{"sleep", "10"}, TimeoutMs: 100})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	lines := follow(t, s, execId, 0)
+	if last := lines[len(lines)-1]; last.Stream != api.ExecStreamExit || last.ExitCode != -1 {
+		t.Errorf("last line = %+v, want the killed command", last)
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	tests := []struct {
+		timeoutMs int
+		want      time.Duration
+	}{
+		{0, defaultStreamTimeout},
+		{-1, defaultStreamTimeout},
+		{5000, 5 * time.Second},
+		{int(48 * time.Hour / time.Millisecond), maxStreamTimeout},
+	}
+
+	for _, tt := range tests {
+		if got := streamTimeout(api.ExecOptions{TimeoutMs: tt.timeoutMs}); got != tt.want {
+			t.Errorf("streamTimeout(%d) = %v, want %v", tt.timeoutMs, got, tt.want)
+		}
+	}
+}
+
+func TestStreamsExpiry(t *testing.T) {
+	s := NewStreams()
+	s.retention = 50 * time.Millisecond
+
+	execId, err := s.Start(api.ExecOptions{Cmd: []string{"true"}})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	follow(t, s, execId, 0)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Exists(execId) {
+		if time.Now().After(deadline) {
+			t.Fatal("the completed exec was not removed after its retention")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStreamTruncation(t *testing.T) {
+	st := &stream{id: "exec", notify: make(chan struct{})}
+	for i := range maxStreamLines + 5 {
+		st.append(api.ExecOutputLine{Stream: api.ExecStreamStdout, Data: strconv.Itoa(i)})
+	}
+
+	lines, done, _ := st.since(0)
+	if done {
+		t.Error("since() reported a running exec as done")
+	}
+
+	if len(lines) != maxStreamLines {
+		t.Fatalf("since() returned %d lines, want %d", len(lines), maxStreamLines)
+	}
+
+	// the oldest lines are dropped, the sequence numbers are kept
+	if first := lines[0]; first.Seq != 5 || first.Data != "5" {
+		t.Errorf("first line = %+v, want seq 5", first)
+	}
+
+	if last := lines[len(lines)-1]; last.Seq != maxStreamLines+4 {
+		t.Errorf("last line = %+v, want seq %d", last, maxStreamLines+4)
+	}
+
+	if lines, _, _ := st.since(maxStreamLines + 3); len(lines) != 2 {
+		t.Errorf("since(%d) returned %d lines, want 2", maxStreamLines+3, len(lines))
+	}
+}
@@ -11,6 +11,31 @@ type SignalOptions struct {
 	Signal int
 }
 
+type GrowFilesystemOptions struct {
+	DevicePath string `json:"device_path"`
+}
+
+// ResetIdentityOptions is sent to the init of a VM restored from the snapshot of another
+// instance, so that it stops sharing the identity of the snapshotted VM.
+type ResetIdentityOptions struct {
+	Hostname string        `json:"hostname"`
+	Network  NetworkConfig `json:"network"`
+	// Entropy is mixed in the kernel random pool, the restored VMs must not generate the same random numbers
+	Entropy []byte `json:"entropy"`
+}
+
 type Status struct {
 	Ok bool `json:"ok"`
 }
+
+// Secrets are delivered by the agent to the init of a machine referencing secrets, which
+// starts its command once they are received.
+type Secrets struct {
+	Env   []string     `json:"env"`   // NAME=value pairs added to the environment of the command
+	Files []SecretFile `json:"files"` // files written in /run/secrets
+}
+
+type SecretFile struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
@@ -3,21 +3,28 @@ package httpclient
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
 
 	"github.com/alexisbouchez/ravel/api/errdefs"
+	"golang.org/x/net/websocket"
 )
 
 type Client struct {
-	baseURL string
-	client  *http.Client
+	baseURL  string
+	client   *http.Client
+	defaults []ReqOpt
 }
 
+// NewClient returns a client of the API at baseURL, the defaults options are applied to all its
+// requests before their own options.
 func NewClient(baseURL string, c *http.Client, defaults ...ReqOpt) *Client {
-	return &Client{client: c, baseURL: baseURL}
+	return &Client{client: c, baseURL: baseURL, defaults: defaults}
 }
 
 type reqOptions struct {
@@ -78,11 +85,21 @@ func buildHttpRequest(ctx context.Context, method, path string, opts ...ReqOpt)
 	}
 
 	req.Header = o.header
-	req.URL.RawQuery = o.query.Encode()
+	if len(o.query) > 0 { // keep the query of the path
+		query := req.URL.Query()
+		for key, values := range o.query {
+			query[key] = append(query[key], values...)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
 
 	return req, nil
 }
 
+func (c *Client) buildRequest(ctx context.Context, method, path string, opts ...ReqOpt) (*http.Request, error) {
+	return buildHttpRequest(ctx, method, c.baseURL+path, append(slices.Clone(c.defaults), opts...)...)
+}
+
 func isOk(status int) bool {
 	return status >= 200 && status <= 204
 }
@@ -110,7 +127,7 @@ func (c *Client) do(req *http.Request, dest any) error {
 }
 
 func (c *Client) Get(ctx context.Context, path string, dest any, opts ...ReqOpt) error {
-	req, err := buildHttpRequest(ctx, http.MethodGet, c.baseURL+path, opts...)
+	req, err := c.buildRequest(ctx, http.MethodGet, path, opts...)
 	if err != nil {
 		return err
 	}
@@ -119,7 +136,16 @@ func (c *Client) Get(ctx context.Context, path string, dest any, opts ...ReqOpt)
 }
 
 func (c *Client) Post(ctx context.Context, path string, dest any, opts ...ReqOpt) error {
-	req, err := buildHttpRequest(ctx, http.MethodPost, c.baseURL+path, opts...)
+	req, err := c.buildRequest(ctx, http.MethodPost, path, opts...)
+	if err != nil {
+		return err
+	}
+
+	return c.do(req, dest)
+}
+
+func (c *Client) Patch(ctx context.Context, path string, dest any, opts ...ReqOpt) error {
+	req, err := c.buildRequest(ctx, http.MethodPatch, path, opts...)
 	if err != nil {
 		return err
 	}
@@ -128,7 +154,7 @@ func (c *Client) Post(ctx context.Context, path string, dest any, opts ...ReqOpt
 }
 
 func (c *Client) Delete(ctx context.Context, path string, opts ...ReqOpt) error {
-	req, err := buildHttpRequest(ctx, http.MethodDelete, c.baseURL+path, opts...)
+	req, err := c.buildRequest(ctx, http.MethodDelete, path, opts...)
 	if err != nil {
 		return err
 	}
@@ -137,11 +163,25 @@ func (c *Client) Delete(ctx context.Context, path string, opts ...ReqOpt) error
 }
 
 func (c *Client) RawGet(ctx context.Context, path string, opts ...ReqOpt) (io.ReadCloser, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	req, err := c.buildRequest(ctx, http.MethodGet, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.doRaw(req)
+}
+
+// RawPost sends a request and returns the response body, to be closed by the caller.
+func (c *Client) RawPost(ctx context.Context, path string, opts ...ReqOpt) (io.ReadCloser, error) {
+	req, err := c.buildRequest(ctx, http.MethodPost, path, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	return c.doRaw(req)
+}
+
+func (c *Client) doRaw(req *http.Request) (io.ReadCloser, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -155,6 +195,83 @@ func (c *Client) RawGet(ctx context.Context, path string, opts ...ReqOpt) (io.Re
 	return resp.Body, nil
 }
 
+// DialWebSocket opens a WebSocket to a path, through the dialer and TLS configuration
+// of the underlying transport.
+func (c *Client) DialWebSocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	u, err := url.Parse(c.baseURL + path)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, ok := c.client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	conn, err := dial(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	origin := *u
+	origin.Path = ""
+	u.Scheme = "ws"
+	if origin.Scheme == "https" {
+		u.Scheme = "wss"
+
+		tlsConfig := &tls.Config{}
+		if transport.TLSClientConfig != nil {
+			tlsConfig = transport.TLSClientConfig.Clone()
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = u.Hostname()
+		}
+
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	config, err := websocket.NewConfig(u.String(), origin.String())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	o := &reqOptions{header: make(http.Header), query: make(url.Values)}
+	for _, opt := range c.defaults {
+		opt(o)
+	}
+	for key, values := range o.header {
+		config.Header[key] = values
+	}
+
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return ws, nil
+}
+
 // HTTPClient returns the underlying http.Client
 func (c *Client) HTTPClient() *http.Client {
 	return c.client
@@ -8,6 +8,8 @@ import (
 
 var ErrInvalidClientCert = errors.New("invalid certificate")
 
+// VerifyAgentConnection accepts the API servers and the other agents, the agents are only
+// allowed to make the transfers granted to their node, see AgentNode.
 func VerifyAgentConnection(cs tls.ConnectionState) error {
 	for _, cert := range cs.PeerCertificates {
 		// <name>.<region>.<certType>.ravel
@@ -16,13 +18,35 @@ func VerifyAgentConnection(cs tls.ConnectionState) error {
 			return ErrInvalidClientCert
 		}
 
-		if sn[2] != "server" {
+		if sn[2] != ServerCert && sn[2] != AgentCert {
 			return ErrInvalidClientCert
 		}
 	}
 	return nil
 }
 
+// IsAgentConnection reports whether the peer of the connection is an agent.
+func IsAgentConnection(cs tls.ConnectionState) bool {
+	if len(cs.PeerCertificates) == 0 {
+		return false
+	}
+
+	sn := strings.Split(cs.PeerCertificates[0].Subject.CommonName, ".")
+	return len(sn) == 4 && sn[2] == AgentCert
+}
+
+// AgentNode returns the node of the agent peer of the connection, the agent certificates are
+// named after the id of their node.
+func AgentNode(cs tls.ConnectionState) (string, bool) {
+	if !IsAgentConnection(cs) {
+		return "", false
+	}
+
+	return strings.Split(cs.PeerCertificates[0].Subject.CommonName, ".")[0], true
+}
+
+// VerifyServerAPIConnection accepts the clients and the agents, the agents are only allowed to
+// fetch the secrets of their machines.
 func VerifyServerAPIConnection(cs tls.ConnectionState) error {
 	for _, cert := range cs.PeerCertificates {
 		// <name>.<region>.<certType>.ravel
@@ -31,7 +55,7 @@ func VerifyServerAPIConnection(cs tls.ConnectionState) error {
 			return ErrInvalidClientCert
 		}
 
-		if sn[2] != "client" {
+		if sn[2] != ClientCert && sn[2] != AgentCert {
 			return ErrInvalidClientCert
 		}
 	}
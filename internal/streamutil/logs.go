@@ -134,3 +134,31 @@ func StreamLogs(ctx huma.Context, replay []*api.LogEntry, logsChan <-chan *api.L
 		}
 	}
 }
+
+// CopyLines streams the lines of a body as a NDJSON response, flushing each line.
+func CopyLines(ctx huma.Context, body io.ReadCloser) {
+	defer body.Close()
+
+	ctx.SetHeader("Content-Type", "application/x-ndjson")
+	ctx.SetStatus(http.StatusOK)
+
+	rw := ctx.BodyWriter().(http.ResponseWriter)
+	rc := http.NewResponseController(rw)
+	rc.SetWriteDeadline(time.Time{}) // the stream lasts as long as the command
+
+	reader := bufio.NewReader(body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, err := rw.Write(line); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
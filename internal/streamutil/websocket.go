@@ -0,0 +1,69 @@
+package streamutil
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/danielgtaylor/huma/v2"
+	"golang.org/x/net/websocket"
+)
+
+// ServeWebSocket upgrades the connection of a streamed huma response to a WebSocket.
+// The origin is not checked, the clients are not browsers.
+func ServeWebSocket(ctx huma.Context, handler func(*websocket.Conn)) {
+	u := ctx.URL()
+	req := &http.Request{
+		Method:     ctx.Method(),
+		URL:        &u,
+		Host:       ctx.Host(),
+		RemoteAddr: ctx.RemoteAddr(),
+		Header:     http.Header{},
+		TLS:        ctx.TLS(),
+	}
+	ctx.EachHeader(func(name, value string) {
+		req.Header.Add(name, value)
+	})
+
+	server := websocket.Server{Handler: handler}
+	server.ServeHTTP(ctx.BodyWriter().(http.ResponseWriter), req)
+}
+
+// Relay copies the data between two connections until one of them is closed, then closes both.
+func Relay(a, b io.ReadWriteCloser) {
+	done := make(chan struct{}, 2)
+	relay := func(dst io.Writer, src io.Reader) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+
+	go relay(a, b)
+	go relay(b, a)
+
+	<-done
+	a.Close()
+	b.Close()
+	<-done
+}
+
+// RelayExecSession relays an exec session to the next hop returned by dial, the client
+// is sent the error if the session cannot be opened.
+func RelayExecSession(client io.ReadWriteCloser, dial func() (io.ReadWriteCloser, error)) {
+	session, err := dial()
+	if err != nil {
+		WriteExecError(client, err)
+		client.Close()
+		return
+	}
+
+	Relay(client, session)
+}
+
+// WriteExecError sends an error message to the client of an exec session.
+func WriteExecError(w io.Writer, err error) error {
+	return json.NewEncoder(w).Encode(api.ExecMessage{
+		Type:  api.ExecMessageError,
+		Error: err.Error(),
+	})
+}
@@ -0,0 +1,105 @@
+package streamutil
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+func pipe(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	a, b := net.Pipe()
+	deadline := time.Now().Add(5 * time.Second)
+	a.SetDeadline(deadline)
+	b.SetDeadline(deadline)
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return a, b
+}
+
+func TestRelay(t *testing.T) {
+	client, clientEnd := pipe(t)
+	server, serverEnd := pipe(t)
+
+	done := make(chan struct{})
+	go func() {
+		Relay(clientEnd, serverEnd)
+		close(done)
+	}()
+
+	go client.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(server, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("server read %q, %v, want %q", buf, err, "ping")
+	}
+
+	go server.Write([]byte("pong"))
+	if _, err := io.ReadFull(client, buf); err != nil || string(buf) != "pong" {
+		t.Fatalf("client read %q, %v, want %q", buf, err, "pong")
+	}
+
+	// closing one side closes the other one
+	server.Close()
+	if _, err := client.Read(buf); err != io.EOF {
+		t.Errorf("client read after the server closed, error = %v, want %v", err, io.EOF)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Relay() did not return once a side was closed")
+	}
+}
+
+func TestRelayExecSession(t *testing.T) {
+	client, clientEnd := pipe(t)
+	session, sessionEnd := pipe(t)
+
+	go RelayExecSession(clientEnd, func() (io.ReadWriteCloser, error) {
+		return sessionEnd, nil
+	})
+
+	go json.NewEncoder(client).Encode(api.ExecSessionOptions{Cmd: []string{"sh"}})
+	var opts api.ExecSessionOptions
+	if err := json.NewDecoder(session).Decode(&opts); err != nil || len(opts.Cmd) != 1 || opts.Cmd[0] != "sh" {
+		t.Fatalf("session received %+v, %v, want the options of the client", opts, err)
+	}
+
+	go func() {
+		json.NewEncoder(session).Encode(api.ExecMessage{Type: api.ExecMessageExit, ExitCode: 2})
+		session.Close()
+	}()
+	var msg api.ExecMessage
+	if err := json.NewDecoder(client).Decode(&msg); err != nil || msg.Type != api.ExecMessageExit || msg.ExitCode != 2 {
+		t.Fatalf("client received %+v, %v, want the exit code of the session", msg, err)
+	}
+}
+
+func TestRelayExecSessionDialError(t *testing.T) {
+	client, clientEnd := pipe(t)
+
+	go RelayExecSession(clientEnd, func() (io.ReadWriteCloser, error) {
+		return nil, errors.New("machine is not running")
+	})
+
+	dec := json.NewDecoder(client)
+	var msg api.ExecMessage
+	if err := dec.Decode(&msg); err != nil {
+		t.Fatalf("failed to read the error: %v", err)
+	}
+
+	if msg.Type != api.ExecMessageError || msg.Error != "machine is not running" {
+		t.Errorf("client received %+v, want the dial error", msg)
+	}
+
+	if err := dec.Decode(&msg); err != io.EOF {
+		t.Errorf("read after the error, error = %v, want %v", err, io.EOF)
+	}
+}
@@ -0,0 +1,37 @@
+package cloudhypervisor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SendMigration sends the VM to a VMM receiving the migration at url, it returns once the
+// migration is complete. The VM of the sending VMM is then gone.
+func (v *VMM) SendMigration(ctx context.Context, url string) error {
+	res, err := v.client.PutVmSendMigrationWithResponse(ctx, SendMigrationData{DestinationUrl: url})
+	if err != nil {
+		return fmt.Errorf("failed to send migration: %w", err)
+	}
+
+	if res.StatusCode() != http.StatusOK && res.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("failed to send migration: %s", string(res.Body))
+	}
+
+	return nil
+}
+
+// ReceiveMigration listens on url for the migration of a VM, it returns once the VM is received
+// and running.
+func (v *VMM) ReceiveMigration(ctx context.Context, url string) error {
+	res, err := v.client.PutVmReceiveMigrationWithResponse(ctx, ReceiveMigrationData{ReceiverUrl: url})
+	if err != nil {
+		return fmt.Errorf("failed to receive migration: %w", err)
+	}
+
+	if res.StatusCode() != http.StatusOK && res.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("failed to receive migration: %s", string(res.Body))
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package cloudhypervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// VmResizeDisk is the body of the vm.resize-disk endpoint, which is more recent
+// than the spec the client is generated from.
+type VmResizeDisk struct {
+	Id          string `json:"id"`
+	DesiredSize int64  `json:"desired_size"`
+}
+
+// ResizeDisk notifies the guest of the new size of a disk, its backing file or device
+// must already have been grown.
+func (v *VMM) ResizeDisk(ctx context.Context, id string, size int64) error {
+	client, ok := v.client.ClientInterface.(*Client)
+	if !ok {
+		return fmt.Errorf("failed to resize disk: unexpected client type")
+	}
+
+	body, err := json.Marshal(VmResizeDisk{Id: id, DesiredSize: size})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, client.Server+"vm.resize-disk", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to resize disk: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("failed to resize disk: %s", string(msg))
+	}
+
+	return nil
+}
@@ -124,6 +124,17 @@ func (c *Client) PutDrive(ctx context.Context, drive Drive) error {
 	return checkResponse(resp)
 }
 
+// PatchDrive updates a block device of a running VM.
+func (c *Client) PatchDrive(ctx context.Context, drive PartialDrive) error {
+	resp, err := c.doRequest(ctx, http.MethodPatch, "/drives/"+drive.DriveID, drive)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
 // PutNetworkInterface adds or updates a network interface.
 func (c *Client) PutNetworkInterface(ctx context.Context, iface NetworkInterface) error {
 	resp, err := c.doRequest(ctx, http.MethodPut, "/network-interfaces/"+iface.IfaceID, iface)
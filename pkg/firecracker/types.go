@@ -23,6 +23,12 @@ type Drive struct {
 	IsReadOnly   bool   `json:"is_read_only"`
 }
 
+// PartialDrive updates a block device after the VM has started.
+type PartialDrive struct {
+	DriveID    string `json:"drive_id"`
+	PathOnHost string `json:"path_on_host,omitempty"`
+}
+
 // NetworkInterface specifies a network interface configuration.
 type NetworkInterface struct {
 	IfaceID     string  `json:"iface_id"`
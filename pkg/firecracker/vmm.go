@@ -89,6 +89,14 @@ func (v *VMM) AddDrive(ctx context.Context, driveID, path string, isRoot, readOn
 	return nil
 }
 
+// RescanDrive makes the guest see the new size of a drive whose backing device has been resized.
+func (v *VMM) RescanDrive(ctx context.Context, driveID, path string) error {
+	if err := v.client.PatchDrive(ctx, PartialDrive{DriveID: driveID, PathOnHost: path}); err != nil {
+		return fmt.Errorf("failed to update drive %s: %w", driveID, err)
+	}
+	return nil
+}
+
 // AddNetworkInterface adds a network interface to the VM.
 func (v *VMM) AddNetworkInterface(ctx context.Context, ifaceID, hostDevName string) error {
 	iface := NetworkInterface{
@@ -151,6 +159,22 @@ func (v *VMM) Snapshot(ctx context.Context, snapshotPath, memFilePath string) er
 		return fmt.Errorf("failed to pause before snapshot: %w", err)
 	}
 
+	if err := v.CreateSnapshot(ctx, snapshotPath, memFilePath); err != nil {
+		// Try to resume on failure
+		v.Resume(ctx)
+		return err
+	}
+
+	// Resume VM after snapshot
+	if err := v.Resume(ctx); err != nil {
+		return fmt.Errorf("failed to resume after snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// CreateSnapshot creates a snapshot of a paused microVM, it is left paused.
+func (v *VMM) CreateSnapshot(ctx context.Context, snapshotPath, memFilePath string) error {
 	snapshotType := "Full"
 	params := SnapshotCreateParams{
 		SnapshotPath: snapshotPath,
@@ -158,16 +182,9 @@ func (v *VMM) Snapshot(ctx context.Context, snapshotPath, memFilePath string) er
 		SnapshotType: &snapshotType,
 	}
 	if err := v.client.CreateSnapshot(ctx, params); err != nil {
-		// Try to resume on failure
-		v.Resume(ctx)
 		return fmt.Errorf("failed to create snapshot: %w", err)
 	}
 
-	// Resume VM after snapshot
-	if err := v.Resume(ctx); err != nil {
-		return fmt.Errorf("failed to resume after snapshot: %w", err)
-	}
-
 	return nil
 }
 
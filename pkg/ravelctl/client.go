@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
+	"golang.org/x/net/websocket"
 )
 
 type Client struct {
@@ -91,9 +92,9 @@ func (c *Client) ListFleets(namespace string) ([]api.Fleet, error) {
 	return result, err
 }
 
-func (c *Client) CreateFleet(namespace, name string) (*api.Fleet, error) {
+func (c *Client) CreateFleet(namespace string, payload api.CreateFleetPayload) (*api.Fleet, error) {
 	var result api.Fleet
-	err := c.do("POST", "/fleets?namespace="+url.QueryEscape(namespace), map[string]string{"name": name}, &result)
+	err := c.do("POST", "/fleets?namespace="+url.QueryEscape(namespace), payload, &result)
 	return &result, err
 }
 
@@ -121,6 +122,12 @@ func (c *Client) CreateMachine(namespace, fleet string, req *api.CreateMachinePa
 	return &result, err
 }
 
+func (c *Client) UpdateMachine(namespace, fleet, id string, req *api.UpdateMachinePayload) (*api.Machine, error) {
+	var result api.Machine
+	err := c.do("PATCH", fmt.Sprintf("/fleets/%s/machines/%s?namespace=%s", fleet, id, url.QueryEscape(namespace)), req, &result)
+	return &result, err
+}
+
 func (c *Client) StartMachine(namespace, fleet, id string) error {
 	return c.do("POST", fmt.Sprintf("/fleets/%s/machines/%s/start?namespace=%s", fleet, id, url.QueryEscape(namespace)), nil, nil)
 }
@@ -137,8 +144,11 @@ func (c *Client) DeleteMachine(namespace, fleet, id string, force bool) error {
 	return c.do("DELETE", path, nil, nil)
 }
 
-func (c *Client) GetMachineLogs(namespace, fleet, id string) (string, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/fleets/%s/machines/%s/logs?namespace=%s", c.baseURL, fleet, id, url.QueryEscape(namespace)), nil)
+func (c *Client) GetMachineLogs(namespace, fleet, id string, opts api.LogsOptions) (string, error) {
+	query := opts.Values()
+	query.Set("namespace", namespace)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/fleets/%s/machines/%s/logs?%s", c.baseURL, fleet, id, query.Encode()), nil)
 	if err != nil {
 		return "", err
 	}
@@ -159,6 +169,102 @@ func (c *Client) GetMachineLogs(namespace, fleet, id string) (string, error) {
 	return string(body), nil
 }
 
+// ExecStream starts a streamed exec in a machine, or attaches to it, and returns the
+// body of the response holding its output lines.
+func (c *Client) ExecStream(namespace, fleet, id string, payload api.ExecStreamRequest) (io.ReadCloser, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/fleets/%s/machines/%s/exec/stream?namespace=%s", c.baseURL, fleet, id, url.QueryEscape(namespace)), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// the output is streamed as long as the command runs, the client timeout does not apply
+	streamClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp.Body, nil
+}
+
+// ExecSession opens an interactive exec session with a machine, see api.ExecSessionOptions.
+func (c *Client) ExecSession(namespace, fleet, id string) (*websocket.Conn, error) {
+	location, err := url.Parse(fmt.Sprintf("%s/fleets/%s/machines/%s/exec/tty?namespace=%s", c.baseURL, fleet, id, url.QueryEscape(namespace)))
+	if err != nil {
+		return nil, err
+	}
+
+	origin := *location
+	origin.Path = ""
+	origin.RawQuery = ""
+
+	if location.Scheme == "https" {
+		location.Scheme = "wss"
+	} else {
+		location.Scheme = "ws"
+	}
+
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		config.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return websocket.DialConfig(config)
+}
+
+// Machine snapshots
+
+func (c *Client) ListMachineSnapshots(namespace, fleet, id string) ([]api.MachineSnapshot, error) {
+	var result []api.MachineSnapshot
+	err := c.do("GET", fmt.Sprintf("/fleets/%s/machines/%s/snapshots?namespace=%s", fleet, id, url.QueryEscape(namespace)), nil, &result)
+	return result, err
+}
+
+func (c *Client) CreateMachineSnapshot(namespace, fleet, id string) (*api.MachineSnapshot, error) {
+	var result api.MachineSnapshot
+	err := c.do("POST", fmt.Sprintf("/fleets/%s/machines/%s/snapshots?namespace=%s", fleet, id, url.QueryEscape(namespace)), nil, &result)
+	return &result, err
+}
+
+func (c *Client) DeleteMachineSnapshot(namespace, fleet, id, snapshot string) error {
+	return c.do("DELETE", fmt.Sprintf("/fleets/%s/machines/%s/snapshots/%s?namespace=%s", fleet, id, snapshot, url.QueryEscape(namespace)), nil, nil)
+}
+
+func (c *Client) RestoreMachineSnapshot(namespace, fleet, id, snapshot string) error {
+	return c.do("POST", fmt.Sprintf("/fleets/%s/machines/%s/snapshots/%s/restore?namespace=%s", fleet, id, snapshot, url.QueryEscape(namespace)), nil, nil)
+}
+
+func (c *Client) ForkMachine(namespace, fleet, id string, count int) ([]api.Machine, error) {
+	var result []api.Machine
+	err := c.do("POST", fmt.Sprintf("/fleets/%s/machines/%s/fork?count=%d&namespace=%s", fleet, id, count, url.QueryEscape(namespace)), nil, &result)
+	return result, err
+}
+
+func (c *Client) MigrateMachine(namespace, fleet, id string, node string) (*api.Machine, error) {
+	var result api.Machine
+	err := c.do("POST", fmt.Sprintf("/fleets/%s/machines/%s/migrate?node=%s&namespace=%s", fleet, id, url.QueryEscape(node), url.QueryEscape(namespace)), nil, &result)
+	return &result, err
+}
+
 // Gateways
 
 func (c *Client) ListGateways(namespace, fleet string) ([]api.Gateway, error) {
@@ -167,10 +273,9 @@ func (c *Client) ListGateways(namespace, fleet string) ([]api.Gateway, error) {
 	return result, err
 }
 
-func (c *Client) CreateGateway(namespace, fleet, name string, targetPort int) (*api.Gateway, error) {
+func (c *Client) CreateGateway(namespace, fleet string, payload api.CreateGatewayPayload) (*api.Gateway, error) {
 	var result api.Gateway
-	err := c.do("POST", fmt.Sprintf("/fleets/%s/gateways?namespace=%s", fleet, url.QueryEscape(namespace)),
-		map[string]interface{}{"name": name, "target_port": targetPort}, &result)
+	err := c.do("POST", fmt.Sprintf("/fleets/%s/gateways?namespace=%s", fleet, url.QueryEscape(namespace)), payload, &result)
 	return &result, err
 }
 
@@ -178,6 +283,149 @@ func (c *Client) DeleteGateway(namespace, fleet, name string) error {
 	return c.do("DELETE", fmt.Sprintf("/fleets/%s/gateways/%s?namespace=%s", fleet, name, url.QueryEscape(namespace)), nil, nil)
 }
 
+func (c *Client) ListGatewayDomains(namespace, fleet, gateway string) ([]api.GatewayDomain, error) {
+	var result []api.GatewayDomain
+	err := c.do("GET", fmt.Sprintf("/fleets/%s/gateways/%s/domains?namespace=%s", fleet, gateway, url.QueryEscape(namespace)), nil, &result)
+	return result, err
+}
+
+func (c *Client) AddGatewayDomain(namespace, fleet, gateway, hostname string) (*api.GatewayDomain, error) {
+	var result api.GatewayDomain
+	err := c.do("POST", fmt.Sprintf("/fleets/%s/gateways/%s/domains?namespace=%s", fleet, gateway, url.QueryEscape(namespace)),
+		api.AddGatewayDomainPayload{Hostname: hostname}, &result)
+	return &result, err
+}
+
+func (c *Client) VerifyGatewayDomain(namespace, fleet, gateway, hostname string) (*api.GatewayDomain, error) {
+	var result api.GatewayDomain
+	err := c.do("POST", fmt.Sprintf("/fleets/%s/gateways/%s/domains/%s/verify?namespace=%s", fleet, gateway, hostname, url.QueryEscape(namespace)), nil, &result)
+	return &result, err
+}
+
+func (c *Client) DeleteGatewayDomain(namespace, fleet, gateway, hostname string) error {
+	return c.do("DELETE", fmt.Sprintf("/fleets/%s/gateways/%s/domains/%s?namespace=%s", fleet, gateway, hostname, url.QueryEscape(namespace)), nil, nil)
+}
+
+// Networks
+
+func (c *Client) ListNetworks(namespace string) ([]api.Network, error) {
+	var result []api.Network
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/networks", nil, &result)
+	return result, err
+}
+
+func (c *Client) GetNetwork(namespace, name string) (*api.Network, error) {
+	var result api.Network
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/networks/"+name, nil, &result)
+	return &result, err
+}
+
+func (c *Client) CreateNetwork(namespace string, payload api.CreateNetworkPayload) (*api.Network, error) {
+	var result api.Network
+	err := c.do("POST", "/namespaces/"+url.PathEscape(namespace)+"/networks", payload, &result)
+	return &result, err
+}
+
+func (c *Client) DeleteNetwork(namespace, name string) error {
+	return c.do("DELETE", "/namespaces/"+url.PathEscape(namespace)+"/networks/"+name, nil, nil)
+}
+
+// Volumes
+
+func (c *Client) ListVolumes(namespace string) ([]api.Volume, error) {
+	var result []api.Volume
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/volumes", nil, &result)
+	return result, err
+}
+
+func (c *Client) GetVolume(namespace, name string) (*api.Volume, error) {
+	var result api.Volume
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/volumes/"+name, nil, &result)
+	return &result, err
+}
+
+func (c *Client) CreateVolume(namespace string, payload api.CreateVolumePayload) (*api.Volume, error) {
+	var result api.Volume
+	err := c.do("POST", "/namespaces/"+url.PathEscape(namespace)+"/volumes", payload, &result)
+	return &result, err
+}
+
+func (c *Client) ResizeVolume(namespace, name string, payload api.ResizeVolumePayload) (*api.Volume, error) {
+	var result api.Volume
+	err := c.do("PATCH", "/namespaces/"+url.PathEscape(namespace)+"/volumes/"+name, payload, &result)
+	return &result, err
+}
+
+func (c *Client) DeleteVolume(namespace, name string) error {
+	return c.do("DELETE", "/namespaces/"+url.PathEscape(namespace)+"/volumes/"+name, nil, nil)
+}
+
+func (c *Client) ListVolumeSnapshots(namespace, volume string) ([]api.VolumeSnapshot, error) {
+	var result []api.VolumeSnapshot
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/volumes/"+volume+"/snapshots", nil, &result)
+	return result, err
+}
+
+func (c *Client) CreateVolumeSnapshot(namespace, volume string) (*api.VolumeSnapshot, error) {
+	var result api.VolumeSnapshot
+	err := c.do("POST", "/namespaces/"+url.PathEscape(namespace)+"/volumes/"+volume+"/snapshots", nil, &result)
+	return &result, err
+}
+
+func (c *Client) DeleteVolumeSnapshot(namespace, volume, snapshot string) error {
+	return c.do("DELETE", "/namespaces/"+url.PathEscape(namespace)+"/volumes/"+volume+"/snapshots/"+snapshot, nil, nil)
+}
+
+func (c *Client) RestoreVolumeSnapshot(namespace, volume, snapshot string) error {
+	return c.do("POST", "/namespaces/"+url.PathEscape(namespace)+"/volumes/"+volume+"/snapshots/"+snapshot+"/restore", nil, nil)
+}
+
+// Sandbox pools
+
+func (c *Client) ListSandboxPools(namespace string) ([]api.SandboxPool, error) {
+	var result []api.SandboxPool
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/sandbox-pools", nil, &result)
+	return result, err
+}
+
+func (c *Client) GetSandboxPool(namespace, name string) (*api.SandboxPool, error) {
+	var result api.SandboxPool
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/sandbox-pools/"+name, nil, &result)
+	return &result, err
+}
+
+func (c *Client) CreateSandboxPool(namespace string, payload api.CreateSandboxPoolPayload) (*api.SandboxPool, error) {
+	var result api.SandboxPool
+	err := c.do("POST", "/namespaces/"+url.PathEscape(namespace)+"/sandbox-pools", payload, &result)
+	return &result, err
+}
+
+func (c *Client) DeleteSandboxPool(namespace, name string) error {
+	return c.do("DELETE", "/namespaces/"+url.PathEscape(namespace)+"/sandbox-pools/"+name, nil, nil)
+}
+
+func (c *Client) GetSandboxPoolStats(namespace, name string) (*api.SandboxPoolStats, error) {
+	var result api.SandboxPoolStats
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/sandbox-pools/"+name+"/stats", nil, &result)
+	return &result, err
+}
+
+func (c *Client) ListSandboxes(namespace, pool string) ([]api.Sandbox, error) {
+	var result []api.Sandbox
+	err := c.do("GET", "/namespaces/"+url.PathEscape(namespace)+"/sandbox-pools/"+pool+"/sandboxes", nil, &result)
+	return result, err
+}
+
+func (c *Client) ClaimSandbox(namespace, pool string, payload api.ClaimSandboxPayload) (*api.Sandbox, error) {
+	var result api.Sandbox
+	err := c.do("POST", "/namespaces/"+url.PathEscape(namespace)+"/sandbox-pools/"+pool+"/claim", payload, &result)
+	return &result, err
+}
+
+func (c *Client) ReleaseSandbox(namespace, pool, sandboxId string) error {
+	return c.do("POST", "/namespaces/"+url.PathEscape(namespace)+"/sandbox-pools/"+pool+"/sandboxes/"+sandboxId+"/release", nil, nil)
+}
+
 // Nodes
 
 func (c *Client) ListNodes() ([]api.Node, error) {
@@ -185,3 +433,23 @@ func (c *Client) ListNodes() ([]api.Node, error) {
 	err := c.do("GET", "/nodes", nil, &result)
 	return result, err
 }
+
+func (c *Client) CordonNode(id string) error {
+	return c.do("POST", fmt.Sprintf("/nodes/%s/cordon", id), nil, nil)
+}
+
+func (c *Client) UncordonNode(id string) error {
+	return c.do("POST", fmt.Sprintf("/nodes/%s/uncordon", id), nil, nil)
+}
+
+func (c *Client) DrainNode(id string) (*api.NodeDrain, error) {
+	var result api.NodeDrain
+	err := c.do("POST", fmt.Sprintf("/nodes/%s/drain", id), nil, &result)
+	return &result, err
+}
+
+func (c *Client) GetNodeDrain(id string) (*api.NodeDrain, error) {
+	var result api.NodeDrain
+	err := c.do("GET", fmt.Sprintf("/nodes/%s/drain", id), nil, &result)
+	return &result, err
+}
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/config"
+	"github.com/alexisbouchez/ravel/ravel/state/db"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certStore persists the data of the certificate cache, implemented by db.DB.
+type certStore interface {
+	GetCertificateData(ctx context.Context, key string) ([]byte, error)
+	PutCertificateData(ctx context.Context, key string, data []byte) error
+	DeleteCertificateData(ctx context.Context, key string) error
+}
+
+var _ certStore = (*db.DB)(nil)
+
+// certCache stores the ACME account key, the certificates and the HTTP-01 challenge
+// tokens in Postgres so every proxy replica shares them.
+type certCache struct {
+	db certStore
+}
+
+var _ autocert.Cache = (*certCache)(nil)
+
+func (c *certCache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.db.GetCertificateData(ctx, key)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, autocert.ErrCacheMiss
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *certCache) Put(ctx context.Context, key string, data []byte) error {
+	return c.db.PutCertificateData(ctx, key, data)
+}
+
+func (c *certCache) Delete(ctx context.Context, key string) error {
+	return c.db.DeleteCertificateData(ctx, key)
+}
+
+func newACMEClient(c *config.ACMEConfig) (*acme.Client, error) {
+	client := &acme.Client{
+		DirectoryURL: c.DirectoryURL,
+	}
+
+	if client.DirectoryURL == "" {
+		client.DirectoryURL = autocert.DefaultACMEDirectory
+	}
+
+	if c.CaFile != "" {
+		bytes, err := os.ReadFile(c.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read acme ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bytes) {
+			return nil, errors.New("failed to append acme CA certificate")
+		}
+
+		client.HTTPClient = &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		}
+	}
+
+	return client, nil
+}
+
+// newCertManager returns a manager issuing and renewing the certificates of the hosts
+// served by the proxy with the HTTP-01 and TLS-ALPN-01 challenges.
+func (p *Proxy) newCertManager(c *config.ACMEConfig) (*autocert.Manager, error) {
+	client, err := newACMEClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Email:      c.Email,
+		Client:     client,
+		Cache:      &certCache{db: p.db},
+		HostPolicy: p.hostPolicy,
+	}, nil
+}
+
+// hostPolicy only lets the manager request certificates for the hosts served by a gateway.
+func (p *Proxy) hostPolicy(ctx context.Context, host string) error {
+	if _, ok := p.lookup(host); !ok {
+		return fmt.Errorf("host %q is not served by this proxy", host)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+//go:build integration
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/core/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// The tests run against a local Pebble, started with PEBBLE_VA_ALWAYS_VALID=0 and a resolver
+// mapping the test host to this machine, or with PEBBLE_VA_ALWAYS_VALID=1 to skip the
+// validation:
+//
+//	PEBBLE_CA_FILE=pebble/test/certs/pebble.minica.pem go test -tags integration ./proxy/
+const (
+	defaultPebbleDirectory = "https://localhost:14000/dir"
+	defaultPebbleHost      = "ravel.test"
+	pebbleTLSALPNAddress   = ":5001"
+	pebbleHTTPAddress      = ":5002"
+)
+
+func newPebbleManager(t *testing.T) (*autocert.Manager, *memoryCertStore, string) {
+	t.Helper()
+
+	caFile := os.Getenv("PEBBLE_CA_FILE")
+	if caFile == "" {
+		t.Skip("PEBBLE_CA_FILE is not set")
+	}
+
+	directory := os.Getenv("PEBBLE_DIRECTORY")
+	if directory == "" {
+		directory = defaultPebbleDirectory
+	}
+
+	host := os.Getenv("PEBBLE_TEST_HOST")
+	if host == "" {
+		host = defaultPebbleHost
+	}
+
+	client, err := newACMEClient(&config.ACMEConfig{DirectoryURL: directory, CaFile: caFile})
+	if err != nil {
+		t.Fatalf("failed to create acme client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Discover(ctx); err != nil {
+		t.Skipf("pebble is not available: %v", err)
+	}
+
+	store := &memoryCertStore{data: map[string][]byte{}}
+	m := &autocert.Manager{
+		Prompt: autocert.AcceptTOS,
+		Client: client,
+		Cache:  &certCache{db: store},
+		HostPolicy: func(ctx context.Context, h string) error {
+			if h != host {
+				return errors.New("unexpected host")
+			}
+			return nil
+		},
+	}
+
+	return m, store, host
+}
+
+func serve(t *testing.T, address string, server *http.Server, tlsConfig *tls.Config) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", address, err)
+	}
+
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Close() })
+}
+
+func getCertificate(t *testing.T, m *autocert.Manager, store *memoryCertStore, host string) {
+	t.Helper()
+
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: host})
+	if err != nil {
+		t.Fatalf("failed to get certificate: %v", err)
+	}
+
+	if !slices.Contains(cert.Leaf.DNSNames, host) {
+		t.Errorf("got certificate for %v, want %s", cert.Leaf.DNSNames, host)
+	}
+
+	if _, ok := store.data[host]; !ok {
+		t.Error("expected the certificate to be stored in the cache")
+	}
+
+	// a second manager sharing the store, like another proxy replica, serves the certificate
+	// without a new order
+	replica := &autocert.Manager{Prompt: autocert.AcceptTOS, Cache: &certCache{db: store}}
+	if _, err := replica.GetCertificate(&tls.ClientHelloInfo{ServerName: host}); err != nil {
+		t.Errorf("failed to get the cached certificate from another replica: %v", err)
+	}
+}
+
+func TestPebbleHTTP01(t *testing.T) {
+	m, store, host := newPebbleManager(t)
+
+	serve(t, pebbleHTTPAddress, &http.Server{Handler: m.HTTPHandler(nil)}, nil)
+
+	getCertificate(t, m, store, host)
+}
+
+func TestPebbleTLSALPN01(t *testing.T) {
+	m, store, host := newPebbleManager(t)
+
+	serve(t, pebbleTLSALPNAddress, &http.Server{}, m.TLSConfig())
+
+	getCertificate(t, m, store, host)
+}
@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// memoryCertStore is an in memory certStore, like the certificates table.
+type memoryCertStore struct {
+	data map[string][]byte
+}
+
+func (m *memoryCertStore) GetCertificateData(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m.data[key]
+	if !ok {
+		return nil, errdefs.NewNotFound("certificate not found")
+	}
+	return data, nil
+}
+
+func (m *memoryCertStore) PutCertificateData(ctx context.Context, key string, data []byte) error {
+	m.data[key] = data
+	return nil
+}
+
+func (m *memoryCertStore) DeleteCertificateData(ctx context.Context, key string) error {
+	delete(m.data, key)
+	return nil
+}
+
+func TestCertCache(t *testing.T) {
+	ctx := context.Background()
+	cache := &certCache{db: &memoryCertStore{data: map[string][]byte{}}}
+
+	if _, err := cache.Get(ctx, "www.example.com"); !errors.Is(err, autocert.ErrCacheMiss) {
+		t.Fatalf("Get() of a missing key error = %v, want %v", err, autocert.ErrCacheMiss)
+	}
+
+	if err := cache.Put(ctx, "www.example.com", []byte("cert")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	data, err := cache.Get(ctx, "www.example.com")
+	if err != nil || !bytes.Equal(data, []byte("cert")) {
+		t.Fatalf("Get() = %q, %v, want %q", data, err, "cert")
+	}
+
+	if err := cache.Put(ctx, "www.example.com", []byte("renewed")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if data, _ := cache.Get(ctx, "www.example.com"); !bytes.Equal(data, []byte("renewed")) {
+		t.Errorf("Get() after a renewal = %q, want %q", data, "renewed")
+	}
+
+	if err := cache.Delete(ctx, "www.example.com"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := cache.Get(ctx, "www.example.com"); !errors.Is(err, autocert.ErrCacheMiss) {
+		t.Errorf("Get() of a deleted key error = %v, want %v", err, autocert.ErrCacheMiss)
+	}
+}
+
+func TestHostPolicy(t *testing.T) {
+	p := &Proxy{domain: "ravel.dev"}
+	p.routes.Store(buildRoutingTable(
+		[]api.Gateway{{Id: "gw-1", Name: "web", FleetId: "fleet-1", Protocol: "https", TargetPort: 8080}},
+		[]api.GatewayDomain{
+			{Hostname: "www.example.com", GatewayId: "gw-1", Status: api.GatewayDomainStatusVerified},
+			{Hostname: "pending.example.com", GatewayId: "gw-1", Status: api.GatewayDomainStatusPending},
+		},
+		map[string][]cluster.MachineInstance{},
+	))
+
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{"web.ravel.dev", true},
+		{"WEB.ravel.dev", true},
+		{"www.example.com", true},
+		{"pending.example.com", false},
+		{"unknown.ravel.dev", false},
+		{"ravel.dev", false},
+		{"attacker.example.org", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			err := p.hostPolicy(context.Background(), tt.host)
+			if (err == nil) != tt.allowed {
+				t.Errorf("hostPolicy(%q) error = %v, want allowed %v", tt.host, err, tt.allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	l4DialTimeout     = 5 * time.Second
+	udpSessionTimeout = 2 * time.Minute
+	maxDatagramSize   = 64 * 1024
+)
+
+// syncListeners opens a listener for each tcp and udp gateway of the routing table
+// and closes the listeners of the gateways which have been removed.
+func (p *Proxy) syncListeners(table *routingTable) {
+	p.listenersMu.Lock()
+	defer p.listenersMu.Unlock()
+
+	if p.listeners == nil {
+		return // the proxy is shutting down
+	}
+
+	for key, l := range p.listeners {
+		if _, ok := table.getByPort(key); !ok {
+			slog.Info("Closing gateway listener", "protocol", key.protocol, "port", key.port)
+			l.Close()
+			delete(p.listeners, key)
+		}
+	}
+
+	for key := range table.ports {
+		if _, ok := p.listeners[key]; ok {
+			continue
+		}
+
+		l, err := p.listen(key)
+		if err != nil {
+			slog.Error("failed to open gateway listener", "protocol", key.protocol, "port", key.port, "error", err)
+			continue // retried on the next refresh
+		}
+
+		slog.Info("Opened gateway listener", "protocol", key.protocol, "port", key.port)
+		p.listeners[key] = l
+	}
+}
+
+func (p *Proxy) closeListeners() {
+	p.listenersMu.Lock()
+	defer p.listenersMu.Unlock()
+
+	for _, l := range p.listeners {
+		l.Close()
+	}
+	p.listeners = nil
+}
+
+func (p *Proxy) listen(key listenerKey) (io.Closer, error) {
+	address := net.JoinHostPort(p.l4ListenIP, strconv.Itoa(key.port))
+
+	if key.protocol == "udp" {
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return nil, err
+		}
+
+		l := &udpListener{
+			proxy:    p,
+			key:      key,
+			conn:     conn,
+			sessions: map[string]*udpSession{},
+		}
+		go l.serve()
+
+		return l, nil
+	}
+
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	go p.serveTCP(ln, key)
+
+	return ln, nil
+}
+
+func (p *Proxy) serveTCP(ln net.Listener, key listenerKey) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			slog.Debug("failed to accept connection", "port", key.port, "error", err)
+			continue
+		}
+
+		go p.handleTCP(conn, key)
+	}
+}
+
+func (p *Proxy) handleTCP(conn net.Conn, key listenerKey) {
+	defer conn.Close()
+
+	u, ok := p.routes.Load().getByPort(key)
+	if !ok {
+		return
+	}
+
+	backend, ok := u.next()
+	if !ok {
+		slog.Debug("no healthy machine available", "gateway", u.gateway.Name)
+		return
+	}
+
+	upstreamConn, err := net.DialTimeout("tcp", backend, l4DialTimeout)
+	if err != nil {
+		slog.Debug("failed to dial backend", "gateway", u.gateway.Name, "backend", backend, "error", err)
+		return
+	}
+	defer upstreamConn.Close()
+
+	if u.gateway.ProxyProtocol {
+		header := proxyProtocolHeader("tcp", addrPort(conn.RemoteAddr()), addrPort(conn.LocalAddr()))
+		if _, err := upstreamConn.Write(header); err != nil {
+			return
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		pipe(upstreamConn, conn)
+	}()
+	go func() {
+		defer wg.Done()
+		pipe(conn, upstreamConn)
+	}()
+	wg.Wait()
+}
+
+// pipe copies src to dst and half-closes dst once src is drained so the peer sees the EOF.
+func pipe(dst, src net.Conn) {
+	io.Copy(dst, src)
+	if c, ok := dst.(interface{ CloseWrite() error }); ok {
+		c.CloseWrite()
+		return
+	}
+	dst.Close()
+}
+
+// udpListener forwards the datagrams of each client to a backend picked when the client
+// sends its first datagram. Sessions expire after udpSessionTimeout without traffic.
+type udpListener struct {
+	proxy    *Proxy
+	key      listenerKey
+	conn     net.PacketConn
+	mu       sync.Mutex
+	sessions map[string]*udpSession
+}
+
+type udpSession struct {
+	client   net.Addr
+	upstream net.Conn
+	header   []byte // PROXY protocol header prepended to each datagram, if enabled
+}
+
+func (l *udpListener) Close() error {
+	err := l.conn.Close()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, s := range l.sessions {
+		s.upstream.Close()
+		delete(l.sessions, key)
+	}
+
+	return err
+}
+
+func (l *udpListener) serve() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := l.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		s, err := l.session(addr)
+		if err != nil {
+			slog.Debug("failed to open udp session", "port", l.key.port, "client", addr, "error", err)
+			continue
+		}
+
+		s.upstream.SetReadDeadline(time.Now().Add(udpSessionTimeout))
+
+		datagram := buf[:n]
+		if s.header != nil {
+			datagram = append(append(make([]byte, 0, len(s.header)+n), s.header...), datagram...)
+		}
+		s.upstream.Write(datagram)
+	}
+}
+
+func (l *udpListener) session(client net.Addr) (*udpSession, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if s, ok := l.sessions[client.String()]; ok {
+		return s, nil
+	}
+
+	u, ok := l.proxy.routes.Load().getByPort(l.key)
+	if !ok {
+		return nil, errors.New("gateway not found")
+	}
+
+	backend, ok := u.next()
+	if !ok {
+		return nil, errors.New("no healthy machine available")
+	}
+
+	upstream, err := net.DialTimeout("udp", backend, l4DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &udpSession{client: client, upstream: upstream}
+	if u.gateway.ProxyProtocol {
+		s.header = proxyProtocolHeader("udp", addrPort(client), addrPort(l.conn.LocalAddr()))
+	}
+
+	l.sessions[client.String()] = s
+	go l.reply(s)
+
+	return s, nil
+}
+
+// reply forwards the datagrams sent by the backend to the client until the session expires.
+func (l *udpListener) reply(s *udpSession) {
+	defer func() {
+		l.mu.Lock()
+		if l.sessions[s.client.String()] == s {
+			delete(l.sessions, s.client.String())
+		}
+		l.mu.Unlock()
+		s.upstream.Close()
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := s.upstream.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrDeadlineExceeded) && !errors.Is(err, net.ErrClosed) {
+				slog.Debug("udp session closed", "client", s.client, "error", err)
+			}
+			return
+		}
+
+		if _, err := l.conn.WriteTo(buf[:n], s.client); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Package proxy implements the Ravel HTTP edge proxy.
+//
+// The proxy watches the fleet gateways and the machine instances reported by the agents
+// and routes the requests made on <gateway-name>.<domain>, or on a verified custom domain
+// of the gateway, to the healthy machines of the gateway fleet on the gateway target port. The routing table is rebuilt in the background
+// and swapped atomically so in-flight requests are never dropped on reload.
+//
+// The tcp and udp gateways are served on a dedicated public port allocated by the server,
+// their raw traffic is forwarded to the machines with an optional PROXY protocol v2 header.
+//
+// When ACME is configured, the certificates of the served hosts are issued and renewed
+// with the HTTP-01 and TLS-ALPN-01 challenges and stored in Postgres.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alexisbouchez/ravel/core/config"
+	"github.com/alexisbouchez/ravel/ravel/state/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nats-io/nats.go"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	defaultHTTPAddress     = ":80"
+	defaultHTTPSAddress    = ":443"
+	defaultRefreshInterval = 5 // seconds
+)
+
+type Proxy struct {
+	domain          string
+	refreshInterval time.Duration
+	pgpool          *pgxpool.Pool
+	db              *db.DB
+	nc              *nats.Conn
+	routes          atomic.Pointer[routingTable]
+	refreshCh       chan struct{}
+	reverseProxy    *httputil.ReverseProxy
+	certManager     *autocert.Manager
+	server          *http.Server
+	tlsServer       *http.Server
+	l4ListenIP      string
+	listenersMu     sync.Mutex
+	listeners       map[listenerKey]io.Closer // tcp and udp gateways listeners
+	cancel          context.CancelFunc
+}
+
+type backendKey struct{}
+
+func New(c config.RavelConfig) (*Proxy, error) {
+	if c.Proxy == nil {
+		return nil, errors.New("proxy configuration is missing")
+	}
+
+	if c.Proxy.Domain == "" {
+		return nil, errors.New("proxy.domain is required")
+	}
+
+	pgpool, err := pgxpool.New(context.Background(), c.Proxy.PostgresURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var nc *nats.Conn
+	if c.Nats != nil {
+		nc, err = c.Nats.Connect()
+		if err != nil {
+			pgpool.Close()
+			return nil, err
+		}
+	}
+
+	address := c.Proxy.HTTPAddress
+	if address == "" {
+		address = defaultHTTPAddress
+	}
+
+	refreshInterval := c.Proxy.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = defaultRefreshInterval
+	}
+
+	p := &Proxy{
+		domain:          strings.ToLower(strings.Trim(c.Proxy.Domain, ".")),
+		refreshInterval: time.Duration(refreshInterval) * time.Second,
+		pgpool:          pgpool,
+		db:              db.New(pgpool),
+		nc:              nc,
+		refreshCh:       make(chan struct{}, 1),
+		l4ListenIP:      c.Proxy.ListenIP,
+		listeners:       map[listenerKey]io.Closer{},
+	}
+
+	p.routes.Store(newRoutingTable())
+
+	p.reverseProxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			backend := pr.In.Context().Value(backendKey{}).(string)
+			pr.SetURL(&url.URL{Scheme: "http", Host: backend})
+			pr.SetXForwarded()
+			pr.Out.Host = pr.In.Host
+		},
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          1024,
+			MaxIdleConnsPerHost:   32,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: 60 * time.Second,
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			slog.Debug("failed to proxy request", "host", r.Host, "backend", r.Context().Value(backendKey{}), "error", err)
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		},
+	}
+
+	p.server = &http.Server{
+		Addr:              address,
+		Handler:           p,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if c.Proxy.ACME != nil {
+		p.certManager, err = p.newCertManager(c.Proxy.ACME)
+		if err != nil {
+			p.close()
+			return nil, err
+		}
+
+		httpsAddress := c.Proxy.HTTPSAddress
+		if httpsAddress == "" {
+			httpsAddress = defaultHTTPSAddress
+		}
+
+		// Answer the HTTP-01 challenges and redirect everything else to HTTPS
+		p.server.Handler = p.certManager.HTTPHandler(nil)
+
+		p.tlsServer = &http.Server{
+			Addr:              httpsAddress,
+			Handler:           p,
+			TLSConfig:         p.certManager.TLSConfig(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upstream, ok := p.lookup(r.Host)
+	if !ok {
+		http.Error(w, "gateway not found", http.StatusNotFound)
+		return
+	}
+
+	backend, ok := upstream.next()
+	if !ok {
+		http.Error(w, "no healthy machine available", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), backendKey{}, backend)
+	p.reverseProxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// lookup returns the upstream serving a host, either a verified custom domain
+// or <gateway-name>.<domain>.
+func (p *Proxy) lookup(host string) (*upstream, bool) {
+	host = normalizeHost(host)
+	routes := p.routes.Load()
+
+	if u, ok := routes.getByDomain(host); ok {
+		return u, true
+	}
+
+	name, ok := gatewayName(host, p.domain)
+	if !ok {
+		return nil, false
+	}
+
+	return routes.get(name)
+}
+
+// Start loads the routing table, starts watching for changes and starts serving requests.
+func (p *Proxy) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	if err := p.refresh(ctx); err != nil {
+		cancel()
+		return fmt.Errorf("failed to load routes: %w", err)
+	}
+
+	if p.nc != nil {
+		// published by the servers once they stored the state of an instance
+		_, err := p.nc.Subscribe("machines.instances.stored", func(msg *nats.Msg) {
+			p.triggerRefresh()
+		})
+		if err != nil {
+			cancel()
+			return err
+		}
+	}
+
+	go p.watch(ctx)
+
+	ln, err := net.Listen("tcp", p.server.Addr)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	slog.Info("Starting proxy", "address", p.server.Addr, "domain", p.domain)
+
+	go p.server.Serve(ln)
+
+	if p.tlsServer != nil {
+		tlsLn, err := net.Listen("tcp", p.tlsServer.Addr)
+		if err != nil {
+			ln.Close()
+			cancel()
+			return err
+		}
+
+		slog.Info("Starting TLS proxy", "address", p.tlsServer.Addr)
+
+		go p.tlsServer.ServeTLS(tlsLn, "", "")
+	}
+
+	return nil
+}
+
+func (p *Proxy) Run(runCtx context.Context) {
+	<-runCtx.Done()
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	slog.Info("Shutting down proxy")
+	p.server.Shutdown(ctxTimeout)
+	if p.tlsServer != nil {
+		p.tlsServer.Shutdown(ctxTimeout)
+	}
+
+	p.cancel()
+	p.closeListeners()
+	p.close()
+}
+
+func (p *Proxy) close() {
+	if p.nc != nil {
+		p.nc.Close()
+	}
+	p.pgpool.Close()
+}
+
+// normalizeHost strips the port and the trailing dot of a request host and lowercases it.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// gatewayName extracts the gateway name from a request host of the form <gateway-name>.<domain>.
+func gatewayName(host string, domain string) (string, bool) {
+	host = normalizeHost(host)
+
+	name, ok := strings.CutSuffix(host, "."+domain)
+	if !ok || name == "" || strings.Contains(name, ".") {
+		return "", false
+	}
+
+	return name, true
+}
@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+)
+
+// proxyProtocolSignature is the signature starting every PROXY protocol v2 header.
+var proxyProtocolSignature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+const (
+	proxyProtocolV2Proxy = 0x21 // version 2, PROXY command
+	proxyProtocolInet    = 0x10
+	proxyProtocolInet6   = 0x20
+	proxyProtocolStream  = 0x01
+	proxyProtocolDgram   = 0x02
+)
+
+// addrPort returns the address and port of a tcp or udp address.
+func addrPort(addr net.Addr) netip.AddrPort {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.AddrPort()
+	case *net.UDPAddr:
+		return a.AddrPort()
+	}
+
+	ap, _ := netip.ParseAddrPort(addr.String())
+	return ap
+}
+
+// proxyProtocolHeader builds a PROXY protocol v2 header for a connection from src to dst.
+// The network is either "tcp" or "udp", for udp the header is prepended to each datagram.
+func proxyProtocolHeader(network string, src, dst netip.AddrPort) []byte {
+	srcAddr, dstAddr := src.Addr().Unmap(), dst.Addr().Unmap()
+
+	family := byte(proxyProtocolInet)
+	if !srcAddr.Is4() || !dstAddr.Is4() {
+		family = proxyProtocolInet6
+		srcAddr = netip.AddrFrom16(srcAddr.As16())
+		dstAddr = netip.AddrFrom16(dstAddr.As16())
+	}
+
+	transport := byte(proxyProtocolStream)
+	if network == "udp" {
+		transport = proxyProtocolDgram
+	}
+
+	addresses := append(srcAddr.AsSlice(), dstAddr.AsSlice()...)
+	addresses = binary.BigEndian.AppendUint16(addresses, src.Port())
+	addresses = binary.BigEndian.AppendUint16(addresses, dst.Port())
+
+	header := make([]byte, 0, 16+len(addresses))
+	header = append(header, proxyProtocolSignature...)
+	header = append(header, proxyProtocolV2Proxy, family|transport)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addresses)))
+	header = append(header, addresses...)
+
+	return header
+}
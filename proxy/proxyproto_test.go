@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+)
+
+func TestProxyProtocolHeader(t *testing.T) {
+	src := netip.MustParseAddrPort("203.0.113.7:51234")
+	dst := netip.MustParseAddrPort("198.51.100.1:5432")
+
+	header := proxyProtocolHeader("tcp", src, dst)
+
+	want := append([]byte{}, proxyProtocolSignature...)
+	want = append(want,
+		0x21, 0x11, 0x00, 0x0C, // v2 PROXY, TCP over IPv4, 12 bytes of addresses
+		203, 0, 113, 7,
+		198, 51, 100, 1,
+		0xC8, 0x22, // 51234
+		0x15, 0x38, // 5432
+	)
+
+	if !bytes.Equal(header, want) {
+		t.Fatalf("got header %x, want %x", header, want)
+	}
+}
+
+func TestProxyProtocolHeaderIPv6(t *testing.T) {
+	src := netip.MustParseAddrPort("[2001:db8::1]:4000")
+	dst := netip.MustParseAddrPort("198.51.100.1:27015")
+
+	header := proxyProtocolHeader("udp", src, dst)
+
+	if len(header) != 16+36 {
+		t.Fatalf("got header of %d bytes, want %d", len(header), 16+36)
+	}
+
+	if header[13] != 0x22 {
+		t.Errorf("got family and transport %#x, want UDP over IPv6 (0x22)", header[13])
+	}
+
+	// the IPv4 destination is mapped into an IPv6 address
+	dstAddr, _ := netip.AddrFromSlice(header[32:48])
+	if dstAddr.Unmap() != dst.Addr() {
+		t.Errorf("got destination %s, want %s", dstAddr, dst.Addr())
+	}
+}
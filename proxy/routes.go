@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+type upstream struct {
+	gateway  api.Gateway
+	backends []string
+	counter  atomic.Uint64
+}
+
+// next returns the backend to use for the next request, backends are picked in a round robin fashion.
+func (u *upstream) next() (string, bool) {
+	if len(u.backends) == 0 {
+		return "", false
+	}
+
+	n := u.counter.Add(1) - 1
+	return u.backends[n%uint64(len(u.backends))], true
+}
+
+// listenerKey identifies the public port of a tcp or udp gateway.
+type listenerKey struct {
+	protocol string
+	port     int
+}
+
+type routingTable struct {
+	upstreams map[string]*upstream      // http gateways, indexed by gateway name
+	domains   map[string]string         // verified custom domains, hostname to gateway name
+	ports     map[listenerKey]*upstream // tcp and udp gateways, indexed by public port
+}
+
+func newRoutingTable() *routingTable {
+	return &routingTable{
+		upstreams: map[string]*upstream{},
+		domains:   map[string]string{},
+		ports:     map[listenerKey]*upstream{},
+	}
+}
+
+func (t *routingTable) getByPort(key listenerKey) (*upstream, bool) {
+	u, ok := t.ports[key]
+	return u, ok
+}
+
+func (t *routingTable) get(name string) (*upstream, bool) {
+	u, ok := t.upstreams[name]
+	return u, ok
+}
+
+func (t *routingTable) getByDomain(hostname string) (*upstream, bool) {
+	name, ok := t.domains[hostname]
+	if !ok {
+		return nil, false
+	}
+	return t.get(name)
+}
+
+func isHTTPGateway(gateway api.Gateway) bool {
+	return gateway.Protocol == "" || gateway.Protocol == api.GatewayProtocolHTTP || gateway.Protocol == api.GatewayProtocolHTTPS
+}
+
+// isRoutable reports whether an instance can receive traffic from the gateways of its fleet.
+func isRoutable(i cluster.MachineInstance) bool {
+	if i.Status != api.MachineStatusRunning || !i.EnableMachineGateway || i.LocalIPV4 == "" {
+		return false
+	}
+
+	return i.Health != api.HealthStatusUnhealthy && i.Health != api.HealthStatusStarting
+}
+
+// buildRoutingTable builds a routing table from the gateways, their verified custom domains
+// and the current instances of each fleet. Custom domains are only served by http gateways.
+func buildRoutingTable(gateways []api.Gateway, domains []api.GatewayDomain, instances map[string][]cluster.MachineInstance) *routingTable {
+	table := newRoutingTable()
+
+	names := make(map[string]string, len(gateways)) // gateway id to gateway name
+	for _, gateway := range gateways {
+		isL4 := gateway.IsL4() && gateway.PublicPort > 0
+		if !isHTTPGateway(gateway) && !isL4 {
+			continue
+		}
+
+		backends := []string{}
+		for _, i := range instances[gateway.FleetId] {
+			if !isRoutable(i) {
+				continue
+			}
+			backends = append(backends, net.JoinHostPort(i.LocalIPV4, strconv.Itoa(gateway.TargetPort)))
+		}
+		slices.Sort(backends)
+
+		u := &upstream{
+			gateway:  gateway,
+			backends: backends,
+		}
+
+		if isL4 {
+			table.ports[listenerKey{protocol: gateway.Protocol, port: gateway.PublicPort}] = u
+			continue
+		}
+
+		table.upstreams[gateway.Name] = u
+		names[gateway.Id] = gateway.Name
+	}
+
+	for _, domain := range domains {
+		if domain.Status != api.GatewayDomainStatusVerified {
+			continue
+		}
+
+		if name, ok := names[domain.GatewayId]; ok {
+			table.domains[domain.Hostname] = name
+		}
+	}
+
+	return table
+}
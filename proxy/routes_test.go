@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+func TestGatewayName(t *testing.T) {
+	tests := []struct {
+		host   string
+		name   string
+		wantOk bool
+	}{
+		{host: "web-abc.ravel.dev", name: "web-abc", wantOk: true},
+		{host: "WEB-abc.Ravel.dev:8080", name: "web-abc", wantOk: true},
+		{host: "web-abc.ravel.dev.", name: "web-abc", wantOk: true},
+		{host: "ravel.dev", wantOk: false},
+		{host: "a.b.ravel.dev", wantOk: false},
+		{host: "web-abc.example.com", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			name, ok := gatewayName(tt.host, "ravel.dev")
+			if ok != tt.wantOk {
+				t.Fatalf("gatewayName(%q) ok = %v, want %v", tt.host, ok, tt.wantOk)
+			}
+			if name != tt.name {
+				t.Errorf("gatewayName(%q) = %q, want %q", tt.host, name, tt.name)
+			}
+		})
+	}
+}
+
+func TestBuildRoutingTable(t *testing.T) {
+	gateways := []api.Gateway{
+		{Id: "gw-1", Name: "web", FleetId: "fleet-1", Protocol: "https", TargetPort: 8080},
+		{Id: "gw-2", Name: "empty", FleetId: "fleet-2", Protocol: "https", TargetPort: 80},
+		{Id: "gw-3", Name: "db", FleetId: "fleet-1", Protocol: "tcp", TargetPort: 5432, PublicPort: 30000},
+	}
+
+	instances := map[string][]cluster.MachineInstance{
+		"fleet-1": {
+			{Id: "i-1", Status: api.MachineStatusRunning, EnableMachineGateway: true, LocalIPV4: "10.0.0.2", Health: api.HealthStatusHealthy},
+			{Id: "i-2", Status: api.MachineStatusRunning, EnableMachineGateway: true, LocalIPV4: "10.0.0.1"},
+			{Id: "i-3", Status: api.MachineStatusRunning, EnableMachineGateway: true, LocalIPV4: "10.0.0.3", Health: api.HealthStatusUnhealthy},
+			{Id: "i-4", Status: api.MachineStatusStopped, EnableMachineGateway: true, LocalIPV4: "10.0.0.4"},
+			{Id: "i-5", Status: api.MachineStatusRunning, EnableMachineGateway: false, LocalIPV4: "10.0.0.5"},
+		},
+	}
+
+	domains := []api.GatewayDomain{
+		{Hostname: "www.example.com", GatewayId: "gw-1", Status: api.GatewayDomainStatusVerified},
+		{Hostname: "pending.example.com", GatewayId: "gw-1", Status: api.GatewayDomainStatusPending},
+		{Hostname: "orphan.example.com", GatewayId: "gw-4", Status: api.GatewayDomainStatusVerified},
+	}
+
+	table := buildRoutingTable(gateways, domains, instances)
+
+	web, ok := table.get("web")
+	if !ok {
+		t.Fatal("expected a route for the web gateway")
+	}
+
+	want := []string{"10.0.0.1:8080", "10.0.0.2:8080"}
+	if len(web.backends) != len(want) {
+		t.Fatalf("got backends %v, want %v", web.backends, want)
+	}
+
+	// backends are picked in a round robin fashion
+	for i := 0; i < 4; i++ {
+		backend, ok := web.next()
+		if !ok || backend != want[i%len(want)] {
+			t.Errorf("next() = %q, want %q", backend, want[i%len(want)])
+		}
+	}
+
+	empty, ok := table.get("empty")
+	if !ok {
+		t.Fatal("expected a route for the empty gateway")
+	}
+
+	if _, ok := empty.next(); ok {
+		t.Error("expected no backend for a gateway without machines")
+	}
+
+	if u, ok := table.getByDomain("www.example.com"); !ok || u != web {
+		t.Error("expected the verified domain to be routed to the web gateway")
+	}
+
+	if _, ok := table.getByDomain("pending.example.com"); ok {
+		t.Error("expected no route for a pending domain")
+	}
+
+	if _, ok := table.getByDomain("orphan.example.com"); ok {
+		t.Error("expected no route for a domain of an unknown gateway")
+	}
+
+	if _, ok := table.get("db"); ok {
+		t.Error("expected the tcp gateway not to be served over http")
+	}
+
+	db, ok := table.getByPort(listenerKey{protocol: "tcp", port: 30000})
+	if !ok {
+		t.Fatal("expected a route for the tcp gateway public port")
+	}
+
+	if backend, _ := db.next(); backend != "10.0.0.1:5432" {
+		t.Errorf("next() = %q, want %q", backend, "10.0.0.1:5432")
+	}
+}
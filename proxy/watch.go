@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+func (p *Proxy) refresh(ctx context.Context) error {
+	gateways, err := p.db.ListAllGateways(ctx)
+	if err != nil {
+		return err
+	}
+
+	domains, err := p.db.ListVerifiedGatewayDomains(ctx)
+	if err != nil {
+		return err
+	}
+
+	instances, err := p.db.ListFleetsInstances(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := buildRoutingTable(gateways, domains, instances)
+	p.routes.Store(table)
+	p.syncListeners(table)
+
+	return nil
+}
+
+func (p *Proxy) triggerRefresh() {
+	select {
+	case p.refreshCh <- struct{}{}:
+	default:
+	}
+}
+
+// watch rebuilds the routing table periodically and each time a server stores an instance change.
+func (p *Proxy) watch(ctx context.Context) {
+	ticker := time.NewTicker(p.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.refreshCh:
+		}
+
+		if err := p.refresh(ctx); err != nil {
+			slog.Error("failed to refresh proxy routes", "error", err)
+		}
+	}
+}
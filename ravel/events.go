@@ -18,12 +18,14 @@ func (r *Ravel) listenMachineEvents() error {
 			return
 		}
 
-		if event.Type == api.MachineDestroyed {
+		if event.Type == api.MachineDestroyed && r.isCurrentInstance(event) {
 			err = r.State.DestroyMachine(context.Background(), event.MachineId)
 			if err != nil {
 				slog.Info("failed to destroy machine", "error", err)
 				return
 			}
+
+			r.leaveNetworks(context.Background(), event.MachineId)
 		}
 
 		err = r.State.StoreMachineEvent(context.Background(), event)
@@ -43,3 +45,15 @@ func (r *Ravel) listenMachineEvents() error {
 
 	return nil
 }
+
+// isCurrentInstance reports whether the event is about the current instance of its machine,
+// the instance left on the previous node of a migrated machine is destroyed after the migration.
+func (r *Ravel) isCurrentInstance(event api.MachineEvent) bool {
+	machine, err := r.State.GetMachineById(context.Background(), event.MachineId)
+	if err != nil {
+		slog.Info("failed to get machine", "error", err)
+		return true
+	}
+
+	return machine.InstanceId == event.InstanceId
+}
@@ -6,10 +6,11 @@ import (
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster/placement"
 	"github.com/alexisbouchez/ravel/internal/id"
 )
 
-func (r *Ravel) CreateFleet(ctx context.Context, ns string, name string, metadata *api.Metadata) (*api.Fleet, error) {
+func (r *Ravel) CreateFleet(ctx context.Context, ns string, name string, metadata *api.Metadata, strategy api.PlacementStrategy) (*api.Fleet, error) {
 	if err := validateObjectName(name); err != nil {
 		return nil, errdefs.NewInvalidArgument(err.Error())
 	}
@@ -19,18 +20,23 @@ func (r *Ravel) CreateFleet(ctx context.Context, ns string, name string, metadat
 		return nil, err
 	}
 
+	if _, err := placement.NewScorer(strategy, 0); err != nil {
+		return nil, errdefs.NewInvalidArgument(err.Error())
+	}
+
 	namespace, err := r.GetNamespace(ctx, ns)
 	if err != nil {
 		return nil, err
 	}
 
 	fleet := api.Fleet{
-		Id:        id.GeneratePrefixed("fleet"),
-		Namespace: namespace.Name,
-		Name:      name,
-		CreatedAt: time.Now(),
-		Status:    api.FleetStatusActive,
-		Metadata:  metadata,
+		Id:                id.GeneratePrefixed("fleet"),
+		Namespace:         namespace.Name,
+		Name:              name,
+		CreatedAt:         time.Now(),
+		Status:            api.FleetStatusActive,
+		Metadata:          metadata,
+		PlacementStrategy: strategy,
 	}
 
 	err = r.State.CreateFleet(ctx, fleet)
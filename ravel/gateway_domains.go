@@ -0,0 +1,195 @@
+package ravel
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/id"
+)
+
+const domainChallengePrefix = "_ravel-challenge."
+
+type GatewayDomain = api.GatewayDomain
+
+type AddGatewayDomainOptions = api.AddGatewayDomainPayload
+
+func normalizeHostname(hostname string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(hostname), "."))
+}
+
+func (r *Ravel) gatewaysDomain() string {
+	return normalizeHostname(r.config.Server.GatewaysDomain)
+}
+
+// withVerification fills the DNS records the user must create to prove the ownership of the domain.
+func (r *Ravel) withVerification(gateway Gateway, domain GatewayDomain) GatewayDomain {
+	domain.Verification.TXTName = domainChallengePrefix + domain.Hostname
+	if gwDomain := r.gatewaysDomain(); gwDomain != "" {
+		domain.Verification.CNAMETarget = gateway.Name + "." + gwDomain
+	}
+	return domain
+}
+
+func (r *Ravel) AddGatewayDomain(ctx context.Context, namespace, fleet, gateway string, options AddGatewayDomainOptions) (GatewayDomain, error) {
+	hostname := normalizeHostname(options.Hostname)
+	if err := validateHostname(hostname); err != nil {
+		return GatewayDomain{}, err
+	}
+
+	if gwDomain := r.gatewaysDomain(); gwDomain != "" && (hostname == gwDomain || strings.HasSuffix(hostname, "."+gwDomain)) {
+		return GatewayDomain{}, errdefs.NewInvalidArgument("hostname cannot be a subdomain of the gateways domain")
+	}
+
+	g, err := r.getGateway(ctx, namespace, fleet, gateway)
+	if err != nil {
+		return GatewayDomain{}, err
+	}
+
+	domain := GatewayDomain{
+		Hostname:  hostname,
+		GatewayId: g.Id,
+		Namespace: g.Namespace,
+		Status:    api.GatewayDomainStatusPending,
+		CreatedAt: time.Now().UTC(),
+		Verification: api.GatewayDomainVerification{
+			TXTValue: id.GeneratePrefixed("ravel"),
+		},
+	}
+
+	if err := r.State.CreateGatewayDomain(ctx, domain); err != nil {
+		return GatewayDomain{}, err
+	}
+
+	domain = r.withVerification(g, domain)
+
+	// The records may already be in place, try to verify the domain right away.
+	verified, err := r.verifyGatewayDomain(ctx, domain)
+	if err != nil {
+		slog.Error("failed to verify gateway domain", "hostname", hostname, "error", err)
+		return domain, nil
+	}
+
+	return verified, nil
+}
+
+func (r *Ravel) ListGatewayDomains(ctx context.Context, namespace, fleet, gateway string) ([]GatewayDomain, error) {
+	g, err := r.getGateway(ctx, namespace, fleet, gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := r.State.ListGatewayDomains(ctx, g.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range domains {
+		domains[i] = r.withVerification(g, domains[i])
+	}
+
+	return domains, nil
+}
+
+func (r *Ravel) GetGatewayDomain(ctx context.Context, namespace, fleet, gateway, hostname string) (GatewayDomain, error) {
+	g, err := r.getGateway(ctx, namespace, fleet, gateway)
+	if err != nil {
+		return GatewayDomain{}, err
+	}
+
+	domain, err := r.State.GetGatewayDomain(ctx, g.Id, normalizeHostname(hostname))
+	if err != nil {
+		return GatewayDomain{}, err
+	}
+
+	return r.withVerification(g, domain), nil
+}
+
+// VerifyGatewayDomain checks the DNS records of a pending domain and marks it as verified
+// when one of them is in place. Once verified, the domain is served by the proxy.
+func (r *Ravel) VerifyGatewayDomain(ctx context.Context, namespace, fleet, gateway, hostname string) (GatewayDomain, error) {
+	g, err := r.getGateway(ctx, namespace, fleet, gateway)
+	if err != nil {
+		return GatewayDomain{}, err
+	}
+
+	domain, err := r.State.GetGatewayDomain(ctx, g.Id, normalizeHostname(hostname))
+	if err != nil {
+		return GatewayDomain{}, err
+	}
+
+	domain = r.withVerification(g, domain)
+	if domain.Status == api.GatewayDomainStatusVerified {
+		return domain, nil
+	}
+
+	domain, err = r.verifyGatewayDomain(ctx, domain)
+	if err != nil {
+		return GatewayDomain{}, err
+	}
+
+	if domain.Status != api.GatewayDomainStatusVerified {
+		return domain, errdefs.NewFailedPrecondition("domain verification failed: no matching TXT or CNAME record found for " + domain.Hostname)
+	}
+
+	return domain, nil
+}
+
+func (r *Ravel) verifyGatewayDomain(ctx context.Context, domain GatewayDomain) (GatewayDomain, error) {
+	if !checkDomainRecords(ctx, domain.Verification) {
+		return domain, nil
+	}
+
+	now := time.Now().UTC()
+	if err := r.State.MarkGatewayDomainVerified(ctx, domain.GatewayId, domain.Hostname, now); err != nil {
+		return domain, err
+	}
+
+	domain.Status = api.GatewayDomainStatusVerified
+	domain.VerifiedAt = &now
+
+	return domain, nil
+}
+
+func (r *Ravel) DeleteGatewayDomain(ctx context.Context, namespace, fleet, gateway, hostname string) error {
+	g, err := r.getGateway(ctx, namespace, fleet, gateway)
+	if err != nil {
+		return err
+	}
+
+	hostname = normalizeHostname(hostname)
+	if _, err := r.State.GetGatewayDomain(ctx, g.Id, hostname); err != nil {
+		return err
+	}
+
+	return r.State.DeleteGatewayDomain(ctx, g.Id, hostname)
+}
+
+var dnsResolver = net.DefaultResolver
+
+// checkDomainRecords reports whether the TXT challenge or the CNAME record of a domain is in place.
+func checkDomainRecords(ctx context.Context, v api.GatewayDomainVerification) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	txts, err := dnsResolver.LookupTXT(ctx, v.TXTName)
+	if err == nil && slices.Contains(txts, v.TXTValue) {
+		return true
+	}
+
+	if v.CNAMETarget == "" {
+		return false
+	}
+
+	cname, err := dnsResolver.LookupCNAME(ctx, strings.TrimPrefix(v.TXTName, domainChallengePrefix))
+	if err != nil {
+		return false
+	}
+
+	return normalizeHostname(cname) == v.CNAMETarget
+}
@@ -5,12 +5,14 @@ import (
 	"math/rand"
 
 	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/config"
 	"github.com/alexisbouchez/ravel/internal/id"
 )
 
 type Gateway = api.Gateway
 
-func (r *Ravel) GetGateway(ctx context.Context, namespace string, fleet string, gateway string) (Gateway, error) {
+func (r *Ravel) getGateway(ctx context.Context, namespace string, fleet string, gateway string) (Gateway, error) {
 	f, err := r.GetFleet(ctx, namespace, fleet)
 	if err != nil {
 		return Gateway{}, err
@@ -19,14 +21,49 @@ func (r *Ravel) GetGateway(ctx context.Context, namespace string, fleet string,
 	return r.State.GetGateway(ctx, f.Namespace, f.Id, gateway)
 }
 
+func (r *Ravel) GetGateway(ctx context.Context, namespace string, fleet string, gateway string) (Gateway, error) {
+	g, err := r.getGateway(ctx, namespace, fleet, gateway)
+	if err != nil {
+		return Gateway{}, err
+	}
+
+	domains, err := r.State.ListGatewayDomains(ctx, g.Id)
+	if err != nil {
+		return Gateway{}, err
+	}
+
+	for _, d := range domains {
+		g.Domains = append(g.Domains, r.withVerification(g, d))
+	}
+
+	return g, nil
+}
+
 func (r *Ravel) ListGateways(ctx context.Context, namespace string, fleet string) ([]Gateway, error) {
 	f, err := r.GetFleet(ctx, namespace, fleet)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.State.ListGatewaysOnFleet(ctx, namespace, f.Id)
+	gateways, err := r.State.ListGatewaysOnFleet(ctx, namespace, f.Id)
+	if err != nil {
+		return nil, err
+	}
 
+	domains, err := r.State.ListFleetGatewayDomains(ctx, namespace, f.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range gateways {
+		for _, d := range domains {
+			if d.GatewayId == gateways[i].Id {
+				gateways[i].Domains = append(gateways[i].Domains, r.withVerification(gateways[i], d))
+			}
+		}
+	}
+
+	return gateways, nil
 }
 
 type CreateGatewayOptions = api.CreateGatewayPayload
@@ -37,6 +74,29 @@ func (r *Ravel) CreateGateway(ctx context.Context, namespace string, fleet strin
 			return Gateway{}, err
 		}
 	}
+
+	if options.TargetPort < 1 || options.TargetPort > 65535 {
+		return Gateway{}, errdefs.NewInvalidArgument("target_port must be between 1 and 65535")
+	}
+
+	protocol := options.Protocol
+	if protocol == "" {
+		protocol = api.GatewayProtocolHTTPS
+	}
+
+	switch protocol {
+	case api.GatewayProtocolHTTP, api.GatewayProtocolHTTPS:
+		if options.ProxyProtocol {
+			return Gateway{}, errdefs.NewInvalidArgument("proxy_protocol is only supported by tcp and udp gateways")
+		}
+	case api.GatewayProtocolTCP, api.GatewayProtocolUDP:
+		if r.config.Server.GatewayPorts == nil {
+			return Gateway{}, errdefs.NewFailedPrecondition("tcp and udp gateways are not enabled on this cluster")
+		}
+	default:
+		return Gateway{}, errdefs.NewInvalidArgument("unsupported gateway protocol: " + protocol)
+	}
+
 	f, err := r.GetFleet(ctx, namespace, fleet)
 	if err != nil {
 		return Gateway{}, err
@@ -50,15 +110,21 @@ func (r *Ravel) CreateGateway(ctx context.Context, namespace string, fleet strin
 	}
 
 	gateway := Gateway{
-		Id:         id.GeneratePrefixed("gw"),
-		Name:       name,
-		Namespace:  namespace,
-		Protocol:   "https",
-		FleetId:    f.Id,
-		TargetPort: options.TargetPort,
+		Id:            id.GeneratePrefixed("gw"),
+		Name:          name,
+		Namespace:     namespace,
+		Protocol:      protocol,
+		FleetId:       f.Id,
+		TargetPort:    options.TargetPort,
+		ProxyProtocol: options.ProxyProtocol,
+	}
+
+	var ports *config.PortRange
+	if gateway.IsL4() {
+		ports = r.config.Server.GatewayPorts
 	}
 
-	err = r.State.CreateGateway(gateway)
+	err = r.State.CreateGateway(&gateway, ports)
 	if err != nil {
 		return Gateway{}, err
 	}
@@ -67,7 +133,7 @@ func (r *Ravel) CreateGateway(ctx context.Context, namespace string, fleet strin
 }
 
 func (r *Ravel) DeleteGateway(ctx context.Context, namespace string, fleet string, gateway string) error {
-	g, err := r.GetGateway(ctx, namespace, fleet, gateway)
+	g, err := r.getGateway(ctx, namespace, fleet, gateway)
 	if err != nil {
 		return err
 	}
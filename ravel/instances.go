@@ -0,0 +1,54 @@
+package ravel
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/nats-io/nats.go"
+)
+
+// instancesStoredSubject is published with each instance state once it is stored.
+const instancesStoredSubject = "machines.instances.stored"
+
+// listenMachineInstances stores the instance states reported by the agents
+// so that components like the proxy can route traffic to them. The agents are answered once the
+// state is stored, they report it again otherwise, and the proxies are told to reload it.
+func (r *Ravel) listenMachineInstances() error {
+	_, err := r.nc.QueueSubscribe("machines.instances", "servers", func(msg *nats.Msg) {
+		var instance cluster.MachineInstance
+		err := json.Unmarshal(msg.Data, &instance)
+		if err != nil {
+			slog.Info("failed to unmarshal message", "error", err)
+			return
+		}
+
+		err = r.State.UpsertInstance(context.Background(), instance)
+		if err != nil && !errdefs.IsNotFound(err) {
+			slog.Info("failed to upsert instance", "error", err)
+			return
+		}
+
+		if err == nil {
+			if err := r.nc.Publish(instancesStoredSubject, msg.Data); err != nil {
+				slog.Info("failed to publish stored instance", "error", err)
+			}
+		}
+
+		if msg.Reply == "" {
+			return
+		}
+
+		err = msg.Respond([]byte("ok"))
+		if err != nil {
+			slog.Info("failed to respond to message", "error", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package ravel
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"time"
+
+	"github.com/oklog/ulid"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/cluster/placement"
+	"github.com/alexisbouchez/ravel/internal/id"
+)
+
+const maxMachineForks = 16
+
+// ForkMachine creates running machines from a snapshot of the memory of a running machine. The
+// forks are placed on the node of the machine and their root filesystem is the copy of the one of
+// the machine taken with the snapshot.
+func (r *Ravel) ForkMachine(ctx context.Context, ns, fleet, machineId string, count int) ([]api.Machine, error) {
+	if count < 1 || count > maxMachineForks {
+		return nil, errdefs.NewInvalidArgument("count must be between 1 and 16")
+	}
+
+	machine, err := r.getMachine(ctx, ns, fleet, machineId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	mv, err := r.getMachineVersion(ctx, machine)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mv.Config.Workload.Volumes) > 0 {
+		return nil, errdefs.NewFailedPrecondition("a machine with volumes cannot be forked")
+	}
+
+	if len(mv.Config.Workload.PrivateNetworks) > 0 {
+		return nil, errdefs.NewFailedPrecondition("a machine with private networks cannot be forked")
+	}
+
+	ctx = context.Background()
+
+	forks := make([]cluster.PutMachineOptions, 0, count)
+	defer func() {
+		if err != nil {
+			destroyMachineForks(forks, func(id string) error {
+				return r.State.DestroyMachine(ctx, id)
+			})
+		}
+	}()
+
+	for range count {
+		fork, forkVersion := newMachineFork(machine, mv)
+
+		fork.Node, err = r.o.PrepareAllocation(ctx, forkPlacementRequest(machine, fork, forkVersion), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		err = r.State.CreateMachine(fork, &forkVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		forks = append(forks, cluster.PutMachineOptions{
+			Machine:      fork,
+			Version:      forkVersion,
+			AllocationId: fork.Id,
+			Start:        true,
+		})
+	}
+
+	err = r.o.ForkMachine(ctx, machine, forks)
+	if err != nil {
+		return nil, err
+	}
+
+	machines := make([]api.Machine, len(forks))
+	for i, fork := range forks {
+		machines[i] = api.Machine{
+			Id:             fork.Machine.Id,
+			Namespace:      fork.Machine.Namespace,
+			FleetId:        fork.Machine.FleetId,
+			InstanceId:     fork.Machine.InstanceId,
+			MachineVersion: fork.Machine.MachineVersion,
+			Region:         fork.Machine.Region,
+			Config:         fork.Version.Config,
+			CreatedAt:      fork.Machine.CreatedAt,
+			UpdatedAt:      fork.Machine.UpdatedAt,
+			Status:         api.MachineStatusCreated,
+		}
+	}
+
+	return machines, nil
+}
+
+// newMachineFork returns a new machine with the configuration of the forked machine.
+func newMachineFork(machine cluster.Machine, mv api.MachineVersion) (cluster.Machine, api.MachineVersion) {
+	versionId := ulid.MustNew(ulid.Now(), rand.Reader).String()
+	fork := cluster.Machine{
+		Id:             id.Generate(),
+		Namespace:      machine.Namespace,
+		FleetId:        machine.FleetId,
+		InstanceId:     id.Generate(),
+		MachineVersion: versionId,
+		Region:         machine.Region,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		Metadata:       machine.Metadata,
+		Placement:      machine.Placement,
+	}
+
+	forkVersion := api.MachineVersion{
+		Id:        versionId,
+		MachineId: fork.Id,
+		Namespace: fork.Namespace,
+		Config:    mv.Config,
+		Resources: mv.Resources,
+	}
+
+	return fork, forkVersion
+}
+
+// forkPlacementRequest places a fork on the node of the forked machine, the snapshot of its
+// memory is only there.
+func forkPlacementRequest(machine cluster.Machine, fork cluster.Machine, forkVersion api.MachineVersion) placement.PlacementRequest {
+	return placement.PlacementRequest{
+		Region:       fork.Region,
+		Node:         machine.Node,
+		AllocationId: fork.Id,
+		Resources:    forkVersion.Resources,
+		Runtime:      forkVersion.Config.Guest.Runtime,
+	}
+}
+
+// destroyMachineForks destroys the forks created before a fork failed, all of them are destroyed
+// even if some fail.
+func destroyMachineForks(forks []cluster.PutMachineOptions, destroy func(id string) error) {
+	for _, fork := range forks {
+		if err := destroy(fork.Machine.Id); err != nil {
+			slog.Error("failed to destroy machine fork", "machine", fork.Machine.Id, "error", err)
+		}
+	}
+}
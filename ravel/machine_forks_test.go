@@ -0,0 +1,89 @@
+package ravel
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+func TestNewMachineFork(t *testing.T) {
+	machine := cluster.Machine{
+		Id:             "machine-1",
+		Namespace:      "ns",
+		FleetId:        "fleet-1",
+		InstanceId:     "instance-1",
+		MachineVersion: "version-1",
+		Region:         "eu",
+		Node:           "node-1",
+	}
+	var mv api.MachineVersion
+	mv.Config.Image = "alpine:latest"
+	mv.Resources.CpusMHz = 1000
+
+	fork, forkVersion := newMachineFork(machine, mv)
+	other, _ := newMachineFork(machine, mv)
+
+	if fork.Id == machine.Id || fork.Id == other.Id || fork.InstanceId == machine.InstanceId {
+		t.Errorf("fork ids = %s, %s, want new ids for each fork", fork.Id, fork.InstanceId)
+	}
+
+	if fork.Namespace != machine.Namespace || fork.FleetId != machine.FleetId || fork.Region != machine.Region {
+		t.Errorf("fork = %+v, want the namespace, fleet and region of the machine", fork)
+	}
+
+	if fork.Node != "" {
+		t.Errorf("fork node = %q before placement, want none", fork.Node)
+	}
+
+	if forkVersion.Id != fork.MachineVersion || forkVersion.MachineId != fork.Id || forkVersion.Id == machine.MachineVersion {
+		t.Errorf("fork version = %+v, want a new version of the fork", forkVersion)
+	}
+
+	if forkVersion.Config.Image != mv.Config.Image || forkVersion.Resources != mv.Resources {
+		t.Errorf("fork version = %+v, want the config and resources of the machine", forkVersion)
+	}
+}
+
+func TestForkPlacementRequest(t *testing.T) {
+	machine := cluster.Machine{Id: "machine-1", Region: "eu", Node: "node-1"}
+	var mv api.MachineVersion
+	mv.Config.Guest.Runtime = "cloud-hypervisor"
+	mv.Resources.CpusMHz = 1000
+
+	fork, forkVersion := newMachineFork(machine, mv)
+	req := forkPlacementRequest(machine, fork, forkVersion)
+
+	// the snapshot of the memory is only on the node of the machine
+	if req.Node != "node-1" {
+		t.Errorf("placement node = %q, want the node of the machine", req.Node)
+	}
+
+	if req.Region != "eu" || req.AllocationId != fork.Id || req.Resources != mv.Resources || req.Runtime != "cloud-hypervisor" {
+		t.Errorf("placement request = %+v, want the region, resources and runtime of the fork", req)
+	}
+}
+
+func TestDestroyMachineForks(t *testing.T) {
+	forks := []cluster.PutMachineOptions{
+		{Machine: cluster.Machine{Id: "fork-1"}},
+		{Machine: cluster.Machine{Id: "fork-2"}},
+		{Machine: cluster.Machine{Id: "fork-3"}},
+	}
+
+	var destroyed []string
+	destroyMachineForks(forks, func(id string) error {
+		destroyed = append(destroyed, id)
+		if id == "fork-2" {
+			return errors.New("database is unavailable")
+		}
+		return nil
+	})
+
+	// a failure does not stop the rollback of the other forks
+	if !slices.Equal(destroyed, []string{"fork-1", "fork-2", "fork-3"}) {
+		t.Errorf("destroyed forks = %v, want all of them", destroyed)
+	}
+}
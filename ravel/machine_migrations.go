@@ -0,0 +1,273 @@
+package ravel
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/internal/id"
+)
+
+// MigrateMachine moves a machine and its volumes to another node of its region, see migrateMachine.
+func (r *Ravel) MigrateMachine(ctx context.Context, ns, fleet, machineId string, nodeId string) (*api.Machine, error) {
+	machine, err := r.getMachine(ctx, ns, fleet, machineId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeId == "" {
+		return nil, errdefs.NewInvalidArgument("node is required")
+	}
+
+	if nodeId == machine.Node {
+		return nil, errdefs.NewInvalidArgument("the machine is already on this node")
+	}
+
+	node, err := r.o.GetNode(ctx, nodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.Region != machine.Region {
+		return nil, errdefs.NewInvalidArgument("a machine can only be migrated to a node of its region")
+	}
+
+	return r.migrateMachine(ctx, machine, node.Id)
+}
+
+// migrateMachine moves a machine and its volumes to the node, or to the node placed in its region
+// with its placement constraints if it is empty. A running machine is live migrated, see liveMigrateMachine. The volumes of a
+// stopped machine are copied to the node before the machine is moved, the machine and the volumes
+// left on the previous node are destroyed once the machine is put on the new one. The snapshots of
+// the machine are moved to the node when they are restored.
+func (r *Ravel) migrateMachine(ctx context.Context, machine cluster.Machine, nodeId string) (*api.Machine, error) {
+	am, err := r.State.GetAPIMachine(ctx, machine.Namespace, machine.FleetId, machine.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	live := am.Status == api.MachineStatusRunning
+	if !live && am.Status != api.MachineStatusStopped && am.Status != api.MachineStatusCreated {
+		return nil, errdefs.NewFailedPrecondition("only a running or stopped machine can be migrated")
+	}
+
+	mv, err := r.getMachineVersion(ctx, machine)
+	if err != nil {
+		return nil, err
+	}
+
+	if live && len(mv.Config.Workload.PrivateNetworks) > 0 {
+		return nil, errdefs.NewFailedPrecondition("a running machine with private networks cannot be migrated")
+	}
+
+	volumes := make([]api.Volume, 0, len(mv.Config.Workload.Volumes))
+	for _, m := range mv.Config.Workload.Volumes {
+		volume, err := r.State.GetVolumeById(ctx, machine.Namespace, m.Volume)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots, err := r.State.ListVolumeSnapshots(ctx, volume.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(snapshots) > 0 {
+			return nil, errdefs.NewFailedPrecondition("the snapshots of volume " + volume.Name + " must be deleted before the migration")
+		}
+
+		volumes = append(volumes, volume)
+	}
+
+	req, err := r.placementRequest(ctx, machine, mv, nodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	fleet, err := r.State.GetFleet(ctx, machine.Namespace, machine.FleetId)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := r.lockFleetPlacement(req)
+	defer unlock()
+
+	node, err := r.o.PrepareAllocation(ctx, req, r.fleetScorer(fleet))
+	if err != nil {
+		return nil, err
+	}
+
+	if node == machine.Node {
+		return nil, errdefs.NewFailedPrecondition("the machine is already on the placed node")
+	}
+
+	if live {
+		return r.liveMigrateMachine(ctx, machine, am, mv, volumes, node)
+	}
+
+	copied, err := r.transferVolumes(ctx, volumes, node)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.Background() // the volumes are copied, the migration must not be left half done
+
+	migrated := machine
+	migrated.Node = node
+	migrated.InstanceId = id.Generate()
+	migrated.UpdatedAt = time.Now()
+
+	if err := r.State.UpdateMachine(migrated); err != nil {
+		r.destroyVolumeCopies(ctx, copied, node)
+		return nil, err
+	}
+
+	for _, volume := range volumes {
+		if err := r.State.UpdateVolumeNode(ctx, volume.Id, node); err != nil {
+			slog.Error("failed to update volume node", "volume", volume.Id, "node", node, "error", err)
+		}
+	}
+
+	err = r.o.PutMachine(ctx, node, &migrated, mv, false, am.GatewayEnabled)
+	if err != nil {
+		r.revertMigration(ctx, machine, volumes, copied, node)
+		return nil, err
+	}
+
+	go r.cleanupMigration(machine, volumes)
+
+	am.InstanceId = migrated.InstanceId
+	am.UpdatedAt = migrated.UpdatedAt
+	am.Status = api.MachineStatusCreated
+	return am, nil
+}
+
+// liveMigrateMachine moves a running machine to another node without stopping it. The agent of
+// the machine copies its volumes and its root filesystem to the node while it runs, then freezes
+// its filesystems to copy the last changes and sends its memory. The machine keeps its IP if it is
+// free on the node. On failure the machine keeps running on its node.
+func (r *Ravel) liveMigrateMachine(ctx context.Context, machine cluster.Machine, am *api.Machine, mv api.MachineVersion, volumes []api.Volume, node string) (*api.Machine, error) {
+	migrated := machine
+	migrated.Node = node
+	migrated.InstanceId = id.Generate()
+
+	if err := r.o.MigrateMachine(ctx, machine, migrated, mv, am.GatewayEnabled); err != nil {
+		return nil, err
+	}
+
+	// the machine runs on the node, the migration must not be left half done
+	migrated.UpdatedAt = time.Now()
+	if err := r.State.UpdateMachine(migrated); err != nil {
+		slog.Error("failed to update migrated machine, retrying", "machine", machine.Id, "node", node, "error", err)
+		go func() {
+			r.retryUpdateMachine(migrated)
+			r.completeLiveMigration(machine, volumes, node)
+		}()
+	} else {
+		r.completeLiveMigration(machine, volumes, node)
+	}
+
+	am.InstanceId = migrated.InstanceId
+	am.UpdatedAt = migrated.UpdatedAt
+	return am, nil
+}
+
+// maxUpdateMachineRetryInterval bounds the interval between two attempts of retryUpdateMachine.
+const maxUpdateMachineRetryInterval = 30 * time.Second
+
+// retryUpdateMachine stores the machine until it succeeds, for the changes already applied on
+// its node.
+func (r *Ravel) retryUpdateMachine(machine cluster.Machine) {
+	interval := time.Second
+	for {
+		time.Sleep(interval)
+
+		err := r.State.UpdateMachine(machine)
+		if err == nil {
+			return
+		}
+		slog.Error("failed to update machine, retrying", "machine", machine.Id, "error", err)
+
+		interval = min(2*interval, maxUpdateMachineRetryInterval)
+	}
+}
+
+// completeLiveMigration moves the volumes to the node in the state once the migrated machine is
+// stored, and destroys what is left on the previous node.
+func (r *Ravel) completeLiveMigration(machine cluster.Machine, volumes []api.Volume, node string) {
+	ctx := context.Background()
+
+	for _, volume := range volumes {
+		if err := r.State.UpdateVolumeNode(ctx, volume.Id, node); err != nil {
+			slog.Error("failed to update volume node", "volume", volume.Id, "node", node, "error", err)
+		}
+	}
+
+	go r.cleanupMigration(machine, volumes)
+}
+
+// transferVolumes copies the volumes to the node and returns the ones copied. On failure the
+// copies are destroyed, except the unfinished one which is resumed by the next migration.
+func (r *Ravel) transferVolumes(ctx context.Context, volumes []api.Volume, node string) ([]api.Volume, error) {
+	copied := make([]api.Volume, 0, len(volumes))
+	for _, volume := range volumes {
+		err := r.o.TransferVolume(ctx, volume.Id, volume.SizeMB, volume.Node, node)
+		if err != nil && !errdefs.IsAlreadyExists(err) {
+			r.destroyVolumeCopies(context.WithoutCancel(ctx), copied, node)
+			return nil, err
+		}
+
+		copied = append(copied, volume)
+	}
+
+	return copied, nil
+}
+
+func (r *Ravel) destroyVolumeCopies(ctx context.Context, volumes []api.Volume, node string) {
+	for _, volume := range volumes {
+		if err := r.o.DestroyVolume(ctx, node, volume.Id); err != nil && !errdefs.IsNotFound(err) {
+			slog.Error("failed to destroy volume copy", "volume", volume.Id, "node", node, "error", err)
+		}
+	}
+}
+
+// revertMigration points the machine and its volumes back to the previous node after the machine
+// failed to be put on the new one.
+func (r *Ravel) revertMigration(ctx context.Context, machine cluster.Machine, volumes []api.Volume, copied []api.Volume, node string) {
+	if err := r.State.UpdateMachine(machine); err != nil {
+		slog.Error("failed to revert machine migration", "machine", machine.Id, "error", err)
+		return
+	}
+
+	for _, volume := range volumes {
+		if err := r.State.UpdateVolumeNode(ctx, volume.Id, volume.Node); err != nil {
+			slog.Error("failed to revert volume node", "volume", volume.Id, "error", err)
+		}
+	}
+
+	r.destroyVolumeCopies(ctx, copied, node)
+}
+
+// cleanupMigration destroys the machine instance and the volumes left on the previous node.
+func (r *Ravel) cleanupMigration(machine cluster.Machine, volumes []api.Volume) {
+	ctx := context.Background()
+
+	if err := r.o.DestroyMachine(ctx, machine, false); err != nil && !errdefs.IsNotFound(err) {
+		slog.Error("failed to destroy migrated machine", "machine", machine.Id, "node", machine.Node, "error", err)
+		return
+	}
+
+	if err := r.o.WaitMachine(ctx, machine, api.MachineStatusDestroyed, 60); err != nil {
+		slog.Error("failed to wait for migrated machine destruction", "machine", machine.Id, "node", machine.Node, "error", err)
+		return
+	}
+
+	for _, volume := range volumes {
+		if err := r.o.DestroyVolume(ctx, machine.Node, volume.Id); err != nil && !errdefs.IsNotFound(err) {
+			slog.Error("failed to destroy migrated volume", "volume", volume.Id, "node", machine.Node, "error", err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package ravel
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/internal/id"
+)
+
+type MachineSnapshot = api.MachineSnapshot
+
+// CreateMachineSnapshot saves the memory of a running machine on its node, with a copy of its
+// root filesystem and a snapshot of its volumes taken while it is paused.
+func (r *Ravel) CreateMachineSnapshot(ctx context.Context, ns, fleet, machineId string) (MachineSnapshot, error) {
+	machine, err := r.getMachine(ctx, ns, fleet, machineId, false)
+	if err != nil {
+		return MachineSnapshot{}, err
+	}
+
+	return r.createMachineSnapshot(ctx, machine)
+}
+
+func (r *Ravel) createMachineSnapshot(ctx context.Context, machine cluster.Machine) (MachineSnapshot, error) {
+	result, err := r.o.MachineSnapshot(ctx, machine, id.GeneratePrefixed("msnap"))
+	if err != nil {
+		return MachineSnapshot{}, err
+	}
+
+	snapshot := MachineSnapshot{
+		Id:             result.Id,
+		MachineId:      machine.Id,
+		Namespace:      machine.Namespace,
+		Node:           machine.Node,
+		SizeBytes:      result.SizeBytes,
+		MachineVersion: result.MachineVersion,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	ctx = context.Background() // the snapshot exists on the node, it must be recorded
+
+	err = recordMachineSnapshot(snapshot, func(snapshot MachineSnapshot) error {
+		return r.State.CreateMachineSnapshot(ctx, snapshot)
+	}, func(node, id string) error {
+		return r.o.DeleteMachineSnapshot(ctx, node, id)
+	})
+	if err != nil {
+		return MachineSnapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+// recordMachineSnapshot records a snapshot taken on a node, it is deleted from the node if it
+// cannot be recorded.
+func recordMachineSnapshot(snapshot MachineSnapshot, record func(MachineSnapshot) error, deleteSnapshot func(node, id string) error) error {
+	err := record(snapshot)
+	if err == nil {
+		return nil
+	}
+
+	if err := deleteSnapshot(snapshot.Node, snapshot.Id); err != nil {
+		slog.Error("failed to delete machine snapshot", "machine", snapshot.MachineId, "snapshot", snapshot.Id, "error", err)
+	}
+
+	return err
+}
+
+func (r *Ravel) ListMachineSnapshots(ctx context.Context, ns, fleet, machineId string) ([]MachineSnapshot, error) {
+	machine, err := r.getMachine(ctx, ns, fleet, machineId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.State.ListMachineSnapshots(ctx, machine.Id)
+}
+
+func (r *Ravel) getMachineSnapshot(ctx context.Context, ns, fleet, machineId, snapshotId string, showDestroyed bool) (cluster.Machine, MachineSnapshot, error) {
+	machine, err := r.getMachine(ctx, ns, fleet, machineId, showDestroyed)
+	if err != nil {
+		return cluster.Machine{}, MachineSnapshot{}, err
+	}
+
+	snapshot, err := r.State.GetMachineSnapshot(ctx, ns, snapshotId)
+	if err != nil {
+		return cluster.Machine{}, MachineSnapshot{}, err
+	}
+
+	if snapshot.MachineId != machine.Id {
+		return cluster.Machine{}, MachineSnapshot{}, errdefs.NewNotFound("machine snapshot not found")
+	}
+
+	return machine, snapshot, nil
+}
+
+func (r *Ravel) DeleteMachineSnapshot(ctx context.Context, ns, fleet, machineId, snapshotId string) error {
+	_, snapshot, err := r.getMachineSnapshot(ctx, ns, fleet, machineId, snapshotId, true)
+	if err != nil {
+		return err
+	}
+
+	return r.deleteMachineSnapshot(ctx, snapshot)
+}
+
+func (r *Ravel) deleteMachineSnapshot(ctx context.Context, snapshot MachineSnapshot) error {
+	err := r.o.DeleteMachineSnapshot(ctx, snapshot.Node, snapshot.Id)
+	if err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+
+	return r.State.DeleteMachineSnapshot(ctx, snapshot.Id)
+}
+
+// RestoreMachineSnapshot brings a running machine back to the state of a snapshot, its memory,
+// its root filesystem and its volumes. The machine must not have been updated since the snapshot
+// was taken, the snapshot is moved to the node of the machine if the machine has migrated. The
+// node rejects the restore if the snapshots of the volumes are gone, when a more recent snapshot
+// has been restored or the volumes have been migrated.
+func (r *Ravel) RestoreMachineSnapshot(ctx context.Context, ns, fleet, machineId, snapshotId string) error {
+	machine, snapshot, err := r.getMachineSnapshot(ctx, ns, fleet, machineId, snapshotId, false)
+	if err != nil {
+		return err
+	}
+
+	if snapshot.MachineVersion != machine.MachineVersion {
+		return errdefs.NewFailedPrecondition("the machine has been updated since the snapshot was taken")
+	}
+
+	if snapshot.Node != machine.Node {
+		snapshot, err = r.moveMachineSnapshot(ctx, snapshot, machine.Node)
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.o.MachineRestore(ctx, machine, snapshot.Id)
+}
+
+// moveMachineSnapshot transfers a snapshot to another node of the region. An interrupted transfer
+// is resumed by the next one.
+func (r *Ravel) moveMachineSnapshot(ctx context.Context, snapshot MachineSnapshot, node string) (MachineSnapshot, error) {
+	err := r.o.TransferMachineSnapshot(ctx, snapshot.Id, snapshot.Node, node)
+	if err != nil && !errdefs.IsAlreadyExists(err) { // a previous move may have failed after the transfer
+		return MachineSnapshot{}, err
+	}
+
+	ctx = context.Background() // the snapshot exists on the node, it must be recorded
+
+	if err := r.State.UpdateMachineSnapshotNode(ctx, snapshot.Id, node); err != nil {
+		return MachineSnapshot{}, err
+	}
+
+	if err := r.o.DeleteMachineSnapshot(ctx, snapshot.Node, snapshot.Id); err != nil && !errdefs.IsNotFound(err) {
+		slog.Error("failed to delete machine snapshot", "machine", snapshot.MachineId, "snapshot", snapshot.Id, "node", snapshot.Node, "error", err)
+	}
+
+	snapshot.Node = node
+	return snapshot, nil
+}
+
+// deleteMachineSnapshots deletes the snapshots of a destroyed machine, the failures are only logged.
+func (r *Ravel) deleteMachineSnapshots(ctx context.Context, machineId string) {
+	snapshots, err := r.State.ListMachineSnapshots(ctx, machineId)
+	if err != nil {
+		slog.Error("failed to list machine snapshots", "machine", machineId, "error", err)
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		if err := r.deleteMachineSnapshot(ctx, snapshot); err != nil {
+			slog.Error("failed to delete machine snapshot", "machine", machineId, "snapshot", snapshot.Id, "error", err)
+		}
+	}
+}
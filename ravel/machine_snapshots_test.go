@@ -0,0 +1,49 @@
+package ravel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRecordMachineSnapshot(t *testing.T) {
+	snapshot := MachineSnapshot{Id: "msnap_1", MachineId: "machine-1", Node: "node-1"}
+
+	var deleted []string
+	deleteSnapshot := func(node, id string) error {
+		deleted = append(deleted, node+"/"+id)
+		return nil
+	}
+
+	err := recordMachineSnapshot(snapshot, func(MachineSnapshot) error { return nil }, deleteSnapshot)
+	if err != nil {
+		t.Fatalf("recordMachineSnapshot() error = %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("deleted snapshots = %v, want none once recorded", deleted)
+	}
+
+	recordErr := errors.New("database is unavailable")
+	err = recordMachineSnapshot(snapshot, func(MachineSnapshot) error { return recordErr }, deleteSnapshot)
+	if !errors.Is(err, recordErr) {
+		t.Fatalf("recordMachineSnapshot() error = %v, want %v", err, recordErr)
+	}
+
+	// the snapshot which cannot be recorded must not be left on the node
+	if len(deleted) != 1 || deleted[0] != "node-1/msnap_1" {
+		t.Errorf("deleted snapshots = %v, want the snapshot deleted from its node", deleted)
+	}
+}
+
+func TestRecordMachineSnapshotDeleteError(t *testing.T) {
+	snapshot := MachineSnapshot{Id: "msnap_1", MachineId: "machine-1", Node: "node-1"}
+	recordErr := errors.New("database is unavailable")
+
+	err := recordMachineSnapshot(snapshot, func(MachineSnapshot) error { return recordErr }, func(node, id string) error {
+		return errors.New("node is unreachable")
+	})
+
+	// the error of the record is returned, not the one of the rollback
+	if !errors.Is(err, recordErr) {
+		t.Errorf("recordMachineSnapshot() error = %v, want %v", err, recordErr)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"io"
 	"log/slog"
+	"net/netip"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
 	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/cluster/placement"
 	"github.com/alexisbouchez/ravel/core/config"
 	"github.com/alexisbouchez/ravel/core/registry"
 	"github.com/alexisbouchez/ravel/internal/id"
@@ -34,27 +37,17 @@ func getResources(m config.MachineResourcesTemplates, vcpus int, memory int) (ap
 	return api.Resources{}, errdefs.NewInvalidArgument("Invalid vcpus and memory config")
 }
 
-func (r *Ravel) CreateMachine(ctx context.Context, namespace string, fleet string, createOptions api.CreateMachinePayload) (*api.Machine, error) {
-	// Validate metadata if provided
-	if err := ValidateMetadata(createOptions.Metadata); err != nil {
-		return nil, err
-	}
-
-	f, err := r.GetFleet(ctx, namespace, fleet)
-	if err != nil {
-		return nil, err
-	}
-
-	config := createOptions.Config
-
+// validateMachineConfig checks the image ref, volumes, private networks and guest
+// config of a machine and returns the config to store along with its resources.
+func (r *Ravel) validateMachineConfig(ctx context.Context, namespace string, config api.MachineConfig) (api.MachineConfig, api.Resources, error) {
 	ref, err := registry.Parse(config.Image)
 	if err != nil {
-		return nil, errdefs.NewInvalidArgument("Invalid image ref")
+		return api.MachineConfig{}, api.Resources{}, errdefs.NewInvalidArgument("Invalid image ref")
 	}
 
 	imageRef, err := registry.CheckImageRef(ctx, ref, r.config.Registries)
 	if err != nil {
-		return nil, errdefs.NewInvalidArgument("Failed to check image ref")
+		return api.MachineConfig{}, api.Resources{}, errdefs.NewInvalidArgument("Failed to check image ref")
 	}
 
 	slog.Debug("Image ref checked", "imageRef", imageRef)
@@ -62,30 +55,73 @@ func (r *Ravel) CreateMachine(ctx context.Context, namespace string, fleet strin
 	if r.config.Server.MainRegistry == ref.Domain && r.config.Server.NamespacedRegistry {
 		parts := strings.Split(ref.Repository, "/")
 		if len(parts) != 2 {
-			return nil, errdefs.NewInvalidArgument("Invalid image ref")
+			return api.MachineConfig{}, api.Resources{}, errdefs.NewInvalidArgument("Invalid image ref")
 		}
 
 		regNS := parts[0]
 
 		if regNS != namespace {
-			return nil, errdefs.NewInvalidArgument("Invalid image ref")
+			return api.MachineConfig{}, api.Resources{}, errdefs.NewInvalidArgument("Invalid image ref")
 		}
 	}
 
 	config.Image = imageRef
 
-	// Resolve secrets and inject them as environment variables
-	if err := r.resolveSecrets(ctx, namespace, &config); err != nil {
-		return nil, err
+	if err := r.validateSecrets(ctx, namespace, config.Workload.Secrets); err != nil {
+		return api.MachineConfig{}, api.Resources{}, err
 	}
 
 	// Validate volumes
 	if err := validateVolumes(config.Workload.Volumes); err != nil {
-		return nil, err
+		return api.MachineConfig{}, api.Resources{}, err
 	}
 
 	// Validate private networks
 	if err := validatePrivateNetworks(config.Workload.PrivateNetworks); err != nil {
+		return api.MachineConfig{}, api.Resources{}, err
+	}
+
+	if !config.Guest.Runtime.IsValid() {
+		return api.MachineConfig{}, api.Resources{}, errdefs.NewInvalidArgument("Invalid runtime")
+	}
+
+	cputemplate, ok := r.vcpusTemplates[config.Guest.CpuKind]
+	if !ok {
+		return api.MachineConfig{}, api.Resources{}, errdefs.NewInvalidArgument("Invalid CPU kind")
+	}
+
+	resources, err := getResources(cputemplate, config.Guest.Cpus, config.Guest.MemoryMB)
+	if err != nil {
+		return api.MachineConfig{}, api.Resources{}, err
+	}
+
+	return config, resources, nil
+}
+
+func (r *Ravel) CreateMachine(ctx context.Context, namespace string, fleet string, createOptions api.CreateMachinePayload) (*api.Machine, error) {
+	// Validate metadata if provided
+	if err := ValidateMetadata(createOptions.Metadata); err != nil {
+		return nil, err
+	}
+
+	f, err := r.GetFleet(ctx, namespace, fleet)
+	if err != nil {
+		return nil, err
+	}
+
+	config, resources, err := r.validateMachineConfig(ctx, namespace, createOptions.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	region := createOptions.Region
+	volumesNode, err := r.resolveVolumes(ctx, namespace, &region, config.Workload.Volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := r.resolvePlacement(ctx, namespace, &region, volumesNode, createOptions.Placement)
+	if err != nil {
 		return nil, err
 	}
 
@@ -98,20 +134,11 @@ func (r *Ravel) CreateMachine(ctx context.Context, namespace string, fleet strin
 		FleetId:        f.Id,
 		InstanceId:     id.Generate(),
 		MachineVersion: versionId,
-		Region:         createOptions.Region,
+		Region:         region,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 		Metadata:       createOptions.Metadata,
-	}
-
-	cputemplate, ok := r.vcpusTemplates[createOptions.Config.Guest.CpuKind]
-	if !ok {
-		return nil, errdefs.NewInvalidArgument("Invalid CPU kind")
-	}
-
-	resources, err := getResources(cputemplate, createOptions.Config.Guest.Cpus, createOptions.Config.Guest.MemoryMB)
-	if err != nil {
-		return nil, err
+		Placement:      createOptions.Placement,
 	}
 
 	mv := api.MachineVersion{
@@ -122,14 +149,26 @@ func (r *Ravel) CreateMachine(ctx context.Context, namespace string, fleet strin
 		Resources: resources,
 	}
 
-	nodeId, err := r.o.PrepareAllocation(ctx, machine.Region, machine.Id, resources)
+	req := placement.PlacementRequest{
+		Region:       machine.Region,
+		Node:         node,
+		AllocationId: machine.Id,
+		Resources:    resources,
+		Runtime:      config.Guest.Runtime,
+	}
+	withPlacementConstraints(&req, machine)
+
+	unlock := r.lockFleetPlacement(req)
+	defer unlock()
+
+	nodeId, err := r.o.PrepareAllocation(ctx, req, r.fleetScorer(f))
 	if err != nil {
 		return nil, err
 	}
 
 	machine.Node = nodeId
 
-	err = r.State.CreateMachine(machine, mv)
+	err = r.State.CreateMachine(machine, &mv)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +192,83 @@ func (r *Ravel) CreateMachine(ctx context.Context, namespace string, fleet strin
 	}, nil
 }
 
+func (r *Ravel) UpdateMachine(ctx context.Context, ns, fleet, machineId string, updateOptions api.UpdateMachinePayload) (*api.Machine, error) {
+	machine, err := r.getMachine(ctx, ns, fleet, machineId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	config, resources, err := r.validateMachineConfig(ctx, ns, updateOptions.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := r.getMachineVersion(ctx, machine)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Workload.PrivateNetworks, err = keepPrivateNetworks(current.Config, config.Workload.PrivateNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Workload.Volumes, err = keepVolumes(current.Config, config.Workload.Volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	// the machine is updated on its node, which may not support another runtime
+	if config.Guest.Runtime != current.Config.Guest.Runtime {
+		return nil, errdefs.NewInvalidArgument("runtime of a machine cannot be changed by an update")
+	}
+
+	ctx = context.Background()
+
+	versionId := ulid.MustNew(ulid.Now(), rand.Reader).String()
+	mv := api.MachineVersion{
+		Id:        versionId,
+		MachineId: machine.Id,
+		Namespace: machine.Namespace,
+		Config:    config,
+		Resources: resources,
+	}
+
+	previous := machine
+	machine.InstanceId = id.Generate()
+	machine.MachineVersion = versionId
+	machine.UpdatedAt = time.Now()
+
+	// the version is stored before the agent gets it, the agent fetches the secrets of the version
+	// when the new instance boots
+	err = r.State.UpdateMachineVersion(machine, mv)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.o.UpdateMachine(ctx, machine, mv)
+	if err != nil {
+		if err := r.State.RevertMachineVersion(previous, versionId); err != nil {
+			slog.Error("failed to restore the machine version after a failed update", "machine", machine.Id, "error", err)
+		}
+		return nil, err
+	}
+
+	return &api.Machine{
+		Id:             machine.Id,
+		Namespace:      machine.Namespace,
+		FleetId:        machine.FleetId,
+		InstanceId:     machine.InstanceId,
+		MachineVersion: machine.MachineVersion,
+		Region:         machine.Region,
+		Config:         config,
+		CreatedAt:      machine.CreatedAt,
+		UpdatedAt:      machine.UpdatedAt,
+		Status:         api.MachineStatusPreparing,
+		Metadata:       machine.Metadata,
+	}, nil
+}
+
 func (r *Ravel) StartMachine(ctx context.Context, ns, fleet, machineId string) error {
 	machine, err := r.getMachine(ctx, ns, fleet, machineId, false)
 	if err != nil {
@@ -180,6 +296,26 @@ func (r *Ravel) MachineExec(ctx context.Context, ns, fleet, machineId string, ex
 	return r.o.MachineExec(ctx, machine, execOpts)
 }
 
+// MachineExecStream starts a streamed exec in a machine or attaches to it, see api.ExecStreamRequest.
+func (r *Ravel) MachineExecStream(ctx context.Context, ns, fleet, machineId string, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	machine, err := r.getMachine(ctx, ns, fleet, machineId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.o.MachineExecStream(ctx, machine, req)
+}
+
+// MachineExecSession opens an interactive exec session with a machine, see api.ExecSessionOptions.
+func (r *Ravel) MachineExecSession(ctx context.Context, ns, fleet, machineId string) (io.ReadWriteCloser, error) {
+	machine, err := r.getMachine(ctx, ns, fleet, machineId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.o.MachineExecSession(ctx, machine)
+}
+
 func (r *Ravel) ListMachines(ctx context.Context, ns, fleet string, includeDestroyed bool) ([]api.Machine, error) {
 	f, err := r.GetFleet(ctx, ns, fleet)
 	if err != nil {
@@ -195,7 +331,13 @@ func (r *Ravel) DestroyMachine(ctx context.Context, ns, fleet, machineId string,
 		return err
 	}
 
-	return r.o.DestroyMachine(ctx, m, force)
+	if err := r.o.DestroyMachine(ctx, m, force); err != nil {
+		return err
+	}
+
+	r.deleteMachineSnapshots(context.WithoutCancel(ctx), m.Id)
+
+	return nil
 }
 
 func (r *Ravel) getMachine(ctx context.Context, ns, fleet, machineId string, showDestroyed bool) (cluster.Machine, error) {
@@ -224,13 +366,13 @@ func (r *Ravel) ListMachineVersions(ctx context.Context, ns, fleet, machineId st
 	return r.State.ListMachineVersions(ctx, machineId)
 }
 
-func (r *Ravel) GetMachineLogsRaw(ctx context.Context, ns, fleet, machineId string, follow bool) (io.ReadCloser, error) {
+func (r *Ravel) GetMachineLogsRaw(ctx context.Context, ns, fleet, machineId string, follow bool, opts api.LogsOptions) (io.ReadCloser, error) {
 	m, err := r.getMachine(ctx, ns, fleet, machineId, false)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.o.GetMachineLogsRaw(ctx, m, follow)
+	return r.o.GetMachineLogsRaw(ctx, m, follow, opts)
 }
 
 func (r *Ravel) ListMachineEvents(ctx context.Context, ns, fleet, machineId string) ([]api.MachineEvent, error) {
@@ -362,6 +504,62 @@ func validateVolumes(volumes []api.VolumeMount) error {
 	return nil
 }
 
+func (r *Ravel) getMachineVersion(ctx context.Context, machine cluster.Machine) (api.MachineVersion, error) {
+	versions, err := r.State.ListMachineVersions(ctx, machine.Id)
+	if err != nil {
+		return api.MachineVersion{}, err
+	}
+
+	for _, v := range versions {
+		if v.Id == machine.MachineVersion {
+			return v, nil
+		}
+	}
+
+	return api.MachineVersion{}, errdefs.NewNotFound("machine version not found")
+}
+
+// keepPrivateNetworks checks that an update does not change the private networks of a machine
+// and returns the networks with the IPs allocated when the machine was created.
+func keepPrivateNetworks(current api.MachineConfig, networks []api.PrivateNetwork) ([]api.PrivateNetwork, error) {
+	previous := current.Workload.PrivateNetworks
+
+	changed := len(previous) != len(networks)
+	for i := 0; !changed && i < len(networks); i++ {
+		ip := networks[i].IP
+		changed = networks[i].Name != previous[i].Name || (ip != "" && ip != previous[i].IP && ip != strings.Split(previous[i].IP, "/")[0])
+	}
+
+	if changed {
+		return nil, errdefs.NewInvalidArgument("private networks of a machine cannot be changed by an update")
+	}
+
+	return previous, nil
+}
+
+// keepVolumes checks that an update mounts the same volumes as the machine and fills their ids,
+// only their mount paths may change.
+func keepVolumes(current api.MachineConfig, volumes []api.VolumeMount) ([]api.VolumeMount, error) {
+	ids := make(map[string]string, len(current.Workload.Volumes))
+	for _, v := range current.Workload.Volumes {
+		ids[v.Name] = v.Volume
+	}
+
+	if len(volumes) != len(ids) {
+		return nil, errdefs.NewInvalidArgument("volumes of a machine cannot be changed by an update")
+	}
+
+	for i, v := range volumes {
+		id, ok := ids[v.Name]
+		if !ok {
+			return nil, errdefs.NewInvalidArgument("volumes of a machine cannot be changed by an update")
+		}
+		volumes[i].Volume = id
+	}
+
+	return volumes, nil
+}
+
 // validatePrivateNetworks validates private network configurations
 func validatePrivateNetworks(networks []api.PrivateNetwork) error {
 	if len(networks) == 0 {
@@ -384,35 +582,47 @@ func validatePrivateNetworks(networks []api.PrivateNetwork) error {
 		}
 		seenNames[network.Name] = true
 
-		// Validate IP (must be in CIDR notation)
+		// The IP is optional, it is allocated in the network if empty
 		if network.IP == "" {
-			return errdefs.NewInvalidArgument("Private network IP cannot be empty")
+			continue
 		}
-		// Simple CIDR validation - check for /prefix
-		if !strings.Contains(network.IP, "/") {
-			return errdefs.NewInvalidArgument("Private network IP must be in CIDR notation (e.g., 10.0.1.2/24): " + network.IP)
+		if _, err := netip.ParseAddr(strings.Split(network.IP, "/")[0]); err != nil {
+			return errdefs.NewInvalidArgument("Invalid private network IP: " + network.IP)
 		}
 	}
 
 	return nil
 }
 
-// resolveSecrets resolves secret references and injects them as environment variables
-func (r *Ravel) resolveSecrets(ctx context.Context, namespace string, config *api.MachineConfig) error {
-	if len(config.Workload.Secrets) == 0 {
-		return nil
-	}
+// validateSecrets checks that the secrets referenced by the machine exist, their values are
+// fetched by the agent when the machine boots.
+func (r *Ravel) validateSecrets(ctx context.Context, namespace string, secrets []api.SecretRef) error {
+	files := map[string]bool{}
+	for _, ref := range secrets {
+		if ref.EnvVar == "" && ref.File == "" {
+			return errdefs.NewInvalidArgument("Secret " + ref.Name + " must set an env var or a file")
+		}
+
+		if ref.EnvVar != "" && strings.ContainsAny(ref.EnvVar, "=\x00") {
+			return errdefs.NewInvalidArgument("Invalid secret env var: " + ref.EnvVar)
+		}
 
-	for _, secretRef := range config.Workload.Secrets {
-		// Get the secret value from the database
-		value, err := r.State.Queries.GetSecretValue(ctx, namespace, secretRef.Name)
-		if err != nil {
-			return errdefs.NewInvalidArgument("Secret not found: " + secretRef.Name)
+		if ref.File != "" {
+			if ref.File != filepath.Base(ref.File) || ref.File == "." || ref.File == ".." {
+				return errdefs.NewInvalidArgument("Invalid secret file: " + ref.File)
+			}
+			if files[ref.File] {
+				return errdefs.NewInvalidArgument("Duplicate secret file: " + ref.File)
+			}
+			files[ref.File] = true
 		}
 
-		// Inject the secret as an environment variable
-		envVar := secretRef.EnvVar + "=" + value
-		config.Workload.Env = append(config.Workload.Env, envVar)
+		if _, err := r.State.Queries.GetSecret(ctx, namespace, ref.Name); err != nil {
+			if errdefs.IsNotFound(err) {
+				return errdefs.NewInvalidArgument("Secret not found: " + ref.Name)
+			}
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,150 @@
+package ravel
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/internal/id"
+	"github.com/nats-io/nats.go"
+)
+
+type Network = api.Network
+
+// localNetworksRange is the range used by the nodes for the machines local networks,
+// private networks must not overlap with it.
+var localNetworksRange = netip.MustParsePrefix("172.18.0.0/16")
+
+func validateNetworkCIDR(cidr string) error {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return errdefs.NewInvalidArgument("invalid cidr: " + cidr)
+	}
+
+	if !prefix.Addr().Is4() {
+		return errdefs.NewInvalidArgument("only IPv4 networks are supported")
+	}
+
+	if prefix.Masked() != prefix {
+		return errdefs.NewInvalidArgument("cidr must be a network address, did you mean " + prefix.Masked().String() + "?")
+	}
+
+	if prefix.Bits() < 8 || prefix.Bits() > 29 {
+		return errdefs.NewInvalidArgument("cidr prefix length must be between 8 and 29")
+	}
+
+	if prefix.Overlaps(localNetworksRange) {
+		return errdefs.NewInvalidArgument("cidr overlaps with the machines local networks range " + localNetworksRange.String())
+	}
+
+	return nil
+}
+
+func (r *Ravel) CreateNetwork(ctx context.Context, namespace string, options api.CreateNetworkPayload) (Network, error) {
+	if err := validateObjectName(options.Name); err != nil {
+		return Network{}, errdefs.NewInvalidArgument(err.Error())
+	}
+
+	if err := validateNetworkCIDR(options.CIDR); err != nil {
+		return Network{}, err
+	}
+
+	if _, err := r.State.GetNamespace(ctx, namespace); err != nil {
+		return Network{}, err
+	}
+
+	network := Network{
+		Id:        id.GeneratePrefixed("net"),
+		Name:      options.Name,
+		Namespace: namespace,
+		CIDR:      options.CIDR,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := r.State.CreateNetwork(ctx, network); err != nil {
+		return Network{}, err
+	}
+
+	return network, nil
+}
+
+func (r *Ravel) GetNetwork(ctx context.Context, namespace, name string) (Network, error) {
+	return r.State.GetNetwork(ctx, namespace, name)
+}
+
+func (r *Ravel) ListNetworks(ctx context.Context, namespace string) ([]Network, error) {
+	return r.State.ListNetworks(ctx, namespace)
+}
+
+func (r *Ravel) DeleteNetwork(ctx context.Context, namespace, name string) error {
+	return r.State.DeleteNetwork(ctx, namespace, name)
+}
+
+func (r *Ravel) publishNetworkPeers(peers cluster.NetworkPeers) {
+	bytes, err := json.Marshal(peers)
+	if err != nil {
+		return
+	}
+
+	if err := r.nc.Publish("networks.peers", bytes); err != nil {
+		slog.Error("failed to publish network peers", "network", peers.Network, "error", err)
+	}
+}
+
+// leaveNetworks removes a destroyed machine from its private networks and notifies the agents.
+func (r *Ravel) leaveNetworks(ctx context.Context, machineId string) {
+	networks, err := r.State.LeaveNetworks(ctx, machineId)
+	if err != nil {
+		slog.Error("failed to leave private networks", "machine_id", machineId, "error", err)
+		return
+	}
+
+	for _, n := range networks {
+		peers, err := r.State.GetNetworkPeers(ctx, n.Namespace, n.Name, n.Id)
+		if err != nil {
+			slog.Error("failed to get network peers", "network", n.Name, "error", err)
+			continue
+		}
+		r.publishNetworkPeers(peers)
+	}
+}
+
+// listenNetworkJoins records the WireGuard peers of the machines joining a private network,
+// answers with the current peers of the network and publishes them to every agent.
+func (r *Ravel) listenNetworkJoins() error {
+	_, err := r.nc.QueueSubscribe("networks.join", "servers", func(msg *nats.Msg) {
+		var join cluster.NetworkJoin
+		err := json.Unmarshal(msg.Data, &join)
+		if err != nil {
+			slog.Info("failed to unmarshal message", "error", err)
+			return
+		}
+
+		peers, err := r.State.JoinNetwork(context.Background(), join)
+		if err != nil {
+			slog.Info("failed to join network", "network", join.Network, "machine_id", join.Peer.MachineId, "error", err)
+			return
+		}
+
+		bytes, err := json.Marshal(peers)
+		if err != nil {
+			return
+		}
+
+		if err = msg.Respond(bytes); err != nil {
+			slog.Info("failed to respond to message", "error", err)
+		}
+
+		r.publishNetworkPeers(peers)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
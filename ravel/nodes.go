@@ -2,8 +2,12 @@ package ravel
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
 	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
 )
 
 func (r *Ravel) ListNodes(ctx context.Context) ([]api.Node, error) {
@@ -14,3 +18,275 @@ func (r *Ravel) ListNodes(ctx context.Context) ([]api.Node, error) {
 
 	return nodes, nil
 }
+
+// CordonNode stops the node from answering the placement requests, the machines of the node
+// keep running.
+func (r *Ravel) CordonNode(ctx context.Context, id string) error {
+	return r.o.CordonNode(ctx, id)
+}
+
+func (r *Ravel) UncordonNode(ctx context.Context, id string) error {
+	r.drainsMu.Lock()
+	drain, ok := r.drains[id]
+	draining := ok && drain.Status == api.NodeDrainStatusDraining
+	r.drainsMu.Unlock()
+
+	if draining {
+		return errdefs.NewFailedPrecondition("the node is being drained")
+	}
+
+	return r.o.UncordonNode(ctx, id)
+}
+
+// DrainNode cordons the node and empties it in the background, its progress is returned by
+// GetNodeDrain. The machines with the never restart policy are stopped on the node, the others
+// are migrated to the nodes placed in their region, live if they are running. A drain in
+// progress is returned as is. The drains are stored, and resumed when the server starts.
+func (r *Ravel) DrainNode(ctx context.Context, id string) (*api.NodeDrain, error) {
+	drain, previous, started := r.reserveNodeDrain(id)
+	if !started {
+		return drain, nil
+	}
+
+	machines, err := r.nodeDrainMachines(ctx, id)
+	if err == nil {
+		r.drainsMu.Lock()
+		drain.Machines = machines
+		drain.Remaining = len(machines)
+		stored := copyNodeDrain(drain)
+		r.drainsMu.Unlock()
+
+		err = r.State.PutNodeDrain(ctx, *stored)
+	}
+	if err != nil {
+		r.releaseNodeDrain(id, previous)
+		return nil, err
+	}
+
+	go r.drainNode(drain)
+
+	return r.GetNodeDrain(ctx, id)
+}
+
+// reserveNodeDrain records a new drain of the node, without machines, and returns it with the
+// previous drain of the node. The drain in progress is returned instead if there is one.
+func (r *Ravel) reserveNodeDrain(id string) (drain *api.NodeDrain, previous *api.NodeDrain, started bool) {
+	r.drainsMu.Lock()
+	defer r.drainsMu.Unlock()
+
+	previous = r.drains[id]
+	if previous != nil && previous.Status == api.NodeDrainStatusDraining {
+		return copyNodeDrain(previous), nil, false
+	}
+
+	drain = &api.NodeDrain{
+		Node:      id,
+		Status:    api.NodeDrainStatusDraining,
+		Machines:  []api.NodeDrainMachine{},
+		StartedAt: time.Now(),
+	}
+	r.drains[id] = drain
+
+	return drain, previous, true
+}
+
+// releaseNodeDrain puts back the previous drain of the node after a drain failed to start.
+func (r *Ravel) releaseNodeDrain(id string, previous *api.NodeDrain) {
+	r.drainsMu.Lock()
+	defer r.drainsMu.Unlock()
+
+	if previous == nil {
+		delete(r.drains, id)
+		return
+	}
+	r.drains[id] = previous
+}
+
+// nodeDrainMachines cordons the node and returns the machines to drain from it.
+func (r *Ravel) nodeDrainMachines(ctx context.Context, id string) ([]api.NodeDrainMachine, error) {
+	if err := r.o.CordonNode(ctx, id); err != nil {
+		return nil, err
+	}
+
+	machines, err := r.State.ListNodeMachines(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	drained := make([]api.NodeDrainMachine, len(machines))
+	for i, machine := range machines {
+		mv, err := r.getMachineVersion(ctx, machine)
+		if err != nil {
+			return nil, err
+		}
+
+		drained[i] = api.NodeDrainMachine{
+			Id:        machine.Id,
+			Namespace: machine.Namespace,
+			FleetId:   machine.FleetId,
+			Action:    nodeDrainAction(mv),
+			Status:    api.NodeDrainMachinePending,
+		}
+	}
+
+	return drained, nil
+}
+
+func nodeDrainAction(mv api.MachineVersion) api.NodeDrainAction {
+	if mv.Config.Workload.Restart.Policy == api.RestartPolicyNever {
+		return api.NodeDrainActionStop
+	}
+	return api.NodeDrainActionMigrate
+}
+
+// GetNodeDrain returns the progress of the last drain of the node.
+func (r *Ravel) GetNodeDrain(ctx context.Context, id string) (*api.NodeDrain, error) {
+	r.drainsMu.Lock()
+	defer r.drainsMu.Unlock()
+
+	drain, ok := r.drains[id]
+	if !ok {
+		return nil, errdefs.NewNotFound("the node is not being drained")
+	}
+
+	return copyNodeDrain(drain), nil
+}
+
+// resumeNodeDrains loads the stored drains and resumes the drains in progress when the server
+// stopped.
+func (r *Ravel) resumeNodeDrains() error {
+	drains, err := r.State.ListNodeDrains(context.Background())
+	if err != nil {
+		return err
+	}
+
+	r.drainsMu.Lock()
+	defer r.drainsMu.Unlock()
+
+	for _, drain := range drains {
+		drain := &drain
+		r.drains[drain.Node] = drain
+
+		if drain.Status == api.NodeDrainStatusDraining {
+			slog.Info("Resuming node drain", "node", drain.Node, "remaining", drain.Remaining)
+			go r.drainNode(drain)
+		}
+	}
+
+	return nil
+}
+
+// drainNode handles the pending machines of a drain one after the other, a failure is reported
+// in the drain and does not stop it. The drain is stored after each machine.
+func (r *Ravel) drainNode(drain *api.NodeDrain) {
+	ctx := context.Background()
+
+	for i, dm := range drain.Machines {
+		if dm.Status != api.NodeDrainMachinePending {
+			continue
+		}
+
+		node, err := r.drainMachine(ctx, drain.Node, dm)
+
+		r.drainsMu.Lock()
+		if err != nil {
+			slog.Error("failed to drain machine", "node", drain.Node, "machine", dm.Id, "error", err)
+			drain.Machines[i].Status = api.NodeDrainMachineFailed
+			drain.Machines[i].Error = err.Error()
+		} else {
+			drain.Machines[i].Status = api.NodeDrainMachineDone
+			drain.Machines[i].Node = node
+		}
+		drain.Remaining--
+		stored := copyNodeDrain(drain)
+		r.drainsMu.Unlock()
+
+		r.storeNodeDrain(ctx, stored)
+	}
+
+	r.drainsMu.Lock()
+	now := time.Now()
+	drain.Status = api.NodeDrainStatusDone
+	drain.CompletedAt = &now
+	stored := copyNodeDrain(drain)
+	r.drainsMu.Unlock()
+
+	r.storeNodeDrain(ctx, stored)
+}
+
+func (r *Ravel) storeNodeDrain(ctx context.Context, drain *api.NodeDrain) {
+	if err := r.State.PutNodeDrain(ctx, *drain); err != nil {
+		slog.Error("failed to store node drain", "node", drain.Node, "error", err)
+	}
+}
+
+// drainMachine stops or migrates a machine of the drained node and returns the node it has
+// been migrated to. A machine which is already gone from the node, migrated before the drain was
+// interrupted or destroyed, is left as is.
+func (r *Ravel) drainMachine(ctx context.Context, node string, dm api.NodeDrainMachine) (string, error) {
+	machine, err := r.State.GetMachineById(ctx, dm.Id)
+	if err != nil && !errdefs.IsNotFound(err) {
+		return "", err
+	}
+
+	if gone, to := drainedMachine(machine, err, node); gone {
+		return to, nil
+	}
+
+	if dm.Action == api.NodeDrainActionStop {
+		return "", r.drainStopMachine(ctx, machine)
+	}
+	return r.drainMigrateMachine(ctx, machine)
+}
+
+// drainedMachine reports whether a machine is no longer on the drained node, and the node it is
+// on.
+func drainedMachine(machine cluster.Machine, err error, node string) (bool, string) {
+	if err != nil || machine.DestroyedAt != nil {
+		return true, ""
+	}
+
+	if machine.Node != node {
+		return true, machine.Node
+	}
+
+	return false, ""
+}
+
+func (r *Ravel) drainStopMachine(ctx context.Context, machine cluster.Machine) error {
+	am, err := r.State.GetAPIMachine(ctx, machine.Namespace, machine.FleetId, machine.Id)
+	if err != nil {
+		return err
+	}
+
+	if am.Status == api.MachineStatusStopped || am.Status == api.MachineStatusCreated {
+		return nil
+	}
+
+	if err := r.o.StopMachineInstance(ctx, machine, nil); err != nil {
+		return err
+	}
+
+	return r.o.WaitMachine(ctx, machine, api.MachineStatusStopped, 60)
+}
+
+// drainMigrateMachine migrates the machine to the node placed in its region, the drained node
+// being cordoned, and returns the node.
+func (r *Ravel) drainMigrateMachine(ctx context.Context, machine cluster.Machine) (string, error) {
+	if _, err := r.migrateMachine(ctx, machine, ""); err != nil {
+		return "", err
+	}
+
+	migrated, err := r.State.GetMachineById(ctx, machine.Id)
+	if err != nil {
+		return "", err
+	}
+
+	return migrated.Node, nil
+}
+
+func copyNodeDrain(drain *api.NodeDrain) *api.NodeDrain {
+	c := *drain
+	c.Machines = append([]api.NodeDrainMachine{}, drain.Machines...)
+	return &c
+}
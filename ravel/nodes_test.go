@@ -0,0 +1,95 @@
+package ravel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+func TestReserveNodeDrain(t *testing.T) {
+	r := &Ravel{drains: map[string]*api.NodeDrain{}}
+
+	drain, previous, started := r.reserveNodeDrain("node-1")
+	if !started || previous != nil {
+		t.Fatalf("reserveNodeDrain() = started %v, previous %v, want a new drain", started, previous)
+	}
+	if drain.Status != api.NodeDrainStatusDraining || drain.Node != "node-1" {
+		t.Errorf("reserved drain = %+v, want node-1 draining", drain)
+	}
+
+	// a drain in progress is returned as is, even before its machines are listed
+	inProgress, _, started := r.reserveNodeDrain("node-1")
+	if started {
+		t.Fatal("reserveNodeDrain() started a second drain of a node being drained")
+	}
+	if inProgress == drain {
+		t.Error("reserveNodeDrain() returned the drain in progress instead of a copy")
+	}
+
+	r.releaseNodeDrain("node-1", nil)
+	if _, ok := r.drains["node-1"]; ok {
+		t.Error("releaseNodeDrain() kept a drain which failed to start")
+	}
+
+	done := &api.NodeDrain{Node: "node-1", Status: api.NodeDrainStatusDone}
+	r.drains["node-1"] = done
+
+	_, previous, started = r.reserveNodeDrain("node-1")
+	if !started || previous != done {
+		t.Fatalf("reserveNodeDrain() = started %v, previous %v, want a new drain after the done one", started, previous)
+	}
+
+	r.releaseNodeDrain("node-1", previous)
+	if r.drains["node-1"] != done {
+		t.Error("releaseNodeDrain() did not put back the previous drain")
+	}
+}
+
+func TestNodeDrainAction(t *testing.T) {
+	tests := []struct {
+		policy api.RestartPolicy
+		want   api.NodeDrainAction
+	}{
+		{policy: api.RestartPolicyNever, want: api.NodeDrainActionStop},
+		{policy: api.RestartPolicyAlways, want: api.NodeDrainActionMigrate},
+		{policy: "", want: api.NodeDrainActionMigrate},
+	}
+
+	for _, tt := range tests {
+		var mv api.MachineVersion
+		mv.Config.Workload.Restart.Policy = tt.policy
+
+		if got := nodeDrainAction(mv); got != tt.want {
+			t.Errorf("nodeDrainAction(%q) = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestDrainedMachine(t *testing.T) {
+	destroyedAt := time.Now()
+
+	tests := []struct {
+		name     string
+		machine  cluster.Machine
+		err      error
+		wantGone bool
+		wantNode string
+	}{
+		{name: "on the node", machine: cluster.Machine{Node: "node-1"}},
+		{name: "migrated", machine: cluster.Machine{Node: "node-2"}, wantGone: true, wantNode: "node-2"},
+		{name: "destroyed", machine: cluster.Machine{Node: "node-1", DestroyedAt: &destroyedAt}, wantGone: true},
+		{name: "not found", err: errdefs.NewNotFound("machine not found"), wantGone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gone, node := drainedMachine(tt.machine, tt.err, "node-1")
+			if gone != tt.wantGone || node != tt.wantNode {
+				t.Errorf("drainedMachine() = %v, %q, want %v, %q", gone, node, tt.wantGone, tt.wantNode)
+			}
+		})
+	}
+}
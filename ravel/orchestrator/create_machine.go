@@ -11,15 +11,14 @@ import (
 	"github.com/alexisbouchez/ravel/core/cluster/placement"
 )
 
-func (o *Orchestrator) PrepareAllocation(ctx context.Context, region string, allocationId string, resources api.Resources) (nodeId string, err error) {
-	workers, err := o.broker.GetAvailableWorkers(placement.PlacementRequest{
-		Region:       region,
-		AllocationId: allocationId,
-		Resources:    resources,
-	})
+// PrepareAllocation places the allocation of the request in its region, on the request node if
+// it is not empty, and returns the node. The nodes are ranked by the scorer, or by the scorer of
+// the server if it is nil.
+func (o *Orchestrator) PrepareAllocation(ctx context.Context, req placement.PlacementRequest, scorer placement.Scorer) (nodeId string, err error) {
+	workers, err := o.broker.GetAvailableWorkers(req, scorer)
 	if err != nil {
 		if err == placement.ErrPlacementFailed {
-			slog.Warn("Failed to place machine", "machine_id", allocationId)
+			slog.Warn("Failed to place machine", "machine_id", req.AllocationId)
 			err = errdefs.NewResourcesExhausted("failed to place machine")
 		}
 		return
@@ -24,6 +24,18 @@ func (o *Orchestrator) DestroyMachine(ctx context.Context, machine cluster.Machi
 	return nil
 }
 
+func (o *Orchestrator) UpdateMachine(ctx context.Context, machine cluster.Machine, mv api.MachineVersion) error {
+	agentClient, err := o.getAgentClient(machine.Node)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.UpdateMachine(ctx, machine.Id, cluster.UpdateMachineOptions{
+		InstanceId: machine.InstanceId,
+		Version:    mv,
+	})
+}
+
 func (o *Orchestrator) StartMachineInstance(ctx context.Context, machine cluster.Machine) error {
 	agentClient, err := o.getAgentClient(machine.Node)
 	if err != nil {
@@ -91,13 +103,31 @@ func (o *Orchestrator) MachineExec(ctx context.Context, machine cluster.Machine,
 	return agentClient.MachineExec(ctx, machine.Id, execOpts.Cmd, execOpts.GetTimeout())
 }
 
-func (o *Orchestrator) GetMachineLogsRaw(ctx context.Context, machine cluster.Machine, follow bool) (io.ReadCloser, error) {
+func (o *Orchestrator) MachineExecStream(ctx context.Context, machine cluster.Machine, req api.ExecStreamRequest) (io.ReadCloser, error) {
 	agentClient, err := o.getAgentClient(machine.Node)
 	if err != nil {
 		return nil, err
 	}
 
-	return agentClient.GetMachineLogsRaw(ctx, machine.Id, follow)
+	return agentClient.MachineExecStream(ctx, machine.Id, req)
+}
+
+func (o *Orchestrator) MachineExecSession(ctx context.Context, machine cluster.Machine) (io.ReadWriteCloser, error) {
+	agentClient, err := o.getAgentClient(machine.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	return agentClient.MachineExecSession(ctx, machine.Id)
+}
+
+func (o *Orchestrator) GetMachineLogsRaw(ctx context.Context, machine cluster.Machine, follow bool, opts api.LogsOptions) (io.ReadCloser, error) {
+	agentClient, err := o.getAgentClient(machine.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	return agentClient.GetMachineLogsRaw(ctx, machine.Id, follow, opts)
 }
 
 func (o *Orchestrator) EnableMachineGateway(ctx context.Context, machine cluster.Machine) error {
@@ -117,3 +147,111 @@ func (o *Orchestrator) DisableMachineGateway(ctx context.Context, machine cluste
 
 	return agentClient.DisableMachineGateway(ctx, machine.Id)
 }
+
+func (o *Orchestrator) MachineSnapshot(ctx context.Context, machine cluster.Machine, snapshotId string) (*api.MachineSnapshot, error) {
+	agentClient, err := o.getAgentClient(machine.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	return agentClient.MachineSnapshot(ctx, machine.Id, snapshotId)
+}
+
+func (o *Orchestrator) MachineRestore(ctx context.Context, machine cluster.Machine, snapshotId string) error {
+	agentClient, err := o.getAgentClient(machine.Node)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.MachineRestore(ctx, machine.Id, snapshotId)
+}
+
+// ForkMachine creates the machines on the node of the forked machine, from a snapshot of its memory.
+func (o *Orchestrator) ForkMachine(ctx context.Context, machine cluster.Machine, clones []cluster.PutMachineOptions) error {
+	agentClient, err := o.getAgentClient(machine.Node)
+	if err != nil {
+		return err
+	}
+
+	_, err = agentClient.ForkMachine(ctx, machine.Id, cluster.ForkMachineOptions{Machines: clones})
+	return err
+}
+
+// MigrateMachine live migrates a running machine to the node of migrated, the allocation of the
+// machine must have been prepared on the node. The node of migrated allows the migration from
+// the node of the machine only.
+func (o *Orchestrator) MigrateMachine(ctx context.Context, machine cluster.Machine, migrated cluster.Machine, mv api.MachineVersion, enableGateway bool) error {
+	target, err := o.clusterState.GetNode(ctx, migrated.Node)
+	if err != nil {
+		return err
+	}
+
+	targetClient, err := o.getAgentClient(migrated.Node)
+	if err != nil {
+		return err
+	}
+
+	agentClient, err := o.getAgentClient(machine.Node)
+	if err != nil {
+		return err
+	}
+
+	grant, err := newTransferGrant(machine.Node)
+	if err != nil {
+		return err
+	}
+
+	if err := targetClient.GrantMigrationTransfer(ctx, migrated.InstanceId, grant); err != nil {
+		return err
+	}
+
+	return agentClient.MigrateMachine(ctx, machine.Id, cluster.MigrateMachineOptions{
+		Target: target.AgentAddress(),
+		Token:  grant.Token,
+		Machine: cluster.PutMachineOptions{
+			AllocationId:  migrated.Id,
+			Machine:       migrated,
+			Version:       mv,
+			EnableGateway: enableGateway,
+		},
+	})
+}
+
+func (o *Orchestrator) DeleteMachineSnapshot(ctx context.Context, nodeId string, snapshotId string) error {
+	agentClient, err := o.getAgentClient(nodeId)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.DeleteMachineSnapshot(ctx, snapshotId)
+}
+
+// TransferMachineSnapshot copies a machine snapshot from a node to another one, the snapshot is kept on the source node.
+// The source node allows the pull of the snapshot to the other node only.
+func (o *Orchestrator) TransferMachineSnapshot(ctx context.Context, snapshotId string, from string, to string) error {
+	source, err := o.clusterState.GetNode(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	sourceClient, err := o.getAgentClient(from)
+	if err != nil {
+		return err
+	}
+
+	agentClient, err := o.getAgentClient(to)
+	if err != nil {
+		return err
+	}
+
+	grant, err := newTransferGrant(to)
+	if err != nil {
+		return err
+	}
+
+	if err := sourceClient.GrantMachineSnapshotTransfer(ctx, snapshotId, grant); err != nil {
+		return err
+	}
+
+	return agentClient.PullMachineSnapshot(ctx, snapshotId, cluster.PullOptions{Source: source.AgentAddress(), Token: grant.Token})
+}
@@ -17,3 +17,21 @@ func (m *Orchestrator) GetNode(ctx context.Context, id string) (api.Node, error)
 func (m *Orchestrator) ListNodesInRegion(ctx context.Context, region string) ([]api.Node, error) {
 	return m.clusterState.ListNodesInRegion(ctx, region)
 }
+
+func (m *Orchestrator) CordonNode(ctx context.Context, id string) error {
+	agentClient, err := m.getAgentClient(id)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.CordonNode(ctx)
+}
+
+func (m *Orchestrator) UncordonNode(ctx context.Context, id string) error {
+	agentClient, err := m.getAgentClient(id)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.UncordonNode(ctx)
+}
@@ -12,8 +12,8 @@ import (
 	"github.com/nats-io/nats.go"
 )
 
-func New(nc *nats.Conn, clusterState cluster.ClusterState, tlsConfig *tls.Config) *Orchestrator {
-	broker := placement.NewBroker(nc)
+func New(nc *nats.Conn, clusterState cluster.ClusterState, tlsConfig *tls.Config, scorer placement.Scorer) *Orchestrator {
+	broker := placement.NewBroker(nc, scorer)
 	httpClient := http.Client{ // to be tuned in the future
 		Transport: &http.Transport{
 			TLSClientConfig: tlsConfig,
@@ -0,0 +1,19 @@
+package orchestrator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+// newTransferGrant returns the grant of a transfer with a node, the agent it is granted on allows
+// the transfer of the object with its token only.
+func newTransferGrant(node string) (cluster.TransferGrant, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return cluster.TransferGrant{}, err
+	}
+
+	return cluster.TransferGrant{Node: node, Token: hex.EncodeToString(token)}, nil
+}
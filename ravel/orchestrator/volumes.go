@@ -0,0 +1,95 @@
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+func (o *Orchestrator) CreateVolume(ctx context.Context, nodeId string, opt cluster.CreateVolumeOptions) error {
+	agentClient, err := o.getAgentClient(nodeId)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.CreateVolume(ctx, opt)
+}
+
+func (o *Orchestrator) DestroyVolume(ctx context.Context, nodeId string, id string) error {
+	agentClient, err := o.getAgentClient(nodeId)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.DestroyVolume(ctx, id)
+}
+
+func (o *Orchestrator) ResizeVolume(ctx context.Context, nodeId string, id string, sizeMB uint64) error {
+	agentClient, err := o.getAgentClient(nodeId)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.ResizeVolume(ctx, id, sizeMB)
+}
+
+func (o *Orchestrator) CreateVolumeSnapshot(ctx context.Context, nodeId string, volumeId string, snapshotId string) error {
+	agentClient, err := o.getAgentClient(nodeId)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.CreateVolumeSnapshot(ctx, volumeId, snapshotId)
+}
+
+func (o *Orchestrator) DeleteVolumeSnapshot(ctx context.Context, nodeId string, volumeId string, snapshotId string) error {
+	agentClient, err := o.getAgentClient(nodeId)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.DeleteVolumeSnapshot(ctx, volumeId, snapshotId)
+}
+
+func (o *Orchestrator) RestoreVolumeSnapshot(ctx context.Context, nodeId string, volumeId string, snapshotId string) error {
+	agentClient, err := o.getAgentClient(nodeId)
+	if err != nil {
+		return err
+	}
+
+	return agentClient.RestoreVolumeSnapshot(ctx, volumeId, snapshotId)
+}
+
+// TransferVolume copies a detached volume from a node to another one, the volume is kept on the source node.
+// The source node allows the pull of the volume to the other node only.
+func (o *Orchestrator) TransferVolume(ctx context.Context, volumeId string, sizeMB uint64, from string, to string) error {
+	source, err := o.clusterState.GetNode(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	sourceClient, err := o.getAgentClient(from)
+	if err != nil {
+		return err
+	}
+
+	agentClient, err := o.getAgentClient(to)
+	if err != nil {
+		return err
+	}
+
+	grant, err := newTransferGrant(to)
+	if err != nil {
+		return err
+	}
+
+	if err := sourceClient.GrantVolumeTransfer(ctx, volumeId, grant); err != nil {
+		return err
+	}
+
+	return agentClient.PullVolume(ctx, volumeId, cluster.PullVolumeOptions{
+		Source: source.AgentAddress(),
+		Token:  grant.Token,
+		SizeMB: sizeMB,
+	})
+}
@@ -0,0 +1,157 @@
+package ravel
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/cluster/placement"
+)
+
+// resolvePlacement validates the placement constraints of a machine being created and
+// returns the node the machine must be placed on, the node of its volumes or of the volume of its
+// volume affinity. The volume affinity is replaced by the id of the volume.
+func (r *Ravel) resolvePlacement(ctx context.Context, namespace string, region *string, volumesNode string, constraints *api.PlacementConstraints) (string, error) {
+	if constraints == nil {
+		return volumesNode, nil
+	}
+
+	if err := validateLabels(constraints.NodeLabels); err != nil {
+		return "", err
+	}
+
+	if constraints.VolumeAffinity == "" {
+		return volumesNode, nil
+	}
+
+	volume, err := r.State.GetVolume(ctx, namespace, constraints.VolumeAffinity)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return "", errdefs.NewInvalidArgument("volume not found: " + constraints.VolumeAffinity)
+		}
+		return "", err
+	}
+
+	if volumesNode != "" && volume.Node != volumesNode {
+		return "", errdefs.NewInvalidArgument("the volume affinity must be on the node of the volumes of the machine")
+	}
+
+	if *region == "" {
+		*region = volume.Region
+	} else if *region != volume.Region {
+		return "", errdefs.NewInvalidArgument("volume " + volume.Name + " is in region " + volume.Region)
+	}
+
+	constraints.VolumeAffinity = volume.Id
+	return volume.Node, nil
+}
+
+// placementRequest returns the placement request of the machine, on the node if it is not empty
+// or on a node satisfying the placement constraints of the machine. The volume affinity is
+// ignored if the volume is mounted by the machine, it moves with the machine, or if it has been
+// deleted.
+func (r *Ravel) placementRequest(ctx context.Context, machine cluster.Machine, mv api.MachineVersion, node string) (placement.PlacementRequest, error) {
+	req := placement.PlacementRequest{
+		Region:       machine.Region,
+		Node:         node,
+		AllocationId: machine.Id,
+		Resources:    mv.Resources,
+		Runtime:      mv.Config.Guest.Runtime,
+	}
+
+	constraints := machine.Placement
+	if node != "" || constraints == nil {
+		return req, nil
+	}
+
+	withPlacementConstraints(&req, machine)
+
+	mounted := slices.ContainsFunc(mv.Config.Workload.Volumes, func(m api.VolumeMount) bool {
+		return m.Volume == constraints.VolumeAffinity
+	})
+	if constraints.VolumeAffinity == "" || mounted {
+		return req, nil
+	}
+
+	volume, err := r.State.GetVolumeById(ctx, machine.Namespace, constraints.VolumeAffinity)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return req, nil
+		}
+		return req, err
+	}
+
+	req.Node = volume.Node
+	return req, nil
+}
+
+// withPlacementConstraints adds the node labels and the fleet anti-affinity of the machine to the
+// placement request.
+func withPlacementConstraints(req *placement.PlacementRequest, machine cluster.Machine) {
+	if machine.Placement == nil {
+		return
+	}
+
+	req.NodeLabels = machine.Placement.NodeLabels
+	if machine.Placement.FleetAntiAffinity {
+		req.AvoidFleet = machine.FleetId
+	}
+}
+
+// fleetPlacement serializes the placements of the machines of a fleet with anti-affinity.
+type fleetPlacement struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockFleetPlacement waits for the other placements of the fleet avoided by the request, until
+// the returned function is called. A node only sees a machine of the fleet once it is put on it,
+// concurrent placements would otherwise be answered by the same node.
+func (r *Ravel) lockFleetPlacement(req placement.PlacementRequest) func() {
+	fleet := req.AvoidFleet
+	if fleet == "" {
+		return func() {}
+	}
+
+	r.fleetPlacementsMu.Lock()
+	fp, ok := r.fleetPlacements[fleet]
+	if !ok {
+		fp = &fleetPlacement{}
+		r.fleetPlacements[fleet] = fp
+	}
+	fp.refs++
+	r.fleetPlacementsMu.Unlock()
+
+	fp.mu.Lock()
+
+	return func() {
+		fp.mu.Unlock()
+
+		r.fleetPlacementsMu.Lock()
+		fp.refs--
+		if fp.refs == 0 {
+			delete(r.fleetPlacements, fleet)
+		}
+		r.fleetPlacementsMu.Unlock()
+	}
+}
+
+// fleetScorer returns the scorer of the placement strategy of the fleet, nil if the fleet uses the
+// strategy of the server.
+func (r *Ravel) fleetScorer(fleet *api.Fleet) placement.Scorer {
+	if fleet.PlacementStrategy == "" {
+		return nil
+	}
+
+	scorer, err := placement.NewScorer(fleet.PlacementStrategy, r.config.Server.Placement.TopK)
+	if err != nil {
+		slog.Warn("invalid fleet placement strategy, using the server one", "fleet", fleet.Id, "error", err)
+		return nil
+	}
+
+	return scorer
+}
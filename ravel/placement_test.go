@@ -0,0 +1,42 @@
+package ravel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/core/cluster/placement"
+)
+
+func TestLockFleetPlacement(t *testing.T) {
+	r := &Ravel{fleetPlacements: map[string]*fleetPlacement{}}
+
+	unlock := r.lockFleetPlacement(placement.PlacementRequest{AvoidFleet: "fleet-1"})
+
+	// the placements without anti-affinity and of the other fleets are not serialized
+	r.lockFleetPlacement(placement.PlacementRequest{})()
+	r.lockFleetPlacement(placement.PlacementRequest{AvoidFleet: "fleet-2"})()
+
+	locked := make(chan func())
+	go func() {
+		locked <- r.lockFleetPlacement(placement.PlacementRequest{AvoidFleet: "fleet-1"})
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("a second placement of the fleet did not wait for the first one")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+
+	select {
+	case unlock := <-locked:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second placement of the fleet was not unblocked")
+	}
+
+	if len(r.fleetPlacements) != 0 {
+		t.Errorf("%d fleet placements left once unlocked, want 0", len(r.fleetPlacements))
+	}
+}
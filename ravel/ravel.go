@@ -3,11 +3,18 @@ package ravel
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"sync"
 
+	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/cluster/placement"
 	"github.com/alexisbouchez/ravel/core/config"
 	"github.com/alexisbouchez/ravel/ravel/orchestrator"
+	"github.com/alexisbouchez/ravel/ravel/secrets"
 	"github.com/alexisbouchez/ravel/ravel/state"
+	"github.com/alexisbouchez/ravel/sandbox"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nats-io/nats.go"
 )
@@ -19,6 +26,15 @@ type Ravel struct {
 	pgpool         *pgxpool.Pool
 	config         *config.RavelConfig
 	vcpusTemplates map[string]config.MachineResourcesTemplates
+
+	sandboxPoolsMu sync.Mutex
+	sandboxPools   map[string]*sandbox.Pool
+
+	drainsMu sync.Mutex
+	drains   map[string]*api.NodeDrain
+
+	fleetPlacementsMu sync.Mutex
+	fleetPlacements   map[string]*fleetPlacement
 }
 
 func getClientTLSConfig(config config.RavelConfig) (*tls.Config, error) {
@@ -48,6 +64,27 @@ func getClientTLSConfig(config config.RavelConfig) (*tls.Config, error) {
 func New(config config.RavelConfig) (*Ravel, error) {
 	ctx := context.Background()
 
+	if config.Server.GatewayPorts != nil {
+		if err := config.Server.GatewayPorts.Validate(); err != nil {
+			return nil, fmt.Errorf("server.gateway_ports: %w", err)
+		}
+	}
+
+	scorer, err := placement.NewScorer(config.Server.Placement.Strategy, config.Server.Placement.TopK)
+	if err != nil {
+		return nil, fmt.Errorf("server.placement: %w", err)
+	}
+
+	var keys secrets.KeyProvider
+	if config.Server.Secrets != nil {
+		keys, err = secrets.NewKeyProvider(*config.Server.Secrets)
+		if err != nil {
+			return nil, fmt.Errorf("server.secrets: %w", err)
+		}
+	} else {
+		slog.Warn("server.secrets is not configured, the secrets are stored in plaintext")
+	}
+
 	pgpool, err := pgxpool.New(ctx, config.Server.PostgresURL)
 	if err != nil {
 		return nil, err
@@ -81,23 +118,43 @@ func New(config config.RavelConfig) (*Ravel, error) {
 		return nil, err
 	}
 
-	o := orchestrator.New(nc, clusterstate, tlsConfig)
+	o := orchestrator.New(nc, clusterstate, tlsConfig, scorer)
 
 	return &Ravel{
-		nc:             nc,
-		o:              o,
-		State:          state.New(pgpool, clusterstate),
-		vcpusTemplates: config.Server.MachineTemplates,
-		pgpool:         pgpool,
-		config:         &config,
+		nc:              nc,
+		o:               o,
+		State:           state.New(pgpool, clusterstate, keys),
+		vcpusTemplates:  config.Server.MachineTemplates,
+		pgpool:          pgpool,
+		config:          &config,
+		sandboxPools:    map[string]*sandbox.Pool{},
+		drains:          map[string]*api.NodeDrain{},
+		fleetPlacements: map[string]*fleetPlacement{},
 	}, nil
 }
 
 func (r *Ravel) Start() error {
-	return r.listenMachineEvents()
+	if err := r.listenMachineEvents(); err != nil {
+		return err
+	}
+
+	if err := r.listenNetworkJoins(); err != nil {
+		return err
+	}
+
+	if err := r.listenMachineInstances(); err != nil {
+		return err
+	}
+
+	if err := r.resumeNodeDrains(); err != nil {
+		return err
+	}
+
+	return r.startSandboxPools()
 }
 
 func (r *Ravel) Stop() error {
+	r.stopSandboxPools()
 	r.nc.Close()
 	r.pgpool.Close()
 	return nil
@@ -0,0 +1,287 @@
+package ravel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/id"
+	"github.com/alexisbouchez/ravel/sandbox"
+)
+
+const (
+	defaultClaimTimeout = 10 * time.Second
+	maxClaimTimeout     = 20 * time.Second
+)
+
+type SandboxPool = api.SandboxPool
+type Sandbox = api.Sandbox
+
+func (r *Ravel) CreateSandboxPool(ctx context.Context, namespace string, options api.CreateSandboxPoolPayload) (SandboxPool, error) {
+	if err := validateObjectName(options.Name); err != nil {
+		return SandboxPool{}, errdefs.NewInvalidArgument(err.Error())
+	}
+
+	if _, err := r.State.GetNamespace(ctx, namespace); err != nil {
+		return SandboxPool{}, err
+	}
+
+	config, err := r.validateSandboxPoolConfig(ctx, namespace, options.Config)
+	if err != nil {
+		return SandboxPool{}, err
+	}
+
+	pool := SandboxPool{
+		Id:        id.GeneratePrefixed("sbp"),
+		Name:      options.Name,
+		Namespace: namespace,
+		Config:    config,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := r.State.CreateSandboxPool(ctx, pool); err != nil {
+		return SandboxPool{}, err
+	}
+
+	if err := r.startSandboxPool(pool); err != nil {
+		return SandboxPool{}, err
+	}
+
+	return pool, nil
+}
+
+// validateSandboxPoolConfig fills the defaults of a pool config and checks that
+// machines can be created from it.
+func (r *Ravel) validateSandboxPoolConfig(ctx context.Context, namespace string, config api.SandboxPoolConfig) (api.SandboxPoolConfig, error) {
+	defaults := sandbox.DefaultPoolConfig()
+	if config.DefaultCPUs == 0 {
+		config.DefaultCPUs = defaults.DefaultCPUs
+	}
+	if config.DefaultMemoryMB == 0 {
+		config.DefaultMemoryMB = defaults.DefaultMemoryMB
+	}
+	if config.IdleTimeoutSeconds == 0 {
+		config.IdleTimeoutSeconds = defaults.IdleTimeoutSeconds
+	}
+
+	if config.Region == "" {
+		return config, errdefs.NewInvalidArgument("region is required")
+	}
+
+	if config.MaxWarm < 1 || config.MinWarm < 0 || config.MinWarm > config.MaxWarm {
+		return config, errdefs.NewInvalidArgument("min_warm must be between 0 and max_warm, max_warm must be at least 1")
+	}
+
+	if config.IdleTimeoutSeconds < 0 {
+		return config, errdefs.NewInvalidArgument("idle_timeout_seconds cannot be negative")
+	}
+
+	if _, err := r.GetFleet(ctx, namespace, config.Fleet); err != nil {
+		if errdefs.IsNotFound(err) {
+			return config, errdefs.NewInvalidArgument("fleet not found: " + config.Fleet)
+		}
+		return config, err
+	}
+
+	if _, _, err := r.validateMachineConfig(ctx, namespace, sandboxMachineConfig(config)); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+func sandboxMachineConfig(config api.SandboxPoolConfig) api.MachineConfig {
+	return api.MachineConfig{
+		Image: config.TemplateImage,
+		Guest: api.GuestConfig{
+			CpuKind:  config.CpuKind,
+			Cpus:     config.DefaultCPUs,
+			MemoryMB: config.DefaultMemoryMB,
+		},
+	}
+}
+
+func (r *Ravel) GetSandboxPool(ctx context.Context, namespace, name string) (SandboxPool, error) {
+	return r.State.GetSandboxPool(ctx, namespace, name)
+}
+
+func (r *Ravel) ListSandboxPools(ctx context.Context, namespace string) ([]SandboxPool, error) {
+	return r.State.ListSandboxPools(ctx, namespace)
+}
+
+// DeleteSandboxPool stops a pool and destroys its sandboxes, the claimed ones included.
+func (r *Ravel) DeleteSandboxPool(ctx context.Context, namespace, name string) error {
+	pool, err := r.State.GetSandboxPool(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	r.sandboxPoolsMu.Lock()
+	p, ok := r.sandboxPools[pool.Id]
+	delete(r.sandboxPools, pool.Id)
+	r.sandboxPoolsMu.Unlock()
+
+	if ok {
+		p.Destroy(context.WithoutCancel(ctx))
+	}
+
+	return r.State.DeleteSandboxPool(context.WithoutCancel(ctx), pool.Id)
+}
+
+// ClaimSandbox takes a ready sandbox of a pool, waiting for one up to the timeout of the options.
+func (r *Ravel) ClaimSandbox(ctx context.Context, namespace, name string, options api.ClaimSandboxPayload) (Sandbox, error) {
+	timeout := defaultClaimTimeout
+	if options.TimeoutMs < 0 || time.Duration(options.TimeoutMs)*time.Millisecond > maxClaimTimeout {
+		return Sandbox{}, errdefs.NewInvalidArgument(fmt.Sprintf("timeout_ms must be between 0 and %d", maxClaimTimeout.Milliseconds()))
+	}
+	if options.TimeoutMs > 0 {
+		timeout = time.Duration(options.TimeoutMs) * time.Millisecond
+	}
+
+	p, err := r.getSandboxPool(ctx, namespace, name)
+	if err != nil {
+		return Sandbox{}, err
+	}
+
+	return p.Claim(ctx, timeout)
+}
+
+// ReleaseSandbox returns a claimed sandbox to its pool.
+func (r *Ravel) ReleaseSandbox(ctx context.Context, namespace, name, sandboxId string) error {
+	p, err := r.getSandboxPool(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	return p.Release(ctx, sandboxId)
+}
+
+func (r *Ravel) ListSandboxes(ctx context.Context, namespace, name string) ([]Sandbox, error) {
+	p, err := r.getSandboxPool(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.List(), nil
+}
+
+func (r *Ravel) GetSandboxPoolStats(ctx context.Context, namespace, name string) (api.SandboxPoolStats, error) {
+	p, err := r.getSandboxPool(ctx, namespace, name)
+	if err != nil {
+		return api.SandboxPoolStats{}, err
+	}
+
+	return p.Stats(), nil
+}
+
+func (r *Ravel) getSandboxPool(ctx context.Context, namespace, name string) (*sandbox.Pool, error) {
+	pool, err := r.State.GetSandboxPool(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.sandboxPoolsMu.Lock()
+	defer r.sandboxPoolsMu.Unlock()
+
+	p, ok := r.sandboxPools[pool.Id]
+	if !ok {
+		return nil, errdefs.NewNotFound("sandbox pool not found")
+	}
+
+	return p, nil
+}
+
+func (r *Ravel) startSandboxPool(pool SandboxPool) error {
+	p := sandbox.NewPool(pool, sandboxMachines{r: r}, r.State)
+	if err := p.Start(context.Background()); err != nil {
+		return err
+	}
+
+	r.sandboxPoolsMu.Lock()
+	r.sandboxPools[pool.Id] = p
+	r.sandboxPoolsMu.Unlock()
+
+	return nil
+}
+
+// startSandboxPools starts the persisted pools, with the sandboxes they had when the server stopped.
+func (r *Ravel) startSandboxPools() error {
+	pools, err := r.State.ListAllSandboxPools(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, pool := range pools {
+		if err := r.startSandboxPool(pool); err != nil {
+			slog.Error("Failed to start sandbox pool", "pool", pool.Id, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Ravel) stopSandboxPools() {
+	r.sandboxPoolsMu.Lock()
+	defer r.sandboxPoolsMu.Unlock()
+
+	for id, p := range r.sandboxPools {
+		p.Stop()
+		delete(r.sandboxPools, id)
+	}
+}
+
+// sandboxMachines creates the sandbox machines like the other machines of the fleet,
+// so they are spread across the nodes of the region by the placement broker.
+type sandboxMachines struct {
+	r *Ravel
+}
+
+var _ sandbox.Machines = sandboxMachines{}
+
+func (m sandboxMachines) CreateSandboxMachine(ctx context.Context, pool api.SandboxPool) (string, string, error) {
+	machine, err := m.r.CreateMachine(ctx, pool.Namespace, pool.Config.Fleet, api.CreateMachinePayload{
+		Region: pool.Config.Region,
+		Config: sandboxMachineConfig(pool.Config),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	created, err := m.r.getMachine(context.WithoutCancel(ctx), pool.Namespace, pool.Config.Fleet, machine.Id, false)
+	if err != nil {
+		return machine.Id, "", err
+	}
+
+	return created.Id, created.Node, nil
+}
+
+func (m sandboxMachines) WaitSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) error {
+	machine, err := m.r.getMachine(ctx, pool.Namespace, pool.Config.Fleet, machineId, false)
+	if err != nil {
+		return err
+	}
+
+	return m.r.o.WaitMachine(ctx, machine, api.MachineStatusRunning, 30)
+}
+
+// SnapshotSandboxMachine records the snapshot like the other machine snapshots, it is deleted
+// with the machine.
+func (m sandboxMachines) SnapshotSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) (string, error) {
+	snapshot, err := m.r.CreateMachineSnapshot(ctx, pool.Namespace, pool.Config.Fleet, machineId)
+	if err != nil {
+		return "", err
+	}
+
+	return snapshot.Id, nil
+}
+
+func (m sandboxMachines) RestoreSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string, snapshotId string) error {
+	return m.r.RestoreMachineSnapshot(ctx, pool.Namespace, pool.Config.Fleet, machineId, snapshotId)
+}
+
+func (m sandboxMachines) DestroySandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) error {
+	return m.r.DestroyMachine(ctx, pool.Namespace, pool.Config.Fleet, machineId, true)
+}
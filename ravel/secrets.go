@@ -0,0 +1,116 @@
+package ravel
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+// MachineSecrets returns the values of the secrets referenced by a version of a machine. The node
+// is the node of the agent asking for them, empty if the caller is not an agent, an agent only
+// gets the secrets of its own machines.
+func (r *Ravel) MachineSecrets(ctx context.Context, machineId, version, node string) ([]cluster.MachineSecret, error) {
+	machine, err := r.State.GetMachineById(ctx, machineId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkMachineSecretsAccess(machine, node); err != nil {
+		return nil, err
+	}
+
+	mv, err := r.State.Queries.GetMachineVersion(ctx, machineId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]cluster.MachineSecret, 0, len(mv.Config.Workload.Secrets))
+	for _, ref := range mv.Config.Workload.Secrets {
+		value, err := r.State.GetSecretValue(ctx, machine.Namespace, ref.Name)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return nil, errdefs.NewFailedPrecondition("secret not found: " + ref.Name)
+			}
+			return nil, err
+		}
+
+		secrets = append(secrets, cluster.MachineSecret{SecretRef: ref, Value: value})
+	}
+
+	return secrets, nil
+}
+
+// checkMachineSecretsAccess hides the destroyed machines, and the machines of the other nodes
+// from an agent.
+func checkMachineSecretsAccess(machine cluster.Machine, node string) error {
+	if machine.DestroyedAt != nil || (node != "" && machine.Node != node) {
+		return errdefs.NewNotFound("machine not found")
+	}
+	return nil
+}
+
+// UpdateSecret updates the value of a secret, the machines get it when they boot. If
+// restartMachines is true, the running machines using the secret are restarted one after the
+// other in the background.
+func (r *Ravel) UpdateSecret(ctx context.Context, namespace, name, value string, restartMachines bool) error {
+	if err := r.State.UpdateSecret(ctx, namespace, name, value); err != nil {
+		return err
+	}
+
+	if !restartMachines {
+		return nil
+	}
+
+	machines, err := r.State.Queries.ListSecretMachines(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	go r.restartMachines(machines)
+
+	return nil
+}
+
+// restartMachines restarts the running machines one after the other, it gives up at the first
+// machine failing to restart so that a bad secret does not take all the machines down.
+func (r *Ravel) restartMachines(machines []cluster.Machine) {
+	ctx := context.Background()
+
+	for _, machine := range machines {
+		if err := r.restartMachine(ctx, machine); err != nil {
+			slog.Error("failed to restart machine, stopping the rollout", "machine", machine.Id, "error", err)
+			return
+		}
+	}
+}
+
+func (r *Ravel) restartMachine(ctx context.Context, machine cluster.Machine) error {
+	am, err := r.State.GetAPIMachine(ctx, machine.Namespace, machine.FleetId, machine.Id)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if am.Status != api.MachineStatusRunning {
+		return nil
+	}
+
+	if err := r.o.StopMachineInstance(ctx, machine, nil); err != nil {
+		return err
+	}
+
+	if err := r.o.WaitMachine(ctx, machine, api.MachineStatusStopped, 60); err != nil {
+		return err
+	}
+
+	if err := r.o.StartMachineInstance(ctx, machine); err != nil {
+		return err
+	}
+
+	return r.o.WaitMachine(ctx, machine, api.MachineStatusRunning, 60)
+}
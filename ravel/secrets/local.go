@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const wrapAAD = "ravel-data-key"
+
+// LocalKeyProvider wraps the data keys with master keys read from files, each file holds a
+// base64 encoded 32 bytes key.
+type LocalKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+var _ KeyProvider = (*LocalKeyProvider)(nil)
+
+// NewLocalKeyProvider reads the current master key and the previous ones, which only unwrap the
+// data keys not rotated yet.
+func NewLocalKeyProvider(keyFile string, previousKeyFiles []string) (*LocalKeyProvider, error) {
+	if keyFile == "" {
+		return nil, fmt.Errorf("key_file is required")
+	}
+
+	p := &LocalKeyProvider{keys: map[string][]byte{}}
+	for i, file := range append([]string{keyFile}, previousKeyFiles...) {
+		key, err := readKeyFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		id := masterKeyId(key)
+		if i == 0 {
+			p.current = id
+		}
+		p.keys[id] = key
+	}
+
+	return p, nil
+}
+
+func readKeyFile(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("key file %s is not base64 encoded: %w", file, err)
+	}
+
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key file %s must hold a %d bytes key, got %d", file, KeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// masterKeyId identifies a key without revealing it.
+func masterKeyId(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (p *LocalKeyProvider) KeyId() string {
+	return p.current
+}
+
+func (p *LocalKeyProvider) Wrap(ctx context.Context, dataKey []byte) (WrappedKey, error) {
+	wrapped, err := seal(p.keys[p.current], dataKey, []byte(wrapAAD))
+	if err != nil {
+		return WrappedKey{}, err
+	}
+
+	return WrappedKey{MasterKeyId: p.current, Key: wrapped}, nil
+}
+
+func (p *LocalKeyProvider) Unwrap(ctx context.Context, key WrappedKey) ([]byte, error) {
+	master, ok := p.keys[key.MasterKeyId]
+	if !ok {
+		return nil, fmt.Errorf("master key %s is not configured", key.MasterKeyId)
+	}
+
+	return open(master, key.Key, []byte(wrapAAD))
+}
@@ -0,0 +1,34 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexisbouchez/ravel/core/config"
+)
+
+// WrappedKey is a data key encrypted by a master key of a KeyProvider.
+type WrappedKey struct {
+	MasterKeyId string
+	Key         []byte
+}
+
+// KeyProvider wraps the data keys of the namespaces with a master key, the master keys never
+// leave the provider.
+type KeyProvider interface {
+	// KeyId is the id of the master key wrapping the new data keys.
+	KeyId() string
+	Wrap(ctx context.Context, dataKey []byte) (WrappedKey, error)
+	// Unwrap decrypts a data key wrapped by the current master key or by a previous one.
+	Unwrap(ctx context.Context, key WrappedKey) ([]byte, error)
+}
+
+// NewKeyProvider returns the key provider of the configuration.
+func NewKeyProvider(config config.SecretsConfig) (KeyProvider, error) {
+	switch config.Provider {
+	case "", "local":
+		return NewLocalKeyProvider(config.KeyFile, config.PreviousKeyFiles)
+	default:
+		return nil, fmt.Errorf("unknown secrets key provider %q", config.Provider)
+	}
+}
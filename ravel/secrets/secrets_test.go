@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKeyFile(t *testing.T, name string) string {
+	t.Helper()
+
+	key, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey() error = %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, _ := GenerateDataKey()
+
+	encrypted, err := Encrypt(key, "ns", "db-password", "hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if encrypted == "hunter2" {
+		t.Fatal("Encrypt() returned the plaintext")
+	}
+
+	value, err := Decrypt(key, "ns", "db-password", encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if value != "hunter2" {
+		t.Errorf("Decrypt() = %q, want %q", value, "hunter2")
+	}
+
+	if _, err := Decrypt(key, "ns", "api-token", encrypted); err == nil {
+		t.Error("Decrypt() of the value of another secret should fail")
+	}
+
+	other, _ := GenerateDataKey()
+	if _, err := Decrypt(other, "ns", "db-password", encrypted); err == nil {
+		t.Error("Decrypt() with another data key should fail")
+	}
+}
+
+func TestLocalKeyProviderRotation(t *testing.T) {
+	ctx := context.Background()
+	oldFile := writeKeyFile(t, "old.key")
+	newFile := writeKeyFile(t, "new.key")
+
+	old, err := NewLocalKeyProvider(oldFile, nil)
+	if err != nil {
+		t.Fatalf("NewLocalKeyProvider() error = %v", err)
+	}
+
+	dataKey, _ := GenerateDataKey()
+	wrapped, err := old.Wrap(ctx, dataKey)
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	if wrapped.MasterKeyId != old.KeyId() {
+		t.Errorf("Wrap() master key = %s, want %s", wrapped.MasterKeyId, old.KeyId())
+	}
+
+	rotated, err := NewLocalKeyProvider(newFile, []string{oldFile})
+	if err != nil {
+		t.Fatalf("NewLocalKeyProvider() error = %v", err)
+	}
+	if rotated.KeyId() == old.KeyId() {
+		t.Fatal("the rotated provider should wrap with the new key")
+	}
+
+	unwrapped, err := rotated.Unwrap(ctx, wrapped)
+	if err != nil {
+		t.Fatalf("Unwrap() with a previous key error = %v", err)
+	}
+	if string(unwrapped) != string(dataKey) {
+		t.Error("Unwrap() returned another data key")
+	}
+
+	withoutOld, _ := NewLocalKeyProvider(newFile, nil)
+	if _, err := withoutOld.Unwrap(ctx, wrapped); err == nil {
+		t.Error("Unwrap() without the master key should fail")
+	}
+}
+
+func TestLocalKeyProviderInvalidKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "short.key")
+	os.WriteFile(file, []byte(base64.StdEncoding.EncodeToString([]byte("too short"))), 0600)
+
+	if _, err := NewLocalKeyProvider(file, nil); err == nil {
+		t.Error("NewLocalKeyProvider() should reject a key which is not 32 bytes")
+	}
+}
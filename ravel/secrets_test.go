@@ -0,0 +1,39 @@
+package ravel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+)
+
+func TestCheckMachineSecretsAccess(t *testing.T) {
+	destroyedAt := time.Now()
+
+	tests := []struct {
+		name    string
+		machine cluster.Machine
+		node    string
+		allowed bool
+	}{
+		{"agent of the machine node", cluster.Machine{Node: "node-1"}, "node-1", true},
+		{"agent of another node", cluster.Machine{Node: "node-1"}, "node-2", false},
+		{"server client", cluster.Machine{Node: "node-1"}, "", true},
+		{"destroyed machine", cluster.Machine{Node: "node-1", DestroyedAt: &destroyedAt}, "node-1", false},
+		{"destroyed machine for a server client", cluster.Machine{Node: "node-1", DestroyedAt: &destroyedAt}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMachineSecretsAccess(tt.machine, tt.node)
+			if tt.allowed && err != nil {
+				t.Errorf("checkMachineSecretsAccess() error = %v, want allowed", err)
+			}
+			// the machines of the other nodes are not revealed to an agent
+			if !tt.allowed && !errdefs.IsNotFound(err) {
+				t.Errorf("checkMachineSecretsAccess() error = %v, want not found", err)
+			}
+		})
+	}
+}
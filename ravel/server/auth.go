@@ -2,8 +2,12 @@ package server
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
+
+	"github.com/alexisbouchez/ravel/internal/mtls"
+	"github.com/alexisbouchez/ravel/ravel/server/endpoints"
 )
 
 func unauthorized(ctx huma.Context) {
@@ -11,8 +15,30 @@ func unauthorized(ctx huma.Context) {
 	ctx.BodyWriter().Write([]byte("Unauthorized"))
 }
 
+func forbidden(ctx huma.Context) {
+	ctx.SetStatus(403)
+	ctx.BodyWriter().Write([]byte("Forbidden"))
+}
+
+// newAuthMiddleware checks the bearer token of the requests. The agents authenticated by their
+// certificate are only allowed to call the internal endpoints, which are not open to the client
+// certificates.
 func newAuthMiddleware(bearer []byte) func(ctx huma.Context, next func(huma.Context)) {
 	return func(ctx huma.Context, next func(huma.Context)) {
+		internal := strings.HasPrefix(ctx.Operation().Path, endpoints.InternalPathPrefix)
+
+		if cs := ctx.TLS(); cs != nil && len(cs.PeerCertificates) > 0 {
+			node, ok := mtls.AgentNode(*cs)
+			if ok != internal {
+				forbidden(ctx)
+				return
+			}
+			if ok {
+				next(endpoints.WithAgentNode(ctx, node))
+				return
+			}
+		}
+
 		if bearer == nil {
 			next(ctx)
 			return
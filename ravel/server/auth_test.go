@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humago"
+
+	"github.com/alexisbouchez/ravel/ravel/server/endpoints"
+)
+
+type nodeResponse struct {
+	Body string
+}
+
+func newTestAuthAPI(bearer []byte) http.Handler {
+	mux := http.NewServeMux()
+	api := humago.New(mux, huma.DefaultConfig("test", "1.0.0"))
+	api.UseMiddleware(newAuthMiddleware(bearer))
+
+	handler := func(ctx context.Context, _ *struct{}) (*nodeResponse, error) {
+		return &nodeResponse{Body: endpoints.AgentNode(ctx)}, nil
+	}
+	huma.Get(api, endpoints.InternalPathPrefix+"node", handler)
+	huma.Get(api, "/public", handler)
+
+	return mux
+}
+
+func peer(commonName string) *tls.ConnectionState {
+	return &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: commonName}}},
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	handler := newTestAuthAPI([]byte("Bearer secret"))
+
+	tests := []struct {
+		name          string
+		path          string
+		tls           *tls.ConnectionState
+		authorization string
+		wantStatus    int
+		wantNode      string
+	}{
+		{"agent on an internal endpoint", "/internal/node", peer("node-1.eu.agent.ravel"), "", http.StatusOK, "node-1"},
+		{"agent on a public endpoint", "/public", peer("node-1.eu.agent.ravel"), "Bearer secret", http.StatusForbidden, ""},
+		{"client certificate on an internal endpoint", "/internal/node", peer("cli.eu.client.ravel"), "Bearer secret", http.StatusForbidden, ""},
+		{"client certificate on a public endpoint", "/public", peer("cli.eu.client.ravel"), "Bearer secret", http.StatusOK, ""},
+		{"client certificate without token", "/public", peer("cli.eu.client.ravel"), "", http.StatusUnauthorized, ""},
+		{"bearer token", "/public", nil, "Bearer secret", http.StatusOK, ""},
+		{"wrong bearer token", "/public", nil, "Bearer wrong", http.StatusUnauthorized, ""},
+		{"bearer token on an internal endpoint", "/internal/node", nil, "Bearer secret", http.StatusOK, ""},
+		{"no credentials on an internal endpoint", "/internal/node", nil, "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.TLS = tt.tls
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var node string
+			if err := json.Unmarshal(rec.Body.Bytes(), &node); err != nil || node != tt.wantNode {
+				t.Errorf("agent node = %q, %v, want %q", node, err, tt.wantNode)
+			}
+		})
+	}
+}
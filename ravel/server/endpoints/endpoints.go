@@ -38,6 +38,38 @@ func (e *Endpoints) Register(api huma.API) {
 		Summary:     "List nodes",
 	}, e.listNodes)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "cordonNode",
+		Path:        "/nodes/{node}/cordon",
+		Method:      http.MethodPost,
+		Tags:        []string{"nodes"},
+		Summary:     "Stop placing machines on a node",
+	}, e.cordonNode)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "uncordonNode",
+		Path:        "/nodes/{node}/uncordon",
+		Method:      http.MethodPost,
+		Tags:        []string{"nodes"},
+		Summary:     "Place machines on a cordoned node again",
+	}, e.uncordonNode)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "drainNode",
+		Path:        "/nodes/{node}/drain",
+		Method:      http.MethodPost,
+		Tags:        []string{"nodes"},
+		Summary:     "Cordon a node and move or stop its machines",
+	}, e.drainNode)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getNodeDrain",
+		Path:        "/nodes/{node}/drain",
+		Method:      http.MethodGet,
+		Tags:        []string{"nodes"},
+		Summary:     "Get the progress of a node drain",
+	}, e.getNodeDrain)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "createNamespace",
 		Path:        "/namespaces",
@@ -110,6 +142,183 @@ func (e *Endpoints) Register(api huma.API) {
 		Tags:        []string{"secrets"},
 	}, e.deleteSecret)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "getMachineSecrets",
+		Summary:     "Get the secrets of a machine version, called by the agents when the machine boots",
+		Method:      http.MethodGet,
+		Path:        InternalPathPrefix + "machines/{machine_id}/versions/{version}/secrets",
+		Tags:        []string{"internal"},
+		Hidden:      true,
+	}, e.getMachineSecrets)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "createNetwork",
+		Summary:     "Create a private network",
+		Method:      http.MethodPost,
+		Path:        "/namespaces/{namespace}/networks",
+		Tags:        []string{"networks"},
+	}, e.createNetwork)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listNetworks",
+		Summary:     "List private networks",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/networks",
+		Tags:        []string{"networks"},
+	}, e.listNetworks)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getNetwork",
+		Summary:     "Get a private network",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/networks/{network}",
+		Tags:        []string{"networks"},
+	}, e.getNetwork)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteNetwork",
+		Summary:     "Delete a private network",
+		Method:      http.MethodDelete,
+		Path:        "/namespaces/{namespace}/networks/{network}",
+		Tags:        []string{"networks"},
+	}, e.deleteNetwork)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "createVolume",
+		Summary:     "Create a volume",
+		Method:      http.MethodPost,
+		Path:        "/namespaces/{namespace}/volumes",
+		Tags:        []string{"volumes"},
+	}, e.createVolume)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listVolumes",
+		Summary:     "List volumes",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/volumes",
+		Tags:        []string{"volumes"},
+	}, e.listVolumes)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getVolume",
+		Summary:     "Get a volume",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/volumes/{volume}",
+		Tags:        []string{"volumes"},
+	}, e.getVolume)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "resizeVolume",
+		Summary:     "Grow a volume",
+		Method:      http.MethodPatch,
+		Path:        "/namespaces/{namespace}/volumes/{volume}",
+		Tags:        []string{"volumes"},
+	}, e.resizeVolume)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteVolume",
+		Summary:     "Delete a volume",
+		Method:      http.MethodDelete,
+		Path:        "/namespaces/{namespace}/volumes/{volume}",
+		Tags:        []string{"volumes"},
+	}, e.deleteVolume)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "createVolumeSnapshot",
+		Summary:     "Snapshot a volume",
+		Method:      http.MethodPost,
+		Path:        "/namespaces/{namespace}/volumes/{volume}/snapshots",
+		Tags:        []string{"volumes"},
+	}, e.createVolumeSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listVolumeSnapshots",
+		Summary:     "List the snapshots of a volume",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/volumes/{volume}/snapshots",
+		Tags:        []string{"volumes"},
+	}, e.listVolumeSnapshots)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteVolumeSnapshot",
+		Summary:     "Delete a volume snapshot",
+		Method:      http.MethodDelete,
+		Path:        "/namespaces/{namespace}/volumes/{volume}/snapshots/{snapshot}",
+		Tags:        []string{"volumes"},
+	}, e.deleteVolumeSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "restoreVolumeSnapshot",
+		Summary:     "Restore a volume to a snapshot",
+		Method:      http.MethodPost,
+		Path:        "/namespaces/{namespace}/volumes/{volume}/snapshots/{snapshot}/restore",
+		Tags:        []string{"volumes"},
+	}, e.restoreVolumeSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "createSandboxPool",
+		Summary:     "Create a sandbox pool",
+		Method:      http.MethodPost,
+		Path:        "/namespaces/{namespace}/sandbox-pools",
+		Tags:        []string{"sandboxes"},
+	}, e.createSandboxPool)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listSandboxPools",
+		Summary:     "List sandbox pools",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/sandbox-pools",
+		Tags:        []string{"sandboxes"},
+	}, e.listSandboxPools)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getSandboxPool",
+		Summary:     "Get a sandbox pool",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/sandbox-pools/{pool}",
+		Tags:        []string{"sandboxes"},
+	}, e.getSandboxPool)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteSandboxPool",
+		Summary:     "Delete a sandbox pool and its sandboxes",
+		Method:      http.MethodDelete,
+		Path:        "/namespaces/{namespace}/sandbox-pools/{pool}",
+		Tags:        []string{"sandboxes"},
+	}, e.deleteSandboxPool)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getSandboxPoolStats",
+		Summary:     "Get the stats of a sandbox pool",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/sandbox-pools/{pool}/stats",
+		Tags:        []string{"sandboxes"},
+	}, e.getSandboxPoolStats)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listSandboxes",
+		Summary:     "List the sandboxes of a pool",
+		Method:      http.MethodGet,
+		Path:        "/namespaces/{namespace}/sandbox-pools/{pool}/sandboxes",
+		Tags:        []string{"sandboxes"},
+	}, e.listSandboxes)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "claimSandbox",
+		Summary:     "Claim a ready sandbox",
+		Method:      http.MethodPost,
+		Path:        "/namespaces/{namespace}/sandbox-pools/{pool}/claim",
+		Tags:        []string{"sandboxes"},
+	}, e.claimSandbox)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "releaseSandbox",
+		Summary:     "Release a claimed sandbox",
+		Method:      http.MethodPost,
+		Path:        "/namespaces/{namespace}/sandbox-pools/{pool}/sandboxes/{sandbox_id}/release",
+		Tags:        []string{"sandboxes"},
+	}, e.releaseSandbox)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "createFleet",
 		Summary:     "Create a fleet",
@@ -182,6 +391,14 @@ func (e *Endpoints) Register(api huma.API) {
 		Tags:        []string{"machines"},
 	}, e.getMachine)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "updateMachine",
+		Summary:     "Update a machine config",
+		Path:        "/fleets/{fleet}/machines/{machine_id}",
+		Method:      http.MethodPatch,
+		Tags:        []string{"machines"},
+	}, e.updateMachine)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "startMachine",
 		Summary:     "Start a machine",
@@ -206,6 +423,22 @@ func (e *Endpoints) Register(api huma.API) {
 		Tags:        []string{"machines"},
 	}, e.machineExec)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "machineExecStream",
+		Summary:     "Execute a command inside a machine and stream its output",
+		Path:        "/fleets/{fleet}/machines/{machine_id}/exec/stream",
+		Method:      http.MethodPost,
+		Tags:        []string{"machines"},
+	}, e.machineExecStream)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "machineExecSession",
+		Summary:     "Open an interactive exec session with a machine over a WebSocket",
+		Path:        "/fleets/{fleet}/machines/{machine_id}/exec/tty",
+		Method:      http.MethodGet,
+		Tags:        []string{"machines"},
+	}, e.machineExecSession)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "listMachineVersions",
 		Summary:     "List machine versions",
@@ -262,6 +495,54 @@ func (e *Endpoints) Register(api huma.API) {
 		Tags:        []string{"machines"},
 	}, e.updateMachineMetadata)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "createMachineSnapshot",
+		Summary:     "Snapshot the memory of a running machine",
+		Method:      http.MethodPost,
+		Path:        "/fleets/{fleet}/machines/{machine_id}/snapshots",
+		Tags:        []string{"machines"},
+	}, e.createMachineSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listMachineSnapshots",
+		Summary:     "List the snapshots of a machine",
+		Method:      http.MethodGet,
+		Path:        "/fleets/{fleet}/machines/{machine_id}/snapshots",
+		Tags:        []string{"machines"},
+	}, e.listMachineSnapshots)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteMachineSnapshot",
+		Summary:     "Delete a machine snapshot",
+		Method:      http.MethodDelete,
+		Path:        "/fleets/{fleet}/machines/{machine_id}/snapshots/{snapshot}",
+		Tags:        []string{"machines"},
+	}, e.deleteMachineSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "restoreMachineSnapshot",
+		Summary:     "Restore a running machine to a snapshot",
+		Method:      http.MethodPost,
+		Path:        "/fleets/{fleet}/machines/{machine_id}/snapshots/{snapshot}/restore",
+		Tags:        []string{"machines"},
+	}, e.restoreMachineSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "forkMachine",
+		Summary:     "Create running machines from a snapshot of the memory of a running machine",
+		Method:      http.MethodPost,
+		Path:        "/fleets/{fleet}/machines/{machine_id}/fork",
+		Tags:        []string{"machines"},
+	}, e.forkMachine)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "migrateMachine",
+		Summary:     "Move a machine and its volumes to another node of its region, a running machine is live migrated",
+		Method:      http.MethodPost,
+		Path:        "/fleets/{fleet}/machines/{machine_id}/migrate",
+		Tags:        []string{"machines"},
+	}, e.migrateMachine)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "createGateway",
 		Summary:     "Create a gateway",
@@ -294,6 +575,46 @@ func (e *Endpoints) Register(api huma.API) {
 		Tags:        []string{"gateways"},
 	}, e.destroyGateway)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "addGatewayDomain",
+		Summary:     "Add a custom domain to a gateway",
+		Method:      http.MethodPost,
+		Path:        "/fleets/{fleet}/gateways/{gateway}/domains",
+		Tags:        []string{"gateways"},
+	}, e.addGatewayDomain)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listGatewayDomains",
+		Summary:     "List the custom domains of a gateway",
+		Method:      http.MethodGet,
+		Path:        "/fleets/{fleet}/gateways/{gateway}/domains",
+		Tags:        []string{"gateways"},
+	}, e.listGatewayDomains)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getGatewayDomain",
+		Summary:     "Get a gateway custom domain",
+		Method:      http.MethodGet,
+		Path:        "/fleets/{fleet}/gateways/{gateway}/domains/{domain}",
+		Tags:        []string{"gateways"},
+	}, e.getGatewayDomain)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "verifyGatewayDomain",
+		Summary:     "Verify the DNS records of a gateway custom domain",
+		Method:      http.MethodPost,
+		Path:        "/fleets/{fleet}/gateways/{gateway}/domains/{domain}/verify",
+		Tags:        []string{"gateways"},
+	}, e.verifyGatewayDomain)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteGatewayDomain",
+		Summary:     "Remove a custom domain from a gateway",
+		Method:      http.MethodDelete,
+		Path:        "/fleets/{fleet}/gateways/{gateway}/domains/{domain}",
+		Tags:        []string{"gateways"},
+	}, e.deleteGatewayDomain)
+
 	// Build endpoints
 	huma.Register(api, huma.Operation{
 		OperationID: "createBuild",
@@ -18,7 +18,7 @@ type CreateFleetResponse struct {
 }
 
 func (e *Endpoints) createFleet(ctx context.Context, req *CreateFleetRequest) (*CreateFleetResponse, error) {
-	fleet, err := e.ravel.CreateFleet(ctx, req.Namespace, req.Body.Name, req.Body.Metadata)
+	fleet, err := e.ravel.CreateFleet(ctx, req.Namespace, req.Body.Name, req.Body.Metadata, req.Body.PlacementStrategy)
 	if err != nil {
 		e.log("Failed to create fleet", err)
 		return nil, err
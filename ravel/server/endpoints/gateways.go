@@ -81,3 +81,85 @@ func (e *Endpoints) destroyGateway(ctx context.Context, req *DestroyGatewayReque
 
 	return nil, nil
 }
+
+type AddGatewayDomainRequest struct {
+	FleetResolver
+	Gateway string `path:"gateway"`
+	Body    api.AddGatewayDomainPayload
+}
+
+type AddGatewayDomainResponse struct {
+	Body ravel.GatewayDomain `json:"domain"`
+}
+
+func (e *Endpoints) addGatewayDomain(ctx context.Context, req *AddGatewayDomainRequest) (*AddGatewayDomainResponse, error) {
+	domain, err := e.ravel.AddGatewayDomain(ctx, req.Namespace, req.Fleet, req.Gateway, req.Body)
+	if err != nil {
+		e.log("Failed to add gateway domain", err)
+		return nil, err
+	}
+
+	return &AddGatewayDomainResponse{Body: domain}, nil
+}
+
+type ListGatewayDomainsRequest struct {
+	FleetResolver
+	Gateway string `path:"gateway"`
+}
+
+type ListGatewayDomainsResponse struct {
+	Body []ravel.GatewayDomain `json:"domains"`
+}
+
+func (e *Endpoints) listGatewayDomains(ctx context.Context, req *ListGatewayDomainsRequest) (*ListGatewayDomainsResponse, error) {
+	domains, err := e.ravel.ListGatewayDomains(ctx, req.Namespace, req.Fleet, req.Gateway)
+	if err != nil {
+		e.log("Failed to list gateway domains", err)
+		return nil, err
+	}
+
+	return &ListGatewayDomainsResponse{Body: domains}, nil
+}
+
+type GatewayDomainRequest struct {
+	FleetResolver
+	Gateway string `path:"gateway"`
+	Domain  string `path:"domain"`
+}
+
+type GatewayDomainResponse struct {
+	Body ravel.GatewayDomain `json:"domain"`
+}
+
+func (e *Endpoints) getGatewayDomain(ctx context.Context, req *GatewayDomainRequest) (*GatewayDomainResponse, error) {
+	domain, err := e.ravel.GetGatewayDomain(ctx, req.Namespace, req.Fleet, req.Gateway, req.Domain)
+	if err != nil {
+		e.log("Failed to get gateway domain", err)
+		return nil, err
+	}
+
+	return &GatewayDomainResponse{Body: domain}, nil
+}
+
+func (e *Endpoints) verifyGatewayDomain(ctx context.Context, req *GatewayDomainRequest) (*GatewayDomainResponse, error) {
+	domain, err := e.ravel.VerifyGatewayDomain(ctx, req.Namespace, req.Fleet, req.Gateway, req.Domain)
+	if err != nil {
+		e.log("Failed to verify gateway domain", err)
+		return nil, err
+	}
+
+	return &GatewayDomainResponse{Body: domain}, nil
+}
+
+type DeleteGatewayDomainResponse struct {
+}
+
+func (e *Endpoints) deleteGatewayDomain(ctx context.Context, req *GatewayDomainRequest) (*DeleteGatewayDomainResponse, error) {
+	err := e.ravel.DeleteGatewayDomain(ctx, req.Namespace, req.Fleet, req.Gateway, req.Domain)
+	if err != nil {
+		e.log("Failed to delete gateway domain", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
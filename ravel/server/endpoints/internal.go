@@ -0,0 +1,43 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// InternalPathPrefix is the prefix of the endpoints called by the agents.
+const InternalPathPrefix = "/internal/"
+
+type agentNodeKey struct{}
+
+// WithAgentNode marks the request as sent by the agent of the node.
+func WithAgentNode(ctx huma.Context, node string) huma.Context {
+	return huma.WithValue(ctx, agentNodeKey{}, node)
+}
+
+// AgentNode returns the node of the agent sending the request, empty if it is not sent by an agent.
+func AgentNode(ctx context.Context) string {
+	node, _ := ctx.Value(agentNodeKey{}).(string)
+	return node
+}
+
+type GetMachineSecretsRequest struct {
+	MachineId string `path:"machine_id"`
+	Version   string `path:"version"`
+}
+
+type GetMachineSecretsResponse struct {
+	Body []cluster.MachineSecret
+}
+
+func (e *Endpoints) getMachineSecrets(ctx context.Context, req *GetMachineSecretsRequest) (*GetMachineSecretsResponse, error) {
+	secrets, err := e.ravel.MachineSecrets(ctx, req.MachineId, req.Version, AgentNode(ctx))
+	if err != nil {
+		e.log("Failed to get machine secrets", err)
+		return nil, err
+	}
+
+	return &GetMachineSecretsResponse{Body: secrets}, nil
+}
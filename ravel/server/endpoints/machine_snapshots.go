@@ -0,0 +1,70 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/ravel"
+)
+
+type MachineSnapshotsRequest struct {
+	MachineResolver
+}
+
+type CreateMachineSnapshotResponse struct {
+	Body ravel.MachineSnapshot `json:"snapshot"`
+}
+
+func (e *Endpoints) createMachineSnapshot(ctx context.Context, req *MachineSnapshotsRequest) (*CreateMachineSnapshotResponse, error) {
+	snapshot, err := e.ravel.CreateMachineSnapshot(ctx, req.Namespace, req.Fleet, req.MachineId)
+	if err != nil {
+		e.log("Failed to create machine snapshot", err)
+		return nil, err
+	}
+
+	return &CreateMachineSnapshotResponse{Body: snapshot}, nil
+}
+
+type ListMachineSnapshotsResponse struct {
+	Body []ravel.MachineSnapshot `json:"snapshots"`
+}
+
+func (e *Endpoints) listMachineSnapshots(ctx context.Context, req *MachineSnapshotsRequest) (*ListMachineSnapshotsResponse, error) {
+	snapshots, err := e.ravel.ListMachineSnapshots(ctx, req.Namespace, req.Fleet, req.MachineId)
+	if err != nil {
+		e.log("Failed to list machine snapshots", err)
+		return nil, err
+	}
+
+	return &ListMachineSnapshotsResponse{Body: snapshots}, nil
+}
+
+type MachineSnapshotRequest struct {
+	MachineResolver
+	Snapshot string `path:"snapshot"`
+}
+
+type DeleteMachineSnapshotResponse struct {
+}
+
+func (e *Endpoints) deleteMachineSnapshot(ctx context.Context, req *MachineSnapshotRequest) (*DeleteMachineSnapshotResponse, error) {
+	err := e.ravel.DeleteMachineSnapshot(ctx, req.Namespace, req.Fleet, req.MachineId, req.Snapshot)
+	if err != nil {
+		e.log("Failed to delete machine snapshot", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type RestoreMachineSnapshotResponse struct {
+}
+
+func (e *Endpoints) restoreMachineSnapshot(ctx context.Context, req *MachineSnapshotRequest) (*RestoreMachineSnapshotResponse, error) {
+	err := e.ravel.RestoreMachineSnapshot(ctx, req.Namespace, req.Fleet, req.MachineId, req.Snapshot)
+	if err != nil {
+		e.log("Failed to restore machine snapshot", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
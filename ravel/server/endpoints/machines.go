@@ -3,12 +3,15 @@ package endpoints
 import (
 	"bufio"
 	"context"
+	"io"
 	"log/slog"
 	"net/http"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/streamutil"
 	"github.com/danielgtaylor/huma/v2"
+	"golang.org/x/net/websocket"
 )
 
 type CreateMachineBody = api.CreateMachinePayload
@@ -34,6 +37,25 @@ func (s *Endpoints) createMachine(ctx context.Context, req *CreateMachineRequest
 
 }
 
+type UpdateMachineRequest struct {
+	MachineResolver
+	Body *api.UpdateMachinePayload
+}
+
+type UpdateMachineResponse struct {
+	Body *api.Machine
+}
+
+func (e *Endpoints) updateMachine(ctx context.Context, req *UpdateMachineRequest) (*UpdateMachineResponse, error) {
+	m, err := e.ravel.UpdateMachine(ctx, req.Namespace, req.Fleet, req.MachineId, *req.Body)
+	if err != nil {
+		e.log("Failed to update machine", err)
+		return nil, err
+	}
+
+	return &UpdateMachineResponse{Body: m}, nil
+}
+
 type DestroyMachineRequest struct {
 	MachineResolver
 	Force bool `query:"force"`
@@ -113,6 +135,44 @@ func (e *Endpoints) listMachineVersions(ctx context.Context, req *ListMachineVer
 	}, nil
 }
 
+type ForkMachineRequest struct {
+	MachineResolver
+	Count int `query:"count" minimum:"1" maximum:"16" default:"1" doc:"Number of machines to create from the machine"`
+}
+
+type ForkMachineResponse struct {
+	Body []api.Machine
+}
+
+func (e *Endpoints) forkMachine(ctx context.Context, req *ForkMachineRequest) (*ForkMachineResponse, error) {
+	machines, err := e.ravel.ForkMachine(ctx, req.Namespace, req.Fleet, req.MachineId, req.Count)
+	if err != nil {
+		e.log("Failed to fork machine", err)
+		return nil, err
+	}
+
+	return &ForkMachineResponse{Body: machines}, nil
+}
+
+type MigrateMachineRequest struct {
+	MachineResolver
+	Node string `query:"node" doc:"Id of the node to move the machine to, in the region of the machine"`
+}
+
+type MigrateMachineResponse struct {
+	Body *api.Machine
+}
+
+func (e *Endpoints) migrateMachine(ctx context.Context, req *MigrateMachineRequest) (*MigrateMachineResponse, error) {
+	m, err := e.ravel.MigrateMachine(ctx, req.Namespace, req.Fleet, req.MachineId, req.Node)
+	if err != nil {
+		e.log("Failed to migrate machine", err)
+		return nil, err
+	}
+
+	return &MigrateMachineResponse{Body: m}, nil
+}
+
 type StartMachineRequest struct {
 	MachineResolver
 }
@@ -165,9 +225,49 @@ func (e *Endpoints) machineExec(ctx context.Context, req *ExecCmdRequest) (*api.
 	return res, nil
 }
 
+type MachineExecStreamRequest struct {
+	MachineResolver
+	Body api.ExecStreamRequest
+}
+
+func (e *Endpoints) machineExecStream(ctx context.Context, req *MachineExecStreamRequest) (*huma.StreamResponse, error) {
+	output, err := e.ravel.MachineExecStream(ctx, req.Namespace, req.Fleet, req.MachineId, req.Body)
+	if err != nil {
+		e.log("Failed to stream command", err)
+		return nil, err
+	}
+
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			streamutil.CopyLines(ctx, output)
+		},
+	}, nil
+}
+
+type MachineExecSessionRequest struct {
+	MachineResolver
+}
+
+func (e *Endpoints) machineExecSession(ctx context.Context, req *MachineExecSessionRequest) (*huma.StreamResponse, error) {
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			streamutil.ServeWebSocket(ctx, func(ws *websocket.Conn) {
+				streamutil.RelayExecSession(ws, func() (io.ReadWriteCloser, error) {
+					session, err := e.ravel.MachineExecSession(ctx.Context(), req.Namespace, req.Fleet, req.MachineId)
+					if err != nil {
+						e.log("Failed to open exec session", err)
+					}
+					return session, err
+				})
+			})
+		},
+	}, nil
+}
+
 type GetMachineLogsRequest struct {
 	MachineResolver
 	Follow bool `query:"follow"`
+	api.LogsOptions
 }
 
 type GetMachineLogsResponse struct {
@@ -175,7 +275,7 @@ type GetMachineLogsResponse struct {
 }
 
 func (e *Endpoints) getMachineLogs(ctx context.Context, req *GetMachineLogsRequest) (*huma.StreamResponse, error) {
-	logs, err := e.ravel.GetMachineLogsRaw(ctx, req.Namespace, req.Fleet, req.MachineId, req.Follow)
+	logs, err := e.ravel.GetMachineLogsRaw(ctx, req.Namespace, req.Fleet, req.MachineId, req.Follow, req.LogsOptions)
 	if err != nil {
 		e.log("Failed to get machine logs", err)
 		return nil, err
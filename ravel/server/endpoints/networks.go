@@ -0,0 +1,77 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/ravel"
+)
+
+type CreateNetworkRequest struct {
+	Namespace string `path:"namespace"`
+	Body      api.CreateNetworkPayload
+}
+
+type CreateNetworkResponse struct {
+	Body ravel.Network `json:"network"`
+}
+
+func (e *Endpoints) createNetwork(ctx context.Context, req *CreateNetworkRequest) (*CreateNetworkResponse, error) {
+	network, err := e.ravel.CreateNetwork(ctx, req.Namespace, req.Body)
+	if err != nil {
+		e.log("Failed to create network", err)
+		return nil, err
+	}
+
+	return &CreateNetworkResponse{Body: network}, nil
+}
+
+type ListNetworksRequest struct {
+	Namespace string `path:"namespace"`
+}
+
+type ListNetworksResponse struct {
+	Body []ravel.Network `json:"networks"`
+}
+
+func (e *Endpoints) listNetworks(ctx context.Context, req *ListNetworksRequest) (*ListNetworksResponse, error) {
+	networks, err := e.ravel.ListNetworks(ctx, req.Namespace)
+	if err != nil {
+		e.log("Failed to list networks", err)
+		return nil, err
+	}
+
+	return &ListNetworksResponse{Body: networks}, nil
+}
+
+type NetworkRequest struct {
+	Namespace string `path:"namespace"`
+	Network   string `path:"network"`
+}
+
+type GetNetworkResponse struct {
+	Body ravel.Network `json:"network"`
+}
+
+func (e *Endpoints) getNetwork(ctx context.Context, req *NetworkRequest) (*GetNetworkResponse, error) {
+	network, err := e.ravel.GetNetwork(ctx, req.Namespace, req.Network)
+	if err != nil {
+		e.log("Failed to get network", err)
+		return nil, err
+	}
+
+	return &GetNetworkResponse{Body: network}, nil
+}
+
+type DeleteNetworkResponse struct {
+}
+
+func (e *Endpoints) deleteNetwork(ctx context.Context, req *NetworkRequest) (*DeleteNetworkResponse, error) {
+	err := e.ravel.DeleteNetwork(ctx, req.Namespace, req.Network)
+	if err != nil {
+		e.log("Failed to delete network", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
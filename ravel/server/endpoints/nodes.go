@@ -24,3 +24,48 @@ func (e *Endpoints) listNodes(ctx context.Context, _ *ListNodesRequest) (*ListNo
 	return &ListNodesResponse{Body: nodes}, nil
 
 }
+
+type NodeRequest struct {
+	Node string `path:"node"`
+}
+
+type CordonNodeResponse struct {
+}
+
+func (e *Endpoints) cordonNode(ctx context.Context, req *NodeRequest) (*CordonNodeResponse, error) {
+	if err := e.ravel.CordonNode(ctx, req.Node); err != nil {
+		e.log("Failed to cordon node", err)
+		return nil, err
+	}
+	return &CordonNodeResponse{}, nil
+}
+
+func (e *Endpoints) uncordonNode(ctx context.Context, req *NodeRequest) (*CordonNodeResponse, error) {
+	if err := e.ravel.UncordonNode(ctx, req.Node); err != nil {
+		e.log("Failed to uncordon node", err)
+		return nil, err
+	}
+	return &CordonNodeResponse{}, nil
+}
+
+type NodeDrainResponse struct {
+	Body *api.NodeDrain
+}
+
+func (e *Endpoints) drainNode(ctx context.Context, req *NodeRequest) (*NodeDrainResponse, error) {
+	drain, err := e.ravel.DrainNode(ctx, req.Node)
+	if err != nil {
+		e.log("Failed to drain node", err)
+		return nil, err
+	}
+	return &NodeDrainResponse{Body: drain}, nil
+}
+
+func (e *Endpoints) getNodeDrain(ctx context.Context, req *NodeRequest) (*NodeDrainResponse, error) {
+	drain, err := e.ravel.GetNodeDrain(ctx, req.Node)
+	if err != nil {
+		e.log("Failed to get node drain", err)
+		return nil, err
+	}
+	return &NodeDrainResponse{Body: drain}, nil
+}
@@ -0,0 +1,144 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/ravel"
+)
+
+type CreateSandboxPoolRequest struct {
+	Namespace string `path:"namespace"`
+	Body      api.CreateSandboxPoolPayload
+}
+
+type CreateSandboxPoolResponse struct {
+	Body ravel.SandboxPool `json:"sandbox_pool"`
+}
+
+func (e *Endpoints) createSandboxPool(ctx context.Context, req *CreateSandboxPoolRequest) (*CreateSandboxPoolResponse, error) {
+	pool, err := e.ravel.CreateSandboxPool(ctx, req.Namespace, req.Body)
+	if err != nil {
+		e.log("Failed to create sandbox pool", err)
+		return nil, err
+	}
+
+	return &CreateSandboxPoolResponse{Body: pool}, nil
+}
+
+type ListSandboxPoolsRequest struct {
+	Namespace string `path:"namespace"`
+}
+
+type ListSandboxPoolsResponse struct {
+	Body []ravel.SandboxPool `json:"sandbox_pools"`
+}
+
+func (e *Endpoints) listSandboxPools(ctx context.Context, req *ListSandboxPoolsRequest) (*ListSandboxPoolsResponse, error) {
+	pools, err := e.ravel.ListSandboxPools(ctx, req.Namespace)
+	if err != nil {
+		e.log("Failed to list sandbox pools", err)
+		return nil, err
+	}
+
+	return &ListSandboxPoolsResponse{Body: pools}, nil
+}
+
+type SandboxPoolRequest struct {
+	Namespace string `path:"namespace"`
+	Pool      string `path:"pool"`
+}
+
+type GetSandboxPoolResponse struct {
+	Body ravel.SandboxPool `json:"sandbox_pool"`
+}
+
+func (e *Endpoints) getSandboxPool(ctx context.Context, req *SandboxPoolRequest) (*GetSandboxPoolResponse, error) {
+	pool, err := e.ravel.GetSandboxPool(ctx, req.Namespace, req.Pool)
+	if err != nil {
+		e.log("Failed to get sandbox pool", err)
+		return nil, err
+	}
+
+	return &GetSandboxPoolResponse{Body: pool}, nil
+}
+
+type DeleteSandboxPoolResponse struct {
+}
+
+func (e *Endpoints) deleteSandboxPool(ctx context.Context, req *SandboxPoolRequest) (*DeleteSandboxPoolResponse, error) {
+	err := e.ravel.DeleteSandboxPool(ctx, req.Namespace, req.Pool)
+	if err != nil {
+		e.log("Failed to delete sandbox pool", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type GetSandboxPoolStatsResponse struct {
+	Body api.SandboxPoolStats `json:"stats"`
+}
+
+func (e *Endpoints) getSandboxPoolStats(ctx context.Context, req *SandboxPoolRequest) (*GetSandboxPoolStatsResponse, error) {
+	stats, err := e.ravel.GetSandboxPoolStats(ctx, req.Namespace, req.Pool)
+	if err != nil {
+		e.log("Failed to get sandbox pool stats", err)
+		return nil, err
+	}
+
+	return &GetSandboxPoolStatsResponse{Body: stats}, nil
+}
+
+type ListSandboxesResponse struct {
+	Body []ravel.Sandbox `json:"sandboxes"`
+}
+
+func (e *Endpoints) listSandboxes(ctx context.Context, req *SandboxPoolRequest) (*ListSandboxesResponse, error) {
+	sandboxes, err := e.ravel.ListSandboxes(ctx, req.Namespace, req.Pool)
+	if err != nil {
+		e.log("Failed to list sandboxes", err)
+		return nil, err
+	}
+
+	return &ListSandboxesResponse{Body: sandboxes}, nil
+}
+
+type ClaimSandboxRequest struct {
+	Namespace string `path:"namespace"`
+	Pool      string `path:"pool"`
+	Body      api.ClaimSandboxPayload
+}
+
+type ClaimSandboxResponse struct {
+	Body ravel.Sandbox `json:"sandbox"`
+}
+
+func (e *Endpoints) claimSandbox(ctx context.Context, req *ClaimSandboxRequest) (*ClaimSandboxResponse, error) {
+	sandbox, err := e.ravel.ClaimSandbox(ctx, req.Namespace, req.Pool, req.Body)
+	if err != nil {
+		e.log("Failed to claim sandbox", err)
+		return nil, err
+	}
+
+	return &ClaimSandboxResponse{Body: sandbox}, nil
+}
+
+type ReleaseSandboxRequest struct {
+	Namespace string `path:"namespace"`
+	Pool      string `path:"pool"`
+	SandboxId string `path:"sandbox_id"`
+}
+
+type ReleaseSandboxResponse struct {
+}
+
+func (e *Endpoints) releaseSandbox(ctx context.Context, req *ReleaseSandboxRequest) (*ReleaseSandboxResponse, error) {
+	err := e.ravel.ReleaseSandbox(ctx, req.Namespace, req.Pool, req.SandboxId)
+	if err != nil {
+		e.log("Failed to release sandbox", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
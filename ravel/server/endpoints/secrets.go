@@ -23,7 +23,7 @@ type CreateSecretResponse struct {
 
 func (e *Endpoints) createSecret(ctx context.Context, req *CreateSecretRequest) (*CreateSecretResponse, error) {
 	secretId := id.Generate()
-	err := e.ravel.State.Queries.CreateSecret(ctx, secretId, req.Body.Name, req.Namespace, req.Body.Value)
+	err := e.ravel.State.CreateSecret(ctx, secretId, req.Namespace, req.Body.Name, req.Body.Value)
 	if err != nil {
 		e.log("Failed to create secret", err)
 		return nil, err
@@ -94,7 +94,7 @@ type UpdateSecretResponse struct {
 }
 
 func (e *Endpoints) updateSecret(ctx context.Context, req *UpdateSecretRequest) (*UpdateSecretResponse, error) {
-	err := e.ravel.State.Queries.UpdateSecret(ctx, req.Namespace, req.SecretName, req.Body.Value)
+	err := e.ravel.UpdateSecret(ctx, req.Namespace, req.SecretName, req.Body.Value, req.Body.RestartMachines)
 	if err != nil {
 		e.log("Failed to update secret", err)
 		return nil, err
@@ -0,0 +1,67 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/ravel"
+)
+
+type CreateVolumeSnapshotResponse struct {
+	Body ravel.VolumeSnapshot `json:"snapshot"`
+}
+
+func (e *Endpoints) createVolumeSnapshot(ctx context.Context, req *VolumeRequest) (*CreateVolumeSnapshotResponse, error) {
+	snapshot, err := e.ravel.CreateVolumeSnapshot(ctx, req.Namespace, req.Volume)
+	if err != nil {
+		e.log("Failed to create volume snapshot", err)
+		return nil, err
+	}
+
+	return &CreateVolumeSnapshotResponse{Body: snapshot}, nil
+}
+
+type ListVolumeSnapshotsResponse struct {
+	Body []ravel.VolumeSnapshot `json:"snapshots"`
+}
+
+func (e *Endpoints) listVolumeSnapshots(ctx context.Context, req *VolumeRequest) (*ListVolumeSnapshotsResponse, error) {
+	snapshots, err := e.ravel.ListVolumeSnapshots(ctx, req.Namespace, req.Volume)
+	if err != nil {
+		e.log("Failed to list volume snapshots", err)
+		return nil, err
+	}
+
+	return &ListVolumeSnapshotsResponse{Body: snapshots}, nil
+}
+
+type VolumeSnapshotRequest struct {
+	Namespace string `path:"namespace"`
+	Volume    string `path:"volume"`
+	Snapshot  string `path:"snapshot"`
+}
+
+type DeleteVolumeSnapshotResponse struct {
+}
+
+func (e *Endpoints) deleteVolumeSnapshot(ctx context.Context, req *VolumeSnapshotRequest) (*DeleteVolumeSnapshotResponse, error) {
+	err := e.ravel.DeleteVolumeSnapshot(ctx, req.Namespace, req.Volume, req.Snapshot)
+	if err != nil {
+		e.log("Failed to delete volume snapshot", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type RestoreVolumeSnapshotResponse struct {
+}
+
+func (e *Endpoints) restoreVolumeSnapshot(ctx context.Context, req *VolumeSnapshotRequest) (*RestoreVolumeSnapshotResponse, error) {
+	err := e.ravel.RestoreVolumeSnapshot(ctx, req.Namespace, req.Volume, req.Snapshot)
+	if err != nil {
+		e.log("Failed to restore volume snapshot", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,97 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/ravel"
+)
+
+type CreateVolumeRequest struct {
+	Namespace string `path:"namespace"`
+	Body      api.CreateVolumePayload
+}
+
+type CreateVolumeResponse struct {
+	Body ravel.Volume `json:"volume"`
+}
+
+func (e *Endpoints) createVolume(ctx context.Context, req *CreateVolumeRequest) (*CreateVolumeResponse, error) {
+	volume, err := e.ravel.CreateVolume(ctx, req.Namespace, req.Body)
+	if err != nil {
+		e.log("Failed to create volume", err)
+		return nil, err
+	}
+
+	return &CreateVolumeResponse{Body: volume}, nil
+}
+
+type ListVolumesRequest struct {
+	Namespace string `path:"namespace"`
+}
+
+type ListVolumesResponse struct {
+	Body []ravel.Volume `json:"volumes"`
+}
+
+func (e *Endpoints) listVolumes(ctx context.Context, req *ListVolumesRequest) (*ListVolumesResponse, error) {
+	volumes, err := e.ravel.ListVolumes(ctx, req.Namespace)
+	if err != nil {
+		e.log("Failed to list volumes", err)
+		return nil, err
+	}
+
+	return &ListVolumesResponse{Body: volumes}, nil
+}
+
+type VolumeRequest struct {
+	Namespace string `path:"namespace"`
+	Volume    string `path:"volume"`
+}
+
+type GetVolumeResponse struct {
+	Body ravel.Volume `json:"volume"`
+}
+
+func (e *Endpoints) getVolume(ctx context.Context, req *VolumeRequest) (*GetVolumeResponse, error) {
+	volume, err := e.ravel.GetVolume(ctx, req.Namespace, req.Volume)
+	if err != nil {
+		e.log("Failed to get volume", err)
+		return nil, err
+	}
+
+	return &GetVolumeResponse{Body: volume}, nil
+}
+
+type ResizeVolumeRequest struct {
+	Namespace string `path:"namespace"`
+	Volume    string `path:"volume"`
+	Body      api.ResizeVolumePayload
+}
+
+type ResizeVolumeResponse struct {
+	Body ravel.Volume `json:"volume"`
+}
+
+func (e *Endpoints) resizeVolume(ctx context.Context, req *ResizeVolumeRequest) (*ResizeVolumeResponse, error) {
+	volume, err := e.ravel.ResizeVolume(ctx, req.Namespace, req.Volume, req.Body)
+	if err != nil {
+		e.log("Failed to resize volume", err)
+		return nil, err
+	}
+
+	return &ResizeVolumeResponse{Body: volume}, nil
+}
+
+type DeleteVolumeResponse struct {
+}
+
+func (e *Endpoints) deleteVolume(ctx context.Context, req *VolumeRequest) (*DeleteVolumeResponse, error) {
+	err := e.ravel.DeleteVolume(ctx, req.Namespace, req.Volume)
+	if err != nil {
+		e.log("Failed to delete volume", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
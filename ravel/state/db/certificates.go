@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/jackc/pgx/v5"
+)
+
+func (q *Queries) GetCertificateData(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := q.db.QueryRow(ctx, `SELECT data FROM certificates WHERE key = $1`, key).Scan(&data)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errdefs.NewNotFound("certificate not found")
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (q *Queries) PutCertificateData(ctx context.Context, key string, data []byte) error {
+	_, err := q.db.Exec(ctx, `
+INSERT INTO certificates (key, data, updated_at) VALUES ($1, $2, timezone('utc', now()))
+ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`, key, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *Queries) DeleteCertificateData(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM certificates WHERE key = $1`, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
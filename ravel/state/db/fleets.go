@@ -17,7 +17,7 @@ import (
 func scanFleet(row dbutil.Scannable) (*api.Fleet, error) {
 	var fleet api.Fleet
 	var metadataJSON []byte
-	err := row.Scan(&fleet.Id, &fleet.Namespace, &fleet.Name, &fleet.CreatedAt, &fleet.Status, &metadataJSON)
+	err := row.Scan(&fleet.Id, &fleet.Namespace, &fleet.Name, &fleet.CreatedAt, &fleet.Status, &metadataJSON, &fleet.PlacementStrategy)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, errdefs.NewNotFound("fleet not found")
@@ -53,7 +53,7 @@ func (q *Queries) CreateFleet(ctx context.Context, fleet api.Fleet) error {
 		metadataJSON = []byte("{}")
 	}
 
-	_, err = q.db.Exec(ctx, `INSERT INTO fleets (id, namespace, name, created_at, metadata) VALUES ($1, $2, $3, $4, $5)`, fleet.Id, fleet.Namespace, fleet.Name, fleet.CreatedAt, metadataJSON)
+	_, err = q.db.Exec(ctx, `INSERT INTO fleets (id, namespace, name, created_at, metadata, placement_strategy) VALUES ($1, $2, $3, $4, $5, $6)`, fleet.Id, fleet.Namespace, fleet.Name, fleet.CreatedAt, metadataJSON, fleet.PlacementStrategy)
 	if err != nil {
 		var pg *pgconn.PgError
 		if errors.As(err, &pg) {
@@ -71,7 +71,7 @@ func (q *Queries) CreateFleet(ctx context.Context, fleet api.Fleet) error {
 }
 
 func (q *Queries) ListFleets(ctx context.Context, namespace string, labelFilters map[string]string) ([]api.Fleet, error) {
-	query := `SELECT id, namespace, name, created_at, status, metadata FROM fleets WHERE namespace = $1 AND status = 'active'`
+	query := `SELECT id, namespace, name, created_at, status, metadata, placement_strategy FROM fleets WHERE namespace = $1 AND status = 'active'`
 	args := []interface{}{namespace}
 
 	// Add label filtering if provided
@@ -131,7 +131,7 @@ func (q *Queries) DestroyFleet(ctx context.Context, id string) error {
 }
 
 func (q *Queries) getFleet(ctx context.Context, where string, args ...any) (*api.Fleet, error) {
-	row := q.db.QueryRow(ctx, fmt.Sprintf(`SELECT id, namespace, name, created_at, status, metadata FROM fleets WHERE %s`, where), args...)
+	row := q.db.QueryRow(ctx, fmt.Sprintf(`SELECT id, namespace, name, created_at, status, metadata, placement_strategy FROM fleets WHERE %s`, where), args...)
 	return scanFleet(row)
 }
 
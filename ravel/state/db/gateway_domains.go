@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/ravel/state/db/schema"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const baseSelectGatewayDomain = `SELECT hostname, gateway_id, namespace, status, token, created_at, verified_at FROM gateway_domains`
+
+func scanGatewayDomain(row pgx.Row) (domain api.GatewayDomain, err error) {
+	err = row.Scan(
+		&domain.Hostname,
+		&domain.GatewayId,
+		&domain.Namespace,
+		&domain.Status,
+		&domain.Verification.TXTValue,
+		&domain.CreatedAt,
+		&domain.VerifiedAt,
+	)
+	return
+}
+
+func (q Queries) CreateGatewayDomain(ctx context.Context, domain api.GatewayDomain) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO gateway_domains (hostname, gateway_id, namespace, status, token, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		domain.Hostname,
+		domain.GatewayId,
+		domain.Namespace,
+		domain.Status,
+		domain.Verification.TXTValue,
+		domain.CreatedAt,
+	)
+	if err != nil {
+		var pg *pgconn.PgError
+		if errors.As(err, &pg) && pg.ConstraintName == schema.GatewayDomainsPkeyConstraint {
+			return errdefs.NewAlreadyExists("domain is already attached to this gateway")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (q Queries) GetGatewayDomain(ctx context.Context, gatewayId, hostname string) (api.GatewayDomain, error) {
+	domain, err := scanGatewayDomain(q.db.QueryRow(ctx, baseSelectGatewayDomain+" WHERE gateway_id = $1 AND hostname = $2", gatewayId, hostname))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return domain, errdefs.NewNotFound("domain not found")
+		}
+		return domain, err
+	}
+	return domain, nil
+}
+
+func (q Queries) listGatewayDomains(ctx context.Context, where string, args ...any) ([]api.GatewayDomain, error) {
+	rows, err := q.db.Query(ctx, baseSelectGatewayDomain+" "+where+" ORDER BY hostname", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	domains := []api.GatewayDomain{}
+	for rows.Next() {
+		domain, err := scanGatewayDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, rows.Err()
+}
+
+func (q Queries) ListGatewayDomains(ctx context.Context, gatewayId string) ([]api.GatewayDomain, error) {
+	return q.listGatewayDomains(ctx, "WHERE gateway_id = $1", gatewayId)
+}
+
+func (q Queries) ListFleetGatewayDomains(ctx context.Context, namespace, fleetId string) ([]api.GatewayDomain, error) {
+	return q.listGatewayDomains(ctx, "WHERE gateway_id IN (SELECT id FROM gateways WHERE namespace = $1 AND fleet_id = $2)", namespace, fleetId)
+}
+
+// ListVerifiedGatewayDomains returns the domains that can be served by the proxy.
+func (q Queries) ListVerifiedGatewayDomains(ctx context.Context) ([]api.GatewayDomain, error) {
+	return q.listGatewayDomains(ctx, "WHERE status = $1", api.GatewayDomainStatusVerified)
+}
+
+// MarkGatewayDomainVerified verifies the claim of a gateway on a domain, it fails if another
+// gateway already verified the domain.
+func (q Queries) MarkGatewayDomainVerified(ctx context.Context, gatewayId, hostname string, verifiedAt time.Time) error {
+	_, err := q.db.Exec(ctx, `UPDATE gateway_domains SET status = $1, verified_at = $2 WHERE gateway_id = $3 AND hostname = $4`, api.GatewayDomainStatusVerified, verifiedAt, gatewayId, hostname)
+	if err != nil {
+		var pg *pgconn.PgError
+		if errors.As(err, &pg) && pg.ConstraintName == schema.GatewayDomainsVerifiedHostnameIndex {
+			return errdefs.NewAlreadyExists("domain is already verified by another gateway")
+		}
+		return err
+	}
+	return nil
+}
+
+func (q Queries) DeleteGatewayDomain(ctx context.Context, gatewayId, hostname string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM gateway_domains WHERE gateway_id = $1 AND hostname = $2`, gatewayId, hostname)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -12,7 +12,7 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-const baseSelectGateway = `SELECT id, name, namespace, fleet_id, protocol, target_port FROM gateways`
+const baseSelectGateway = `SELECT id, name, namespace, fleet_id, protocol, target_port, COALESCE(public_port, 0), proxy_protocol FROM gateways`
 
 func (q Queries) DeleteFleetGateways(ctx context.Context, fleetId string) error {
 	_, err := q.db.Exec(ctx, `DELETE FROM gateways WHERE fleet_id = $1`, fleetId)
@@ -23,13 +23,15 @@ func (q Queries) DeleteFleetGateways(ctx context.Context, fleetId string) error
 }
 
 func (q Queries) GetGatewayByName(ctx context.Context, namespace, fleet, name string) (gateway api.Gateway, err error) {
-	err = q.db.QueryRow(ctx, baseSelectGateway+" WHERE namespace = $1 AND fleet = $2 name = $3", namespace, fleet, name).Scan(
+	err = q.db.QueryRow(ctx, baseSelectGateway+" WHERE namespace = $1 AND fleet_id = $2 AND name = $3", namespace, fleet, name).Scan(
 		&gateway.Id,
 		&gateway.Name,
 		&gateway.Namespace,
 		&gateway.FleetId,
 		&gateway.Protocol,
 		&gateway.TargetPort,
+		&gateway.PublicPort,
+		&gateway.ProxyProtocol,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -48,6 +50,8 @@ func (q Queries) GetGatewayById(ctx context.Context, namespace, fleetId, id stri
 		&gateway.FleetId,
 		&gateway.Protocol,
 		&gateway.TargetPort,
+		&gateway.PublicPort,
+		&gateway.ProxyProtocol,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -75,6 +79,8 @@ func (q Queries) listGateways(ctx context.Context, where string, args ...any) ([
 			&gateway.FleetId,
 			&gateway.Protocol,
 			&gateway.TargetPort,
+			&gateway.PublicPort,
+			&gateway.ProxyProtocol,
 		)
 		if err != nil {
 			return nil, err
@@ -93,14 +99,20 @@ func (q Queries) ListGateways(ctx context.Context, namespace string) ([]api.Gate
 	return q.listGateways(ctx, "WHERE namespace = $1", namespace)
 }
 
+func (q Queries) ListAllGateways(ctx context.Context) ([]api.Gateway, error) {
+	return q.listGateways(ctx, "")
+}
+
 func (q Queries) CreateGateway(ctx context.Context, gateway api.Gateway) error {
-	_, err := q.db.Exec(ctx, `INSERT INTO gateways (id, name, namespace, fleet_id, protocol, target_port) VALUES ($1, $2, $3, $4, $5, $6)`,
+	_, err := q.db.Exec(ctx, `INSERT INTO gateways (id, name, namespace, fleet_id, protocol, target_port, public_port, proxy_protocol) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), $8)`,
 		gateway.Id,
 		gateway.Name,
 		gateway.Namespace,
 		gateway.FleetId,
 		gateway.Protocol,
 		gateway.TargetPort,
+		gateway.PublicPort,
+		gateway.ProxyProtocol,
 	)
 	if err != nil {
 		var pg *pgconn.PgError
@@ -108,12 +120,39 @@ func (q Queries) CreateGateway(ctx context.Context, gateway api.Gateway) error {
 			if pg.ConstraintName == schema.UniqueGatewayNameConstraint {
 				return errdefs.NewAlreadyExists("gateway name already exists")
 			}
+			if pg.ConstraintName == schema.UniqueGatewayPublicPortConstraint {
+				return errdefs.NewAlreadyExists("gateway public port already allocated")
+			}
 		}
+		return err
 	}
 
 	return nil
 }
 
+// AllocateGatewayPublicPort returns the lowest port of the range which is not used by another gateway of the same protocol.
+// It must be called in the transaction creating the gateway, concurrent allocations are serialized until the transaction ends.
+func (q Queries) AllocateGatewayPublicPort(ctx context.Context, protocol string, min, max int) (int, error) {
+	_, err := q.db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('gateway_public_ports'))`)
+	if err != nil {
+		return 0, err
+	}
+
+	var port int
+	err = q.db.QueryRow(ctx, `
+SELECT p FROM generate_series($1::integer, $2::integer) AS p
+WHERE p NOT IN (SELECT public_port FROM gateways WHERE protocol = $3 AND public_port IS NOT NULL)
+ORDER BY p LIMIT 1`, min, max, protocol).Scan(&port)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errdefs.NewResourcesExhausted("no public port available for " + protocol + " gateways")
+		}
+		return 0, err
+	}
+
+	return port, nil
+}
+
 func (q Queries) DeleteGateway(ctx context.Context, id string) error {
 	_, err := q.db.Exec(ctx, `DELETE FROM gateways WHERE  id = $1`, id)
 	if err != nil {
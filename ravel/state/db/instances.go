@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const upsertInstanceQuery = `
+INSERT INTO instances (id, machine_id, namespace, node, machine_version, status, health, local_ipv4, enable_machine_gateway, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (id) DO UPDATE SET
+	node = EXCLUDED.node,
+	machine_version = EXCLUDED.machine_version,
+	status = EXCLUDED.status,
+	health = EXCLUDED.health,
+	local_ipv4 = EXCLUDED.local_ipv4,
+	enable_machine_gateway = EXCLUDED.enable_machine_gateway,
+	updated_at = EXCLUDED.updated_at
+WHERE instances.updated_at <= EXCLUDED.updated_at`
+
+func (q *Queries) UpsertInstance(ctx context.Context, i cluster.MachineInstance) error {
+	_, err := q.db.Exec(
+		ctx,
+		upsertInstanceQuery,
+		i.Id,
+		i.MachineId,
+		i.Namespace,
+		i.Node,
+		i.MachineVersion,
+		i.Status,
+		i.Health,
+		i.LocalIPV4,
+		i.EnableMachineGateway,
+		i.CreatedAt,
+		i.UpdatedAt,
+	)
+	if err != nil {
+		var pgerr *pgconn.PgError
+		if errors.As(err, &pgerr) {
+			if pgerr.ConstraintName == "instances_machine_id_fkey" {
+				return errdefs.NewNotFound("machine not found")
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ListFleetsInstances returns the current instance of every machine that is not destroyed, grouped by fleet id.
+func (q *Queries) ListFleetsInstances(ctx context.Context) (map[string][]cluster.MachineInstance, error) {
+	rows, err := q.db.Query(ctx, `
+SELECT m.fleet_id, i.id, i.machine_id, i.namespace, i.node, i.machine_version, i.status, i.health, i.local_ipv4, i.enable_machine_gateway, i.created_at, i.updated_at
+FROM instances i
+JOIN machines m ON m.instance_id = i.id
+WHERE m.destroyed_at IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instances := map[string][]cluster.MachineInstance{}
+	for rows.Next() {
+		var fleetId string
+		var i cluster.MachineInstance
+		err := rows.Scan(
+			&fleetId,
+			&i.Id,
+			&i.MachineId,
+			&i.Namespace,
+			&i.Node,
+			&i.MachineVersion,
+			&i.Status,
+			&i.Health,
+			&i.LocalIPV4,
+			&i.EnableMachineGateway,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		instances[fleetId] = append(instances[fleetId], i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return instances, nil
+}
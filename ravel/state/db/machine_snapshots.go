@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/jackc/pgx/v5"
+)
+
+const baseSelectMachineSnapshot = `SELECT id, machine_id, namespace, node, size_bytes, machine_version, created_at FROM machine_snapshots`
+
+func scanMachineSnapshot(row pgx.Row) (snapshot api.MachineSnapshot, err error) {
+	err = row.Scan(
+		&snapshot.Id,
+		&snapshot.MachineId,
+		&snapshot.Namespace,
+		&snapshot.Node,
+		&snapshot.SizeBytes,
+		&snapshot.MachineVersion,
+		&snapshot.CreatedAt,
+	)
+	return
+}
+
+func (q Queries) CreateMachineSnapshot(ctx context.Context, snapshot api.MachineSnapshot) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO machine_snapshots (id, machine_id, namespace, node, size_bytes, machine_version, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		snapshot.Id,
+		snapshot.MachineId,
+		snapshot.Namespace,
+		snapshot.Node,
+		snapshot.SizeBytes,
+		snapshot.MachineVersion,
+		snapshot.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Queries) GetMachineSnapshot(ctx context.Context, namespace, id string) (api.MachineSnapshot, error) {
+	snapshot, err := scanMachineSnapshot(q.db.QueryRow(ctx, baseSelectMachineSnapshot+" WHERE namespace = $1 AND id = $2", namespace, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return snapshot, errdefs.NewNotFound("machine snapshot not found")
+		}
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+func (q Queries) ListMachineSnapshots(ctx context.Context, machineId string) ([]api.MachineSnapshot, error) {
+	rows, err := q.db.Query(ctx, baseSelectMachineSnapshot+" WHERE machine_id = $1 ORDER BY created_at", machineId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []api.MachineSnapshot{}
+	for rows.Next() {
+		snapshot, err := scanMachineSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func (q Queries) DeleteMachineSnapshot(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM machine_snapshots WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Queries) UpdateMachineSnapshotNode(ctx context.Context, id string, node string) error {
+	_, err := q.db.Exec(ctx, `UPDATE machine_snapshots SET node = $2 WHERE id = $1`, id, node)
+	if err != nil {
+		return err
+	}
+	return nil
+}
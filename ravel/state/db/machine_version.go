@@ -7,6 +7,7 @@ import (
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/dbutil"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
@@ -45,32 +46,48 @@ func (q *Queries) CreateMachineVersion(ctx context.Context, mv api.MachineVersio
 	return nil
 }
 
+const baseSelectMachineVersion = `SELECT id, machine_id, config, resources, namespace FROM machine_versions`
+
+func scanMachineVersion(s dbutil.Scannable) (api.MachineVersion, error) {
+	var mv api.MachineVersion
+	var configBytes []byte
+	var resourcesBytes []byte
+	err := s.Scan(&mv.Id, &mv.MachineId, &configBytes, &resourcesBytes, &mv.Namespace)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return mv, errdefs.NewNotFound("machine version not found")
+		}
+		return mv, err
+	}
+
+	err = json.Unmarshal(configBytes, &mv.Config)
+	if err != nil {
+		return mv, err
+	}
+
+	err = json.Unmarshal(resourcesBytes, &mv.Resources)
+	if err != nil {
+		return mv, err
+	}
+
+	return mv, nil
+}
+
+func (q *Queries) GetMachineVersion(ctx context.Context, machineId, id string) (api.MachineVersion, error) {
+	return scanMachineVersion(q.db.QueryRow(ctx, baseSelectMachineVersion+` WHERE machine_id = $1 AND id = $2`, machineId, id))
+}
+
 func (q *Queries) ListMachineVersions(ctx context.Context, machineId string) ([]api.MachineVersion, error) {
-	rows, err := q.db.Query(ctx, `SELECT id, machine_id, config, resources, namespace FROM machine_versions WHERE machine_id = $1 ORDER BY id DESC`, machineId)
+	rows, err := q.db.Query(ctx, baseSelectMachineVersion+` WHERE machine_id = $1 ORDER BY id DESC`, machineId)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	mvs := []api.MachineVersion{}
 
 	for rows.Next() {
-		var mv api.MachineVersion
-		var configBytes []byte
-		var resourcesBytes []byte
-		err := rows.Scan(&mv.Id, &mv.MachineId, &configBytes, &resourcesBytes, &mv.Namespace)
-		if err != nil {
-			if err == pgx.ErrNoRows {
-				return mvs, nil
-			}
-			return nil, err
-		}
-
-		err = json.Unmarshal(configBytes, &mv.Config)
-		if err != nil {
-			return nil, err
-		}
-
-		err = json.Unmarshal(resourcesBytes, &mv.Resources)
+		mv, err := scanMachineVersion(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -80,3 +97,12 @@ func (q *Queries) ListMachineVersions(ctx context.Context, machineId string) ([]
 
 	return mvs, nil
 }
+
+// DeleteMachineVersion deletes a version which the machine does not point to.
+func (q *Queries) DeleteMachineVersion(ctx context.Context, machineId, id string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM machine_versions WHERE machine_id = $1 AND id = $2 AND NOT EXISTS (SELECT 1 FROM machines WHERE id = $1 AND machine_version = $2)`, machineId, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
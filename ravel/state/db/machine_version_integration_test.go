@@ -0,0 +1,43 @@
+//go:build integration
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/id"
+)
+
+func TestDeleteMachineVersion(t *testing.T) {
+	db := newTestDB(t)
+	machine := createTestMachine(t, db)
+	ctx := context.Background()
+
+	mv := api.MachineVersion{Id: id.Generate(), MachineId: machine.Id, Namespace: machine.Namespace}
+	if err := db.CreateMachineVersion(ctx, mv); err != nil {
+		t.Fatalf("CreateMachineVersion() error = %v", err)
+	}
+
+	if err := db.DeleteMachineVersion(ctx, machine.Id, mv.Id); err != nil {
+		t.Fatalf("DeleteMachineVersion() error = %v", err)
+	}
+	if _, err := db.GetMachineVersion(ctx, machine.Id, mv.Id); !errdefs.IsNotFound(err) {
+		t.Errorf("GetMachineVersion() of a deleted version error = %v, want not found", err)
+	}
+
+	// the version the machine points to is kept
+	if err := db.DeleteMachineVersion(ctx, machine.Id, machine.MachineVersion); err != nil {
+		t.Fatalf("DeleteMachineVersion() error = %v", err)
+	}
+	if _, err := db.GetMachineVersion(ctx, machine.Id, machine.MachineVersion); err != nil {
+		t.Errorf("GetMachineVersion() of the current version error = %v, want it kept", err)
+	}
+
+	versions, err := db.ListMachineVersions(ctx, machine.Id)
+	if err != nil || len(versions) != 1 || versions[0].Id != machine.MachineVersion {
+		t.Errorf("ListMachineVersions() = %+v, %v, want the current version only", versions, err)
+	}
+}
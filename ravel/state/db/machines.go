@@ -15,7 +15,7 @@ import (
 
 func scanMachine(s dbutil.Scannable) (m cluster.Machine, err error) {
 	var metadataJSON []byte
-	err = s.Scan(&m.Id, &m.Namespace, &m.FleetId, &m.Node, &m.InstanceId, &m.MachineVersion, &m.Region, &m.CreatedAt, &m.UpdatedAt, &m.DestroyedAt, &metadataJSON)
+	err = s.Scan(&m.Id, &m.Namespace, &m.FleetId, &m.Node, &m.InstanceId, &m.MachineVersion, &m.Region, &m.CreatedAt, &m.UpdatedAt, &m.DestroyedAt, &metadataJSON, &m.Placement)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			err = errdefs.NewNotFound("machine not found")
@@ -55,8 +55,8 @@ func (q *Queries) CreateMachine(ctx context.Context, machine cluster.Machine, mv
 
 	queuedQueries := []*pgx.QueuedQuery{
 		{
-			SQL:       `INSERT INTO machines (id, namespace, fleet_id, node, instance_id, machine_version, region, created_at, updated_at, metadata) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
-			Arguments: []interface{}{machine.Id, machine.Namespace, machine.FleetId, machine.Node, machine.InstanceId, machine.MachineVersion, machine.Region, machine.CreatedAt, machine.UpdatedAt, metadataJSON},
+			SQL:       `INSERT INTO machines (id, namespace, fleet_id, node, instance_id, machine_version, region, created_at, updated_at, metadata, placement) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			Arguments: []interface{}{machine.Id, machine.Namespace, machine.FleetId, machine.Node, machine.InstanceId, machine.MachineVersion, machine.Region, machine.CreatedAt, machine.UpdatedAt, metadataJSON, machine.Placement},
 		},
 		{
 			SQL:       mvQueries,
@@ -109,7 +109,7 @@ func (q *Queries) CountExistingMachinesInFleet(ctx context.Context, fleetId stri
 	return count, nil
 }
 
-const baseSelectMachine = `SELECT id, namespace, fleet_id, node, instance_id, machine_version, region, created_at, updated_at, destroyed_at, metadata FROM machines`
+const baseSelectMachine = `SELECT id, namespace, fleet_id, node, instance_id, machine_version, region, created_at, updated_at, destroyed_at, metadata, placement FROM machines`
 
 func (q *Queries) ListMachines(ctx context.Context, fleetId string) ([]cluster.Machine, error) {
 	rows, err := q.db.Query(ctx, fmt.Sprintf("%s WHERE fleet_id = $1", baseSelectMachine), fleetId)
@@ -130,6 +130,55 @@ func (q *Queries) ListMachines(ctx context.Context, fleetId string) ([]cluster.M
 	return machines, nil
 }
 
+// ListNodeMachines lists the machines of all the namespaces placed on a node, except the destroyed ones.
+func (q *Queries) ListNodeMachines(ctx context.Context, node string) ([]cluster.Machine, error) {
+	rows, err := q.db.Query(ctx, fmt.Sprintf("%s WHERE node = $1 AND destroyed_at IS NULL ORDER BY created_at", baseSelectMachine), node)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	machines := []cluster.Machine{}
+	for rows.Next() {
+		machine, err := scanMachine(rows)
+		if err != nil {
+			return nil, err
+		}
+		machines = append(machines, machine)
+	}
+
+	return machines, nil
+}
+
+// ListSecretMachines lists the machines of a namespace, except the destroyed ones, whose current
+// version references a secret.
+func (q *Queries) ListSecretMachines(ctx context.Context, namespace, secret string) ([]cluster.Machine, error) {
+	ref, err := json.Marshal([]api.SecretRef{{Name: secret}})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.Query(ctx, `SELECT m.id, m.namespace, m.fleet_id, m.node, m.instance_id, m.machine_version, m.region, m.created_at, m.updated_at, m.destroyed_at, m.metadata, m.placement
+		FROM machines m JOIN machine_versions mv ON mv.machine_id = m.id AND mv.id = m.machine_version
+		WHERE m.namespace = $1 AND m.destroyed_at IS NULL AND mv.config->'workload'->'secrets' @> $2::jsonb
+		ORDER BY m.created_at`, namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	machines := []cluster.Machine{}
+	for rows.Next() {
+		machine, err := scanMachine(rows)
+		if err != nil {
+			return nil, err
+		}
+		machines = append(machines, machine)
+	}
+
+	return machines, nil
+}
+
 func (q *Queries) GetMachine(ctx context.Context, namespace, fleetId, id string, showDestroyed bool) (cluster.Machine, error) {
 	where := fmt.Sprintf("%s WHERE namespace = $1 AND fleet_id = $2 AND id = $3", baseSelectMachine)
 	if !showDestroyed {
@@ -145,6 +194,10 @@ func (q *Queries) GetMachine(ctx context.Context, namespace, fleetId, id string,
 	return machine, nil
 }
 
+func (q *Queries) GetMachineById(ctx context.Context, id string) (cluster.Machine, error) {
+	return scanMachine(q.db.QueryRow(ctx, fmt.Sprintf("%s WHERE id = $1", baseSelectMachine), id))
+}
+
 func (q *Queries) DestroyMachine(ctx context.Context, id string) error {
 	_, err := q.db.Exec(ctx, `UPDATE machines SET destroyed_at = $1, updated_at = $1 WHERE  id = $2`, time.Now(), id)
 	if err != nil {
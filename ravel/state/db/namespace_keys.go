@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/ravel/secrets"
+	"github.com/jackc/pgx/v5"
+)
+
+func (q *Queries) GetNamespaceKey(ctx context.Context, namespace string) (secrets.WrappedKey, error) {
+	var key secrets.WrappedKey
+	err := q.db.QueryRow(ctx, `SELECT master_key_id, wrapped_key FROM namespace_keys WHERE namespace = $1`, namespace).Scan(&key.MasterKeyId, &key.Key)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return key, errdefs.NewNotFound("namespace key not found")
+		}
+		return key, err
+	}
+
+	return key, nil
+}
+
+// CreateNamespaceKey stores the data key of the namespace, unless a concurrent transaction stored one first.
+func (q *Queries) CreateNamespaceKey(ctx context.Context, namespace string, key secrets.WrappedKey) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO namespace_keys (namespace, master_key_id, wrapped_key) VALUES ($1, $2, $3) ON CONFLICT (namespace) DO NOTHING`, namespace, key.MasterKeyId, key.Key)
+	return err
+}
+
+// PutNamespaceKey replaces the data key of the namespace.
+func (q *Queries) PutNamespaceKey(ctx context.Context, namespace string, key secrets.WrappedKey) error {
+	_, err := q.db.Exec(ctx, `
+INSERT INTO namespace_keys (namespace, master_key_id, wrapped_key) VALUES ($1, $2, $3)
+ON CONFLICT (namespace) DO UPDATE SET master_key_id = $2, wrapped_key = $3, created_at = timezone('utc', now())`, namespace, key.MasterKeyId, key.Key)
+	return err
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/ravel/state/db/schema"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const baseSelectNetwork = `SELECT id, name, namespace, cidr, created_at FROM networks`
+
+func scanNetwork(row pgx.Row) (network api.Network, err error) {
+	err = row.Scan(
+		&network.Id,
+		&network.Name,
+		&network.Namespace,
+		&network.CIDR,
+		&network.CreatedAt,
+	)
+	return
+}
+
+func (q Queries) CreateNetwork(ctx context.Context, network api.Network) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO networks (id, name, namespace, cidr, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		network.Id,
+		network.Name,
+		network.Namespace,
+		network.CIDR,
+		network.CreatedAt,
+	)
+	if err != nil {
+		var pg *pgconn.PgError
+		if errors.As(err, &pg) && pg.ConstraintName == schema.UniqueNetworkNameConstraint {
+			return errdefs.NewAlreadyExists("network already exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (q Queries) getNetwork(ctx context.Context, query string, args ...any) (api.Network, error) {
+	network, err := scanNetwork(q.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return network, errdefs.NewNotFound("network not found")
+		}
+		return network, err
+	}
+	return network, nil
+}
+
+func (q Queries) GetNetwork(ctx context.Context, namespace, name string) (api.Network, error) {
+	return q.getNetwork(ctx, baseSelectNetwork+" WHERE namespace = $1 AND name = $2", namespace, name)
+}
+
+// GetNetworkForUpdate locks the network until the end of the transaction to serialize the IP allocations.
+func (q Queries) GetNetworkForUpdate(ctx context.Context, namespace, name string) (api.Network, error) {
+	return q.getNetwork(ctx, baseSelectNetwork+" WHERE namespace = $1 AND name = $2 FOR UPDATE", namespace, name)
+}
+
+func (q Queries) ListNetworks(ctx context.Context, namespace string) ([]api.Network, error) {
+	rows, err := q.db.Query(ctx, baseSelectNetwork+" WHERE namespace = $1 ORDER BY name", namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	networks := []api.Network{}
+	for rows.Next() {
+		network, err := scanNetwork(rows)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, rows.Err()
+}
+
+func (q Queries) DeleteNetwork(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM networks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Queries) ListNetworkMembers(ctx context.Context, networkId string) ([]api.NetworkMember, error) {
+	rows, err := q.db.Query(ctx, `SELECT machine_id, ip, node, public_key FROM network_members WHERE network_id = $1 ORDER BY ip`, networkId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []api.NetworkMember{}
+	for rows.Next() {
+		var m api.NetworkMember
+		if err := rows.Scan(&m.MachineId, &m.IP, &m.Node, &m.PublicKey); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+func (q Queries) CreateNetworkMember(ctx context.Context, networkId, machineId, ip string) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO network_members (network_id, machine_id, ip) VALUES ($1, $2, $3)`, networkId, machineId, ip)
+	if err != nil {
+		var pg *pgconn.PgError
+		if errors.As(err, &pg) && pg.ConstraintName == schema.UniqueNetworkMemberIPConstraint {
+			return errdefs.NewAlreadyExists("ip " + ip + " is already used in the network")
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateNetworkMemberPeer records the WireGuard peer of a machine and returns the id of the network.
+func (q Queries) UpdateNetworkMemberPeer(ctx context.Context, namespace, network string, peer cluster.NetworkPeer) (string, error) {
+	var networkId string
+	err := q.db.QueryRow(ctx, `
+UPDATE network_members SET node = $1, public_key = $2, endpoint = $3
+WHERE machine_id = $4 AND network_id = (SELECT id FROM networks WHERE namespace = $5 AND name = $6)
+RETURNING network_id`, peer.Node, peer.PublicKey, peer.Endpoint, peer.MachineId, namespace, network).Scan(&networkId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errdefs.NewNotFound("network member not found")
+		}
+		return "", err
+	}
+
+	return networkId, nil
+}
+
+// ListNetworkPeers returns the members of a network whose WireGuard interface is configured.
+func (q Queries) ListNetworkPeers(ctx context.Context, networkId string) ([]cluster.NetworkPeer, error) {
+	rows, err := q.db.Query(ctx, `
+SELECT machine_id, node, public_key, endpoint, ip FROM network_members
+WHERE network_id = $1 AND public_key != ''
+ORDER BY ip`, networkId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	peers := []cluster.NetworkPeer{}
+	for rows.Next() {
+		var p cluster.NetworkPeer
+		if err := rows.Scan(&p.MachineId, &p.Node, &p.PublicKey, &p.Endpoint, &p.IP); err != nil {
+			return nil, err
+		}
+		peers = append(peers, p)
+	}
+
+	return peers, rows.Err()
+}
+
+// DeleteMachineNetworkMembers removes a machine from all its networks and returns the networks it was member of.
+func (q Queries) DeleteMachineNetworkMembers(ctx context.Context, machineId string) ([]api.Network, error) {
+	rows, err := q.db.Query(ctx, `
+WITH deleted AS (DELETE FROM network_members WHERE machine_id = $1 RETURNING network_id)
+SELECT n.id, n.name, n.namespace, n.cidr, n.created_at FROM networks n JOIN deleted d ON d.network_id = n.id`, machineId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	networks := []api.Network{}
+	for rows.Next() {
+		network, err := scanNetwork(rows)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, rows.Err()
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/jackc/pgx/v5"
+)
+
+const baseSelectNodeDrain = `SELECT node, status, remaining, machines, started_at, completed_at FROM node_drains`
+
+func scanNodeDrain(row pgx.Row) (drain api.NodeDrain, err error) {
+	var machinesBytes []byte
+	err = row.Scan(
+		&drain.Node,
+		&drain.Status,
+		&drain.Remaining,
+		&machinesBytes,
+		&drain.StartedAt,
+		&drain.CompletedAt,
+	)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(machinesBytes, &drain.Machines)
+	return
+}
+
+// PutNodeDrain stores the drain of a node, it replaces the previous drain of the node.
+func (q Queries) PutNodeDrain(ctx context.Context, drain api.NodeDrain) error {
+	machinesBytes, err := json.Marshal(drain.Machines)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.db.Exec(ctx, `INSERT INTO node_drains (node, status, remaining, machines, started_at, completed_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (node) DO UPDATE SET status = $2, remaining = $3, machines = $4, started_at = $5, completed_at = $6`,
+		drain.Node,
+		drain.Status,
+		drain.Remaining,
+		machinesBytes,
+		drain.StartedAt,
+		drain.CompletedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListNodeDrains returns the last drain of each drained node.
+func (q Queries) ListNodeDrains(ctx context.Context) ([]api.NodeDrain, error) {
+	rows, err := q.db.Query(ctx, baseSelectNodeDrain+" ORDER BY started_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drains := []api.NodeDrain{}
+	for rows.Next() {
+		drain, err := scanNodeDrain(rows)
+		if err != nil {
+			return nil, err
+		}
+		drains = append(drains, drain)
+	}
+
+	return drains, rows.Err()
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/ravel/state/db/schema"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const baseSelectSandboxPool = `SELECT id, name, namespace, config, created_at FROM sandbox_pools`
+
+func scanSandboxPool(row pgx.Row) (pool api.SandboxPool, err error) {
+	var configBytes []byte
+	err = row.Scan(
+		&pool.Id,
+		&pool.Name,
+		&pool.Namespace,
+		&configBytes,
+		&pool.CreatedAt,
+	)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(configBytes, &pool.Config)
+	return
+}
+
+func (q Queries) CreateSandboxPool(ctx context.Context, pool api.SandboxPool) error {
+	configBytes, err := json.Marshal(pool.Config)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.db.Exec(ctx, `INSERT INTO sandbox_pools (id, name, namespace, config, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		pool.Id,
+		pool.Name,
+		pool.Namespace,
+		configBytes,
+		pool.CreatedAt,
+	)
+	if err != nil {
+		var pg *pgconn.PgError
+		if errors.As(err, &pg) && pg.ConstraintName == schema.UniqueSandboxPoolNameConstraint {
+			return errdefs.NewAlreadyExists("sandbox pool already exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (q Queries) GetSandboxPool(ctx context.Context, namespace, name string) (api.SandboxPool, error) {
+	pool, err := scanSandboxPool(q.db.QueryRow(ctx, baseSelectSandboxPool+" WHERE namespace = $1 AND name = $2", namespace, name))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return pool, errdefs.NewNotFound("sandbox pool not found")
+		}
+		return pool, err
+	}
+	return pool, nil
+}
+
+func (q Queries) ListSandboxPools(ctx context.Context, namespace string) ([]api.SandboxPool, error) {
+	return q.listSandboxPools(ctx, " WHERE namespace = $1 ORDER BY name", namespace)
+}
+
+// ListAllSandboxPools returns the sandbox pools of all the namespaces.
+func (q Queries) ListAllSandboxPools(ctx context.Context) ([]api.SandboxPool, error) {
+	return q.listSandboxPools(ctx, " ORDER BY created_at")
+}
+
+func (q Queries) listSandboxPools(ctx context.Context, where string, args ...any) ([]api.SandboxPool, error) {
+	rows, err := q.db.Query(ctx, baseSelectSandboxPool+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pools := []api.SandboxPool{}
+	for rows.Next() {
+		pool, err := scanSandboxPool(rows)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+
+	return pools, rows.Err()
+}
+
+func (q Queries) DeleteSandboxPool(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM sandbox_pools WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PutSandbox creates or updates a sandbox.
+func (q Queries) PutSandbox(ctx context.Context, sandbox api.Sandbox) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO sandboxes (id, pool_id, machine_id, node, state, snapshot_id, claimed_at, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET machine_id = $3, node = $4, state = $5, snapshot_id = $6, claimed_at = $7, last_used_at = $9`,
+		sandbox.Id,
+		sandbox.PoolId,
+		sandbox.MachineId,
+		sandbox.Node,
+		sandbox.State,
+		sandbox.SnapshotId,
+		sandbox.ClaimedAt,
+		sandbox.CreatedAt,
+		sandbox.LastUsedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Queries) ListSandboxes(ctx context.Context, poolId string) ([]api.Sandbox, error) {
+	rows, err := q.db.Query(ctx, `SELECT id, pool_id, machine_id, node, state, snapshot_id, claimed_at, created_at, last_used_at FROM sandboxes WHERE pool_id = $1 ORDER BY created_at`, poolId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sandboxes := []api.Sandbox{}
+	for rows.Next() {
+		var sandbox api.Sandbox
+		err := rows.Scan(
+			&sandbox.Id,
+			&sandbox.PoolId,
+			&sandbox.MachineId,
+			&sandbox.Node,
+			&sandbox.State,
+			&sandbox.SnapshotId,
+			&sandbox.ClaimedAt,
+			&sandbox.CreatedAt,
+			&sandbox.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sandboxes = append(sandboxes, sandbox)
+	}
+
+	return sandboxes, rows.Err()
+}
+
+func (q Queries) DeleteSandbox(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM sandboxes WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
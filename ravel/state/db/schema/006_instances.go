@@ -0,0 +1,23 @@
+package schema
+
+const instancesUp = `
+CREATE TABLE instances (
+    "id" text primary key,
+    "machine_id" text not null references machines(id) on delete cascade,
+    "namespace" text not null references namespaces(name) on delete cascade,
+    "node" text not null default '',
+    "machine_version" text not null default '',
+    "status" text not null default '',
+    "health" text not null default '',
+    "local_ipv4" text not null default '',
+    "enable_machine_gateway" boolean not null default false,
+    "created_at" timestamp not null default timezone('utc', now()),
+    "updated_at" timestamp not null default timezone('utc', now())
+);
+
+CREATE INDEX instances_machine_id_idx ON instances(machine_id);
+`
+
+const instancesDown = `
+DROP TABLE instances;
+`
@@ -0,0 +1,14 @@
+package schema
+
+const certificatesUp = `
+-- ACME account keys, certificates and challenge tokens shared by the proxy replicas
+CREATE TABLE certificates (
+    "key" text primary key,
+    "data" bytea not null,
+    "updated_at" timestamp not null default timezone('utc', now())
+);
+`
+
+const certificatesDown = `
+DROP TABLE IF EXISTS certificates;
+`
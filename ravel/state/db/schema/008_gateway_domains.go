@@ -0,0 +1,20 @@
+package schema
+
+const gatewayDomainsUp = `
+CREATE TABLE gateway_domains (
+    "hostname" text primary key,
+    "gateway_id" text not null references gateways("id") on delete cascade,
+    "namespace" text not null references namespaces("name") on delete cascade,
+    "status" text not null default 'pending',
+    "token" text not null,
+    "created_at" timestamp not null default timezone('utc', now()),
+    "verified_at" timestamp default null
+);
+CREATE INDEX gateway_domains_gateway_id_idx ON gateway_domains(gateway_id);
+`
+
+const gatewayDomainsDown = `
+DROP TABLE gateway_domains;
+`
+
+const GatewayDomainsPkeyConstraint = "gateway_domains_pkey"
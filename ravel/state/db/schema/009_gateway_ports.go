@@ -0,0 +1,15 @@
+package schema
+
+const gatewayPortsUp = `
+ALTER TABLE gateways ADD COLUMN public_port integer default null;
+ALTER TABLE gateways ADD COLUMN proxy_protocol boolean not null default false;
+ALTER TABLE gateways ADD CONSTRAINT unique_gateway_public_port UNIQUE (protocol, public_port);
+`
+
+const gatewayPortsDown = `
+ALTER TABLE gateways DROP CONSTRAINT unique_gateway_public_port;
+ALTER TABLE gateways DROP COLUMN proxy_protocol;
+ALTER TABLE gateways DROP COLUMN public_port;
+`
+
+const UniqueGatewayPublicPortConstraint = "unique_gateway_public_port"
@@ -0,0 +1,32 @@
+package schema
+
+const networksUp = `
+CREATE TABLE networks (
+    "id" text primary key,
+    "name" text not null,
+    "namespace" text not null references namespaces("name") on delete cascade,
+    "cidr" text not null,
+    "created_at" timestamp not null default timezone('utc', now()),
+    CONSTRAINT unique_network_name UNIQUE (namespace, name)
+);
+
+CREATE TABLE network_members (
+    "network_id" text not null references networks("id") on delete cascade,
+    "machine_id" text not null references machines("id") on delete cascade,
+    "ip" text not null,
+    "node" text not null default '',
+    "public_key" text not null default '',
+    "endpoint" text not null default '',
+    PRIMARY KEY (network_id, machine_id),
+    CONSTRAINT unique_network_member_ip UNIQUE (network_id, ip)
+);
+CREATE INDEX network_members_machine_id_idx ON network_members(machine_id);
+`
+
+const networksDown = `
+DROP TABLE network_members;
+DROP TABLE networks;
+`
+
+const UniqueNetworkNameConstraint = "unique_network_name"
+const UniqueNetworkMemberIPConstraint = "unique_network_member_ip"
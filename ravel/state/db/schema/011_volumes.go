@@ -0,0 +1,22 @@
+package schema
+
+const volumesUp = `
+CREATE TABLE volumes (
+    "id" text primary key,
+    "name" text not null,
+    "namespace" text not null references namespaces("name") on delete cascade,
+    "region" text not null,
+    "node" text not null,
+    "size_mb" bigint not null,
+    "machine_id" text references machines("id") on delete set null,
+    "created_at" timestamp not null default timezone('utc', now()),
+    CONSTRAINT unique_volume_name UNIQUE (namespace, name)
+);
+CREATE INDEX volumes_machine_id_idx ON volumes(machine_id);
+`
+
+const volumesDown = `
+DROP TABLE volumes;
+`
+
+const UniqueVolumeNameConstraint = "unique_volume_name"
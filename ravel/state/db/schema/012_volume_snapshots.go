@@ -0,0 +1,15 @@
+package schema
+
+const volumeSnapshotsUp = `
+CREATE TABLE volume_snapshots (
+    "id" text primary key,
+    "volume_id" text not null references volumes("id") on delete cascade,
+    "namespace" text not null references namespaces("name") on delete cascade,
+    "created_at" timestamp not null default timezone('utc', now())
+);
+CREATE INDEX volume_snapshots_volume_id_idx ON volume_snapshots(volume_id);
+`
+
+const volumeSnapshotsDown = `
+DROP TABLE volume_snapshots;
+`
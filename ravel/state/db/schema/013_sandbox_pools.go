@@ -0,0 +1,32 @@
+package schema
+
+const sandboxPoolsUp = `
+CREATE TABLE sandbox_pools (
+    "id" text primary key,
+    "name" text not null,
+    "namespace" text not null references namespaces("name") on delete cascade,
+    "config" jsonb not null default '{}',
+    "created_at" timestamp not null default timezone('utc', now()),
+    CONSTRAINT unique_sandbox_pool_name UNIQUE (namespace, name)
+);
+
+CREATE TABLE sandboxes (
+    "id" text primary key,
+    "pool_id" text not null references sandbox_pools("id") on delete cascade,
+    "machine_id" text not null default '',
+    "node" text not null default '',
+    "state" text not null,
+    "snapshot_id" text not null default '',
+    "claimed_at" timestamp,
+    "created_at" timestamp not null default timezone('utc', now()),
+    "last_used_at" timestamp not null default timezone('utc', now())
+);
+CREATE INDEX sandboxes_pool_id_idx ON sandboxes(pool_id);
+`
+
+const sandboxPoolsDown = `
+DROP TABLE sandboxes;
+DROP TABLE sandbox_pools;
+`
+
+const UniqueSandboxPoolNameConstraint = "unique_sandbox_pool_name"
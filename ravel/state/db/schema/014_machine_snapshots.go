@@ -0,0 +1,18 @@
+package schema
+
+const machineSnapshotsUp = `
+CREATE TABLE machine_snapshots (
+    "id" text primary key,
+    "machine_id" text not null references machines("id") on delete cascade,
+    "namespace" text not null references namespaces("name") on delete cascade,
+    "node" text not null,
+    "size_bytes" bigint not null default 0,
+    "machine_version" text not null,
+    "created_at" timestamp not null default timezone('utc', now())
+);
+CREATE INDEX machine_snapshots_machine_id_idx ON machine_snapshots(machine_id);
+`
+
+const machineSnapshotsDown = `
+DROP TABLE machine_snapshots;
+`
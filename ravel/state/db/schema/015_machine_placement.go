@@ -0,0 +1,9 @@
+package schema
+
+const machinePlacementUp = `
+ALTER TABLE machines ADD COLUMN placement jsonb;
+`
+
+const machinePlacementDown = `
+ALTER TABLE machines DROP COLUMN placement;
+`
@@ -0,0 +1,9 @@
+package schema
+
+const fleetPlacementStrategyUp = `
+ALTER TABLE fleets ADD COLUMN placement_strategy text NOT NULL DEFAULT '';
+`
+
+const fleetPlacementStrategyDown = `
+ALTER TABLE fleets DROP COLUMN placement_strategy;
+`
@@ -0,0 +1,19 @@
+package schema
+
+const secretsEncryptionUp = `
+-- Data keys of the namespaces, wrapped by a master key of the server
+CREATE TABLE namespace_keys (
+    "namespace" text PRIMARY KEY REFERENCES namespaces(name) ON DELETE CASCADE,
+    "master_key_id" text NOT NULL,
+    "wrapped_key" bytea NOT NULL,
+    "created_at" timestamp NOT NULL DEFAULT timezone('utc', now())
+);
+
+-- The secrets written before the encryption are kept in plaintext until the keys are rotated
+ALTER TABLE secrets ADD COLUMN encrypted boolean NOT NULL DEFAULT false;
+`
+
+const secretsEncryptionDown = `
+ALTER TABLE secrets DROP COLUMN encrypted;
+DROP TABLE IF EXISTS namespace_keys;
+`
@@ -0,0 +1,19 @@
+package schema
+
+const gatewayDomainClaimsUp = `
+-- A domain can be claimed by several gateways, only one of them can verify it
+ALTER TABLE gateway_domains DROP CONSTRAINT gateway_domains_pkey;
+ALTER TABLE gateway_domains ADD CONSTRAINT gateway_domains_pkey PRIMARY KEY (gateway_id, hostname);
+CREATE UNIQUE INDEX gateway_domains_verified_hostname_idx ON gateway_domains(hostname) WHERE status = 'verified';
+`
+
+const gatewayDomainClaimsDown = `
+DROP INDEX IF EXISTS gateway_domains_verified_hostname_idx;
+DELETE FROM gateway_domains WHERE status <> 'verified' AND hostname IN (
+    SELECT hostname FROM gateway_domains GROUP BY hostname HAVING count(*) > 1
+);
+ALTER TABLE gateway_domains DROP CONSTRAINT gateway_domains_pkey;
+ALTER TABLE gateway_domains ADD CONSTRAINT gateway_domains_pkey PRIMARY KEY (hostname);
+`
+
+const GatewayDomainsVerifiedHostnameIndex = "gateway_domains_verified_hostname_idx"
@@ -0,0 +1,18 @@
+package schema
+
+// The versions created before the secrets were delivered at boot hold the values of their secrets
+// in their environment, the secret variables are removed from it. It cannot be reverted.
+const stripSecretEnvUp = `
+UPDATE machine_versions SET config = jsonb_set(config, '{workload,env}', COALESCE((
+    SELECT jsonb_agg(env.value ORDER BY env.position)
+    FROM jsonb_array_elements_text(config->'workload'->'env') WITH ORDINALITY AS env(value, position)
+    WHERE split_part(env.value, '=', 1) NOT IN (
+        SELECT secret->>'env_var' FROM jsonb_array_elements(config->'workload'->'secrets') AS secret
+        WHERE COALESCE(secret->>'env_var', '') <> ''
+    )
+), '[]'::jsonb))
+WHERE jsonb_typeof(config->'workload'->'env') = 'array'
+    AND jsonb_typeof(config->'workload'->'secrets') = 'array';
+`
+
+const stripSecretEnvDown = ``
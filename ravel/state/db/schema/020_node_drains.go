@@ -0,0 +1,17 @@
+package schema
+
+const nodeDrainsUp = `
+-- The last drain of each node, the drains in progress are resumed when the server starts
+CREATE TABLE node_drains (
+    "node" text primary key,
+    "status" text not null,
+    "remaining" integer not null,
+    "machines" jsonb not null default '[]',
+    "started_at" timestamp not null,
+    "completed_at" timestamp
+);
+`
+
+const nodeDrainsDown = `
+DROP TABLE node_drains;
+`
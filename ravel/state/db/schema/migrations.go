@@ -27,5 +27,80 @@ func getMigrations() []Migration {
 			Up:   secretsUp,
 			Down: secretsDown,
 		},
+		{
+			Name: "instances",
+			Up:   instancesUp,
+			Down: instancesDown,
+		},
+		{
+			Name: "certificates",
+			Up:   certificatesUp,
+			Down: certificatesDown,
+		},
+		{
+			Name: "gateway_domains",
+			Up:   gatewayDomainsUp,
+			Down: gatewayDomainsDown,
+		},
+		{
+			Name: "gateway_ports",
+			Up:   gatewayPortsUp,
+			Down: gatewayPortsDown,
+		},
+		{
+			Name: "networks",
+			Up:   networksUp,
+			Down: networksDown,
+		},
+		{
+			Name: "volumes",
+			Up:   volumesUp,
+			Down: volumesDown,
+		},
+		{
+			Name: "volume_snapshots",
+			Up:   volumeSnapshotsUp,
+			Down: volumeSnapshotsDown,
+		},
+		{
+			Name: "sandbox_pools",
+			Up:   sandboxPoolsUp,
+			Down: sandboxPoolsDown,
+		},
+		{
+			Name: "machine_snapshots",
+			Up:   machineSnapshotsUp,
+			Down: machineSnapshotsDown,
+		},
+		{
+			Name: "machine_placement",
+			Up:   machinePlacementUp,
+			Down: machinePlacementDown,
+		},
+		{
+			Name: "fleet_placement_strategy",
+			Up:   fleetPlacementStrategyUp,
+			Down: fleetPlacementStrategyDown,
+		},
+		{
+			Name: "secrets_encryption",
+			Up:   secretsEncryptionUp,
+			Down: secretsEncryptionDown,
+		},
+		{
+			Name: "gateway_domain_claims",
+			Up:   gatewayDomainClaimsUp,
+			Down: gatewayDomainClaimsDown,
+		},
+		{
+			Name: "strip_secret_env",
+			Up:   stripSecretEnvUp,
+			Down: stripSecretEnvDown,
+		},
+		{
+			Name: "node_drains",
+			Up:   nodeDrainsUp,
+			Down: nodeDrainsDown,
+		},
 	}
 }
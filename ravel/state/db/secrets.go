@@ -21,14 +21,22 @@ func scanSecret(s dbutil.Scannable) (secret api.Secret, err error) {
 	return
 }
 
+// SecretValue is the value of a secret as stored, encrypted by the data key of its namespace or
+// in plaintext.
+type SecretValue struct {
+	Name      string
+	Value     string
+	Encrypted bool
+}
+
 const createSecretQuery = `
-INSERT INTO secrets (id, name, namespace, value, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO secrets (id, name, namespace, value, encrypted, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 `
 
-func (q *Queries) CreateSecret(ctx context.Context, id, name, namespace, value string) error {
+func (q *Queries) CreateSecret(ctx context.Context, id, namespace string, value SecretValue) error {
 	now := time.Now()
-	_, err := q.db.Exec(ctx, createSecretQuery, id, name, namespace, value, now, now)
+	_, err := q.db.Exec(ctx, createSecretQuery, id, value.Name, namespace, value.Value, value.Encrypted, now, now)
 	if err != nil {
 		return err
 	}
@@ -47,23 +55,48 @@ func (q *Queries) GetSecret(ctx context.Context, namespace, name string) (api.Se
 }
 
 const getSecretValueQuery = `
-SELECT value
+SELECT name, value, encrypted
 FROM secrets
 WHERE namespace = $1 AND name = $2
 `
 
-func (q *Queries) GetSecretValue(ctx context.Context, namespace, name string) (string, error) {
-	var value string
-	err := q.db.QueryRow(ctx, getSecretValueQuery, namespace, name).Scan(&value)
+func (q *Queries) GetSecretValue(ctx context.Context, namespace, name string) (SecretValue, error) {
+	var value SecretValue
+	err := q.db.QueryRow(ctx, getSecretValueQuery, namespace, name).Scan(&value.Name, &value.Value, &value.Encrypted)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return "", errdefs.NewNotFound("secret not found")
+			return value, errdefs.NewNotFound("secret not found")
 		}
-		return "", err
+		return value, err
 	}
 	return value, nil
 }
 
+const listSecretValuesQuery = `
+SELECT name, value, encrypted
+FROM secrets
+WHERE namespace = $1
+`
+
+func (q *Queries) ListSecretValues(ctx context.Context, namespace string) ([]SecretValue, error) {
+	rows, err := q.db.Query(ctx, listSecretValuesQuery, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []SecretValue
+	for rows.Next() {
+		var value SecretValue
+		if err := rows.Scan(&value.Name, &value.Value, &value.Encrypted); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
 const listSecretsQuery = `
 SELECT id, name, namespace, created_at, updated_at
 FROM secrets
@@ -92,12 +125,12 @@ func (q *Queries) ListSecrets(ctx context.Context, namespace string) ([]api.Secr
 
 const updateSecretQuery = `
 UPDATE secrets
-SET value = $1, updated_at = $2
-WHERE namespace = $3 AND name = $4
+SET value = $1, encrypted = $2, updated_at = $3
+WHERE namespace = $4 AND name = $5
 `
 
-func (q *Queries) UpdateSecret(ctx context.Context, namespace, name, value string) error {
-	result, err := q.db.Exec(ctx, updateSecretQuery, value, time.Now(), namespace, name)
+func (q *Queries) UpdateSecret(ctx context.Context, namespace string, value SecretValue) error {
+	result, err := q.db.Exec(ctx, updateSecretQuery, value.Value, value.Encrypted, time.Now(), namespace, value.Name)
 	if err != nil {
 		return err
 	}
@@ -109,6 +142,13 @@ func (q *Queries) UpdateSecret(ctx context.Context, namespace, name, value strin
 	return nil
 }
 
+// ReencryptSecret replaces the stored value of a secret by the same value encrypted with a new
+// data key, the secret is not considered updated.
+func (q *Queries) ReencryptSecret(ctx context.Context, namespace string, value SecretValue) error {
+	_, err := q.db.Exec(ctx, `UPDATE secrets SET value = $1, encrypted = $2 WHERE namespace = $3 AND name = $4`, value.Value, value.Encrypted, namespace, value.Name)
+	return err
+}
+
 const deleteSecretQuery = `
 DELETE FROM secrets
 WHERE namespace = $1 AND name = $2
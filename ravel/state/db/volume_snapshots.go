@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/jackc/pgx/v5"
+)
+
+const baseSelectVolumeSnapshot = `SELECT id, volume_id, namespace, created_at FROM volume_snapshots`
+
+func scanVolumeSnapshot(row pgx.Row) (snapshot api.VolumeSnapshot, err error) {
+	err = row.Scan(
+		&snapshot.Id,
+		&snapshot.VolumeId,
+		&snapshot.Namespace,
+		&snapshot.CreatedAt,
+	)
+	return
+}
+
+func (q Queries) CreateVolumeSnapshot(ctx context.Context, snapshot api.VolumeSnapshot) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO volume_snapshots (id, volume_id, namespace, created_at) VALUES ($1, $2, $3, $4)`,
+		snapshot.Id,
+		snapshot.VolumeId,
+		snapshot.Namespace,
+		snapshot.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Queries) GetVolumeSnapshot(ctx context.Context, namespace, id string) (api.VolumeSnapshot, error) {
+	snapshot, err := scanVolumeSnapshot(q.db.QueryRow(ctx, baseSelectVolumeSnapshot+" WHERE namespace = $1 AND id = $2", namespace, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return snapshot, errdefs.NewNotFound("volume snapshot not found")
+		}
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+func (q Queries) ListVolumeSnapshots(ctx context.Context, volumeId string) ([]api.VolumeSnapshot, error) {
+	rows, err := q.db.Query(ctx, baseSelectVolumeSnapshot+" WHERE volume_id = $1 ORDER BY created_at", volumeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []api.VolumeSnapshot{}
+	for rows.Next() {
+		snapshot, err := scanVolumeSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func (q Queries) DeleteVolumeSnapshot(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM volume_snapshots WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteVolumeSnapshotsAfter deletes the snapshots of a volume taken after the given time,
+// they are destroyed on the node when the volume is restored to an older snapshot.
+func (q Queries) DeleteVolumeSnapshotsAfter(ctx context.Context, volumeId string, after time.Time) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM volume_snapshots WHERE volume_id = $1 AND created_at > $2`, volumeId, after)
+	if err != nil {
+		return err
+	}
+	return nil
+}
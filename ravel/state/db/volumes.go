@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/ravel/state/db/schema"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const baseSelectVolume = `SELECT id, name, namespace, region, node, size_mb, COALESCE(machine_id, ''), created_at FROM volumes`
+
+func scanVolume(row pgx.Row) (volume api.Volume, err error) {
+	err = row.Scan(
+		&volume.Id,
+		&volume.Name,
+		&volume.Namespace,
+		&volume.Region,
+		&volume.Node,
+		&volume.SizeMB,
+		&volume.AttachedMachine,
+		&volume.CreatedAt,
+	)
+	return
+}
+
+func (q Queries) CreateVolume(ctx context.Context, volume api.Volume) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO volumes (id, name, namespace, region, node, size_mb, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		volume.Id,
+		volume.Name,
+		volume.Namespace,
+		volume.Region,
+		volume.Node,
+		volume.SizeMB,
+		volume.CreatedAt,
+	)
+	if err != nil {
+		var pg *pgconn.PgError
+		if errors.As(err, &pg) && pg.ConstraintName == schema.UniqueVolumeNameConstraint {
+			return errdefs.NewAlreadyExists("volume already exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (q Queries) GetVolume(ctx context.Context, namespace, name string) (api.Volume, error) {
+	return q.getVolume(ctx, " WHERE namespace = $1 AND name = $2", namespace, name)
+}
+
+func (q Queries) GetVolumeById(ctx context.Context, namespace, id string) (api.Volume, error) {
+	return q.getVolume(ctx, " WHERE namespace = $1 AND id = $2", namespace, id)
+}
+
+func (q Queries) getVolume(ctx context.Context, where string, args ...any) (api.Volume, error) {
+	volume, err := scanVolume(q.db.QueryRow(ctx, baseSelectVolume+where, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return volume, errdefs.NewNotFound("volume not found")
+		}
+		return volume, err
+	}
+	return volume, nil
+}
+
+func (q Queries) ListVolumes(ctx context.Context, namespace string) ([]api.Volume, error) {
+	rows, err := q.db.Query(ctx, baseSelectVolume+" WHERE namespace = $1 ORDER BY name", namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	volumes := []api.Volume{}
+	for rows.Next() {
+		volume, err := scanVolume(rows)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volume)
+	}
+
+	return volumes, rows.Err()
+}
+
+// CountNodesVolumes returns the number of volumes held by each of the given nodes.
+func (q Queries) CountNodesVolumes(ctx context.Context, nodes []string) (map[string]int, error) {
+	rows, err := q.db.Query(ctx, `SELECT node, COUNT(*) FROM volumes WHERE node = ANY($1) GROUP BY node`, nodes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var node string
+		var count int
+		if err := rows.Scan(&node, &count); err != nil {
+			return nil, err
+		}
+		counts[node] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// AttachVolume marks a volume as used by a machine, it fails if the volume is already attached.
+func (q Queries) AttachVolume(ctx context.Context, id, machineId string) error {
+	result, err := q.db.Exec(ctx, `UPDATE volumes SET machine_id = $2 WHERE id = $1 AND machine_id IS NULL`, id, machineId)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return errdefs.NewFailedPrecondition("volume is already attached to a machine")
+	}
+
+	return nil
+}
+
+func (q Queries) DetachMachineVolumes(ctx context.Context, machineId string) error {
+	_, err := q.db.Exec(ctx, `UPDATE volumes SET machine_id = NULL WHERE machine_id = $1`, machineId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Queries) UpdateVolumeSize(ctx context.Context, id string, sizeMB uint64) error {
+	_, err := q.db.Exec(ctx, `UPDATE volumes SET size_mb = $2 WHERE id = $1`, id, sizeMB)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Queries) UpdateVolumeNode(ctx context.Context, id string, node string) error {
+	_, err := q.db.Exec(ctx, `UPDATE volumes SET node = $2 WHERE id = $1`, id, node)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// LockDetachedVolume locks a volume against an attachment until the end of the transaction, it
+// fails if the volume is attached to a machine.
+func (q Queries) LockDetachedVolume(ctx context.Context, id string) error {
+	var machineId string
+	err := q.db.QueryRow(ctx, `SELECT COALESCE(machine_id, '') FROM volumes WHERE id = $1 FOR UPDATE`, id).Scan(&machineId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errdefs.NewNotFound("volume not found")
+		}
+		return err
+	}
+
+	if machineId != "" {
+		return errdefs.NewFailedPrecondition("volume is attached to machine " + machineId)
+	}
+
+	return nil
+}
+
+func (q Queries) DeleteVolume(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, `DELETE FROM volumes WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/config"
 )
 
 func (s *State) GetGateway(ctx context.Context, namespace, fleetId, idOrName string) (api.Gateway, error) {
@@ -18,7 +20,8 @@ func (s *State) GetGateway(ctx context.Context, namespace, fleetId, idOrName str
 
 }
 
-func (s *State) CreateGateway(gateway api.Gateway) error {
+// CreateGateway stores a new gateway. When ports is set, a public port of the range is allocated to the gateway.
+func (s *State) CreateGateway(gateway *api.Gateway, ports *config.PortRange) error {
 	ctx := context.Background()
 	tx, err := s.db.BeginTx(ctx)
 	if err != nil {
@@ -35,7 +38,14 @@ func (s *State) CreateGateway(gateway api.Gateway) error {
 		return errdefs.NewNotFound("fleet not found")
 	}
 
-	if err = tx.CreateGateway(ctx, gateway); err != nil {
+	if ports != nil {
+		gateway.PublicPort, err = tx.AllocateGatewayPublicPort(ctx, gateway.Protocol, ports.Min, ports.Max)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.CreateGateway(ctx, *gateway); err != nil {
 		return fmt.Errorf("failed to create gateway on pg: %w", err)
 	}
 
@@ -44,7 +54,7 @@ func (s *State) CreateGateway(gateway api.Gateway) error {
 		return fmt.Errorf("failed to begin tx: %w", err)
 	}
 
-	err = cstx.CreateGateway(ctx, gateway)
+	err = cstx.CreateGateway(ctx, *gateway)
 	if err != nil {
 		return fmt.Errorf("failed to create gateway on corro: %w", err)
 	}
@@ -99,3 +109,27 @@ func (s *State) ListGateways(ctx context.Context, namespace string) ([]api.Gatew
 func (s *State) ListGatewaysOnFleet(ctx context.Context, ns, fleetId string) ([]api.Gateway, error) {
 	return s.db.ListGatewaysOnFleet(ctx, ns, fleetId)
 }
+
+func (s *State) CreateGatewayDomain(ctx context.Context, domain api.GatewayDomain) error {
+	return s.db.CreateGatewayDomain(ctx, domain)
+}
+
+func (s *State) GetGatewayDomain(ctx context.Context, gatewayId, hostname string) (api.GatewayDomain, error) {
+	return s.db.GetGatewayDomain(ctx, gatewayId, hostname)
+}
+
+func (s *State) ListGatewayDomains(ctx context.Context, gatewayId string) ([]api.GatewayDomain, error) {
+	return s.db.ListGatewayDomains(ctx, gatewayId)
+}
+
+func (s *State) ListFleetGatewayDomains(ctx context.Context, ns, fleetId string) ([]api.GatewayDomain, error) {
+	return s.db.ListFleetGatewayDomains(ctx, ns, fleetId)
+}
+
+func (s *State) MarkGatewayDomainVerified(ctx context.Context, gatewayId, hostname string, verifiedAt time.Time) error {
+	return s.db.MarkGatewayDomainVerified(ctx, gatewayId, hostname, verifiedAt)
+}
+
+func (s *State) DeleteGatewayDomain(ctx context.Context, gatewayId, hostname string) error {
+	return s.db.DeleteGatewayDomain(ctx, gatewayId, hostname)
+}
@@ -0,0 +1,27 @@
+package state
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+func (s *State) CreateMachineSnapshot(ctx context.Context, snapshot api.MachineSnapshot) error {
+	return s.db.CreateMachineSnapshot(ctx, snapshot)
+}
+
+func (s *State) GetMachineSnapshot(ctx context.Context, namespace, id string) (api.MachineSnapshot, error) {
+	return s.db.GetMachineSnapshot(ctx, namespace, id)
+}
+
+func (s *State) ListMachineSnapshots(ctx context.Context, machineId string) ([]api.MachineSnapshot, error) {
+	return s.db.ListMachineSnapshots(ctx, machineId)
+}
+
+func (s *State) DeleteMachineSnapshot(ctx context.Context, id string) error {
+	return s.db.DeleteMachineSnapshot(ctx, id)
+}
+
+func (s *State) UpdateMachineSnapshotNode(ctx context.Context, id string, node string) error {
+	return s.db.UpdateMachineSnapshotNode(ctx, id, node)
+}
@@ -26,7 +26,19 @@ func (s *State) GetMachine(ctx context.Context, namespace, fleetId, id string, s
 	return s.db.GetMachine(ctx, namespace, fleetId, id, showDestroyed)
 }
 
-func (s *State) CreateMachine(machine cluster.Machine, mv api.MachineVersion) error {
+// GetMachineById returns a machine, destroyed or not, without checking its namespace and fleet.
+func (s *State) GetMachineById(ctx context.Context, id string) (cluster.Machine, error) {
+	return s.db.GetMachineById(ctx, id)
+}
+
+func (s *State) ListNodeMachines(ctx context.Context, node string) ([]cluster.Machine, error) {
+	return s.db.ListNodeMachines(ctx, node)
+}
+
+// CreateMachine stores a new machine. The IPs of the machine private networks are
+// allocated in the same transaction and written to the machine version config, and
+// its volumes are attached to it.
+func (s *State) CreateMachine(machine cluster.Machine, mv *api.MachineVersion) error {
 	ctx := context.Background()
 	tx, err := s.db.BeginTx(ctx)
 	if err != nil {
@@ -43,15 +55,35 @@ func (s *State) CreateMachine(machine cluster.Machine, mv api.MachineVersion) er
 		return errdefs.NewNotFound("fleet not found")
 	}
 
-	if err = tx.CreateMachine(ctx, machine, mv); err != nil {
+	networks, members, err := allocateNetworkIPs(ctx, tx, machine.Namespace, mv.Config.Workload.PrivateNetworks)
+	if err != nil {
+		return err
+	}
+	if len(networks) > 0 {
+		mv.Config.Workload.PrivateNetworks = networks
+	}
+
+	if err = tx.CreateMachine(ctx, machine, *mv); err != nil {
 		return fmt.Errorf("failed to create machine on pg: %w", err)
 	}
 
+	for _, m := range members {
+		if err = tx.CreateNetworkMember(ctx, m.networkId, machine.Id, m.ip); err != nil {
+			return fmt.Errorf("failed to create network member on pg: %w", err)
+		}
+	}
+
+	for _, v := range mv.Config.Workload.Volumes {
+		if err = tx.AttachVolume(ctx, v.Volume, machine.Id); err != nil {
+			return err
+		}
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit tx: %w", err)
 	}
 
-	err = s.clusterState.CreateMachine(ctx, machine, mv)
+	err = s.clusterState.CreateMachine(ctx, machine, *mv)
 	if err != nil {
 		return fmt.Errorf("failed to create machine on corro: %w", err)
 	}
@@ -83,12 +115,88 @@ func (s *State) UpdateMachine(machine cluster.Machine) error {
 	return nil
 }
 
+// UpdateMachineVersion records a new machine version and points the machine to it
+func (s *State) UpdateMachineVersion(machine cluster.Machine, mv api.MachineVersion) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.CreateMachineVersion(ctx, mv); err != nil {
+		return fmt.Errorf("failed to create machine version on pg: %w", err)
+	}
+
+	if err = tx.UpdateMachine(ctx, machine); err != nil {
+		return fmt.Errorf("failed to update machine on pg: %w", err)
+	}
+
+	cstx, err := s.clusterState.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+
+	if err = cstx.CreateMachineVersion(ctx, mv); err != nil {
+		return fmt.Errorf("failed to create machine version on corro: %w", err)
+	}
+
+	if err = cstx.UpdateMachine(ctx, machine); err != nil {
+		return fmt.Errorf("failed to update machine on corro: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	if err = cstx.Commit(ctx); err != nil { // commit cluster-state tx after pg to avoid inconsistency
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// RevertMachineVersion points the machine back to its previous version and deletes the version
+// which was never applied.
+func (s *State) RevertMachineVersion(previous cluster.Machine, versionId string) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if err = tx.UpdateMachine(ctx, previous); err != nil {
+		return fmt.Errorf("failed to update machine on pg: %w", err)
+	}
+
+	if err = tx.DeleteMachineVersion(ctx, previous.Id, versionId); err != nil {
+		return fmt.Errorf("failed to delete machine version on pg: %w", err)
+	}
+
+	if err = s.clusterState.UpdateMachine(ctx, previous); err != nil {
+		return fmt.Errorf("failed to update machine on corro: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
 func (s *State) DestroyMachine(ctx context.Context, id string) error {
 	err := s.db.DestroyMachine(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to destroy machine on pg: %w", err)
 	}
 
+	err = s.db.DetachMachineVolumes(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to detach machine volumes on pg: %w", err)
+	}
+
 	err = s.clusterState.DestroyMachine(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to destroy machine on corro: %w", err)
@@ -105,6 +213,10 @@ func (s *State) ListMachineVersions(ctx context.Context, machineId string) ([]ap
 	return s.db.ListMachineVersions(ctx, machineId)
 }
 
+func (s *State) UpsertInstance(ctx context.Context, instance cluster.MachineInstance) error {
+	return s.db.UpsertInstance(ctx, instance)
+}
+
 func (s *State) StoreMachineEvent(ctx context.Context, event api.MachineEvent) error {
 	return s.db.PushMachineEvent(ctx, event)
 }
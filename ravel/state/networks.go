@@ -0,0 +1,157 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/core/networking"
+	"github.com/alexisbouchez/ravel/ravel/state/db"
+)
+
+func (s *State) CreateNetwork(ctx context.Context, network api.Network) error {
+	return s.db.CreateNetwork(ctx, network)
+}
+
+func (s *State) GetNetwork(ctx context.Context, namespace, name string) (api.Network, error) {
+	network, err := s.db.GetNetwork(ctx, namespace, name)
+	if err != nil {
+		return api.Network{}, err
+	}
+
+	network.Members, err = s.db.ListNetworkMembers(ctx, network.Id)
+	if err != nil {
+		return api.Network{}, err
+	}
+
+	return network, nil
+}
+
+func (s *State) ListNetworks(ctx context.Context, namespace string) ([]api.Network, error) {
+	return s.db.ListNetworks(ctx, namespace)
+}
+
+func (s *State) DeleteNetwork(ctx context.Context, namespace, name string) error {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	network, err := tx.GetNetworkForUpdate(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	members, err := tx.ListNetworkMembers(ctx, network.Id)
+	if err != nil {
+		return err
+	}
+
+	if len(members) > 0 {
+		return errdefs.NewFailedPrecondition(fmt.Sprintf("network %s still has %d machines", name, len(members)))
+	}
+
+	if err = tx.DeleteNetwork(ctx, network.Id); err != nil {
+		return fmt.Errorf("failed to delete network on pg: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+type networkMember struct {
+	networkId string
+	ip        string
+}
+
+// allocateNetworkIPs picks the IP of a machine in each of its private networks. The requested
+// IPs are checked, missing ones are allocated. It returns the private networks with their IP
+// in CIDR notation and the members to create once the machine exists.
+func allocateNetworkIPs(ctx context.Context, tx *db.Transaction, namespace string, networks []api.PrivateNetwork) ([]api.PrivateNetwork, []networkMember, error) {
+	allocated := make([]api.PrivateNetwork, 0, len(networks))
+	members := make([]networkMember, 0, len(networks))
+
+	for _, pn := range networks {
+		network, err := tx.GetNetworkForUpdate(ctx, namespace, pn.Name)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return nil, nil, errdefs.NewInvalidArgument("private network not found: " + pn.Name)
+			}
+			return nil, nil, err
+		}
+
+		prefix, err := netip.ParsePrefix(network.CIDR)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid network cidr %q: %w", network.CIDR, err)
+		}
+
+		current, err := tx.ListNetworkMembers(ctx, network.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		used := make(map[netip.Addr]bool, len(current))
+		for _, m := range current {
+			if addr, err := netip.ParseAddr(m.IP); err == nil {
+				used[addr] = true
+			}
+		}
+
+		var addr netip.Addr
+		if pn.IP != "" {
+			addr, err = netip.ParseAddr(strings.Split(pn.IP, "/")[0])
+			if err != nil || !networking.IsHostAddress(prefix, addr) {
+				return nil, nil, errdefs.NewInvalidArgument(fmt.Sprintf("ip %s is not a valid host address of network %s (%s)", pn.IP, pn.Name, network.CIDR))
+			}
+			if used[addr] {
+				return nil, nil, errdefs.NewAlreadyExists(fmt.Sprintf("ip %s is already used in network %s", addr, pn.Name))
+			}
+		} else {
+			var ok bool
+			addr, ok = networking.FirstFreeAddress(prefix, used)
+			if !ok {
+				return nil, nil, errdefs.NewResourcesExhausted("no ip available in network " + pn.Name)
+			}
+		}
+
+		allocated = append(allocated, api.PrivateNetwork{
+			Name: pn.Name,
+			IP:   netip.PrefixFrom(addr, prefix.Bits()).String(),
+		})
+		members = append(members, networkMember{networkId: network.Id, ip: addr.String()})
+	}
+
+	return allocated, members, nil
+}
+
+// JoinNetwork records the WireGuard peer of a machine and returns the peers of the network.
+func (s *State) JoinNetwork(ctx context.Context, join cluster.NetworkJoin) (cluster.NetworkPeers, error) {
+	networkId, err := s.db.UpdateNetworkMemberPeer(ctx, join.Namespace, join.Network, join.Peer)
+	if err != nil {
+		return cluster.NetworkPeers{}, err
+	}
+
+	return s.GetNetworkPeers(ctx, join.Namespace, join.Network, networkId)
+}
+
+func (s *State) GetNetworkPeers(ctx context.Context, namespace, name, networkId string) (cluster.NetworkPeers, error) {
+	peers, err := s.db.ListNetworkPeers(ctx, networkId)
+	if err != nil {
+		return cluster.NetworkPeers{}, err
+	}
+
+	return cluster.NetworkPeers{
+		Namespace: namespace,
+		Network:   name,
+		Peers:     peers,
+	}, nil
+}
+
+// LeaveNetworks releases the IPs of a machine and returns the networks it has left.
+func (s *State) LeaveNetworks(ctx context.Context, machineId string) ([]api.Network, error) {
+	return s.db.DeleteMachineNetworkMembers(ctx, machineId)
+}
@@ -0,0 +1,15 @@
+package state
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+func (s *State) PutNodeDrain(ctx context.Context, drain api.NodeDrain) error {
+	return s.db.PutNodeDrain(ctx, drain)
+}
+
+func (s *State) ListNodeDrains(ctx context.Context) ([]api.NodeDrain, error) {
+	return s.db.ListNodeDrains(ctx)
+}
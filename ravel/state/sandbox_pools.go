@@ -0,0 +1,39 @@
+package state
+
+import (
+	"context"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+func (s *State) CreateSandboxPool(ctx context.Context, pool api.SandboxPool) error {
+	return s.db.CreateSandboxPool(ctx, pool)
+}
+
+func (s *State) GetSandboxPool(ctx context.Context, namespace, name string) (api.SandboxPool, error) {
+	return s.db.GetSandboxPool(ctx, namespace, name)
+}
+
+func (s *State) ListSandboxPools(ctx context.Context, namespace string) ([]api.SandboxPool, error) {
+	return s.db.ListSandboxPools(ctx, namespace)
+}
+
+func (s *State) ListAllSandboxPools(ctx context.Context) ([]api.SandboxPool, error) {
+	return s.db.ListAllSandboxPools(ctx)
+}
+
+func (s *State) DeleteSandboxPool(ctx context.Context, id string) error {
+	return s.db.DeleteSandboxPool(ctx, id)
+}
+
+func (s *State) PutSandbox(ctx context.Context, sandbox api.Sandbox) error {
+	return s.db.PutSandbox(ctx, sandbox)
+}
+
+func (s *State) ListSandboxes(ctx context.Context, poolId string) ([]api.Sandbox, error) {
+	return s.db.ListSandboxes(ctx, poolId)
+}
+
+func (s *State) DeleteSandbox(ctx context.Context, id string) error {
+	return s.db.DeleteSandbox(ctx, id)
+}
@@ -0,0 +1,283 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/ravel/secrets"
+	"github.com/alexisbouchez/ravel/ravel/state/db"
+)
+
+type dataKey struct {
+	wrapped secrets.WrappedKey
+	key     []byte
+}
+
+// CreateSecret stores the secret encrypted with the data key of its namespace, the data key is
+// created with the first secret of the namespace. The secret is stored in plaintext if no key
+// provider is configured.
+func (s *State) CreateSecret(ctx context.Context, id, namespace, name, value string) error {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// the namespace is locked against a rotation of its key
+	if _, err := tx.GetNamespaceForShare(ctx, namespace); err != nil {
+		return err
+	}
+
+	stored, err := s.encryptSecret(ctx, tx.Queries, namespace, name, value)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.CreateSecret(ctx, id, namespace, stored); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *State) UpdateSecret(ctx context.Context, namespace, name, value string) error {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.GetNamespaceForShare(ctx, namespace); err != nil {
+		return err
+	}
+
+	stored, err := s.encryptSecret(ctx, tx.Queries, namespace, name, value)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.UpdateSecret(ctx, namespace, stored); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetSecretValue returns the decrypted value of the secret.
+func (s *State) GetSecretValue(ctx context.Context, namespace, name string) (string, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	// the secret and the key of the namespace are read together, not during a rotation
+	if _, err := tx.GetNamespaceForShare(ctx, namespace); err != nil {
+		return "", err
+	}
+
+	stored, err := tx.GetSecretValue(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+
+	if !stored.Encrypted {
+		return stored.Value, nil
+	}
+
+	if s.keys == nil {
+		return "", errdefs.NewFailedPrecondition("the secret is encrypted but no secrets key is configured")
+	}
+
+	wrapped, err := tx.GetNamespaceKey(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := s.unwrapDataKey(ctx, namespace, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := secrets.Decrypt(key, namespace, name, stored.Value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+	}
+
+	return value, nil
+}
+
+// RotateSecretsKeys replaces the data key of each namespace by a new one wrapped by the current
+// master key and encrypts the secrets again with it, the secrets stored in plaintext included.
+// The previous master keys are no longer needed once it returns.
+func (s *State) RotateSecretsKeys(ctx context.Context) (namespaces int, rotated int, err error) {
+	if s.keys == nil {
+		return 0, 0, errdefs.NewFailedPrecondition("no secrets key is configured")
+	}
+
+	list, err := s.db.ListNamespaces(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, ns := range list {
+		count, err := s.rotateNamespaceKey(ctx, ns.Name)
+		if err != nil {
+			return namespaces, rotated, fmt.Errorf("failed to rotate the key of namespace %s: %w", ns.Name, err)
+		}
+
+		if count > 0 {
+			namespaces++
+			rotated += count
+		}
+	}
+
+	return namespaces, rotated, nil
+}
+
+func (s *State) rotateNamespaceKey(ctx context.Context, namespace string) (int, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// the secrets cannot be written during the rotation
+	if _, err := tx.GetNamespaceForUpdate(ctx, namespace); err != nil {
+		return 0, err
+	}
+
+	values, err := tx.ListSecretValues(ctx, namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	var oldKey []byte
+	wrapped, err := tx.GetNamespaceKey(ctx, namespace)
+	if err == nil {
+		oldKey, err = s.unwrapDataKey(ctx, namespace, wrapped)
+	}
+	if err != nil && !errdefs.IsNotFound(err) {
+		return 0, err
+	}
+
+	newKey, err := secrets.GenerateDataKey()
+	if err != nil {
+		return 0, err
+	}
+
+	newWrapped, err := s.keys.Wrap(ctx, newKey)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.PutNamespaceKey(ctx, namespace, newWrapped); err != nil {
+		return 0, err
+	}
+
+	for _, stored := range values {
+		value := stored.Value
+		if stored.Encrypted {
+			if oldKey == nil {
+				return 0, fmt.Errorf("secret %s is encrypted but the namespace has no key", stored.Name)
+			}
+
+			value, err = secrets.Decrypt(oldKey, namespace, stored.Name, stored.Value)
+			if err != nil {
+				return 0, fmt.Errorf("failed to decrypt secret %s: %w", stored.Name, err)
+			}
+		}
+
+		encrypted, err := secrets.Encrypt(newKey, namespace, stored.Name, value)
+		if err != nil {
+			return 0, err
+		}
+
+		err = tx.ReencryptSecret(ctx, namespace, db.SecretValue{Name: stored.Name, Value: encrypted, Encrypted: true})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(values), nil
+}
+
+func (s *State) encryptSecret(ctx context.Context, q *db.Queries, namespace, name, value string) (db.SecretValue, error) {
+	if s.keys == nil {
+		return db.SecretValue{Name: name, Value: value}, nil
+	}
+
+	key, err := s.namespaceDataKey(ctx, q, namespace)
+	if err != nil {
+		return db.SecretValue{}, err
+	}
+
+	encrypted, err := secrets.Encrypt(key, namespace, name, value)
+	if err != nil {
+		return db.SecretValue{}, err
+	}
+
+	return db.SecretValue{Name: name, Value: encrypted, Encrypted: true}, nil
+}
+
+// namespaceDataKey returns the data key of the namespace, it is created if the namespace has none.
+func (s *State) namespaceDataKey(ctx context.Context, q *db.Queries, namespace string) ([]byte, error) {
+	wrapped, err := q.GetNamespaceKey(ctx, namespace)
+	if errdefs.IsNotFound(err) {
+		var key []byte
+		key, err = secrets.GenerateDataKey()
+		if err != nil {
+			return nil, err
+		}
+
+		wrapped, err = s.keys.Wrap(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = q.CreateNamespaceKey(ctx, namespace, wrapped); err != nil {
+			return nil, err
+		}
+
+		// another server may have created the key first
+		wrapped, err = q.GetNamespaceKey(ctx, namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.unwrapDataKey(ctx, namespace, wrapped)
+}
+
+// unwrapDataKey unwraps the data key with the key provider, the unwrapped keys are cached until
+// the key of the namespace is rotated.
+func (s *State) unwrapDataKey(ctx context.Context, namespace string, wrapped secrets.WrappedKey) ([]byte, error) {
+	s.dataKeysMu.Lock()
+	cached, ok := s.dataKeys[namespace]
+	s.dataKeysMu.Unlock()
+
+	if ok && cached.wrapped.MasterKeyId == wrapped.MasterKeyId && bytes.Equal(cached.wrapped.Key, wrapped.Key) {
+		return cached.key, nil
+	}
+
+	key, err := s.keys.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap the data key of namespace %s: %w", namespace, err)
+	}
+
+	s.dataKeysMu.Lock()
+	s.dataKeys[namespace] = dataKey{wrapped: wrapped, key: key}
+	s.dataKeysMu.Unlock()
+
+	return key, nil
+}
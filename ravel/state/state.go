@@ -1,7 +1,10 @@
 package state
 
 import (
+	"sync"
+
 	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/ravel/secrets"
 	"github.com/alexisbouchez/ravel/ravel/state/db"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -10,13 +13,19 @@ type State struct {
 	clusterState cluster.ClusterState
 	db           *db.DB
 	Queries      *db.Queries
+
+	keys       secrets.KeyProvider // nil if the secrets are stored in plaintext
+	dataKeysMu sync.Mutex
+	dataKeys   map[string]dataKey
 }
 
-func New(pgxpool *pgxpool.Pool, clusterState cluster.ClusterState) *State {
+func New(pgxpool *pgxpool.Pool, clusterState cluster.ClusterState, keys secrets.KeyProvider) *State {
 	database := db.New(pgxpool)
 	return &State{
 		clusterState: clusterState,
 		db:           database,
 		Queries:      database.Queries,
+		keys:         keys,
+		dataKeys:     map[string]dataKey{},
 	}
 }
@@ -0,0 +1,84 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+func (s *State) CreateVolume(ctx context.Context, volume api.Volume) error {
+	return s.db.CreateVolume(ctx, volume)
+}
+
+func (s *State) GetVolume(ctx context.Context, namespace, name string) (api.Volume, error) {
+	return s.db.GetVolume(ctx, namespace, name)
+}
+
+func (s *State) GetVolumeById(ctx context.Context, namespace, id string) (api.Volume, error) {
+	return s.db.GetVolumeById(ctx, namespace, id)
+}
+
+func (s *State) ListVolumes(ctx context.Context, namespace string) ([]api.Volume, error) {
+	return s.db.ListVolumes(ctx, namespace)
+}
+
+func (s *State) CountNodesVolumes(ctx context.Context, nodes []string) (map[string]int, error) {
+	return s.db.CountNodesVolumes(ctx, nodes)
+}
+
+func (s *State) UpdateVolumeSize(ctx context.Context, id string, sizeMB uint64) error {
+	return s.db.UpdateVolumeSize(ctx, id, sizeMB)
+}
+
+func (s *State) UpdateVolumeNode(ctx context.Context, id string, node string) error {
+	return s.db.UpdateVolumeNode(ctx, id, node)
+}
+
+func (s *State) DeleteVolume(ctx context.Context, id string) error {
+	return s.db.DeleteVolume(ctx, id)
+}
+
+// DeleteDetachedVolume destroys a volume with destroy and deletes it, the volume is locked
+// against an attachment until it is deleted.
+func (s *State) DeleteDetachedVolume(ctx context.Context, id string, destroy func() error) error {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.LockDetachedVolume(ctx, id); err != nil {
+		return err
+	}
+
+	if err := destroy(); err != nil {
+		return err
+	}
+
+	if err := tx.DeleteVolume(ctx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *State) CreateVolumeSnapshot(ctx context.Context, snapshot api.VolumeSnapshot) error {
+	return s.db.CreateVolumeSnapshot(ctx, snapshot)
+}
+
+func (s *State) GetVolumeSnapshot(ctx context.Context, namespace, id string) (api.VolumeSnapshot, error) {
+	return s.db.GetVolumeSnapshot(ctx, namespace, id)
+}
+
+func (s *State) ListVolumeSnapshots(ctx context.Context, volumeId string) ([]api.VolumeSnapshot, error) {
+	return s.db.ListVolumeSnapshots(ctx, volumeId)
+}
+
+func (s *State) DeleteVolumeSnapshot(ctx context.Context, id string) error {
+	return s.db.DeleteVolumeSnapshot(ctx, id)
+}
+
+func (s *State) DeleteVolumeSnapshotsAfter(ctx context.Context, volumeId string, after time.Time) error {
+	return s.db.DeleteVolumeSnapshotsAfter(ctx, volumeId, after)
+}
@@ -226,3 +226,23 @@ func validateAnnotationValue(value string) error {
 	}
 	return nil
 }
+
+// validateHostname validates a fully qualified domain name such as "app.example.com".
+func validateHostname(hostname string) error {
+	if len(hostname) > 253 {
+		return errdefs.NewInvalidArgument("hostname cannot be longer than 253 characters")
+	}
+
+	labels := strings.Split(hostname, ".")
+	if len(labels) < 2 {
+		return errdefs.NewInvalidArgument("hostname must be a fully qualified domain name")
+	}
+
+	for _, label := range labels {
+		if err := validateObjectName(label); err != nil {
+			return errdefs.NewInvalidArgument(fmt.Sprintf("invalid hostname label %q: %s", label, err))
+		}
+	}
+
+	return nil
+}
@@ -187,3 +187,30 @@ func TestValidateMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateHostname(t *testing.T) {
+	tests := []struct {
+		hostname string
+		wantErr  bool
+	}{
+		{hostname: "example.com", wantErr: false},
+		{hostname: "app.example.com", wantErr: false},
+		{hostname: "my-app.example.co.uk", wantErr: false},
+		{hostname: "localhost", wantErr: true},
+		{hostname: "", wantErr: true},
+		{hostname: "app..example.com", wantErr: true},
+		{hostname: "-app.example.com", wantErr: true},
+		{hostname: "*.example.com", wantErr: true},
+		{hostname: "app_1.example.com", wantErr: true},
+		{hostname: strings.Repeat("a", 64) + ".com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			err := validateHostname(tt.hostname)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateHostname(%q) error = %v, wantErr %v", tt.hostname, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package ravel
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/id"
+)
+
+type VolumeSnapshot = api.VolumeSnapshot
+
+// CreateVolumeSnapshot snapshots a volume on its node. The snapshot of an attached volume is
+// crash consistent, stop the machine first to include the writes it did not flush yet.
+func (r *Ravel) CreateVolumeSnapshot(ctx context.Context, namespace, volumeName string) (VolumeSnapshot, error) {
+	volume, err := r.State.GetVolume(ctx, namespace, volumeName)
+	if err != nil {
+		return VolumeSnapshot{}, err
+	}
+
+	snapshot := VolumeSnapshot{
+		Id:        id.GeneratePrefixed("vsnap"),
+		VolumeId:  volume.Id,
+		Namespace: namespace,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := r.o.CreateVolumeSnapshot(ctx, volume.Node, volume.Id, snapshot.Id); err != nil {
+		return VolumeSnapshot{}, err
+	}
+
+	ctx = context.Background() // the snapshot exists on the node, it must be recorded
+
+	if err := r.State.CreateVolumeSnapshot(ctx, snapshot); err != nil {
+		if err := r.o.DeleteVolumeSnapshot(ctx, volume.Node, volume.Id, snapshot.Id); err != nil {
+			slog.Error("failed to delete volume snapshot", "volume", volume.Id, "snapshot", snapshot.Id, "error", err)
+		}
+		return VolumeSnapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+func (r *Ravel) ListVolumeSnapshots(ctx context.Context, namespace, volumeName string) ([]VolumeSnapshot, error) {
+	volume, err := r.State.GetVolume(ctx, namespace, volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.State.ListVolumeSnapshots(ctx, volume.Id)
+}
+
+func (r *Ravel) getVolumeSnapshot(ctx context.Context, namespace, volumeName, snapshotId string) (Volume, VolumeSnapshot, error) {
+	volume, err := r.State.GetVolume(ctx, namespace, volumeName)
+	if err != nil {
+		return Volume{}, VolumeSnapshot{}, err
+	}
+
+	snapshot, err := r.State.GetVolumeSnapshot(ctx, namespace, snapshotId)
+	if err != nil {
+		return Volume{}, VolumeSnapshot{}, err
+	}
+
+	if snapshot.VolumeId != volume.Id {
+		return Volume{}, VolumeSnapshot{}, errdefs.NewNotFound("volume snapshot not found")
+	}
+
+	return volume, snapshot, nil
+}
+
+func (r *Ravel) DeleteVolumeSnapshot(ctx context.Context, namespace, volumeName, snapshotId string) error {
+	volume, snapshot, err := r.getVolumeSnapshot(ctx, namespace, volumeName, snapshotId)
+	if err != nil {
+		return err
+	}
+
+	err = r.o.DeleteVolumeSnapshot(ctx, volume.Node, volume.Id, snapshot.Id)
+	if err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+
+	return r.State.DeleteVolumeSnapshot(ctx, snapshot.Id)
+}
+
+// RestoreVolumeSnapshot rolls a detached volume back to a snapshot. The snapshots taken
+// after it are destroyed.
+func (r *Ravel) RestoreVolumeSnapshot(ctx context.Context, namespace, volumeName, snapshotId string) error {
+	volume, snapshot, err := r.getVolumeSnapshot(ctx, namespace, volumeName, snapshotId)
+	if err != nil {
+		return err
+	}
+
+	if volume.AttachedMachine != "" {
+		return errdefs.NewFailedPrecondition("volume is attached to machine " + volume.AttachedMachine)
+	}
+
+	if err := r.o.RestoreVolumeSnapshot(ctx, volume.Node, volume.Id, snapshot.Id); err != nil {
+		return err
+	}
+
+	return r.State.DeleteVolumeSnapshotsAfter(context.Background(), volume.Id, snapshot.CreatedAt)
+}
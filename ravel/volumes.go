@@ -0,0 +1,218 @@
+package ravel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/internal/id"
+)
+
+const minVolumeSizeMB = 64
+
+type Volume = api.Volume
+
+func (r *Ravel) CreateVolume(ctx context.Context, namespace string, options api.CreateVolumePayload) (Volume, error) {
+	if err := validateObjectName(options.Name); err != nil {
+		return Volume{}, errdefs.NewInvalidArgument(err.Error())
+	}
+
+	if _, err := r.State.GetNamespace(ctx, namespace); err != nil {
+		return Volume{}, err
+	}
+
+	var source *cluster.VolumeSnapshotRef
+	if options.Snapshot != "" {
+		snapshot, err := r.State.GetVolumeSnapshot(ctx, namespace, options.Snapshot)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return Volume{}, errdefs.NewInvalidArgument("volume snapshot not found: " + options.Snapshot)
+			}
+			return Volume{}, err
+		}
+
+		sourceVolume, err := r.State.GetVolumeById(ctx, namespace, snapshot.VolumeId)
+		if err != nil {
+			return Volume{}, err
+		}
+
+		if options.Node != "" && options.Node != sourceVolume.Node {
+			return Volume{}, errdefs.NewInvalidArgument("a volume created from a snapshot is on the node of the snapshot: " + sourceVolume.Node)
+		}
+
+		options.Node = sourceVolume.Node
+		options.SizeMB = sourceVolume.SizeMB
+		source = &cluster.VolumeSnapshotRef{VolumeId: sourceVolume.Id, SnapshotId: snapshot.Id}
+	}
+
+	if options.SizeMB < minVolumeSizeMB {
+		return Volume{}, errdefs.NewInvalidArgument(fmt.Sprintf("size_mb must be at least %d", minVolumeSizeMB))
+	}
+
+	region, node, err := r.pickVolumeNode(ctx, options.Region, options.Node)
+	if err != nil {
+		return Volume{}, err
+	}
+
+	volume := Volume{
+		Id:        id.GeneratePrefixed("vol"),
+		Name:      options.Name,
+		Namespace: namespace,
+		Region:    region,
+		Node:      node,
+		SizeMB:    options.SizeMB,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := r.State.CreateVolume(ctx, volume); err != nil {
+		return Volume{}, err
+	}
+
+	ctx = context.Background() // the volume record must not outlive its disk
+
+	err = r.o.CreateVolume(ctx, node, cluster.CreateVolumeOptions{Id: volume.Id, SizeMB: volume.SizeMB, FromSnapshot: source})
+	if err != nil {
+		if err := r.State.DeleteVolume(ctx, volume.Id); err != nil {
+			slog.Error("failed to delete volume", "volume", volume.Id, "error", err)
+		}
+		return Volume{}, err
+	}
+
+	return volume, nil
+}
+
+// pickVolumeNode checks the requested node or picks the node of the region holding the fewest volumes.
+func (r *Ravel) pickVolumeNode(ctx context.Context, region, node string) (string, string, error) {
+	if node != "" {
+		n, err := r.o.GetNode(ctx, node)
+		if err != nil {
+			return "", "", errdefs.NewInvalidArgument("unknown node: " + node)
+		}
+
+		if region != "" && n.Region != region {
+			return "", "", errdefs.NewInvalidArgument(fmt.Sprintf("node %s is not in region %s", node, region))
+		}
+
+		return n.Region, n.Id, nil
+	}
+
+	if region == "" {
+		return "", "", errdefs.NewInvalidArgument("region or node is required")
+	}
+
+	nodes, err := r.o.ListNodesInRegion(ctx, region)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(nodes) == 0 {
+		return "", "", errdefs.NewResourcesExhausted("no node available in region " + region)
+	}
+
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.Id
+	}
+
+	counts, err := r.State.CountNodesVolumes(ctx, ids)
+	if err != nil {
+		return "", "", err
+	}
+
+	picked := ids[0]
+	for _, id := range ids[1:] {
+		if counts[id] < counts[picked] {
+			picked = id
+		}
+	}
+
+	return region, picked, nil
+}
+
+func (r *Ravel) GetVolume(ctx context.Context, namespace, name string) (Volume, error) {
+	return r.State.GetVolume(ctx, namespace, name)
+}
+
+func (r *Ravel) ListVolumes(ctx context.Context, namespace string) ([]Volume, error) {
+	return r.State.ListVolumes(ctx, namespace)
+}
+
+// ResizeVolume grows a volume and its filesystem, the machine mounting it keeps running.
+func (r *Ravel) ResizeVolume(ctx context.Context, namespace, name string, options api.ResizeVolumePayload) (Volume, error) {
+	volume, err := r.State.GetVolume(ctx, namespace, name)
+	if err != nil {
+		return Volume{}, err
+	}
+
+	if options.SizeMB <= volume.SizeMB {
+		return Volume{}, errdefs.NewInvalidArgument(fmt.Sprintf("size_mb must be greater than the current size (%d)", volume.SizeMB))
+	}
+
+	if err := r.o.ResizeVolume(ctx, volume.Node, volume.Id, options.SizeMB); err != nil {
+		return Volume{}, err
+	}
+
+	if err := r.State.UpdateVolumeSize(context.Background(), volume.Id, options.SizeMB); err != nil {
+		return Volume{}, err
+	}
+
+	volume.SizeMB = options.SizeMB
+	return volume, nil
+}
+
+func (r *Ravel) DeleteVolume(ctx context.Context, namespace, name string) error {
+	volume, err := r.State.GetVolume(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if volume.AttachedMachine != "" {
+		return errdefs.NewFailedPrecondition("volume is attached to machine " + volume.AttachedMachine)
+	}
+
+	return r.State.DeleteDetachedVolume(ctx, volume.Id, func() error {
+		err := r.o.DestroyVolume(ctx, volume.Node, volume.Id)
+		if err != nil && !errdefs.IsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// resolveVolumes fills the ids of the volumes mounted by a new machine and returns the node
+// holding them, the machine must be placed on it. The region is checked against the volumes one.
+func (r *Ravel) resolveVolumes(ctx context.Context, namespace string, region *string, mounts []api.VolumeMount) (string, error) {
+	var node string
+	for i, m := range mounts {
+		volume, err := r.State.GetVolume(ctx, namespace, m.Name)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return "", errdefs.NewInvalidArgument("volume not found: " + m.Name)
+			}
+			return "", err
+		}
+
+		if volume.AttachedMachine != "" {
+			return "", errdefs.NewFailedPrecondition(fmt.Sprintf("volume %s is already attached to machine %s", m.Name, volume.AttachedMachine))
+		}
+
+		if node != "" && volume.Node != node {
+			return "", errdefs.NewInvalidArgument("the volumes of a machine must be on the same node")
+		}
+		node = volume.Node
+
+		if *region == "" {
+			*region = volume.Region
+		} else if *region != volume.Region {
+			return "", errdefs.NewInvalidArgument(fmt.Sprintf("volume %s is in region %s", m.Name, volume.Region))
+		}
+
+		mounts[i].Volume = volume.Id
+	}
+
+	return node, nil
+}
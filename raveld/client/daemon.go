@@ -7,7 +7,6 @@ import (
 	"net"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
@@ -76,6 +75,20 @@ func (a *DaemonClient) InstanceExec(ctx context.Context, id string, cmd []string
 	return &result, nil
 }
 
+// InstanceExecStream implements daemon.Daemon.
+func (a *DaemonClient) InstanceExecStream(ctx context.Context, id string, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	return a.client.RawPost(ctx, "/instances/"+id+"/exec/stream", httpclient.WithJSONBody(req))
+}
+
+// InstanceExecSession implements daemon.Daemon.
+func (a *DaemonClient) InstanceExecSession(ctx context.Context, id string) (io.ReadWriteCloser, error) {
+	ws, err := a.client.DialWebSocket(ctx, "/instances/"+id+"/exec/tty")
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
 func (a *DaemonClient) ListInstances(ctx context.Context) ([]instance.Instance, error) {
 	var instances []instance.Instance
 	err := a.client.Get(ctx, "/instances", &instances)
@@ -97,11 +110,7 @@ func (a *DaemonClient) StartInstance(ctx context.Context, id string) error {
 
 // StartInstanceFromSnapshot starts an instance by restoring from a snapshot.
 // This enables sub-100ms cold starts for AI sandbox workloads.
-// Note: The API only needs snapshotId, the server calculates the paths
-func (a *DaemonClient) StartInstanceFromSnapshot(ctx context.Context, id string, globalSnapshotPath, jailSnapshotPath string) error {
-	// Extract snapshot ID from the path (last component)
-	parts := strings.Split(globalSnapshotPath, "/")
-	snapshotId := parts[len(parts)-1]
+func (a *DaemonClient) StartInstanceFromSnapshot(ctx context.Context, id string, snapshotId string) error {
 	body := struct {
 		SnapshotId string `json:"snapshot_id"`
 	}{SnapshotId: snapshotId}
@@ -117,9 +126,14 @@ func (a *DaemonClient) StopInstance(ctx context.Context, id string, opt *api.Sto
 	return nil
 }
 
-func (a *DaemonClient) GetInstanceLogs(ctx context.Context, id string) ([]*api.LogEntry, error) {
+func (a *DaemonClient) GetInstanceLogs(ctx context.Context, id string, opts api.LogsOptions) ([]*api.LogEntry, error) {
+	path := "/instances/" + id + "/logs"
+	if query := opts.Values().Encode(); query != "" {
+		path += "?" + query
+	}
+
 	var logs []*api.LogEntry
-	err := a.client.Get(ctx, "/instances/"+id+"/logs", &logs)
+	err := a.client.Get(ctx, path, &logs)
 	if err != nil {
 		return nil, err
 	}
@@ -164,6 +178,18 @@ func (c *DaemonClient) PullImage(ctx context.Context, opts daemon.ImagePullOptio
 	return &image, err
 }
 
+func (c *DaemonClient) PruneImages(ctx context.Context) (*daemon.ImagePruneResult, error) {
+	var result daemon.ImagePruneResult
+	err := c.client.Post(ctx, "/images/prune", &result)
+	return &result, err
+}
+
+func (c *DaemonClient) ImageGCStats(ctx context.Context) (*daemon.ImageGCStats, error) {
+	var stats daemon.ImageGCStats
+	err := c.client.Get(ctx, "/images/gc", &stats)
+	return &stats, err
+}
+
 // CreateDisk implements daemon.Daemon.
 func (a *DaemonClient) CreateDisk(ctx context.Context, opt daemon.DiskOptions) (*disks.Disk, error) {
 	var disk disks.Disk
@@ -204,18 +230,68 @@ func (a *DaemonClient) ListDisks(ctx context.Context) ([]disks.Disk, error) {
 	return disksList, nil
 }
 
+// ResizeDisk implements daemon.Daemon.
+func (a *DaemonClient) ResizeDisk(ctx context.Context, id string, opt daemon.ResizeDiskOptions) (*disks.Disk, error) {
+	var disk disks.Disk
+	err := a.client.Patch(ctx, "/disks/"+id, &disk, httpclient.WithJSONBody(&opt))
+	if err != nil {
+		return nil, err
+	}
+	return &disk, nil
+}
+
+// CreateDiskSnapshot implements daemon.Daemon.
+func (a *DaemonClient) CreateDiskSnapshot(ctx context.Context, disk string, opt daemon.DiskSnapshotOptions) (*disks.DiskSnapshot, error) {
+	var snapshot disks.DiskSnapshot
+	err := a.client.Post(ctx, "/disks/"+disk+"/snapshots", &snapshot, httpclient.WithJSONBody(&opt))
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// ListDiskSnapshots implements daemon.Daemon.
+func (a *DaemonClient) ListDiskSnapshots(ctx context.Context, disk string) ([]disks.DiskSnapshot, error) {
+	var snapshots []disks.DiskSnapshot
+	err := a.client.Get(ctx, "/disks/"+disk+"/snapshots", &snapshots)
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// DeleteDiskSnapshot implements daemon.Daemon.
+func (a *DaemonClient) DeleteDiskSnapshot(ctx context.Context, disk string, id string) error {
+	return a.client.Delete(ctx, "/disks/"+disk+"/snapshots/"+id)
+}
+
+// RestoreDiskSnapshot implements daemon.Daemon.
+func (a *DaemonClient) RestoreDiskSnapshot(ctx context.Context, disk string, id string) error {
+	return a.client.Post(ctx, "/disks/"+disk+"/snapshots/"+id+"/restore", nil)
+}
+
 // InstanceSnapshot creates a snapshot of a running instance for fast restore.
-func (a *DaemonClient) InstanceSnapshot(ctx context.Context, id string, path string) error {
+func (a *DaemonClient) InstanceSnapshot(ctx context.Context, id string, snapshotId string) (*daemon.InstanceSnapshot, error) {
 	body := struct {
 		SnapshotId string `json:"snapshot_id"`
-	}{SnapshotId: path}
-	return a.client.Post(ctx, "/instances/"+id+"/snapshot", nil, httpclient.WithJSONBody(&body))
+	}{SnapshotId: snapshotId}
+	var snapshot daemon.InstanceSnapshot
+	err := a.client.Post(ctx, "/instances/"+id+"/snapshot", &snapshot, httpclient.WithJSONBody(&body))
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
 }
 
 // InstanceRestore restores an instance from a snapshot.
-func (a *DaemonClient) InstanceRestore(ctx context.Context, id string, path string) error {
+func (a *DaemonClient) InstanceRestore(ctx context.Context, id string, snapshotId string) error {
 	body := struct {
 		SnapshotId string `json:"snapshot_id"`
-	}{SnapshotId: path}
+	}{SnapshotId: snapshotId}
 	return a.client.Post(ctx, "/instances/"+id+"/restore", nil, httpclient.WithJSONBody(&body))
 }
+
+// DeleteInstanceSnapshot deletes an instance snapshot from the node.
+func (a *DaemonClient) DeleteInstanceSnapshot(ctx context.Context, snapshotId string) error {
+	return a.client.Delete(ctx, "/snapshots/"+snapshotId)
+}
@@ -126,4 +126,5 @@ func (d *Daemon) Run(runCtx context.Context) {
 	}()
 
 	waitGroup.Wait()
+	d.runtime.Stop()
 }
@@ -2,6 +2,7 @@ package raveld
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
@@ -25,6 +26,15 @@ func (a *Daemon) PullImage(ctx context.Context, opts daemon.ImagePullOptions) (*
 	return a.runtime.PullImage(ctx, opts)
 }
 
+func (a *Daemon) PruneImages(ctx context.Context) (*daemon.ImagePruneResult, error) {
+	return a.runtime.PruneImages(ctx)
+}
+
+func (a *Daemon) ImageGCStats(ctx context.Context) (*daemon.ImageGCStats, error) {
+	stats := a.runtime.ImageGCStats()
+	return &stats, nil
+}
+
 func (a *Daemon) CreateInstance(ctx context.Context, opt daemon.InstanceOptions) (*instance.Instance, error) {
 	err := opt.Validate()
 	if err != nil {
@@ -49,8 +59,8 @@ func (s *Daemon) StartInstance(ctx context.Context, id string) error {
 
 // StartInstanceFromSnapshot starts an instance by restoring from a snapshot.
 // This enables sub-100ms cold starts for AI sandbox workloads.
-func (s *Daemon) StartInstanceFromSnapshot(ctx context.Context, id string, globalSnapshotPath, jailSnapshotPath string) error {
-	return s.runtime.StartInstanceFromSnapshot(ctx, id, globalSnapshotPath, jailSnapshotPath)
+func (s *Daemon) StartInstanceFromSnapshot(ctx context.Context, id string, snapshotId string) error {
+	return s.runtime.StartInstanceFromSnapshot(ctx, id, snapshotId)
 }
 
 func (s *Daemon) DestroyInstance(ctx context.Context, id string) error {
@@ -79,6 +89,14 @@ func (s *Daemon) StopInstance(ctx context.Context, id string, opt *api.StopConfi
 	return s.runtime.StopInstance(ctx, id, opt)
 }
 
+func (s *Daemon) InstanceExecStream(ctx context.Context, id string, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	return s.runtime.InstanceExecStream(ctx, id, req)
+}
+
+func (s *Daemon) InstanceExecSession(ctx context.Context, id string) (io.ReadWriteCloser, error) {
+	return s.runtime.InstanceExecSession(ctx, id)
+}
+
 func (s *Daemon) InstanceExec(ctx context.Context, id string, cmd []string, timeout time.Duration) (*api.ExecResult, error) {
 	return s.runtime.InstanceExec(ctx, id, cmd, timeout)
 }
@@ -95,11 +113,14 @@ func (a *Daemon) SubscribeToInstanceLogs(ctx context.Context, id string) ([]*api
 	return a.runtime.SubscribeToInstanceLogs(ctx, id)
 }
 
-func (a *Daemon) GetInstanceLogs(ctx context.Context, id string) ([]*api.LogEntry, error) {
-	return a.runtime.GetInstanceLogs(id)
+func (a *Daemon) GetInstanceLogs(ctx context.Context, id string, opts api.LogsOptions) ([]*api.LogEntry, error) {
+	return a.runtime.GetInstanceLogs(id, opts)
 }
 
 func (a *Daemon) CreateDisk(ctx context.Context, opts daemon.DiskOptions) (*disks.Disk, error) {
+	if opts.FromSnapshot != nil {
+		return a.runtime.CreateDiskFromSnapshot(opts.Id, opts.FromSnapshot.Disk, opts.FromSnapshot.Snapshot)
+	}
 	return a.runtime.CreateDisk(ctx, opts.Id, opts.SizeMB)
 }
 
@@ -115,12 +136,36 @@ func (a *Daemon) DestroyDisk(ctx context.Context, id string) error {
 	return a.runtime.DestroyDisk(id)
 }
 
+func (a *Daemon) ResizeDisk(ctx context.Context, id string, opts daemon.ResizeDiskOptions) (*disks.Disk, error) {
+	return a.runtime.ResizeDisk(ctx, id, opts.SizeMB)
+}
+
+func (a *Daemon) CreateDiskSnapshot(ctx context.Context, disk string, opts daemon.DiskSnapshotOptions) (*disks.DiskSnapshot, error) {
+	return a.runtime.CreateDiskSnapshot(disk, opts.Id)
+}
+
+func (a *Daemon) ListDiskSnapshots(ctx context.Context, disk string) ([]disks.DiskSnapshot, error) {
+	return a.runtime.ListDiskSnapshots(disk)
+}
+
+func (a *Daemon) DeleteDiskSnapshot(ctx context.Context, disk string, id string) error {
+	return a.runtime.DeleteDiskSnapshot(disk, id)
+}
+
+func (a *Daemon) RestoreDiskSnapshot(ctx context.Context, disk string, id string) error {
+	return a.runtime.RestoreDiskSnapshot(disk, id)
+}
+
 // InstanceSnapshot saves the VM state for fast restore.
-func (a *Daemon) InstanceSnapshot(ctx context.Context, id string, path string) error {
-	return a.runtime.InstanceSnapshot(ctx, id, path)
+func (a *Daemon) InstanceSnapshot(ctx context.Context, id string, snapshotId string) (*daemon.InstanceSnapshot, error) {
+	return a.runtime.InstanceSnapshot(ctx, id, snapshotId)
 }
 
 // InstanceRestore restores the VM from a previously saved snapshot.
-func (a *Daemon) InstanceRestore(ctx context.Context, id string, path string) error {
-	return a.runtime.InstanceRestore(ctx, id, path)
+func (a *Daemon) InstanceRestore(ctx context.Context, id string, snapshotId string) error {
+	return a.runtime.InstanceRestore(ctx, id, snapshotId)
+}
+
+func (a *Daemon) DeleteInstanceSnapshot(ctx context.Context, snapshotId string) error {
+	return a.runtime.DeleteInstanceSnapshot(ctx, snapshotId)
 }
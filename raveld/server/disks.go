@@ -72,3 +72,83 @@ func (s *DaemonServer) destroyDisk(ctx context.Context, r *DestroyDiskRequest) (
 	}
 	return &DestroyDiskResponse{}, nil
 }
+
+type ResizeDiskRequest struct {
+	Id   string `path:"id"`
+	Body daemon.ResizeDiskOptions
+}
+
+type ResizeDiskResponse struct {
+	Body disks.Disk
+}
+
+func (s *DaemonServer) resizeDisk(ctx context.Context, r *ResizeDiskRequest) (*ResizeDiskResponse, error) {
+	disk, err := s.daemon.ResizeDisk(ctx, r.Id, r.Body)
+	if err != nil {
+		s.log("error resizing disk: %v", err)
+		return nil, err
+	}
+	return &ResizeDiskResponse{Body: *disk}, nil
+}
+
+type CreateDiskSnapshotRequest struct {
+	Id   string `path:"id"`
+	Body daemon.DiskSnapshotOptions
+}
+
+type CreateDiskSnapshotResponse struct {
+	Body disks.DiskSnapshot
+}
+
+func (s *DaemonServer) createDiskSnapshot(ctx context.Context, r *CreateDiskSnapshotRequest) (*CreateDiskSnapshotResponse, error) {
+	snapshot, err := s.daemon.CreateDiskSnapshot(ctx, r.Id, r.Body)
+	if err != nil {
+		s.log("error creating disk snapshot: %v", err)
+		return nil, err
+	}
+	return &CreateDiskSnapshotResponse{Body: *snapshot}, nil
+}
+
+type ListDiskSnapshotsRequest struct {
+	Id string `path:"id"`
+}
+
+type ListDiskSnapshotsResponse struct {
+	Body []disks.DiskSnapshot
+}
+
+func (s *DaemonServer) listDiskSnapshots(ctx context.Context, r *ListDiskSnapshotsRequest) (*ListDiskSnapshotsResponse, error) {
+	snapshots, err := s.daemon.ListDiskSnapshots(ctx, r.Id)
+	if err != nil {
+		s.log("error listing disk snapshots: %v", err)
+		return nil, err
+	}
+	return &ListDiskSnapshotsResponse{Body: snapshots}, nil
+}
+
+type DiskSnapshotRequest struct {
+	Id       string `path:"id"`
+	Snapshot string `path:"snapshot"`
+}
+
+type DeleteDiskSnapshotResponse struct{}
+
+func (s *DaemonServer) deleteDiskSnapshot(ctx context.Context, r *DiskSnapshotRequest) (*DeleteDiskSnapshotResponse, error) {
+	err := s.daemon.DeleteDiskSnapshot(ctx, r.Id, r.Snapshot)
+	if err != nil {
+		s.log("error deleting disk snapshot: %v", err)
+		return nil, err
+	}
+	return &DeleteDiskSnapshotResponse{}, nil
+}
+
+type RestoreDiskSnapshotResponse struct{}
+
+func (s *DaemonServer) restoreDiskSnapshot(ctx context.Context, r *DiskSnapshotRequest) (*RestoreDiskSnapshotResponse, error) {
+	err := s.daemon.RestoreDiskSnapshot(ctx, r.Id, r.Snapshot)
+	if err != nil {
+		s.log("error restoring disk snapshot: %v", err)
+		return nil, err
+	}
+	return &RestoreDiskSnapshotResponse{}, nil
+}
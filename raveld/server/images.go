@@ -59,3 +59,35 @@ func (s *DaemonServer) deleteImage(ctx context.Context, req *DeleteImageRequest)
 	}
 	return &DeleteImageResponse{}, nil
 }
+
+type PruneImagesRequest struct {
+}
+
+type PruneImagesResponse struct {
+	Body *daemon.ImagePruneResult
+}
+
+func (s *DaemonServer) pruneImages(ctx context.Context, req *PruneImagesRequest) (*PruneImagesResponse, error) {
+	res, err := s.daemon.PruneImages(ctx)
+	if err != nil {
+		s.log("Failed to prune images", err)
+		return nil, err
+	}
+	return &PruneImagesResponse{Body: res}, nil
+}
+
+type GetImageGCStatsRequest struct {
+}
+
+type GetImageGCStatsResponse struct {
+	Body *daemon.ImageGCStats
+}
+
+func (s *DaemonServer) getImageGCStats(ctx context.Context, req *GetImageGCStatsRequest) (*GetImageGCStatsResponse, error) {
+	res, err := s.daemon.ImageGCStats(ctx)
+	if err != nil {
+		s.log("Failed to get image gc stats", err)
+		return nil, err
+	}
+	return &GetImageGCStatsResponse{Body: res}, nil
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
@@ -9,6 +10,7 @@ import (
 	"github.com/alexisbouchez/ravel/core/instance"
 	"github.com/alexisbouchez/ravel/internal/streamutil"
 	"github.com/danielgtaylor/huma/v2"
+	"golang.org/x/net/websocket"
 )
 
 type CreateInstanceRequest struct {
@@ -105,10 +107,7 @@ type StartInstanceFromSnapshotResponse struct {
 }
 
 func (s *DaemonServer) startInstanceFromSnapshot(ctx context.Context, req *StartInstanceFromSnapshotRequest) (*StartInstanceFromSnapshotResponse, error) {
-	// Global snapshot path and jail-relative path
-	globalSnapshotPath := "/var/lib/ravel/global-snapshots/" + req.Id + "/" + req.Body.SnapshotId
-	jailSnapshotPath := "/snapshots/" + req.Body.SnapshotId
-	err := s.daemon.StartInstanceFromSnapshot(ctx, req.Id, globalSnapshotPath, jailSnapshotPath)
+	err := s.daemon.StartInstanceFromSnapshot(ctx, req.Id, req.Body.SnapshotId)
 	if err != nil {
 		s.log("Failed to start instance from snapshot", err)
 		return nil, err
@@ -156,6 +155,45 @@ func (s *DaemonServer) exec(ctx context.Context, req *ExecRequest) (*ExecRespons
 	return &ExecResponse{Body: res}, nil
 }
 
+type ExecStreamRequest struct {
+	Id   string `path:"id"`
+	Body api.ExecStreamRequest
+}
+
+func (s *DaemonServer) execStream(ctx context.Context, req *ExecStreamRequest) (*huma.StreamResponse, error) {
+	output, err := s.daemon.InstanceExecStream(ctx, req.Id, req.Body)
+	if err != nil {
+		s.log("Failed to stream exec", err)
+		return nil, err
+	}
+
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			streamutil.CopyLines(ctx, output)
+		},
+	}, nil
+}
+
+type ExecSessionRequest struct {
+	Id string `path:"id"`
+}
+
+func (s *DaemonServer) execSession(ctx context.Context, req *ExecSessionRequest) (*huma.StreamResponse, error) {
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			streamutil.ServeWebSocket(ctx, func(ws *websocket.Conn) {
+				streamutil.RelayExecSession(ws, func() (io.ReadWriteCloser, error) {
+					session, err := s.daemon.InstanceExecSession(ctx.Context(), req.Id)
+					if err != nil {
+						s.log("Failed to open exec session", err)
+					}
+					return session, err
+				})
+			})
+		},
+	}, nil
+}
+
 type FollowInstanceLogsRequest struct {
 	Id string `path:"id"`
 }
@@ -176,6 +214,7 @@ func (s *DaemonServer) followInstanceLogs(ctx context.Context, req *GetInstanceL
 type GetInstanceLogsRequest struct {
 	Id     string `path:"id"`
 	Follow bool   `query:"follow"`
+	api.LogsOptions
 }
 
 type GetInstanceLogsResponse struct {
@@ -183,7 +222,7 @@ type GetInstanceLogsResponse struct {
 }
 
 func (s *DaemonServer) getInstanceLogs(ctx context.Context, req *GetInstanceLogsRequest) (*GetInstanceLogsResponse, error) {
-	logs, err := s.daemon.GetInstanceLogs(ctx, req.Id)
+	logs, err := s.daemon.GetInstanceLogs(ctx, req.Id, req.LogsOptions)
 	if err != nil {
 		s.log("Failed to get instance logs", err)
 		return nil, err
@@ -202,31 +241,17 @@ type InstanceSnapshotRequest struct {
 }
 
 type InstanceSnapshotResponse struct {
-	Body struct {
-		SnapshotId string `json:"snapshot_id"`
-		Path       string `json:"path"`
-	}
+	Body daemon.InstanceSnapshot
 }
 
 func (s *DaemonServer) instanceSnapshot(ctx context.Context, req *InstanceSnapshotRequest) (*InstanceSnapshotResponse, error) {
-	// Snapshot path relative to jail chroot - CloudHypervisor runs inside the jail
-	// so /snapshots becomes /var/lib/ravel/instances/{id}/snapshots on host
-	jailRelativePath := "/snapshots/" + req.Body.SnapshotId
-	err := s.daemon.InstanceSnapshot(ctx, req.Id, jailRelativePath)
+	snapshot, err := s.daemon.InstanceSnapshot(ctx, req.Id, req.Body.SnapshotId)
 	if err != nil {
 		s.log("Failed to snapshot instance", err)
 		return nil, err
 	}
 
-	return &InstanceSnapshotResponse{
-		Body: struct {
-			SnapshotId string `json:"snapshot_id"`
-			Path       string `json:"path"`
-		}{
-			SnapshotId: req.Body.SnapshotId,
-			Path:       jailRelativePath,
-		},
-	}, nil
+	return &InstanceSnapshotResponse{Body: *snapshot}, nil
 }
 
 type InstanceRestoreRequest struct {
@@ -240,9 +265,7 @@ type InstanceRestoreResponse struct {
 }
 
 func (s *DaemonServer) instanceRestore(ctx context.Context, req *InstanceRestoreRequest) (*InstanceRestoreResponse, error) {
-	// Snapshot path relative to jail chroot
-	jailRelativePath := "/snapshots/" + req.Body.SnapshotId
-	err := s.daemon.InstanceRestore(ctx, req.Id, jailRelativePath)
+	err := s.daemon.InstanceRestore(ctx, req.Id, req.Body.SnapshotId)
 	if err != nil {
 		s.log("Failed to restore instance", err)
 		return nil, err
@@ -250,3 +273,20 @@ func (s *DaemonServer) instanceRestore(ctx context.Context, req *InstanceRestore
 
 	return &InstanceRestoreResponse{}, nil
 }
+
+type DeleteInstanceSnapshotRequest struct {
+	Id string `path:"id"`
+}
+
+type DeleteInstanceSnapshotResponse struct {
+}
+
+func (s *DaemonServer) deleteInstanceSnapshot(ctx context.Context, req *DeleteInstanceSnapshotRequest) (*DeleteInstanceSnapshotResponse, error) {
+	err := s.daemon.DeleteInstanceSnapshot(ctx, req.Id)
+	if err != nil {
+		s.log("Failed to delete instance snapshot", err)
+		return nil, err
+	}
+
+	return &DeleteInstanceSnapshotResponse{}, nil
+}
@@ -81,6 +81,18 @@ func (s DaemonServer) registerEndpoints(mux humago.Mux) {
 		Method:      http.MethodPost,
 	}, s.exec)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "execStream",
+		Path:        "/instances/{id}/exec/stream",
+		Method:      http.MethodPost,
+	}, s.execStream)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "execSession",
+		Path:        "/instances/{id}/exec/tty",
+		Method:      http.MethodGet,
+	}, s.execSession)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "getInstanceLogs",
 		Path:        "/instances/{id}/logs",
@@ -105,6 +117,18 @@ func (s DaemonServer) registerEndpoints(mux humago.Mux) {
 		Method:      http.MethodPost,
 	}, s.pullImage)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "pruneImages",
+		Path:        "/images/prune",
+		Method:      http.MethodPost,
+	}, s.pruneImages)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "getImageGCStats",
+		Path:        "/images/gc",
+		Method:      http.MethodGet,
+	}, s.getImageGCStats)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "deleteImage",
 		Path:        "/images/{ref}",
@@ -135,6 +159,36 @@ func (s DaemonServer) registerEndpoints(mux humago.Mux) {
 		Method:      http.MethodDelete,
 	}, s.destroyDisk)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "resizeDisk",
+		Path:        "/disks/{id}",
+		Method:      http.MethodPatch,
+	}, s.resizeDisk)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "createDiskSnapshot",
+		Path:        "/disks/{id}/snapshots",
+		Method:      http.MethodPost,
+	}, s.createDiskSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "listDiskSnapshots",
+		Path:        "/disks/{id}/snapshots",
+		Method:      http.MethodGet,
+	}, s.listDiskSnapshots)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteDiskSnapshot",
+		Path:        "/disks/{id}/snapshots/{snapshot}",
+		Method:      http.MethodDelete,
+	}, s.deleteDiskSnapshot)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "restoreDiskSnapshot",
+		Path:        "/disks/{id}/snapshots/{snapshot}/restore",
+		Method:      http.MethodPost,
+	}, s.restoreDiskSnapshot)
+
 	// Snapshot/Restore endpoints for AI sandbox fast starts
 	huma.Register(api, huma.Operation{
 		OperationID: "instanceSnapshot",
@@ -151,4 +205,12 @@ func (s DaemonServer) registerEndpoints(mux humago.Mux) {
 		Summary:     "Restore an instance from a snapshot",
 		Tags:        []string{"sandbox"},
 	}, s.instanceRestore)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "deleteInstanceSnapshot",
+		Path:        "/snapshots/{id}",
+		Method:      http.MethodDelete,
+		Summary:     "Delete an instance snapshot",
+		Tags:        []string{"sandbox"},
+	}, s.deleteInstanceSnapshot)
 }
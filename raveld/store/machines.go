@@ -6,6 +6,7 @@ import (
 	"github.com/alexisbouchez/ravel/agent/structs"
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/cluster"
 	"go.etcd.io/bbolt"
 )
 
@@ -168,6 +169,42 @@ func (s *Store) UpdateMachineInstance(id string, mi *structs.MachineInstanceStat
 	return tx.Commit()
 }
 
+func (s *Store) UpdateMachineInstanceVersion(id string, m cluster.Machine, mv api.MachineVersion) error {
+	tx, err := s.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	machineInstances := tx.Bucket(machineInstancesBucket)
+	assertMachineInstancesBucketExists(machineInstances)
+
+	machine := machineInstances.Bucket([]byte(id))
+	if machine == nil {
+		return errdefs.NewNotFound("machine not found")
+	}
+
+	mBytes, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	mvBytes, err := json.Marshal(mv)
+	if err != nil {
+		return err
+	}
+
+	if err = machine.Put([]byte(machineInstanceMachineKey), mBytes); err != nil {
+		return err
+	}
+
+	if err = machine.Put([]byte(machineInstanceVersionKey), mvBytes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (s *Store) DeleteMachineInstance(id string) error {
 	tx, err := s.db.Begin(true)
 	if err != nil {
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"encoding/json"
+)
+
+var cordonedKey = []byte("cordoned")
+
+func (s *Store) LoadNodeCordoned() (bool, error) {
+	tx, err := s.db.Begin(false)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	node := tx.Bucket(nodeBucket)
+	if node == nil {
+		return false, ErrBucketNotFound
+	}
+
+	v := node.Get(cordonedKey)
+	if v == nil {
+		return false, nil
+	}
+
+	var cordoned bool
+	if err := json.Unmarshal(v, &cordoned); err != nil {
+		return false, err
+	}
+
+	return cordoned, nil
+}
+
+func (s *Store) PutNodeCordoned(cordoned bool) error {
+	tx, err := s.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	node := tx.Bucket(nodeBucket)
+	if node == nil {
+		return ErrBucketNotFound
+	}
+
+	bytes, err := json.Marshal(cordoned)
+	if err != nil {
+		return err
+	}
+
+	if err := node.Put(cordonedKey, bytes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
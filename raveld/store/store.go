@@ -16,6 +16,8 @@ allocations/
 	  <allocation_id> -> structs.Reservation
 events/
 	  <event_id> -> core.InstanceEvent
+node/
+	  cordoned -> bool
 **/
 
 var (
@@ -24,6 +26,7 @@ var (
 	allocationsBucket      = []byte("allocations")
 	eventsBucket           = []byte("events")
 	disksBucket            = []byte("disks")
+	nodeBucket             = []byte("node")
 )
 
 func NewStore(path string) (*Store, error) {
@@ -66,6 +69,11 @@ func (s *Store) Init() error {
 		return err
 	}
 
+	_, err = tx.CreateBucketIfNotExists(nodeBucket)
+	if err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
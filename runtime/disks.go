@@ -2,8 +2,13 @@ package runtime
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
 
 	"github.com/alexisbouchez/ravel/runtime/disks"
+	"github.com/alexisbouchez/ravel/runtime/instancerunner"
 )
 
 func (r *Runtime) GetDisk(id string) (*disks.Disk, error) {
@@ -21,3 +26,83 @@ func (r *Runtime) DestroyDisk(id string) error {
 func (r *Runtime) CreateDisk(ctx context.Context, id string, sizeMB uint64) (*disks.Disk, error) {
 	return r.disks.CreateDisk(ctx, id, sizeMB)
 }
+
+// ResizeDisk grows a disk and its filesystem, online if the disk is mounted by a running instance.
+func (r *Runtime) ResizeDisk(ctx context.Context, id string, sizeMB uint64) (*disks.Disk, error) {
+	disk, err := r.disks.ResizeDisk(id, sizeMB)
+	if err != nil {
+		return nil, err
+	}
+
+	if disk.AttachedInstance == "" {
+		err = disks.GrowEXT4(ctx, disk.Path)
+	} else {
+		var ir *instancerunner.InstanceRunner
+		ir, err = r.getInstance(disk.AttachedInstance)
+		if err == nil {
+			err = ir.ResizeDisk(ctx, *disk)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("disk has been resized but not its filesystem: %w", err)
+	}
+
+	return disk, nil
+}
+
+// ImportDisk creates a disk with the content of an image, the raw copy of a disk of another node.
+func (r *Runtime) ImportDisk(ctx context.Context, id string, sizeMB uint64, image string) (*disks.Disk, error) {
+	disk, err := r.disks.CreateDisk(ctx, id, sizeMB)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := copyImage(image, disk.Path); err != nil {
+		if err := r.disks.DestroyDisk(id); err != nil {
+			slog.Error("failed to destroy disk", "disk", id, "error", err)
+		}
+		return nil, fmt.Errorf("failed to copy disk image: %w", err)
+	}
+
+	return disk, nil
+}
+
+func copyImage(image string, device string) error {
+	src, err := os.Open(image)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(device, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+
+	return dst.Sync()
+}
+
+func (r *Runtime) CreateDiskFromSnapshot(id string, diskId string, snapshotId string) (*disks.Disk, error) {
+	return r.disks.CreateDiskFromSnapshot(id, diskId, snapshotId)
+}
+
+func (r *Runtime) CreateDiskSnapshot(diskId string, id string) (*disks.DiskSnapshot, error) {
+	return r.disks.CreateSnapshot(diskId, id)
+}
+
+func (r *Runtime) ListDiskSnapshots(diskId string) ([]disks.DiskSnapshot, error) {
+	return r.disks.ListSnapshots(diskId)
+}
+
+func (r *Runtime) DeleteDiskSnapshot(diskId string, id string) error {
+	return r.disks.DeleteSnapshot(diskId, id)
+}
+
+func (r *Runtime) RestoreDiskSnapshot(diskId string, id string) error {
+	return r.disks.RestoreSnapshot(diskId, id)
+}
@@ -10,11 +10,12 @@ import (
 )
 
 type Disk struct {
-	Id               string    `json:"id"`
-	SizeMB           uint64    `json:"size_mb"`
-	CreatedAt        time.Time `json:"created_at"`
-	AttachedInstance string    `json:"attached_instance"`
-	Path             string    `json:"path"`
+	Id               string         `json:"id"`
+	SizeMB           uint64         `json:"size_mb"`
+	CreatedAt        time.Time      `json:"created_at"`
+	AttachedInstance string         `json:"attached_instance"`
+	Path             string         `json:"path"`
+	Snapshots        []DiskSnapshot `json:"snapshots,omitempty"`
 }
 
 type DiskSnapshot struct {
@@ -165,6 +166,40 @@ func attachInstance(tx DiskTX, disk string, instance string) error {
 	return nil
 }
 
+// ResizeDisk grows a disk device, the filesystem is left to the caller which
+// knows if the disk is mounted by a running instance.
+func (s *Service) ResizeDisk(id string, sizeMB uint64) (*Disk, error) {
+	tx, err := s.store.BeginDiskTX(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	d, err := tx.GetDisk(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sizeMB <= d.SizeMB {
+		return nil, errdefs.NewInvalidArgument("a disk can only grow")
+	}
+
+	if err = s.pool.ResizeDevice(id, sizeMB); err != nil {
+		return nil, err
+	}
+
+	d.SizeMB = sizeMB
+	if err = tx.PutDisk(d); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
 func (s *Service) AttachInstance(instance string, disks ...string) error {
 	if len(disks) == 0 {
 		return nil
@@ -1,6 +1,7 @@
 package disks
 
 import (
+	"io"
 	"testing"
 	"time"
 )
@@ -24,6 +25,10 @@ func (m *mockDevicePool) CreateDevice(id string, sizeMB uint64) (string, error)
 	return path, nil
 }
 
+func (m *mockDevicePool) ResizeDevice(id string, sizeMB uint64) error {
+	return nil
+}
+
 func (m *mockDevicePool) DeleteDevice(id string) error {
 	delete(m.devices, id)
 	return nil
@@ -39,6 +44,22 @@ func (m *mockDevicePool) DeleteSnapshot(id, snapshot string) error {
 	return nil
 }
 
+func (m *mockDevicePool) RollbackSnapshot(id, snapshot string) error {
+	return nil
+}
+
+func (m *mockDevicePool) SendSnapshot(id, snapshot, base string, w io.Writer) error {
+	return nil
+}
+
+func (m *mockDevicePool) ReceiveSnapshot(id string, r io.Reader) (string, error) {
+	return m.CreateDevice(id, 0)
+}
+
+func (m *mockDevicePool) CreateDeviceFromSnapshot(id, source, snapshot string) (string, error) {
+	return m.CreateDevice(id, 0)
+}
+
 // mockStore implements Store for testing
 type mockStore struct {
 	disks map[string]*Disk
@@ -223,6 +244,106 @@ func TestServiceAttachAlreadyAttached(t *testing.T) {
 	}
 }
 
+func TestServiceSnapshots(t *testing.T) {
+	store := newMockStore()
+	pool := newMockDevicePool()
+	svc := NewService(store, pool)
+
+	store.disks["disk1"] = &Disk{Id: "disk1", SizeMB: 1024, CreatedAt: time.Now()}
+
+	for _, id := range []string{"snap1", "snap2", "snap3"} {
+		if _, err := svc.CreateSnapshot("disk1", id); err != nil {
+			t.Fatalf("CreateSnapshot(%s) error = %v", id, err)
+		}
+	}
+
+	if _, err := svc.CreateSnapshot("disk1", "snap1"); err == nil {
+		t.Error("CreateSnapshot() expected error for existing snapshot")
+	}
+
+	if err := svc.DeleteSnapshot("disk1", "snap3"); err != nil {
+		t.Fatalf("DeleteSnapshot() error = %v", err)
+	}
+	if _, ok := pool.snapshots["snap3"]; ok {
+		t.Error("DeleteSnapshot() did not delete the device snapshot")
+	}
+
+	// restoring drops the more recent snapshots
+	if err := svc.RestoreSnapshot("disk1", "snap1"); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+
+	snapshots, err := svc.ListSnapshots("disk1")
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Id != "snap1" {
+		t.Errorf("ListSnapshots() = %v, want only snap1", snapshots)
+	}
+
+	disk, err := svc.CreateDiskFromSnapshot("disk2", "disk1", "snap1")
+	if err != nil {
+		t.Fatalf("CreateDiskFromSnapshot() error = %v", err)
+	}
+	if disk.SizeMB != 1024 {
+		t.Errorf("CreateDiskFromSnapshot() size = %d, want 1024", disk.SizeMB)
+	}
+}
+
+func TestServiceRestoreAttachedDisk(t *testing.T) {
+	store := newMockStore()
+	pool := newMockDevicePool()
+	svc := NewService(store, pool)
+
+	store.disks["disk1"] = &Disk{
+		Id:               "disk1",
+		SizeMB:           1024,
+		CreatedAt:        time.Now(),
+		AttachedInstance: "instance1",
+		Snapshots:        []DiskSnapshot{{Id: "snap1", DiskId: "disk1"}},
+	}
+
+	if err := svc.RestoreSnapshot("disk1", "snap1"); err == nil {
+		t.Error("RestoreSnapshot() expected error for attached disk")
+	}
+
+	if err := svc.RestoreInstanceSnapshot("disk1", "snap1", "instance2"); err == nil {
+		t.Error("RestoreInstanceSnapshot() expected error for a disk attached to another instance")
+	}
+
+	if err := svc.RestoreInstanceSnapshot("disk1", "snap1", "instance1"); err != nil {
+		t.Errorf("RestoreInstanceSnapshot() error = %v", err)
+	}
+}
+
+func TestServiceResizeDisk(t *testing.T) {
+	store := newMockStore()
+	pool := newMockDevicePool()
+	svc := NewService(store, pool)
+
+	store.disks["disk1"] = &Disk{Id: "disk1", SizeMB: 1024, CreatedAt: time.Now()}
+
+	if _, err := svc.ResizeDisk("disk1", 512); err == nil {
+		t.Error("ResizeDisk() expected error when shrinking")
+	}
+
+	if _, err := svc.ResizeDisk("disk1", 1024); err == nil {
+		t.Error("ResizeDisk() expected error for the same size")
+	}
+
+	d, err := svc.ResizeDisk("disk1", 2048)
+	if err != nil {
+		t.Fatalf("ResizeDisk() error = %v", err)
+	}
+	if d.SizeMB != 2048 || store.disks["disk1"].SizeMB != 2048 {
+		t.Errorf("ResizeDisk() size = %d, want 2048", store.disks["disk1"].SizeMB)
+	}
+
+	if _, err := svc.ResizeDisk("unknown", 2048); err == nil {
+		t.Error("ResizeDisk() expected error for unknown disk")
+	}
+}
+
 func TestDiskConfigGetDisks(t *testing.T) {
 	// This would test the InstanceConfig.GetDisks() method
 	// which is in core/instance/instance.go
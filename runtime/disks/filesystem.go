@@ -2,9 +2,24 @@ package disks
 
 import (
 	"context"
+	"fmt"
 	"os/exec"
 )
 
 func MkfsEXT4(ctx context.Context, dev string) error {
 	return exec.CommandContext(ctx, "mkfs.ext4", "-F", dev).Run()
 }
+
+// GrowEXT4 grows the filesystem of an unmounted device to the size of the device.
+func GrowEXT4(ctx context.Context, dev string) error {
+	// resize2fs requires a checked filesystem to resize it offline
+	if err := exec.CommandContext(ctx, "e2fsck", "-f", "-p", dev).Run(); err != nil {
+		return fmt.Errorf("failed to check filesystem: %w", err)
+	}
+
+	if err := exec.CommandContext(ctx, "resize2fs", dev).Run(); err != nil {
+		return fmt.Errorf("failed to resize filesystem: %w", err)
+	}
+
+	return nil
+}
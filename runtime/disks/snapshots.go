@@ -0,0 +1,203 @@
+package disks
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/validation"
+)
+
+func findSnapshot(d *Disk, id string) int {
+	return slices.IndexFunc(d.Snapshots, func(s DiskSnapshot) bool { return s.Id == id })
+}
+
+// CreateSnapshot snapshots a disk. The snapshot of an attached disk is crash consistent,
+// the writes not yet flushed by the guest are not included.
+func (s *Service) CreateSnapshot(diskId string, id string) (*DiskSnapshot, error) {
+	if err := validation.ValidateObjectId(id); err != nil {
+		return nil, errdefs.NewInvalidArgument(err.Error())
+	}
+
+	tx, err := s.store.BeginDiskTX(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	d, err := tx.GetDisk(diskId)
+	if err != nil {
+		return nil, err
+	}
+
+	if findSnapshot(d, id) != -1 {
+		return nil, errdefs.NewAlreadyExists("snapshot already exists")
+	}
+
+	if err = s.pool.Snapshot(diskId, id); err != nil {
+		return nil, err
+	}
+
+	snapshot := DiskSnapshot{
+		Id:        id,
+		DiskId:    diskId,
+		CreatedAt: time.Now(),
+	}
+	d.Snapshots = append(d.Snapshots, snapshot)
+
+	if err = tx.PutDisk(d); err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		if err := s.pool.DeleteSnapshot(diskId, id); err != nil {
+			slog.Error("failed to delete snapshot", "disk", diskId, "snapshot", id, "error", err)
+		}
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (s *Service) ListSnapshots(diskId string) ([]DiskSnapshot, error) {
+	d, err := s.GetDisk(diskId)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Snapshots, nil
+}
+
+func (s *Service) DeleteSnapshot(diskId string, id string) error {
+	tx, err := s.store.BeginDiskTX(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	d, err := tx.GetDisk(diskId)
+	if err != nil {
+		return err
+	}
+
+	i := findSnapshot(d, id)
+	if i == -1 {
+		return errdefs.NewNotFound("snapshot not found")
+	}
+
+	d.Snapshots = slices.Delete(d.Snapshots, i, i+1)
+	if err = tx.PutDisk(d); err != nil {
+		return err
+	}
+
+	if err = s.pool.DeleteSnapshot(diskId, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreSnapshot rolls a detached disk back to a snapshot. The more recent snapshots
+// of the disk are deleted.
+func (s *Service) RestoreSnapshot(diskId string, id string) error {
+	return s.restoreSnapshot(diskId, id, "")
+}
+
+// RestoreInstanceSnapshot rolls a disk attached to an instance back to a snapshot, the instance
+// must not write the disk meanwhile. The more recent snapshots of the disk are deleted.
+func (s *Service) RestoreInstanceSnapshot(diskId string, id string, instance string) error {
+	return s.restoreSnapshot(diskId, id, instance)
+}
+
+func (s *Service) restoreSnapshot(diskId string, id string, instance string) error {
+	tx, err := s.store.BeginDiskTX(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	d, err := tx.GetDisk(diskId)
+	if err != nil {
+		return err
+	}
+
+	if d.AttachedInstance != instance {
+		if instance == "" {
+			return errdefs.NewFailedPrecondition("disk is attached")
+		}
+		return errdefs.NewFailedPrecondition("disk is not attached to the instance")
+	}
+
+	i := findSnapshot(d, id)
+	if i == -1 {
+		return errdefs.NewNotFound("snapshot not found")
+	}
+
+	d.Snapshots = d.Snapshots[:i+1]
+	if err = tx.PutDisk(d); err != nil {
+		return err
+	}
+
+	if err = s.pool.RollbackSnapshot(diskId, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateDiskFromSnapshot creates a new disk holding a copy of a snapshot, it has the
+// size of the snapshotted disk.
+func (s *Service) CreateDiskFromSnapshot(id string, diskId string, snapshotId string) (*Disk, error) {
+	err := validation.ValidateObjectId(id)
+	if err != nil {
+		return nil, errdefs.NewInvalidArgument(err.Error())
+	}
+
+	tx, err := s.store.BeginDiskTX(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.GetDisk(id); err == nil {
+		return nil, errdefs.NewAlreadyExists("disk already exists")
+	}
+
+	source, err := tx.GetDisk(diskId)
+	if err != nil {
+		return nil, err
+	}
+
+	if findSnapshot(source, snapshotId) == -1 {
+		return nil, errdefs.NewNotFound("snapshot not found")
+	}
+
+	path, err := s.pool.CreateDeviceFromSnapshot(id, diskId, snapshotId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if err := s.pool.DeleteDevice(id); err != nil {
+				slog.Error("failed to delete disk", "disk", id, "error", err)
+			}
+		}
+	}()
+
+	d := &Disk{
+		Id:        id,
+		SizeMB:    source.SizeMB,
+		CreatedAt: time.Now(),
+		Path:      path,
+	}
+
+	if err = tx.PutDisk(d); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
@@ -0,0 +1,104 @@
+package disks
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/validation"
+)
+
+// SendDisk snapshots a disk and writes the snapshot to w, only the changes since the base
+// snapshot if it is set. The snapshots sent are not listed in the disk snapshots, they are
+// deleted with DeleteSentSnapshot.
+func (s *Service) SendDisk(id, snapshot, base string, w io.Writer) error {
+	d, err := s.GetDisk(id)
+	if err != nil {
+		return err
+	}
+
+	// the writes of a running instance may still be cached by the host
+	if err := syncDevice(d.Path); err != nil {
+		return err
+	}
+
+	if err := s.pool.Snapshot(id, snapshot); err != nil {
+		return err
+	}
+
+	return s.pool.SendSnapshot(id, snapshot, base, w)
+}
+
+// DeleteSentSnapshot deletes a snapshot written by SendDisk, or received from it by ReceiveDisk.
+func (s *Service) DeleteSentSnapshot(id, snapshot string) error {
+	return s.pool.DeleteSnapshot(id, snapshot)
+}
+
+// ReceiveDisk creates a disk from the full stream written by SendDisk on another node, or applies
+// an incremental stream to the disk created by a previous one.
+func (s *Service) ReceiveDisk(id string, sizeMB uint64, r io.Reader) (*Disk, error) {
+	if err := validation.ValidateObjectId(id); err != nil {
+		return nil, err
+	}
+
+	if d, err := s.GetDisk(id); err == nil {
+		if _, err := s.pool.ReceiveSnapshot(id, r); err != nil {
+			return nil, err
+		}
+		return d, nil
+	} else if !errdefs.IsNotFound(err) {
+		return nil, err
+	}
+
+	path, err := s.pool.ReceiveSnapshot(id, r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if err := s.pool.DeleteDevice(id); err != nil {
+				slog.Error("failed to delete disk", "disk", id, "error", err)
+			}
+		}
+	}()
+
+	tx, err := s.store.BeginDiskTX(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.GetDisk(id); err == nil {
+		err = errdefs.NewAlreadyExists("disk already exists")
+		return nil, err
+	}
+
+	d := &Disk{
+		Id:        id,
+		SizeMB:    sizeMB,
+		CreatedAt: time.Now(),
+		Path:      path,
+	}
+
+	if err = tx.PutDisk(d); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func syncDevice(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
@@ -1,7 +1,10 @@
 package disks
 
 import (
+	"io"
+	"log/slog"
 	"os/exec"
+	"strconv"
 
 	"github.com/mistifyio/go-zfs/v3"
 )
@@ -18,9 +21,22 @@ func NewZFSPool(pool string) *ZFSPool {
 
 type DevicePool interface {
 	CreateDevice(id string, size uint64) (string, error)
+	// ResizeDevice grows a device to the given size in MB.
+	ResizeDevice(id string, size uint64) error
+	// CreateDeviceFromSnapshot creates a device holding a copy of the snapshot of another device.
+	CreateDeviceFromSnapshot(id, source, snapshot string) (string, error)
+	// DeleteDevice deletes a device and its snapshots.
 	DeleteDevice(id string) error
 	Snapshot(id, snapshot string) error
 	DeleteSnapshot(id, snapshot string) error
+	// RollbackSnapshot restores a device to a snapshot, destroying the more recent snapshots.
+	RollbackSnapshot(id, snapshot string) error
+	// SendSnapshot writes a stream of a snapshot, holding only the changes since the base
+	// snapshot if it is not empty.
+	SendSnapshot(id, snapshot, base string, w io.Writer) error
+	// ReceiveSnapshot creates a device from a full stream of SendSnapshot, or applies an
+	// incremental one to the device.
+	ReceiveSnapshot(id string, r io.Reader) (string, error)
 }
 
 func (z *ZFSPool) volumeName(id string) string {
@@ -45,11 +61,52 @@ func (z *ZFSPool) CreateDevice(id string, size uint64) (string, error) {
 	return z.devPath(id), nil
 }
 
+func (z *ZFSPool) ResizeDevice(id string, size uint64) error {
+	dataset := zfs.Dataset{
+		Name: z.volumeName(id),
+	}
+	return dataset.SetProperty("volsize", strconv.FormatUint(size*1024*1024, 10))
+}
+
+// CreateDeviceFromSnapshot sends the snapshot to a new volume, unlike a clone the
+// new volume does not depend on the snapshot which can still be deleted.
+func (z *ZFSPool) CreateDeviceFromSnapshot(id, source, snapshot string) (string, error) {
+	snap, err := zfs.GetDataset(z.snaphotName(source, snapshot))
+	if err != nil {
+		return "", err
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(snap.SendSnapshot(w))
+	}()
+
+	_, err = zfs.ReceiveSnapshot(r, z.volumeName(id))
+	r.Close()
+	if err != nil {
+		return "", err
+	}
+
+	// the received snapshot is not tracked on the new device
+	received := zfs.Dataset{Name: z.snaphotName(id, snapshot)}
+	if err := received.Destroy(zfs.DestroyDefault); err != nil {
+		// the new volume is removed so the creation can be retried
+		volume := zfs.Dataset{Name: z.volumeName(id)}
+		if derr := volume.Destroy(zfs.DestroyRecursive); derr != nil {
+			slog.Error("Failed to destroy received volume", "id", id, "error", derr)
+		}
+		return "", err
+	}
+	exec.Command("zvol_wait").Run()
+
+	return z.devPath(id), nil
+}
+
 func (z *ZFSPool) DeleteDevice(id string) error {
 	dataset := zfs.Dataset{
 		Name: z.volumeName(id),
 	}
-	return dataset.Destroy(zfs.DestroyDefault)
+	return dataset.Destroy(zfs.DestroyRecursive)
 }
 
 func (z *ZFSPool) Snapshot(id, snapshot string) error {
@@ -66,3 +123,38 @@ func (z *ZFSPool) DeleteSnapshot(id, snapshot string) error {
 	}
 	return dataset.Destroy(zfs.DestroyDefault)
 }
+
+func (z *ZFSPool) RollbackSnapshot(id, snapshot string) error {
+	dataset := zfs.Dataset{
+		Name: z.snaphotName(id, snapshot),
+		Type: zfs.DatasetSnapshot,
+	}
+	return dataset.Rollback(true)
+}
+
+func (z *ZFSPool) SendSnapshot(id, snapshot, base string, w io.Writer) error {
+	snap, err := zfs.GetDataset(z.snaphotName(id, snapshot))
+	if err != nil {
+		return err
+	}
+
+	if base == "" {
+		return snap.SendSnapshot(w)
+	}
+
+	baseSnap, err := zfs.GetDataset(z.snaphotName(id, base))
+	if err != nil {
+		return err
+	}
+
+	return snap.IncrementalSend(baseSnap, w)
+}
+
+func (z *ZFSPool) ReceiveSnapshot(id string, r io.Reader) (string, error) {
+	if _, err := zfs.ReceiveSnapshot(r, z.volumeName(id)); err != nil {
+		return "", err
+	}
+	exec.Command("zvol_wait").Run()
+
+	return z.devPath(id), nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"time"
 
@@ -20,6 +21,44 @@ func StringPtr(s string) *string {
 	return &s
 }
 
+// NetworkConfig builds the guest network configuration of an instance. The private network
+// addresses are assigned to the main interface and their traffic is routed through the host,
+// which forwards it to the Wireguard interface of the network.
+func NetworkConfig(network instance.NetworkingConfig) initd.NetworkConfig {
+	gateway := network.Local.Gateway.String()
+	config := initd.NetworkConfig{
+		IPConfigs: []initd.IPConfig{
+			{
+				IPNet:     network.Local.InstanceIPNet().String(),
+				Broadcast: network.Local.Broadcast.String(),
+				Gateway:   gateway,
+			},
+		},
+		DefaultGateway: gateway,
+	}
+
+	for _, pn := range network.PrivateNetworks {
+		ip, ipNet, err := net.ParseCIDR(pn.IPAddress)
+		if err != nil {
+			slog.Error("invalid private network address", "network", pn.NetworkName, "address", pn.IPAddress)
+			continue
+		}
+
+		config.IPConfigs = append(config.IPConfigs, initd.IPConfig{
+			IPNet:     ip.String() + "/32",
+			Broadcast: ip.String(),
+			Gateway:   gateway,
+		})
+		config.Routes = append(config.Routes, initd.Route{
+			Destination: ipNet.String(),
+			Gateway:     gateway,
+			Source:      ip.String(),
+		})
+	}
+
+	return config
+}
+
 // WriteInitrd writes an initramfs to the given file.
 func WriteInitrd(file *os.File, initBinaryPath string, inst *instance.Instance, image v1.Image) error {
 	slog.Debug("writing initrd", "instance", inst.Id)
@@ -71,6 +110,14 @@ func WriteInitrd(file *os.File, initBinaryPath string, inst *instance.Instance,
 	return nil
 }
 
+// GetHostname returns the hostname of the VM of an instance, the id of its machine.
+func GetHostname(inst *instance.Instance) string {
+	if inst.Metadata.MachineId != "" {
+		return inst.Metadata.MachineId
+	}
+	return inst.Id
+}
+
 // GetInitConfig creates an initd configuration from an instance and image config.
 func GetInitConfig(inst *instance.Instance, image v1.ImageConfig) initd.Config {
 	config := inst.Config
@@ -92,17 +139,10 @@ func GetInitConfig(inst *instance.Instance, image v1.ImageConfig) initd.Config {
 		EtcResolv: initd.EtcResolv{
 			Nameservers: []string{"8.8.8.8"},
 		},
-		ExtraEnv: config.Env,
-		Network: initd.NetworkConfig{
-			IPConfigs: []initd.IPConfig{
-				{
-					IPNet:     inst.Network.Local.InstanceIPNet().String(),
-					Broadcast: inst.Network.Local.Broadcast.String(),
-					Gateway:   inst.Network.Local.Gateway.String(),
-				},
-			},
-			DefaultGateway: inst.Network.Local.Gateway.String(),
-		},
-		Mounts: mounts,
+		Hostname:    GetHostname(inst),
+		ExtraEnv:    config.Env,
+		WaitSecrets: config.WaitSecrets,
+		Network:     NetworkConfig(inst.Network),
+		Mounts:      mounts,
 	}
 }
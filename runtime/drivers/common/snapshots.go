@@ -0,0 +1,124 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SnapshotRootFSFile is the file of a snapshot directory holding the copy of the root filesystem
+// of the instance, taken while the VM was paused for the snapshot of its memory.
+const SnapshotRootFSFile = "rootfs.img"
+
+const rootFSCopyBlockSize = 1 << 20
+
+// GetJailSnapshotPath returns the path, relative to the jail of an instance, where the
+// VMM reads and writes the snapshot stored in the given directory of the host.
+func GetJailSnapshotPath(snapshotDir string) string {
+	return "/snapshots/" + filepath.Base(snapshotDir)
+}
+
+// SaveSnapshotRootFS copies the root filesystem device of a paused VM in the snapshot directory,
+// the blocks of zeros are left as holes of the file.
+func SaveSnapshotRootFS(rootfs string, snapshotDir string) error {
+	src, err := os.Open(rootfs)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(filepath.Join(snapshotDir, SnapshotRootFSFile), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	size, err := copyBlocks(dst, src, true)
+	if err != nil {
+		return fmt.Errorf("failed to copy root filesystem: %w", err)
+	}
+
+	// the holes at the end of the file are not written
+	if err := dst.Truncate(size); err != nil {
+		return err
+	}
+
+	return dst.Sync()
+}
+
+// RestoreSnapshotRootFS writes the copy of the root filesystem of a snapshot on the root
+// filesystem device of an instance which is not running. It returns false if the snapshot has
+// no copy of the root filesystem.
+func RestoreSnapshotRootFS(snapshotDir string, rootfs string) (bool, error) {
+	src, err := os.Open(filepath.Join(snapshotDir, SnapshotRootFSFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(rootfs, os.O_WRONLY, 0)
+	if err != nil {
+		return false, err
+	}
+	defer dst.Close()
+
+	srcSize, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+	dstSize, err := dst.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+	if dstSize < srcSize {
+		return false, fmt.Errorf("the root filesystem device (%d bytes) is smaller than the one of the snapshot (%d bytes)", dstSize, srcSize)
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	if _, err := dst.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	// the device holds the data of another filesystem, the blocks of zeros are written too
+	if _, err := copyBlocks(dst, src, false); err != nil {
+		return false, fmt.Errorf("failed to restore root filesystem: %w", err)
+	}
+
+	return true, dst.Sync()
+}
+
+// copyBlocks copies src to dst and returns the number of bytes read, the blocks of zeros are
+// skipped if sparse is set.
+func copyBlocks(dst io.WriteSeeker, src io.Reader, sparse bool) (int64, error) {
+	buf := make([]byte, rootFSCopyBlockSize)
+	zeros := make([]byte, rootFSCopyBlockSize)
+
+	var size int64
+	for {
+		n, err := io.ReadFull(src, buf)
+		if n > 0 {
+			if sparse && bytes.Equal(buf[:n], zeros[:n]) {
+				if _, err := dst.Seek(int64(n), io.SeekCurrent); err != nil {
+					return 0, err
+				}
+			} else if _, err := dst.Write(buf[:n]); err != nil {
+				return 0, err
+			}
+			size += int64(n)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
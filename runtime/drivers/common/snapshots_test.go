@@ -0,0 +1,112 @@
+package common
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func writeDevice(t *testing.T, path string, size int, data map[int][]byte) []byte {
+	t.Helper()
+
+	content := make([]byte, size)
+	for offset, b := range data {
+		copy(content[offset:], b)
+	}
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return content
+}
+
+func TestSaveAndRestoreSnapshotRootFS(t *testing.T) {
+	dir := t.TempDir()
+	size := 4*rootFSCopyBlockSize + 512
+
+	source := filepath.Join(dir, "source")
+	want := writeDevice(t, source, size, map[int][]byte{
+		0:                         []byte("superblock"),
+		2*rootFSCopyBlockSize + 7: []byte("written after boot"),
+		size - 3:                  []byte("end"),
+	})
+
+	snapshotDir := filepath.Join(dir, "snapshot")
+	if err := os.Mkdir(snapshotDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveSnapshotRootFS(source, snapshotDir); err != nil {
+		t.Fatalf("SaveSnapshotRootFS() error = %v", err)
+	}
+
+	image := filepath.Join(snapshotDir, SnapshotRootFSFile)
+	info, err := os.Stat(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(size) {
+		t.Errorf("root filesystem copy size = %d, want %d", info.Size(), size)
+	}
+
+	// the blocks of zeros are holes of the copy
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Blocks*512 >= int64(size) {
+		t.Errorf("root filesystem copy uses %d bytes, want a sparse file", stat.Blocks*512)
+	}
+
+	// the device of the fork holds another filesystem, it is overwritten with zeros too
+	target := filepath.Join(dir, "target")
+	writeDevice(t, target, size+rootFSCopyBlockSize, map[int][]byte{
+		rootFSCopyBlockSize: bytes.Repeat([]byte{0xff}, rootFSCopyBlockSize),
+	})
+
+	restored, err := RestoreSnapshotRootFS(snapshotDir, target)
+	if err != nil {
+		t.Fatalf("RestoreSnapshotRootFS() error = %v", err)
+	}
+	if !restored {
+		t.Fatal("RestoreSnapshotRootFS() = false, want the root filesystem restored")
+	}
+
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got[:size], want) {
+		t.Error("restored root filesystem differs from the saved one")
+	}
+}
+
+func TestRestoreSnapshotRootFSWithoutCopy(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	want := writeDevice(t, target, 1024, map[int][]byte{0: []byte("image")})
+
+	restored, err := RestoreSnapshotRootFS(dir, target)
+	if err != nil || restored {
+		t.Fatalf("RestoreSnapshotRootFS() = %v, %v, want nothing restored", restored, err)
+	}
+
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("the root filesystem was written without a copy in the snapshot")
+	}
+}
+
+func TestRestoreSnapshotRootFSSmallerDevice(t *testing.T) {
+	dir := t.TempDir()
+	writeDevice(t, filepath.Join(dir, SnapshotRootFSFile), 2048, nil)
+
+	target := filepath.Join(dir, "target")
+	writeDevice(t, target, 1024, nil)
+
+	if _, err := RestoreSnapshotRootFS(dir, target); err == nil {
+		t.Error("RestoreSnapshotRootFS() succeeded on a device smaller than the copy")
+	}
+}
@@ -5,6 +5,7 @@ package containerd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -14,13 +15,18 @@ import (
 	"github.com/alexisbouchez/ravel/internal/resources"
 	"github.com/alexisbouchez/ravel/runtime/disks"
 	"github.com/alexisbouchez/ravel/runtime/drivers"
+	"github.com/alexisbouchez/ravel/runtime/drivers/common"
 	"github.com/containerd/cgroups/v3/cgroup2"
 	"github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/errdefs"
 )
 
 const (
-	dataDir     = "/var/lib/ravel/containers"
+	// dataDir is shared with the VM drivers, the instance runner reads the logs of an instance
+	// in its directory
+	dataDir     = "/var/lib/ravel/instances"
+	cgroupRoot  = "/ravel-containers"
 	snapshotter = "overlayfs"
 )
 
@@ -49,7 +55,7 @@ func NewDriver(ctrd *client.Client) (*Driver, error) {
 	}
 
 	// Create root cgroup for ravel containers
-	_, err = cgroup2.NewManager("/sys/fs/cgroup", "/ravel-containers", &cgroup2.Resources{})
+	_, err = cgroup2.NewManager("/sys/fs/cgroup", cgroupRoot, &cgroup2.Resources{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cgroup2 manager: %w", err)
 	}
@@ -70,127 +76,268 @@ func (d *Driver) Snapshotter() string {
 	return snapshotter
 }
 
-// getCgroupMemory creates memory cgroup configuration.
-func getCgroupMemory(c *instance.InstanceGuestConfig) *cgroup2.Memory {
-	max := int64(c.MemoryMB * 1_000_000)
-	return &cgroup2.Memory{
-		Max: &max,
-	}
+// getInstanceDir returns the working directory for an instance.
+func getInstanceDir(id string) string {
+	return path.Join(dataDir, id)
 }
 
-// getCgroupCPU creates CPU cgroup configuration.
-func getCgroupCPU(cpuMhz int64, c *instance.InstanceGuestConfig) *cgroup2.CPU {
-	quota := int64(float64(c.CpusMHz) / float64(cpuMhz) * 1_000_000)
-	period := uint64(1_000_000)
-	max := cgroup2.NewCPUMax(&quota, &period)
-	return &cgroup2.CPU{
-		Max: max,
-	}
+// getLogFile returns the fifo the output of the container is written to.
+func getLogFile(id string) string {
+	return path.Join(getInstanceDir(id), "vm.logs")
 }
 
-// getInstanceResources creates cgroup resource limits.
-func getInstanceResources(cpuMhz int64, c *instance.InstanceGuestConfig) *cgroup2.Resources {
-	return &cgroup2.Resources{
-		CPU:    getCgroupCPU(cpuMhz, c),
-		Memory: getCgroupMemory(c),
-	}
+func getCgroupPath(id string) string {
+	return path.Join(cgroupRoot, id)
 }
 
-// getInstanceDir returns the working directory for an instance.
-func getInstanceDir(id string) string {
-	return path.Join(dataDir, id)
+func rootFSName(id string) string {
+	return fmt.Sprintf("%s-%s", id, "rootfs")
+}
+
+func (d *Driver) getImage(ctx context.Context, ref string) (client.Image, error) {
+	image, err := d.ctrd.GetImage(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	isUnpacked, err := image.IsUnpacked(ctx, d.Snapshotter())
+	if err != nil {
+		return nil, err
+	}
+
+	if !isUnpacked {
+		err = image.Unpack(ctx, d.Snapshotter())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
 }
 
-// BuildInstanceTask creates a new container task for the instance.
-func (d *Driver) BuildInstanceTask(ctx context.Context, inst *instance.Instance, disks []disks.Disk) (drivers.InstanceTask, error) {
+// BuildInstanceTask creates the container of the instance, its task is created when it starts.
+func (d *Driver) BuildInstanceTask(ctx context.Context, inst *instance.Instance, disks []disks.Disk) (task drivers.InstanceTask, err error) {
 	instanceDir := getInstanceDir(inst.Id)
 	if err := os.MkdirAll(instanceDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create instance directory: %w", err)
 	}
 
-	// Get image
-	image, err := d.ctrd.GetImage(ctx, inst.ImageRef)
+	image, err := d.getImage(ctx, inst.ImageRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get image: %w", err)
 	}
 
-	// Prepare snapshot for container
-	snapshotName := inst.Id
-	diffIDs, err := image.RootFS(ctx)
+	imageSpec, err := image.Spec(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get rootfs: %w", err)
+		return nil, fmt.Errorf("failed to get image spec: %w", err)
+	}
+
+	if err := writeEtcFiles(instanceDir, inst); err != nil {
+		return nil, err
 	}
 
-	// Create snapshot from image layers
-	parent := ""
-	if len(diffIDs) > 0 {
-		parent = diffIDs[len(diffIDs)-1].String()
+	if err := prepareNetwork(inst); err != nil {
+		return nil, err
 	}
+	defer func() {
+		if err != nil {
+			if err := cleanupNetwork(inst); err != nil {
+				slog.Error("failed to cleanup network", "error", err)
+			}
+		}
+	}()
 
-	_, err = d.snapshotter.Prepare(ctx, snapshotName, parent)
+	cgroup, err := cgroup2.Load(cgroupRoot)
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare snapshot: %w", err)
+		return nil, fmt.Errorf("failed to load cgroup: %w", err)
 	}
 
-	// Create cgroup manager
-	resources := getInstanceResources(d.cpuMhz, &inst.Config.Guest)
-	cgroupPath := path.Join("/ravel-containers", inst.Id)
-	cgroupManager, err := cgroup2.NewManager("/sys/fs/cgroup", cgroupPath, resources)
+	_, err = cgroup.NewChild(inst.Id, common.GetInstanceResources(d.cpuMhz, &inst.Config.Guest))
 	if err != nil {
-		d.snapshotter.Remove(ctx, snapshotName)
-		return nil, fmt.Errorf("failed to create cgroup manager: %w", err)
-	}
-
-	return &containerTask{
-		id:            inst.Id,
-		snapshotName:  snapshotName,
-		image:         image,
-		config:        &inst.Config,
-		ctrd:          d.ctrd,
-		cgroupManager: cgroupManager,
-		cgroupPath:    cgroupPath,
-		stopConfig:    inst.Config.Stop,
-		waitChan:      make(chan struct{}),
-	}, nil
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if err := deleteCgroup(inst.Id); err != nil {
+				slog.Error("failed to delete cgroup", "error", err)
+			}
+		}
+	}()
+
+	// a container left by a previous run of the instance is replaced
+	if err := d.deleteContainer(ctx, inst.Id); err != nil {
+		return nil, err
+	}
+
+	container, err := d.ctrd.NewContainer(
+		ctx,
+		inst.Id,
+		client.WithImage(image),
+		client.WithSnapshotter(snapshotter),
+		client.WithNewSnapshot(rootFSName(inst.Id), image),
+		client.WithNewSpec(getSpecOpts(inst, image, imageSpec.Config, disks)...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if err := container.Delete(context.Background(), client.WithSnapshotCleanup); err != nil {
+				slog.Error("failed to delete container", "error", err)
+			}
+		}
+	}()
+
+	if inst.Config.WaitSecrets {
+		spec, err := container.Spec(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get container spec: %w", err)
+		}
+
+		err = mountSecretsDir(getSecretsDir(instanceDir), spec.Process.User.UID, spec.Process.User.GID)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				unmountSecretsDir(getSecretsDir(instanceDir))
+			}
+		}()
+	}
+
+	logs, err := openLogFile(getLogFile(inst.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	return newContainerTask(inst, container, logs), nil
+}
+
+// deleteContainer deletes the container of an instance and its root filesystem, its task is
+// killed if it is still running.
+func (d *Driver) deleteContainer(ctx context.Context, id string) error {
+	container, err := d.ctrd.LoadContainer(ctx, id)
+	if err == nil {
+		if task, err := container.Task(ctx, nil); err == nil {
+			if _, err := task.Delete(ctx, client.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
+				return fmt.Errorf("failed to delete task: %w", err)
+			}
+		}
+
+		if err := container.Delete(ctx, client.WithSnapshotCleanup); err != nil && !errdefs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete container: %w", err)
+		}
+	} else if !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to load container: %w", err)
+	}
+
+	if err := d.snapshotter.Remove(ctx, rootFSName(id)); err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to remove snapshot: %w", err)
+	}
+
+	return nil
 }
 
 // CleanupInstanceTask cleans up a stopped container task.
 func (d *Driver) CleanupInstanceTask(ctx context.Context, inst *instance.Instance) error {
-	// Remove snapshot
-	if err := d.snapshotter.Remove(ctx, inst.Id); err != nil {
-		slog.Warn("snapshot not found during cleanup", "id", inst.Id, "error", err)
+	var errs []error
+
+	if err := d.deleteContainer(ctx, inst.Id); err != nil {
+		errs = append(errs, err)
 	}
 
-	// Remove instance directory
 	instanceDir := getInstanceDir(inst.Id)
+	if err := unmountSecretsDir(getSecretsDir(instanceDir)); err != nil {
+		errs = append(errs, err)
+	}
+
 	if err := os.RemoveAll(instanceDir); err != nil {
-		slog.Error("failed to remove instance directory", "error", err)
+		errs = append(errs, err)
+	}
+
+	if err := cleanupNetwork(inst); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := deleteCgroup(inst.Id); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func deleteCgroup(id string) error {
+	cg, err := cgroup2.Load(getCgroupPath(id))
+	if err != nil {
+		return fmt.Errorf("failed to load cgroup: %w", err)
+	}
+
+	// runc removes the cgroup of the container when it is deleted
+	if err := cg.Delete(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete cgroup: %w", err)
 	}
 
 	return nil
 }
 
-// RecoverInstanceTask attempts to recover a running container.
+// BuildIncomingInstanceTask is not supported, the containers cannot be live migrated.
+func (d *Driver) BuildIncomingInstanceTask(ctx context.Context, inst *instance.Instance, disks []disks.Disk, devices []string) (drivers.InstanceTask, error) {
+	return nil, fmt.Errorf("containerd driver does not support migrations")
+}
+
+func (d *Driver) RootFS(ctx context.Context, inst *instance.Instance) (string, error) {
+	return "", fmt.Errorf("containerd driver does not support migrations")
+}
+
+// RecoverInstanceTask reattaches to the task of a container still running after a restart of
+// the daemon.
 func (d *Driver) RecoverInstanceTask(ctx context.Context, inst *instance.Instance) (drivers.InstanceTask, error) {
-	// For now, containerd driver doesn't support recovery
-	// Containers are ephemeral and should be restarted on daemon restart
-	return nil, fmt.Errorf("containerd driver does not support task recovery")
+	container, err := d.ctrd.LoadContainer(ctx, inst.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load container: %w", err)
+	}
+
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task: %w", err)
+	}
+
+	status, err := task.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task status: %w", err)
+	}
+
+	if status.Status != client.Running {
+		return nil, fmt.Errorf("task is not running: %s", status.Status)
+	}
+
+	logs, err := openLogFile(getLogFile(inst.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	ct := newContainerTask(inst, container, logs)
+	if err := ct.attach(task); err != nil {
+		logs.Close()
+		return nil, err
+	}
+
+	return ct, nil
 }
 
 // CleanupInstance performs final cleanup for a destroyed instance.
 func (d *Driver) CleanupInstance(ctx context.Context, inst *instance.Instance) error {
+	if err := d.deleteContainer(ctx, inst.Id); err != nil {
+		return err
+	}
+
 	instanceDir := getInstanceDir(inst.Id)
-	if err := os.RemoveAll(instanceDir); err != nil {
-		return fmt.Errorf("failed to remove instance directory: %w", err)
+	if err := unmountSecretsDir(getSecretsDir(instanceDir)); err != nil {
+		return err
 	}
 
-	// Cleanup cgroup
-	cgroupPath := path.Join("/ravel-containers", inst.Id)
-	cgroupManager, err := cgroup2.Load(cgroupPath)
-	if err == nil {
-		if err := cgroupManager.Delete(); err != nil {
-			slog.Error("failed to delete cgroup", "error", err)
-		}
+	if err := os.RemoveAll(instanceDir); err != nil {
+		return fmt.Errorf("failed to remove instance directory: %w", err)
 	}
 
 	return nil
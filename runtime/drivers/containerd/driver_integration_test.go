@@ -0,0 +1,287 @@
+//go:build integration
+
+package containerd
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/core/networking"
+	"github.com/alexisbouchez/ravel/initd"
+	"github.com/alexisbouchez/ravel/internal/id"
+	"github.com/containerd/containerd/v2/client"
+)
+
+const (
+	containerdSocket = "/var/run/containerd/containerd.sock"
+	defaultTestImage = "docker.io/library/alpine:latest"
+)
+
+func newTestDriver(t *testing.T) (*Driver, string) {
+	t.Helper()
+
+	if os.Geteuid() != 0 {
+		t.Skip("the containerd driver tests must run as root")
+	}
+
+	if _, err := os.Stat(containerdSocket); err != nil {
+		t.Skipf("containerd is not available: %v", err)
+	}
+
+	ctrd, err := client.New(containerdSocket, client.WithDefaultNamespace("ravel-test"))
+	if err != nil {
+		t.Skipf("containerd is not available: %v", err)
+	}
+	t.Cleanup(func() { ctrd.Close() })
+
+	ctx := context.Background()
+	if _, err := ctrd.Version(ctx); err != nil {
+		t.Skipf("containerd is not available: %v", err)
+	}
+
+	ref := os.Getenv("RAVEL_TEST_IMAGE")
+	if ref == "" {
+		ref = defaultTestImage
+	}
+
+	if _, err := ctrd.Pull(ctx, ref, client.WithPullUnpack, client.WithPullSnapshotter(snapshotter)); err != nil {
+		t.Fatalf("failed to pull %s: %v", ref, err)
+	}
+
+	d, err := NewDriver(ctrd)
+	if err != nil {
+		t.Fatalf("failed to create driver: %v", err)
+	}
+
+	return d, ref
+}
+
+var testSubnet byte
+
+func newTestInstance(ref string, cmd ...string) *instance.Instance {
+	testSubnet++
+	network := instance.GetLocalNetwork(networking.Network{
+		Family:       networking.IPv4,
+		IP:           net.IPv4(10, 213, testSubnet, 0).To4(),
+		PrefixLength: 29,
+	})
+
+	return &instance.Instance{
+		Id:       id.Generate(),
+		ImageRef: ref,
+		Config: instance.InstanceConfig{
+			Guest: instance.InstanceGuestConfig{MemoryMB: 64, VCpus: 1, CpusMHz: 100},
+			Init:  api.InitConfig{Cmd: cmd},
+			Env:   []string{"RAVEL_TEST=1"},
+		},
+		Network: instance.NetworkingConfig{
+			TapDevice:      id.Generate()[:14],
+			Local:          network,
+			DefaultGateway: network.HostIP,
+		},
+	}
+}
+
+func buildTestTask(t *testing.T, d *Driver, inst *instance.Instance) (*containerTask, <-chan string) {
+	t.Helper()
+
+	ctx := context.Background()
+	task, err := d.BuildInstanceTask(ctx, inst, nil)
+	if err != nil {
+		t.Fatalf("failed to build task: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := d.CleanupInstanceTask(context.Background(), inst); err != nil {
+			t.Errorf("failed to cleanup task: %v", err)
+		}
+	})
+
+	// read like the instance logger, the fifo is open for writing until the container exits
+	logs := make(chan string, 1)
+	go func() {
+		f, err := os.Open(getLogFile(inst.Id))
+		if err != nil {
+			logs <- err.Error()
+			return
+		}
+		defer f.Close()
+		out, _ := io.ReadAll(f)
+		logs <- string(out)
+	}()
+
+	return task.(*containerTask), logs
+}
+
+func waitExit(t *testing.T, task *containerTask) instance.ExitResult {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if !task.WaitExit(ctx) {
+		t.Fatal("the container did not exit in time")
+	}
+	return task.Run()
+}
+
+func TestRunContainer(t *testing.T) {
+	d, ref := newTestDriver(t)
+	inst := newTestInstance(ref, "sh", "-c", "echo $RAVEL_TEST; cat /etc/hostname; exit 3")
+
+	task, logs := buildTestTask(t, d, inst)
+	if err := task.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start task: %v", err)
+	}
+
+	result := waitExit(t, task)
+	if result.ExitCode != 3 || result.Success || result.Requested {
+		t.Errorf("unexpected exit result: %+v", result)
+	}
+
+	out := <-logs
+	if !strings.Contains(out, "1\n") || !strings.Contains(out, inst.Id) {
+		t.Errorf("unexpected logs: %q", out)
+	}
+}
+
+func TestExecAndStop(t *testing.T) {
+	d, ref := newTestDriver(t)
+	inst := newTestInstance(ref, "sleep", "300")
+
+	task, _ := buildTestTask(t, d, inst)
+	ctx := context.Background()
+	if err := task.Start(ctx); err != nil {
+		t.Fatalf("failed to start task: %v", err)
+	}
+
+	res, err := task.Exec(ctx, []string{"ip", "-4", "addr", "show", "eth0"}, 10*time.Second)
+	if err != nil {
+		t.Fatalf("failed to exec: %v", err)
+	}
+	if res.ExitCode != 0 || !strings.Contains(res.Stdout, inst.Network.Local.InstanceIP.String()) {
+		t.Errorf("unexpected exec result: %+v", res)
+	}
+
+	res, err = task.Exec(ctx, []string{"sleep", "10"}, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to exec: %v", err)
+	}
+	if res.ExitCode != -1 {
+		t.Errorf("expected the exec to time out, got %+v", res)
+	}
+
+	// sleep runs as pid 1 and ignores SIGTERM
+	if err := task.Stop(ctx, "SIGTERM"); err != nil {
+		t.Fatalf("failed to stop: %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if !task.WaitExit(waitCtx) {
+		if err := task.Shutdown(ctx); err != nil {
+			t.Fatalf("failed to shutdown: %v", err)
+		}
+	}
+
+	result := waitExit(t, task)
+	if !result.Requested {
+		t.Errorf("expected a requested exit, got %+v", result)
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	d, ref := newTestDriver(t)
+	inst := newTestInstance(ref, "sh", "-c", "echo $TOKEN; cat /run/secrets/key")
+	inst.Config.WaitSecrets = true
+
+	task, logs := buildTestTask(t, d, inst)
+	ctx := context.Background()
+	if err := task.Start(ctx); err != nil {
+		t.Fatalf("failed to start task: %v", err)
+	}
+
+	if _, err := task.Exec(ctx, []string{"true"}, time.Second); err == nil {
+		t.Error("expected the exec to fail before the secrets are set")
+	}
+
+	err := task.SetSecrets(ctx, initd.Secrets{
+		Env:   []string{"TOKEN=abc"},
+		Files: []initd.SecretFile{{Name: "key", Value: "def"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to set secrets: %v", err)
+	}
+
+	if err := task.SetSecrets(ctx, initd.Secrets{}); err == nil {
+		t.Error("expected the secrets to be accepted only once")
+	}
+
+	result := waitExit(t, task)
+	if result.ExitCode != 0 {
+		t.Errorf("unexpected exit result: %+v", result)
+	}
+
+	out := <-logs
+	if !strings.Contains(out, "abc") || !strings.Contains(out, "def") {
+		t.Errorf("unexpected logs: %q", out)
+	}
+}
+
+func TestStopWaitingSecrets(t *testing.T) {
+	d, ref := newTestDriver(t)
+	inst := newTestInstance(ref, "true")
+	inst.Config.WaitSecrets = true
+
+	task, _ := buildTestTask(t, d, inst)
+	ctx := context.Background()
+	if err := task.Start(ctx); err != nil {
+		t.Fatalf("failed to start task: %v", err)
+	}
+
+	if err := task.Stop(ctx, "SIGTERM"); err != nil {
+		t.Fatalf("failed to stop: %v", err)
+	}
+
+	result := waitExit(t, task)
+	if !result.Requested {
+		t.Errorf("expected a requested exit, got %+v", result)
+	}
+}
+
+func TestRecoverContainer(t *testing.T) {
+	d, ref := newTestDriver(t)
+	inst := newTestInstance(ref, "sleep", "300")
+
+	task, _ := buildTestTask(t, d, inst)
+	ctx := context.Background()
+	if err := task.Start(ctx); err != nil {
+		t.Fatalf("failed to start task: %v", err)
+	}
+
+	recovered, err := d.RecoverInstanceTask(ctx, inst)
+	if err != nil {
+		t.Fatalf("failed to recover task: %v", err)
+	}
+
+	res, err := recovered.Exec(ctx, []string{"hostname"}, 10*time.Second)
+	if err != nil {
+		t.Fatalf("failed to exec in recovered task: %v", err)
+	}
+	if strings.TrimSpace(res.Stdout) != inst.Id {
+		t.Errorf("unexpected hostname: %q", res.Stdout)
+	}
+
+	if err := recovered.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown: %v", err)
+	}
+
+	waitExit(t, recovered.(*containerTask))
+	waitExit(t, task)
+}
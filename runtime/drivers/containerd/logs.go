@@ -0,0 +1,27 @@
+package containerd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// openLogFile creates the fifo the output of a container is written to and opens it like the
+// jailer does for the VMMs. The returned file is kept open for writing until the task exits, so
+// the shim never blocks on the fifo nor gets an error while the logger is not reading it, and the
+// logger reads until the end of the output once it is closed.
+func openLogFile(path string) (*os.File, error) {
+	err := unix.Mkfifo(path, 0o600)
+	if err != nil && !errors.Is(err, unix.EEXIST) {
+		return nil, fmt.Errorf("failed to create log fifo: %w", err)
+	}
+
+	fd, err := unix.Open(path, unix.O_RDWR|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log fifo: %w", err)
+	}
+
+	return os.NewFile(uintptr(fd), path), nil
+}
@@ -0,0 +1,180 @@
+package containerd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/initd"
+	"github.com/alexisbouchez/ravel/runtime/drivers/common"
+	"github.com/alexisbouchez/ravel/runtime/drivers/vm/tap"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+const (
+	netnsDir = "/var/run/netns"
+	// containerInterface is the name of the interface of the instance in its network namespace,
+	// the same as in the VMs
+	containerInterface = "eth0"
+)
+
+func getNetNSPath(id string) string {
+	return filepath.Join(netnsDir, id)
+}
+
+// prepareNetwork creates the network namespace of an instance and the veth pair linking it to
+// the host, which is configured like the tap device of a VM. The interface in the namespace
+// gets the configuration given to the init of a VM.
+func prepareNetwork(inst *instance.Instance) (err error) {
+	ns, err := createNetNS(inst.Id)
+	if err != nil {
+		return fmt.Errorf("failed to create network namespace: %w", err)
+	}
+	defer ns.Close()
+	defer func() {
+		if err != nil {
+			netns.DeleteNamed(inst.Id)
+		}
+	}()
+
+	if _, err = tap.PrepareInstanceVethDevice(inst.Id, inst.Network, ns, containerInterface); err != nil {
+		return fmt.Errorf("failed to prepare veth device: %w", err)
+	}
+
+	if err = configureNetNS(ns, common.NetworkConfig(inst.Network)); err != nil {
+		tap.CleanupInstanceTapDevice(inst.Id, inst.Network)
+		return fmt.Errorf("failed to configure network namespace: %w", err)
+	}
+
+	return nil
+}
+
+// cleanupNetwork removes the veth pair and the network namespace of an instance.
+func cleanupNetwork(inst *instance.Instance) error {
+	errs := []error{}
+
+	if err := tap.CleanupInstanceTapDevice(inst.Id, inst.Network); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := netns.DeleteNamed(inst.Id); err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("failed to delete network namespace: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// createNetNS creates a named network namespace. The namespace is entered by the thread creating
+// it, which is locked and thrown away if it cannot get back to the namespace of the host.
+func createNetNS(name string) (netns.NsHandle, error) {
+	type result struct {
+		ns  netns.NsHandle
+		err error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		goruntime.LockOSThread()
+
+		origin, err := netns.Get()
+		if err != nil {
+			goruntime.UnlockOSThread()
+			ch <- result{err: err}
+			return
+		}
+		defer origin.Close()
+
+		if err := os.MkdirAll(netnsDir, 0755); err != nil {
+			goruntime.UnlockOSThread()
+			ch <- result{err: err}
+			return
+		}
+
+		netns.DeleteNamed(name)
+		ns, err := netns.NewNamed(name)
+		if err := netns.Set(origin); err != nil {
+			if ns.IsOpen() {
+				ns.Close()
+			}
+			ch <- result{err: fmt.Errorf("failed to restore the host network namespace: %w", err)}
+			return
+		}
+		goruntime.UnlockOSThread()
+
+		ch <- result{ns: ns, err: err}
+	}()
+
+	r := <-ch
+	return r.ns, r.err
+}
+
+func configureNetNS(ns netns.NsHandle, config initd.NetworkConfig) error {
+	handle, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	lo, err := handle.LinkByName("lo")
+	if err != nil {
+		return fmt.Errorf("error getting loopback interface: %w", err)
+	}
+
+	if err := handle.LinkSetUp(lo); err != nil {
+		return fmt.Errorf("error configuring loopback interface: %w", err)
+	}
+
+	eth0, err := handle.LinkByName(containerInterface)
+	if err != nil {
+		return fmt.Errorf("error getting %s interface: %w", containerInterface, err)
+	}
+
+	for _, ipConfig := range config.IPConfigs {
+		ip, ipNet, err := net.ParseCIDR(ipConfig.IPNet)
+		if err != nil {
+			return fmt.Errorf("error parsing IP address: %w", err)
+		}
+
+		addr := &netlink.Addr{
+			IPNet:     &net.IPNet{IP: ip, Mask: ipNet.Mask},
+			Broadcast: net.ParseIP(ipConfig.Broadcast),
+		}
+		if err := handle.AddrAdd(eth0, addr); err != nil {
+			return fmt.Errorf("error adding IP address to interface: %w", err)
+		}
+	}
+
+	if err := handle.LinkSetUp(eth0); err != nil {
+		return fmt.Errorf("error setting %s interface up: %w", containerInterface, err)
+	}
+
+	if err := handle.RouteReplace(&netlink.Route{
+		LinkIndex: eth0.Attrs().Index,
+		Gw:        net.ParseIP(config.DefaultGateway),
+	}); err != nil {
+		return fmt.Errorf("error adding default route: %w", err)
+	}
+
+	for _, r := range config.Routes {
+		_, dst, err := net.ParseCIDR(r.Destination)
+		if err != nil {
+			return fmt.Errorf("error parsing route destination: %w", err)
+		}
+
+		if err := handle.RouteReplace(&netlink.Route{
+			LinkIndex: eth0.Attrs().Index,
+			Dst:       dst,
+			Gw:        net.ParseIP(r.Gateway),
+			Src:       net.ParseIP(r.Source),
+		}); err != nil {
+			return fmt.Errorf("error adding route to %s: %w", r.Destination, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package containerd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/initd"
+	"github.com/containerd/containerd/v2/client"
+	"golang.org/x/sys/unix"
+)
+
+func getSecretsDir(instanceDir string) string {
+	return filepath.Join(instanceDir, "secrets")
+}
+
+// mountSecretsDir mounts the tmpfs holding the secret files of an instance, it is bind mounted
+// in the container and only the user running the process can read it.
+func mountSecretsDir(dir string, uid, gid uint32) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	data := fmt.Sprintf("mode=0700,uid=%d,gid=%d", uid, gid)
+	if err := unix.Mount("secrets", dir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, data); err != nil {
+		return fmt.Errorf("failed to mount secrets directory: %w", err)
+	}
+
+	return nil
+}
+
+func unmountSecretsDir(dir string) error {
+	err := unix.Unmount(dir, unix.MNT_DETACH)
+	if err != nil && !errors.Is(err, unix.EINVAL) && !errors.Is(err, unix.ENOENT) {
+		return fmt.Errorf("failed to unmount secrets directory: %w", err)
+	}
+	return nil
+}
+
+// SetSecrets writes the secret files, adds the secret environment variables to the process of
+// the container and starts it. The secrets are only accepted once, like the init does.
+func (ct *containerTask) SetSecrets(ctx context.Context, secrets initd.Secrets) error {
+	if !ct.waitSecrets {
+		return errdefs.NewFailedPrecondition("the container does not wait for secrets")
+	}
+
+	err := errdefs.NewFailedPrecondition("the secrets have already been set")
+	ct.secretsOnce.Do(func() {
+		err = ct.setSecrets(ctx, secrets)
+	})
+	return err
+}
+
+func (ct *containerTask) setSecrets(ctx context.Context, secrets initd.Secrets) error {
+	spec, err := ct.container.Spec(ctx)
+	if err != nil {
+		return errdefs.NewUnknown(fmt.Sprintf("failed to get container spec: %v", err))
+	}
+
+	dir := getSecretsDir(getInstanceDir(ct.id))
+	for _, file := range secrets.Files {
+		if err := writeSecretFile(dir, file, spec.Process.User.UID, spec.Process.User.GID); err != nil {
+			return err
+		}
+	}
+
+	for _, pair := range secrets.Env {
+		name, _, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || strings.IndexByte(pair, 0) >= 0 {
+			return errdefs.NewInvalidArgument("invalid secret env var")
+		}
+	}
+
+	if len(secrets.Env) > 0 {
+		spec.Process.Env = append(spec.Process.Env, secrets.Env...)
+		if err := ct.container.Update(ctx, client.UpdateContainerOpts(client.WithSpec(spec))); err != nil {
+			return errdefs.NewUnknown(fmt.Sprintf("failed to update container spec: %v", err))
+		}
+	}
+
+	if err := ct.startTask(ctx); err != nil {
+		return errdefs.NewUnknown(err.Error())
+	}
+
+	return nil
+}
+
+func writeSecretFile(dir string, file initd.SecretFile, uid, gid uint32) error {
+	if file.Name == "" || file.Name != filepath.Base(file.Name) || file.Name == "." || file.Name == ".." {
+		return errdefs.NewInvalidArgument(fmt.Sprintf("invalid secret file name: %q", file.Name))
+	}
+
+	path := filepath.Join(dir, file.Name)
+	if err := os.WriteFile(path, []byte(file.Value), 0400); err != nil {
+		return errdefs.NewUnknown(fmt.Sprintf("error writing secret file %s: %v", file.Name, err))
+	}
+
+	if err := os.Chown(path, int(uid), int(gid)); err != nil {
+		return errdefs.NewUnknown(fmt.Sprintf("error setting permissions: %v", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package containerd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/runtime/disks"
+	"github.com/alexisbouchez/ravel/runtime/drivers/common"
+	"github.com/containerd/containerd/v2/pkg/oci"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const secretsDir = "/run/secrets"
+
+// getSpecOpts returns the options of the OCI spec of the container of an instance. The process
+// is the one the init of a VM would run, in the network namespace and the cgroup prepared by the
+// driver, with the disks of the instance mounted like the init does.
+func getSpecOpts(inst *instance.Instance, image oci.Image, imageConfig v1.ImageConfig, disks []disks.Disk) []oci.SpecOpts {
+	config := inst.Config
+	instanceDir := getInstanceDir(inst.Id)
+
+	opts := []oci.SpecOpts{
+		oci.WithImageConfig(image),
+		oci.WithProcessArgs(getProcessArgs(config, imageConfig)...),
+		oci.WithEnv(config.Env),
+		oci.WithHostname(common.GetHostname(inst)),
+		oci.WithLinuxNamespace(specs.LinuxNamespace{
+			Type: specs.NetworkNamespace,
+			Path: getNetNSPath(inst.Id),
+		}),
+		oci.WithCgroup(getCgroupPath(inst.Id)),
+		oci.WithMounts(getMounts(instanceDir, config, disks)),
+	}
+
+	if config.Init.User != "" {
+		opts = append(opts, oci.WithUser(config.Init.User))
+	}
+
+	return opts
+}
+
+// getProcessArgs returns the command of the instance, the entrypoint and the cmd of the image
+// unless they are overridden.
+func getProcessArgs(config instance.InstanceConfig, imageConfig v1.ImageConfig) []string {
+	entrypoint := imageConfig.Entrypoint
+	if config.Init.Entrypoint != nil {
+		entrypoint = config.Init.Entrypoint
+	}
+
+	cmd := imageConfig.Cmd
+	if config.Init.Cmd != nil {
+		cmd = config.Init.Cmd
+	}
+
+	return append(append([]string{}, entrypoint...), cmd...)
+}
+
+func getMounts(instanceDir string, config instance.InstanceConfig, disks []disks.Disk) []specs.Mount {
+	mounts := []specs.Mount{}
+
+	for _, name := range etcFiles {
+		mounts = append(mounts, specs.Mount{
+			Destination: filepath.Join("/etc", name),
+			Type:        "bind",
+			Source:      filepath.Join(instanceDir, "etc", name),
+			Options:     []string{"rbind", "rprivate"},
+		})
+	}
+
+	for i, m := range config.Mounts {
+		if i >= len(disks) {
+			break
+		}
+		mounts = append(mounts, specs.Mount{
+			Destination: m.Path,
+			Type:        "ext4",
+			Source:      disks[i].Path,
+			Options:     []string{"relatime"},
+		})
+	}
+
+	if config.WaitSecrets {
+		mounts = append(mounts, specs.Mount{
+			Destination: secretsDir,
+			Type:        "bind",
+			Source:      getSecretsDir(instanceDir),
+			Options:     []string{"rbind", "rprivate", "ro"},
+		})
+	}
+
+	return mounts
+}
+
+var etcFiles = []string{"hostname", "hosts", "resolv.conf"}
+
+// writeEtcFiles writes the files the init of a VM writes in /etc, they are bind mounted in the
+// container.
+func writeEtcFiles(instanceDir string, inst *instance.Instance) error {
+	dir := filepath.Join(instanceDir, "etc")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	hostname := common.GetHostname(inst)
+
+	hosts := strings.Builder{}
+	hosts.WriteString("127.0.0.1\tlocalhost localhost.localdomain localhost4 localhost4.localdomain4\n")
+	hosts.WriteString("::1\tlocalhost localhost.localdomain localhost6 localhost6.localdomain6\n")
+	fmt.Fprintf(&hosts, "%s\t%s\n", inst.Network.Local.InstanceIP, hostname)
+
+	files := map[string]string{
+		"hostname":    hostname + "\n",
+		"hosts":       hosts.String(),
+		"resolv.conf": "nameserver\t8.8.8.8\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write /etc/%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
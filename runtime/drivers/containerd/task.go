@@ -1,52 +1,165 @@
 package containerd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
 	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/initd"
+	"github.com/alexisbouchez/ravel/internal/id"
+	"github.com/alexisbouchez/ravel/internal/signals"
+	"github.com/alexisbouchez/ravel/runtime/disks"
 	"github.com/alexisbouchez/ravel/runtime/drivers"
-	"github.com/containerd/cgroups/v3/cgroup2"
 	"github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/pkg/cio"
 )
 
 // containerTask implements the drivers.InstanceTask interface for containerd containers.
-// Note: This is a simplified implementation that runs containers via containerd,
-// providing lower overhead than microVMs but less isolation.
+// The task of the container is created when it starts, or once the secrets are delivered
+// if the instance waits for them.
 type containerTask struct {
-	id            string
-	snapshotName  string
-	image         client.Image
-	config        *instance.InstanceConfig
-	ctrd          *client.Client
-	container     client.Container
+	id          string
+	container   client.Container
+	logs        *os.File
+	waitSecrets bool
+	secretsOnce sync.Once
+
+	mutex         sync.Mutex
 	task          client.Task
-	cgroupManager *cgroup2.Manager
-	cgroupPath    string
-	stopConfig    *api.StopConfig
-	exitStatus    <-chan client.ExitStatus
+	stopRequested atomic.Bool
+	exitOnce      sync.Once
+	exitResult    instance.ExitResult
 	waitChan      chan struct{}
-	exitResult    *instance.ExitResult
-	stopRequested bool
 }
 
 var _ drivers.InstanceTask = (*containerTask)(nil)
 
-// Start starts the container task.
+func newContainerTask(inst *instance.Instance, container client.Container, logs *os.File) *containerTask {
+	return &containerTask{
+		id:          inst.Id,
+		container:   container,
+		logs:        logs,
+		waitSecrets: inst.Config.WaitSecrets,
+		waitChan:    make(chan struct{}),
+	}
+}
+
+// Start starts the container task, the task of a container waiting for its secrets is started
+// by SetSecrets.
 func (ct *containerTask) Start(ctx context.Context) error {
-	// For containerd driver, we don't use high-level Container API
-	// Instead we work directly with containerd primitives
-	// This is a simplified placeholder - a full implementation would:
-	// 1. Create OCI runtime spec
-	// 2. Use containerd's runtime to start container
-	// 3. Monitor via containerd events
+	if ct.waitSecrets {
+		return nil
+	}
+
+	if err := ct.startTask(ctx); err != nil {
+		ct.exit(instance.ExitResult{ExitCode: -1, ExitedAt: time.Now()})
+		return err
+	}
+
+	return nil
+}
+
+func (ct *containerTask) startTask(ctx context.Context) error {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	if ct.exited() {
+		return fmt.Errorf("container %q has been stopped", ct.id)
+	}
+
+	task, err := ct.container.NewTask(ctx, cio.LogFile(getLogFile(ct.id)))
+	if err != nil {
+		return fmt.Errorf("failed to create task for container %q: %w", ct.id, err)
+	}
+
+	exitStatus, err := task.Wait(context.Background())
+	if err != nil {
+		task.Delete(context.Background(), client.WithProcessKill)
+		return fmt.Errorf("failed to wait task for container %q: %w", ct.id, err)
+	}
+
+	if err := task.Start(ctx); err != nil {
+		task.Delete(context.Background(), client.WithProcessKill)
+		return fmt.Errorf("failed to start task for container %q: %w", ct.id, err)
+	}
+
+	ct.task = task
+	go ct.monitor(task, exitStatus)
+
+	return nil
+}
+
+// attach monitors the running task of a recovered container.
+func (ct *containerTask) attach(task client.Task) error {
+	exitStatus, err := task.Wait(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to wait task for container %q: %w", ct.id, err)
+	}
+
+	ct.task = task
+	go ct.monitor(task, exitStatus)
+
+	return nil
+}
 
-	// For now, return an error indicating this needs proper implementation
-	return fmt.Errorf("containerd driver start not fully implemented - needs OCI runtime integration")
+// monitor waits for the task to exit and deletes it.
+func (ct *containerTask) monitor(task client.Task, exitStatus <-chan client.ExitStatus) {
+	status := <-exitStatus
+	code, exitedAt, err := status.Result()
+	if err != nil {
+		slog.Error("failed to get container exit status", "id", ct.id, "error", err)
+		code = 1
+		exitedAt = time.Now()
+	}
+
+	slog.Debug("container exited", "id", ct.id, "exitCode", code)
+
+	if _, err := task.Delete(context.Background()); err != nil {
+		slog.Error("failed to delete task", "id", ct.id, "error", err)
+	}
+
+	ct.exit(instance.ExitResult{
+		Success:   code == 0,
+		ExitCode:  int(code),
+		ExitedAt:  exitedAt,
+		Requested: ct.stopRequested.Load(),
+	})
+}
+
+// exit records the result of the container and releases the logs, the logger reads them until
+// their end.
+func (ct *containerTask) exit(result instance.ExitResult) {
+	ct.exitOnce.Do(func() {
+		ct.exitResult = result
+		ct.logs.Close()
+		close(ct.waitChan)
+	})
+}
+
+func (ct *containerTask) exited() bool {
+	select {
+	case <-ct.waitChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// getTask returns the task of the container, nil if it has not been started.
+func (ct *containerTask) getTask() client.Task {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+	return ct.task
 }
 
 // StartFromSnapshot starts the container by restoring from a snapshot.
@@ -57,65 +170,118 @@ func (ct *containerTask) StartFromSnapshot(ctx context.Context, globalSnapshotPa
 
 // Snapshot saves the container state to a file.
 // Note: Containerd containers don't support VM-style snapshots.
-func (ct *containerTask) Snapshot(ctx context.Context, path string) error {
+func (ct *containerTask) Snapshot(ctx context.Context, path string, snapshotDisks func() error) error {
 	return fmt.Errorf("containerd driver does not support snapshots")
 }
 
 // Restore restores the container state from a snapshot file.
 // Note: Containerd containers don't support VM-style snapshots.
-func (ct *containerTask) Restore(ctx context.Context, path string) error {
+func (ct *containerTask) Restore(ctx context.Context, path string, restoreDisks func() error) error {
 	return fmt.Errorf("containerd driver does not support snapshot restore")
 }
 
-// monitor watches the task and handles exit.
-func (ct *containerTask) monitor() {
-	defer close(ct.waitChan)
+// ResetIdentity is not supported, the containers are never restored from a snapshot.
+func (ct *containerTask) ResetIdentity(ctx context.Context, opts initd.ResetIdentityOptions) error {
+	return fmt.Errorf("containerd driver does not support identity reset")
+}
 
-	exitStatus := <-ct.exitStatus
-	ct.exitResult = &instance.ExitResult{
-		Success:   exitStatus.ExitCode() == 0,
-		ExitCode:  int(exitStatus.ExitCode()),
-		ExitedAt:  exitStatus.ExitTime(),
-		Requested: ct.stopRequested,
-	}
+// ResizeDisk is not supported, the disks are mounted by the runtime and not by an init which
+// could grow their filesystem.
+func (ct *containerTask) ResizeDisk(ctx context.Context, index int, disk disks.Disk) error {
+	return fmt.Errorf("containerd driver does not support disk resize")
+}
 
-	slog.Debug("container exited", "id", ct.id, "exitCode", exitStatus.ExitCode())
+// FreezeFilesystems is not supported, the containers do not own their disks.
+func (ct *containerTask) FreezeFilesystems(ctx context.Context) error {
+	return fmt.Errorf("containerd driver does not support filesystem freeze")
+}
 
-	// Cleanup task
-	if ct.task != nil {
-		if _, err := ct.task.Delete(context.Background()); err != nil {
-			slog.Error("failed to delete task", "id", ct.id, "error", err)
-		}
-	}
+func (ct *containerTask) ThawFilesystems(ctx context.Context) error {
+	return fmt.Errorf("containerd driver does not support filesystem freeze")
+}
+
+func (ct *containerTask) Devices(ctx context.Context) ([]string, error) {
+	return nil, fmt.Errorf("containerd driver does not support migrations")
 }
 
-// Stop stops the container with the configured signal.
+// SendMigration is not supported, the containers cannot be live migrated.
+func (ct *containerTask) SendMigration(ctx context.Context, conn io.ReadWriteCloser) error {
+	return fmt.Errorf("containerd driver does not support migrations")
+}
+
+func (ct *containerTask) ReceiveMigration(ctx context.Context, conn io.ReadWriteCloser) error {
+	return fmt.Errorf("containerd driver does not support migrations")
+}
+
+// Stop stops the container with the configured signal. A container still waiting for its
+// secrets exits right away.
 func (ct *containerTask) Stop(ctx context.Context, signal string) error {
-	ct.stopRequested = true
-	return fmt.Errorf("not implemented")
+	ct.stopRequested.Store(true)
+	if ct.exitIfNotStarted() {
+		return nil
+	}
+
+	if signal == "" {
+		signal = api.DefaultStopSignal
+	}
+
+	return ct.Signal(ctx, signal)
 }
 
 // Signal sends a signal to the container process.
 func (ct *containerTask) Signal(ctx context.Context, signal string) error {
-	return fmt.Errorf("not implemented")
+	sig, ok := signals.FromString(signal)
+	if !ok {
+		return errdefs.NewInvalidArgument("invalid signal: " + signal)
+	}
+
+	return ct.kill(ctx, sig)
 }
 
 // Shutdown forcefully stops the container.
 func (ct *containerTask) Shutdown(ctx context.Context) error {
-	return nil // Cleanup handled in CleanupInstanceTask
+	if ct.exitIfNotStarted() {
+		return nil
+	}
+
+	return ct.kill(ctx, syscall.SIGKILL)
+}
+
+// exitIfNotStarted makes a container whose task has not been started exit, it reports whether
+// the container had no task.
+func (ct *containerTask) exitIfNotStarted() bool {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	if ct.task != nil {
+		return false
+	}
+
+	ct.exit(instance.ExitResult{
+		Success:   true,
+		ExitedAt:  time.Now(),
+		Requested: ct.stopRequested.Load(),
+	})
+	return true
+}
+
+func (ct *containerTask) kill(ctx context.Context, sig syscall.Signal) error {
+	task := ct.getTask()
+	if task == nil {
+		return errdefs.NewFailedPrecondition("container is not running")
+	}
+
+	if err := task.Kill(ctx, sig); err != nil {
+		return fmt.Errorf("failed to signal container %q: %w", ct.id, err)
+	}
+
+	return nil
 }
 
 // Run waits for the container to exit and returns the result.
 func (ct *containerTask) Run() instance.ExitResult {
 	<-ct.waitChan
-	if ct.exitResult == nil {
-		return instance.ExitResult{
-			Success:  false,
-			ExitCode: -1,
-			ExitedAt: time.Now(),
-		}
-	}
-	return *ct.exitResult
+	return ct.exitResult
 }
 
 // WaitExit waits for the container to exit.
@@ -128,23 +294,75 @@ func (ct *containerTask) WaitExit(ctx context.Context) bool {
 	}
 }
 
-// Exec executes a command inside the running container.
+// Exec executes a command inside the running container, with the user, the environment and the
+// working directory of its process. The command is killed once the timeout expires.
 func (ct *containerTask) Exec(ctx context.Context, cmd []string, timeout time.Duration) (*api.ExecResult, error) {
-	return nil, fmt.Errorf("not implemented")
-}
-
-// parseSignal converts signal string to syscall.Signal.
-func parseSignal(signal string) syscall.Signal {
-	switch signal {
-	case "SIGTERM":
-		return syscall.SIGTERM
-	case "SIGKILL":
-		return syscall.SIGKILL
-	case "SIGINT":
-		return syscall.SIGINT
-	case "SIGHUP":
-		return syscall.SIGHUP
-	default:
-		return syscall.SIGTERM
+	if len(cmd) == 0 {
+		return nil, errdefs.NewInvalidArgument("cmd cannot be empty")
+	}
+
+	task := ct.getTask()
+	if task == nil {
+		return nil, errdefs.NewFailedPrecondition("container is not running")
 	}
+
+	spec, err := ct.container.Spec(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container spec: %w", err)
+	}
+
+	pspec := *spec.Process
+	pspec.Args = cmd
+	pspec.Terminal = false
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+
+	process, err := task.Exec(ctx, "exec-"+id.Generate(), &pspec, cio.NewCreator(cio.WithStreams(nil, &stdout, &stderr)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exec in container %q: %w", ct.id, err)
+	}
+	defer process.Delete(context.Background(), client.WithProcessKill)
+
+	exitStatus, err := process.Wait(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait exec in container %q: %w", ct.id, err)
+	}
+
+	if err := process.Start(ctx); err != nil {
+		return nil, errdefs.NewInvalidArgument(err.Error())
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	exitCode := -1
+	select {
+	case status := <-exitStatus:
+		exitCode = int(status.ExitCode())
+	case <-timeoutCtx.Done():
+		// killed like the init kills the commands running past their timeout
+		if err := process.Kill(context.Background(), syscall.SIGKILL); err != nil {
+			slog.Error("failed to kill exec process", "id", ct.id, "error", err)
+		}
+		<-exitStatus
+	}
+
+	process.IO().Wait()
+
+	return &api.ExecResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: exitCode,
+	}, nil
+}
+
+// ExecStream runs a command inside the running container and streams its output.
+func (ct *containerTask) ExecStream(ctx context.Context, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("containerd driver does not support streamed exec")
+}
+
+// ExecSession opens an interactive exec session inside the running container.
+func (ct *containerTask) ExecSession(ctx context.Context) (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("containerd driver does not support interactive exec")
 }
@@ -2,10 +2,12 @@ package drivers
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/initd"
 	"github.com/alexisbouchez/ravel/runtime/disks"
 )
 
@@ -15,21 +17,53 @@ type InstanceTask interface {
 	// globalSnapshotPath is the source on the host, jailSnapshotPath is the jail-relative destination
 	StartFromSnapshot(ctx context.Context, globalSnapshotPath, jailSnapshotPath string) error
 	Exec(ctx context.Context, cmd []string, timeout time.Duration) (*api.ExecResult, error)
+	// ExecStream starts a streamed exec or attaches to it, see api.ExecStreamRequest
+	ExecStream(ctx context.Context, req api.ExecStreamRequest) (io.ReadCloser, error)
+	// ExecSession opens an interactive exec session, see api.ExecSessionOptions
+	ExecSession(ctx context.Context) (io.ReadWriteCloser, error)
 	Run() instance.ExitResult
 	WaitExit(ctx context.Context) bool
 	Signal(ctx context.Context, signal string) error
 	Stop(ctx context.Context, signal string) error
 	Shutdown(ctx context.Context) error
-	// Snapshot saves the VM state to a file for fast restore
-	Snapshot(ctx context.Context, path string) error
-	// Restore restores the VM state from a snapshot file
-	Restore(ctx context.Context, path string) error
+	// Snapshot saves the VM state and a copy of its root filesystem in a directory of the host
+	// for fast restore, snapshotDisks snapshots the additional disks while the VM is paused
+	Snapshot(ctx context.Context, dir string, snapshotDisks func() error) error
+	// Restore restores the VM state and its root filesystem from a snapshot saved in a directory
+	// of the host, restoreDisks rolls the additional disks back while the VM is paused
+	Restore(ctx context.Context, dir string, restoreDisks func() error) error
+	// ResetIdentity gives its own hostname, network and random state to a VM restored
+	// from the snapshot of another instance
+	ResetIdentity(ctx context.Context, opts initd.ResetIdentityOptions) error
+	// SetSecrets delivers the secrets to the guest of an instance configured to wait for them,
+	// which starts its workload
+	SetSecrets(ctx context.Context, secrets initd.Secrets) error
+	// ResizeDisk notifies the guest that an additional disk, at the given index in the
+	// instance mounts, has been grown and grows its filesystem
+	ResizeDisk(ctx context.Context, index int, disk disks.Disk) error
+	// FreezeFilesystems suspends the writes of the guest to its disks until they are thawed
+	FreezeFilesystems(ctx context.Context) error
+	ThawFilesystems(ctx context.Context) error
+	// Devices returns the paths of the disks given to the VMM, the root filesystem first
+	Devices(ctx context.Context) ([]string, error)
+	// SendMigration sends the running VM over conn to a task receiving it, the VM is gone
+	// once the migration is sent
+	SendMigration(ctx context.Context, conn io.ReadWriteCloser) error
+	// ReceiveMigration starts the VMM of a task built by BuildIncomingInstanceTask and
+	// receives the VM sent over conn, it runs once received
+	ReceiveMigration(ctx context.Context, conn io.ReadWriteCloser) error
 }
 
 type Driver interface {
 	BuildInstanceTask(ctx context.Context, instance *instance.Instance, disks []disks.Disk) (InstanceTask, error)
 	CleanupInstanceTask(ctx context.Context, instance *instance.Instance) error
+	// BuildIncomingInstanceTask builds the task of an instance receiving a VM migrated from
+	// another node, the disks are given to the VMM at the devices paths of the source VMM
+	BuildIncomingInstanceTask(ctx context.Context, instance *instance.Instance, disks []disks.Disk, devices []string) (InstanceTask, error)
 	RecoverInstanceTask(ctx context.Context, i *instance.Instance) (InstanceTask, error)
 	CleanupInstance(ctx context.Context, instance *instance.Instance) error
+	// RootFS returns the device of the root filesystem of an instance, it is prepared if the
+	// instance task has not been built yet
+	RootFS(ctx context.Context, instance *instance.Instance) (string, error)
 	Snapshotter() string
 }
@@ -256,10 +256,26 @@ func (d *Driver) CleanupInstance(ctx context.Context, inst *instance.Instance) e
 	return nil
 }
 
+// BuildIncomingInstanceTask is not supported, Firecracker has no live migration.
+func (d *Driver) BuildIncomingInstanceTask(ctx context.Context, inst *instance.Instance, instanceDisks []disks.Disk, devices []string) (drivers.InstanceTask, error) {
+	return nil, fmt.Errorf("firecracker driver does not support migrations")
+}
+
+// RootFS is not supported, the root filesystem is only used by the migrations.
+func (d *Driver) RootFS(ctx context.Context, inst *instance.Instance) (string, error) {
+	return "", fmt.Errorf("firecracker driver does not support migrations")
+}
+
 // RecoverInstanceTask implements drivers.Driver.
 func (d *Driver) RecoverInstanceTask(ctx context.Context, inst *instance.Instance) (drivers.InstanceTask, error) {
+	rootfs, err := d.getRootFS(ctx, inst.Id)
+	if err != nil {
+		slog.Warn("failed to get the root filesystem of the recovered vm", "id", inst.Id, "error", err)
+	}
+
 	vm := &firecrackerVM{
 		id:       inst.Id,
+		rootfs:   rootfs,
 		waitChan: make(chan struct{}),
 	}
 
@@ -292,6 +308,20 @@ func (d *Driver) removeRootFS(id string) error {
 	return nil
 }
 
+// getRootFS returns the device of the root filesystem of an instance, it must have been prepared.
+func (d *Driver) getRootFS(ctx context.Context, id string) (string, error) {
+	mounts, err := d.snapshotter.Mounts(ctx, rootFSName(id))
+	if err != nil {
+		return "", err
+	}
+
+	if len(mounts) == 0 {
+		return "", fmt.Errorf("no mounts found for instance %q", id)
+	}
+
+	return mounts[0].Source, nil
+}
+
 func (d *Driver) prepareRootFS(ctx context.Context, id string, image client.Image) (rootfs string, err error) {
 	slog.Debug("preparing rootfs for container", "id", id)
 	diffIDs, err := image.RootFS(ctx)
@@ -3,6 +3,7 @@ package firecracker
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -12,8 +13,10 @@ import (
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/initd"
 	initdclient "github.com/alexisbouchez/ravel/initd/client"
 	"github.com/alexisbouchez/ravel/pkg/firecracker"
+	"github.com/alexisbouchez/ravel/runtime/disks"
 	"github.com/alexisbouchez/ravel/runtime/drivers"
 	"github.com/alexisbouchez/ravel/runtime/drivers/common"
 )
@@ -27,6 +30,7 @@ type firecrackerVM struct {
 	initClient           *initdclient.InternalClient
 	successfullyShutdown atomic.Bool
 	vmConfig             VMConfig
+	rootfs               string // device of the root filesystem on the host
 	stopRequested        bool
 	waitChan             chan struct{}
 }
@@ -45,6 +49,7 @@ func newVM(id string, cmd *exec.Cmd, vmConfig VMConfig) *firecrackerVM {
 		id:         id,
 		cmd:        cmd,
 		vmConfig:   vmConfig,
+		rootfs:     vmConfig.RootfsPath,
 		vmm:        vmm,
 		waitChan:   make(chan struct{}),
 		initClient: client,
@@ -145,6 +150,13 @@ func (vm *firecrackerVM) StartFromSnapshot(ctx context.Context, globalSnapshotPa
 
 	slog.Debug("snapshot copied", "from", globalSnapshotPath, "to", jailHostPath)
 
+	// the memory of the snapshot expects the root filesystem as it was when it was taken
+	restored, err := common.RestoreSnapshotRootFS(globalSnapshotPath, vm.rootfs)
+	if err != nil {
+		return err
+	}
+	slog.Debug("snapshot root filesystem restored", "id", vm.id, "restored", restored)
+
 	err = vm.cmd.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start firecracker for machine %q: %w", vm.Id(), err)
@@ -176,12 +188,14 @@ func (vm *firecrackerVM) StartFromSnapshot(ctx context.Context, globalSnapshotPa
 }
 
 // Snapshot implements drivers.InstanceTask.
-func (vm *firecrackerVM) Snapshot(ctx context.Context, path string) error {
-	// Create the directory on the host
+func (vm *firecrackerVM) Snapshot(ctx context.Context, dir string, snapshotDisks func() error) error {
+	// Firecracker runs in the jail, the snapshot is taken in the jail then moved to dir
+	path := common.GetJailSnapshotPath(dir)
 	hostPath := getInstanceDir(vm.id) + path
 	if err := os.MkdirAll(hostPath, 0755); err != nil {
 		return fmt.Errorf("failed to create snapshot directory: %w", err)
 	}
+	defer os.RemoveAll(hostPath)
 
 	// Chown to ravel-jailer user
 	jailerUid, jailerGid, err := common.SetupRavelJailerUser()
@@ -193,21 +207,137 @@ func (vm *firecrackerVM) Snapshot(ctx context.Context, path string) error {
 	}
 
 	// Create snapshot (Firecracker requires path relative to its chroot)
+	if err := vm.vmm.Pause(ctx); err != nil {
+		return fmt.Errorf("failed to pause VM before snapshot: %w", err)
+	}
+
 	snapshotFile := path + "/snapshot"
 	memFile := path + "/mem"
-	if err := vm.vmm.Snapshot(ctx, snapshotFile, memFile); err != nil {
-		return fmt.Errorf("failed to create snapshot: %w", err)
+	if err := vm.vmm.CreateSnapshot(ctx, snapshotFile, memFile); err != nil {
+		vm.vmm.Resume(ctx)
+		return err
+	}
+
+	// the root filesystem is copied while the VM is paused, it is consistent with the memory
+	if err := common.SaveSnapshotRootFS(vm.rootfs, hostPath); err != nil {
+		vm.vmm.Resume(ctx)
+		return fmt.Errorf("failed to copy root filesystem: %w", err)
+	}
+
+	if err := snapshotDisks(); err != nil {
+		vm.vmm.Resume(ctx)
+		return fmt.Errorf("failed to snapshot disks: %w", err)
+	}
+
+	if err := vm.vmm.Resume(ctx); err != nil {
+		return fmt.Errorf("failed to resume VM after snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+
+	if err := os.Rename(hostPath, dir); err != nil {
+		return fmt.Errorf("failed to move snapshot: %w", err)
 	}
 
 	return nil
 }
 
 // Restore implements drivers.InstanceTask.
-func (vm *firecrackerVM) Restore(ctx context.Context, path string) error {
+func (vm *firecrackerVM) Restore(ctx context.Context, dir string, restoreDisks func() error) error {
+	path := common.GetJailSnapshotPath(dir)
+	hostPath := getInstanceDir(vm.id) + path
+
+	// the snapshot files are linked in the jail, a previous restore may have left them
+	if err := os.RemoveAll(hostPath); err != nil {
+		return fmt.Errorf("failed to clean snapshot directory: %w", err)
+	}
+	if err := copyDirWithLinks(dir, hostPath); err != nil {
+		return fmt.Errorf("failed to copy snapshot to jail: %w", err)
+	}
+
+	jailerUid, jailerGid, err := common.SetupRavelJailerUser()
+	if err != nil {
+		return fmt.Errorf("failed to get jailer user: %w", err)
+	}
+	if err := chownRecursive(hostPath, jailerUid, jailerGid); err != nil {
+		return fmt.Errorf("failed to chown snapshot directory: %w", err)
+	}
+
+	// the disks are written back while the VM is paused, the memory of the snapshot expects
+	// them as they were when it was taken
+	if err := vm.vmm.Pause(ctx); err != nil {
+		return fmt.Errorf("failed to pause VM before restore: %w", err)
+	}
+
+	if _, err := common.RestoreSnapshotRootFS(dir, vm.rootfs); err != nil {
+		return vm.abortRestore(ctx, fmt.Errorf("failed to restore root filesystem: %w", err))
+	}
+
+	if err := restoreDisks(); err != nil {
+		return vm.abortRestore(ctx, fmt.Errorf("failed to restore disks: %w", err))
+	}
+
 	snapshotFile := path + "/snapshot"
 	memFile := path + "/mem"
 	if err := vm.vmm.LoadSnapshot(ctx, snapshotFile, memFile, true); err != nil {
-		return fmt.Errorf("failed to restore from snapshot: %w", err)
+		return vm.abortRestore(ctx, fmt.Errorf("failed to restore from snapshot: %w", err))
+	}
+
+	return nil
+}
+
+// abortRestore shuts the VM down after its disks may have been written by a failed restore,
+// its memory does not match them anymore.
+func (vm *firecrackerVM) abortRestore(ctx context.Context, err error) error {
+	if err := vm.shutdown(ctx); err != nil {
+		slog.Error("failed to shutdown vm after a failed restore", "id", vm.id, "error", err)
+	}
+	return err
+}
+
+// ResetIdentity implements drivers.InstanceTask.
+func (vm *firecrackerVM) ResetIdentity(ctx context.Context, opts initd.ResetIdentityOptions) error {
+	return vm.initClient.ResetIdentity(ctx, opts)
+}
+
+func (vm *firecrackerVM) SetSecrets(ctx context.Context, secrets initd.Secrets) error {
+	return vm.initClient.SetSecrets(ctx, secrets)
+}
+
+// FreezeFilesystems implements drivers.InstanceTask.
+func (vm *firecrackerVM) FreezeFilesystems(ctx context.Context) error {
+	return vm.initClient.FreezeFilesystems(ctx)
+}
+
+// ThawFilesystems implements drivers.InstanceTask.
+func (vm *firecrackerVM) ThawFilesystems(ctx context.Context) error {
+	return vm.initClient.ThawFilesystems(ctx)
+}
+
+// Devices is not supported, Firecracker has no live migration.
+func (vm *firecrackerVM) Devices(ctx context.Context) ([]string, error) {
+	return nil, fmt.Errorf("firecracker driver does not support migrations")
+}
+
+func (vm *firecrackerVM) SendMigration(ctx context.Context, conn io.ReadWriteCloser) error {
+	return fmt.Errorf("firecracker driver does not support migrations")
+}
+
+func (vm *firecrackerVM) ReceiveMigration(ctx context.Context, conn io.ReadWriteCloser) error {
+	return fmt.Errorf("firecracker driver does not support migrations")
+}
+
+// ResizeDisk implements drivers.InstanceTask.
+func (vm *firecrackerVM) ResizeDisk(ctx context.Context, index int, disk disks.Disk) error {
+	driveID := fmt.Sprintf("disk%d", index+1)
+	if err := vm.vmm.RescanDrive(ctx, driveID, disk.Path); err != nil {
+		return err
+	}
+
+	if err := vm.initClient.GrowFilesystem(ctx, common.GetVirtioDiskPath(index+1)); err != nil {
+		return fmt.Errorf("failed to grow filesystem: %w", err)
 	}
 
 	return nil
@@ -245,6 +375,16 @@ func (vm *firecrackerVM) Exec(ctx context.Context, cmd []string, timeout time.Du
 	}, nil
 }
 
+// ExecStream implements drivers.InstanceTask.
+func (vm *firecrackerVM) ExecStream(ctx context.Context, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	return vm.initClient.ExecStream(ctx, req)
+}
+
+// ExecSession implements drivers.InstanceTask.
+func (vm *firecrackerVM) ExecSession(ctx context.Context) (io.ReadWriteCloser, error) {
+	return vm.initClient.ExecSession(ctx)
+}
+
 // Stop implements drivers.InstanceTask.
 func (vm *firecrackerVM) Stop(ctx context.Context, signal string) error {
 	vm.stopRequested = true
@@ -425,7 +565,7 @@ func copyDirWithLinks(src, dst string) error {
 			if err := copyDirWithLinks(srcPath, dstPath); err != nil {
 				return err
 			}
-		} else {
+		} else if entry.Name() != common.SnapshotRootFSFile { // restored by the host
 			// Try hard link first (instant, no copy), fall back to copy
 			if err := os.Link(srcPath, dstPath); err != nil {
 				if err := copyFile(srcPath, dstPath); err != nil {
@@ -13,6 +13,7 @@ func getAdditionalDisks(disks []disks.Disk) []cloudhypervisor.DiskConfig {
 	additionalDisks := make([]cloudhypervisor.DiskConfig, 0, len(disks))
 	for _, d := range disks {
 		additionalDisks = append(additionalDisks, cloudhypervisor.DiskConfig{
+			Id:   cloudhypervisor.StringPtr(d.Id), // used to resize the disk
 			Path: d.Path,
 		})
 	}
@@ -22,6 +22,7 @@ import (
 	"github.com/containerd/cgroups/v3/cgroup2"
 	"github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/errdefs"
 )
 
 const (
@@ -107,6 +108,20 @@ var _ drivers.Driver = (*Driver)(nil)
 
 // BuildInstanceTask implements instance.VMBuilder.
 func (b *Driver) BuildInstanceTask(ctx context.Context, instance *instance.Instance, disks []disks.Disk) (drivers.InstanceTask, error) {
+	return b.buildInstanceTask(ctx, instance, disks, nil)
+}
+
+// BuildIncomingInstanceTask implements drivers.Driver.
+func (b *Driver) BuildIncomingInstanceTask(ctx context.Context, instance *instance.Instance, disks []disks.Disk, devices []string) (drivers.InstanceTask, error) {
+	if len(devices) != len(disks)+1 {
+		return nil, fmt.Errorf("expected %d devices, got %d", len(disks)+1, len(devices))
+	}
+	return b.buildInstanceTask(ctx, instance, disks, devices)
+}
+
+// buildInstanceTask builds the task of an instance, the disks are created in the jail at the
+// paths of devices if it is set, the root filesystem first, and at their host paths otherwise.
+func (b *Driver) buildInstanceTask(ctx context.Context, instance *instance.Instance, disks []disks.Disk, devices []string) (drivers.InstanceTask, error) {
 	_, err := tap.PrepareInstanceTapDevice(instance.Id, instance.Network, b.jailerUser.Uid, b.jailerUser.Gid)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare tap device: %w", err)
@@ -121,24 +136,18 @@ func (b *Driver) BuildInstanceTask(ctx context.Context, instance *instance.Insta
 	}()
 
 	startTime := time.Now()
-	image, err := b.ctrd.GetImage(ctx, instance.ImageRef)
-	if err != nil {
-		return nil, err
-	}
-
-	isUnpacked, err := image.IsUnpacked(ctx, b.Snapshotter())
+	image, err := b.getImage(ctx, instance.ImageRef)
 	if err != nil {
 		return nil, err
 	}
 
-	if !isUnpacked {
-		err = image.Unpack(ctx, b.Snapshotter())
-		if err != nil {
-			return nil, err
-		}
+	var rootfs string
+	if devices == nil {
+		rootfs, err = b.prepareRootFS(ctx, instance.Id, image)
+	} else {
+		// the root filesystem of a migrated instance is prepared and filled before its task is built
+		rootfs, err = b.getRootFS(ctx, instance.Id)
 	}
-
-	rootfs, err := b.prepareRootFS(ctx, instance.Id, image)
 	if err != nil {
 		return nil, err
 	}
@@ -160,19 +169,26 @@ func (b *Driver) BuildInstanceTask(ctx context.Context, instance *instance.Insta
 		return nil, fmt.Errorf("failed to create cgroup: %w", err)
 	}
 
+	if devices == nil {
+		devices = []string{rootfs}
+		for _, disk := range disks {
+			devices = append(devices, disk.Path)
+		}
+	}
+
 	opts := []jailer.Opt{
 		jailer.WithTUN(),
 		jailer.WithKVM(),
 		jailer.WithURandom(),
-		jailer.WithBlockDevice(rootfs),
+		jailer.WithBlockDeviceAt(rootfs, devices[0]),
 		jailer.WithBinary(b.chBinary, "/cloud-hypervisor"),
 		jailer.WithHardLink(b.linuxKernel, "/vmlinux.bin", true),
 		jailer.WithNewPidNS(),
 		jailer.WithMountProc(),
 		jailer.WithCgroup("/ravel/" + instance.Id),
 	}
-	for _, disk := range disks {
-		opts = append(opts, jailer.WithBlockDevice(disk.Path))
+	for i, disk := range disks {
+		opts = append(opts, jailer.WithBlockDeviceAt(disk.Path, devices[i+1]))
 	}
 
 	jail, err := jailer.CreateJail(
@@ -210,11 +226,47 @@ func (b *Driver) BuildInstanceTask(ctx context.Context, instance *instance.Insta
 	}
 
 	vmConfig := b.getContainerMachineCHVmConfig(instance, rootfs, disks)
-	vm := newVM(instance.Id, cmd, vmConfig)
+	vm := newVM(instance.Id, cmd, vmConfig, rootfs)
 
 	return vm, nil
 }
 
+func (b *Driver) getImage(ctx context.Context, ref string) (client.Image, error) {
+	image, err := b.ctrd.GetImage(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	isUnpacked, err := image.IsUnpacked(ctx, b.Snapshotter())
+	if err != nil {
+		return nil, err
+	}
+
+	if !isUnpacked {
+		err = image.Unpack(ctx, b.Snapshotter())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
+// RootFS implements drivers.Driver.
+func (b *Driver) RootFS(ctx context.Context, instance *instance.Instance) (string, error) {
+	rootfs, err := b.getRootFS(ctx, instance.Id)
+	if err == nil || !errdefs.IsNotFound(err) {
+		return rootfs, err
+	}
+
+	image, err := b.getImage(ctx, instance.ImageRef)
+	if err != nil {
+		return "", err
+	}
+
+	return b.prepareRootFS(ctx, instance.Id, image)
+}
+
 // CleanupInstanceTask implements instance.VMBuilder.
 func (b *Driver) CleanupInstanceTask(ctx context.Context, instance *instance.Instance) error {
 	var errs []error
@@ -263,9 +315,15 @@ func (b *Driver) RecoverInstanceTask(ctx context.Context, i *instance.Instance)
 
 	client := initdclient.NewInternalClient(getVsockPath(i.Id))
 
+	rootfs, err := b.getRootFS(ctx, i.Id)
+	if err != nil {
+		slog.Warn("failed to get the root filesystem of the recovered vm", "id", i.Id, "error", err)
+	}
+
 	vm := &vm{
 		id:         i.Id,
 		vmm:        vmm,
+		rootfs:     rootfs,
 		waitChan:   make(chan struct{}),
 		initClient: client,
 	}
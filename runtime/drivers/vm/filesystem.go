@@ -37,6 +37,20 @@ func (b *Driver) prepareRootFS(ctx context.Context, id string, image client.Imag
 	return rootfs, nil
 }
 
+// getRootFS returns the device of the root filesystem of an instance, it must have been prepared.
+func (b *Driver) getRootFS(ctx context.Context, id string) (string, error) {
+	mounts, err := b.snapshotter.Mounts(ctx, rootFSName(id))
+	if err != nil {
+		return "", err
+	}
+
+	if len(mounts) == 0 {
+		return "", fmt.Errorf("no mounts found for instance %q", id)
+	}
+
+	return mounts[0].Source, nil
+}
+
 func rootFSName(id string) string {
 	return fmt.Sprintf("%s-%s", id, "rootfs")
 }
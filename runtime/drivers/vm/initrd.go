@@ -86,17 +86,10 @@ func getInitConfig(instance *instance.Instance, image v1.ImageConfig) initd.Conf
 		EtcResolv: initd.EtcResolv{
 			Nameservers: []string{"8.8.8.8"},
 		},
-		ExtraEnv: config.Env,
-		Network: initd.NetworkConfig{
-			IPConfigs: []initd.IPConfig{
-				{
-					IPNet:     instance.Network.Local.InstanceIPNet().String(),
-					Broadcast: instance.Network.Local.Broadcast.String(),
-					Gateway:   instance.Network.Local.Gateway.String(),
-				},
-			},
-			DefaultGateway: instance.Network.Local.Gateway.String(),
-		},
-		Mounts: mounts,
+		Hostname:    common.GetHostname(instance),
+		ExtraEnv:    config.Env,
+		WaitSecrets: config.WaitSecrets,
+		Network:     common.NetworkConfig(instance.Network),
+		Mounts:      mounts,
 	}
 }
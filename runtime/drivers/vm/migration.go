@@ -0,0 +1,164 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"time"
+
+	"github.com/alexisbouchez/ravel/internal/streamutil"
+	"github.com/alexisbouchez/ravel/runtime/drivers/common"
+)
+
+// CloudHypervisor sends and receives the migrations on a socket of the jail, the agent relays
+// it to the other node.
+const migrationSocketPath = "/migration.sock"
+
+func getMigrationSocketPath(id string) string {
+	return path.Join(getInstanceDir(id), "migration.sock")
+}
+
+// FreezeFilesystems implements drivers.InstanceTask.
+func (vm *vm) FreezeFilesystems(ctx context.Context) error {
+	return vm.initClient.FreezeFilesystems(ctx)
+}
+
+// ThawFilesystems implements drivers.InstanceTask.
+func (vm *vm) ThawFilesystems(ctx context.Context) error {
+	return vm.initClient.ThawFilesystems(ctx)
+}
+
+// Devices implements drivers.InstanceTask.
+func (vm *vm) Devices(ctx context.Context) ([]string, error) {
+	info, err := vm.vmm.VMInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Config.Disks == nil {
+		return nil, nil
+	}
+
+	devices := make([]string, 0, len(*info.Config.Disks))
+	for _, disk := range *info.Config.Disks {
+		devices = append(devices, disk.Path)
+	}
+
+	return devices, nil
+}
+
+// SendMigration implements drivers.InstanceTask. CloudHypervisor connects to a socket of the jail
+// listened by the agent, the connection is relayed to conn.
+func (vm *vm) SendMigration(ctx context.Context, conn io.ReadWriteCloser) error {
+	socketPath := getMigrationSocketPath(vm.id)
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on migration socket: %w", err)
+	}
+	defer os.Remove(socketPath)
+	defer l.Close()
+
+	jailerUid, jailerGid, err := common.SetupRavelJailerUser()
+	if err != nil {
+		return fmt.Errorf("failed to get jailer user: %w", err)
+	}
+	if err := os.Chown(socketPath, jailerUid, jailerGid); err != nil {
+		return fmt.Errorf("failed to chown migration socket: %w", err)
+	}
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- vm.vmm.SendMigration(ctx, "unix:"+migrationSocketPath)
+	}()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		sock, err := l.Accept()
+		if err == nil {
+			accepted <- sock
+		}
+	}()
+
+	select {
+	case err := <-sent:
+		if err == nil {
+			err = errors.New("the migration has been sent without connecting to the socket")
+		}
+		return err
+	case sock := <-accepted:
+		go streamutil.Relay(sock, conn)
+	}
+
+	return <-sent
+}
+
+// ReceiveMigration implements drivers.InstanceTask. CloudHypervisor listens on a socket of the
+// jail, the agent connects to it and relays conn.
+func (vm *vm) ReceiveMigration(ctx context.Context, conn io.ReadWriteCloser) (err error) {
+	err = vm.cmd.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start vmm for machine %q: %w", vm.Id(), err)
+	}
+	defer func() {
+		if err != nil {
+			vm.vmm.ShutdownVMM(context.Background())
+		}
+	}()
+
+	err = vm.vmm.WaitReady(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for vmm to be ready for machine %q: %w", vm.Id(), err)
+	}
+
+	received := make(chan error, 1)
+	go func() {
+		received <- vm.vmm.ReceiveMigration(ctx, "unix:"+migrationSocketPath)
+	}()
+
+	sock, err := dialMigrationSocket(ctx, getMigrationSocketPath(vm.id), received)
+	if err != nil {
+		return err
+	}
+	go streamutil.Relay(sock, conn)
+
+	err = <-received
+	if err != nil {
+		return err
+	}
+
+	go vm.run()
+
+	return nil
+}
+
+// dialMigrationSocket connects to the migration socket once CloudHypervisor listens on it.
+func dialMigrationSocket(ctx context.Context, socketPath string, received <-chan error) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	for {
+		sock, err := net.Dial("unix", socketPath)
+		if err == nil {
+			return sock, nil
+		}
+
+		select {
+		case err := <-received:
+			if err == nil {
+				err = errors.New("the migration has been received without connecting to the socket")
+			}
+			return nil, err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to connect to migration socket: %w", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
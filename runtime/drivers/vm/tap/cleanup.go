@@ -5,12 +5,19 @@ import (
 	"fmt"
 
 	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/runtime/wireguard"
 )
 
 func CleanupInstanceTapDevice(id string, config instance.NetworkingConfig) error {
 	tapName := config.TapDevice
 	errs := []error{}
 
+	for _, pn := range config.PrivateNetworks {
+		if err := wireguard.CleanupInstanceNetwork(tapName, pn); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cleanup private network %s: %w", pn.NetworkName, err))
+		}
+	}
+
 	err := cleanupTapDeviceConfig(config)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("failed to cleanup tap device config: %w", err))
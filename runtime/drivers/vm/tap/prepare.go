@@ -1,7 +1,10 @@
 package tap
 
 import (
+	"fmt"
+
 	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/runtime/wireguard"
 )
 
 func PrepareInstanceTapDevice(id string, config instance.NetworkingConfig, uid, gid int) (string, error) {
@@ -16,9 +19,29 @@ func PrepareInstanceTapDevice(id string, config instance.NetworkingConfig, uid,
 		}
 	}()
 
-	if err := configureTapDevice(tapName, config); err != nil {
+	if err = setupInstanceLink(tapName, config); err != nil {
 		return "", err
 	}
 
 	return tapName, nil
 }
+
+// setupInstanceLink configures the host side of the link of an instance, its address, the
+// forwarding of its traffic and its private networks.
+func setupInstanceLink(name string, config instance.NetworkingConfig) error {
+	if err := configureTapDevice(name, config); err != nil {
+		return err
+	}
+
+	for i, pn := range config.PrivateNetworks {
+		if err := wireguard.SetupInstanceNetwork(name, pn); err != nil {
+			for _, previous := range config.PrivateNetworks[:i] {
+				wireguard.CleanupInstanceNetwork(name, previous)
+			}
+			cleanupTapDeviceConfig(config)
+			return fmt.Errorf("failed to setup private network %s: %w", pn.NetworkName, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package tap
+
+import (
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// PrepareInstanceVethDevice creates a veth pair for an instance running in the network namespace
+// ns instead of a VM. The host side is named after the tap device of the instance and configured
+// like it, the peer is moved to the namespace as peer. It is cleaned up by
+// CleanupInstanceTapDevice.
+func PrepareInstanceVethDevice(id string, config instance.NetworkingConfig, ns netns.NsHandle, peer string) (string, error) {
+	name := config.TapDevice
+	err := createVeth(name, ns, peer)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			deleteTap(name)
+		}
+	}()
+
+	if err = setupInstanceLink(name, config); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+func createVeth(name string, ns netns.NsHandle, peer string) error {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: name,
+		},
+		PeerName:      peer,
+		PeerNamespace: netlink.NsFd(ns),
+	}
+
+	if err := deleteTap(name); err != nil {
+		return err
+	}
+
+	if err := netlink.LinkAdd(veth); err != nil {
+		return err
+	}
+
+	if err := netlink.LinkSetUp(veth); err != nil {
+		return err
+	}
+
+	return nil
+}
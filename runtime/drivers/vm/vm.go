@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -13,8 +14,10 @@ import (
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/initd"
 	"github.com/alexisbouchez/ravel/initd/client"
 	"github.com/alexisbouchez/ravel/pkg/cloudhypervisor"
+	"github.com/alexisbouchez/ravel/runtime/disks"
 	"github.com/alexisbouchez/ravel/runtime/drivers"
 	"github.com/alexisbouchez/ravel/runtime/drivers/common"
 )
@@ -27,6 +30,7 @@ type vm struct {
 	initClient             *client.InternalClient
 	successFullyShutdowned atomic.Bool
 	vmConfig               cloudhypervisor.VmConfig
+	rootfs                 string // device of the root filesystem on the host
 	stopRequested          bool
 	waitChan               chan struct{}
 }
@@ -37,7 +41,7 @@ func (vm *vm) Id() string {
 	return vm.id
 }
 
-func newVM(id string, cmd *exec.Cmd, vmConfig cloudhypervisor.VmConfig) *vm {
+func newVM(id string, cmd *exec.Cmd, vmConfig cloudhypervisor.VmConfig, rootfs string) *vm {
 	vmm := cloudhypervisor.NewVMMClient(getAPISocketPath(id))
 
 	client := client.NewInternalClient(getVsockPath(id))
@@ -46,6 +50,7 @@ func newVM(id string, cmd *exec.Cmd, vmConfig cloudhypervisor.VmConfig) *vm {
 		id:         id,
 		cmd:        cmd,
 		vmConfig:   vmConfig,
+		rootfs:     rootfs,
 		vmm:        vmm,
 		waitChan:   make(chan struct{}),
 		initClient: client,
@@ -86,7 +91,7 @@ func (vm *vm) Start(ctx context.Context) error {
 
 // StartFromSnapshot starts the VM by restoring from a snapshot instead of cold booting.
 // This enables sub-100ms cold starts for AI sandbox workloads.
-// globalSnapshotPath is the source path on the host (e.g., /var/lib/ravel/global-snapshots/instance-id/snap-1)
+// globalSnapshotPath is the source path on the host (e.g., /var/lib/ravel/snapshots/snap-1)
 // jailSnapshotPath is the jail-relative path (e.g., /snapshots/snap-1)
 func (vm *vm) StartFromSnapshot(ctx context.Context, globalSnapshotPath, jailSnapshotPath string) error {
 	bootStart := time.Now()
@@ -117,6 +122,13 @@ func (vm *vm) StartFromSnapshot(ctx context.Context, globalSnapshotPath, jailSna
 
 	slog.Debug("snapshot copied and patched", "from", globalSnapshotPath, "to", jailHostPath)
 
+	// the memory of the snapshot expects the root filesystem as it was when it was taken
+	restored, err := common.RestoreSnapshotRootFS(globalSnapshotPath, vm.rootfs)
+	if err != nil {
+		return err
+	}
+	slog.Debug("snapshot root filesystem restored", "id", vm.id, "restored", restored)
+
 	err = vm.cmd.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start vmm for machine %q: %w", vm.Id(), err)
@@ -235,6 +247,11 @@ func copyDirWithLinks(src, dst string) error {
 				return err
 			}
 		} else {
+			// the copy of the root filesystem is restored by the host, the VMM does not read it
+			if entry.Name() == common.SnapshotRootFSFile {
+				continue
+			}
+
 			// Always copy config.json since we need to modify it
 			if entry.Name() == "config.json" {
 				if err := copyFile(srcPath, dstPath); err != nil {
@@ -485,15 +502,23 @@ func (vm *vm) Exec(ctx context.Context, cmd []string, timeout time.Duration) (*a
 	}, nil
 }
 
-// Snapshot saves the VM state to a file for fast restore.
-// This enables sub-100ms cold starts by restoring from a pre-booted snapshot.
-func (vm *vm) Snapshot(ctx context.Context, path string) error {
-	// The path is relative to the jail chroot (e.g., /snapshots/snap-1)
-	// We need to create the directory on the host at /var/lib/ravel/instances/{id}/snapshots/snap-1
+func (vm *vm) ExecStream(ctx context.Context, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	return vm.initClient.ExecStream(ctx, req)
+}
+
+func (vm *vm) ExecSession(ctx context.Context) (io.ReadWriteCloser, error) {
+	return vm.initClient.ExecSession(ctx)
+}
+
+// Snapshot saves the VM state in a directory of the host.
+func (vm *vm) Snapshot(ctx context.Context, dir string, snapshotDisks func() error) error {
+	// CloudHypervisor runs in the jail, the snapshot is taken in the jail then moved to dir
+	path := common.GetJailSnapshotPath(dir)
 	hostPath := getInstanceDir(vm.id) + path
 	if err := os.MkdirAll(hostPath, 0755); err != nil {
 		return fmt.Errorf("failed to create snapshot directory: %w", err)
 	}
+	defer os.RemoveAll(hostPath)
 
 	// Chown to ravel-jailer user so CloudHypervisor (running as jailer) can write
 	jailerUid, jailerGid, err := common.SetupRavelJailerUser()
@@ -523,28 +548,88 @@ func (vm *vm) Snapshot(ctx context.Context, path string) error {
 		return fmt.Errorf("failed to create snapshot: %w", err)
 	}
 
+	// the root filesystem is copied while the VM is paused, it is consistent with the memory
+	err = common.SaveSnapshotRootFS(vm.rootfs, hostPath)
+	if err != nil {
+		vm.vmm.ResumeVM(ctx)
+		return fmt.Errorf("failed to copy root filesystem: %w", err)
+	}
+
+	err = snapshotDisks()
+	if err != nil {
+		vm.vmm.ResumeVM(ctx)
+		return fmt.Errorf("failed to snapshot disks: %w", err)
+	}
+
 	// Resume the VM after snapshot
 	_, err = vm.vmm.ResumeVM(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to resume VM after snapshot: %w", err)
 	}
 
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+
+	if err := os.Rename(hostPath, dir); err != nil {
+		return fmt.Errorf("failed to move snapshot: %w", err)
+	}
+
 	return nil
 }
 
-// Restore restores the VM state from a snapshot file.
+// Restore restores the VM state from a snapshot saved in a directory of the host.
 // This provides sub-100ms cold starts for AI sandbox workloads.
-func (vm *vm) Restore(ctx context.Context, path string) error {
+func (vm *vm) Restore(ctx context.Context, dir string, restoreDisks func() error) error {
+	path := common.GetJailSnapshotPath(dir)
+	hostPath := getInstanceDir(vm.id) + path
+
+	// the snapshot files are linked in the jail, a previous restore may have left them
+	if err := os.RemoveAll(hostPath); err != nil {
+		return fmt.Errorf("failed to clean snapshot directory: %w", err)
+	}
+	if err := copyDirWithLinks(dir, hostPath); err != nil {
+		return fmt.Errorf("failed to copy snapshot to jail: %w", err)
+	}
+
+	// the snapshot may have been taken by the instance of the machine on another node
+	if err := vm.patchSnapshotConfig(hostPath); err != nil {
+		return fmt.Errorf("failed to patch snapshot config: %w", err)
+	}
+
+	jailerUid, jailerGid, err := common.SetupRavelJailerUser()
+	if err != nil {
+		return fmt.Errorf("failed to get jailer user: %w", err)
+	}
+	if err := chownRecursive(hostPath, jailerUid, jailerGid); err != nil {
+		return fmt.Errorf("failed to chown snapshot directory: %w", err)
+	}
+
+	// the disks are written back while the VM is paused, the memory of the snapshot expects
+	// them as they were when it was taken
+	_, err = vm.vmm.PauseVM(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to pause VM before restore: %w", err)
+	}
+
+	if _, err := common.RestoreSnapshotRootFS(dir, vm.rootfs); err != nil {
+		return vm.abortRestore(ctx, fmt.Errorf("failed to restore root filesystem: %w", err))
+	}
+
+	if err := restoreDisks(); err != nil {
+		return vm.abortRestore(ctx, fmt.Errorf("failed to restore disks: %w", err))
+	}
+
 	// CloudHypervisor expects file:// URL format
 	snapshotUrl := "file://" + path
 
 	prefault := true
-	_, err := vm.vmm.PutVmRestore(ctx, cloudhypervisor.RestoreConfig{
+	_, err = vm.vmm.PutVmRestore(ctx, cloudhypervisor.RestoreConfig{
 		SourceUrl: snapshotUrl,
 		Prefault:  &prefault,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to restore from snapshot: %w", err)
+		return vm.abortRestore(ctx, fmt.Errorf("failed to restore from snapshot: %w", err))
 	}
 
 	// Resume the VM after restore
@@ -555,3 +640,37 @@ func (vm *vm) Restore(ctx context.Context, path string) error {
 
 	return nil
 }
+
+// abortRestore shuts the VM down after its disks may have been written by a failed restore,
+// its memory does not match them anymore.
+func (vm *vm) abortRestore(ctx context.Context, err error) error {
+	if err := vm.Shutdown(ctx); err != nil {
+		slog.Error("failed to shutdown vm after a failed restore", "id", vm.id, "error", err)
+	}
+	return err
+}
+
+// ResetIdentity implements drivers.InstanceTask.
+func (vm *vm) ResetIdentity(ctx context.Context, opts initd.ResetIdentityOptions) error {
+	return vm.initClient.ResetIdentity(ctx, opts)
+}
+
+// SetSecrets implements drivers.InstanceTask.
+func (vm *vm) SetSecrets(ctx context.Context, secrets initd.Secrets) error {
+	return vm.initClient.SetSecrets(ctx, secrets)
+}
+
+// ResizeDisk notifies the VM of the new size of a disk and grows its filesystem.
+func (vm *vm) ResizeDisk(ctx context.Context, index int, disk disks.Disk) error {
+	err := vm.vmm.ResizeDisk(ctx, disk.Id, int64(disk.SizeMB)*1024*1024)
+	if err != nil {
+		return err
+	}
+
+	err = vm.initClient.GrowFilesystem(ctx, common.GetVirtioDiskPath(index+1)) // rootfs is at index 0
+	if err != nil {
+		return fmt.Errorf("failed to grow filesystem: %w", err)
+	}
+
+	return nil
+}
@@ -33,7 +33,8 @@ func (r *Runtime) PullImage(ctx context.Context, opt daemon.ImagePullOptions) (*
 		auth = r.registries
 	}
 
-	image, err := r.images.Pull(ctx, ref, r.driver.Snapshotter(), auth)
+	// the image is unpacked for the default runtime, the other drivers unpack it when they use it
+	image, err := r.images.Pull(ctx, ref, r.drivers[r.defaultRuntime].Snapshotter(), auth)
 	if err != nil {
 		return nil, err
 	}
@@ -47,3 +48,13 @@ func (r *Runtime) PullImage(ctx context.Context, opt daemon.ImagePullOptions) (*
 func (r *Runtime) DeleteImage(ctx context.Context, ref string) error {
 	return r.images.DeleteImage(ctx, ref)
 }
+
+// PruneImages deletes all the images no instance uses.
+func (r *Runtime) PruneImages(ctx context.Context) (*daemon.ImagePruneResult, error) {
+	return r.imageGC.Prune(ctx)
+}
+
+// ImageGCStats returns the counters of the garbage collection of the images.
+func (r *Runtime) ImageGCStats() daemon.ImageGCStats {
+	return r.imageGC.Stats()
+}
@@ -0,0 +1,92 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// DiskUsage reports the usage in bytes of the disk holding the unpacked images.
+type DiskUsage interface {
+	Usage(ctx context.Context) (used uint64, total uint64, err error)
+}
+
+// FilesystemUsage is the usage of the filesystem of a directory, like the root of the overlayfs
+// snapshotter.
+type FilesystemUsage struct {
+	Path string
+}
+
+func (f FilesystemUsage) Usage(ctx context.Context) (uint64, uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(f.Path, &stat); err != nil {
+		return 0, 0, fmt.Errorf("failed to statfs %s: %w", f.Path, err)
+	}
+
+	total := stat.Blocks * uint64(stat.Bsize)
+	free := stat.Bfree * uint64(stat.Bsize)
+	return total - free, total, nil
+}
+
+// ThinPoolUsage is the usage of the data of the device mapper thin pool of the devmapper
+// snapshotter.
+type ThinPoolUsage struct {
+	Pool string
+}
+
+func (t ThinPoolUsage) Usage(ctx context.Context) (uint64, uint64, error) {
+	status, err := exec.CommandContext(ctx, "dmsetup", "status", t.Pool).Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get status of thin pool %s: %w", t.Pool, err)
+	}
+
+	table, err := exec.CommandContext(ctx, "dmsetup", "table", t.Pool).Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get table of thin pool %s: %w", t.Pool, err)
+	}
+
+	return parseThinPoolUsage(string(status), string(table))
+}
+
+// parseThinPoolUsage returns the used and total bytes of the data of a thin pool from its
+// dmsetup status, "<start> <length> thin-pool <transaction> <used>/<total metadata blocks>
+// <used>/<total data blocks> ...", and its table, "<start> <length> thin-pool <metadata dev>
+// <data dev> <data block size in sectors> ...".
+func parseThinPoolUsage(status, table string) (uint64, uint64, error) {
+	statusFields := strings.Fields(status)
+	if len(statusFields) < 6 || statusFields[2] != "thin-pool" {
+		return 0, 0, fmt.Errorf("unexpected thin pool status: %q", status)
+	}
+
+	usedBlocks, totalBlocks, ok := strings.Cut(statusFields[5], "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected thin pool data usage: %q", statusFields[5])
+	}
+
+	used, err := strconv.ParseUint(usedBlocks, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid thin pool used data blocks: %w", err)
+	}
+
+	total, err := strconv.ParseUint(totalBlocks, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid thin pool total data blocks: %w", err)
+	}
+
+	tableFields := strings.Fields(table)
+	if len(tableFields) < 6 || tableFields[2] != "thin-pool" {
+		return 0, 0, fmt.Errorf("unexpected thin pool table: %q", table)
+	}
+
+	sectors, err := strconv.ParseUint(tableFields[5], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid thin pool data block size: %w", err)
+	}
+
+	blockSize := sectors * 512
+	return used * blockSize, total * blockSize, nil
+}
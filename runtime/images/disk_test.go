@@ -0,0 +1,22 @@
+package images
+
+import "testing"
+
+func TestParseThinPoolUsage(t *testing.T) {
+	status := "0 62914560 thin-pool 1 206/4161600 3000/61440 - rw discard_passdown queue_if_no_space - 1024\n"
+	table := "0 62914560 thin-pool 253:1 253:2 1024 32768 1 skip_block_zeroing\n"
+
+	used, total, err := parseThinPoolUsage(status, table)
+	if err != nil {
+		t.Fatalf("parseThinPoolUsage() error = %v", err)
+	}
+
+	// blocks of 1024 sectors of 512 bytes
+	if used != 3000*512*1024 || total != 61440*512*1024 {
+		t.Errorf("parseThinPoolUsage() = %d, %d", used, total)
+	}
+
+	if _, _, err := parseThinPoolUsage("0 1024 linear", table); err == nil {
+		t.Error("expected an error for a device which is not a thin pool")
+	}
+}
@@ -0,0 +1,275 @@
+package images
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	containerdimages "github.com/containerd/containerd/v2/core/images"
+)
+
+// GCConfig is the policy of the garbage collection of the images no instance uses.
+type GCConfig struct {
+	Interval      time.Duration // between two checks of the disk usage
+	HighThreshold int           // disk usage percentage above which the images are deleted
+	LowThreshold  int           // disk usage percentage the deletions stop at
+	MinAge        time.Duration // an image is kept this long after it has been pulled or last used
+}
+
+// GCResult is the outcome of a garbage collection.
+type GCResult struct {
+	Deleted        []string `json:"deleted"`
+	ReclaimedBytes uint64   `json:"reclaimed_bytes"`
+}
+
+// GCStats are the counters of the garbage collections since the daemon started.
+type GCStats struct {
+	Runs           uint64    `json:"runs"`
+	Errors         uint64    `json:"errors"`
+	DeletedImages  uint64    `json:"deleted_images"`
+	ReclaimedBytes uint64    `json:"reclaimed_bytes"`
+	LastRunAt      time.Time `json:"last_run_at,omitzero"`
+	DiskUsedBytes  uint64    `json:"disk_used_bytes"`
+	DiskTotalBytes uint64    `json:"disk_total_bytes"`
+}
+
+// GC deletes the least recently used images when the disk holding them fills up. The images
+// leased by an instance are never deleted.
+type GC struct {
+	service *Service
+	usage   *ImagesUsage
+	disk    DiskUsage
+	config  GCConfig
+
+	runLock   sync.Mutex // a single collection at a time
+	statsLock sync.Mutex
+	stats     GCStats
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewGC(service *Service, usage *ImagesUsage, disk DiskUsage, config GCConfig) *GC {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &GC{
+		service: service,
+		usage:   usage,
+		disk:    disk,
+		config:  config,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start checks the disk usage periodically until the GC is stopped.
+func (gc *GC) Start() {
+	go gc.run()
+}
+
+func (gc *GC) Stop() {
+	gc.cancel()
+}
+
+func (gc *GC) run() {
+	ticker := time.NewTicker(gc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-gc.ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := gc.Collect(gc.ctx)
+			if err != nil {
+				slog.Error("Image garbage collection failed", "error", err)
+				continue
+			}
+
+			if len(result.Deleted) > 0 {
+				slog.Info("Image garbage collection done", "deleted", len(result.Deleted), "reclaimed_bytes", result.ReclaimedBytes)
+			}
+		}
+	}
+}
+
+// Collect deletes the least recently used images while the disk usage is above the high
+// threshold, until it is below the low threshold.
+func (gc *GC) Collect(ctx context.Context) (*GCResult, error) {
+	return gc.collect(ctx, false)
+}
+
+// Prune deletes all the images no instance uses, whatever the disk usage and their age.
+func (gc *GC) Prune(ctx context.Context) (*GCResult, error) {
+	return gc.collect(ctx, true)
+}
+
+// Stats returns the counters of the garbage collections.
+func (gc *GC) Stats() GCStats {
+	gc.statsLock.Lock()
+	defer gc.statsLock.Unlock()
+	return gc.stats
+}
+
+func (gc *GC) collect(ctx context.Context, prune bool) (result *GCResult, err error) {
+	gc.runLock.Lock()
+	defer gc.runLock.Unlock()
+
+	result = &GCResult{Deleted: []string{}}
+	defer func() {
+		gc.record(result, err)
+	}()
+
+	used, total, err := gc.disk.Usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gc.recordDiskUsage(used, total)
+
+	if !prune && !aboveThreshold(used, total, gc.config.HighThreshold) {
+		return result, nil
+	}
+
+	minAge := gc.config.MinAge
+	if prune {
+		minAge = 0
+	}
+
+	candidates, err := gc.candidates(ctx, minAge)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range candidates {
+		if !prune && !aboveThreshold(used, total, gc.config.LowThreshold) {
+			break
+		}
+
+		deleted, err := gc.deleteUnused(ctx, ref)
+		if err != nil {
+			return result, err
+		}
+		if !deleted {
+			continue
+		}
+		result.Deleted = append(result.Deleted, ref)
+
+		after, afterTotal, err := gc.disk.Usage(ctx)
+		if err != nil {
+			return result, err
+		}
+		gc.recordDiskUsage(after, afterTotal)
+
+		if after < used {
+			result.ReclaimedBytes += used - after
+		}
+		used, total = after, afterTotal
+	}
+
+	return result, nil
+}
+
+// aboveThreshold reports whether the usage is above a percentage of the disk.
+func aboveThreshold(used, total uint64, threshold int) bool {
+	return used*100 > total*uint64(threshold)
+}
+
+type gcCandidate struct {
+	ref      string
+	lastUsed time.Time
+	used     bool
+}
+
+// candidates returns the images no instance uses, the least recently used first.
+func (gc *GC) candidates(ctx context.Context, minAge time.Duration) ([]string, error) {
+	images, err := gc.service.ListImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gc.usage.Lock()
+	candidates := make([]gcCandidate, 0, len(images))
+	for _, image := range images {
+		metadata := image.Metadata()
+		lastUsed, ok := gc.usage.LastUsed(metadata.Name)
+		if !ok || metadata.UpdatedAt.After(lastUsed) {
+			lastUsed = metadata.UpdatedAt
+		}
+
+		candidates = append(candidates, gcCandidate{
+			ref:      metadata.Name,
+			lastUsed: lastUsed,
+			used:     gc.usage.IsUsed(metadata.Name),
+		})
+	}
+	gc.usage.Unlock()
+
+	return lruCandidates(candidates, time.Now(), minAge), nil
+}
+
+// lruCandidates returns the refs of the unused images not used for minAge, the least recently
+// used first.
+func lruCandidates(candidates []gcCandidate, now time.Time, minAge time.Duration) []string {
+	candidates = slices.DeleteFunc(slices.Clone(candidates), func(c gcCandidate) bool {
+		return c.used || now.Sub(c.lastUsed) < minAge
+	})
+
+	slices.SortStableFunc(candidates, func(a, b gcCandidate) int {
+		return a.lastUsed.Compare(b.lastUsed)
+	})
+
+	refs := make([]string, len(candidates))
+	for i, c := range candidates {
+		refs[i] = c.ref
+	}
+	return refs
+}
+
+// deleteUnused deletes an image if no instance started using it since it has been selected, the
+// usage is locked until its content and snapshots are removed.
+func (gc *GC) deleteUnused(ctx context.Context, ref string) (bool, error) {
+	gc.usage.Lock()
+	defer gc.usage.Unlock()
+
+	if gc.usage.IsUsed(ref) {
+		return false, nil
+	}
+
+	if _, err := gc.service.GetImage(ctx, ref); err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := gc.service.DeleteImage(ctx, ref, containerdimages.SynchronousDelete()); err != nil {
+		return false, err
+	}
+	gc.usage.Forget(ref)
+
+	return true, nil
+}
+
+func (gc *GC) recordDiskUsage(used, total uint64) {
+	gc.statsLock.Lock()
+	defer gc.statsLock.Unlock()
+	gc.stats.DiskUsedBytes = used
+	gc.stats.DiskTotalBytes = total
+}
+
+func (gc *GC) record(result *GCResult, err error) {
+	gc.statsLock.Lock()
+	defer gc.statsLock.Unlock()
+
+	gc.stats.Runs++
+	gc.stats.LastRunAt = time.Now()
+	if err != nil {
+		gc.stats.Errors++
+	}
+	if result != nil {
+		gc.stats.DeletedImages += uint64(len(result.Deleted))
+		gc.stats.ReclaimedBytes += result.ReclaimedBytes
+	}
+}
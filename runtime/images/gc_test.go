@@ -0,0 +1,49 @@
+package images
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLRUCandidates(t *testing.T) {
+	now := time.Now()
+	candidates := []gcCandidate{
+		{ref: "recent", lastUsed: now.Add(-2 * time.Hour)},
+		{ref: "used", lastUsed: now.Add(-48 * time.Hour), used: true},
+		{ref: "oldest", lastUsed: now.Add(-24 * time.Hour)},
+		{ref: "just-pulled", lastUsed: now.Add(-time.Minute)},
+	}
+
+	got := lruCandidates(candidates, now, 10*time.Minute)
+	want := []string{"oldest", "recent"}
+	if !slices.Equal(got, want) {
+		t.Errorf("lruCandidates() = %v, want %v", got, want)
+	}
+
+	got = lruCandidates(candidates, now, 0)
+	want = []string{"oldest", "recent", "just-pulled"}
+	if !slices.Equal(got, want) {
+		t.Errorf("lruCandidates() without min age = %v, want %v", got, want)
+	}
+}
+
+func TestAboveThreshold(t *testing.T) {
+	tests := []struct {
+		used, total uint64
+		threshold   int
+		want        bool
+	}{
+		{90, 100, 85, true},
+		{85, 100, 85, false},
+		{10, 100, 85, false},
+		{1 << 40, 1 << 40, 99, true},
+		{0, 0, 85, false},
+	}
+
+	for _, tt := range tests {
+		if got := aboveThreshold(tt.used, tt.total, tt.threshold); got != tt.want {
+			t.Errorf("aboveThreshold(%d, %d, %d) = %v, want %v", tt.used, tt.total, tt.threshold, got, tt.want)
+		}
+	}
+}
@@ -2,16 +2,19 @@ package images
 
 import (
 	"sync"
+	"time"
 )
 
 type ImagesUsage struct {
-	leases map[string]int
-	lock   sync.Mutex
+	leases   map[string]int
+	lastUsed map[string]time.Time // last time an image was leased or released, for the garbage collection
+	lock     sync.Mutex
 }
 
 func NewImagesUsage() *ImagesUsage {
 	return &ImagesUsage{
-		leases: make(map[string]int),
+		leases:   make(map[string]int),
+		lastUsed: make(map[string]time.Time),
 	}
 }
 
@@ -39,6 +42,7 @@ func (iu *ImagesUsage) Unlock() {
 // UseImage increments the lease count for the image with the given ref.
 // Lock should be held when calling this function
 func (iu *ImagesUsage) UseImage(ref string) {
+	iu.lastUsed[ref] = time.Now()
 	_, ok := iu.leases[ref]
 	if !ok {
 		iu.leases[ref] = 1
@@ -54,9 +58,24 @@ func (iu *ImagesUsage) ReleaseImage(ref string) {
 	if !ok {
 		return
 	}
+	iu.lastUsed[ref] = time.Now()
 
 	if lease == 1 {
 		delete(iu.leases, ref)
 		return
 	}
+	iu.leases[ref]--
+}
+
+// LastUsed returns the last time the image was leased or released since the daemon started.
+// Lock should be held when calling this function
+func (iu *ImagesUsage) LastUsed(ref string) (time.Time, bool) {
+	t, ok := iu.lastUsed[ref]
+	return t, ok
+}
+
+// Forget drops the usage history of a deleted image.
+// Lock should be held when calling this function
+func (iu *ImagesUsage) Forget(ref string) {
+	delete(iu.lastUsed, ref)
 }
@@ -76,9 +76,9 @@ func newTransport() *http.Transport {
 	}
 }
 
-func (r *Service) DeleteImage(ctx context.Context, ref string) error {
+func (r *Service) DeleteImage(ctx context.Context, ref string, opts ...containerdimages.DeleteOpt) error {
 	slog.Info("Deleting image", "ref", ref)
-	err := r.ctrd.ImageService().Delete(ctx, ref)
+	err := r.ctrd.ImageService().Delete(ctx, ref, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to delete image %q: %w", ref, err)
 	}
@@ -13,10 +13,6 @@ import (
 	"github.com/containerd/containerd/v2/client"
 )
 
-func (r *Runtime) PruneImages(ctx context.Context) error {
-	return nil
-}
-
 func (r *Runtime) useImage(ref string) (client.Image, error) {
 	r.imagesUsage.Lock()
 	defer r.imagesUsage.Unlock()
@@ -35,8 +31,12 @@ func (r *Runtime) releaseImage(ref string) {
 	r.imagesUsage.Unlock()
 }
 
-func (r *Runtime) newInstanceManager(i instance.Instance, disks []disks.Disk) *instancerunner.InstanceRunner {
-	return instancerunner.New(r.instancesStore, i, r.driver, disks)
+func (r *Runtime) newInstanceManager(i instance.Instance, disks []disks.Disk) (*instancerunner.InstanceRunner, error) {
+	driver, err := r.getDriver(&i)
+	if err != nil {
+		return nil, err
+	}
+	return instancerunner.New(r.instancesStore, i, driver, disks), nil
 }
 
 func (r *Runtime) CreateInstance(ctx context.Context, opt instance.InstanceOptions) (*instance.Instance, error) {
@@ -53,6 +53,15 @@ func (r *Runtime) CreateInstance(ctx context.Context, opt instance.InstanceOptio
 		}
 	}()
 
+	if opt.Config.Guest.Runtime == "" {
+		opt.Config.Guest.Runtime = r.defaultRuntime
+	}
+
+	if _, ok := r.drivers[opt.Config.Guest.Runtime]; !ok {
+		err = errdefs.NewInvalidArgument(fmt.Sprintf("runtime %q is not supported by this node", opt.Config.Guest.Runtime))
+		return nil, err
+	}
+
 	image, err := r.useImage(opt.Config.Image)
 	if err != nil {
 		return nil, err
@@ -98,7 +107,10 @@ func (r *Runtime) CreateInstance(ctx context.Context, opt instance.InstanceOptio
 		return nil, fmt.Errorf("failed to save instance: %w", err)
 	}
 
-	manager := r.newInstanceManager(i, disks)
+	manager, err := r.newInstanceManager(i, disks)
+	if err != nil {
+		return nil, err
+	}
 
 	r.instances.AddInstance(id, manager)
 
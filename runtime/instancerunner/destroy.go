@@ -3,6 +3,7 @@ package instancerunner
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/alexisbouchez/ravel/api/errdefs"
 	"github.com/alexisbouchez/ravel/core/instance"
@@ -37,5 +38,9 @@ func (ir *InstanceRunner) destroyImpl(ctx context.Context) error {
 		return err
 	}
 
+	if err := ir.logger.Remove(); err != nil {
+		slog.Error("failed to remove instance logs", "instance", i.Id, "error", err)
+	}
+
 	return nil
 }
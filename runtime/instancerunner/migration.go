@@ -0,0 +1,100 @@
+package instancerunner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/daemon"
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/initd"
+)
+
+// SetSecrets delivers the secrets to the started instance waiting for them.
+func (ir *InstanceRunner) SetSecrets(ctx context.Context, secrets initd.Secrets) error {
+	runner := ir.getVMRunner()
+	if runner == nil {
+		return errNotRunning
+	}
+
+	return runner.SetSecrets(ctx, secrets)
+}
+
+// FreezeFilesystems suspends the writes of the running instance to its disks.
+func (ir *InstanceRunner) FreezeFilesystems(ctx context.Context) error {
+	runner := ir.getVMRunner()
+	if runner == nil {
+		return errNotRunning
+	}
+
+	return runner.FreezeFilesystems(ctx)
+}
+
+func (ir *InstanceRunner) ThawFilesystems(ctx context.Context) error {
+	runner := ir.getVMRunner()
+	if runner == nil {
+		return errNotRunning
+	}
+
+	return runner.ThawFilesystems(ctx)
+}
+
+// Devices returns the paths of the disks of the running instance in its VMM.
+func (ir *InstanceRunner) Devices(ctx context.Context) ([]string, error) {
+	runner := ir.getVMRunner()
+	if runner == nil {
+		return nil, errNotRunning
+	}
+
+	return runner.Devices(ctx)
+}
+
+// SendMigration sends the running VM over conn, the instance then exits like a stopped VM.
+func (ir *InstanceRunner) SendMigration(ctx context.Context, conn io.ReadWriteCloser) error {
+	runner := ir.getVMRunner()
+	if runner == nil {
+		return errNotRunning
+	}
+
+	return runner.SendMigration(ctx, conn)
+}
+
+// ReceiveMigration starts the instance from the running VM sent over conn.
+func (ir *InstanceRunner) ReceiveMigration(ctx context.Context, opt daemon.InstanceMigrationOptions, conn io.ReadWriteCloser) error {
+	ir.lock()
+	defer ir.unlock()
+	slog.Debug("receiving instance migration", "id", ir.Instance().Id)
+
+	if ir.Status() != instance.InstanceStatusCreated {
+		return errdefs.NewFailedPrecondition(fmt.Sprintf("instance is in %s status", ir.Status()))
+	}
+
+	err := ir.updateInstanceState(instance.State{
+		Status: instance.InstanceStatusStarting,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			ir.updateInstanceState(instance.State{Status: instance.InstanceStatusStopped})
+		}
+	}()
+
+	runner := ir.newVMRunner()
+	ir.setVMRunner(runner)
+
+	err = runner.ReceiveMigration(ctx, opt, conn)
+	if err != nil {
+		return err
+	}
+
+	ir.updateInstanceState(instance.State{Status: instance.InstanceStatusRunning})
+
+	go ir.run()
+
+	return nil
+}
@@ -2,7 +2,10 @@ package instancerunner
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
@@ -57,7 +60,7 @@ func New(
 	disks []disks.Disk,
 ) *InstanceRunner {
 	return &InstanceRunner{
-		logger:        logging.NewInstanceLogger(instance.Id),
+		logger:        logging.NewInstanceLogger(instance.Id, instance.Metadata.MachineId),
 		store:         store,
 		instance:      instance,
 		stateObserver: pubsub.NewObservable(instance.State),
@@ -89,11 +92,35 @@ func (ir *InstanceRunner) Exec(ctx context.Context, cmd []string, timeout time.D
 	return runner.Exec(ctx, cmd, timeout)
 }
 
-func (ir *InstanceRunner) GetLog() []*api.LogEntry {
-	return ir.logger.GetLog()
+// ExecStream starts a streamed exec in the running instance or attaches to it.
+func (ir *InstanceRunner) ExecStream(ctx context.Context, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	if req.ExecId == "" && len(req.Cmd) == 0 {
+		return nil, errdefs.NewInvalidArgument("cmd is required")
+	}
+
+	runner := ir.getVMRunner()
+	if runner == nil {
+		return nil, errNotRunning
+	}
+
+	return runner.ExecStream(ctx, req)
+}
+
+// ExecSession opens an interactive exec session with the running instance.
+func (ir *InstanceRunner) ExecSession(ctx context.Context) (io.ReadWriteCloser, error) {
+	runner := ir.getVMRunner()
+	if runner == nil {
+		return nil, errNotRunning
+	}
+
+	return runner.ExecSession(ctx)
+}
+
+func (ir *InstanceRunner) GetLog(opts api.LogsOptions) ([]*api.LogEntry, error) {
+	return ir.logger.GetLog(opts)
 }
 
-func (ir *InstanceRunner) SubscribeToLogs() ([]*api.LogEntry, *logging.LogSubscriber) {
+func (ir *InstanceRunner) SubscribeToLogs() ([]*api.LogEntry, *logging.LogSubscriber, error) {
 	return ir.logger.Subscribe()
 }
 
@@ -108,23 +135,49 @@ func (ir *InstanceRunner) Signal(ctx context.Context, signal string) error {
 
 // Snapshot saves the running VM state for fast restore.
 // This enables sub-100ms cold starts for AI sandbox workloads.
-func (ir *InstanceRunner) Snapshot(ctx context.Context, path string) error {
+func (ir *InstanceRunner) Snapshot(ctx context.Context, path string, snapshotDisks func() error) error {
 	runner := ir.getVMRunner()
 	if runner == nil {
 		return errNotRunning
 	}
 
-	return runner.Snapshot(ctx, path)
+	return runner.Snapshot(ctx, path, snapshotDisks)
 }
 
 // Restore restores the VM from a previously saved snapshot.
-func (ir *InstanceRunner) Restore(ctx context.Context, path string) error {
+func (ir *InstanceRunner) Restore(ctx context.Context, path string, restoreDisks func() error) error {
 	runner := ir.getVMRunner()
 	if runner == nil {
 		return errNotRunning
 	}
 
-	return runner.Restore(ctx, path)
+	return runner.Restore(ctx, path, restoreDisks)
+}
+
+// ResizeDisk grows the filesystem of a disk of the instance after its device has been grown,
+// online when the instance is running.
+func (ir *InstanceRunner) ResizeDisk(ctx context.Context, disk disks.Disk) error {
+	ir.lock()
+	defer ir.unlock()
+
+	index := slices.IndexFunc(ir.disks, func(d disks.Disk) bool { return d.Id == disk.Id })
+	if index == -1 {
+		return errdefs.NewNotFound("disk is not mounted by the instance")
+	}
+	ir.disks[index].SizeMB = disk.SizeMB
+
+	switch status := ir.Status(); status {
+	case instance.InstanceStatusCreated, instance.InstanceStatusStopped:
+		return disks.GrowEXT4(ctx, disk.Path)
+	case instance.InstanceStatusRunning:
+		runner := ir.getVMRunner()
+		if runner == nil {
+			return errNotRunning
+		}
+		return runner.ResizeDisk(ctx, index, disk)
+	default:
+		return errdefs.NewFailedPrecondition(fmt.Sprintf("instance is in %s status", status))
+	}
 }
 
 func (s *InstanceRunner) Instance() instance.Instance {
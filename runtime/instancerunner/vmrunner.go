@@ -2,16 +2,22 @@ package instancerunner
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"sync/atomic"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/daemon"
 	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/initd"
 	"github.com/alexisbouchez/ravel/runtime/disks"
 	"github.com/alexisbouchez/ravel/runtime/drivers"
+	"github.com/alexisbouchez/ravel/runtime/drivers/common"
 	"github.com/alexisbouchez/ravel/runtime/logging"
 )
 
@@ -130,7 +136,7 @@ func (r *vmRunner) Start() error {
 }
 
 // StartFromSnapshot starts the VM by restoring from a snapshot instead of cold booting.
-// globalSnapshotPath is the path to the global snapshot storage (e.g., /var/lib/ravel/global-snapshots/instance-id/snap-1)
+// globalSnapshotPath is the path to the global snapshot storage (e.g., /var/lib/ravel/snapshots/snap-1)
 // jailSnapshotPath is the jail-relative path where the snapshot will be placed (e.g., /snapshots/snap-1)
 func (r *vmRunner) StartFromSnapshot(globalSnapshotPath, jailSnapshotPath string) error {
 	ctx := context.Background()
@@ -156,12 +162,78 @@ func (r *vmRunner) StartFromSnapshot(globalSnapshotPath, jailSnapshotPath string
 		return err
 	}
 
+	// the snapshot may have been taken from another instance, the restored VM is given the
+	// network, the hostname and a random state of its own before anything runs on it
+	err = r.resetIdentity(ctx)
+	if err != nil {
+		if err := vm.Shutdown(ctx); err != nil {
+			slog.Error("failed to shutdown vm", "error", err)
+		}
+		return fmt.Errorf("failed to reset the identity of the restored vm: %w", err)
+	}
+
 	r.hasStarted.Store(true)
 
 	go r.run()
 	return nil
 }
 
+// ReceiveMigration starts the VM from the running VM of another node sent over conn.
+func (r *vmRunner) ReceiveMigration(ctx context.Context, opt daemon.InstanceMigrationOptions, conn io.ReadWriteCloser) error {
+	vm, err := r.driver.BuildIncomingInstanceTask(ctx, &r.i, r.disks, opt.Devices)
+	if err != nil {
+		slog.Error("failed to build vm", "error", err)
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err := r.driver.CleanupInstanceTask(context.Background(), &r.i)
+			if err != nil {
+				slog.Error("failed to cleanup vm", "error", err)
+			}
+		}
+	}()
+
+	r.vm = vm
+
+	err = vm.ReceiveMigration(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	// the VM still has the network of the source instance
+	if opt.ResetNetwork {
+		err = r.resetIdentity(ctx)
+		if err != nil {
+			if err := vm.Shutdown(ctx); err != nil {
+				slog.Error("failed to shutdown vm", "error", err)
+			}
+			return fmt.Errorf("failed to reset the identity of the migrated vm: %w", err)
+		}
+	}
+
+	r.hasStarted.Store(true)
+
+	go r.run()
+	return nil
+}
+
+func (r *vmRunner) resetIdentity(ctx context.Context) error {
+	entropy := make([]byte, 64)
+	if _, err := rand.Read(entropy); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return r.vm.ResetIdentity(ctx, initd.ResetIdentityOptions{
+		Hostname: common.GetHostname(&r.i),
+		Network:  common.NetworkConfig(r.i.Network),
+		Entropy:  entropy,
+	})
+}
+
 func getLogFile(id string) string {
 	return fmt.Sprintf("/var/lib/ravel/instances/%s/vm.logs", id)
 }
@@ -204,6 +276,20 @@ func (r *vmRunner) Exec(ctx context.Context, cmd []string, timeout time.Duration
 	return r.vm.Exec(ctx, cmd, timeout)
 }
 
+func (r *vmRunner) ExecStream(ctx context.Context, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	if !r.hasStarted.Load() || r.terminated() {
+		return nil, errdefs.NewFailedPrecondition("instance is not running")
+	}
+	return r.vm.ExecStream(ctx, req)
+}
+
+func (r *vmRunner) ExecSession(ctx context.Context) (io.ReadWriteCloser, error) {
+	if !r.hasStarted.Load() || r.terminated() {
+		return nil, errdefs.NewFailedPrecondition("instance is not running")
+	}
+	return r.vm.ExecSession(ctx)
+}
+
 func (r *vmRunner) Signal(ctx context.Context, signal string) error {
 	if !r.hasStarted.Load() || r.terminated() {
 		return errdefs.NewFailedPrecondition("instance is not running")
@@ -212,17 +298,87 @@ func (r *vmRunner) Signal(ctx context.Context, signal string) error {
 }
 
 // Snapshot saves the VM state to enable fast restore later.
-func (r *vmRunner) Snapshot(ctx context.Context, path string) error {
+func (r *vmRunner) Snapshot(ctx context.Context, path string, snapshotDisks func() error) error {
 	if !r.hasStarted.Load() || r.terminated() {
 		return errdefs.NewFailedPrecondition("instance is not running")
 	}
-	return r.vm.Snapshot(ctx, path)
+	return r.vm.Snapshot(ctx, path, snapshotDisks)
 }
 
 // Restore restores the VM from a previously saved snapshot.
-func (r *vmRunner) Restore(ctx context.Context, path string) error {
+func (r *vmRunner) Restore(ctx context.Context, path string, restoreDisks func() error) error {
+	if !r.hasStarted.Load() || r.terminated() {
+		return errdefs.NewFailedPrecondition("instance is not running")
+	}
+	if err := r.vm.Restore(ctx, path, restoreDisks); err != nil {
+		return err
+	}
+
+	return r.resetIdentity(ctx)
+}
+
+func (r *vmRunner) FreezeFilesystems(ctx context.Context) error {
+	if !r.hasStarted.Load() || r.terminated() {
+		return errdefs.NewFailedPrecondition("instance is not running")
+	}
+	return r.vm.FreezeFilesystems(ctx)
+}
+
+func (r *vmRunner) ThawFilesystems(ctx context.Context) error {
+	if !r.hasStarted.Load() || r.terminated() {
+		return errdefs.NewFailedPrecondition("instance is not running")
+	}
+	return r.vm.ThawFilesystems(ctx)
+}
+
+func (r *vmRunner) Devices(ctx context.Context) ([]string, error) {
+	if !r.hasStarted.Load() || r.terminated() {
+		return nil, errdefs.NewFailedPrecondition("instance is not running")
+	}
+	return r.vm.Devices(ctx)
+}
+
+func (r *vmRunner) SendMigration(ctx context.Context, conn io.ReadWriteCloser) error {
+	if !r.hasStarted.Load() || r.terminated() {
+		return errdefs.NewFailedPrecondition("instance is not running")
+	}
+	return r.vm.SendMigration(ctx, conn)
+}
+
+// SetSecrets delivers the secrets to the init of the VM, it is retried until the init serves
+// its API or the context is done.
+func (r *vmRunner) SetSecrets(ctx context.Context, secrets initd.Secrets) error {
+	if !r.hasStarted.Load() || r.terminated() {
+		return errdefs.NewFailedPrecondition("instance is not running")
+	}
+
+	for {
+		err := r.vm.SetSecrets(ctx, secrets)
+		if err == nil || isInitdError(err) {
+			return err
+		}
+
+		slog.Debug("waiting for init to receive the secrets", "err", err, "id", r.i.Id)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to deliver the secrets: %w", err)
+		case <-r.waitCh:
+			return errdefs.NewFailedPrecondition("instance is not running")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// isInitdError reports whether the error has been returned by the init, the request has not
+// failed because the init is still booting.
+func isInitdError(err error) bool {
+	var rerr *errdefs.RavelError
+	return errors.As(err, &rerr)
+}
+
+func (r *vmRunner) ResizeDisk(ctx context.Context, index int, disk disks.Disk) error {
 	if !r.hasStarted.Load() || r.terminated() {
 		return errdefs.NewFailedPrecondition("instance is not running")
 	}
-	return r.vm.Restore(ctx, path)
+	return r.vm.ResizeDisk(ctx, index, disk)
 }
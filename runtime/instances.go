@@ -2,11 +2,13 @@ package runtime
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
 	"github.com/alexisbouchez/ravel/core/instance"
 	"github.com/alexisbouchez/ravel/runtime/instancerunner"
+	"github.com/alexisbouchez/ravel/runtime/logging"
 )
 
 func (r *Runtime) StartInstance(ctx context.Context, id string) error {
@@ -23,24 +25,6 @@ func (r *Runtime) StartInstance(ctx context.Context, id string) error {
 	return nil
 }
 
-// StartInstanceFromSnapshot starts the instance by restoring from a snapshot.
-// This enables sub-100ms cold starts for AI sandbox workloads.
-// globalSnapshotPath is the path to the global snapshot storage
-// jailSnapshotPath is the jail-relative path where the snapshot will be placed
-func (r *Runtime) StartInstanceFromSnapshot(ctx context.Context, id string, globalSnapshotPath, jailSnapshotPath string) error {
-	instance, err := r.getInstance(id)
-	if err != nil {
-		return err
-	}
-
-	err = instance.StartFromSnapshot(context.Background(), globalSnapshotPath, jailSnapshotPath)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (r *Runtime) DestroyInstance(ctx context.Context, id string) error {
 	instance, err := r.instances.GetInstance(id)
 	if err != nil {
@@ -64,7 +48,7 @@ func (r *Runtime) DestroyInstance(ctx context.Context, id string) error {
 
 	r.instances.Delete(id)
 	r.instances.ReleaseId(id)
-	r.imagesUsage.ReleaseImage(instance.Instance().ImageRef)
+	r.releaseImage(instance.Instance().ImageRef)
 
 	return nil
 }
@@ -100,6 +84,24 @@ func (r *Runtime) InstanceExec(ctx context.Context, id string, cmd []string, tim
 	}, nil
 }
 
+func (r *Runtime) InstanceExecStream(ctx context.Context, id string, req api.ExecStreamRequest) (io.ReadCloser, error) {
+	i, err := r.getInstance(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.ExecStream(ctx, req)
+}
+
+func (r *Runtime) InstanceExecSession(ctx context.Context, id string) (io.ReadWriteCloser, error) {
+	i, err := r.getInstance(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.ExecSession(ctx)
+}
+
 func (r *Runtime) ListInstances() []instance.Instance {
 	return r.instances.List()
 }
@@ -124,7 +126,10 @@ func (r *Runtime) SubscribeToInstanceLogs(ctx context.Context, id string) ([]*ap
 		return nil, nil, err
 	}
 
-	replay, sub := ir.SubscribeToLogs()
+	replay, sub, err := ir.SubscribeToLogs()
+	if err != nil {
+		return nil, nil, err
+	}
 
 	ch := sub.Ch()
 
@@ -136,13 +141,17 @@ func (r *Runtime) SubscribeToInstanceLogs(ctx context.Context, id string) ([]*ap
 	return replay, ch, nil
 }
 
-func (r *Runtime) GetInstanceLogs(id string) ([]*api.LogEntry, error) {
+func (r *Runtime) GetInstanceLogs(id string, opts api.LogsOptions) ([]*api.LogEntry, error) {
 	ir, err := r.getInstance(id)
 	if err != nil {
 		return nil, err
 	}
 
-	return ir.GetLog(), nil
+	return ir.GetLog(opts)
+}
+
+func (r *Runtime) RemoveMachineLogs(machineId string) error {
+	return logging.RemoveMachineLogs(machineId)
 }
 
 func (r *Runtime) WatchInstanceState(ctx context.Context, id string) (<-chan instance.State, error) {
@@ -153,24 +162,3 @@ func (r *Runtime) WatchInstanceState(ctx context.Context, id string) (<-chan ins
 
 	return ir.WatchState(ctx), nil
 }
-
-// InstanceSnapshot saves the VM state for fast restore.
-// This enables sub-100ms cold starts for AI sandbox workloads.
-func (r *Runtime) InstanceSnapshot(ctx context.Context, id string, path string) error {
-	ir, err := r.getInstance(id)
-	if err != nil {
-		return err
-	}
-
-	return ir.Snapshot(ctx, path)
-}
-
-// InstanceRestore restores the VM from a previously saved snapshot.
-func (r *Runtime) InstanceRestore(ctx context.Context, id string, path string) error {
-	ir, err := r.getInstance(id)
-	if err != nil {
-		return err
-	}
-
-	return ir.Restore(ctx, path)
-}
@@ -3,37 +3,66 @@ package logging
 import (
 	"bufio"
 	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/core/config"
 	"github.com/alexisbouchez/ravel/pkg/pubsub"
 )
 
 type LogSubscriber = pubsub.Subscriber[*api.LogEntry]
 
-// For now, we keep logs in memory but this is not suitable for production
+// subscribeHistory is the number of past entries sent to a new subscriber.
+const subscribeHistory = 100
+
+// InstanceLogger persists the logs of an instance under config.LOGS_DIRECTORY, in size-capped
+// segment files which survive restarts, and broadcasts them to the subscribers.
 type InstanceLogger struct {
 	instanceId string
+	dir        string
+	owned      bool // the logs are removed with the instance
 
-	maxEntries int
-	truncateBy int
+	segmentSize int64
+	maxSegments int
 
 	stop chan struct{}
 
-	log   []*api.LogEntry
-	bc    *pubsub.MessageBroadcaster[*api.LogEntry]
-	mutex sync.RWMutex
+	writer *segmentWriter
+	bc     *pubsub.MessageBroadcaster[*api.LogEntry]
+	mutex  sync.RWMutex
+}
+
+// NewInstanceLogger returns the logger of an instance. The instances of a machine share the
+// logs of the machine, which outlive the instances replaced by its updates, see
+// RemoveMachineLogs.
+func NewInstanceLogger(instanceId, machineId string) *InstanceLogger {
+	if machineId != "" {
+		return newInstanceLogger(instanceId, machineLogsDir(machineId), false)
+	}
+	return newInstanceLogger(instanceId, filepath.Join(config.LOGS_DIRECTORY, instanceId), true)
+}
+
+func machineLogsDir(machineId string) string {
+	return filepath.Join(config.LOGS_DIRECTORY, "machines", machineId)
+}
+
+// RemoveMachineLogs deletes the persisted logs of a machine, once it is destroyed.
+func RemoveMachineLogs(machineId string) error {
+	return os.RemoveAll(machineLogsDir(machineId))
 }
 
-func NewInstanceLogger(instanceId string) *InstanceLogger {
+func newInstanceLogger(instanceId string, dir string, owned bool) *InstanceLogger {
 	il := &InstanceLogger{
-		maxEntries: 100,
-		truncateBy: 20,
-		stop:       make(chan struct{}),
-		instanceId: instanceId,
-		log:        []*api.LogEntry{},
+		instanceId:  instanceId,
+		dir:         dir,
+		owned:       owned,
+		segmentSize: DefaultSegmentSize,
+		maxSegments: DefaultMaxSegments,
+		stop:        make(chan struct{}),
 	}
 
 	bc := pubsub.NewMessageBroadcaster(pubsub.BroadcasterOpts[*api.LogEntry]{
@@ -51,6 +80,16 @@ func (m *InstanceLogger) Start(path string) error {
 		return err
 	}
 
+	writer, err := openSegmentWriter(m.dir, m.segmentSize, m.maxSegments)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	m.mutex.Lock()
+	m.writer = writer
+	m.mutex.Unlock()
+
 	m.stop = make(chan struct{})
 
 	go func() {
@@ -58,19 +97,23 @@ func (m *InstanceLogger) Start(path string) error {
 		m.startReading(file)
 		file.Close()
 		m.bc.Stop()
+
+		m.mutex.Lock()
+		m.writer.Close()
+		m.writer = nil
+		m.mutex.Unlock()
 	}()
 
 	return nil
 }
 
 func (m *InstanceLogger) startReading(r io.Reader) {
+	reader := bufio.NewReaderSize(r, 4096)
 	for {
 		select {
 		case <-m.stop:
 			return
 		default:
-			reader := bufio.NewReaderSize(r, 4096)
-
 			line, _, err := reader.ReadLine()
 			if err != nil {
 				return
@@ -84,43 +127,46 @@ func (m *InstanceLogger) startReading(r io.Reader) {
 				Message:    string(line),
 			}
 			m.mutex.Lock()
-			m.appendOrResize(log)
+			if err := m.writer.Append(log); err != nil {
+				slog.Error("failed to persist log entry", "instance", m.instanceId, "error", err)
+			}
 			m.bc.Publish(log)
 			m.mutex.Unlock()
-
 		}
 	}
 }
 
-func (m *InstanceLogger) appendOrResize(log *api.LogEntry) {
-	if len(m.log) < m.maxEntries {
-		m.log = append(m.log, log)
-		return
-	}
-
-	m.log = append(m.log[m.truncateBy:], log)
-}
-
 func (m *InstanceLogger) Stop() {
 	close(m.stop)
 }
 
-func (m *InstanceLogger) getLog() []*api.LogEntry {
-	logs := make([]*api.LogEntry, len(m.log))
-	copy(logs, m.log)
-	return logs
-}
-
-func (m *InstanceLogger) GetLog() []*api.LogEntry {
+// GetLog reads the persisted entries matching the options.
+func (m *InstanceLogger) GetLog(opts api.LogsOptions) ([]*api.LogEntry, error) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
-	return m.getLog()
+	return readSegments(m.dir, opts)
 }
 
-func (m *InstanceLogger) Subscribe() ([]*api.LogEntry, *LogSubscriber) {
+// Subscribe returns the last entries and a subscriber receiving the next ones.
+func (m *InstanceLogger) Subscribe() ([]*api.LogEntry, *LogSubscriber, error) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	log := m.getLog()
-	return log, m.bc.Subscribe()
+	log, err := readSegments(m.dir, api.LogsOptions{Tail: subscribeHistory})
+	if err != nil {
+		return nil, nil, err
+	}
+	return log, m.bc.Subscribe(), nil
+}
+
+// Remove deletes the persisted logs once the instance is destroyed, unless they are the logs of
+// its machine.
+func (m *InstanceLogger) Remove() error {
+	if !m.owned {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return os.RemoveAll(m.dir)
 }
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"golang.org/x/sys/unix"
+)
+
+// logLines makes a logger read lines from a named pipe, like the output of an instance, and
+// waits for them to be persisted.
+func logLines(t *testing.T, l *InstanceLogger, lines string, want int) {
+	t.Helper()
+
+	fifo := filepath.Join(t.TempDir(), "output")
+	if err := unix.Mkfifo(fifo, 0600); err != nil {
+		t.Fatalf("failed to create fifo: %v", err)
+	}
+
+	go func() {
+		w, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		w.WriteString(lines)
+		w.Close()
+	}()
+
+	if err := l.Start(fifo); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries, err := l.GetLog(api.LogsOptions{})
+		if err == nil && len(entries) == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GetLog() = %d entries, %v, want %d entries", len(entries), err, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMachineLogsOutliveInstances(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "machine")
+
+	previous := newInstanceLogger("instance-1", dir, false)
+	logLines(t, previous, "first\n", 1)
+	if err := previous.Remove(); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	// the instance replacing it after an update appends to the logs of the machine
+	next := newInstanceLogger("instance-2", dir, false)
+	logLines(t, next, "second\n", 2)
+
+	entries, err := next.GetLog(api.LogsOptions{})
+	if err != nil {
+		t.Fatalf("GetLog() error = %v", err)
+	}
+	if entries[0].InstanceId != "instance-1" || entries[1].InstanceId != "instance-2" {
+		t.Errorf("entries logged by %s and %s, want instance-1 and instance-2", entries[0].InstanceId, entries[1].InstanceId)
+	}
+}
+
+func TestInstanceLogsRemoved(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "instance")
+
+	l := newInstanceLogger("instance", dir, true)
+	logLines(t, l, "line\n", 1)
+
+	if err := l.Remove(); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("the logs of the instance still exist after Remove(): %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+const (
+	// DefaultSegmentSize is the size from which a segment is rotated.
+	DefaultSegmentSize = 4 * 1024 * 1024
+	// DefaultMaxSegments is the number of segments kept per instance, the oldest is deleted on rotation.
+	DefaultMaxSegments = 8
+
+	segmentExt = ".log"
+)
+
+// segmentWriter appends log entries as JSON lines to numbered segment files,
+// a new segment is started when the current one reaches the segment size.
+type segmentWriter struct {
+	dir         string
+	segmentSize int64
+	maxSegments int
+
+	file *os.File
+	size int64
+	seq  uint64
+}
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%010d%s", seq, segmentExt))
+}
+
+// listSegments returns the sequence numbers of the segments of a directory, in order.
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	segments := []uint64{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, seq)
+	}
+
+	slices.Sort(segments)
+	return segments, nil
+}
+
+// openSegmentWriter opens the last segment of a directory, the entries logged
+// before a restart are kept.
+func openSegmentWriter(dir string, segmentSize int64, maxSegments int) (*segmentWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &segmentWriter{
+		dir:         dir,
+		segmentSize: segmentSize,
+		maxSegments: maxSegments,
+		seq:         1,
+	}
+	if len(segments) > 0 {
+		w.seq = segments[len(segments)-1]
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *segmentWriter) open() error {
+	file, err := os.OpenFile(segmentPath(w.dir, w.seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = stat.Size()
+	return nil
+}
+
+func (w *segmentWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	w.seq++
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	segments, err := listSegments(w.dir)
+	if err != nil {
+		return err
+	}
+
+	for len(segments) > w.maxSegments {
+		if err := os.Remove(segmentPath(w.dir, segments[0])); err != nil {
+			return err
+		}
+		segments = segments[1:]
+	}
+
+	return nil
+}
+
+func (w *segmentWriter) Append(entry *api.LogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if w.size > 0 && w.size+int64(len(line)) > w.segmentSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+func (w *segmentWriter) Close() error {
+	return w.file.Close()
+}
+
+// readSegments returns the entries of the segments of a directory matching the options.
+func readSegments(dir string, opts api.LogsOptions) ([]*api.LogEntry, error) {
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*api.LogEntry{}
+	for _, seq := range segments {
+		entries, err = readSegment(segmentPath(dir, seq), opts, entries)
+		if err != nil {
+			return nil, err
+		}
+
+		// without tail the oldest entries are kept, no need to read further
+		if opts.Tail <= 0 && limitsReached(entries, opts) {
+			break
+		}
+	}
+
+	return applyLimits(entries, opts), nil
+}
+
+func readSegment(path string, opts api.LogsOptions, entries []*api.LogEntry) ([]*api.LogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) { // deleted by a rotation
+			return entries, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry api.LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // truncated by a crash
+		}
+
+		if opts.Since > 0 && entry.Timestamp < opts.Since {
+			continue
+		}
+		if opts.Until > 0 && entry.Timestamp > opts.Until {
+			continue
+		}
+
+		entries = append(entries, &entry)
+		if opts.Tail > 0 && len(entries) > opts.Tail {
+			entries = entries[1:]
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+func limitsReached(entries []*api.LogEntry, opts api.LogsOptions) bool {
+	return opts.Limit > 0 && len(entries) >= opts.Limit
+}
+
+// applyLimits applies the line and byte limits, from the end when tail is set.
+func applyLimits(entries []*api.LogEntry, opts api.LogsOptions) []*api.LogEntry {
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		if opts.Tail > 0 {
+			entries = entries[len(entries)-opts.Limit:]
+		} else {
+			entries = entries[:opts.Limit]
+		}
+	}
+
+	if opts.MaxBytes <= 0 {
+		return entries
+	}
+
+	size := 0
+	if opts.Tail > 0 {
+		for i := len(entries) - 1; i >= 0; i-- {
+			size += len(entries[i].Message)
+			if size > opts.MaxBytes {
+				return entries[i+1:]
+			}
+		}
+		return entries
+	}
+
+	for i, e := range entries {
+		size += len(e.Message)
+		if size > opts.MaxBytes {
+			return entries[:i]
+		}
+	}
+	return entries
+}
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alexisbouchez/ravel/api"
+)
+
+func writeEntries(t *testing.T, w *segmentWriter, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		err := w.Append(&api.LogEntry{Timestamp: int64(i), Message: fmt.Sprintf("line %03d", i)})
+		if err != nil {
+			t.Fatalf("failed to append entry: %v", err)
+		}
+	}
+}
+
+func messages(entries []*api.LogEntry) []string {
+	result := make([]string, len(entries))
+	for i, e := range entries {
+		result[i] = e.Message
+	}
+	return result
+}
+
+func TestSegmentsRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := openSegmentWriter(dir, 256, 3)
+	if err != nil {
+		t.Fatalf("failed to open writer: %v", err)
+	}
+	writeEntries(t, w, 0, 100)
+	w.Close()
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		t.Fatalf("failed to list segments: %v", err)
+	}
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments, got %d", len(segments))
+	}
+
+	entries, err := readSegments(dir, api.LogsOptions{})
+	if err != nil {
+		t.Fatalf("failed to read segments: %v", err)
+	}
+	if len(entries) == 0 || len(entries) >= 100 {
+		t.Fatalf("expected the oldest entries to be dropped, got %d entries", len(entries))
+	}
+	if last := entries[len(entries)-1].Message; last != "line 099" {
+		t.Errorf("expected last entry to be line 099, got %s", last)
+	}
+}
+
+func TestSegmentsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := openSegmentWriter(dir, DefaultSegmentSize, DefaultMaxSegments)
+	if err != nil {
+		t.Fatalf("failed to open writer: %v", err)
+	}
+	writeEntries(t, w, 0, 5)
+	w.Close()
+
+	w, err = openSegmentWriter(dir, DefaultSegmentSize, DefaultMaxSegments)
+	if err != nil {
+		t.Fatalf("failed to reopen writer: %v", err)
+	}
+	writeEntries(t, w, 5, 10)
+	w.Close()
+
+	entries, err := readSegments(dir, api.LogsOptions{})
+	if err != nil {
+		t.Fatalf("failed to read segments: %v", err)
+	}
+	if len(entries) != 10 {
+		t.Fatalf("expected 10 entries, got %d", len(entries))
+	}
+}
+
+func TestReadSegmentsOptions(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := openSegmentWriter(dir, 256, 100)
+	if err != nil {
+		t.Fatalf("failed to open writer: %v", err)
+	}
+	writeEntries(t, w, 0, 50)
+	w.Close()
+
+	tests := []struct {
+		name     string
+		opts     api.LogsOptions
+		expected []string
+	}{
+		{"since until", api.LogsOptions{Since: 10, Until: 12}, []string{"line 010", "line 011", "line 012"}},
+		{"tail", api.LogsOptions{Tail: 2}, []string{"line 048", "line 049"}},
+		{"limit", api.LogsOptions{Limit: 2}, []string{"line 000", "line 001"}},
+		{"tail limit", api.LogsOptions{Tail: 10, Limit: 1}, []string{"line 049"}},
+		{"since limit", api.LogsOptions{Since: 40, Limit: 2}, []string{"line 040", "line 041"}},
+		{"max bytes", api.LogsOptions{MaxBytes: 20}, []string{"line 000", "line 001"}},
+		{"tail max bytes", api.LogsOptions{Tail: 5, MaxBytes: 20}, []string{"line 048", "line 049"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := readSegments(dir, tt.opts)
+			if err != nil {
+				t.Fatalf("failed to read segments: %v", err)
+			}
+
+			got := messages(entries)
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
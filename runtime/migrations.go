@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"context"
+	"io"
+
+	"github.com/alexisbouchez/ravel/core/daemon"
+	"github.com/alexisbouchez/ravel/initd"
+	"github.com/alexisbouchez/ravel/runtime/disks"
+)
+
+// SetInstanceSecrets delivers the secrets to a started instance waiting for them, see
+// instance.InstanceConfig.WaitSecrets.
+func (r *Runtime) SetInstanceSecrets(ctx context.Context, id string, secrets initd.Secrets) error {
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return err
+	}
+
+	return ir.SetSecrets(ctx, secrets)
+}
+
+// FreezeInstanceFilesystems suspends the writes of a running instance to its disks, they are
+// copied in a consistent state until ThawInstanceFilesystems is called.
+func (r *Runtime) FreezeInstanceFilesystems(ctx context.Context, id string) error {
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return err
+	}
+
+	return ir.FreezeFilesystems(ctx)
+}
+
+func (r *Runtime) ThawInstanceFilesystems(ctx context.Context, id string) error {
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return err
+	}
+
+	return ir.ThawFilesystems(ctx)
+}
+
+// InstanceDevices returns the paths of the disks of a running instance in its VMM, the root
+// filesystem first.
+func (r *Runtime) InstanceDevices(ctx context.Context, id string) ([]string, error) {
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return ir.Devices(ctx)
+}
+
+// InstanceRootFS returns the device of the root filesystem of an instance on the host, it is
+// prepared for an instance which has not been started yet.
+func (r *Runtime) InstanceRootFS(ctx context.Context, id string) (string, error) {
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return "", err
+	}
+
+	i := ir.Instance()
+	driver, err := r.getDriver(&i)
+	if err != nil {
+		return "", err
+	}
+
+	return driver.RootFS(ctx, &i)
+}
+
+func (r *Runtime) SendInstanceMigration(ctx context.Context, id string, conn io.ReadWriteCloser) error {
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return err
+	}
+
+	return ir.SendMigration(ctx, conn)
+}
+
+func (r *Runtime) ReceiveInstanceMigration(ctx context.Context, id string, opt daemon.InstanceMigrationOptions, conn io.ReadWriteCloser) error {
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return err
+	}
+
+	return ir.ReceiveMigration(ctx, opt, conn)
+}
+
+// SendDisk snapshots a disk and writes the snapshot to w, see disks.Service.SendDisk.
+func (r *Runtime) SendDisk(id, snapshot, base string, w io.Writer) error {
+	return r.disks.SendDisk(id, snapshot, base, w)
+}
+
+// ReceiveDisk creates or updates a disk from a stream of SendDisk on another node.
+func (r *Runtime) ReceiveDisk(id string, sizeMB uint64, rd io.Reader) (*disks.Disk, error) {
+	return r.disks.ReceiveDisk(id, sizeMB, rd)
+}
+
+func (r *Runtime) DeleteSentDiskSnapshot(id, snapshot string) error {
+	return r.disks.DeleteSentSnapshot(id, snapshot)
+}
@@ -15,13 +15,17 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
 	"github.com/alexisbouchez/ravel/core/config"
 	"github.com/alexisbouchez/ravel/core/daemon"
 	"github.com/alexisbouchez/ravel/core/instance"
 	"github.com/alexisbouchez/ravel/core/registry"
 	"github.com/alexisbouchez/ravel/runtime/disks"
 	"github.com/alexisbouchez/ravel/runtime/drivers"
+	"github.com/alexisbouchez/ravel/runtime/drivers/containerd"
 	"github.com/alexisbouchez/ravel/runtime/drivers/firecracker"
 	"github.com/alexisbouchez/ravel/runtime/drivers/vm"
 	"github.com/alexisbouchez/ravel/runtime/images"
@@ -39,13 +43,16 @@ func createRavelCgroup() error {
 // It orchestrates containerd for image management, Cloud Hypervisor for VM
 // execution, and ZFS for disk provisioning.
 type Runtime struct {
-	instancesStore instance.InstanceStore    // Persistent storage for instance metadata
-	imagesUsage    *images.ImagesUsage       // Tracks which images are in use
-	images         *images.Service           // OCI image management service
-	driver         drivers.Driver            // VM driver (Cloud Hypervisor)
-	instances      *State                    // In-memory state of running instances
-	disks          *disks.Service            // Disk provisioning and management
-	registries     registry.RegistriesConfig // OCI registry configurations
+	instancesStore instance.InstanceStore         // Persistent storage for instance metadata
+	imagesUsage    *images.ImagesUsage            // Tracks which images are in use
+	images         *images.Service                // OCI image management service
+	imageGC        *images.GC                     // Deletes the unused images under disk pressure
+	imageGCEnabled bool                           // Whether the image GC runs in the background
+	drivers        map[api.Runtime]drivers.Driver // Instance drivers by runtime
+	defaultRuntime api.Runtime                    // Runtime of the instances which do not choose one
+	instances      *State                         // In-memory state of running instances
+	disks          *disks.Service                 // Disk provisioning and management
+	registries     registry.RegistriesConfig      // OCI registry configurations
 }
 
 // Store combines instance and disk storage interfaces required by the Runtime.
@@ -60,8 +67,8 @@ var _ daemon.Runtime = (*Runtime)(nil)
 // New creates and initializes a new Runtime instance with the provided configuration.
 // It sets up:
 //   - Containerd client connection
-//   - VM driver (CloudHypervisor or Firecracker based on config)
-//   - Image management service
+//   - Instance drivers (CloudHypervisor, Firecracker and containerd based on config)
+//   - Image management service and garbage collection
 //   - Disk service with ZFS backend
 //
 // Returns an error if any initialization step fails.
@@ -81,31 +88,30 @@ func New(runtimeConfig *config.RuntimeConfig, registries registry.RegistriesConf
 
 	state := NewState()
 
-	// Select driver based on runtime type configuration
-	var driver drivers.Driver
-	runtimeType := runtimeConfig.GetRuntimeType()
+	gcConfig := runtimeConfig.ImageGC
+	if err := gcConfig.Validate(); err != nil {
+		return nil, err
+	}
 
-	switch runtimeType {
-	case config.RuntimeTypeFirecracker:
-		slog.Info("Using Firecracker runtime")
-		driver, err = firecracker.NewDriver(firecracker.Config{
-			FirecrackerBinary: runtimeConfig.FirecrackerBinary,
-			JailerBinary:      runtimeConfig.JailerBinary,
-			InitBinary:        runtimeConfig.InitBinary,
-			LinuxKernel:       runtimeConfig.LinuxKernel,
-		}, ctrd)
-	default: // CloudHypervisor (default)
-		slog.Info("Using CloudHypervisor runtime")
-		driver, err = vm.NewDriver(vm.Config{
-			CloudHypervisorBinary: runtimeConfig.CloudHypervisorBinary,
-			JailerBinary:          runtimeConfig.JailerBinary,
-			InitBinary:            runtimeConfig.InitBinary,
-			LinuxKernel:           runtimeConfig.LinuxKernel,
-		}, ctrd)
+	var diskUsage images.DiskUsage = images.FilesystemUsage{Path: gcConfig.GetPath()}
+	if gcConfig.ThinPool != "" {
+		diskUsage = images.ThinPoolUsage{Pool: gcConfig.ThinPool}
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to create VM driver: %w", err)
+	imageGC := images.NewGC(imagesService, imageUsage, diskUsage, images.GCConfig{
+		Interval:      gcConfig.GetInterval(),
+		HighThreshold: gcConfig.GetHighThreshold(),
+		LowThreshold:  gcConfig.GetLowThreshold(),
+		MinAge:        gcConfig.GetMinAge(),
+	})
+
+	runtimeDrivers := make(map[api.Runtime]drivers.Driver)
+	for _, runtimeType := range runtimeConfig.GetRuntimeTypes() {
+		driver, err := newDriver(runtimeConfig, runtimeType, ctrd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s driver: %w", runtimeType, err)
+		}
+		runtimeDrivers[api.Runtime(runtimeType)] = driver
 	}
 
 	runtime := &Runtime{
@@ -113,13 +119,64 @@ func New(runtimeConfig *config.RuntimeConfig, registries registry.RegistriesConf
 		instancesStore: store,
 		imagesUsage:    imageUsage,
 		images:         imagesService,
-		driver:         driver,
+		imageGC:        imageGC,
+		imageGCEnabled: !gcConfig.Disabled,
+		drivers:        runtimeDrivers,
+		defaultRuntime: api.Runtime(runtimeConfig.GetRuntimeType()),
 		instances:      state,
 		registries:     registries,
 	}
 	return runtime, nil
 }
 
+// newDriver creates the driver of a runtime type.
+func newDriver(runtimeConfig *config.RuntimeConfig, runtimeType config.RuntimeType, ctrd *client.Client) (drivers.Driver, error) {
+	switch runtimeType {
+	case config.RuntimeTypeCloudHypervisor:
+		slog.Info("Using CloudHypervisor runtime")
+		return vm.NewDriver(vm.Config{
+			CloudHypervisorBinary: runtimeConfig.CloudHypervisorBinary,
+			JailerBinary:          runtimeConfig.JailerBinary,
+			InitBinary:            runtimeConfig.InitBinary,
+			LinuxKernel:           runtimeConfig.LinuxKernel,
+		}, ctrd)
+	case config.RuntimeTypeFirecracker:
+		slog.Info("Using Firecracker runtime")
+		return firecracker.NewDriver(firecracker.Config{
+			FirecrackerBinary: runtimeConfig.FirecrackerBinary,
+			JailerBinary:      runtimeConfig.JailerBinary,
+			InitBinary:        runtimeConfig.InitBinary,
+			LinuxKernel:       runtimeConfig.LinuxKernel,
+		}, ctrd)
+	case config.RuntimeTypeContainerd:
+		slog.Info("Using containerd runtime")
+		return containerd.NewDriver(ctrd)
+	default:
+		return nil, fmt.Errorf("unknown runtime type %q", runtimeType)
+	}
+}
+
+// Runtimes returns the runtimes supported by the node, the default one first.
+func (r *Runtime) Runtimes() []api.Runtime {
+	runtimes := []api.Runtime{r.defaultRuntime}
+	for runtime := range r.drivers {
+		if runtime != r.defaultRuntime {
+			runtimes = append(runtimes, runtime)
+		}
+	}
+	slices.Sort(runtimes[1:])
+	return runtimes
+}
+
+// getDriver returns the driver of the runtime of an instance.
+func (r *Runtime) getDriver(i *instance.Instance) (drivers.Driver, error) {
+	driver, ok := r.drivers[i.Config.Guest.Runtime]
+	if !ok {
+		return nil, errdefs.NewFailedPrecondition(fmt.Sprintf("runtime %q is not supported by this node", i.Config.Guest.Runtime))
+	}
+	return driver, nil
+}
+
 // initContainerd initializes a connection to the containerd daemon and ensures
 // the "ravel" namespace exists. This namespace isolates Ravel's images and
 // containers from other containerd users on the system.
@@ -147,6 +204,7 @@ func initContainerd() (*client.Client, error) {
 //   - Loads all instances from the store
 //   - Recovers each instance's state (reattaches to running VMs)
 //   - Provisions disks for each instance
+//   - Starts the garbage collection of the unused images
 //
 // This method should be called once during daemon startup.
 func (r *Runtime) Start() error {
@@ -168,13 +226,31 @@ func (r *Runtime) Start() error {
 			return err
 		}
 
+		// the instances created before the node supported several runtimes ran on the default one
+		if i.Config.Guest.Runtime == "" {
+			i.Config.Guest.Runtime = r.defaultRuntime
+		}
+
 		r.imagesUsage.UseImage(i.ImageRef)
-		manager := r.newInstanceManager(i, disks)
+		manager, err := r.newInstanceManager(i, disks)
+		if err != nil {
+			slog.Error("Failed to recover instance", "id", i.Id, "error", err)
+			return err
+		}
 		manager.Recover()
 		r.instances.AddInstance(i.Id, manager)
 	}
 
+	if r.imageGCEnabled {
+		r.imageGC.Start()
+	}
+
 	slog.Info("Runtime started")
 
 	return nil
 }
+
+// Stop stops the background tasks of the Runtime, the instances keep running.
+func (r *Runtime) Stop() {
+	r.imageGC.Stop()
+}
@@ -0,0 +1,294 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/config"
+	"github.com/alexisbouchez/ravel/core/daemon"
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/alexisbouchez/ravel/runtime/disks"
+	"github.com/alexisbouchez/ravel/runtime/drivers/common"
+)
+
+// snapshotDisksFile lists the disks of the instance snapshotted with its memory, their
+// snapshots are named after the instance snapshot.
+const snapshotDisksFile = "disks.json"
+
+// getSnapshotDir returns the directory of the host where an instance snapshot is stored.
+func getSnapshotDir(snapshotId string) (string, error) {
+	if snapshotId == "" || snapshotId == "." || snapshotId == ".." || filepath.Base(snapshotId) != snapshotId {
+		return "", errdefs.NewInvalidArgument("invalid snapshot id: " + snapshotId)
+	}
+	return filepath.Join(config.SNAPSHOTS_DIRECTORY, snapshotId), nil
+}
+
+func getExistingSnapshotDir(snapshotId string) (string, error) {
+	dir, err := getSnapshotDir(snapshotId)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return "", errdefs.NewNotFound("snapshot not found")
+		}
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// StartInstanceFromSnapshot starts the instance by restoring from a snapshot.
+// This enables sub-100ms cold starts for AI sandbox workloads.
+func (r *Runtime) StartInstanceFromSnapshot(ctx context.Context, id string, snapshotId string) error {
+	dir, err := getExistingSnapshotDir(snapshotId)
+	if err != nil {
+		return err
+	}
+
+	instance, err := r.getInstance(id)
+	if err != nil {
+		return err
+	}
+
+	return instance.StartFromSnapshot(context.Background(), dir, common.GetJailSnapshotPath(dir))
+}
+
+// InstanceSnapshot saves the VM state in the snapshots directory of the node for fast restore.
+// This enables sub-100ms cold starts for AI sandbox workloads. The root filesystem is copied and
+// the disks of the instance are snapshotted while the VM is paused, with its memory.
+func (r *Runtime) InstanceSnapshot(ctx context.Context, id string, snapshotId string) (*daemon.InstanceSnapshot, error) {
+	dir, err := getSnapshotDir(snapshotId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(dir); err == nil {
+		return nil, errdefs.NewAlreadyExists("snapshot already exists")
+	}
+
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return nil, err
+	}
+
+	i := ir.Instance()
+	var snapshotted []string
+	snapshotDisks := func() error {
+		for _, disk := range instanceDisks(&i) {
+			if _, err := r.disks.CreateSnapshot(disk, snapshotId); err != nil {
+				return fmt.Errorf("disk %s: %w", disk, err)
+			}
+			snapshotted = append(snapshotted, disk)
+		}
+		return nil
+	}
+
+	err = ir.Snapshot(ctx, dir, snapshotDisks)
+	if err == nil {
+		err = writeSnapshotDisks(dir, snapshotted)
+	}
+	if err != nil {
+		r.deleteSnapshotDisks(snapshotted, snapshotId)
+		if err := os.RemoveAll(dir); err != nil {
+			slog.Error("failed to remove snapshot", "snapshot", snapshotId, "err", err)
+		}
+		return nil, err
+	}
+
+	size, err := dirSize(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &daemon.InstanceSnapshot{Id: snapshotId, SizeBytes: size}, nil
+}
+
+// InstanceRestore restores the VM from a previously saved snapshot, with its root filesystem and
+// its disks. The snapshots of the disks more recent than the restored one are deleted.
+func (r *Runtime) InstanceRestore(ctx context.Context, id string, snapshotId string) error {
+	dir, err := getExistingSnapshotDir(snapshotId)
+	if err != nil {
+		return err
+	}
+
+	// the disks of the instance have been written since the snapshot, its memory would not
+	// match them without the copies taken with it
+	if _, err := os.Stat(filepath.Join(dir, common.SnapshotRootFSFile)); err != nil {
+		if os.IsNotExist(err) {
+			return errdefs.NewFailedPrecondition("the snapshot has no copy of the root filesystem, it cannot be restored")
+		}
+		return err
+	}
+
+	snapshotted, err := readSnapshotDisks(dir)
+	if err != nil {
+		return err
+	}
+
+	ir, err := r.getInstance(id)
+	if err != nil {
+		return err
+	}
+
+	i := ir.Instance()
+	if err := r.checkSnapshotDisks(&i, snapshotted, snapshotId); err != nil {
+		return err
+	}
+
+	restoreDisks := func() error {
+		for _, disk := range snapshotted {
+			if err := r.disks.RestoreInstanceSnapshot(disk, snapshotId, id); err != nil {
+				return fmt.Errorf("disk %s: %w", disk, err)
+			}
+		}
+		return nil
+	}
+
+	return ir.Restore(ctx, dir, restoreDisks)
+}
+
+// checkSnapshotDisks checks that the disks of the instance are the ones snapshotted with its
+// memory and that their snapshots still exist, a more recent snapshot may have been restored or
+// the disks moved to another node.
+func (r *Runtime) checkSnapshotDisks(i *instance.Instance, snapshotted []string, snapshotId string) error {
+	if !sameDisks(instanceDisks(i), snapshotted) {
+		return errdefs.NewFailedPrecondition("the disks of the instance have changed since the snapshot was taken")
+	}
+
+	for _, disk := range snapshotted {
+		snapshots, err := r.disks.ListSnapshots(disk)
+		if err != nil {
+			return err
+		}
+
+		if !slices.ContainsFunc(snapshots, func(s disks.DiskSnapshot) bool { return s.Id == snapshotId }) {
+			return errdefs.NewFailedPrecondition(fmt.Sprintf("the snapshot of disk %s taken with the instance snapshot is gone", disk))
+		}
+	}
+
+	return nil
+}
+
+// GetInstanceSnapshotDir returns the directory of an instance snapshot of the node.
+func (r *Runtime) GetInstanceSnapshotDir(snapshotId string) (string, error) {
+	return getExistingSnapshotDir(snapshotId)
+}
+
+// ImportInstanceSnapshot moves the files of a snapshot transferred from another node into the
+// snapshots directory.
+func (r *Runtime) ImportInstanceSnapshot(snapshotId string, dir string) error {
+	dst, err := getSnapshotDir(snapshotId)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		return errdefs.NewAlreadyExists("snapshot already exists")
+	}
+
+	if err := os.MkdirAll(config.SNAPSHOTS_DIRECTORY, 0700); err != nil {
+		return err
+	}
+
+	return os.Rename(dir, dst)
+}
+
+// DeleteInstanceSnapshot deletes a snapshot and the snapshots of the disks taken with it.
+func (r *Runtime) DeleteInstanceSnapshot(ctx context.Context, snapshotId string) error {
+	dir, err := getExistingSnapshotDir(snapshotId)
+	if err != nil {
+		return err
+	}
+
+	snapshotted, err := readSnapshotDisks(dir)
+	if err != nil {
+		return err
+	}
+	r.deleteSnapshotDisks(snapshotted, snapshotId)
+
+	return os.RemoveAll(dir)
+}
+
+// deleteSnapshotDisks deletes the snapshots of the disks taken with an instance snapshot, the
+// failures are only logged.
+func (r *Runtime) deleteSnapshotDisks(snapshotted []string, snapshotId string) {
+	for _, disk := range snapshotted {
+		if err := r.disks.DeleteSnapshot(disk, snapshotId); err != nil && !errdefs.IsNotFound(err) {
+			slog.Error("failed to delete disk snapshot", "disk", disk, "snapshot", snapshotId, "err", err)
+		}
+	}
+}
+
+func instanceDisks(i *instance.Instance) []string {
+	ids := make([]string, len(i.Config.Mounts))
+	for j, m := range i.Config.Mounts {
+		ids[j] = m.Disk
+	}
+	return ids
+}
+
+func sameDisks(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+func writeSnapshotDisks(dir string, snapshotted []string) error {
+	if snapshotted == nil {
+		snapshotted = []string{}
+	}
+
+	data, err := json.Marshal(snapshotted)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, snapshotDisksFile), data, 0600)
+}
+
+// readSnapshotDisks returns the disks snapshotted with an instance snapshot, none for the
+// snapshots taken before the disks were.
+func readSnapshotDisks(dir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotDisksFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshotted []string
+	if err := json.Unmarshal(data, &snapshotted); err != nil {
+		return nil, fmt.Errorf("invalid snapshot disks: %w", err)
+	}
+
+	return snapshotted, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
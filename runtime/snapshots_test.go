@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/core/config"
+)
+
+func TestGetSnapshotDir(t *testing.T) {
+	dir, err := getSnapshotDir("msnap_01abc")
+	if err != nil {
+		t.Fatalf("getSnapshotDir() error = %v", err)
+	}
+	if want := filepath.Join(config.SNAPSHOTS_DIRECTORY, "msnap_01abc"); dir != want {
+		t.Errorf("getSnapshotDir() = %q, want %q", dir, want)
+	}
+
+	// the ids come from the API, they must not escape the snapshots directory
+	for _, id := range []string{"", ".", "..", "../instances", "a/b", "/etc", "snap/"} {
+		if _, err := getSnapshotDir(id); !errdefs.IsInvalidArgument(err) {
+			t.Errorf("getSnapshotDir(%q) error = %v, want an invalid argument", id, err)
+		}
+	}
+}
+
+func TestGetExistingSnapshotDir(t *testing.T) {
+	if _, err := getExistingSnapshotDir("../../etc"); !errdefs.IsInvalidArgument(err) {
+		t.Errorf("getExistingSnapshotDir() error = %v, want an invalid argument", err)
+	}
+
+	if _, err := getExistingSnapshotDir("missing-snapshot-for-test"); !errdefs.IsNotFound(err) {
+		t.Errorf("getExistingSnapshotDir() error = %v, want not found", err)
+	}
+}
+
+func TestSnapshotDisks(t *testing.T) {
+	dir := t.TempDir()
+
+	// the snapshots taken before the disks were have no disks file
+	snapshotted, err := readSnapshotDisks(dir)
+	if err != nil || snapshotted != nil {
+		t.Fatalf("readSnapshotDisks() = %v, %v, want no disks", snapshotted, err)
+	}
+
+	if err := writeSnapshotDisks(dir, nil); err != nil {
+		t.Fatalf("writeSnapshotDisks() error = %v", err)
+	}
+	snapshotted, err = readSnapshotDisks(dir)
+	if err != nil || snapshotted == nil || len(snapshotted) != 0 {
+		t.Errorf("readSnapshotDisks() = %v, %v, want an empty list", snapshotted, err)
+	}
+
+	if err := writeSnapshotDisks(dir, []string{"vol-1", "vol-2"}); err != nil {
+		t.Fatalf("writeSnapshotDisks() error = %v", err)
+	}
+	snapshotted, err = readSnapshotDisks(dir)
+	if err != nil || !slices.Equal(snapshotted, []string{"vol-1", "vol-2"}) {
+		t.Errorf("readSnapshotDisks() = %v, %v, want the written disks", snapshotted, err)
+	}
+}
+
+func TestSameDisks(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want bool
+	}{
+		{a: nil, b: []string{}, want: true},
+		{a: []string{"vol-1", "vol-2"}, b: []string{"vol-2", "vol-1"}, want: true},
+		{a: []string{"vol-1"}, b: []string{"vol-1", "vol-2"}, want: false},
+		{a: []string{"vol-1"}, b: []string{"vol-3"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := sameDisks(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameDisks(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
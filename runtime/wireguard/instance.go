@@ -0,0 +1,166 @@
+package wireguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/alexisbouchez/ravel/core/instance"
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/vishvananda/netlink"
+)
+
+// The host runs one Wireguard interface per instance and private network, it acts as a router
+// between the instance tap device and the tunnels to the other machines of the network:
+//   - the instance private IP is routed to its tap device
+//   - the traffic coming from the instance private IP to the network is routed to the
+//     Wireguard interface through a dedicated routing table, numbered after the listen port
+//     which is unique on the node
+
+// PeersFromInstance converts the peers of an instance private network.
+func PeersFromInstance(peers []instance.WireguardPeer) ([]PeerConfig, error) {
+	configs := make([]PeerConfig, 0, len(peers))
+	for _, p := range peers {
+		publicKey, err := ParseKey(p.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer public key: %w", err)
+		}
+
+		allowedIPs := make([]*net.IPNet, 0, len(p.AllowedIPs))
+		for _, cidr := range p.AllowedIPs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid peer allowed ip %s: %w", cidr, err)
+			}
+			allowedIPs = append(allowedIPs, ipNet)
+		}
+
+		configs = append(configs, PeerConfig{
+			PublicKey:  publicKey,
+			AllowedIPs: allowedIPs,
+			Endpoint:   p.Endpoint,
+		})
+	}
+
+	return configs, nil
+}
+
+func parseInstanceAddress(config instance.WireguardNetworkConfig) (*net.IPNet, *net.IPNet, error) {
+	ip, network, err := net.ParseCIDR(config.IPAddress)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid private network address %s: %w", config.IPAddress, err)
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)}, network, nil
+}
+
+func forwardRules(tap string, config instance.WireguardNetworkConfig) [][]string {
+	return [][]string{
+		{"-i", tap, "-o", config.InterfaceName, "-j", "ACCEPT"},
+		{"-i", config.InterfaceName, "-o", tap, "-j", "ACCEPT"},
+	}
+}
+
+// SetupInstanceNetwork creates the Wireguard interface of an instance private network
+// and routes the traffic between the instance tap device and the interface.
+func SetupInstanceNetwork(tap string, config instance.WireguardNetworkConfig) (err error) {
+	privateKey, err := ParseKey(config.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+
+	peers, err := PeersFromInstance(config.Peers)
+	if err != nil {
+		return err
+	}
+
+	address, network, err := parseInstanceAddress(config)
+	if err != nil {
+		return err
+	}
+
+	err = CreateInterface(InterfaceConfig{
+		Name:       config.InterfaceName,
+		PrivateKey: privateKey,
+		ListenPort: config.ListenPort,
+		Peers:      peers,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			CleanupInstanceNetwork(tap, config)
+		}
+	}()
+
+	tapLink, err := netlink.LinkByName(tap)
+	if err != nil {
+		return err
+	}
+
+	wgLink, err := netlink.LinkByName(config.InterfaceName)
+	if err != nil {
+		return err
+	}
+
+	if err = netlink.RouteReplace(&netlink.Route{Dst: address, LinkIndex: tapLink.Attrs().Index}); err != nil {
+		return fmt.Errorf("failed to route the instance address: %w", err)
+	}
+
+	if err = netlink.RouteReplace(&netlink.Route{Dst: network, LinkIndex: wgLink.Attrs().Index, Table: config.ListenPort}); err != nil {
+		return fmt.Errorf("failed to route the private network: %w", err)
+	}
+
+	rule := netlink.NewRule()
+	rule.Src = address
+	rule.Dst = network
+	rule.Table = config.ListenPort
+	if err = netlink.RuleAdd(rule); err != nil {
+		return fmt.Errorf("failed to add routing rule: %w", err)
+	}
+
+	ipt, err := iptables.New()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range forwardRules(tap, config) {
+		if err = ipt.AppendUnique("filter", "FORWARD", r...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CleanupInstanceNetwork removes the Wireguard interface of an instance private network and its routing.
+func CleanupInstanceNetwork(tap string, config instance.WireguardNetworkConfig) error {
+	errs := []error{}
+
+	if address, network, err := parseInstanceAddress(config); err == nil {
+		rule := netlink.NewRule()
+		rule.Src = address
+		rule.Dst = network
+		rule.Table = config.ListenPort
+		netlink.RuleDel(rule) // the rule does not exist if the setup failed early, ignore the error
+	}
+
+	ipt, err := iptables.New()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to create iptables client: %w", err))
+	} else {
+		for _, r := range forwardRules(tap, config) {
+			if err := ipt.DeleteIfExists("filter", "FORWARD", r...); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete iptables rule: %w", err))
+			}
+		}
+	}
+
+	// the routes are removed along with the interfaces
+	if err := DeleteInterface(config.InterfaceName); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
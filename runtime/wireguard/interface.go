@@ -28,13 +28,15 @@ func CreateInterface(config InterfaceConfig) error {
 		return fmt.Errorf("failed to set interface up: %w", err)
 	}
 
-	// Add IP address to the interface
-	addr := &netlink.Addr{
-		IPNet: config.Address,
-	}
-	if err := netlink.AddrAdd(link, addr); err != nil {
-		netlink.LinkDel(link)
-		return fmt.Errorf("failed to add address to interface: %w", err)
+	// Add IP address to the interface, interfaces used as routers don't need one
+	if config.Address != nil {
+		addr := &netlink.Addr{
+			IPNet: config.Address,
+		}
+		if err := netlink.AddrAdd(link, addr); err != nil {
+			netlink.LinkDel(link)
+			return fmt.Errorf("failed to add address to interface: %w", err)
+		}
 	}
 
 	// Configure Wireguard settings (private key, listen port, peers)
@@ -58,9 +60,58 @@ func configureWireguard(config InterfaceConfig) error {
 	var privateKey wgtypes.Key
 	copy(privateKey[:], config.PrivateKey[:])
 
-	// Build peer configs
+	peers, err := buildPeerConfigs(config.Peers)
+	if err != nil {
+		return err
+	}
+
+	// Build Wireguard configuration
+	wgConfig := wgtypes.Config{
+		PrivateKey: &privateKey,
+		Peers:      peers,
+	}
+
+	// Set listen port if specified
+	if config.ListenPort > 0 {
+		wgConfig.ListenPort = &config.ListenPort
+	}
+
+	// Configure the interface
+	if err := client.ConfigureDevice(config.Name, wgConfig); err != nil {
+		return fmt.Errorf("failed to configure device: %w", err)
+	}
+
+	return nil
+}
+
+// SetPeers replaces the peers of a Wireguard interface
+func SetPeers(interfaceName string, peers []PeerConfig) error {
+	client, err := wgctrl.New()
+	if err != nil {
+		return fmt.Errorf("failed to create wgctrl client: %w", err)
+	}
+	defer client.Close()
+
+	peerConfigs, err := buildPeerConfigs(peers)
+	if err != nil {
+		return err
+	}
+
+	config := wgtypes.Config{
+		ReplacePeers: true,
+		Peers:        peerConfigs,
+	}
+
+	if err := client.ConfigureDevice(interfaceName, config); err != nil {
+		return fmt.Errorf("failed to set peers: %w", err)
+	}
+
+	return nil
+}
+
+func buildPeerConfigs(configs []PeerConfig) ([]wgtypes.PeerConfig, error) {
 	var peers []wgtypes.PeerConfig
-	for _, p := range config.Peers {
+	for _, p := range configs {
 		var publicKey wgtypes.Key
 		copy(publicKey[:], p.PublicKey[:])
 
@@ -79,7 +130,7 @@ func configureWireguard(config InterfaceConfig) error {
 		if p.Endpoint != "" {
 			endpoint, err := net.ResolveUDPAddr("udp", p.Endpoint)
 			if err != nil {
-				return fmt.Errorf("invalid peer endpoint %s: %w", p.Endpoint, err)
+				return nil, fmt.Errorf("invalid peer endpoint %s: %w", p.Endpoint, err)
 			}
 			peerConfig.Endpoint = endpoint
 		}
@@ -87,23 +138,7 @@ func configureWireguard(config InterfaceConfig) error {
 		peers = append(peers, peerConfig)
 	}
 
-	// Build Wireguard configuration
-	wgConfig := wgtypes.Config{
-		PrivateKey: &privateKey,
-		Peers:      peers,
-	}
-
-	// Set listen port if specified
-	if config.ListenPort > 0 {
-		wgConfig.ListenPort = &config.ListenPort
-	}
-
-	// Configure the interface
-	if err := client.ConfigureDevice(config.Name, wgConfig); err != nil {
-		return fmt.Errorf("failed to configure device: %w", err)
-	}
-
-	return nil
+	return peers, nil
 }
 
 // DeleteInterface deletes a Wireguard interface
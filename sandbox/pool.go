@@ -5,98 +5,123 @@ package sandbox
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/alexisbouchez/ravel/api"
-	"github.com/alexisbouchez/ravel/core/cluster"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+	"github.com/alexisbouchez/ravel/internal/id"
 )
 
 // PoolConfig configures the sandbox pool behavior.
-type PoolConfig struct {
-	// MinWarm is the minimum number of warm sandboxes to maintain
-	MinWarm int `json:"min_warm"`
-	// MaxWarm is the maximum number of warm sandboxes
-	MaxWarm int `json:"max_warm"`
-	// TemplateImage is the OCI image to use for sandboxes
-	TemplateImage string `json:"template_image"`
-	// SnapshotAfterBoot creates a snapshot after initial boot for faster restore
-	SnapshotAfterBoot bool `json:"snapshot_after_boot"`
-	// DefaultCPUs is the number of CPUs for sandbox VMs
-	DefaultCPUs int `json:"default_cpus"`
-	// DefaultMemoryMB is the memory in MB for sandbox VMs
-	DefaultMemoryMB int `json:"default_memory_mb"`
-	// IdleTimeout is how long an idle sandbox stays warm before being destroyed
-	IdleTimeout time.Duration `json:"idle_timeout"`
-	// Namespace is the Ravel namespace for sandbox machines
-	Namespace string `json:"namespace"`
-	// Fleet is the Ravel fleet for sandbox machines
-	Fleet string `json:"fleet"`
-}
+type PoolConfig = api.SandboxPoolConfig
 
 // DefaultPoolConfig returns sensible defaults for AI sandbox workloads.
 func DefaultPoolConfig() PoolConfig {
 	return PoolConfig{
-		MinWarm:           2,
-		MaxWarm:           10,
-		TemplateImage:     "python:3.11-slim",
-		SnapshotAfterBoot: true,
-		DefaultCPUs:       1,
-		DefaultMemoryMB:   512,
-		IdleTimeout:       5 * time.Minute,
-		Namespace:         "sandbox",
-		Fleet:             "default",
+		MinWarm:            2,
+		MaxWarm:            10,
+		TemplateImage:      "python:3.11-slim",
+		SnapshotAfterBoot:  true,
+		DefaultCPUs:        1,
+		DefaultMemoryMB:    512,
+		IdleTimeoutSeconds: 300,
 	}
 }
 
 // SandboxState represents the state of a pooled sandbox.
-type SandboxState string
+type SandboxState = api.SandboxState
 
 const (
-	SandboxStateWarming  SandboxState = "warming"  // VM is booting
-	SandboxStateReady    SandboxState = "ready"    // VM is warm and available
-	SandboxStateClaimed  SandboxState = "claimed"  // VM is in use
-	SandboxStateReseting SandboxState = "reseting" // VM is restoring from snapshot
+	SandboxStateWarming  = api.SandboxStateWarming
+	SandboxStateReady    = api.SandboxStateReady
+	SandboxStateClaimed  = api.SandboxStateClaimed
+	SandboxStateReseting = api.SandboxStateReseting
 )
 
 // PooledSandbox represents a sandbox in the pool.
-type PooledSandbox struct {
-	ID         string       `json:"id"`
-	MachineID  string       `json:"machine_id"`
-	State      SandboxState `json:"state"`
-	SnapshotID string       `json:"snapshot_id,omitempty"`
-	ClaimedAt  *time.Time   `json:"claimed_at,omitempty"`
-	CreatedAt  time.Time    `json:"created_at"`
-	LastUsedAt time.Time    `json:"last_used_at"`
+type PooledSandbox = api.Sandbox
+
+// PoolStats contains pool statistics.
+type PoolStats = api.SandboxPoolStats
+
+// Machines creates and drives the machines backing the sandboxes of a pool.
+type Machines interface {
+	// CreateSandboxMachine creates and starts a machine, it returns its id and its node.
+	CreateSandboxMachine(ctx context.Context, pool api.SandboxPool) (machineId string, node string, err error)
+	WaitSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) error
+	// SnapshotSandboxMachine snapshots a running machine, it returns the id of the snapshot.
+	SnapshotSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) (snapshotId string, err error)
+	RestoreSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string, snapshotId string) error
+	DestroySandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) error
+}
+
+// Store persists the sandboxes of the pools so that they survive restarts.
+type Store interface {
+	PutSandbox(ctx context.Context, sandbox api.Sandbox) error
+	DeleteSandbox(ctx context.Context, id string) error
+	ListSandboxes(ctx context.Context, poolId string) ([]api.Sandbox, error)
 }
 
 // Pool manages a collection of pre-warmed sandbox VMs.
 type Pool struct {
-	config    PoolConfig
-	agent     cluster.Agent
+	pool      api.SandboxPool
+	machines  Machines
+	store     Store
 	mu        sync.RWMutex
 	sandboxes map[string]*PooledSandbox
-	ready     chan *PooledSandbox
+	notify    chan struct{} // closed when a sandbox becomes ready
+	cancel    context.CancelFunc
 	stopCh    chan struct{}
 	wg        sync.WaitGroup
 }
 
 // NewPool creates a new sandbox pool.
-func NewPool(config PoolConfig, agent cluster.Agent) *Pool {
+func NewPool(pool api.SandboxPool, machines Machines, store Store) *Pool {
 	return &Pool{
-		config:    config,
-		agent:     agent,
+		pool:      pool,
+		machines:  machines,
+		store:     store,
 		sandboxes: make(map[string]*PooledSandbox),
-		ready:     make(chan *PooledSandbox, config.MaxWarm),
+		notify:    make(chan struct{}),
 		stopCh:    make(chan struct{}),
 	}
 }
 
-// Start begins the pool manager background processes.
+// Pool returns the pool definition.
+func (p *Pool) Pool() api.SandboxPool {
+	return p.pool
+}
+
+// Start loads the persisted sandboxes and begins the pool manager background processes.
+// The sandboxes which were warming or reseting when the pool stopped are in an unknown
+// state, they are destroyed and replaced.
 func (p *Pool) Start(ctx context.Context) error {
-	slog.Info("Starting sandbox pool", "min_warm", p.config.MinWarm, "max_warm", p.config.MaxWarm)
+	sandboxes, err := p.store.ListSandboxes(ctx, p.pool.Id)
+	if err != nil {
+		return err
+	}
+
+	ctx, p.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	var stale []*PooledSandbox
+	p.mu.Lock()
+	for i := range sandboxes {
+		sandbox := &sandboxes[i]
+		p.sandboxes[sandbox.Id] = sandbox
+		if sandbox.State == SandboxStateWarming || sandbox.State == SandboxStateReseting {
+			stale = append(stale, sandbox)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, sandbox := range stale {
+		slog.Info("Destroying stale sandbox", "pool", p.pool.Id, "id", sandbox.Id, "state", sandbox.State)
+		p.destroySandbox(ctx, sandbox)
+	}
+
+	slog.Info("Starting sandbox pool", "pool", p.pool.Id, "sandboxes", len(sandboxes), "min_warm", p.pool.Config.MinWarm, "max_warm", p.pool.Config.MaxWarm)
 
 	// Start the warmer goroutine
 	p.wg.Add(1)
@@ -109,104 +134,182 @@ func (p *Pool) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop gracefully shuts down the pool.
-func (p *Pool) Stop(ctx context.Context) error {
-	slog.Info("Stopping sandbox pool")
+// Stop shuts down the pool manager, the sandboxes are kept and picked up by the next Start.
+func (p *Pool) Stop() {
+	slog.Info("Stopping sandbox pool", "pool", p.pool.Id)
 	close(p.stopCh)
+	if p.cancel != nil {
+		p.cancel()
+	}
 	p.wg.Wait()
+}
 
-	// Destroy all sandboxes
-	p.mu.Lock()
-	defer p.mu.Unlock()
+// Destroy stops the pool and destroys all its sandboxes.
+func (p *Pool) Destroy(ctx context.Context) {
+	p.Stop()
 
+	p.mu.RLock()
+	sandboxes := make([]*PooledSandbox, 0, len(p.sandboxes))
 	for _, sandbox := range p.sandboxes {
-		if err := p.agent.DestroyMachine(ctx, sandbox.MachineID, true); err != nil {
-			slog.Error("Failed to destroy sandbox", "id", sandbox.ID, "error", err)
-		}
+		sandboxes = append(sandboxes, sandbox)
 	}
+	p.mu.RUnlock()
 
-	return nil
+	for _, sandbox := range sandboxes {
+		p.destroySandbox(ctx, sandbox)
+	}
 }
 
 // Claim gets a ready sandbox from the pool.
 // If no sandbox is immediately available, it waits up to timeout.
-func (p *Pool) Claim(ctx context.Context, timeout time.Duration) (*PooledSandbox, error) {
+// The ready sandbox picked is on the node with the fewest sandboxes of the pool claimed.
+func (p *Pool) Claim(ctx context.Context, timeout time.Duration) (PooledSandbox, error) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	select {
-	case sandbox := <-p.ready:
+	for {
 		p.mu.Lock()
-		sandbox.State = SandboxStateClaimed
-		now := time.Now()
-		sandbox.ClaimedAt = &now
+		sandbox := p.pickReady()
+		if sandbox != nil {
+			now := time.Now()
+			sandbox.State = SandboxStateClaimed
+			sandbox.ClaimedAt = &now
+			claimed := *sandbox
+			p.mu.Unlock()
+
+			if err := p.store.PutSandbox(context.Background(), claimed); err != nil {
+				p.mu.Lock()
+				sandbox.State = SandboxStateReady
+				sandbox.ClaimedAt = nil
+				p.mu.Unlock()
+				p.signalReady()
+				return PooledSandbox{}, err
+			}
+
+			slog.Info("Claimed sandbox from pool", "pool", p.pool.Id, "id", claimed.Id, "machine_id", claimed.MachineId, "node", claimed.Node)
+			return claimed, nil
+		}
+		notify := p.notify
 		p.mu.Unlock()
 
-		slog.Info("Claimed sandbox from pool", "id", sandbox.ID, "machine_id", sandbox.MachineID)
-		return sandbox, nil
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return PooledSandbox{}, errdefs.NewDeadlineExceeded("no sandbox available within timeout")
+		}
+	}
+}
 
-	case <-ctx.Done():
-		return nil, fmt.Errorf("no sandbox available within timeout")
+// pickReady returns the ready sandbox on the node with the fewest claimed sandboxes,
+// the oldest one if several are on such nodes. p.mu must be held.
+func (p *Pool) pickReady() *PooledSandbox {
+	claimed := map[string]int{}
+	for _, s := range p.sandboxes {
+		if s.State == SandboxStateClaimed {
+			claimed[s.Node]++
+		}
 	}
+
+	var picked *PooledSandbox
+	for _, s := range p.sandboxes {
+		if s.State != SandboxStateReady {
+			continue
+		}
+		if picked == nil || claimed[s.Node] < claimed[picked.Node] ||
+			(claimed[s.Node] == claimed[picked.Node] && s.CreatedAt.Before(picked.CreatedAt)) {
+			picked = s
+		}
+	}
+
+	return picked
+}
+
+// signalReady wakes up the claims waiting for a sandbox.
+func (p *Pool) signalReady() {
+	p.mu.Lock()
+	close(p.notify)
+	p.notify = make(chan struct{})
+	p.mu.Unlock()
 }
 
 // Release returns a sandbox to the pool.
-// If SnapshotAfterBoot is enabled, it restores the sandbox to its clean state.
+// The sandbox is restored to its clean state from its snapshot, a sandbox without
+// snapshot is destroyed and replaced by the warmer.
 func (p *Pool) Release(ctx context.Context, sandboxID string) error {
 	p.mu.Lock()
 	sandbox, ok := p.sandboxes[sandboxID]
 	if !ok {
 		p.mu.Unlock()
-		return fmt.Errorf("sandbox not found: %s", sandboxID)
+		return errdefs.NewNotFound("sandbox not found: " + sandboxID)
 	}
 
 	if sandbox.State != SandboxStateClaimed {
 		p.mu.Unlock()
-		return fmt.Errorf("sandbox not claimed: %s", sandboxID)
+		return errdefs.NewFailedPrecondition("sandbox not claimed: " + sandboxID)
 	}
 
 	sandbox.State = SandboxStateReseting
+	reseting := *sandbox
 	p.mu.Unlock()
 
-	// Restore from snapshot if available
-	if sandbox.SnapshotID != "" {
-		if err := p.agent.MachineRestore(ctx, sandbox.MachineID, sandbox.SnapshotID); err != nil {
-			slog.Error("Failed to restore sandbox from snapshot, destroying", "id", sandbox.ID, "error", err)
-			p.destroySandbox(ctx, sandbox)
-			return err
-		}
+	ctx = context.WithoutCancel(ctx)
+
+	if reseting.SnapshotId == "" {
+		slog.Info("Destroying released sandbox without snapshot", "pool", p.pool.Id, "id", sandboxID)
+		p.destroySandbox(ctx, sandbox)
+		return nil
+	}
+
+	if err := p.store.PutSandbox(ctx, reseting); err != nil {
+		slog.Error("Failed to save sandbox", "pool", p.pool.Id, "id", sandboxID, "error", err)
+	}
+
+	if err := p.machines.RestoreSandboxMachine(ctx, p.pool, reseting.MachineId, reseting.SnapshotId); err != nil {
+		slog.Error("Failed to restore sandbox from snapshot, destroying", "pool", p.pool.Id, "id", sandboxID, "error", err)
+		p.destroySandbox(ctx, sandbox)
+		return err
 	}
 
 	p.mu.Lock()
 	sandbox.State = SandboxStateReady
 	sandbox.ClaimedAt = nil
 	sandbox.LastUsedAt = time.Now()
+	ready := *sandbox
 	p.mu.Unlock()
 
-	// Return to ready pool
-	select {
-	case p.ready <- sandbox:
-		slog.Info("Returned sandbox to pool", "id", sandbox.ID)
-	default:
-		// Pool is full, destroy this sandbox
-		slog.Info("Pool full, destroying sandbox", "id", sandbox.ID)
-		p.destroySandbox(ctx, sandbox)
+	if err := p.store.PutSandbox(ctx, ready); err != nil {
+		slog.Error("Failed to save sandbox", "pool", p.pool.Id, "id", sandboxID, "error", err)
 	}
 
+	slog.Info("Returned sandbox to pool", "pool", p.pool.Id, "id", sandboxID)
+	p.signalReady()
+
 	return nil
 }
 
+// List returns the sandboxes of the pool.
+func (p *Pool) List() []PooledSandbox {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	sandboxes := make([]PooledSandbox, 0, len(p.sandboxes))
+	for _, s := range p.sandboxes {
+		sandboxes = append(sandboxes, *s)
+	}
+
+	return sandboxes
+}
+
 // Stats returns current pool statistics.
 func (p *Pool) Stats() PoolStats {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
+	return p.stats()
+}
 
+func (p *Pool) stats() PoolStats {
 	stats := PoolStats{
-		Total:    len(p.sandboxes),
-		Ready:    0,
-		Claimed:  0,
-		Warming:  0,
-		Reseting: 0,
+		Total: len(p.sandboxes),
 	}
 
 	for _, s := range p.sandboxes {
@@ -225,15 +328,6 @@ func (p *Pool) Stats() PoolStats {
 	return stats
 }
 
-// PoolStats contains pool statistics.
-type PoolStats struct {
-	Total    int `json:"total"`
-	Ready    int `json:"ready"`
-	Claimed  int `json:"claimed"`
-	Warming  int `json:"warming"`
-	Reseting int `json:"reseting"`
-}
-
 // warmerLoop maintains the minimum number of warm sandboxes.
 func (p *Pool) warmerLoop(ctx context.Context) {
 	defer p.wg.Done()
@@ -247,9 +341,10 @@ func (p *Pool) warmerLoop(ctx context.Context) {
 			return
 		case <-ticker.C:
 			stats := p.Stats()
-			needed := p.config.MinWarm - (stats.Ready + stats.Warming)
+			needed := p.pool.Config.MinWarm - (stats.Ready + stats.Warming)
 
-			for i := 0; i < needed && stats.Total < p.config.MaxWarm; i++ {
+			for i := 0; i < needed && stats.Total < p.pool.Config.MaxWarm; i++ {
+				p.wg.Add(1)
 				go p.warmNewSandbox(ctx)
 				stats.Total++
 				stats.Warming++
@@ -280,86 +375,90 @@ func (p *Pool) cleanupIdleSandboxes(ctx context.Context) {
 	var toDestroy []*PooledSandbox
 	now := time.Now()
 
+	// Keep minimum warm
+	ready := p.stats().Ready
 	for _, sandbox := range p.sandboxes {
-		if sandbox.State == SandboxStateReady {
-			if now.Sub(sandbox.LastUsedAt) > p.config.IdleTimeout {
-				// Keep minimum warm
-				stats := p.Stats()
-				if stats.Ready > p.config.MinWarm {
-					toDestroy = append(toDestroy, sandbox)
-				}
-			}
+		if ready <= p.pool.Config.MinWarm {
+			break
+		}
+		if sandbox.State == SandboxStateReady && now.Sub(sandbox.LastUsedAt) > p.pool.Config.GetIdleTimeout() {
+			// claims must not pick it while it is destroyed
+			sandbox.State = SandboxStateReseting
+			toDestroy = append(toDestroy, sandbox)
+			ready--
 		}
 	}
 	p.mu.Unlock()
 
 	for _, sandbox := range toDestroy {
-		slog.Info("Destroying idle sandbox", "id", sandbox.ID, "idle_duration", time.Since(sandbox.LastUsedAt))
+		slog.Info("Destroying idle sandbox", "pool", p.pool.Id, "id", sandbox.Id, "idle_duration", time.Since(sandbox.LastUsedAt))
 		p.destroySandbox(ctx, sandbox)
 	}
 }
 
 func (p *Pool) warmNewSandbox(ctx context.Context) {
-	sandboxID := generateSandboxID()
-
-	slog.Info("Warming new sandbox", "id", sandboxID)
+	defer p.wg.Done()
 
+	now := time.Now()
 	sandbox := &PooledSandbox{
-		ID:         sandboxID,
+		Id:         id.GeneratePrefixed("sbx"),
+		PoolId:     p.pool.Id,
 		State:      SandboxStateWarming,
-		CreatedAt:  time.Now(),
-		LastUsedAt: time.Now(),
+		CreatedAt:  now,
+		LastUsedAt: now,
 	}
 
+	slog.Info("Warming new sandbox", "pool", p.pool.Id, "id", sandbox.Id)
+
 	p.mu.Lock()
-	p.sandboxes[sandboxID] = sandbox
+	p.sandboxes[sandbox.Id] = sandbox
 	p.mu.Unlock()
 
-	// Create the machine (simplified - in production would use full machine creation)
-	machineConfig := cluster.Machine{
-		Id:        "sandbox-" + sandboxID,
-		Namespace: p.config.Namespace,
-		FleetId:   p.config.Fleet,
-	}
-
-	mi, err := p.agent.PutMachine(ctx, cluster.PutMachineOptions{
-		Machine: machineConfig,
-		Version: api.MachineVersion{
-			Config: api.MachineConfig{
-				Image: p.config.TemplateImage,
-				Guest: api.GuestConfig{
-					Cpus:     p.config.DefaultCPUs,
-					MemoryMB: p.config.DefaultMemoryMB,
-				},
-			},
-		},
-		Start: true,
-	})
-	if err != nil {
-		slog.Error("Failed to create sandbox machine", "id", sandboxID, "error", err)
+	if err := p.save(ctx, sandbox); err != nil {
+		slog.Error("Failed to save sandbox", "pool", p.pool.Id, "id", sandbox.Id, "error", err)
 		p.mu.Lock()
-		delete(p.sandboxes, sandboxID)
+		delete(p.sandboxes, sandbox.Id)
 		p.mu.Unlock()
 		return
 	}
 
-	sandbox.MachineID = mi.MachineId
+	machineId, node, err := p.machines.CreateSandboxMachine(ctx, p.pool)
+	if err != nil {
+		slog.Error("Failed to create sandbox machine", "pool", p.pool.Id, "id", sandbox.Id, "error", err)
+		p.destroySandbox(context.WithoutCancel(ctx), sandbox)
+		return
+	}
+
+	p.mu.Lock()
+	sandbox.MachineId = machineId
+	sandbox.Node = node
+	p.mu.Unlock()
+
+	// the machine is recorded before waiting for it, to be destroyed if the pool stops meanwhile
+	if err := p.save(context.WithoutCancel(ctx), sandbox); err != nil {
+		slog.Error("Failed to save sandbox", "pool", p.pool.Id, "id", sandbox.Id, "error", err)
+	}
 
 	// Wait for machine to be running
-	err = p.agent.WaitForMachineStatus(ctx, sandbox.MachineID, api.MachineStatusRunning, 30)
+	err = p.machines.WaitSandboxMachine(ctx, p.pool, machineId)
 	if err != nil {
-		slog.Error("Sandbox failed to start", "id", sandboxID, "error", err)
+		if ctx.Err() != nil {
+			return // the pool is stopping, the sandbox is destroyed when the pool is destroyed or restarted
+		}
+		slog.Error("Sandbox failed to start", "pool", p.pool.Id, "id", sandbox.Id, "error", err)
 		p.destroySandbox(ctx, sandbox)
 		return
 	}
 
 	// Take initial snapshot if configured
-	if p.config.SnapshotAfterBoot {
-		snapshotID := sandboxID + "-base"
-		if err := p.agent.MachineSnapshot(ctx, sandbox.MachineID, snapshotID); err != nil {
-			slog.Warn("Failed to create base snapshot", "id", sandboxID, "error", err)
+	if p.pool.Config.SnapshotAfterBoot {
+		snapshotID, err := p.machines.SnapshotSandboxMachine(ctx, p.pool, machineId)
+		if err != nil {
+			slog.Warn("Failed to create base snapshot", "pool", p.pool.Id, "id", sandbox.Id, "error", err)
 		} else {
-			sandbox.SnapshotID = snapshotID
+			p.mu.Lock()
+			sandbox.SnapshotId = snapshotID
+			p.mu.Unlock()
 		}
 	}
 
@@ -367,26 +466,36 @@ func (p *Pool) warmNewSandbox(ctx context.Context) {
 	sandbox.State = SandboxStateReady
 	p.mu.Unlock()
 
-	// Add to ready pool
-	select {
-	case p.ready <- sandbox:
-		slog.Info("Sandbox ready", "id", sandboxID, "machine_id", sandbox.MachineID)
-	default:
-		slog.Warn("Ready pool full, keeping sandbox warm", "id", sandboxID)
+	if err := p.save(ctx, sandbox); err != nil {
+		slog.Error("Failed to save sandbox", "pool", p.pool.Id, "id", sandbox.Id, "error", err)
 	}
+
+	slog.Info("Sandbox ready", "pool", p.pool.Id, "id", sandbox.Id, "machine_id", machineId, "node", node)
+	p.signalReady()
+}
+
+// save persists a snapshot of the sandbox.
+func (p *Pool) save(ctx context.Context, sandbox *PooledSandbox) error {
+	p.mu.RLock()
+	s := *sandbox
+	p.mu.RUnlock()
+	return p.store.PutSandbox(ctx, s)
 }
 
 func (p *Pool) destroySandbox(ctx context.Context, sandbox *PooledSandbox) {
 	p.mu.Lock()
-	delete(p.sandboxes, sandbox.ID)
+	delete(p.sandboxes, sandbox.Id)
+	machineId := sandbox.MachineId
 	p.mu.Unlock()
 
-	if err := p.agent.DestroyMachine(ctx, sandbox.MachineID, true); err != nil {
-		slog.Error("Failed to destroy sandbox machine", "id", sandbox.ID, "error", err)
+	if machineId != "" {
+		err := p.machines.DestroySandboxMachine(ctx, p.pool, machineId)
+		if err != nil && !errdefs.IsNotFound(err) {
+			slog.Error("Failed to destroy sandbox machine", "pool", p.pool.Id, "id", sandbox.Id, "error", err)
+		}
 	}
-}
 
-// generateSandboxID creates a unique sandbox identifier.
-func generateSandboxID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	if err := p.store.DeleteSandbox(ctx, sandbox.Id); err != nil {
+		slog.Error("Failed to delete sandbox", "pool", p.pool.Id, "id", sandbox.Id, "error", err)
+	}
 }
@@ -0,0 +1,178 @@
+package sandbox
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexisbouchez/ravel/api"
+	"github.com/alexisbouchez/ravel/api/errdefs"
+)
+
+type fakeMachines struct {
+	mu        sync.Mutex
+	destroyed []string
+	restored  []string
+}
+
+func (f *fakeMachines) CreateSandboxMachine(ctx context.Context, pool api.SandboxPool) (string, string, error) {
+	return "", "", errdefs.NewNotImplemented("not implemented")
+}
+
+func (f *fakeMachines) WaitSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) error {
+	return nil
+}
+
+func (f *fakeMachines) SnapshotSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) (string, error) {
+	return machineId + "-snapshot", nil
+}
+
+func (f *fakeMachines) RestoreSandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string, snapshotId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.restored = append(f.restored, machineId)
+	return nil
+}
+
+func (f *fakeMachines) DestroySandboxMachine(ctx context.Context, pool api.SandboxPool, machineId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.destroyed = append(f.destroyed, machineId)
+	return nil
+}
+
+type fakeStore struct {
+	mu        sync.Mutex
+	sandboxes map[string]api.Sandbox
+}
+
+func (f *fakeStore) PutSandbox(ctx context.Context, sandbox api.Sandbox) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sandboxes[sandbox.Id] = sandbox
+	return nil
+}
+
+func (f *fakeStore) DeleteSandbox(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sandboxes, id)
+	return nil
+}
+
+func (f *fakeStore) ListSandboxes(ctx context.Context, poolId string) ([]api.Sandbox, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sandboxes := []api.Sandbox{}
+	for _, s := range f.sandboxes {
+		if s.PoolId == poolId {
+			sandboxes = append(sandboxes, s)
+		}
+	}
+	return sandboxes, nil
+}
+
+func newTestPool(t *testing.T, sandboxes ...api.Sandbox) (*Pool, *fakeMachines, *fakeStore) {
+	t.Helper()
+
+	store := &fakeStore{sandboxes: map[string]api.Sandbox{}}
+	for _, s := range sandboxes {
+		s.PoolId = "pool"
+		store.sandboxes[s.Id] = s
+	}
+
+	machines := &fakeMachines{}
+	config := DefaultPoolConfig()
+	config.MinWarm = 0
+
+	p := NewPool(api.SandboxPool{Id: "pool", Config: config}, machines, store)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start pool: %v", err)
+	}
+	t.Cleanup(p.Stop)
+
+	return p, machines, store
+}
+
+func TestPoolResumesPersistedSandboxes(t *testing.T) {
+	p, machines, store := newTestPool(t,
+		api.Sandbox{Id: "ready", MachineId: "m1", State: SandboxStateReady},
+		api.Sandbox{Id: "claimed", MachineId: "m2", State: SandboxStateClaimed},
+		api.Sandbox{Id: "warming", MachineId: "m3", State: SandboxStateWarming},
+	)
+
+	stats := p.Stats()
+	if stats.Total != 2 || stats.Ready != 1 || stats.Claimed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if len(machines.destroyed) != 1 || machines.destroyed[0] != "m3" {
+		t.Errorf("expected the warming sandbox machine to be destroyed, got %v", machines.destroyed)
+	}
+
+	if _, ok := store.sandboxes["warming"]; ok {
+		t.Errorf("expected the warming sandbox to be deleted from the store")
+	}
+}
+
+func TestPoolClaimSpreadsAcrossNodes(t *testing.T) {
+	now := time.Now()
+	p, _, store := newTestPool(t,
+		api.Sandbox{Id: "a", Node: "node-1", MachineId: "m1", State: SandboxStateClaimed, CreatedAt: now},
+		api.Sandbox{Id: "b", Node: "node-1", MachineId: "m2", State: SandboxStateReady, CreatedAt: now},
+		api.Sandbox{Id: "c", Node: "node-2", MachineId: "m3", State: SandboxStateReady, CreatedAt: now.Add(time.Second)},
+	)
+
+	sandbox, err := p.Claim(context.Background(), time.Second)
+	if err != nil {
+		t.Fatalf("failed to claim sandbox: %v", err)
+	}
+
+	if sandbox.Node != "node-2" {
+		t.Errorf("expected the sandbox of node-2 to be claimed, got %s", sandbox.Node)
+	}
+
+	if store.sandboxes["c"].State != SandboxStateClaimed {
+		t.Errorf("expected the claim to be persisted")
+	}
+}
+
+func TestPoolClaimTimeout(t *testing.T) {
+	p, _, _ := newTestPool(t)
+
+	_, err := p.Claim(context.Background(), 10*time.Millisecond)
+	if !errdefs.IsDeadlineExceeded(err) {
+		t.Errorf("expected a deadline exceeded error, got %v", err)
+	}
+}
+
+func TestPoolRelease(t *testing.T) {
+	p, machines, _ := newTestPool(t,
+		api.Sandbox{Id: "snap", MachineId: "m1", State: SandboxStateClaimed, SnapshotId: "snap-base"},
+		api.Sandbox{Id: "nosnap", MachineId: "m2", State: SandboxStateClaimed},
+	)
+
+	if err := p.Release(context.Background(), "snap"); err != nil {
+		t.Fatalf("failed to release sandbox: %v", err)
+	}
+	if len(machines.restored) != 1 || machines.restored[0] != "m1" {
+		t.Errorf("expected the sandbox to be restored, got %v", machines.restored)
+	}
+
+	if err := p.Release(context.Background(), "nosnap"); err != nil {
+		t.Fatalf("failed to release sandbox: %v", err)
+	}
+	if len(machines.destroyed) != 1 || machines.destroyed[0] != "m2" {
+		t.Errorf("expected the sandbox without snapshot to be destroyed, got %v", machines.destroyed)
+	}
+
+	stats := p.Stats()
+	if stats.Total != 1 || stats.Ready != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if err := p.Release(context.Background(), "snap"); !errdefs.IsFailedPrecondition(err) {
+		t.Errorf("expected a failed precondition error, got %v", err)
+	}
+}